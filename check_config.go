@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"rpc-proxy/internal/app"
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/types"
+)
+
+// isCheckConfig reports whether the binary was started as `rpc-proxy check-config` or
+// `rpc-proxy --validate`
+func isCheckConfig(args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+	switch args[1] {
+	case "check-config", "--validate", "-validate":
+		return true
+	}
+	return false
+}
+
+// checkConfig loads the configuration exactly as the server would, probes every enabled
+// endpoint without serving traffic, prints a report and returns the process exit code: 0 when
+// everything passed, 1 otherwise
+func checkConfig() int {
+	application, err := app.New()
+	if err != nil {
+		fmt.Printf("FAIL configuration: %v\n", err)
+		return 1
+	}
+	defer application.Close()
+	cfg := application.Config
+
+	failures := 0
+	fail := func(format string, args ...interface{}) {
+		failures++
+		fmt.Printf("FAIL "+format+"\n", args...)
+	}
+
+	fmt.Printf("Chains loaded from %s: %d chains\n", cfg.ChainsSource, len(cfg.Chains))
+	if cfg.Database.Host != "" && (cfg.ChainsSource == config.ChainsFromEnvironment || cfg.ChainsSource == config.ChainsFromFallback) {
+		fail("database %s:%d is configured but chains could not be loaded from it", cfg.Database.Host, cfg.Database.Port)
+	}
+
+	// The checker is never started: it only lends ValidateEndpoint its probes and settings
+	checker := cfg.CreateMultiChainHealthChecker()
+
+	for _, chain := range cfg.Chains {
+		fmt.Printf("\n%s (chain ID %d, /rpc/%s)\n", chain.Name, chain.ChainID, chain.RPCPath)
+
+		for key, value := range cfg.ChainConfigs[chain.Name] {
+			if err := config.ValidateChainConfig(key, value); err != nil {
+				fail("  config: %v", err)
+			}
+		}
+
+		if !chain.IsEnabled {
+			fmt.Printf("SKIP   chain is disabled\n")
+			continue
+		}
+
+		// Probe concurrently so a pipeline waits for one health check timeout, not one per endpoint
+		endpoints := cfg.ChainEndpoints[chain.Name]
+		results := make([]*health.EndpointValidation, len(endpoints))
+		errs := make([]error, len(endpoints))
+		var wg sync.WaitGroup
+		for i, endpoint := range endpoints {
+			if !endpoint.Enabled {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, endpoint *types.RPCEndpoint) {
+				defer wg.Done()
+				results[i], errs[i] = checker.ValidateEndpoint(endpoint, chain.Name)
+			}(i, endpoint)
+		}
+		wg.Wait()
+
+		enabled := 0
+		for i, endpoint := range endpoints {
+			switch {
+			case !endpoint.Enabled:
+				fmt.Printf("SKIP   %s %s (disabled)\n", endpoint.Name, endpoint.URL)
+			case errs[i] != nil:
+				fail("  %s %s: %v", endpoint.Name, endpoint.URL, errs[i])
+			case !results[i].Valid:
+				fail("  %s %s: %s", endpoint.Name, endpoint.URL, strings.Join(results[i].Errors, "; "))
+			default:
+				fmt.Printf("OK     %s %s (%dms, block %s)\n", endpoint.Name, endpoint.URL,
+					results[i].ResponseTimeMs, results[i].BlockNumber)
+			}
+			if endpoint.Enabled {
+				enabled++
+			}
+		}
+		if enabled == 0 {
+			fail("  chain has no enabled endpoints")
+		}
+	}
+
+	fmt.Println()
+	if failures > 0 {
+		fmt.Printf("Configuration check failed: %d problems\n", failures)
+		return 1
+	}
+	fmt.Println("Configuration check passed")
+	return 0
+}
@@ -0,0 +1,9 @@
+-- Health check retention and downsampling
+-- Downsampled rows aggregate sample_count raw checks into a single hourly row
+ALTER TABLE health_checks
+ADD COLUMN IF NOT EXISTS sample_count INTEGER NOT NULL DEFAULT 1;
+
+INSERT INTO settings (key, value, description) VALUES
+    ('health_check_retention_days', '30', 'Days of health check history to keep (0 keeps everything)'),
+    ('health_check_downsample_after_days', '0', 'Collapse health checks older than this many days into hourly aggregates (0 disables)')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,16 @@
+-- Scheduled maintenance windows per endpoint
+-- Endpoints are excluded from routing while a window is active; recurrence repeats the
+-- starts_at..ends_at span every day or week
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id SERIAL PRIMARY KEY,
+    endpoint_id INTEGER NOT NULL REFERENCES rpc_endpoints(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    recurrence VARCHAR(20) NOT NULL DEFAULT 'none',
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at),
+    CHECK (recurrence IN ('none', 'daily', 'weekly'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_endpoint_id ON maintenance_windows(endpoint_id);
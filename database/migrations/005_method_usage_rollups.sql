@@ -0,0 +1,14 @@
+-- Hourly per-method request rollups written by the analytics rollup job
+CREATE TABLE IF NOT EXISTS method_usage_rollups (
+    id SERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    method VARCHAR(100) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    total_latency_ms BIGINT NOT NULL DEFAULT 0,
+    max_latency_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_method_usage_period ON method_usage_rollups(chain_name, method, period_start);
+CREATE INDEX IF NOT EXISTS idx_method_usage_rollups_period_start ON method_usage_rollups(period_start);
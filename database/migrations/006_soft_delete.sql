@@ -0,0 +1,6 @@
+-- Soft deletes: deleted chains and endpoints keep their rows with deleted_at set until restored or purged
+ALTER TABLE chains ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE rpc_endpoints ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_chains_deleted_at ON chains(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_rpc_endpoints_deleted_at ON rpc_endpoints(deleted_at);
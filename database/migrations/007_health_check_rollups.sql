@@ -0,0 +1,26 @@
+-- Hourly and daily health check summaries per endpoint written by the health rollup job
+CREATE TABLE IF NOT EXISTS health_check_hourly_rollups (
+    id SERIAL PRIMARY KEY,
+    endpoint_id INTEGER NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    checks BIGINT NOT NULL DEFAULT 0,
+    healthy_checks BIGINT NOT NULL DEFAULT 0,
+    avg_response_time_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p95_response_time_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_health_hourly_period ON health_check_hourly_rollups(endpoint_id, period_start);
+CREATE INDEX IF NOT EXISTS idx_health_check_hourly_rollups_period_start ON health_check_hourly_rollups(period_start);
+
+CREATE TABLE IF NOT EXISTS health_check_daily_rollups (
+    id SERIAL PRIMARY KEY,
+    endpoint_id INTEGER NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    checks BIGINT NOT NULL DEFAULT 0,
+    healthy_checks BIGINT NOT NULL DEFAULT 0,
+    avg_response_time_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p95_response_time_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_health_daily_period ON health_check_daily_rollups(endpoint_id, period_start);
+CREATE INDEX IF NOT EXISTS idx_health_check_daily_rollups_period_start ON health_check_daily_rollups(period_start);
@@ -0,0 +1,20 @@
+-- Sampled proxied requests written by the request log job
+CREATE TABLE IF NOT EXISTS request_logs (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    chain_name VARCHAR(50) NOT NULL,
+    method VARCHAR(100) NOT NULL,
+    batch_size INTEGER NOT NULL DEFAULT 1,
+    upstream VARCHAR(100),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    status INTEGER NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    success BOOLEAN NOT NULL,
+    error TEXT,
+    client_ip VARCHAR(64),
+    client_key VARCHAR(20)
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_logs_created_at ON request_logs(created_at);
+CREATE INDEX IF NOT EXISTS idx_request_logs_chain_name ON request_logs(chain_name);
+CREATE INDEX IF NOT EXISTS idx_request_logs_success ON request_logs(success);
@@ -0,0 +1,10 @@
+-- Configuration snapshots recorded after each admin change, for diffs and rollback
+CREATE TABLE IF NOT EXISTS config_revisions (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    action VARCHAR(200) NOT NULL,
+    changes INTEGER NOT NULL DEFAULT 0,
+    document TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_config_revisions_created_at ON config_revisions(created_at);
@@ -0,0 +1,2 @@
+-- Comma-separated chain names a tenant's keys may call; empty allows every chain
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS allowed_chains TEXT;
@@ -0,0 +1,14 @@
+-- Requests, errors and compute units per API key per UTC day, written by the usage flush job
+CREATE TABLE IF NOT EXISTS api_key_usages (
+    id SERIAL PRIMARY KEY,
+    api_key_id INTEGER NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    day TIMESTAMP WITH TIME ZONE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    compute_units BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_key_usage_day ON api_key_usages(api_key_id, day);
+CREATE INDEX IF NOT EXISTS idx_api_key_usages_tenant_id ON api_key_usages(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_api_key_usages_day ON api_key_usages(day);
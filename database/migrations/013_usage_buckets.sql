@@ -0,0 +1,14 @@
+-- Requests, errors and compute units per tenant, chain and method per UTC day, for billing exports
+CREATE TABLE IF NOT EXISTS usage_buckets (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    day TIMESTAMP WITH TIME ZONE NOT NULL,
+    chain_name VARCHAR(50) NOT NULL,
+    method VARCHAR(100) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    compute_units BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_bucket ON usage_buckets(tenant_id, day, chain_name, method);
+CREATE INDEX IF NOT EXISTS idx_usage_buckets_day ON usage_buckets(day);
@@ -0,0 +1,2 @@
+-- Comma-separated method names and prefix* patterns a tenant's keys may call; empty allows every method
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS allowed_methods TEXT;
@@ -0,0 +1,4 @@
+-- Per-tenant overrides of the plan's rate, burst and concurrency limits; 0 inherits them
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS rate_limit DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS rate_burst INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS max_concurrency INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- End of a rotated API key's grace window, after which it is revoked
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
@@ -0,0 +1,3 @@
+-- Comma-separated origins and client CIDRs an API key may be used from; empty allows any
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_origins TEXT;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_cidrs TEXT;
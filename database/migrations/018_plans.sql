@@ -0,0 +1,20 @@
+-- Plans assigned to tenants by name; their limits, quota and allowlists apply to every tenant
+-- on the plan that doesn't set its own
+CREATE TABLE IF NOT EXISTS plans (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    rate_limit DOUBLE PRECISION NOT NULL DEFAULT 0,
+    rate_burst INTEGER NOT NULL DEFAULT 0,
+    max_concurrency INTEGER NOT NULL DEFAULT 0,
+    -- Compute units per tenant per UTC month; 0 is unlimited
+    monthly_quota BIGINT NOT NULL DEFAULT 0,
+    -- Comma-separated; empty allows any
+    allowed_chains TEXT,
+    allowed_methods TEXT,
+    hedging_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    cache_priority INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_plans_name ON plans(name);
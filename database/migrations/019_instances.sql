@@ -0,0 +1,14 @@
+-- Running proxy replicas, refreshed by each one's heartbeat and read by GET /admin/cluster
+CREATE TABLE IF NOT EXISTS instances (
+    id VARCHAR(100) PRIMARY KEY,
+    hostname VARCHAR(255),
+    version VARCHAR(50),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    -- Comma-separated chain names
+    chains TEXT,
+    -- JSON health and traffic as of the last heartbeat
+    stats TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_instances_last_seen ON instances(last_seen);
@@ -0,0 +1,6 @@
+-- How an endpoint is reached: http endpoints take proxied requests, ws endpoints only serve
+-- subscriptions. An http endpoint's ws_url is its WebSocket URL at the same provider.
+ALTER TABLE rpc_endpoints ADD COLUMN IF NOT EXISTS transport VARCHAR(10) NOT NULL DEFAULT 'http';
+ALTER TABLE rpc_endpoints ADD COLUMN IF NOT EXISTS ws_url VARCHAR(500);
+
+UPDATE rpc_endpoints SET transport = 'ws' WHERE url LIKE 'ws://%' OR url LIKE 'wss://%';
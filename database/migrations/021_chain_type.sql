@@ -0,0 +1,3 @@
+-- Chain type, selecting how a chain's endpoints are health checked and its requests routed:
+-- evm (eth_blockNumber probes) or solana (getHealth and getSlot probes)
+ALTER TABLE chains ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'evm';
@@ -0,0 +1,3 @@
+-- HTTP basic auth credentials sent with every request to the endpoint, as Bitcoin nodes require
+ALTER TABLE rpc_endpoints ADD COLUMN IF NOT EXISTS auth_username VARCHAR(100);
+ALTER TABLE rpc_endpoints ADD COLUMN IF NOT EXISTS auth_password VARCHAR(255);
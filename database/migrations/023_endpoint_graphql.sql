@@ -0,0 +1,3 @@
+-- Where an http endpoint serves GraphQL, such as Geth's /graphql. Only endpoints with a
+-- graphql_url get requests proxied to /graphql/{chain}.
+ALTER TABLE rpc_endpoints ADD COLUMN IF NOT EXISTS graphql_url VARCHAR(500);
@@ -0,0 +1,3 @@
+-- The method namespaces an endpoint serves that most nodes don't, comma-separated: debug and
+-- trace calls are only routed to endpoints listing the namespace.
+ALTER TABLE rpc_endpoints ADD COLUMN IF NOT EXISTS capabilities VARCHAR(200);
@@ -0,0 +1,151 @@
+package analytics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	clientBuckets = 60
+
+	// maxClientsPerBucket bounds memory under traffic from many distinct addresses;
+	// further clients in the same bucket are counted under overflowClient
+	maxClientsPerBucket = 10000
+	overflowClient      = "(other)"
+)
+
+// ClientKey identifies a consumer by address and, when it sent one, API key fingerprint and
+// the tenant that key belongs to
+type ClientKey struct {
+	IP       string `json:"ip,omitempty"`
+	Key      string `json:"keyFingerprint,omitempty"`
+	TenantID int    `json:"tenantId,omitempty"`
+	// KeyID is the tenant API key's ID, for usage metering; it identifies the same key as Key
+	KeyID int `json:"-"`
+}
+
+// ClientUsage is one consumer's traffic over the tracker's window
+type ClientUsage struct {
+	ClientKey
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+}
+
+type clientCounts struct {
+	requests int64
+	errors   int64
+}
+
+type clientBucket struct {
+	slot    int64
+	clients map[ClientKey]*clientCounts
+}
+
+// ClientTracker counts requests per client over a rolling window split into fixed buckets
+type ClientTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	width   time.Duration
+	buckets [clientBuckets]clientBucket
+}
+
+func NewClientTracker(window time.Duration) *ClientTracker {
+	width := window / clientBuckets
+	if width <= 0 {
+		width = time.Second
+	}
+
+	return &ClientTracker{window: window, width: width}
+}
+
+// Window returns the span covered by TopClients
+func (t *ClientTracker) Window() time.Duration {
+	return t.window
+}
+
+func (t *ClientTracker) Record(client ClientKey, success bool) {
+	slot := time.Now().UnixNano() / int64(t.width)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket := &t.buckets[slot%clientBuckets]
+	if bucket.slot != slot || bucket.clients == nil {
+		*bucket = clientBucket{slot: slot, clients: make(map[ClientKey]*clientCounts)}
+	}
+
+	counts, ok := bucket.clients[client]
+	if !ok {
+		if len(bucket.clients) >= maxClientsPerBucket {
+			client = ClientKey{IP: overflowClient}
+			counts = bucket.clients[client]
+		}
+		if counts == nil {
+			counts = &clientCounts{}
+			bucket.clients[client] = counts
+		}
+	}
+
+	counts.requests++
+	if !success {
+		counts.errors++
+	}
+}
+
+// TopClients returns up to limit clients by request count over the window. groupBy "ip" or
+// "key" merges clients on that field alone; anything else keeps IP and key together.
+func (t *ClientTracker) TopClients(groupBy string, limit int) []ClientUsage {
+	current := time.Now().UnixNano() / int64(t.width)
+	oldest := current - clientBuckets + 1
+
+	totals := make(map[ClientKey]*ClientUsage)
+
+	t.mu.Lock()
+	for i := range t.buckets {
+		bucket := &t.buckets[i]
+		if bucket.slot < oldest || bucket.slot > current {
+			continue
+		}
+		for client, counts := range bucket.clients {
+			switch groupBy {
+			case "ip":
+				client.Key = ""
+			case "key":
+				if client.IP != overflowClient {
+					client.IP = ""
+				}
+			}
+
+			usage, ok := totals[client]
+			if !ok {
+				usage = &ClientUsage{ClientKey: client}
+				totals[client] = usage
+			}
+			usage.Requests += counts.requests
+			usage.Errors += counts.errors
+		}
+	}
+	t.mu.Unlock()
+
+	top := make([]ClientUsage, 0, len(totals))
+	for _, usage := range totals {
+		top = append(top, *usage)
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Requests != top[j].Requests {
+			return top[i].Requests > top[j].Requests
+		}
+		if top[i].IP != top[j].IP {
+			return top[i].IP < top[j].IP
+		}
+		return top[i].Key < top[j].Key
+	})
+
+	if limit > 0 && len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top
+}
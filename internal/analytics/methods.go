@@ -0,0 +1,190 @@
+// Package analytics aggregates proxied JSON-RPC traffic for the admin API
+package analytics
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// maxTrackedMethods bounds memory use when clients send arbitrary method names
+	maxTrackedMethods = 1000
+
+	// Methods that don't look like JSON-RPC method names, or that arrive after
+	// maxTrackedMethods distinct ones, are counted under these names
+	invalidMethod  = "(invalid)"
+	overflowMethod = "(other)"
+)
+
+var methodNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{1,64}$`)
+
+type methodKey struct {
+	chain  string
+	method string
+}
+
+type methodCounts struct {
+	requests       int64
+	errors         int64
+	totalLatencyMs int64
+	maxLatencyMs   int64
+}
+
+func (c *methodCounts) add(success bool, latencyMs int64) {
+	c.requests++
+	if !success {
+		c.errors++
+	}
+	c.totalLatencyMs += latencyMs
+	if latencyMs > c.maxLatencyMs {
+		c.maxLatencyMs = latencyMs
+	}
+}
+
+// MethodUsage summarises one method on one chain since the tracker started
+type MethodUsage struct {
+	Chain        string  `json:"chain"`
+	Method       string  `json:"method"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ErrorRate    float64 `json:"errorRate"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	MaxLatencyMs int64   `json:"maxLatencyMs"`
+}
+
+// MethodTracker counts requests and latency per chain and JSON-RPC method. Totals live for
+// the life of the process; a separate pending set accumulates until drained into rollups.
+type MethodTracker struct {
+	mu      sync.Mutex
+	since   time.Time
+	totals  map[methodKey]*methodCounts
+	pending map[methodKey]*methodCounts
+}
+
+func NewMethodTracker() *MethodTracker {
+	return &MethodTracker{
+		since:   time.Now(),
+		totals:  make(map[methodKey]*methodCounts),
+		pending: make(map[methodKey]*methodCounts),
+	}
+}
+
+// Record adds one call of method on chain; batch members are recorded individually with the batch latency
+func (t *MethodTracker) Record(chain, method string, latency time.Duration, success bool) {
+	if !methodNamePattern.MatchString(method) {
+		method = invalidMethod
+	}
+	latencyMs := latency.Milliseconds()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := methodKey{chain: chain, method: method}
+	counts, ok := t.totals[key]
+	if !ok {
+		if len(t.totals) >= maxTrackedMethods {
+			key.method = overflowMethod
+			counts = t.totals[key]
+		}
+		if counts == nil {
+			counts = &methodCounts{}
+			t.totals[key] = counts
+		}
+	}
+	counts.add(success, latencyMs)
+
+	delta, ok := t.pending[key]
+	if !ok {
+		delta = &methodCounts{}
+		t.pending[key] = delta
+	}
+	delta.add(success, latencyMs)
+}
+
+// Since returns when the tracker started counting
+func (t *MethodTracker) Since() time.Time {
+	return t.since
+}
+
+// Usage returns per-method totals, busiest first; an empty chain returns every chain
+func (t *MethodTracker) Usage(chain string) []MethodUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := make([]MethodUsage, 0, len(t.totals))
+	for key, counts := range t.totals {
+		if chain != "" && key.chain != chain {
+			continue
+		}
+		usage = append(usage, MethodUsage{
+			Chain:        key.chain,
+			Method:       key.method,
+			Requests:     counts.requests,
+			Errors:       counts.errors,
+			ErrorRate:    float64(counts.errors) / float64(counts.requests),
+			AvgLatencyMs: float64(counts.totalLatencyMs) / float64(counts.requests),
+			MaxLatencyMs: counts.maxLatencyMs,
+		})
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Requests != usage[j].Requests {
+			return usage[i].Requests > usage[j].Requests
+		}
+		if usage[i].Chain != usage[j].Chain {
+			return usage[i].Chain < usage[j].Chain
+		}
+		return usage[i].Method < usage[j].Method
+	})
+
+	return usage
+}
+
+// Drain returns the counts accumulated since the previous drain as rollups for periodStart
+// and resets them
+func (t *MethodTracker) Drain(periodStart time.Time) []*types.MethodUsageRollup {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[methodKey]*methodCounts)
+	t.mu.Unlock()
+
+	rollups := make([]*types.MethodUsageRollup, 0, len(pending))
+	for key, counts := range pending {
+		rollups = append(rollups, &types.MethodUsageRollup{
+			ChainName:      key.chain,
+			Method:         key.method,
+			PeriodStart:    periodStart,
+			Requests:       counts.requests,
+			Errors:         counts.errors,
+			TotalLatencyMs: counts.totalLatencyMs,
+			MaxLatencyMs:   counts.maxLatencyMs,
+		})
+	}
+
+	return rollups
+}
+
+// Restore puts drained rollups back into the pending set, e.g. after a failed write
+func (t *MethodTracker) Restore(rollups []*types.MethodUsageRollup) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, rollup := range rollups {
+		key := methodKey{chain: rollup.ChainName, method: rollup.Method}
+		delta, ok := t.pending[key]
+		if !ok {
+			delta = &methodCounts{}
+			t.pending[key] = delta
+		}
+		delta.requests += rollup.Requests
+		delta.errors += rollup.Errors
+		delta.totalLatencyMs += rollup.TotalLatencyMs
+		if rollup.MaxLatencyMs > delta.maxLatencyMs {
+			delta.maxLatencyMs = rollup.MaxLatencyMs
+		}
+	}
+}
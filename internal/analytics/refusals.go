@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of tenant policy, client limit and quota refusal
+const (
+	RefusalUnknownKey         = "unknown_key"
+	RefusalRevokedKey         = "revoked_key"
+	RefusalSuspendedTenant    = "suspended_tenant"
+	RefusalChainNotAllowed    = "chain_not_allowed"
+	RefusalMethodNotAllowed   = "method_not_allowed"
+	RefusalOriginNotAllowed   = "origin_not_allowed"
+	RefusalIPNotAllowed       = "ip_not_allowed"
+	RefusalRateLimited        = "rate_limited"
+	RefusalConcurrencyLimited = "concurrency_limited"
+	RefusalQuotaExceeded      = "quota_exceeded"
+)
+
+// maxTrackedRefusals bounds memory when refused clients send arbitrary chain or method names;
+// further combinations are counted under overflowMethod
+const maxTrackedRefusals = 1000
+
+type refusalKey struct {
+	tenantID int
+	kind     string
+	detail   string
+}
+
+type refusalCounts struct {
+	count int64
+	last  time.Time
+}
+
+// RefusalCount is how often requests of one tenant were refused for one reason since start
+type RefusalCount struct {
+	TenantID int    `json:"tenantId"`
+	Kind     string `json:"kind"`
+	// Detail is the refused chain, method, origin or client address, when the kind has one
+	Detail   string    `json:"detail,omitempty"`
+	Count    int64     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// RefusalTracker counts requests refused by tenant policy or client limits per tenant, kind
+// and detail; requests without a tenant key count under tenant 0
+type RefusalTracker struct {
+	mu       sync.Mutex
+	since    time.Time
+	refusals map[refusalKey]*refusalCounts
+}
+
+func NewRefusalTracker() *RefusalTracker {
+	return &RefusalTracker{
+		since:    time.Now(),
+		refusals: make(map[refusalKey]*refusalCounts),
+	}
+}
+
+// Record counts one refused request
+func (t *RefusalTracker) Record(tenantID int, kind, detail string) {
+	if kind == RefusalMethodNotAllowed && !methodNamePattern.MatchString(detail) {
+		detail = invalidMethod
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := refusalKey{tenantID: tenantID, kind: kind, detail: detail}
+	counts, ok := t.refusals[key]
+	if !ok {
+		if len(t.refusals) >= maxTrackedRefusals {
+			key.detail = overflowMethod
+			counts = t.refusals[key]
+		}
+		if counts == nil {
+			counts = &refusalCounts{}
+			t.refusals[key] = counts
+		}
+	}
+	counts.count++
+	counts.last = time.Now()
+}
+
+// Since returns when the tracker started counting
+func (t *RefusalTracker) Since() time.Time {
+	return t.since
+}
+
+// Refusals returns the counts, most frequent first; a zero tenantID returns every tenant
+func (t *RefusalTracker) Refusals(tenantID int) []RefusalCount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	refusals := make([]RefusalCount, 0, len(t.refusals))
+	for key, counts := range t.refusals {
+		if tenantID != 0 && key.tenantID != tenantID {
+			continue
+		}
+		refusals = append(refusals, RefusalCount{
+			TenantID: key.tenantID,
+			Kind:     key.kind,
+			Detail:   key.detail,
+			Count:    counts.count,
+			LastSeen: counts.last,
+		})
+	}
+
+	sort.Slice(refusals, func(i, j int) bool {
+		a, b := refusals[i], refusals[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.TenantID != b.TenantID {
+			return a.TenantID < b.TenantID
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Detail < b.Detail
+	})
+	return refusals
+}
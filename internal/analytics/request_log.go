@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// maxBufferedRequestLogs bounds memory use when the database falls behind; further logs
+	// are dropped and counted until the next drain
+	maxBufferedRequestLogs = 10000
+
+	// maxRequestLogErrorLength truncates upstream error messages kept in a log
+	maxRequestLogErrorLength = 500
+)
+
+// RequestLogger samples proxied requests for the request log. Failed requests are kept
+// whenever logErrors is set, and any request with probability sampleRate. Kept logs are
+// buffered until drained into the database.
+type RequestLogger struct {
+	sampleRate float64
+	logErrors  bool
+
+	mu      sync.Mutex
+	pending []*types.RequestLog
+	dropped int64
+}
+
+func NewRequestLogger(sampleRate float64, logErrors bool) *RequestLogger {
+	return &RequestLogger{
+		sampleRate: sampleRate,
+		logErrors:  logErrors,
+	}
+}
+
+// Sampled reports whether a request with this outcome should be logged; callers check it
+// before building the log
+func (l *RequestLogger) Sampled(success bool) bool {
+	if !success && l.logErrors {
+		return true
+	}
+	return l.sampleRate > 0 && rand.Float64() < l.sampleRate
+}
+
+// Record buffers a sampled log
+func (l *RequestLogger) Record(entry *types.RequestLog) {
+	if !methodNamePattern.MatchString(entry.Method) {
+		entry.Method = invalidMethod
+	}
+	if len(entry.Error) > maxRequestLogErrorLength {
+		entry.Error = entry.Error[:maxRequestLogErrorLength]
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) >= maxBufferedRequestLogs {
+		l.dropped++
+		return
+	}
+	l.pending = append(l.pending, entry)
+}
+
+// Drain returns the buffered logs and the number dropped since the previous drain, and
+// resets both
+func (l *RequestLogger) Drain() ([]*types.RequestLog, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pending, dropped := l.pending, l.dropped
+	l.pending, l.dropped = nil, 0
+	return pending, dropped
+}
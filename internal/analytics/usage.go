@@ -0,0 +1,190 @@
+package analytics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// defaultComputeUnits is the cost of a successful call to a method without its own weight
+const defaultComputeUnits = 1
+
+// methodComputeUnits weighs methods that cost upstreams noticeably more than a simple read
+var methodComputeUnits = map[string]int64{
+	"eth_call":               2,
+	"eth_estimateGas":        3,
+	"eth_getLogs":            5,
+	"eth_getBlockReceipts":   10,
+	"eth_sendRawTransaction": 10,
+
+	// Solana
+	"getProgramAccounts":      10,
+	"getSignaturesForAddress": 5,
+	"getBlock":                5,
+	"sendTransaction":         10,
+
+	// Starknet
+	"starknet_call":                        2,
+	"starknet_estimateFee":                 3,
+	"starknet_estimateMessageFee":          3,
+	"starknet_getEvents":                   5,
+	"starknet_getBlockWithReceipts":        10,
+	"starknet_addInvokeTransaction":        10,
+	"starknet_addDeclareTransaction":       10,
+	"starknet_addDeployAccountTransaction": 10,
+	"starknet_traceTransaction":            20,
+	"starknet_traceBlockTransactions":      20,
+	"starknet_simulateTransactions":        20,
+}
+
+// traceComputeUnits is the cost of trace_* and debug_* calls, which replay transactions
+const traceComputeUnits = 20
+
+// ComputeUnits returns the cost of one successful call to method
+func ComputeUnits(method string) int64 {
+	if units, ok := methodComputeUnits[method]; ok {
+		return units
+	}
+	if strings.HasPrefix(method, "trace_") || strings.HasPrefix(method, "debug_") {
+		return traceComputeUnits
+	}
+	return defaultComputeUnits
+}
+
+type usageKey struct {
+	keyID    int
+	tenantID int
+	day      time.Time
+}
+
+type bucketKey struct {
+	tenantID int
+	day      time.Time
+	chain    string
+	method   string
+}
+
+type usageCounts struct {
+	requests     int64
+	errors       int64
+	computeUnits int64
+}
+
+func (c *usageCounts) add(requests, errors, computeUnits int64) {
+	c.requests += requests
+	c.errors += errors
+	c.computeUnits += computeUnits
+}
+
+// UsageMeter counts calls and compute units per API key per UTC day, and per tenant, chain
+// and method per UTC day, until they are drained to the database
+type UsageMeter struct {
+	mu      sync.Mutex
+	pending map[usageKey]*usageCounts
+	buckets map[bucketKey]*usageCounts
+}
+
+func NewUsageMeter() *UsageMeter {
+	return &UsageMeter{
+		pending: make(map[usageKey]*usageCounts),
+		buckets: make(map[bucketKey]*usageCounts),
+	}
+}
+
+// Record adds one call of method on chain made with the API key keyID; batch members are
+// recorded individually. Failed calls count as requests and errors but cost no compute units.
+func (m *UsageMeter) Record(keyID, tenantID int, chain, method string, success bool) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	var errors, computeUnits int64
+	if success {
+		computeUnits = ComputeUnits(method)
+	} else {
+		errors = 1
+	}
+	if !methodNamePattern.MatchString(method) {
+		method = invalidMethod
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counts(usageKey{keyID: keyID, tenantID: tenantID, day: day}).add(1, errors, computeUnits)
+
+	bucket := bucketKey{tenantID: tenantID, day: day, chain: chain, method: method}
+	if _, ok := m.buckets[bucket]; !ok && len(m.buckets) >= maxTrackedMethods {
+		bucket.method = overflowMethod
+	}
+	m.bucketCounts(bucket).add(1, errors, computeUnits)
+}
+
+// counts returns the pending counts for key, adding them if needed; m.mu must be held
+func (m *UsageMeter) counts(key usageKey) *usageCounts {
+	counts, ok := m.pending[key]
+	if !ok {
+		counts = &usageCounts{}
+		m.pending[key] = counts
+	}
+	return counts
+}
+
+// bucketCounts returns the pending counts for key, adding them if needed; m.mu must be held
+func (m *UsageMeter) bucketCounts(key bucketKey) *usageCounts {
+	counts, ok := m.buckets[key]
+	if !ok {
+		counts = &usageCounts{}
+		m.buckets[key] = counts
+	}
+	return counts
+}
+
+// Drain returns the usage and buckets recorded since the previous drain and resets them
+func (m *UsageMeter) Drain() ([]*types.APIKeyUsage, []*types.UsageBucket) {
+	m.mu.Lock()
+	pending, pendingBuckets := m.pending, m.buckets
+	m.pending = make(map[usageKey]*usageCounts)
+	m.buckets = make(map[bucketKey]*usageCounts)
+	m.mu.Unlock()
+
+	usage := make([]*types.APIKeyUsage, 0, len(pending))
+	for key, counts := range pending {
+		usage = append(usage, &types.APIKeyUsage{
+			APIKeyID:     key.keyID,
+			TenantID:     key.tenantID,
+			Day:          key.day,
+			Requests:     counts.requests,
+			Errors:       counts.errors,
+			ComputeUnits: counts.computeUnits,
+		})
+	}
+
+	buckets := make([]*types.UsageBucket, 0, len(pendingBuckets))
+	for key, counts := range pendingBuckets {
+		buckets = append(buckets, &types.UsageBucket{
+			TenantID:     key.tenantID,
+			Day:          key.day,
+			ChainName:    key.chain,
+			Method:       key.method,
+			Requests:     counts.requests,
+			Errors:       counts.errors,
+			ComputeUnits: counts.computeUnits,
+		})
+	}
+	return usage, buckets
+}
+
+// Restore puts drained usage and buckets back, e.g. after a failed write
+func (m *UsageMeter) Restore(usage []*types.APIKeyUsage, buckets []*types.UsageBucket) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range usage {
+		key := usageKey{keyID: u.APIKeyID, tenantID: u.TenantID, day: u.Day}
+		m.counts(key).add(u.Requests, u.Errors, u.ComputeUnits)
+	}
+	for _, b := range buckets {
+		key := bucketKey{tenantID: b.TenantID, day: b.Day, chain: b.ChainName, method: b.Method}
+		m.bucketCounts(key).add(b.Requests, b.Errors, b.ComputeUnits)
+	}
+}
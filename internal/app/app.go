@@ -0,0 +1,83 @@
+// Package app owns the resources shared by the whole process: the configuration and the
+// single database connection every repository, job and handler is built on.
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/database"
+)
+
+type App struct {
+	Config *config.Config
+	// DB is nil when no database is configured or it could not be reached at startup
+	DB *database.GormDB
+}
+
+// New reads the configuration, opens the database connection once if one is configured, brings
+// its schema up to date, and loads chains and settings through it. An unreachable database is
+// not fatal: chains then come from the fallback and database-backed features stay off. A
+// failed migration, or an outdated schema with auto-migration disabled, is.
+func New() (*App, error) {
+	cfg := config.Read()
+
+	var db *database.GormDB
+	if cfg.Database.Host != "" {
+		var err error
+		db, err = database.NewGormConnection(cfg.DatabaseConfig())
+		if err != nil {
+			log.Printf("Warning: Database unavailable, continuing without it: %v", err)
+			db = nil
+		}
+	}
+
+	if db != nil {
+		if err := prepareSchema(cfg, db); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	if err := cfg.Load(db); err != nil {
+		if db != nil {
+			db.Close()
+		}
+		return nil, err
+	}
+
+	return &App{Config: cfg, DB: db}, nil
+}
+
+// Close releases the database connection; call it once everything using it has stopped
+func (a *App) Close() error {
+	if a.DB == nil {
+		return nil
+	}
+	return a.DB.Close()
+}
+
+// prepareSchema applies pending migrations and seeds default settings when auto-migration is
+// on, and otherwise only checks that nothing is pending
+func prepareSchema(cfg *config.Config, db *database.GormDB) error {
+	if !cfg.Database.AutoMigrate {
+		pending, err := db.PendingMigrations()
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("database schema has %d pending migrations (up to version %d) and DB_AUTO_MIGRATE is off; run `rpc-proxy migrate`",
+				len(pending), database.LatestMigrationVersion())
+		}
+		return nil
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		return err
+	}
+	if err := db.SeedData(); err != nil {
+		return fmt.Errorf("failed to seed default data: %w", err)
+	}
+	return nil
+}
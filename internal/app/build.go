@@ -0,0 +1,56 @@
+package app
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Build information of this binary, which builds set with
+//
+//	-ldflags "-X rpc-proxy/internal/app.Version=<version> -X rpc-proxy/internal/app.Commit=<sha> -X rpc-proxy/internal/app.BuildDate=<RFC 3339 time>"
+//
+// Builds without them report version dev, and the commit Go stamps into binaries built from a
+// git checkout.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo tells which build a replica runs
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	// Modified is set on binaries built from a checkout with uncommitted changes
+	Modified bool `json:"modified,omitempty"`
+}
+
+// Build returns the build information of this binary
+var Build = sync.OnceValue(func() BuildInfo {
+	build := BuildInfo{Version: Version, Commit: Commit, BuildDate: BuildDate, GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if build.Commit == "" {
+					build.Commit = setting.Value
+				}
+			case "vcs.modified":
+				build.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return build
+})
+
+// Label is the version with the commit's first 12 characters as build metadata, e.g.
+// 1.4.0+3f2a9c1d0b7e, so instances running different builds of a version tell apart
+func (b BuildInfo) Label() string {
+	if b.Commit == "" {
+		return b.Version
+	}
+	return b.Version + "+" + b.Commit[:min(len(b.Commit), 12)]
+}
@@ -0,0 +1,231 @@
+// Package cluster registers each proxy replica in the database with a periodic heartbeat
+// carrying its health and traffic, so any replica can report on the whole fleet.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// HeartbeatInterval is how often each instance refreshes its registration
+	HeartbeatInterval = 15 * time.Second
+	// liveHeartbeats is how many heartbeats an instance may miss before it counts as down
+	liveHeartbeats = 3
+	// forgetAfter is how long a stopped or crashed instance stays listed as down
+	forgetAfter = 24 * time.Hour
+)
+
+// Instance is this replica's registration
+type Instance struct {
+	repo    repository.InstanceRepository
+	checker *health.MultiChainChecker
+
+	id        string
+	hostname  string
+	version   string
+	startedAt time.Time
+
+	// configEpoch returns the config epoch applied; nil when the configuration isn't versioned
+	configEpoch func() int64
+}
+
+// New returns the registration of this replica, built from version and the health checker's
+// chains, endpoints and traffic
+func New(repo repository.InstanceRepository, checker *health.MultiChainChecker, version string) *Instance {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	id := hex.EncodeToString(suffix)
+	if hostname != "" {
+		id = hostname + "-" + id
+	}
+	return &Instance{
+		repo:      repo,
+		checker:   checker,
+		id:        id,
+		hostname:  hostname,
+		version:   version,
+		startedAt: time.Now(),
+	}
+}
+
+// SetConfigEpoch reports the config epoch returned by epoch in heartbeats
+func (i *Instance) SetConfigEpoch(epoch func() int64) {
+	i.configEpoch = epoch
+}
+
+// ID returns the instance ID, the hostname with a random suffix
+func (i *Instance) ID() string {
+	return i.id
+}
+
+// Run records a heartbeat and forgets instances gone for a day; it is the scheduler's
+// instance_heartbeat task
+func (i *Instance) Run(ctx context.Context) error {
+	if err := i.repo.Upsert(i.snapshot()); err != nil {
+		return err
+	}
+	forgotten, err := i.repo.DeleteStale(time.Now().Add(-forgetAfter))
+	if err != nil {
+		return err
+	}
+	if forgotten > 0 {
+		log.Printf("Forgot %d proxy instance(s) without a heartbeat for %v", forgotten, forgetAfter)
+	}
+	return nil
+}
+
+// Deregister removes the registration on shutdown, so the instance leaves the cluster view
+// at once rather than once its heartbeats stop
+func (i *Instance) Deregister() {
+	if err := i.repo.Delete(i.id); err != nil {
+		log.Printf("Failed to deregister instance %s: %v", i.id, err)
+	}
+}
+
+// snapshot returns the registration with current health and traffic
+func (i *Instance) snapshot() *types.Instance {
+	stats := types.InstanceStats{Chains: make(map[string]*types.InstanceChainStats)}
+	if i.configEpoch != nil {
+		stats.ConfigEpoch = i.configEpoch()
+	}
+	chains := i.checker.GetSupportedChains()
+	sort.Strings(chains)
+
+	for _, chainName := range chains {
+		chainStats := &types.InstanceChainStats{}
+		if status := i.checker.GetChainStatus(chainName); status != nil {
+			chainStats.HealthyEndpoints = status.HealthyCount
+			chainStats.TotalEndpoints = status.TotalEndpoints
+		}
+		for _, endpoint := range i.checker.GetAllEndpoints(chainName) {
+			chainStats.Traffic.Add(endpoint.GetTrafficStats().Total)
+			chainStats.InFlight += endpoint.GetInFlight()
+		}
+		stats.Traffic.Add(chainStats.Traffic)
+		stats.InFlight += chainStats.InFlight
+		stats.Chains[chainName] = chainStats
+	}
+
+	return &types.Instance{
+		ID:        i.id,
+		Hostname:  i.hostname,
+		Version:   i.version,
+		StartedAt: i.startedAt,
+		LastSeen:  time.Now(),
+		Chains:    chains,
+		Stats:     stats,
+	}
+}
+
+// Member is a registered instance in the cluster view
+type Member struct {
+	*types.Instance
+	// Live is false once the instance has missed liveHeartbeats heartbeats
+	Live bool `json:"live"`
+	// Self marks the instance serving the request
+	Self bool `json:"self"`
+}
+
+// ChainSummary is one chain across the live instances serving it
+type ChainSummary struct {
+	Instances int `json:"instances"`
+	// MinHealthyEndpoints and MaxHealthyEndpoints differ when instances disagree on health
+	MinHealthyEndpoints int                 `json:"minHealthyEndpoints"`
+	MaxHealthyEndpoints int                 `json:"maxHealthyEndpoints"`
+	TotalEndpoints      int                 `json:"totalEndpoints"`
+	Traffic             types.TrafficCounts `json:"traffic"`
+	InFlight            int64               `json:"inFlight"`
+}
+
+// Status is the fleet as registered: every known instance, and totals over the live ones
+type Status struct {
+	Instances []*Member `json:"instances"`
+	Total     int       `json:"total"`
+	Live      int       `json:"live"`
+	// Versions counts live instances per version, showing rollouts in progress
+	Versions map[string]int `json:"versions"`
+	// ConfigEpochs counts live instances per config epoch; a single entry means they all
+	// applied the same configuration
+	ConfigEpochs map[int64]int            `json:"configEpochs,omitempty"`
+	Traffic      types.TrafficCounts      `json:"traffic"`
+	InFlight     int64                    `json:"inFlight"`
+	Chains       map[string]*ChainSummary `json:"chains"`
+}
+
+// Status returns the cluster view, with this instance's entry taken fresh rather than from
+// its last heartbeat
+func (i *Instance) Status() (*Status, error) {
+	instances, err := i.repo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load instances: %w", err)
+	}
+
+	self := i.snapshot()
+	members := []*Member{{Instance: self, Live: true, Self: true}}
+	liveSince := time.Now().Add(-liveHeartbeats * HeartbeatInterval)
+	for _, instance := range instances {
+		if instance.ID == i.id {
+			continue
+		}
+		members = append(members, &Member{Instance: instance, Live: instance.LastSeen.After(liveSince)})
+	}
+	return summarize(members), nil
+}
+
+// summarize totals the live members
+func summarize(members []*Member) *Status {
+	status := &Status{
+		Instances: members,
+		Total:     len(members),
+		Versions:  make(map[string]int),
+		Chains:    make(map[string]*ChainSummary),
+	}
+	epochs := make(map[int64]int)
+
+	for _, member := range members {
+		if !member.Live {
+			continue
+		}
+		status.Live++
+		status.Versions[member.Version]++
+		if member.Stats.ConfigEpoch > 0 {
+			epochs[member.Stats.ConfigEpoch]++
+		}
+		status.Traffic.Add(member.Stats.Traffic)
+		status.InFlight += member.Stats.InFlight
+
+		for chainName, chainStats := range member.Stats.Chains {
+			summary, ok := status.Chains[chainName]
+			if !ok {
+				summary = &ChainSummary{
+					MinHealthyEndpoints: chainStats.HealthyEndpoints,
+					MaxHealthyEndpoints: chainStats.HealthyEndpoints,
+				}
+				status.Chains[chainName] = summary
+			}
+			summary.Instances++
+			summary.MinHealthyEndpoints = min(summary.MinHealthyEndpoints, chainStats.HealthyEndpoints)
+			summary.MaxHealthyEndpoints = max(summary.MaxHealthyEndpoints, chainStats.HealthyEndpoints)
+			summary.TotalEndpoints = max(summary.TotalEndpoints, chainStats.TotalEndpoints)
+			summary.Traffic.Add(chainStats.Traffic)
+			summary.InFlight += chainStats.InFlight
+		}
+	}
+	if len(epochs) > 0 {
+		status.ConfigEpochs = epochs
+	}
+	return status
+}
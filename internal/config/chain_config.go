@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// chainConfigValidators lists the chain config keys the proxy understands
+var chainConfigValidators = map[string]func(string) error{
+	"max_block_lag":            validateNonNegativeInt,
+	"max_block_divergence":     validateNonNegativeInt,
+	"gas_price_gwei_threshold": validateNonNegativeFloat,
+	"timeout_seconds":          validatePositiveInt,
+	"retry_attempts":           validatePositiveInt,
+	"lb_strategy":              validateLBStrategy,
+	"proxy_timeout":            validatePositiveDuration,
+	"max_failover_attempts":    validatePositiveInt,
+	"failover_backoff":         validateNonNegativeDuration,
+	"discovery_weight":         validatePositiveInt,
+	"k8s_selector":             validateLabelSelector,
+	"k8s_namespace":            validateNamespace,
+	"k8s_port":                 validatePortOrName,
+	"dns_srv":                  validateDNSName,
+	"dns_name":                 validateDNSName,
+	"dns_port":                 validatePort,
+	"dns_scheme":               validateDNSScheme,
+	"starknet_chain_id":        validateStarknetChainID,
+	"gas_oracle_method":        validateGasOracleMethod,
+}
+
+var (
+	// namespacePattern matches Kubernetes namespace names (DNS labels)
+	namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	// portNamePattern matches Kubernetes port names (IANA service names)
+	portNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,13}[a-z0-9])?$`)
+	// dnsNamePattern matches DNS names, including the underscored labels of SRV names
+	dnsNamePattern = regexp.MustCompile(`^([A-Za-z0-9_]([-A-Za-z0-9_]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([-A-Za-z0-9]{0,61}[A-Za-z0-9])?\.?$`)
+)
+
+// KnownChainConfigKeys returns the supported chain config keys in sorted order
+func KnownChainConfigKeys() []string {
+	keys := make([]string, 0, len(chainConfigValidators))
+	for key := range chainConfigValidators {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateChainConfig checks that key is a known chain config key and value is valid for it
+func ValidateChainConfig(key, value string) error {
+	validate, known := chainConfigValidators[key]
+	if !known {
+		return fmt.Errorf("unknown chain config key %q, must be one of: %s", key, strings.Join(KnownChainConfigKeys(), ", "))
+	}
+
+	if err := validate(strings.TrimSpace(value)); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+
+	return nil
+}
+
+func validateNonNegativeInt(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	if n < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func validatePositiveInt(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	if n <= 0 {
+		return fmt.Errorf("must be positive")
+	}
+	return nil
+}
+
+func validateNonNegativeFloat(value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	if f < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func validateNonNegativeDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("must be a duration such as 100ms")
+	}
+	if d < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+// validateLabelSelector accepts a non-empty Kubernetes label selector; the API server checks
+// its syntax when it is used
+func validateLabelSelector(value string) error {
+	if value == "" {
+		return fmt.Errorf("must be a label selector such as app=geth")
+	}
+	return nil
+}
+
+func validateNamespace(value string) error {
+	if !namespacePattern.MatchString(value) {
+		return fmt.Errorf("must be a Kubernetes namespace name")
+	}
+	return nil
+}
+
+// validatePortOrName accepts a port number or a port name
+func validatePortOrName(value string) error {
+	if _, err := strconv.Atoi(value); err == nil {
+		return validatePort(value)
+	}
+	if !portNamePattern.MatchString(value) {
+		return fmt.Errorf("must be a port number or name")
+	}
+	return nil
+}
+
+func validateDNSName(value string) error {
+	if len(value) > 253 || !dnsNamePattern.MatchString(value) {
+		return fmt.Errorf("must be a DNS name such as _rpc._tcp.nodes.example.com")
+	}
+	return nil
+}
+
+func validateDNSScheme(value string) error {
+	if value != "http" && value != "https" {
+		return fmt.Errorf("must be http or https")
+	}
+	return nil
+}
+
+// validateStarknetChainID accepts Starknet short strings such as SN_MAIN: at most 31 printable
+// ASCII characters, so the felt starknet_chainId returns decodes back to them
+func validateStarknetChainID(value string) error {
+	if value == "" || len(value) > 31 {
+		return fmt.Errorf("must be 1 to 31 characters")
+	}
+	for _, c := range value {
+		if c <= ' ' || c > '~' {
+			return fmt.Errorf("must be printable ASCII without spaces")
+		}
+	}
+	return nil
+}
+
+func validateGasOracleMethod(value string) error {
+	switch value {
+	case types.GasOracleMedian, types.GasOracleTrimmedMean:
+		return nil
+	}
+	return fmt.Errorf("must be one of: %s, %s", types.GasOracleMedian, types.GasOracleTrimmedMean)
+}
+
+func validateLBStrategy(value string) error {
+	switch value {
+	case types.LBStrategyWeighted, types.LBStrategyRoundRobin, types.LBStrategyLatency, types.LBStrategySticky:
+		return nil
+	}
+	return fmt.Errorf("must be one of: %s, %s, %s, %s", types.LBStrategyWeighted, types.LBStrategyRoundRobin, types.LBStrategyLatency, types.LBStrategySticky)
+}
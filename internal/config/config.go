@@ -3,12 +3,17 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"rpc-proxy/internal/database"
 	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/metrics"
+	"rpc-proxy/internal/redis"
 	"rpc-proxy/internal/repository/gorm"
+	"rpc-proxy/internal/transport"
 	"rpc-proxy/internal/types"
 
 	"github.com/joho/godotenv"
@@ -20,42 +25,218 @@ type Config struct {
 	Database    DatabaseConfig
 	HealthCheck health.HealthCheckConfig
 	Proxy       ProxyConfig
+	DNS         DNSConfig
+	Upstream    transport.Config
+	Admin       AdminConfig
+	Reload      ReloadConfig
+	Analytics   AnalyticsConfig
+	Tenants     TenantsConfig
+	RequestLog  RequestLogConfig
+	Metrics     MetricsConfig
+	Settings    SettingsConfig
+	Remote      RemoteConfig
+	Redis       RedisConfig
+	Discovery   DiscoveryConfig
 	App         AppConfig
 
 	// Multi-chain runtime fields loaded from database
 	Chains         []*types.Chain
 	ChainEndpoints map[string][]*types.RPCEndpoint // chainName -> endpoints
 	ChainConfigs   map[string]map[string]string    // chainName -> configKey -> configValue
+	ChainsSource   string                          // where the chains came from, one of the ChainsFrom constants
 
 	// Legacy single-chain support (deprecated)
 	RPCEndpoints []*types.RPCEndpoint
+
+	// mu guards the multi-chain runtime fields once admin handlers start changing them
+	mu sync.RWMutex
 }
 
+// Chain configuration sources, in the order Load prefers them
+const (
+	ChainsFromFile        = "file"
+	ChainsFromRemote      = "remote"
+	ChainsFromDatabase    = "database"
+	ChainsFromEnvironment = "environment"
+	ChainsFromFallback    = "fallback"
+)
+
+// Listener modes
+const (
+	ServerModeStandard = "standard"
+	// ServerModePerformance tunes the HTTP server for high-QPS sidecar deployments and serves
+	// RPC requests without per-request debug logging
+	ServerModePerformance = "performance"
+)
+
 type ServerConfig struct {
 	Port int
+	Mode string
 }
 
 type DatabaseConfig struct {
+	Driver   string // postgres or mysql
 	Host     string
 	Port     int
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate applies pending schema migrations at startup; when off, startup refuses an
+	// out-of-date schema and `rpc-proxy migrate` must be run deliberately
+	AutoMigrate bool
 }
 
 type ProxyConfig struct {
 	Timeout        time.Duration
 	MaxConnections int
+	// RateLimitCooldown is how long an endpoint stays degraded after an upstream 429
+	RateLimitCooldown time.Duration
+	// TrustForwardedFor takes the client address from X-Forwarded-For; enable only behind a trusted proxy
+	TrustForwardedFor bool
+	// ClientRateLimit, ClientBurst and ClientMaxConcurrency limit each client address and are
+	// the defaults for tenants; 0 is unlimited
+	ClientRateLimit      float64
+	ClientBurst          int
+	ClientMaxConcurrency int
+	// PeerURLs are base URLs of other proxies, e.g. in another region, to forward a chain's
+	// requests to while it has no healthy endpoint here
+	PeerURLs []string
+	// PeerAPIKey is sent as X-API-Key to peers in place of the client's own key
+	PeerAPIKey string
+	// CoalesceMethods are the methods whose identical concurrent calls share one upstream
+	// request, across replicas too when Redis is configured; empty disables coalescing
+	CoalesceMethods []string
+	// CoalesceLockTTL bounds how long replicas wait for the replica making a coalesced call
+	CoalesceLockTTL time.Duration
+	// CoalesceResultTTL is how long a coalesced call's result is served to other replicas
+	CoalesceResultTTL time.Duration
+	// LegacyRootRPC proxies requests to paths no route serves, such as /, to ethereum like /rpc,
+	// in place of the service index
+	LegacyRootRPC bool
+	// GenerateTraceparent starts a W3C trace context for requests that arrive without a
+	// traceparent header, so upstreams can correlate them with the request ID
+	GenerateTraceparent bool
+}
+
+type DNSConfig struct {
+	// CacheEnabled resolves upstream hostnames out-of-band and round-robins dials across resolved IPs
+	CacheEnabled bool
+	CacheTTL     time.Duration
+	// CacheMaxStale bounds how long addresses are served after refreshes start failing; 0 never
+	// serves stale addresses
+	CacheMaxStale time.Duration
+}
+
+type AdminConfig struct {
+	// APIKey is required on every /admin request; without one the admin API is not served
+	APIKey string
+	// Insecure serves the admin API without an APIKey, for local development
+	Insecure bool
+	// Port serves the admin API on its own listener instead of the proxy's, when set
+	Port int
+	// ChainlistURL is the chainid.network-format dataset used by POST /admin/chains/import/{chainId}
+	ChainlistURL string
+}
+
+type ReloadConfig struct {
+	// Interval between database config reloads; 0 reloads only on POST /admin/reload
+	Interval time.Duration
+}
+
+type SettingsConfig struct {
+	// PollInterval between checks of the settings table for runtime changes; 0 disables polling
+	PollInterval time.Duration
+	// FlagCacheTTL is how long feature flags are served from memory before the settings table is read again
+	FlagCacheTTL time.Duration
+}
+
+type AnalyticsConfig struct {
+	// RollupInterval between writes of per-method request counts to the database; 0 keeps them in memory only
+	RollupInterval time.Duration
+	// ClientWindow is the rolling window covered by GET /admin/analytics/clients
+	ClientWindow time.Duration
+	// HealthRollupInterval between summaries of raw health checks into hourly and daily rollups; 0 disables them
+	HealthRollupInterval time.Duration
+	// UsageFlushInterval between writes of per-API-key daily usage to the database
+	UsageFlushInterval time.Duration
+}
+
+type TenantsConfig struct {
+	// KeyRotationGrace is the longest a rotated API key keeps working next to its replacement
+	KeyRotationGrace time.Duration
+}
+
+type RequestLogConfig struct {
+	// SampleRate is the fraction of proxied requests logged, from 0 to 1
+	SampleRate float64
+	// Errors logs every failed request regardless of SampleRate
+	Errors        bool
+	FlushInterval time.Duration
+	// Retention is how long logs are kept; 0 keeps them forever
+	Retention time.Duration
+}
+
+// Enabled reports whether any requests are logged
+func (c RequestLogConfig) Enabled() bool {
+	return c.SampleRate > 0 || c.Errors
+}
+
+type MetricsConfig struct {
+	// Backend is clickhouse or timescaledb; empty disables the long-term export
+	Backend string
+	URL     string
+	Table   string
+	// Interval between batches written to the backend
+	Interval time.Duration
+}
+
+// Sink returns the settings needed to connect the export backend
+func (c MetricsConfig) Sink() metrics.Config {
+	return metrics.Config{
+		Backend: c.Backend,
+		URL:     c.URL,
+		Table:   c.Table,
+	}
+}
+
+type RedisConfig struct {
+	// URL, when set, shares endpoint health between replicas through Redis, e.g.
+	// redis://:password@redis:6379/0 or rediss:// for TLS
+	URL string
+	// Prefix starts every key the proxy writes, so replicas of one deployment share state and
+	// other deployments on the same server don't
+	Prefix string
+	// SharedLimits keeps client and tenant rate limit buckets in Redis, so replicas enforce
+	// one limit between them and restarts don't refill them; it costs a Redis call per request
+	SharedLimits bool
+}
+
+type DiscoveryConfig struct {
+	// Interval is how often chains with a discovery source are re-resolved
+	Interval time.Duration
+	// KubernetesAPIURL overrides the in-cluster API server, e.g. http://127.0.0.1:8001 through
+	// kubectl proxy; without it Kubernetes discovery only works inside a cluster
+	KubernetesAPIURL string
 }
 
 type AppConfig struct {
 	Environment          string
 	LogLevel             string
 	FallbackRPCEndpoints []string
+	// FallbackChainsFile, when set, replaces the built-in fallback chains used while the
+	// database is unavailable
+	FallbackChainsFile string
+	// ChainsFile, when set, is a YAML or TOML file that replaces the database as the source of
+	// chains, endpoints and chain configs
+	ChainsFile string
+	// WatchChainsFile reloads the chains file when it changes on disk (SIGHUP always reloads)
+	WatchChainsFile bool
 }
 
-func Load() (*Config, error) {
+// Read parses the configuration from the environment and .env without loading chains or
+// database settings; Load completes it
+func Read() *Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found, using environment variables: %v", err)
@@ -71,79 +252,334 @@ func Load() (*Config, error) {
 	config := &Config{
 		Server: ServerConfig{
 			Port: viper.GetInt("server.port"),
+			Mode: viper.GetString("server.mode"),
 		},
 		Database: DatabaseConfig{
+			Driver:   viper.GetString("db.driver"),
 			Host:     viper.GetString("db.host"),
 			Port:     viper.GetInt("db.port"),
 			User:     viper.GetString("db.user"),
 			Password: viper.GetString("db.password"),
 			DBName:   viper.GetString("db.name"),
 			SSLMode:  viper.GetString("db.sslmode"),
+
+			AutoMigrate: viper.GetBool("db.auto_migrate"),
 		},
 		HealthCheck: health.HealthCheckConfig{
 			Interval: viper.GetDuration("health_check.interval"),
 			Timeout:  viper.GetDuration("health_check.timeout"),
 			Retries:  viper.GetInt("health_check.retries"),
+
+			CheckSync:    viper.GetBool("health_check.check_sync"),
+			MinPeerCount: viper.GetInt("health_check.min_peer_count"),
+
+			ArchiveProbeDepth:    viper.GetInt64("health_check.archive_probe_depth"),
+			ArchiveProbeInterval: viper.GetDuration("health_check.archive_probe_interval"),
+
+			Jitter: viper.GetDuration("health_check.jitter"),
+			Spread: viper.GetBool("health_check.spread"),
+
+			MaxConcurrency:   viper.GetInt("health_check.max_concurrency"),
+			MaxProbesPerHost: viper.GetInt("health_check.max_probes_per_host"),
+
+			CheckGasPrice: viper.GetBool("health_check.check_gas_price"),
+
+			CertExpiryWarningDays: viper.GetInt("health_check.cert_expiry_warning_days"),
 		},
 		Proxy: ProxyConfig{
 			Timeout:        viper.GetDuration("proxy.timeout"),
 			MaxConnections: viper.GetInt("proxy.max_connections"),
+
+			RateLimitCooldown: viper.GetDuration("proxy.rate_limit_cooldown"),
+			TrustForwardedFor: viper.GetBool("proxy.trust_forwarded_for"),
+
+			ClientRateLimit:      viper.GetFloat64("proxy.client_rate_limit"),
+			ClientBurst:          viper.GetInt("proxy.client_burst"),
+			ClientMaxConcurrency: viper.GetInt("proxy.client_max_concurrency"),
+			PeerURLs:             splitList(viper.GetString("proxy.peer_urls")),
+			PeerAPIKey:           viper.GetString("proxy.peer_api_key"),
+
+			CoalesceMethods:   splitList(viper.GetString("proxy.coalesce_methods")),
+			CoalesceLockTTL:   viper.GetDuration("proxy.coalesce_lock_ttl"),
+			CoalesceResultTTL: viper.GetDuration("proxy.coalesce_result_ttl"),
+
+			LegacyRootRPC:       viper.GetBool("proxy.legacy_root_rpc"),
+			GenerateTraceparent: viper.GetBool("proxy.generate_traceparent"),
+		},
+		DNS: DNSConfig{
+			CacheEnabled:  viper.GetBool("dns.cache_enabled"),
+			CacheTTL:      viper.GetDuration("dns.cache_ttl"),
+			CacheMaxStale: viper.GetDuration("dns.cache_max_stale"),
+		},
+		Upstream: transport.Config{
+			MaxIdleConns:        viper.GetInt("upstream.max_idle_conns"),
+			MaxIdleConnsPerHost: viper.GetInt("upstream.max_idle_conns_per_host"),
+			MaxConnsPerHost:     viper.GetInt("upstream.max_conns_per_host"),
+			IdleConnTimeout:     viper.GetDuration("upstream.idle_conn_timeout"),
+			DialTimeout:         viper.GetDuration("upstream.dial_timeout"),
+			KeepAlive:           viper.GetDuration("upstream.keep_alive"),
+			TLSHandshakeTimeout: viper.GetDuration("upstream.tls_handshake_timeout"),
+			DisableKeepAlives:   viper.GetBool("upstream.disable_keep_alives"),
+			PerEndpoint:         viper.GetBool("upstream.per_endpoint"),
+			HTTP1Hosts:          splitList(viper.GetString("upstream.http1_hosts")),
+			PrewarmInterval:     viper.GetDuration("upstream.prewarm_interval"),
+			PrewarmConnections:  viper.GetInt("upstream.prewarm_connections"),
+			FixtureMode:         viper.GetString("upstream.fixture_mode"),
+			FixtureDir:          viper.GetString("upstream.fixture_dir"),
+		},
+		Admin: AdminConfig{
+			APIKey:       viper.GetString("admin.api_key"),
+			Insecure:     viper.GetBool("admin.insecure"),
+			Port:         viper.GetInt("admin.port"),
+			ChainlistURL: viper.GetString("admin.chainlist_url"),
+		},
+		Reload: ReloadConfig{
+			Interval: viper.GetDuration("reload.interval"),
+		},
+		Settings: SettingsConfig{
+			PollInterval: viper.GetDuration("settings.poll_interval"),
+			FlagCacheTTL: viper.GetDuration("settings.flag_cache_ttl"),
+		},
+		Analytics: AnalyticsConfig{
+			RollupInterval:       viper.GetDuration("analytics.rollup_interval"),
+			ClientWindow:         viper.GetDuration("analytics.client_window"),
+			HealthRollupInterval: viper.GetDuration("analytics.health_rollup_interval"),
+			UsageFlushInterval:   viper.GetDuration("analytics.usage_flush_interval"),
+		},
+		Tenants: TenantsConfig{
+			KeyRotationGrace: viper.GetDuration("tenants.key_rotation_grace"),
+		},
+		RequestLog: RequestLogConfig{
+			SampleRate:    viper.GetFloat64("request_log.sample_rate"),
+			Errors:        viper.GetBool("request_log.errors"),
+			FlushInterval: viper.GetDuration("request_log.flush_interval"),
+			Retention:     viper.GetDuration("request_log.retention"),
+		},
+		Metrics: MetricsConfig{
+			Backend:  viper.GetString("metrics.backend"),
+			URL:      viper.GetString("metrics.url"),
+			Table:    viper.GetString("metrics.table"),
+			Interval: viper.GetDuration("metrics.interval"),
+		},
+		Remote: RemoteConfig{
+			Backend: viper.GetString("remote.backend"),
+			Address: viper.GetString("remote.address"),
+			Prefix:  viper.GetString("remote.prefix"),
+			Token:   viper.GetString("remote.token"),
+		},
+		Redis: RedisConfig{
+			URL:    viper.GetString("redis.url"),
+			Prefix: viper.GetString("redis.prefix"),
+
+			SharedLimits: viper.GetBool("redis.shared_limits"),
+		},
+		Discovery: DiscoveryConfig{
+			Interval:         viper.GetDuration("discovery.interval"),
+			KubernetesAPIURL: viper.GetString("discovery.kubernetes_api_url"),
 		},
 		App: AppConfig{
 			Environment:          viper.GetString("app.env"),
 			LogLevel:             viper.GetString("log.level"),
 			FallbackRPCEndpoints: viper.GetStringSlice("fallback.rpc_endpoints"),
+			FallbackChainsFile:   viper.GetString("fallback.chains_file"),
+			ChainsFile:           viper.GetString("chains.file"),
+			WatchChainsFile:      viper.GetBool("chains.watch"),
 		},
 	}
 
-	// Load multi-chain configuration from database if available
-	if config.Database.Host != "" {
-		if err := loadMultiChainConfigFromDB(config); err != nil {
+	return config
+}
+
+// Load loads chains, endpoints, chain configs and settings from the configured source and
+// validates the result. db is the application's shared connection, or nil when no database is
+// configured or it could not be reached; Load never closes it.
+func (config *Config) Load(db *database.GormDB) error {
+	// The chains to fall back on are read up front, so a malformed CHAINS variable or fallback
+	// chains file fails startup even while the database is reachable
+	fallback, fallbackSource, err := loadFallbackChains(config.App.FallbackChainsFile)
+	if err != nil {
+		return err
+	}
+
+	if config.App.ChainsFile != "" {
+		// A chains file is explicit configuration, so failing to load it is fatal rather than a fallback
+		if err := loadMultiChainConfigFromFile(config, config.App.ChainsFile); err != nil {
+			return fmt.Errorf("failed to load chains file: %w", err)
+		}
+		config.ChainsSource = ChainsFromFile
+
+		if db != nil {
+			if err := loadSettingsFromDB(config, db); err != nil {
+				log.Printf("Warning: Failed to load settings from database: %v", err)
+			}
+		}
+	} else if config.Remote.Backend != "" {
+		// Like a chains file, a remote store is explicit configuration and must load
+		if err := loadMultiChainConfigFromRemote(config); err != nil {
+			return fmt.Errorf("failed to load remote configuration: %w", err)
+		}
+		config.ChainsSource = ChainsFromRemote
+	} else if db != nil {
+		// Load multi-chain configuration from database if available
+		if err := loadMultiChainConfigFromDB(config, db); err != nil {
 			log.Printf("Warning: Failed to load multi-chain config from database: %v", err)
 			// Use fallback configuration
-			config = createFallbackMultiChainConfig(config)
+			createFallbackMultiChainConfig(config, fallback, fallbackSource)
+		} else {
+			config.ChainsSource = ChainsFromDatabase
 		}
 
 		// Load and override settings from database
-		if err := loadSettingsFromDB(config); err != nil {
+		if err := loadSettingsFromDB(config, db); err != nil {
 			log.Printf("Warning: Failed to load settings from database: %v", err)
 		}
 	} else {
-		// Use fallback configuration if no database configured
-		config = createFallbackMultiChainConfig(config)
+		// Use fallback configuration if no database is configured or reachable
+		createFallbackMultiChainConfig(config, fallback, fallbackSource)
 	}
 
 	if err := validateConfig(config); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	return config, nil
+	return nil
+}
+
+// DatabaseConfig returns the connection settings for database.NewGormConnection
+func (c *Config) DatabaseConfig() database.Config {
+	return database.Config{
+		Driver:   c.Database.Driver,
+		Host:     c.Database.Host,
+		Port:     c.Database.Port,
+		User:     c.Database.User,
+		Password: c.Database.Password,
+		DBName:   c.Database.DBName,
+		SSLMode:  c.Database.SSLMode,
+	}
 }
 
 func setDefaults() {
 	// Server defaults
 	viper.SetDefault("server.port", 8888)
+	viper.SetDefault("server.mode", ServerModeStandard)
 
 	// Database defaults - set empty to disable DB by default
+	viper.SetDefault("db.driver", database.DriverPostgres)
 	viper.SetDefault("db.host", "")
 	viper.SetDefault("db.port", 5432)
 	viper.SetDefault("db.user", "postgres")
 	viper.SetDefault("db.password", "")
 	viper.SetDefault("db.name", "rpc_proxy")
 	viper.SetDefault("db.sslmode", "disable")
+	viper.SetDefault("db.auto_migrate", true)
 
 	// Health check defaults
 	viper.SetDefault("health_check.interval", "30s")
 	viper.SetDefault("health_check.timeout", "5s")
 	viper.SetDefault("health_check.retries", 3)
+	viper.SetDefault("health_check.check_sync", false)
+	viper.SetDefault("health_check.min_peer_count", 0)
+	viper.SetDefault("health_check.archive_probe_depth", 0)
+	viper.SetDefault("health_check.archive_probe_interval", "10m")
+	viper.SetDefault("health_check.jitter", "0s")
+	viper.SetDefault("health_check.spread", false)
+	viper.SetDefault("health_check.max_concurrency", 64)
+	viper.SetDefault("health_check.max_probes_per_host", 8)
+	viper.SetDefault("health_check.check_gas_price", false)
+	viper.SetDefault("health_check.cert_expiry_warning_days", 14)
 
 	// Proxy defaults
 	viper.SetDefault("proxy.timeout", "10s")
 	viper.SetDefault("proxy.max_connections", 1000)
+	viper.SetDefault("proxy.rate_limit_cooldown", "60s")
+	viper.SetDefault("proxy.trust_forwarded_for", false)
+	viper.SetDefault("proxy.client_rate_limit", 0)
+	viper.SetDefault("proxy.client_burst", 0)
+	viper.SetDefault("proxy.client_max_concurrency", 0)
+	viper.SetDefault("proxy.peer_urls", "")
+	viper.SetDefault("proxy.peer_api_key", "")
+	viper.SetDefault("proxy.coalesce_methods", "eth_blockNumber,eth_gasPrice,eth_chainId,net_version")
+	viper.SetDefault("proxy.coalesce_lock_ttl", "2s")
+	viper.SetDefault("proxy.coalesce_result_ttl", "500ms")
+	viper.SetDefault("proxy.legacy_root_rpc", false)
+	viper.SetDefault("proxy.generate_traceparent", false)
+
+	// DNS defaults
+	viper.SetDefault("dns.cache_enabled", false)
+	viper.SetDefault("dns.cache_ttl", "60s")
+	viper.SetDefault("dns.cache_max_stale", "10m")
+
+	// Upstream connection defaults
+	viper.SetDefault("upstream.max_idle_conns", 1000)
+	viper.SetDefault("upstream.max_idle_conns_per_host", 100)
+	viper.SetDefault("upstream.max_conns_per_host", 0)
+	viper.SetDefault("upstream.idle_conn_timeout", "90s")
+	viper.SetDefault("upstream.dial_timeout", "5s")
+	viper.SetDefault("upstream.keep_alive", "30s")
+	viper.SetDefault("upstream.tls_handshake_timeout", "5s")
+	viper.SetDefault("upstream.disable_keep_alives", false)
+	viper.SetDefault("upstream.per_endpoint", false)
+	viper.SetDefault("upstream.http1_hosts", "")
+	viper.SetDefault("upstream.prewarm_interval", "0s")
+	viper.SetDefault("upstream.prewarm_connections", 2)
+	viper.SetDefault("upstream.fixture_mode", "")
+	viper.SetDefault("upstream.fixture_dir", "fixtures")
+
+	// Admin defaults
+	viper.SetDefault("admin.api_key", "")
+	viper.SetDefault("admin.insecure", false)
+	viper.SetDefault("admin.port", 0)
+	viper.SetDefault("admin.chainlist_url", "https://chainid.network/chains.json")
+
+	// Reload defaults
+	viper.SetDefault("reload.interval", "0s")
+
+	// Settings defaults
+	viper.SetDefault("settings.poll_interval", "30s")
+	viper.SetDefault("settings.flag_cache_ttl", "30s")
+
+	// Remote config defaults
+	viper.SetDefault("remote.backend", "")
+	viper.SetDefault("remote.address", "")
+	viper.SetDefault("remote.prefix", "rpc-proxy")
+	viper.SetDefault("remote.token", "")
+
+	// Shared state defaults
+	viper.SetDefault("redis.url", "")
+	viper.SetDefault("redis.prefix", "rpc-proxy")
+	viper.SetDefault("redis.shared_limits", false)
+
+	// Endpoint discovery defaults
+	viper.SetDefault("discovery.interval", "30s")
+	viper.SetDefault("discovery.kubernetes_api_url", "")
+
+	// Analytics defaults
+	viper.SetDefault("analytics.rollup_interval", "0s")
+	viper.SetDefault("analytics.client_window", "1h")
+	viper.SetDefault("analytics.health_rollup_interval", "10m")
+	viper.SetDefault("analytics.usage_flush_interval", "1m")
+
+	// Tenant defaults
+	viper.SetDefault("tenants.key_rotation_grace", "24h")
+
+	// Request log defaults; nothing is logged unless a sample rate or errors is set
+	viper.SetDefault("request_log.sample_rate", 0)
+	viper.SetDefault("request_log.errors", false)
+	viper.SetDefault("request_log.flush_interval", "5s")
+	viper.SetDefault("request_log.retention", "168h")
+
+	// Long-term metrics export defaults; disabled unless a backend is set
+	viper.SetDefault("metrics.backend", "")
+	viper.SetDefault("metrics.url", "")
+	viper.SetDefault("metrics.table", "rpc_proxy_measurements")
+	viper.SetDefault("metrics.interval", "1m")
 
 	// App defaults
 	viper.SetDefault("app.env", "development")
 	viper.SetDefault("log.level", "info")
+	viper.SetDefault("chains.file", "")
+	viper.SetDefault("chains.watch", true)
+	viper.SetDefault("fallback.chains_file", "")
 	viper.SetDefault("fallback.rpc_endpoints", []string{
 		"https://eth.llamarpc.com",
 		"https://ethereum.publicnode.com",
@@ -151,58 +587,7 @@ func setDefaults() {
 	})
 }
 
-func loadRPCEndpointsFromDB(config *Config) error {
-	dbConfig := database.Config{
-		Host:     config.Database.Host,
-		Port:     config.Database.Port,
-		User:     config.Database.User,
-		Password: config.Database.Password,
-		DBName:   config.Database.DBName,
-		SSLMode:  config.Database.SSLMode,
-	}
-
-	db, err := database.NewGormConnection(dbConfig)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer db.Close()
-
-	// Run auto-migrations
-	if err := db.AutoMigrate(); err != nil {
-		return fmt.Errorf("failed to run auto-migrations: %w", err)
-	}
-
-	// Seed default data
-	if err := db.SeedData(); err != nil {
-		return fmt.Errorf("failed to seed default data: %w", err)
-	}
-
-	repo := gorm.NewRPCEndpointRepository(db)
-	endpoints, err := repo.GetEnabled()
-	if err != nil {
-		return fmt.Errorf("failed to get enabled endpoints: %w", err)
-	}
-
-	config.RPCEndpoints = endpoints
-	return nil
-}
-
-func loadSettingsFromDB(config *Config) error {
-	dbConfig := database.Config{
-		Host:     config.Database.Host,
-		Port:     config.Database.Port,
-		User:     config.Database.User,
-		Password: config.Database.Password,
-		DBName:   config.Database.DBName,
-		SSLMode:  config.Database.SSLMode,
-	}
-
-	db, err := database.NewGormConnection(dbConfig)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer db.Close()
-
+func loadSettingsFromDB(config *Config, db *database.GormDB) error {
 	settingsRepo := gorm.NewSettingsRepository(db)
 	settings, err := settingsRepo.GetAll()
 	if err != nil {
@@ -210,73 +595,30 @@ func loadSettingsFromDB(config *Config) error {
 	}
 
 	// Override config with database settings
-	if val, exists := settings["health_check_interval"]; exists {
-		if duration, err := time.ParseDuration(val); err == nil {
-			config.HealthCheck.Interval = duration
-		}
-	}
-	if val, exists := settings["health_check_timeout"]; exists {
-		if duration, err := time.ParseDuration(val); err == nil {
-			config.HealthCheck.Timeout = duration
-		}
-	}
-	if val, exists := settings["health_check_retries"]; exists {
-		if retries := viper.Get(val); retries != nil {
-			if r, ok := retries.(int); ok {
-				config.HealthCheck.Retries = r
-			}
-		}
-	}
-	if val, exists := settings["proxy_timeout"]; exists {
-		if duration, err := time.ParseDuration(val); err == nil {
-			config.Proxy.Timeout = duration
-		}
-	}
-	if val, exists := settings["max_connections"]; exists {
-		if maxConn := viper.Get(val); maxConn != nil {
-			if mc, ok := maxConn.(int); ok {
-				config.Proxy.MaxConnections = mc
-			}
-		}
-	}
-	if val, exists := settings["server_port"]; exists {
-		if port := viper.Get(val); port != nil {
-			if p, ok := port.(int); ok {
-				config.Server.Port = p
-			}
-		}
-	}
+	applyRuntimeSettings(config, settings)
 
 	return nil
 }
 
-// loadMultiChainConfigFromDB loads chains, endpoints, and chain-specific configs from database
-func loadMultiChainConfigFromDB(config *Config) error {
-	dbConfig := database.Config{
-		Host:     config.Database.Host,
-		Port:     config.Database.Port,
-		User:     config.Database.User,
-		Password: config.Database.Password,
-		DBName:   config.Database.DBName,
-		SSLMode:  config.Database.SSLMode,
-	}
-
-	db, err := database.NewGormConnection(dbConfig)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-	defer db.Close()
-
-	// Run auto-migrations
-	if err := db.AutoMigrate(); err != nil {
-		return fmt.Errorf("failed to run auto-migrations: %w", err)
-	}
-
-	// Seed default data
-	if err := db.SeedData(); err != nil {
-		return fmt.Errorf("failed to seed default data: %w", err)
+// applyRuntimeSettings overrides config with settings, skipping invalid values
+func applyRuntimeSettings(config *Config, settings map[string]string) {
+	runtime, invalid := ParseRuntimeSettings(config.RuntimeSettings(), settings)
+	for key, err := range invalid {
+		log.Printf("Warning: Ignoring setting %s: %v", key, err)
 	}
+	config.HealthCheck.Interval = runtime.HealthCheckInterval
+	config.HealthCheck.Timeout = runtime.HealthCheckTimeout
+	config.HealthCheck.Retries = runtime.HealthCheckRetries
+	config.Proxy.Timeout = runtime.ProxyTimeout
+	config.Proxy.MaxConnections = runtime.MaxConnections
+	config.Proxy.ClientRateLimit = runtime.ClientLimits.Rate
+	config.Proxy.ClientBurst = runtime.ClientLimits.Burst
+	config.Proxy.ClientMaxConcurrency = runtime.ClientLimits.MaxConcurrency
+	config.Server.Port = runtime.ServerPort
+}
 
+// loadMultiChainConfigFromDB loads chains, endpoints, and chain-specific configs from database
+func loadMultiChainConfigFromDB(config *Config, db *database.GormDB) error {
 	// Initialize maps
 	config.ChainEndpoints = make(map[string][]*types.RPCEndpoint)
 	config.ChainConfigs = make(map[string]map[string]string)
@@ -328,106 +670,17 @@ func loadMultiChainConfigFromDB(config *Config) error {
 	return nil
 }
 
-// createFallbackMultiChainConfig creates fallback configuration when database is unavailable
-func createFallbackMultiChainConfig(config *Config) *Config {
-	// Create fallback chains
-	config.Chains = []*types.Chain{
-		{
-			ID:                     1,
-			ChainID:                1,
-			Name:                   "ethereum",
-			DisplayName:            "Ethereum Mainnet",
-			RPCPath:                "ethereum",
-			IsTestnet:              false,
-			IsEnabled:              true,
-			NativeCurrencySymbol:   "ETH",
-			NativeCurrencyDecimals: 18,
-			BlockExplorerURL:       "https://etherscan.io",
-		},
-		{
-			ID:                     2,
-			ChainID:                11155111,
-			Name:                   "sepolia",
-			DisplayName:            "Sepolia Testnet",
-			RPCPath:                "sepolia",
-			IsTestnet:              true,
-			IsEnabled:              true,
-			NativeCurrencySymbol:   "ETH",
-			NativeCurrencyDecimals: 18,
-			BlockExplorerURL:       "https://sepolia.etherscan.io",
-		},
-		{
-			ID:                     3,
-			ChainID:                1868,
-			Name:                   "soneium",
-			DisplayName:            "Soneium Mainnet",
-			RPCPath:                "soneium",
-			IsTestnet:              false,
-			IsEnabled:              true,
-			NativeCurrencySymbol:   "ETH",
-			NativeCurrencyDecimals: 18,
-			BlockExplorerURL:       "https://explorer.soneium.org",
-		},
-		{
-			ID:                     4,
-			ChainID:                1946,
-			Name:                   "soneium-testnet",
-			DisplayName:            "Soneium Testnet",
-			RPCPath:                "soneium-testnet",
-			IsTestnet:              true,
-			IsEnabled:              true,
-			NativeCurrencySymbol:   "ETH",
-			NativeCurrencyDecimals: 18,
-			BlockExplorerURL:       "https://explorer-testnet.soneium.org",
-		},
-	}
-
-	// Create fallback endpoints
-	config.ChainEndpoints = map[string][]*types.RPCEndpoint{
-		"ethereum": {
-			{ID: 1, Name: "Ethereum-LlamaRPC", URL: "https://eth.llamarpc.com", Weight: 3, Enabled: true, ChainID: 1},
-			{ID: 2, Name: "Ethereum-PublicNode", URL: "https://ethereum.publicnode.com", Weight: 2, Enabled: true, ChainID: 1},
-			{ID: 3, Name: "Ethereum-Cloudflare", URL: "https://cloudflare-eth.com", Weight: 2, Enabled: true, ChainID: 1},
-		},
-		"sepolia": {
-			{ID: 4, Name: "Sepolia-1RPC", URL: "https://1rpc.io/sepolia", Weight: 3, Enabled: true, ChainID: 2},
-			{ID: 5, Name: "Sepolia-PublicNode", URL: "https://ethereum-sepolia-rpc.publicnode.com", Weight: 2, Enabled: true, ChainID: 2},
-			{ID: 6, Name: "Sepolia-DRPC", URL: "https://sepolia.drpc.org", Weight: 2, Enabled: true, ChainID: 2},
-		},
-		"soneium": {
-			{ID: 7, Name: "Soneium-DRPC", URL: "https://soneium.drpc.org", Weight: 3, Enabled: true, ChainID: 3},
-			{ID: 8, Name: "Soneium-Official", URL: "https://rpc.soneium.org", Weight: 2, Enabled: true, ChainID: 3},
-		},
-		"soneium-testnet": {
-			{ID: 9, Name: "Soneium-Testnet-Official", URL: "https://rpc.minato.soneium.org", Weight: 3, Enabled: true, ChainID: 4},
-			{ID: 10, Name: "Soneium-Testnet-DRPC", URL: "https://soneium-minato.drpc.org", Weight: 2, Enabled: true, ChainID: 4},
-		},
-	}
+// createFallbackMultiChainConfig applies the fallback chains when the database is unavailable
+func createFallbackMultiChainConfig(config *Config, fallback *ChainSet, source string) {
+	applyChainSet(config, fallback)
+	config.ChainsSource = source
 
-	// Create fallback chain configs
-	config.ChainConfigs = map[string]map[string]string{
-		"ethereum": {
-			"max_block_lag":            "5",
-			"gas_price_gwei_threshold": "100",
-		},
-		"sepolia": {
-			"max_block_lag":            "10",
-			"gas_price_gwei_threshold": "20",
-		},
-		"soneium": {
-			"max_block_lag":            "5",
-			"gas_price_gwei_threshold": "50",
-		},
-		"soneium-testnet": {
-			"max_block_lag":            "10",
-			"gas_price_gwei_threshold": "20",
-		},
+	if source == ChainsFromFallback {
+		// Legacy fallback endpoints
+		config.RPCEndpoints = createFallbackEndpoints(config.App.FallbackRPCEndpoints)
 	}
 
-	// Legacy fallback endpoints
-	config.RPCEndpoints = createFallbackEndpoints(config.App.FallbackRPCEndpoints)
-
-	return config
+	log.Printf("Using %s chains: %d chains, %d total endpoints", source, len(config.Chains), len(config.RPCEndpoints))
 }
 
 func createFallbackEndpoints(urls []string) []*types.RPCEndpoint {
@@ -445,6 +698,17 @@ func createFallbackEndpoints(urls []string) []*types.RPCEndpoint {
 	return endpoints
 }
 
+// splitList splits a comma-separated list, dropping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // CreateMultiChainHealthChecker creates a multi-chain health checker from config
 func (c *Config) CreateMultiChainHealthChecker() *health.MultiChainChecker {
 	chainsConfig := make(map[string]*health.ChainConfig)
@@ -463,6 +727,7 @@ func (c *Config) CreateMultiChainHealthChecker() *health.MultiChainChecker {
 		chainsConfig[chain.Name] = &health.ChainConfig{
 			Chain:     chain,
 			Endpoints: endpoints,
+			Configs:   c.ChainConfigs[chain.Name],
 		}
 	}
 
@@ -471,6 +736,9 @@ func (c *Config) CreateMultiChainHealthChecker() *health.MultiChainChecker {
 
 // GetChainByName returns chain configuration by name
 func (c *Config) GetChainByName(chainName string) *types.Chain {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	for _, chain := range c.Chains {
 		if chain.Name == chainName {
 			return chain
@@ -479,6 +747,113 @@ func (c *Config) GetChainByName(chainName string) *types.Chain {
 	return nil
 }
 
+// GetChainByID returns the chain with a chain ID, or nil
+func (c *Config) GetChainByID(chainID int) *types.Chain {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, chain := range c.Chains {
+		if chain.ChainID == chainID {
+			return chain
+		}
+	}
+	return nil
+}
+
+// GetChains returns a snapshot of the configured chains
+func (c *Config) GetChains() []*types.Chain {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	chains := make([]*types.Chain, len(c.Chains))
+	copy(chains, c.Chains)
+	return chains
+}
+
+// AddChain registers a newly created chain with no endpoints or configs yet
+func (c *Config) AddChain(chain *types.Chain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Chains = append(c.Chains, chain)
+	if c.ChainEndpoints == nil {
+		c.ChainEndpoints = make(map[string][]*types.RPCEndpoint)
+	}
+	if c.ChainConfigs == nil {
+		c.ChainConfigs = make(map[string]map[string]string)
+	}
+	c.ChainEndpoints[chain.Name] = []*types.RPCEndpoint{}
+	c.ChainConfigs[chain.Name] = make(map[string]string)
+}
+
+// UpdateChain replaces the chain registered under chainName, moving its endpoints and
+// configs along if the chain was renamed
+func (c *Config) UpdateChain(chainName string, chain *types.Chain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, existing := range c.Chains {
+		if existing.Name == chainName {
+			c.Chains[i] = chain
+			break
+		}
+	}
+
+	if chain.Name != chainName {
+		c.ChainEndpoints[chain.Name] = c.ChainEndpoints[chainName]
+		c.ChainConfigs[chain.Name] = c.ChainConfigs[chainName]
+		delete(c.ChainEndpoints, chainName)
+		delete(c.ChainConfigs, chainName)
+	}
+}
+
+// GetChainEndpoints returns the endpoints configured for a chain
+func (c *Config) GetChainEndpoints(chainName string) []*types.RPCEndpoint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ChainEndpoints[chainName]
+}
+
+// SetChainEndpoints replaces the endpoints configured for a chain
+func (c *Config) SetChainEndpoints(chainName string, endpoints []*types.RPCEndpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ChainEndpoints[chainName] = endpoints
+}
+
+// GetChainConfigs returns the config keys set for a chain
+func (c *Config) GetChainConfigs(chainName string) map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ChainConfigs[chainName]
+}
+
+// SetChainConfigs replaces the config keys set for a chain
+func (c *Config) SetChainConfigs(chainName string, configs map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ChainConfigs[chainName] = configs
+}
+
+// RemoveChain drops a chain together with its endpoints and configs
+func (c *Config) RemoveChain(chainName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, chain := range c.Chains {
+		if chain.Name == chainName {
+			c.Chains = append(c.Chains[:i], c.Chains[i+1:]...)
+			break
+		}
+	}
+	delete(c.ChainEndpoints, chainName)
+	delete(c.ChainConfigs, chainName)
+}
+
 func validateConfig(config *Config) error {
 	// Validate multi-chain configuration
 	if len(config.Chains) == 0 && len(config.RPCEndpoints) == 0 {
@@ -498,6 +873,21 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("server port must be between 1 and 65535")
 	}
 
+	if config.Admin.Port < 0 || config.Admin.Port > 65535 {
+		return fmt.Errorf("admin port must be between 1 and 65535, or 0 to serve the admin API on the server port")
+	}
+	if config.Admin.Port != 0 && config.Admin.Port == config.Server.Port {
+		return fmt.Errorf("admin port must differ from the server port")
+	}
+
+	if config.Server.Mode != ServerModeStandard && config.Server.Mode != ServerModePerformance {
+		return fmt.Errorf("server mode must be %s or %s", ServerModeStandard, ServerModePerformance)
+	}
+
+	if config.Database.Driver != database.DriverPostgres && config.Database.Driver != database.DriverMySQL {
+		return fmt.Errorf("database driver must be %s or %s", database.DriverPostgres, database.DriverMySQL)
+	}
+
 	if config.HealthCheck.Interval <= 0 {
 		return fmt.Errorf("health check interval must be positive")
 	}
@@ -510,6 +900,30 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("health check retries must be positive")
 	}
 
+	if config.HealthCheck.MinPeerCount < 0 {
+		return fmt.Errorf("health check min peer count must not be negative")
+	}
+
+	if config.HealthCheck.ArchiveProbeDepth < 0 {
+		return fmt.Errorf("archive probe depth must not be negative")
+	}
+
+	if config.HealthCheck.Jitter < 0 || config.HealthCheck.Jitter >= config.HealthCheck.Interval {
+		return fmt.Errorf("health check jitter must be between 0 and the health check interval")
+	}
+
+	if config.HealthCheck.MaxConcurrency <= 0 {
+		return fmt.Errorf("health check max concurrency must be positive")
+	}
+
+	if config.HealthCheck.MaxProbesPerHost < 0 {
+		return fmt.Errorf("health check max probes per host must not be negative")
+	}
+
+	if config.HealthCheck.ArchiveProbeDepth > 0 && config.HealthCheck.ArchiveProbeInterval <= 0 {
+		return fmt.Errorf("archive probe interval must be positive when archive probing is enabled")
+	}
+
 	if config.Proxy.Timeout <= 0 {
 		return fmt.Errorf("proxy timeout must be positive")
 	}
@@ -518,5 +932,133 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("max connections must be positive")
 	}
 
+	if config.DNS.CacheEnabled && config.DNS.CacheTTL <= 0 {
+		return fmt.Errorf("dns cache ttl must be positive when the dns cache is enabled")
+	}
+	if config.DNS.CacheMaxStale < 0 {
+		return fmt.Errorf("dns cache max stale must not be negative")
+	}
+
+	if config.Upstream.MaxIdleConns < 0 || config.Upstream.MaxIdleConnsPerHost < 0 || config.Upstream.MaxConnsPerHost < 0 {
+		return fmt.Errorf("upstream connection limits must not be negative")
+	}
+
+	if config.Upstream.IdleConnTimeout < 0 || config.Upstream.KeepAlive < 0 {
+		return fmt.Errorf("upstream idle connection timeout and keep-alive must not be negative")
+	}
+
+	if config.Upstream.DialTimeout <= 0 || config.Upstream.TLSHandshakeTimeout <= 0 {
+		return fmt.Errorf("upstream dial and TLS handshake timeouts must be positive")
+	}
+
+	if config.Upstream.PrewarmInterval < 0 || config.Upstream.PrewarmConnections <= 0 {
+		return fmt.Errorf("upstream prewarm interval must not be negative and prewarm connections must be positive")
+	}
+
+	if config.Upstream.PrewarmInterval > 0 && config.Upstream.IdleConnTimeout > 0 && config.Upstream.PrewarmInterval >= config.Upstream.IdleConnTimeout {
+		return fmt.Errorf("upstream prewarm interval must be shorter than the idle connection timeout")
+	}
+
+	switch config.Upstream.FixtureMode {
+	case "", transport.FixtureRecord, transport.FixtureReplay:
+	default:
+		return fmt.Errorf("upstream fixture mode must be %s or %s, got %q", transport.FixtureRecord, transport.FixtureReplay, config.Upstream.FixtureMode)
+	}
+	if config.Upstream.FixtureMode != "" && config.Upstream.FixtureDir == "" {
+		return fmt.Errorf("upstream fixture dir is required in fixture %s mode", config.Upstream.FixtureMode)
+	}
+
+	if config.Reload.Interval < 0 {
+		return fmt.Errorf("reload interval must not be negative")
+	}
+
+	if config.Settings.PollInterval < 0 {
+		return fmt.Errorf("settings poll interval must not be negative")
+	}
+
+	if config.Analytics.RollupInterval < 0 {
+		return fmt.Errorf("analytics rollup interval must not be negative")
+	}
+
+	if config.Analytics.HealthRollupInterval < 0 {
+		return fmt.Errorf("analytics health rollup interval must not be negative")
+	}
+
+	if config.Analytics.ClientWindow <= 0 {
+		return fmt.Errorf("analytics client window must be positive")
+	}
+
+	if config.Analytics.UsageFlushInterval <= 0 {
+		return fmt.Errorf("analytics usage flush interval must be positive")
+	}
+
+	if config.Tenants.KeyRotationGrace <= 0 {
+		return fmt.Errorf("tenant key rotation grace must be positive")
+	}
+
+	if config.RequestLog.SampleRate < 0 || config.RequestLog.SampleRate > 1 {
+		return fmt.Errorf("request log sample rate must be between 0 and 1")
+	}
+
+	if config.RequestLog.Enabled() && config.RequestLog.FlushInterval <= 0 {
+		return fmt.Errorf("request log flush interval must be positive")
+	}
+
+	if config.RequestLog.Retention < 0 {
+		return fmt.Errorf("request log retention must not be negative")
+	}
+
+	switch config.Metrics.Backend {
+	case "":
+	case metrics.BackendClickHouse, metrics.BackendTimescale:
+		if config.Metrics.URL == "" {
+			return fmt.Errorf("metrics URL is required when the %s backend is set", config.Metrics.Backend)
+		}
+		if config.Metrics.Interval <= 0 {
+			return fmt.Errorf("metrics export interval must be positive")
+		}
+	default:
+		return fmt.Errorf("metrics backend must be %s, %s or empty", metrics.BackendClickHouse, metrics.BackendTimescale)
+	}
+
+	if config.Redis.URL != "" {
+		if _, err := redis.ParseURL(config.Redis.URL); err != nil {
+			return err
+		}
+		if config.Redis.Prefix == "" {
+			return fmt.Errorf("redis prefix is required when redis URL is set")
+		}
+	}
+
+	if config.Discovery.Interval <= 0 {
+		return fmt.Errorf("discovery interval must be positive")
+	}
+
+	if config.Discovery.KubernetesAPIURL != "" {
+		u, err := url.Parse(config.Discovery.KubernetesAPIURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("kubernetes API URL must be an http(s) URL")
+		}
+	}
+
+	if config.Proxy.RateLimitCooldown < 0 {
+		return fmt.Errorf("rate limit cooldown must not be negative")
+	}
+
+	if config.Proxy.ClientRateLimit < 0 || config.Proxy.ClientBurst < 0 || config.Proxy.ClientMaxConcurrency < 0 {
+		return fmt.Errorf("client rate limit, burst and max concurrency must not be negative")
+	}
+
+	for _, peerURL := range config.Proxy.PeerURLs {
+		u, err := url.Parse(peerURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("peer URL %s must be an http(s) URL", peerURL)
+		}
+	}
+
+	if len(config.Proxy.CoalesceMethods) > 0 && (config.Proxy.CoalesceLockTTL <= 0 || config.Proxy.CoalesceResultTTL <= 0) {
+		return fmt.Errorf("coalesce lock and result TTLs must be positive")
+	}
+
 	return nil
 }
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// chainsEnvVar lists the chains declared through the environment, for deployments that can
+// neither mount a chains file nor run a database. Each chain is described by variables named
+// CHAIN_<NAME>_<FIELD>, where <NAME> is the chain name upper-cased with every character other
+// than a letter or digit replaced by an underscore:
+//
+//	CHAINS=ethereum,base
+//	CHAIN_ETHEREUM_ENDPOINTS=https://eth.llamarpc.com|3,https://ethereum.publicnode.com|2
+//	CHAIN_BASE_CHAIN_ID=8453
+//	CHAIN_BASE_ENDPOINTS=https://mainnet.base.org
+//	CHAIN_BASE_CONFIGS=max_block_lag=5,gas_price_gwei_threshold=50
+//
+// CHAIN_<NAME>_TYPE is evm by default; CHAIN_SOLANA_TYPE=solana declares a Solana cluster.
+const chainsEnvVar = "CHAINS"
+
+// loadChainsFromEnv reads the chains declared in CHAINS, or returns nil if it is unset. Chains
+// that are part of the built-in fallback set default to its chain ID and metadata.
+func loadChainsFromEnv() (*ChainSet, error) {
+	list := os.Getenv(chainsEnvVar)
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	builtin, err := builtinChains()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]chainsFileChain)
+	for _, chain := range builtin.Chains {
+		known[chain.Name] = chainsFileChain{
+			ChainID:              chain.ChainID,
+			Type:                 chain.Type,
+			DisplayName:          chain.DisplayName,
+			RPCPath:              chain.RPCPath,
+			IsTestnet:            chain.IsTestnet,
+			NativeCurrencySymbol: chain.NativeCurrencySymbol,
+			BlockExplorerURL:     chain.BlockExplorerURL,
+		}
+	}
+
+	var file chainsFile
+	prefixes := make(map[string]string)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := envChainPrefix(name)
+		if other, exists := prefixes[prefix]; exists && other != name {
+			return nil, fmt.Errorf("chains %s and %s both use variables %s*", other, name, prefix)
+		}
+		prefixes[prefix] = name
+
+		chain, err := chainFromEnv(name, prefix, known[name])
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", name, err)
+		}
+		file.Chains = append(file.Chains, chain)
+	}
+
+	return file.chainSet()
+}
+
+// chainFromEnv reads the CHAIN_<NAME>_* variables of one chain on top of its defaults
+func chainFromEnv(name, prefix string, chain chainsFileChain) (chainsFileChain, error) {
+	chain.Name = name
+
+	if value := os.Getenv(prefix + "CHAIN_ID"); value != "" {
+		chainID, err := strconv.Atoi(value)
+		if err != nil {
+			return chain, fmt.Errorf("invalid %sCHAIN_ID %q", prefix, value)
+		}
+		chain.ChainID = chainID
+	}
+	if chain.ChainID == 0 {
+		return chain, fmt.Errorf("%sCHAIN_ID is required", prefix)
+	}
+
+	if value := os.Getenv(prefix + "TYPE"); value != "" {
+		chain.Type = value
+	}
+	if value := os.Getenv(prefix + "DISPLAY_NAME"); value != "" {
+		chain.DisplayName = value
+	}
+	if value := os.Getenv(prefix + "RPC_PATH"); value != "" {
+		chain.RPCPath = value
+	}
+	if value := os.Getenv(prefix + "CURRENCY_SYMBOL"); value != "" {
+		chain.NativeCurrencySymbol = value
+	}
+	if value := os.Getenv(prefix + "EXPLORER_URL"); value != "" {
+		chain.BlockExplorerURL = value
+	}
+	if value := os.Getenv(prefix + "TESTNET"); value != "" {
+		isTestnet, err := strconv.ParseBool(value)
+		if err != nil {
+			return chain, fmt.Errorf("invalid %sTESTNET %q", prefix, value)
+		}
+		chain.IsTestnet = isTestnet
+	}
+	if value := os.Getenv(prefix + "ENABLED"); value != "" {
+		isEnabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return chain, fmt.Errorf("invalid %sENABLED %q", prefix, value)
+		}
+		chain.IsEnabled = &isEnabled
+	}
+
+	// CONFIGS holds key=value pairs; the keys are checked by chainSet like a chains file's
+	if value := os.Getenv(prefix + "CONFIGS"); value != "" {
+		chain.Configs = make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			key, configValue, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || key == "" {
+				return chain, fmt.Errorf("invalid %sCONFIGS entry %q, must be key=value", prefix, pair)
+			}
+			chain.Configs[key] = configValue
+		}
+	}
+
+	// ENDPOINTS holds url or url|weight entries
+	value := os.Getenv(prefix + "ENDPOINTS")
+	if value == "" {
+		return chain, fmt.Errorf("%sENDPOINTS is required", prefix)
+	}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		endpoint := chainsFileEndpoint{URL: entry}
+		if i := strings.LastIndex(entry, "|"); i >= 0 {
+			weight, err := strconv.Atoi(entry[i+1:])
+			if err != nil {
+				return chain, fmt.Errorf("invalid weight in %sENDPOINTS entry %q", prefix, entry)
+			}
+			endpoint.URL = entry[:i]
+			endpoint.Weight = weight
+		}
+		chain.Endpoints = append(chain.Endpoints, endpoint)
+	}
+
+	return chain, nil
+}
+
+// envChainPrefix returns the variable prefix of a chain, e.g. CHAIN_SONEIUM_TESTNET_ for
+// soneium-testnet
+func envChainPrefix(name string) string {
+	var b strings.Builder
+	b.WriteString("CHAIN_")
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	b.WriteString("_")
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+)
+
+// builtinChainsFile is the default fallback configuration, kept as data so operators can
+// replace it with FALLBACK_CHAINS_FILE or CHAINS instead of recompiling
+//
+//go:embed fallback.yaml
+var builtinChainsFile []byte
+
+// builtinChains parses the embedded fallback chains
+func builtinChains() (*ChainSet, error) {
+	set, err := parseChainsFile(builtinChainsFile, ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("built-in fallback chains: %w", err)
+	}
+	return set, nil
+}
+
+// loadFallbackChains returns the chains to use when the database is unavailable and where they
+// came from: chains declared in the environment, else the fallback chains file when path is
+// set, else the built-in chains
+func loadFallbackChains(path string) (*ChainSet, string, error) {
+	envChains, err := loadChainsFromEnv()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load chains from environment: %w", err)
+	}
+	if envChains != nil {
+		return envChains, ChainsFromEnvironment, nil
+	}
+
+	if path != "" {
+		set, err := LoadChainsFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load fallback chains file: %w", err)
+		}
+		log.Printf("Fallback chains loaded from %s", path)
+		return set, ChainsFromFallback, nil
+	}
+
+	set, err := builtinChains()
+	if err != nil {
+		return nil, "", err
+	}
+	return set, ChainsFromFallback, nil
+}
@@ -0,0 +1,373 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rpc-proxy/internal/types"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// chainsFile is the on-disk chain configuration. Field names match the /admin/export document,
+// so an export can be used as a chains file; its settings section is ignored.
+type chainsFile struct {
+	Chains []chainsFileChain `yaml:"chains" toml:"chains"`
+}
+
+type chainsFileChain struct {
+	ChainID              int    `yaml:"chainId" toml:"chainId"`
+	Name                 string `yaml:"name" toml:"name"`
+	Type                 string `yaml:"type" toml:"type"` // defaults to evm
+	DisplayName          string `yaml:"displayName" toml:"displayName"`
+	RPCPath              string `yaml:"rpcPath" toml:"rpcPath"`
+	IsTestnet            bool   `yaml:"isTestnet" toml:"isTestnet"`
+	IsEnabled            *bool  `yaml:"isEnabled" toml:"isEnabled"` // defaults to true
+	NativeCurrencySymbol string `yaml:"nativeCurrencySymbol" toml:"nativeCurrencySymbol"`
+	BlockExplorerURL     string `yaml:"blockExplorerUrl" toml:"blockExplorerUrl"`
+
+	Configs   map[string]string    `yaml:"configs" toml:"configs"`
+	Endpoints []chainsFileEndpoint `yaml:"endpoints" toml:"endpoints"`
+}
+
+type chainsFileEndpoint struct {
+	Name      string `yaml:"name" toml:"name"`
+	URL       string `yaml:"url" toml:"url"`
+	Transport string `yaml:"transport" toml:"transport"` // defaults to the URL's
+	WSURL     string `yaml:"wsUrl" toml:"wsUrl"`
+	Weight    int    `yaml:"weight" toml:"weight"`   // defaults to 1
+	Enabled   *bool  `yaml:"enabled" toml:"enabled"` // defaults to true
+
+	GraphQLURL   string   `yaml:"graphqlUrl" toml:"graphqlUrl"`
+	Capabilities []string `yaml:"capabilities" toml:"capabilities"` // debug and trace
+
+	AuthUsername string `yaml:"authUsername" toml:"authUsername"`
+	AuthPassword string `yaml:"authPassword" toml:"authPassword"`
+}
+
+// ChainSet is a complete chain configuration: chains with their endpoints and chain configs
+type ChainSet struct {
+	Chains    []*types.Chain
+	Endpoints map[string][]*types.RPCEndpoint // chainName -> endpoints
+	Configs   map[string]map[string]string    // chainName -> configKey -> configValue
+}
+
+// LoadChainsFile reads and validates a YAML (.yaml, .yml) or TOML (.toml) chains file. Chains
+// and endpoints are numbered in file order, since there is no database to assign IDs.
+func LoadChainsFile(path string) (*ChainSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chains file: %w", err)
+	}
+
+	set, err := parseChainsFile(data, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("chains file %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// parseChainsFile parses a chains file in the format given by its extension
+func parseChainsFile(data []byte, ext string) (*ChainSet, error) {
+	var file chainsFile
+	var err error
+	switch ext = strings.ToLower(ext); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("unsupported chains file extension %q, must be .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+
+	return file.chainSet()
+}
+
+func (f *chainsFile) chainSet() (*ChainSet, error) {
+	set := &ChainSet{
+		Chains:    make([]*types.Chain, 0, len(f.Chains)),
+		Endpoints: make(map[string][]*types.RPCEndpoint, len(f.Chains)),
+		Configs:   make(map[string]map[string]string, len(f.Chains)),
+	}
+
+	names := make(map[string]bool)
+	chainIDs := make(map[int]string)
+	rpcPaths := make(map[string]string)
+	endpointID := 0
+
+	for i, fc := range f.Chains {
+		if fc.Name == "" {
+			return nil, fmt.Errorf("chain %d: name is required", i+1)
+		}
+		if fc.ChainID <= 0 {
+			return nil, fmt.Errorf("chain %s: chainId must be positive", fc.Name)
+		}
+		if err := ValidateChainType(fc.Type); err != nil {
+			return nil, fmt.Errorf("chain %s: %w", fc.Name, err)
+		}
+
+		chain := &types.Chain{
+			ID:                     i + 1,
+			ChainID:                fc.ChainID,
+			Name:                   fc.Name,
+			Type:                   fc.Type,
+			DisplayName:            fc.DisplayName,
+			RPCPath:                fc.RPCPath,
+			IsTestnet:              fc.IsTestnet,
+			IsEnabled:              fc.IsEnabled == nil || *fc.IsEnabled,
+			NativeCurrencySymbol:   fc.NativeCurrencySymbol,
+			NativeCurrencyDecimals: DefaultCurrencyDecimals(fc.Type),
+			BlockExplorerURL:       fc.BlockExplorerURL,
+		}
+		if chain.DisplayName == "" {
+			chain.DisplayName = chain.Name
+		}
+		if chain.RPCPath == "" {
+			chain.RPCPath = chain.Name
+		}
+		if chain.Type == "" {
+			chain.Type = types.ChainTypeEVM
+		}
+		if chain.NativeCurrencySymbol == "" {
+			chain.NativeCurrencySymbol = defaultCurrencySymbol(chain.Type)
+		}
+
+		if names[chain.Name] {
+			return nil, fmt.Errorf("chain %s is defined more than once", chain.Name)
+		}
+		if other, exists := chainIDs[chain.ChainID]; exists {
+			return nil, fmt.Errorf("chain %s: chainId %d is already used by chain %s", chain.Name, chain.ChainID, other)
+		}
+		if other, exists := rpcPaths[chain.RPCPath]; exists {
+			return nil, fmt.Errorf("chain %s: rpcPath %s is already used by chain %s", chain.Name, chain.RPCPath, other)
+		}
+		names[chain.Name] = true
+		chainIDs[chain.ChainID] = chain.Name
+		rpcPaths[chain.RPCPath] = chain.Name
+
+		configs := make(map[string]string, len(fc.Configs))
+		for key, value := range fc.Configs {
+			if err := ValidateChainConfig(key, value); err != nil {
+				return nil, fmt.Errorf("chain %s: %w", chain.Name, err)
+			}
+			configs[key] = value
+		}
+
+		endpoints := make([]*types.RPCEndpoint, 0, len(fc.Endpoints))
+		for j, fe := range fc.Endpoints {
+			if fe.URL == "" {
+				return nil, fmt.Errorf("chain %s: endpoint %d: url is required", chain.Name, j+1)
+			}
+			if fe.Weight < 0 {
+				return nil, fmt.Errorf("chain %s: endpoint %s: weight must be positive", chain.Name, fe.URL)
+			}
+			if err := ValidateEndpointTransport(chain.Type, fe.URL, fe.Transport, fe.WSURL); err != nil {
+				return nil, fmt.Errorf("chain %s: endpoint %s: %w", chain.Name, fe.URL, err)
+			}
+			if err := ValidateGraphQLURL(chain.Type, fe.URL, fe.Transport, fe.GraphQLURL); err != nil {
+				return nil, fmt.Errorf("chain %s: endpoint %s: %w", chain.Name, fe.URL, err)
+			}
+			if err := ValidateCapabilities(chain.Type, fe.Capabilities); err != nil {
+				return nil, fmt.Errorf("chain %s: endpoint %s: %w", chain.Name, fe.URL, err)
+			}
+
+			endpointID++
+			endpoint := &types.RPCEndpoint{
+				ID:        endpointID,
+				Name:      fe.Name,
+				URL:       fe.URL,
+				Transport: fe.Transport,
+				WSURL:     fe.WSURL,
+				Weight:    fe.Weight,
+				Enabled:   fe.Enabled == nil || *fe.Enabled,
+				ChainID:   chain.ID,
+				ChainName: chain.Name,
+
+				GraphQLURL:   fe.GraphQLURL,
+				Capabilities: fe.Capabilities,
+				AuthUsername: fe.AuthUsername,
+				AuthPassword: fe.AuthPassword,
+			}
+			if endpoint.Name == "" {
+				endpoint.Name = defaultEndpointName(fe.URL)
+			}
+			if endpoint.Transport == "" {
+				endpoint.Transport = types.TransportForURL(fe.URL)
+			}
+			if endpoint.Weight == 0 {
+				endpoint.Weight = 1
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+
+		set.Chains = append(set.Chains, chain)
+		set.Endpoints[chain.Name] = endpoints
+		set.Configs[chain.Name] = configs
+	}
+
+	return set, nil
+}
+
+// defaultEndpointName names an unnamed endpoint after its host, which unlike its position in
+// the file stays the same when endpoints are added or reordered
+func defaultEndpointName(endpointURL string) string {
+	if u, err := url.Parse(endpointURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return endpointURL
+}
+
+// ValidateChainType checks a chain type; empty is evm
+func ValidateChainType(chainType string) error {
+	switch chainType {
+	case "", types.ChainTypeEVM, types.ChainTypeSolana, types.ChainTypeBitcoin, types.ChainTypeBeacon, types.ChainTypeStarknet:
+		return nil
+	}
+	return fmt.Errorf("invalid chain type %q, must be %s, %s, %s, %s or %s", chainType,
+		types.ChainTypeEVM, types.ChainTypeSolana, types.ChainTypeBitcoin, types.ChainTypeBeacon, types.ChainTypeStarknet)
+}
+
+// defaultCurrencySymbol is the native currency of chains of a type that don't name theirs
+func defaultCurrencySymbol(chainType string) string {
+	switch chainType {
+	case types.ChainTypeSolana:
+		return "SOL"
+	case types.ChainTypeBitcoin:
+		return "BTC"
+	case types.ChainTypeStarknet:
+		return "STRK"
+	}
+	return "ETH"
+}
+
+// DefaultCurrencyDecimals is the native currency precision of chains of a type
+func DefaultCurrencyDecimals(chainType string) int {
+	switch chainType {
+	case types.ChainTypeSolana:
+		return 9
+	case types.ChainTypeBitcoin:
+		return 8
+	}
+	return 18
+}
+
+// ValidateEndpointTransport checks an endpoint's transport against its URLs: http endpoints
+// need an http or https URL and may have a ws or wss WSURL, ws endpoints need a ws or wss URL.
+// An empty transport is the one implied by the URL. Subscriptions are only proxied for evm
+// chains, so endpoints of other chains must be plain http endpoints.
+func ValidateEndpointTransport(chainType, endpointURL, transport, wsURL string) error {
+	if transport == "" {
+		transport = types.TransportForURL(endpointURL)
+	}
+	if chainType != "" && chainType != types.ChainTypeEVM && (transport == types.TransportWS || wsURL != "") {
+		return fmt.Errorf("WebSocket endpoints are only supported on %s chains", types.ChainTypeEVM)
+	}
+
+	scheme := ""
+	if u, err := url.Parse(endpointURL); err == nil {
+		scheme = u.Scheme
+	}
+	switch transport {
+	case types.TransportHTTP:
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("http endpoint URL scheme must be http or https")
+		}
+	case types.TransportWS:
+		if scheme != "ws" && scheme != "wss" {
+			return fmt.Errorf("ws endpoint URL scheme must be ws or wss")
+		}
+		if wsURL != "" {
+			return fmt.Errorf("wsUrl is only for http endpoints, a ws endpoint's URL is its WebSocket URL")
+		}
+	default:
+		return fmt.Errorf("invalid transport %q, must be http or ws", transport)
+	}
+
+	if wsURL != "" {
+		u, err := url.Parse(wsURL)
+		if err != nil || u.Host == "" || (u.Scheme != "ws" && u.Scheme != "wss") {
+			return fmt.Errorf("invalid wsUrl %q, must be a ws or wss URL", wsURL)
+		}
+	}
+	return nil
+}
+
+// ValidateGraphQLURL checks an endpoint's GraphQL URL, which only http endpoints of evm chains
+// may have and which must be an http or https URL. An empty URL is always valid.
+func ValidateGraphQLURL(chainType, endpointURL, transport, graphqlURL string) error {
+	if graphqlURL == "" {
+		return nil
+	}
+	if chainType != "" && chainType != types.ChainTypeEVM {
+		return fmt.Errorf("graphqlUrl is only supported on %s chains", types.ChainTypeEVM)
+	}
+	if transport == "" {
+		transport = types.TransportForURL(endpointURL)
+	}
+	if transport != types.TransportHTTP {
+		return fmt.Errorf("graphqlUrl is only for http endpoints")
+	}
+	u, err := url.Parse(graphqlURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid graphqlUrl %q, must be an http or https URL", graphqlURL)
+	}
+	return nil
+}
+
+// ValidateCapabilities checks an endpoint's capabilities: debug and trace, each listed at most
+// once, and only on evm chains, the only ones with those namespaces
+func ValidateCapabilities(chainType string, capabilities []string) error {
+	seen := make(map[string]bool, len(capabilities))
+	for _, capability := range capabilities {
+		switch {
+		case capability != types.CapabilityDebug && capability != types.CapabilityTrace:
+			return fmt.Errorf("invalid capability %q, must be %s or %s", capability, types.CapabilityDebug, types.CapabilityTrace)
+		case seen[capability]:
+			return fmt.Errorf("capability %s is listed more than once", capability)
+		}
+		seen[capability] = true
+	}
+	if len(capabilities) > 0 && chainType != "" && chainType != types.ChainTypeEVM {
+		return fmt.Errorf("capabilities are only supported on %s chains", types.ChainTypeEVM)
+	}
+	return nil
+}
+
+// loadMultiChainConfigFromFile loads chains, endpoints and chain configs from the chains file
+func loadMultiChainConfigFromFile(config *Config, path string) error {
+	set, err := LoadChainsFile(path)
+	if err != nil {
+		return err
+	}
+
+	applyChainSet(config, set)
+	log.Printf("Multi-chain configuration loaded from %s: %d chains, %d total endpoints",
+		path, len(config.Chains), len(config.RPCEndpoints))
+
+	return nil
+}
+
+// applyChainSet replaces the chains, endpoints and chain configs of config with those of set
+func applyChainSet(config *Config, set *ChainSet) {
+	config.Chains = set.Chains
+	config.ChainEndpoints = set.Endpoints
+	config.ChainConfigs = set.Configs
+
+	// Legacy single-chain support: every enabled endpoint, as the database load does
+	config.RPCEndpoints = nil
+	for _, chain := range set.Chains {
+		for _, endpoint := range set.Endpoints[chain.Name] {
+			if endpoint.Enabled {
+				config.RPCEndpoints = append(config.RPCEndpoints, endpoint)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/remote"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layout of the remote key prefix: chains/<name> holds one chain in chains file format, as
+// YAML or JSON, and settings/<key> holds one value of the settings table
+const (
+	remoteChainsDir   = "chains/"
+	remoteSettingsDir = "settings/"
+)
+
+// remoteLoadTimeout bounds the initial read of the remote store at startup
+const remoteLoadTimeout = 10 * time.Second
+
+type RemoteConfig struct {
+	// Backend is consul or etcd; empty disables remote configuration
+	Backend string
+	Address string
+	Prefix  string
+	Token   string
+}
+
+// Store returns the configured remote store
+func (c RemoteConfig) Store() (remote.Store, error) {
+	return remote.New(remote.Config{
+		Backend: c.Backend,
+		Address: c.Address,
+		Prefix:  c.Prefix,
+		Token:   c.Token,
+	})
+}
+
+// RemoteChains parses the chains/ keys of a listing of the remote prefix. Chains are numbered
+// in key order and validated like a chains file.
+func RemoteChains(kvs map[string]string) (*ChainSet, error) {
+	var keys []string
+	for key := range kvs {
+		if strings.HasPrefix(key, remoteChainsDir) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var file chainsFile
+	for _, key := range keys {
+		var chain chainsFileChain
+		if err := yaml.Unmarshal([]byte(kvs[key]), &chain); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+
+		// The key names the chain; a name in the document must agree with it
+		name := strings.TrimPrefix(key, remoteChainsDir)
+		if chain.Name == "" {
+			chain.Name = name
+		} else if chain.Name != name {
+			return nil, fmt.Errorf("%s: name %q does not match its key", key, chain.Name)
+		}
+		file.Chains = append(file.Chains, chain)
+	}
+
+	return file.chainSet()
+}
+
+// RemoteSettings returns the settings/ keys of a listing of the remote prefix
+func RemoteSettings(kvs map[string]string) map[string]string {
+	settings := make(map[string]string)
+	for key, value := range kvs {
+		if strings.HasPrefix(key, remoteSettingsDir) {
+			settings[strings.TrimPrefix(key, remoteSettingsDir)] = value
+		}
+	}
+	return settings
+}
+
+// loadMultiChainConfigFromRemote loads chains, endpoints, chain configs and settings from the
+// remote store
+func loadMultiChainConfigFromRemote(config *Config) error {
+	store, err := config.Remote.Store()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteLoadTimeout)
+	defer cancel()
+
+	kvs, _, err := store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", store.Name(), err)
+	}
+
+	set, err := RemoteChains(kvs)
+	if err != nil {
+		return fmt.Errorf("invalid configuration in %s: %w", store.Name(), err)
+	}
+
+	applyChainSet(config, set)
+	applyRuntimeSettings(config, RemoteSettings(kvs))
+
+	log.Printf("Multi-chain configuration loaded from %s: %d chains, %d total endpoints",
+		store.Name(), len(config.Chains), len(config.RPCEndpoints))
+
+	return nil
+}
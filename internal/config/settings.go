@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/flags"
+	"rpc-proxy/internal/ratelimit"
+	"rpc-proxy/internal/scheduler"
+)
+
+// settingValidators lists the database settings the proxy interprets; other keys are stored as-is
+var settingValidators = map[string]func(string) error{
+	"health_check_interval":              validatePositiveDuration,
+	"health_check_timeout":               validatePositiveDuration,
+	"health_check_retries":               validatePositiveInt,
+	"proxy_timeout":                      validatePositiveDuration,
+	"max_connections":                    validatePositiveInt,
+	"server_port":                        validatePort,
+	"health_check_retention_days":        validateNonNegativeInt,
+	"health_check_downsample_after_days": validateNonNegativeInt,
+	"client_rate_limit":                  validateNonNegativeFloat,
+	"client_burst":                       validateNonNegativeInt,
+	"client_max_concurrency":             validateNonNegativeInt,
+}
+
+// jobSettingValidator returns the validator for a scheduler task setting, job_<name>_enabled
+// or job_<name>_interval
+func jobSettingValidator(key string) (func(string) error, bool) {
+	if !strings.HasPrefix(key, scheduler.SettingPrefix) {
+		return nil, false
+	}
+	switch {
+	case strings.HasSuffix(key, scheduler.EnabledSettingSuffix):
+		return validateBool, true
+	case strings.HasSuffix(key, scheduler.IntervalSettingSuffix):
+		return validatePositiveDuration, true
+	}
+	return nil, false
+}
+
+// flagSettingValidator returns the validator for a feature flag setting, feature_<name>
+func flagSettingValidator(key string) (func(string) error, bool) {
+	if !strings.HasPrefix(key, flags.SettingPrefix) {
+		return nil, false
+	}
+	return validateBool, true
+}
+
+// RuntimeSettings are the database settings that can change while the proxy is running.
+// ServerPort is only read at startup.
+type RuntimeSettings struct {
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+	HealthCheckRetries  int
+	ProxyTimeout        time.Duration
+	MaxConnections      int
+	ServerPort          int
+	// ClientLimits bound each client address and are the defaults of tenant limits
+	ClientLimits ratelimit.Limits
+}
+
+// ValidateSetting rejects values the proxy can't use for the settings it interprets
+func ValidateSetting(key, value string) error {
+	validate, known := settingValidators[key]
+	if !known {
+		validate, known = jobSettingValidator(key)
+	}
+	if !known {
+		if validate, known = flagSettingValidator(key); !known {
+			return nil
+		}
+	}
+
+	if err := validate(strings.TrimSpace(value)); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+
+	return nil
+}
+
+// RuntimeSettings returns the settings in effect according to the loaded configuration
+func (c *Config) RuntimeSettings() RuntimeSettings {
+	return RuntimeSettings{
+		HealthCheckInterval: c.HealthCheck.Interval,
+		HealthCheckTimeout:  c.HealthCheck.Timeout,
+		HealthCheckRetries:  c.HealthCheck.Retries,
+		ProxyTimeout:        c.Proxy.Timeout,
+		MaxConnections:      c.Proxy.MaxConnections,
+		ServerPort:          c.Server.Port,
+		ClientLimits: ratelimit.Limits{
+			Rate:           c.Proxy.ClientRateLimit,
+			Burst:          c.Proxy.ClientBurst,
+			MaxConcurrency: c.Proxy.ClientMaxConcurrency,
+		},
+	}
+}
+
+// ParseRuntimeSettings overlays settings onto base. Invalid values are skipped and returned by key,
+// leaving the base value in place.
+func ParseRuntimeSettings(base RuntimeSettings, settings map[string]string) (RuntimeSettings, map[string]error) {
+	result := base
+	invalid := make(map[string]error)
+
+	for key, value := range settings {
+		if err := ValidateSetting(key, value); err != nil {
+			invalid[key] = err
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		switch key {
+		case "health_check_interval":
+			result.HealthCheckInterval, _ = time.ParseDuration(value)
+		case "health_check_timeout":
+			result.HealthCheckTimeout, _ = time.ParseDuration(value)
+		case "health_check_retries":
+			result.HealthCheckRetries, _ = strconv.Atoi(value)
+		case "proxy_timeout":
+			result.ProxyTimeout, _ = time.ParseDuration(value)
+		case "max_connections":
+			result.MaxConnections, _ = strconv.Atoi(value)
+		case "server_port":
+			result.ServerPort, _ = strconv.Atoi(value)
+		case "client_rate_limit":
+			result.ClientLimits.Rate, _ = strconv.ParseFloat(value, 64)
+		case "client_burst":
+			result.ClientLimits.Burst, _ = strconv.Atoi(value)
+		case "client_max_concurrency":
+			result.ClientLimits.MaxConcurrency, _ = strconv.Atoi(value)
+		}
+	}
+
+	return result, invalid
+}
+
+func validatePositiveDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("must be a duration such as 30s")
+	}
+	if d <= 0 {
+		return fmt.Errorf("must be positive")
+	}
+	return nil
+}
+
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("must be true or false")
+	}
+	return nil
+}
+
+func validatePort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("must be between 1 and 65535")
+	}
+	return nil
+}
@@ -4,16 +4,24 @@ import (
 	"fmt"
 	"log"
 
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// Supported values for Config.Driver
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql" // also MariaDB
+)
+
 type GormDB struct {
 	*gorm.DB
 }
 
 type Config struct {
+	Driver   string // postgres (default) or mysql
 	Host     string
 	Port     int
 	User     string
@@ -23,10 +31,12 @@ type Config struct {
 }
 
 func NewGormConnection(config Config) (*GormDB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=UTC",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+	dialector, name, err := newDialector(config)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
@@ -47,11 +57,43 @@ func NewGormConnection(config Config) (*GormDB, error) {
 	sqlDB.SetMaxOpenConns(25)
 	sqlDB.SetMaxIdleConns(5)
 
-	log.Printf("Connected to PostgreSQL database with GORM: %s:%d/%s", config.Host, config.Port, config.DBName)
+	log.Printf("Connected to %s database with GORM: %s:%d/%s", name, config.Host, config.Port, config.DBName)
 
 	return &GormDB{db}, nil
 }
 
+func newDialector(config Config) (gorm.Dialector, string, error) {
+	switch config.Driver {
+	case "", DriverPostgres:
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=UTC",
+			config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+		return postgres.Open(dsn), "PostgreSQL", nil
+	case DriverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC&tls=%s",
+			config.User, config.Password, config.Host, config.Port, config.DBName, mysqlTLS(config.SSLMode))
+		// Second precision keeps DEFAULT CURRENT_TIMESTAMP valid on datetime columns
+		precision := 0
+		return mysql.New(mysql.Config{DSN: dsn, DefaultDatetimePrecision: &precision}), "MySQL", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported database driver %q, must be %s or %s", config.Driver, DriverPostgres, DriverMySQL)
+	}
+}
+
+// mysqlTLS maps PostgreSQL sslmode values, which db.sslmode uses for both drivers, to the
+// MySQL driver's tls parameter
+func mysqlTLS(sslMode string) string {
+	switch sslMode {
+	case "require":
+		return "skip-verify"
+	case "verify-ca", "verify-full":
+		return "true"
+	case "prefer", "allow":
+		return "preferred"
+	default:
+		return "false"
+	}
+}
+
 func (db *GormDB) Close() error {
 	sqlDB, err := db.DB.DB()
 	if err != nil {
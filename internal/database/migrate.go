@@ -0,0 +1,227 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID identifies the advisory lock that keeps replicas starting together from
+// applying the same migration twice
+const migrationLockID = 72_037_001
+
+// Migration is one versioned schema change. Up runs in a transaction together with the
+// insert of its schema_migrations record, so a failed migration leaves no record and is
+// retried next time. Up must tolerate objects that already exist: the baseline is built from
+// the current models, and databases created by the old AutoMigrate-on-boot already have them.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// MigrationStatus reports whether a migration has been applied, and when
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// migrations lists every schema change in order. Append new ones with the next version;
+// never edit or renumber one that has shipped.
+var migrations = []Migration{
+	{Version: 1, Name: "baseline schema", Up: models.AutoMigrate},
+	{Version: 2, Name: "health check rollups", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.HealthCheckHourlyRollup{}, &models.HealthCheckDailyRollup{})
+	}},
+	{Version: 3, Name: "request logs", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.RequestLog{})
+	}},
+	{Version: 4, Name: "config revisions", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.ConfigRevision{})
+	}},
+	{Version: 5, Name: "tenants and api keys", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Tenant{}, &models.APIKey{}, &models.RequestLog{})
+	}},
+	{Version: 6, Name: "tenant allowed chains", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Tenant{})
+	}},
+	{Version: 7, Name: "api key usage", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.APIKeyUsage{})
+	}},
+	{Version: 8, Name: "usage buckets", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.UsageBucket{})
+	}},
+	{Version: 9, Name: "tenant allowed methods", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Tenant{})
+	}},
+	{Version: 10, Name: "tenant limits", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Tenant{})
+	}},
+	{Version: 11, Name: "api key expiry", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.APIKey{})
+	}},
+	{Version: 12, Name: "api key restrictions", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.APIKey{})
+	}},
+	{Version: 13, Name: "plans", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Plan{})
+	}},
+	{Version: 14, Name: "instances", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Instance{})
+	}},
+	{Version: 15, Name: "endpoint transport", Up: func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&models.RPCEndpoint{}); err != nil {
+			return err
+		}
+		// Endpoints registered by their ws:// or wss:// URL were WebSocket endpoints all along
+		return tx.Unscoped().Model(&models.RPCEndpoint{}).
+			Where("url LIKE ? OR url LIKE ?", "ws://%", "wss://%").
+			Update("transport", types.TransportWS).Error
+	}},
+	{Version: 16, Name: "chain type", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Chain{})
+	}},
+	{Version: 17, Name: "endpoint credentials", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.RPCEndpoint{})
+	}},
+	{Version: 18, Name: "endpoint graphql url", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.RPCEndpoint{})
+	}},
+	{Version: 19, Name: "endpoint capabilities", Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.RPCEndpoint{})
+	}},
+}
+
+// LatestMigrationVersion is the schema version this binary expects
+func LatestMigrationVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate applies every pending migration in order and returns the ones it applied
+func (db *GormDB) Migrate() ([]Migration, error) {
+	var applied []Migration
+	err := db.withMigrationLock(func(conn *gorm.DB) error {
+		if err := conn.AutoMigrate(&models.SchemaMigration{}); err != nil {
+			return fmt.Errorf("failed to create schema_migrations table: %w", err)
+		}
+
+		pending, err := pendingMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range pending {
+			log.Printf("Applying migration %d: %s", migration.Version, migration.Name)
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&models.SchemaMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return applied, err
+	}
+
+	if len(applied) == 0 {
+		log.Printf("Database schema is up to date at version %d", LatestMigrationVersion())
+	} else {
+		log.Printf("Applied %d migrations, database schema is at version %d", len(applied), LatestMigrationVersion())
+	}
+	return applied, nil
+}
+
+// PendingMigrations returns the migrations not yet applied, without changing the database
+func (db *GormDB) PendingMigrations() ([]Migration, error) {
+	return pendingMigrations(db.DB)
+}
+
+// MigrationStatuses returns every known migration with the time it was applied, if it was
+func (db *GormDB) MigrationStatuses() ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func pendingMigrations(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// appliedMigrations reads schema_migrations; a database that has never been migrated has none
+func appliedMigrations(db *gorm.DB) (map[int]models.SchemaMigration, error) {
+	applied := make(map[int]models.SchemaMigration)
+	if !db.Migrator().HasTable(&models.SchemaMigration{}) {
+		return applied, nil
+	}
+
+	var records []models.SchemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// withMigrationLock runs fn on a single connection holding a database-wide advisory lock
+func (db *GormDB) withMigrationLock(fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		switch conn.Dialector.Name() {
+		case DriverPostgres:
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+		case DriverMySQL:
+			var acquired int
+			if err := conn.Raw("SELECT GET_LOCK(?, 60)", fmt.Sprint(migrationLockID)).Scan(&acquired).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			if acquired != 1 {
+				return fmt.Errorf("timed out waiting for the migration lock")
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", fmt.Sprint(migrationLockID))
+		}
+		return fn(conn)
+	})
+}
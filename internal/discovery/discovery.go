@@ -0,0 +1,185 @@
+// Package discovery registers RPC endpoints found by discovery sources, such as the
+// Kubernetes API, next to a chain's configured endpoints, and deregisters them once they are
+// gone. Chains opt in through chain configs, so sources are set up like any other chain
+// setting.
+package discovery
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/types"
+)
+
+// firstID is the ID of the first discovered endpoint; discovered endpoints are numbered
+// from here so their IDs never collide with those of configured endpoints
+const firstID = 1_000_000_000
+
+// defaultWeight is the weight of discovered endpoints on chains without discovery_weight
+const defaultWeight = 1
+
+// Target is an endpoint a source found for a chain
+type Target struct {
+	Name string
+	URL  string
+	// Weight is the endpoint's weight; 0 takes the chain's discovery_weight
+	Weight int
+}
+
+// Source finds a chain's endpoints from its chain configs
+type Source interface {
+	// Name is the source recorded on the endpoints it finds, e.g. kubernetes
+	Name() string
+	// Discover returns the chain's endpoints, with ok false when the chain's configs don't
+	// use this source
+	Discover(ctx context.Context, chainName string, configs map[string]string) (targets []Target, ok bool, err error)
+}
+
+// Job re-resolves every running chain against the sources and applies the differences:
+// new targets become endpoints, health checked at once, and endpoints whose target is gone
+// are removed. A failing source leaves the chain's endpoints from it as they are.
+type Job struct {
+	config  *config.Config
+	checker *health.MultiChainChecker
+	sources []Source
+
+	// ids keeps a discovered endpoint's ID stable while it comes and goes, by chain and URL
+	ids    map[string]int
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewJob returns a job applying the endpoints the sources find
+func NewJob(cfg *config.Config, checker *health.MultiChainChecker, sources ...Source) *Job {
+	return &Job{
+		config:  cfg,
+		checker: checker,
+		sources: sources,
+		ids:     make(map[string]int),
+		nextID:  firstID,
+	}
+}
+
+// Run resolves every running chain once; it is the scheduler's endpoint_discovery task
+func (j *Job) Run(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, chainName := range j.checker.GetSupportedChains() {
+		configs := j.config.GetChainConfigs(chainName)
+		for _, source := range j.sources {
+			targets, ok, err := source.Discover(ctx, chainName, configs)
+			if err != nil {
+				log.Printf("Endpoint discovery from %s failed for chain %s: %v", source.Name(), chainName, err)
+				continue
+			}
+			if !ok {
+				// Endpoints left over after the chain stopped using the source are removed
+				targets = nil
+			}
+			j.apply(chainName, source.Name(), targets, weight(configs))
+		}
+	}
+	return ctx.Err()
+}
+
+// apply makes the chain's endpoints from source match targets
+func (j *Job) apply(chainName, source string, targets []Target, weight int) {
+	var added, removed []*types.RPCEndpoint
+	err := j.checker.UpdateEndpoints(chainName, func(current []*types.RPCEndpoint) ([]*types.RPCEndpoint, bool) {
+		added, removed = nil, nil
+		wanted := make(map[string]Target, len(targets))
+		for _, target := range targets {
+			if target.Weight <= 0 {
+				target.Weight = weight
+			}
+			wanted[target.URL] = target
+		}
+
+		endpoints := make([]*types.RPCEndpoint, 0, len(current)+len(targets))
+		for _, endpoint := range current {
+			if endpoint.Source != source {
+				endpoints = append(endpoints, endpoint)
+				continue
+			}
+			if target, ok := wanted[endpoint.URL]; ok && endpoint.Weight == target.Weight {
+				delete(wanted, endpoint.URL)
+				endpoints = append(endpoints, endpoint)
+				continue
+			}
+			removed = append(removed, endpoint)
+		}
+
+		for _, found := range targets {
+			target, ok := wanted[found.URL]
+			if !ok {
+				continue
+			}
+			delete(wanted, target.URL)
+			endpoint := j.newEndpoint(chainName, source, target)
+			for _, old := range removed {
+				// A weight change replaces the endpoint; it keeps its health and traffic
+				if old.URL == endpoint.URL {
+					endpoint.InheritState(old)
+				}
+			}
+			endpoints = append(endpoints, endpoint)
+			added = append(added, endpoint)
+		}
+
+		return endpoints, len(added) > 0 || len(removed) > 0
+	})
+	if err != nil {
+		log.Printf("Failed to apply discovered endpoints for chain %s: %v", chainName, err)
+		return
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	j.config.SetChainEndpoints(chainName, j.checker.GetAllEndpoints(chainName))
+	log.Printf("Discovered endpoints for chain %s from %s: +%d -%d", chainName, source, len(added), len(removed))
+
+	// Check new endpoints now rather than at the next cycle, so they take traffic sooner
+	for _, endpoint := range added {
+		if !endpoint.IsHealthy() {
+			j.checker.CheckEndpointNow(chainName, endpoint.ID)
+		}
+	}
+}
+
+// newEndpoint returns the endpoint for a target, reusing its previous ID if it had one
+func (j *Job) newEndpoint(chainName, source string, target Target) *types.RPCEndpoint {
+	key := chainName + " " + target.URL
+	id, ok := j.ids[key]
+	if !ok {
+		id = j.nextID
+		j.nextID++
+		j.ids[key] = id
+	}
+
+	endpoint := &types.RPCEndpoint{
+		ID:        id,
+		Name:      target.Name,
+		URL:       target.URL,
+		Weight:    target.Weight,
+		Enabled:   true,
+		ChainName: chainName,
+		Source:    source,
+	}
+	if chain := j.config.GetChainByName(chainName); chain != nil {
+		endpoint.ChainID = chain.ID
+	}
+	return endpoint
+}
+
+// weight returns the discovery_weight chain config, validated when it was stored
+func weight(configs map[string]string) int {
+	if n, err := strconv.Atoi(configs["discovery_weight"]); err == nil && n > 0 {
+		return n
+	}
+	return defaultWeight
+}
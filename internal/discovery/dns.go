@@ -0,0 +1,88 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultDNSPort is the port of endpoints found through dns_name without dns_port, the usual
+// JSON-RPC port of Ethereum nodes
+const defaultDNSPort = "8545"
+
+// DNS finds a chain's endpoints in DNS: the targets of the SRV records at the dns_srv chain
+// config, and the addresses of the A and AAAA records at dns_name. SRV record weights become
+// endpoint weights; priorities are ignored, failover being left to health checks and scores.
+type DNS struct {
+	resolver *net.Resolver
+}
+
+// NewDNS returns a source resolving through the system resolver
+func NewDNS() *DNS {
+	return &DNS{resolver: net.DefaultResolver}
+}
+
+// Name implements Source
+func (d *DNS) Name() string {
+	return "dns"
+}
+
+// Discover implements Source for chains with dns_srv or dns_name, reached over dns_scheme
+// (http by default); dns_name endpoints are on dns_port
+func (d *DNS) Discover(ctx context.Context, chainName string, configs map[string]string) ([]Target, bool, error) {
+	srvName, name := configs["dns_srv"], configs["dns_name"]
+	if srvName == "" && name == "" {
+		return nil, false, nil
+	}
+	scheme := configs["dns_scheme"]
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	var targets []Target
+	if srvName != "" {
+		_, records, err := d.resolver.LookupSRV(ctx, "", "", srvName)
+		if err != nil && !isNotFound(err) {
+			return nil, true, fmt.Errorf("failed to look up SRV records of %s: %w", srvName, err)
+		}
+		for _, record := range records {
+			host := strings.TrimSuffix(record.Target, ".")
+			targets = append(targets, Target{
+				Name:   host,
+				URL:    scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(record.Port))),
+				Weight: int(record.Weight),
+			})
+		}
+	}
+
+	if name != "" {
+		port := configs["dns_port"]
+		if port == "" {
+			port = defaultDNSPort
+		}
+		addrs, err := d.resolver.LookupIPAddr(ctx, name)
+		if err != nil && !isNotFound(err) {
+			return nil, true, fmt.Errorf("failed to look up addresses of %s: %w", name, err)
+		}
+		for _, addr := range addrs {
+			targets = append(targets, Target{
+				Name: name + "/" + addr.IP.String(),
+				URL:  scheme + "://" + net.JoinHostPort(addr.IP.String(), port),
+			})
+		}
+	}
+
+	sort.Slice(targets, func(a, b int) bool { return targets[a].URL < targets[b].URL })
+	return targets, true, nil
+}
+
+// isNotFound reports whether a lookup failed because the name has no records, which
+// deregisters the endpoints found before rather than keeping them
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,197 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into every pod
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubernetesTimeout bounds each Kubernetes API call
+const kubernetesTimeout = 10 * time.Second
+
+// Kubernetes finds the pods behind Services matching a chain's k8s_selector chain config,
+// through their EndpointSlices. Only ready endpoints are registered, so pods that are
+// starting or terminating leave rotation before health checks would notice.
+type Kubernetes struct {
+	apiURL    string
+	namespace string
+	client    *http.Client
+}
+
+// NewKubernetes returns a source reading the API server at apiURL, or inside a cluster the
+// in-cluster API server with the pod's service account when apiURL is empty
+func NewKubernetes(apiURL string) (*Kubernetes, error) {
+	k := &Kubernetes{
+		apiURL:    strings.TrimRight(apiURL, "/"),
+		namespace: "default",
+		client:    &http.Client{Timeout: kubernetesTimeout},
+	}
+	if namespace, err := os.ReadFile(serviceAccountDir + "/namespace"); err == nil {
+		k.namespace = strings.TrimSpace(string(namespace))
+	}
+	if k.apiURL != "" {
+		return k, nil
+	}
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster")
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("service account CA is not a PEM certificate")
+	}
+	k.apiURL = "https://" + net.JoinHostPort(host, port)
+	k.client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	return k, nil
+}
+
+// Name implements Source
+func (k *Kubernetes) Name() string {
+	return "kubernetes"
+}
+
+type objectMeta struct {
+	Name string `json:"name"`
+}
+
+type serviceList struct {
+	Items []struct {
+		Metadata objectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+type endpointSliceList struct {
+	Items []struct {
+		Endpoints []struct {
+			Addresses  []string `json:"addresses"`
+			Conditions struct {
+				Ready       *bool `json:"ready"`
+				Terminating *bool `json:"terminating"`
+			} `json:"conditions"`
+			TargetRef *objectMeta `json:"targetRef"`
+		} `json:"endpoints"`
+		Ports []struct {
+			Name *string `json:"name"`
+			Port *int32  `json:"port"`
+		} `json:"ports"`
+	} `json:"items"`
+}
+
+// Discover implements Source for chains with k8s_selector, in k8s_namespace (the proxy's own
+// namespace by default) on k8s_port, a port name or number (the first port by default)
+func (k *Kubernetes) Discover(ctx context.Context, chainName string, configs map[string]string) ([]Target, bool, error) {
+	selector := configs["k8s_selector"]
+	if selector == "" {
+		return nil, false, nil
+	}
+	namespace := configs["k8s_namespace"]
+	if namespace == "" {
+		namespace = k.namespace
+	}
+
+	var services serviceList
+	if err := k.list(ctx, "/api/v1/namespaces/"+namespace+"/services", selector, &services); err != nil {
+		return nil, true, err
+	}
+
+	seen := make(map[string]bool)
+	var targets []Target
+	for _, service := range services.Items {
+		var slices endpointSliceList
+		if err := k.list(ctx, "/apis/discovery.k8s.io/v1/namespaces/"+namespace+"/endpointslices",
+			"kubernetes.io/service-name="+service.Metadata.Name, &slices); err != nil {
+			return nil, true, err
+		}
+
+		for _, slice := range slices.Items {
+			port := 0
+			for _, p := range slice.Ports {
+				if p.Port == nil {
+					continue
+				}
+				name := ""
+				if p.Name != nil {
+					name = *p.Name
+				}
+				if want := configs["k8s_port"]; want == "" || want == name || want == strconv.Itoa(int(*p.Port)) {
+					port = int(*p.Port)
+					break
+				}
+			}
+			if port == 0 {
+				continue
+			}
+
+			for _, endpoint := range slice.Endpoints {
+				// A missing condition means ready and not terminating
+				ready := endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+				terminating := endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating
+				if !ready || terminating || len(endpoint.Addresses) == 0 {
+					continue
+				}
+
+				// Addresses of one endpoint are interchangeable, so the first is used
+				target := Target{URL: "http://" + net.JoinHostPort(endpoint.Addresses[0], strconv.Itoa(port))}
+				if seen[target.URL] {
+					continue
+				}
+				seen[target.URL] = true
+				target.Name = service.Metadata.Name + "/" + endpoint.Addresses[0]
+				if endpoint.TargetRef != nil && endpoint.TargetRef.Name != "" {
+					target.Name = service.Metadata.Name + "/" + endpoint.TargetRef.Name
+				}
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	sort.Slice(targets, func(a, b int) bool { return targets[a].URL < targets[b].URL })
+	return targets, true, nil
+}
+
+// list fetches the objects at path matching selector into out
+func (k *Kubernetes) list(ctx context.Context, path, selector string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", k.apiURL+path+"?labelSelector="+url.QueryEscape(selector), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	// Projected service account tokens are rotated, so the token is read for every call
+	if token, err := os.ReadFile(serviceAccountDir + "/token"); err == nil {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kubernetes API returned HTTP %d for %s: %s", resp.StatusCode, path, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode kubernetes API response: %w", err)
+	}
+	return nil
+}
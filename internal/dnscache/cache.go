@@ -0,0 +1,165 @@
+package dnscache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache resolves upstream hostnames out-of-band and serves dials from cached addresses,
+// round-robining across all resolved IPs. Failed refreshes keep serving the last good
+// addresses for up to maxStale so a resolver outage doesn't take healthy upstreams down
+// with it, without pinning a host that no longer resolves to its old IPs forever.
+//
+// The system resolver doesn't expose record TTLs, so ttl is a fixed refresh interval rather
+// than the records' own TTL.
+type Cache struct {
+	lookup   func(ctx context.Context, host string) ([]string, error)
+	dialer   *net.Dialer
+	ttl      time.Duration
+	maxStale time.Duration
+
+	entries map[string]*entry
+	mu      sync.RWMutex
+}
+
+// entry is never modified once stored except for next, so readers need no lock; a refresh
+// replaces it
+type entry struct {
+	addrs []string
+	// resolved is the last resolution attempt, fresh the last successful one
+	resolved time.Time
+	fresh    time.Time
+	err      error
+	next     uint32
+}
+
+// New creates a cache refreshing every ttl that dials resolved addresses with dialer, serving
+// the last good addresses for at most maxStale after refreshes start failing
+func New(ttl, maxStale time.Duration, dialer *net.Dialer) *Cache {
+	return &Cache{
+		lookup:   net.DefaultResolver.LookupHost,
+		dialer:   dialer,
+		ttl:      ttl,
+		maxStale: maxStale,
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Run refreshes every known host once per TTL until ctx is cancelled
+func (c *Cache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.RLock()
+			hosts := make([]string, 0, len(c.entries))
+			for host := range c.entries {
+				hosts = append(hosts, host)
+			}
+			c.mu.RUnlock()
+
+			for _, host := range hosts {
+				c.refresh(ctx, host)
+			}
+		}
+	}
+}
+
+// Lookup returns the cached addresses for host, resolving synchronously only on first use or
+// when the entry is more than twice the TTL old (the refresh loop normally keeps it warm)
+func (c *Cache) Lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.RLock()
+	e, exists := c.entries[host]
+	c.mu.RUnlock()
+
+	if exists && time.Since(e.resolved) < 2*c.ttl {
+		if len(e.addrs) > 0 {
+			return e.addrs, nil
+		}
+		return nil, e.err
+	}
+
+	e = c.refresh(ctx, host)
+	if len(e.addrs) > 0 {
+		return e.addrs, nil
+	}
+	return nil, e.err
+}
+
+// refresh resolves host and replaces its entry, keeping stale addresses on failure until they
+// are older than maxStale
+func (c *Cache) refresh(ctx context.Context, host string) *entry {
+	addrs, err := c.lookup(ctx, host)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.entries[host]
+	e := &entry{addrs: addrs, resolved: now, fresh: now}
+	if previous != nil {
+		e.next = atomic.LoadUint32(&previous.next)
+	}
+	if err != nil {
+		e.addrs, e.fresh, e.err = nil, time.Time{}, err
+		if previous != nil {
+			e.fresh = previous.fresh
+			if len(previous.addrs) > 0 && now.Sub(previous.fresh) < c.maxStale {
+				log.Printf("DNS refresh failed for %s, serving %d stale addresses: %v", host, len(previous.addrs), err)
+				e.addrs = previous.addrs
+			} else if len(previous.addrs) > 0 {
+				log.Printf("DNS refresh failed for %s and its addresses are older than %s, dropping them: %v", host, c.maxStale, err)
+			}
+		}
+	}
+	c.entries[host] = e
+	return e
+}
+
+// DialContext dials addr using cached addresses, rotating the starting address on every dial
+// and falling through to the next address when one fails
+func (c *Cache) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := c.Lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	e := c.entries[host]
+	c.mu.RUnlock()
+
+	start := 0
+	if e != nil {
+		start = int(atomic.AddUint32(&e.next, 1)) % len(addrs)
+	}
+
+	var lastErr error
+	for i := 0; i < len(addrs); i++ {
+		ip := addrs[(start+i)%len(addrs)]
+		conn, err := c.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("all %d addresses for %s failed: %w", len(addrs), host, lastErr)
+}
@@ -0,0 +1,124 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLookup answers with addrs until failing is set
+type fakeLookup struct {
+	mu      sync.Mutex
+	addrs   []string
+	failing bool
+}
+
+func (f *fakeLookup) lookup(ctx context.Context, host string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing {
+		return nil, errors.New("no such host")
+	}
+	return f.addrs, nil
+}
+
+func (f *fakeLookup) fail() {
+	f.mu.Lock()
+	f.failing = true
+	f.mu.Unlock()
+}
+
+func newTestCache(maxStale time.Duration, f *fakeLookup) *Cache {
+	c := New(time.Hour, maxStale, &net.Dialer{})
+	c.lookup = f.lookup
+	return c
+}
+
+func TestRefreshServesStaleAddresses(t *testing.T) {
+	f := &fakeLookup{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+	c := newTestCache(time.Hour, f)
+	ctx := context.Background()
+
+	if addrs, err := c.Lookup(ctx, "node.example"); err != nil || len(addrs) != 2 {
+		t.Fatalf("Lookup = %v, %v, want two addresses", addrs, err)
+	}
+
+	f.fail()
+	if e := c.refresh(ctx, "node.example"); len(e.addrs) != 2 || e.err == nil {
+		t.Fatalf("failed refresh kept %v (err %v), want the two stale addresses and the error", e.addrs, e.err)
+	}
+	if addrs, err := c.Lookup(ctx, "node.example"); err != nil || len(addrs) != 2 {
+		t.Errorf("Lookup after a failed refresh = %v, %v, want the stale addresses", addrs, err)
+	}
+}
+
+func TestRefreshDropsAddressesPastMaxStale(t *testing.T) {
+	f := &fakeLookup{addrs: []string{"10.0.0.1"}}
+	c := newTestCache(time.Minute, f)
+	ctx := context.Background()
+
+	c.refresh(ctx, "node.example")
+	// Age the last successful resolution past maxStale
+	c.mu.Lock()
+	old := *c.entries["node.example"]
+	old.fresh = time.Now().Add(-2 * time.Minute)
+	c.entries["node.example"] = &old
+	c.mu.Unlock()
+
+	f.fail()
+	for i := 0; i < 3; i++ {
+		if e := c.refresh(ctx, "node.example"); len(e.addrs) != 0 || e.err == nil {
+			t.Fatalf("refresh %d kept %v, want the stale addresses dropped", i, e.addrs)
+		}
+	}
+}
+
+func TestRefreshKeepsLastSuccess(t *testing.T) {
+	f := &fakeLookup{addrs: []string{"10.0.0.1"}}
+	c := newTestCache(time.Minute, f)
+	ctx := context.Background()
+
+	first := c.refresh(ctx, "node.example")
+	f.fail()
+	// Repeated failures must not move the last success forward, or stale addresses would be
+	// served forever
+	for i := 0; i < 3; i++ {
+		e := c.refresh(ctx, "node.example")
+		if !e.fresh.Equal(first.fresh) {
+			t.Fatalf("refresh %d moved the last success from %v to %v", i, first.fresh, e.fresh)
+		}
+		if e == first {
+			t.Fatal("a failed refresh modified the stored entry in place")
+		}
+	}
+}
+
+func TestLookupConcurrentWithRefresh(t *testing.T) {
+	f := &fakeLookup{addrs: []string{"10.0.0.1"}}
+	c := newTestCache(time.Hour, f)
+	c.ttl = 0 // every Lookup resolves, racing with the refreshes below
+	ctx := context.Background()
+	c.refresh(ctx, "node.example")
+	f.fail()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Lookup(ctx, "node.example")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.refresh(ctx, "node.example")
+			}
+		}()
+	}
+	wg.Wait()
+}
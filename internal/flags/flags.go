@@ -0,0 +1,219 @@
+// Package flags gates experimental subsystems behind feature flags, which are stored in the
+// settings table as feature_<name> so each deployment can toggle them without a redeploy.
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rpc-proxy/internal/repository"
+)
+
+// SettingPrefix starts the settings keys of feature flags: feature_<name>
+const SettingPrefix = "feature_"
+
+// ErrNoDatabase is returned when changing a flag without a settings table to store it in
+var ErrNoDatabase = errors.New("feature flags need a database")
+
+// Flag describes a feature flag
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Default applies while the flag has no setting
+	Default bool `json:"default"`
+}
+
+// known lists the flags, in the order they are listed. An experimental subsystem adds its flag
+// here when it is built and checks it with Store.Enabled; no subsystem is gated yet.
+var known = []Flag{}
+
+// Lookup returns the flag of a name
+func Lookup(name string) (Flag, bool) {
+	for _, flag := range known {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// SettingKey is the settings key holding a flag
+func SettingKey(name string) string {
+	return SettingPrefix + name
+}
+
+// State is a flag with its current value
+type State struct {
+	Flag
+	Enabled bool `json:"enabled"`
+	// Overridden is set when the value comes from the settings table rather than the default
+	Overridden bool `json:"overridden"`
+}
+
+// Store reads flags from the settings table and caches them for ttl, so checking a flag on the
+// request path costs no query. An expired cache is refreshed in the background while the
+// previous values keep being served. Without a settings repository every flag has its default.
+type Store struct {
+	repo repository.SettingsRepository
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	values   map[string]bool
+	loadedAt time.Time
+
+	refreshing atomic.Bool
+}
+
+// NewStore returns a store over the settings table; repo may be nil
+func NewStore(repo repository.SettingsRepository, ttl time.Duration) *Store {
+	s := &Store{repo: repo, ttl: ttl, values: make(map[string]bool)}
+	if repo != nil {
+		if err := s.Refresh(); err != nil {
+			log.Printf("Warning: Failed to load feature flags: %v", err)
+		}
+	}
+	return s
+}
+
+// Enabled reports whether the flag of a name is on. Unknown flags are off.
+func (s *Store) Enabled(name string) bool {
+	if s == nil {
+		return false
+	}
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	enabled, overridden := s.values[name]
+	s.mu.RUnlock()
+	if overridden {
+		return enabled
+	}
+	flag, _ := Lookup(name)
+	return flag.Default
+}
+
+// All returns every flag with its current value
+func (s *Store) All() []State {
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make([]State, 0, len(known))
+	for _, flag := range known {
+		enabled, overridden := s.values[flag.Name]
+		if !overridden {
+			enabled = flag.Default
+		}
+		states = append(states, State{Flag: flag, Enabled: enabled, Overridden: overridden})
+	}
+	return states
+}
+
+// Set stores a flag's value; it applies here at once and on other replicas within the ttl
+func (s *Store) Set(name string, enabled bool) error {
+	flag, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown feature flag %s", name)
+	}
+	if s.repo == nil {
+		return ErrNoDatabase
+	}
+	if err := s.repo.Set(SettingKey(name), strconv.FormatBool(enabled), "Feature flag: "+flag.Description); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.values[name] = enabled
+	s.mu.Unlock()
+	return nil
+}
+
+// Reset deletes a flag's setting, returning it to its default
+func (s *Store) Reset(name string) error {
+	if _, ok := Lookup(name); !ok {
+		return fmt.Errorf("unknown feature flag %s", name)
+	}
+	if s.repo == nil {
+		return ErrNoDatabase
+	}
+	settings, err := s.repo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	if _, exists := settings[SettingKey(name)]; exists {
+		if err := s.repo.Delete(SettingKey(name)); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.values, name)
+	s.mu.Unlock()
+	return nil
+}
+
+// Refresh reloads the flags from the settings table. Values that aren't booleans are ignored,
+// leaving the flag at its default.
+func (s *Store) Refresh() error {
+	if s.repo == nil {
+		return nil
+	}
+	settings, err := s.repo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	values := make(map[string]bool)
+	for key, value := range settings {
+		name, ok := strings.CutPrefix(key, SettingPrefix)
+		if !ok {
+			continue
+		}
+		if _, known := Lookup(name); !known {
+			continue
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			log.Printf("Ignoring feature flag setting %s: %q is not a boolean", key, value)
+			continue
+		}
+		values[name] = enabled
+	}
+
+	s.mu.Lock()
+	s.values = values
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// refreshIfStale starts a background refresh once the cache is older than the ttl
+func (s *Store) refreshIfStale() {
+	if s.repo == nil {
+		return
+	}
+	s.mu.RLock()
+	stale := time.Since(s.loadedAt) >= s.ttl
+	s.mu.RUnlock()
+	if !stale || !s.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.refreshing.Store(false)
+		if err := s.Refresh(); err != nil {
+			log.Printf("Feature flag refresh failed: %v", err)
+			// Retry after another ttl rather than on every check
+			s.mu.Lock()
+			s.loadedAt = time.Now()
+			s.mu.Unlock()
+		}
+	}()
+}
@@ -3,11 +3,14 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"rpc-proxy/internal/config"
 	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/jobs"
 	"rpc-proxy/internal/repository"
 	"rpc-proxy/internal/repository/gorm"
 )
@@ -17,6 +20,7 @@ type AdminHandler struct {
 	rpcRepo      repository.RPCEndpointRepository
 	settingsRepo repository.SettingsRepository
 	healthRepo   repository.HealthCheckRepository
+	settingsJob  *jobs.SettingsJob
 }
 
 func NewAdminHandler(db *database.GormDB) *AdminHandler {
@@ -111,6 +115,11 @@ func (h *AdminHandler) createEndpoint(w http.ResponseWriter, r *http.Request) {
 		req.Weight = 1
 	}
 
+	if req.ChainID == 0 {
+		http.Error(w, "chainId is required", http.StatusBadRequest)
+		return
+	}
+
 	endpoint, err := h.rpcRepo.Create(&req)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create endpoint: %v", err), http.StatusInternalServerError)
@@ -208,6 +217,11 @@ func (h *AdminHandler) getSetting(w http.ResponseWriter, r *http.Request, key st
 	})
 }
 
+// SetSettingsJob applies setting updates to the running proxy as soon as they are saved
+func (h *AdminHandler) SetSettingsJob(job *jobs.SettingsJob) {
+	h.settingsJob = job
+}
+
 func (h *AdminHandler) updateSetting(w http.ResponseWriter, r *http.Request, key string) {
 	var req struct {
 		Value       string `json:"value"`
@@ -218,11 +232,22 @@ func (h *AdminHandler) updateSetting(w http.ResponseWriter, r *http.Request, key
 		return
 	}
 
+	if err := config.ValidateSetting(key, req.Value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err := h.settingsRepo.Set(key, req.Value, req.Description); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update setting: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	if h.settingsJob != nil {
+		if _, err := h.settingsJob.Run(); err != nil {
+			log.Printf("Setting %s saved but not applied: %v", key, err)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"key":   key,
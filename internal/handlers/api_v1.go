@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	apiV1Prefix = "/api/v1"
+	adminPrefix = "/admin"
+)
+
+// apiEnvelope is the response body of every /api/v1 route; exactly one of Data and Error is set
+type apiEnvelope struct {
+	Data  interface{} `json:"data"`
+	Error *apiError   `json:"error"`
+	Meta  apiMeta     `json:"meta"`
+}
+
+type apiError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+type apiMeta struct {
+	APIVersion string    `json:"apiVersion"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// APIv1 serves the admin routes registered on adminMux under /api/v1 and wraps their
+// responses in an apiEnvelope. Non-JSON success bodies (such as a YAML export) pass through.
+func APIv1(adminMux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner := r.Clone(r.Context())
+		inner.URL.Path = adminPrefix + strings.TrimPrefix(r.URL.Path, apiV1Prefix)
+		inner.URL.RawPath = ""
+
+		rec := newResponseRecorder()
+		adminMux.ServeHTTP(rec, inner)
+
+		for key, values := range rec.header {
+			if key == "Content-Length" {
+				continue
+			}
+			w.Header()[key] = values
+		}
+
+		if rec.status == http.StatusNoContent {
+			w.WriteHeader(rec.status)
+			return
+		}
+
+		contentType := rec.header.Get("Content-Type")
+		if rec.status < 400 && !strings.HasPrefix(contentType, "application/json") {
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+			return
+		}
+
+		envelope := apiEnvelope{
+			Meta: apiMeta{APIVersion: "v1", Timestamp: time.Now().UTC()},
+		}
+		if rec.status >= 400 {
+			envelope.Error = &apiError{
+				Status:  rec.status,
+				Message: strings.TrimSpace(rec.body.String()),
+			}
+		} else if body := bytes.TrimSpace(rec.body.Bytes()); json.Valid(body) {
+			envelope.Data = json.RawMessage(body)
+		}
+
+		w.Header().Del("X-Content-Type-Options")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rec.status)
+		json.NewEncoder(w).Encode(envelope)
+	})
+}
+
+// Deprecated marks responses from the legacy /admin paths as deprecated and points clients
+// at the equivalent /api/v1 path
+func Deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		successor := apiV1Prefix + strings.TrimPrefix(r.URL.Path, adminPrefix)
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// responseRecorder buffers a handler's response so it can be rewritten before sending
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wrote {
+		return
+	}
+	rec.status = status
+	rec.wrote = true
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wrote = true
+	return rec.body.Write(b)
+}
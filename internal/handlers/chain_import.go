@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+// chainlistFetchTimeout bounds the download of the chain dataset, a few megabytes of JSON
+const chainlistFetchTimeout = 30 * time.Second
+
+// chainlistEntry is the subset of a chainid.network chains.json entry that import uses
+type chainlistEntry struct {
+	Name           string   `json:"name"`
+	ShortName      string   `json:"shortName"`
+	ChainID        int      `json:"chainId"`
+	RPC            []string `json:"rpc"`
+	Slip44         int      `json:"slip44"`
+	NativeCurrency struct {
+		Symbol   string `json:"symbol"`
+		Decimals int    `json:"decimals"`
+	} `json:"nativeCurrency"`
+	Explorers []struct {
+		URL string `json:"url"`
+	} `json:"explorers"`
+}
+
+// handleChainImport handles POST /admin/chains/import/{chainId}. It looks the chain up in the
+// chainid.network dataset, creates it with the dataset's public RPC URLs as disabled candidate
+// endpoints, and returns both for review. An optional body {"name": ..., "rpcPath": ...}
+// overrides the name and path derived from the dataset's short name.
+func (h *MultiChainAdminHandler) handleChainImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	chainID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/admin/chains/import/"))
+	if err != nil || chainID <= 0 {
+		http.Error(w, "Invalid chain ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Name    string `json:"name"`
+		RPCPath string `json:"rpcPath"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entry, err := fetchChainlistEntry(r.Context(), h.config.Admin.ChainlistURL, chainID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch chain dataset: %v", err), http.StatusBadGateway)
+		return
+	}
+	if entry == nil {
+		http.Error(w, fmt.Sprintf("Chain ID %d not found in chain dataset", chainID), http.StatusNotFound)
+		return
+	}
+
+	chain := entry.chain()
+	if req.Name != "" {
+		chain.Name = req.Name
+	}
+	chain.RPCPath = chain.Name
+	if req.RPCPath != "" {
+		chain.RPCPath = req.RPCPath
+	}
+
+	if err := h.validateChain(chain, ""); err != nil {
+		// A clashing name is fixable by passing another one; a clashing chain ID is not
+		status := http.StatusBadRequest
+		if h.chainIDInUse(chain.ChainID) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if _, err := h.chainRepo.GetDeletedByName(chain.Name); err == nil {
+		http.Error(w, fmt.Sprintf("Chain %s is in the trash; restore it or delete it permanently first", chain.Name), http.StatusConflict)
+		return
+	}
+
+	if err := h.chainRepo.Create(chain); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.config.AddChain(chain)
+	h.multiChainHealthChecker.AddChain(chain.Name, &health.ChainConfig{
+		Chain:     chain,
+		Endpoints: []*types.RPCEndpoint{},
+		Configs:   make(map[string]string),
+	})
+
+	// Candidates stay disabled, so nothing is health checked or routed until an operator
+	// enables the ones worth keeping
+	endpoints := make([]*types.RPCEndpoint, 0, len(entry.RPC))
+	for _, rpcURL := range entry.candidateURLs() {
+		endpoint, err := h.endpointRepo.Create(&repository.CreateRPCEndpointRequest{
+			Name:    defaultImportedEndpointName(rpcURL),
+			URL:     rpcURL,
+			Weight:  1,
+			Enabled: false,
+			ChainID: chain.ID,
+		})
+		if err != nil {
+			log.Printf("Failed to import endpoint %s for chain %s: %v", rpcURL, chain.Name, err)
+			continue
+		}
+		endpoint.ChainName = chain.Name
+		endpoints = append(endpoints, endpoint)
+	}
+	log.Printf("Imported chain %s (chain ID %d) with %d candidate endpoints", chain.Name, chain.ChainID, len(endpoints))
+
+	response := map[string]interface{}{
+		"chain":     chain,
+		"endpoints": endpoints,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MultiChainAdminHandler) chainIDInUse(chainID int) bool {
+	for _, chain := range h.config.GetChains() {
+		if chain.ChainID == chainID {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchChainlistEntry downloads the chain dataset and returns the entry for chainID, or nil
+func fetchChainlistEntry(ctx context.Context, datasetURL string, chainID int) (*chainlistEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, chainlistFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", datasetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", datasetURL, resp.Status)
+	}
+
+	var entries []chainlistEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid chain dataset: %w", err)
+	}
+
+	for i := range entries {
+		if entries[i].ChainID == chainID {
+			return &entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// chain converts the entry to a chain named after its short name
+func (e *chainlistEntry) chain() *types.Chain {
+	chain := &types.Chain{
+		ChainID:                e.ChainID,
+		Name:                   chainNameFromShortName(e.ShortName),
+		DisplayName:            e.Name,
+		IsTestnet:              e.Slip44 == 1 || strings.Contains(strings.ToLower(e.Name), "testnet"),
+		IsEnabled:              true,
+		NativeCurrencySymbol:   e.NativeCurrency.Symbol,
+		NativeCurrencyDecimals: e.NativeCurrency.Decimals,
+	}
+	if chain.Name == "" {
+		chain.Name = fmt.Sprintf("chain-%d", e.ChainID)
+	}
+	if chain.NativeCurrencySymbol == "" {
+		chain.NativeCurrencySymbol = "ETH"
+	}
+	if chain.NativeCurrencyDecimals == 0 {
+		chain.NativeCurrencyDecimals = 18
+	}
+	if len(e.Explorers) > 0 {
+		chain.BlockExplorerURL = e.Explorers[0].URL
+	}
+	return chain
+}
+
+// candidateURLs returns the entry's public HTTP(S) RPC URLs, skipping WebSocket URLs and ones
+// needing an API key (the dataset marks those with ${...} placeholders)
+func (e *chainlistEntry) candidateURLs() []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, rpcURL := range e.RPC {
+		if strings.Contains(rpcURL, "${") || seen[rpcURL] {
+			continue
+		}
+		if err := validateEndpoint("candidate", rpcURL, 1); err != nil {
+			continue
+		}
+		if !strings.HasPrefix(rpcURL, "http://") && !strings.HasPrefix(rpcURL, "https://") {
+			continue
+		}
+		seen[rpcURL] = true
+		urls = append(urls, rpcURL)
+	}
+	return urls
+}
+
+// chainNameFromShortName turns a dataset short name such as "arb1" or "base-sep" into a valid
+// chain name
+func chainNameFromShortName(shortName string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(shortName) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// defaultImportedEndpointName names a candidate endpoint after its host
+func defaultImportedEndpointName(rpcURL string) string {
+	if u, err := url.Parse(rpcURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rpcURL
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"rpc-proxy/internal/cluster"
+)
+
+// SetCluster enables GET /admin/cluster
+func (h *MultiChainAdminHandler) SetCluster(instance *cluster.Instance) {
+	h.cluster = instance
+}
+
+// handleCluster lists the registered proxy instances with their health and traffic, and totals
+// across the live ones
+func (h *MultiChainAdminHandler) handleCluster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.cluster == nil {
+		http.Error(w, "Cluster status requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	status, err := h.cluster.Status()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get cluster status: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxConfigDocumentSize bounds the body accepted by POST /admin/import
+const maxConfigDocumentSize = 10 << 20
+
+// handleExport handles GET /admin/export; ?format=yaml (or an Accept header naming yaml) selects YAML
+func (h *MultiChainAdminHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.configDocRepo == nil {
+		http.Error(w, "Configuration export requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	doc, err := h.configDocRepo.Export()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to export configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if wantsYAML(r.URL.Query().Get("format"), r.Header.Get("Accept")) {
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to encode configuration: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(out)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
+
+// handleImport handles POST /admin/import. The document is applied in one transaction;
+// ?dry_run=true reports the changes without keeping them and ?prune=true also deletes
+// chains, endpoints and chain configs the document does not mention.
+func (h *MultiChainAdminHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.configDocRepo == nil {
+		http.Error(w, "Configuration import requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	var doc repository.ConfigDocument
+	body := http.MaxBytesReader(w, r.Body, maxConfigDocumentSize)
+	var err error
+	if wantsYAML(r.URL.Query().Get("format"), r.Header.Get("Content-Type")) {
+		err = yaml.NewDecoder(body).Decode(&doc)
+	} else {
+		err = json.NewDecoder(body).Decode(&doc)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid configuration document: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateConfigDocument(&doc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := repository.ImportOptions{
+		DryRun: r.URL.Query().Get("dry_run") == "true",
+		Prune:  r.URL.Query().Get("prune") == "true",
+	}
+
+	changes, err := h.configDocRepo.Import(&doc, opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to import configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"dryRun":  opts.DryRun,
+		"changes": changes,
+		"total":   len(changes),
+	}
+
+	if !opts.DryRun {
+		log.Printf("Imported configuration document: %d changes", len(changes))
+
+		// Apply the imported tree to the running proxy the same way POST /admin/reload does
+		if h.reloadJob != nil && len(changes) > 0 {
+			if result, err := h.reloadJob.Reload(); err != nil {
+				log.Printf("Configuration imported but reload failed: %v", err)
+				response["reloadError"] = err.Error()
+			} else {
+				response["reload"] = result
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// validateConfigDocument checks a document before anything is written. Defaults are filled
+// in place: chain type falls back to evm, display name and RPC path to the chain name,
+// endpoint weight to 1 and endpoint transport to the one its URL implies.
+func validateConfigDocument(doc *repository.ConfigDocument) error {
+	names := make(map[string]bool, len(doc.Chains))
+	paths := make(map[string]bool, len(doc.Chains))
+	chainIDs := make(map[int]bool, len(doc.Chains))
+
+	for i := range doc.Chains {
+		chain := &doc.Chains[i]
+		if chain.DisplayName == "" {
+			chain.DisplayName = chain.Name
+		}
+		if chain.RPCPath == "" {
+			chain.RPCPath = chain.Name
+		}
+
+		if !chainNamePattern.MatchString(chain.Name) {
+			return fmt.Errorf("chain %d: name must be non-empty and contain only letters, digits and hyphens", i)
+		}
+		if !chainNamePattern.MatchString(chain.RPCPath) {
+			return fmt.Errorf("chain %s: rpc path must contain only letters, digits and hyphens", chain.Name)
+		}
+		if chain.ChainID <= 0 {
+			return fmt.Errorf("chain %s: chain ID must be positive", chain.Name)
+		}
+		if chain.Type == "" {
+			chain.Type = types.ChainTypeEVM
+		}
+		if err := config.ValidateChainType(chain.Type); err != nil {
+			return fmt.Errorf("chain %s: %w", chain.Name, err)
+		}
+		switch {
+		case names[chain.Name]:
+			return fmt.Errorf("chain %s appears more than once", chain.Name)
+		case paths[chain.RPCPath]:
+			return fmt.Errorf("chain %s: rpc path %s is used by another chain", chain.Name, chain.RPCPath)
+		case chainIDs[chain.ChainID]:
+			return fmt.Errorf("chain %s: chain ID %d is used by another chain", chain.Name, chain.ChainID)
+		}
+		names[chain.Name] = true
+		paths[chain.RPCPath] = true
+		chainIDs[chain.ChainID] = true
+
+		for key, value := range chain.Configs {
+			if err := config.ValidateChainConfig(key, value); err != nil {
+				return fmt.Errorf("chain %s: %w", chain.Name, err)
+			}
+		}
+
+		urls := make(map[string]bool, len(chain.Endpoints))
+		for j := range chain.Endpoints {
+			endpoint := &chain.Endpoints[j]
+			if endpoint.Weight == 0 {
+				endpoint.Weight = 1
+			}
+			if endpoint.Transport == "" {
+				endpoint.Transport = types.TransportForURL(endpoint.URL)
+			}
+			if err := validateEndpoint(endpoint.Name, endpoint.URL, endpoint.Weight); err != nil {
+				return fmt.Errorf("chain %s: %w", chain.Name, err)
+			}
+			if err := config.ValidateEndpointTransport(chain.Type, endpoint.URL, endpoint.Transport, endpoint.WSURL); err != nil {
+				return fmt.Errorf("chain %s: endpoint %s: %w", chain.Name, endpoint.URL, err)
+			}
+			if err := config.ValidateGraphQLURL(chain.Type, endpoint.URL, endpoint.Transport, endpoint.GraphQLURL); err != nil {
+				return fmt.Errorf("chain %s: endpoint %s: %w", chain.Name, endpoint.URL, err)
+			}
+			if err := config.ValidateCapabilities(chain.Type, endpoint.Capabilities); err != nil {
+				return fmt.Errorf("chain %s: endpoint %s: %w", chain.Name, endpoint.URL, err)
+			}
+			if urls[endpoint.URL] {
+				return fmt.Errorf("chain %s: endpoint %s appears more than once", chain.Name, endpoint.URL)
+			}
+			urls[endpoint.URL] = true
+		}
+	}
+
+	for key, value := range doc.Settings {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("setting keys must be non-empty")
+		}
+		if err := config.ValidateSetting(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// wantsYAML reports whether an explicit format or a media type header asks for YAML
+func wantsYAML(format, mediaType string) bool {
+	if format != "" {
+		return strings.EqualFold(format, "yaml") || strings.EqualFold(format, "yml")
+	}
+	return strings.Contains(strings.ToLower(mediaType), "yaml")
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"rpc-proxy/internal/transport"
+)
+
+// endpointConnStats is one endpoint's upstream connection statistics
+type endpointConnStats struct {
+	Chain      string `json:"chain"`
+	EndpointID int    `json:"endpointId"`
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	transport.ConnStats
+}
+
+// SetUpstreamTransport enables GET /admin/analytics/connections
+func (h *MultiChainAdminHandler) SetUpstreamTransport(t *transport.Transport) {
+	h.upstreamTransport = t
+}
+
+// handleConnectionAnalytics reports how each endpoint's requests got their connections: reuse,
+// negotiated protocol and time spent dialing and in TLS handshakes. ?chain= narrows it to one
+// chain; endpoints not yet called are left out.
+func (h *MultiChainAdminHandler) handleConnectionAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.upstreamTransport == nil {
+		http.Error(w, "Connection analytics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	chains := h.multiChainHealthChecker.GetSupportedChains()
+	if chainName := r.URL.Query().Get("chain"); chainName != "" {
+		if h.multiChainHealthChecker.GetAllEndpoints(chainName) == nil {
+			http.Error(w, "Chain not found", http.StatusNotFound)
+			return
+		}
+		chains = []string{chainName}
+	}
+
+	endpoints := []endpointConnStats{}
+	for _, chainName := range chains {
+		for _, endpoint := range h.multiChainHealthChecker.GetAllEndpoints(chainName) {
+			stats, ok := h.upstreamTransport.Stats(endpoint.URL)
+			if !ok {
+				continue
+			}
+			endpoints = append(endpoints, endpointConnStats{
+				Chain:      chainName,
+				EndpointID: endpoint.ID,
+				Name:       endpoint.Name,
+				URL:        endpoint.URL,
+				ConnStats:  stats,
+			})
+		}
+	}
+
+	response := map[string]interface{}{
+		"endpoints": endpoints,
+		"total":     len(endpoints),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"rpc-proxy/internal/flags"
+)
+
+// SetFlags enables GET /admin/flags and PUT and DELETE /admin/flags/{name}
+func (h *MultiChainAdminHandler) SetFlags(store *flags.Store) {
+	h.flags = store
+}
+
+// handleFlags handles GET /admin/flags: every feature flag with its current value
+func (h *MultiChainAdminHandler) handleFlags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.flags == nil {
+		http.Error(w, "Feature flags not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	states := h.flags.All()
+	response := map[string]interface{}{
+		"flags": states,
+		"total": len(states),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleFlag handles PUT /admin/flags/{name}, which turns a flag on or off, and DELETE, which
+// returns it to its default
+func (h *MultiChainAdminHandler) handleFlag(w http.ResponseWriter, r *http.Request) {
+	if h.flags == nil {
+		http.Error(w, "Feature flags not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/admin/flags/")
+	if _, ok := flags.Lookup(name); !ok {
+		http.Error(w, fmt.Sprintf("Feature flag %s not found", name), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "PUT":
+		var req struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if req.Enabled == nil {
+			http.Error(w, "enabled is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.flags.Set(name, *req.Enabled); err != nil {
+			writeFlagError(w, "set", err)
+			return
+		}
+		log.Printf("Feature flag %s set to %t from the admin API", name, *req.Enabled)
+	case "DELETE":
+		if err := h.flags.Reset(name); err != nil {
+			writeFlagError(w, "reset", err)
+			return
+		}
+		log.Printf("Feature flag %s reset from the admin API", name)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	for _, state := range h.flags.All() {
+		if state.Name == name {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(state)
+			return
+		}
+	}
+}
+
+// writeFlagError answers a failed flag change: 503 without a database to store flags in
+func writeFlagError(w http.ResponseWriter, action string, err error) {
+	if errors.Is(err, flags.ErrNoDatabase) {
+		http.Error(w, "Feature flags need a database", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Failed to %s feature flag: %v", action, err), http.StatusInternalServerError)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"rpc-proxy/internal/scheduler"
+)
+
+// SetScheduler enables GET /admin/jobs and POST /admin/jobs/{name}/run
+func (h *MultiChainAdminHandler) SetScheduler(s *scheduler.Scheduler) {
+	h.scheduler = s
+}
+
+// handleJobs handles GET /admin/jobs: every scheduled task with its schedule and last run
+func (h *MultiChainAdminHandler) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.scheduler == nil {
+		http.Error(w, "Job scheduler not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	jobs := h.scheduler.Status()
+	response := map[string]interface{}{
+		"jobs":  jobs,
+		"total": len(jobs),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleJob handles POST /admin/jobs/{name}/run, which starts a task now, enabled or not
+func (h *MultiChainAdminHandler) handleJob(w http.ResponseWriter, r *http.Request) {
+	if h.scheduler == nil {
+		http.Error(w, "Job scheduler not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/jobs/"), "/")
+	if action != "run" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.scheduler.Has(name) {
+		http.Error(w, fmt.Sprintf("Job %s not found", name), http.StatusNotFound)
+		return
+	}
+	if err := h.scheduler.RunNow(name); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Printf("Job %s started from the admin API", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":    name,
+		"started": true,
+	})
+}
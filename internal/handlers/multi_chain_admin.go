@@ -5,26 +5,113 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/app"
+	"rpc-proxy/internal/cluster"
 	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/flags"
 	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/jobs"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/repository/gorm"
+	"rpc-proxy/internal/scheduler"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/transport"
 	"rpc-proxy/internal/types"
 )
 
+// chainNamePattern matches names routable under /rpc/{chainName}
+var chainNamePattern = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
 // MultiChainAdminHandler handles multi-chain administration endpoints
 type MultiChainAdminHandler struct {
 	config                  *config.Config
 	multiChainHealthChecker *health.MultiChainChecker
+
+	// Repositories are nil when running on the fallback configuration without a database
+	chainRepo        repository.ChainRepository
+	endpointRepo     repository.RPCEndpointRepository
+	chainConfigRepo  repository.ChainConfigRepository
+	maintenanceRepo  repository.MaintenanceWindowRepository
+	configDocRepo    repository.ConfigDocumentRepository
+	methodUsageRepo  repository.MethodUsageRepository
+	healthRollupRepo repository.HealthRollupRepository
+	requestLogRepo   repository.RequestLogRepository
+	revisionRepo     repository.ConfigRevisionRepository
+	tenantRepo       repository.TenantRepository
+	apiKeyRepo       repository.APIKeyRepository
+	usageRepo        repository.APIKeyUsageRepository
+	planRepo         repository.PlanRepository
+
+	// revisionMu serializes RecordRevision
+	revisionMu sync.Mutex
+
+	reloadJob     *jobs.ReloadJob
+	settingsJob   *jobs.SettingsJob
+	scheduler     *scheduler.Scheduler
+	flags         *flags.Store
+	methodTracker *analytics.MethodTracker
+	clientTracker *analytics.ClientTracker
+	refusals      *analytics.RefusalTracker
+
+	upstreamTransport *transport.Transport
+	tenants           *tenant.Registry
+	cluster           *cluster.Instance
 }
 
-// NewMultiChainAdminHandler creates a new multi-chain admin handler
-func NewMultiChainAdminHandler(cfg *config.Config, healthChecker *health.MultiChainChecker) *MultiChainAdminHandler {
-	return &MultiChainAdminHandler{
+// NewMultiChainAdminHandler creates a new multi-chain admin handler; db may be nil, in which
+// case write operations are rejected. Chain writes are also rejected when chains come
+// from a file or a remote store.
+func NewMultiChainAdminHandler(cfg *config.Config, healthChecker *health.MultiChainChecker, db *database.GormDB) *MultiChainAdminHandler {
+	h := &MultiChainAdminHandler{
 		config:                  cfg,
 		multiChainHealthChecker: healthChecker,
 	}
+
+	if db != nil {
+		// With a chains file or remote store that source owns chain configuration, so chain
+		// writes stay disabled
+		if cfg.App.ChainsFile == "" && cfg.Remote.Backend == "" {
+			h.chainRepo = gorm.NewChainRepository(db)
+			h.endpointRepo = gorm.NewRPCEndpointRepository(db)
+			h.chainConfigRepo = gorm.NewChainConfigRepository(db)
+			h.configDocRepo = gorm.NewConfigDocumentRepository(db)
+			h.revisionRepo = gorm.NewConfigRevisionRepository(db)
+		}
+		h.maintenanceRepo = gorm.NewMaintenanceWindowRepository(db)
+		h.methodUsageRepo = gorm.NewMethodUsageRepository(db)
+		h.healthRollupRepo = gorm.NewHealthRollupRepository(db)
+		h.requestLogRepo = gorm.NewRequestLogRepository(db)
+		h.tenantRepo = gorm.NewTenantRepository(db)
+		h.apiKeyRepo = gorm.NewAPIKeyRepository(db)
+		h.usageRepo = gorm.NewAPIKeyUsageRepository(db)
+		h.planRepo = gorm.NewPlanRepository(db)
+	}
+
+	return h
+}
+
+// SetReloadJob enables POST /admin/reload
+func (h *MultiChainAdminHandler) SetReloadJob(job *jobs.ReloadJob) {
+	h.reloadJob = job
+}
+
+// SetMethodTracker enables GET /admin/analytics/methods
+func (h *MultiChainAdminHandler) SetMethodTracker(tracker *analytics.MethodTracker) {
+	h.methodTracker = tracker
+}
+
+// SetClientTracker enables GET /admin/analytics/clients
+func (h *MultiChainAdminHandler) SetClientTracker(tracker *analytics.ClientTracker) {
+	h.clientTracker = tracker
 }
 
 // RegisterRoutes registers all multi-chain admin routes
@@ -32,6 +119,8 @@ func (h *MultiChainAdminHandler) RegisterRoutes(mux *http.ServeMux) {
 	// Chain management endpoints
 	mux.HandleFunc("/admin/chains", h.handleChains)
 	mux.HandleFunc("/admin/chains/", h.handleChain)
+	mux.HandleFunc("/admin/chains/{chainName}/restore", h.handleChainRestore)
+	// POST /admin/chains/import/{chainId} is dispatched by handleChain
 	
 	// Chain endpoint management
 	mux.HandleFunc("/admin/chains/{chainName}/endpoints", h.handleChainEndpoints)
@@ -47,6 +136,49 @@ func (h *MultiChainAdminHandler) RegisterRoutes(mux *http.ServeMux) {
 	// Statistics and monitoring
 	mux.HandleFunc("/admin/stats", h.handleStats)
 	mux.HandleFunc("/admin/status", h.handleStatus)
+	mux.HandleFunc("/admin/cluster", h.handleCluster)
+	
+	// Configuration reload from the database
+	mux.HandleFunc("/admin/reload", h.handleReload)
+	
+	// Scheduled endpoint maintenance windows
+	mux.HandleFunc("/admin/maintenance", h.handleMaintenanceWindows)
+	mux.HandleFunc("/admin/maintenance/", h.handleMaintenanceWindow)
+	
+	// Bulk configuration export and import
+	mux.HandleFunc("/admin/export", h.handleExport)
+	mux.HandleFunc("/admin/import", h.handleImport)
+	
+	// Configuration change history and rollback
+	mux.HandleFunc("/admin/revisions", h.handleRevisions)
+	mux.HandleFunc("/admin/revisions/", h.handleRevision)
+	
+	// Scheduled maintenance jobs
+	mux.HandleFunc("/admin/jobs", h.handleJobs)
+	mux.HandleFunc("/admin/jobs/", h.handleJob)
+	
+	// Feature flags of experimental subsystems
+	mux.HandleFunc("/admin/flags", h.handleFlags)
+	mux.HandleFunc("/admin/flags/", h.handleFlag)
+	
+	// Tenants, their API keys and plans
+	mux.HandleFunc("/admin/tenants", h.handleTenants)
+	mux.HandleFunc("/admin/tenants/", h.handleTenant)
+	mux.HandleFunc("/admin/plans", h.handlePlans)
+	mux.HandleFunc("/admin/plans/", h.handlePlan)
+	mux.HandleFunc("/admin/usage", h.handleUsage)
+	mux.HandleFunc("/admin/usage/export", h.handleUsageExport)
+	
+	// Probe a candidate endpoint without adding it
+	mux.HandleFunc("/admin/validate-endpoint", h.handleValidateEndpoint)
+	
+	// Per-method usage analytics
+	mux.HandleFunc("/admin/analytics/methods", h.handleMethodAnalytics)
+	mux.HandleFunc("/admin/analytics/clients", h.handleClientAnalytics)
+	mux.HandleFunc("/admin/analytics/uptime", h.handleUptimeAnalytics)
+	mux.HandleFunc("/admin/analytics/connections", h.handleConnectionAnalytics)
+	mux.HandleFunc("/admin/analytics/refusals", h.handleRefusalAnalytics)
+	mux.HandleFunc("/admin/request-logs", h.handleRequestLogs)
 }
 
 // handleChains handles requests to /admin/chains
@@ -63,6 +195,12 @@ func (h *MultiChainAdminHandler) handleChains(w http.ResponseWriter, r *http.Req
 
 // handleChain handles requests to /admin/chains/{chainName}
 func (h *MultiChainAdminHandler) handleChain(w http.ResponseWriter, r *http.Request) {
+	// Routed here rather than registered, as a pattern for it would overlap .../{chainName}/restore
+	if strings.HasPrefix(r.URL.Path, "/admin/chains/import/") {
+		h.handleChainImport(w, r)
+		return
+	}
+
 	chainName := h.extractChainNameFromPath(r.URL.Path, "/admin/chains/")
 	if chainName == "" {
 		http.Error(w, "Invalid chain name", http.StatusBadRequest)
@@ -74,6 +212,8 @@ func (h *MultiChainAdminHandler) handleChain(w http.ResponseWriter, r *http.Requ
 		h.getChain(w, r, chainName)
 	case "PUT":
 		h.updateChain(w, r, chainName)
+	case "PATCH":
+		h.patchChain(w, r, chainName)
 	case "DELETE":
 		h.deleteChain(w, r, chainName)
 	default:
@@ -117,11 +257,41 @@ func (h *MultiChainAdminHandler) handleChainEndpoint(w http.ResponseWriter, r *h
 		return
 	}
 
+	// /admin/chains/{chainName}/endpoints/{endpointId}/override
+	if len(parts) == 6 && parts[5] == "override" {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.overrideChainEndpoint(w, r, chainName, endpointID)
+		return
+	}
+
+	// /admin/chains/{chainName}/endpoints/{endpointId}/restore
+	if len(parts) == 6 && parts[5] == "restore" {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.restoreChainEndpoint(w, r, chainName, endpointID)
+		return
+	}
+
+	// Discovered endpoints follow their source; they can be drained or overridden but not edited
+	if endpoint := h.multiChainHealthChecker.GetEndpoint(chainName, endpointID); endpoint != nil && endpoint.Discovered() &&
+		(r.Method == "PUT" || r.Method == "DELETE") {
+		http.Error(w, fmt.Sprintf("Endpoint %d was discovered from %s and can't be changed here", endpointID, endpoint.Source),
+			http.StatusConflict)
+		return
+	}
+
 	switch r.Method {
 	case "GET":
 		h.getChainEndpoint(w, r, chainName, endpointID)
 	case "PUT":
 		h.updateChainEndpoint(w, r, chainName, endpointID)
+	case "PATCH":
+		h.patchChainEndpoint(w, r, chainName, endpointID)
 	case "DELETE":
 		h.deleteChainEndpoint(w, r, chainName, endpointID)
 	default:
@@ -170,17 +340,41 @@ func (h *MultiChainAdminHandler) handleHealthOverview(w http.ResponseWriter, r *
 
 // handleChainHealthDetails provides detailed health information for a specific chain
 func (h *MultiChainAdminHandler) handleChainHealthDetails(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	chainName := h.extractChainNameFromPath(r.URL.Path, "/admin/health/")
 	if chainName == "" {
 		http.Error(w, "Invalid chain name", http.StatusBadRequest)
 		return
 	}
 
+	// On-demand checks: /admin/health/{chainName}/check and /admin/health/{chainName}/endpoints/{endpointId}/check
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/health/"), "/"), "/")
+	if len(parts) > 1 {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch {
+		case len(parts) == 2 && parts[1] == "check":
+			h.checkChainNow(w, r, chainName)
+		case len(parts) == 4 && parts[1] == "endpoints" && parts[3] == "check":
+			endpointID, err := strconv.Atoi(parts[2])
+			if err != nil {
+				http.Error(w, "Invalid endpoint ID", http.StatusBadRequest)
+				return
+			}
+			h.checkEndpointNow(w, r, chainName, endpointID)
+		default:
+			http.Error(w, "Invalid health check path", http.StatusNotFound)
+		}
+		return
+	}
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	status := h.multiChainHealthChecker.GetChainStatus(chainName)
 	if status == nil {
 		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
@@ -191,6 +385,37 @@ func (h *MultiChainAdminHandler) handleChainHealthDetails(w http.ResponseWriter,
 	json.NewEncoder(w).Encode(status)
 }
 
+// checkChainNow runs an immediate health check for every endpoint on a chain
+func (h *MultiChainAdminHandler) checkChainNow(w http.ResponseWriter, r *http.Request, chainName string) {
+	status, err := h.multiChainHealthChecker.CheckChainNow(chainName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// checkEndpointNow runs an immediate health check for a single endpoint
+func (h *MultiChainAdminHandler) checkEndpointNow(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	endpoint, err := h.multiChainHealthChecker.CheckEndpointNow(chainName, endpointID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"chain_name": chainName,
+		"healthy":    endpoint.IsHealthy(),
+		"available":  endpoint.IsAvailable(),
+		"endpoint":   endpoint,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // handleStats provides comprehensive statistics
 func (h *MultiChainAdminHandler) handleStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -198,13 +423,17 @@ func (h *MultiChainAdminHandler) handleStats(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	build := app.Build()
 	stats := map[string]interface{}{
 		"health_check": h.multiChainHealthChecker.GetHealthCheckStats(),
 		"supported_chains": h.multiChainHealthChecker.GetSupportedChains(),
 		"server_info": map[string]interface{}{
-			"version": "1.0.0",
-			"mode":    "multi-chain",
-			"uptime":  "calculated_uptime_placeholder",
+			"version":    build.Version,
+			"commit":     build.Commit,
+			"build_date": build.BuildDate,
+			"go_version": build.GoVersion,
+			"mode":       "multi-chain",
+			"uptime":     h.multiChainHealthChecker.Uptime().Round(time.Second).String(),
 		},
 	}
 
@@ -243,6 +472,10 @@ func (h *MultiChainAdminHandler) handleStatus(w http.ResponseWriter, r *http.Req
 		"degraded_chains":     totalChains - totalHealthyChains,
 		"chains":              chainStatuses,
 	}
+	// Replicas have converged on a configuration once they report the same epoch
+	if h.reloadJob != nil && h.reloadJob.Epoch() > 0 {
+		response["config_epoch"] = h.reloadJob.Epoch()
+	}
 
 	// Set appropriate HTTP status code
 	if overallStatus == "unhealthy" {
@@ -255,10 +488,199 @@ func (h *MultiChainAdminHandler) handleStatus(w http.ResponseWriter, r *http.Req
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleReload re-reads chains, endpoints and chain configs from the database and applies the differences
+func (h *MultiChainAdminHandler) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.reloadJob == nil {
+		http.Error(w, "Configuration reload requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := h.reloadJob.Reload()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to reload configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleValidateEndpoint probes {"url": ..., "chain": ...}, with optional "authUsername" and
+// "authPassword", with the standard health check and an eth_chainId check; nothing is persisted
+// and the endpoint is not added to routing
+func (h *MultiChainAdminHandler) handleValidateEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		URL          string `json:"url"`
+		Chain        string `json:"chain"`
+		AuthUsername string `json:"authUsername"`
+		AuthPassword string `json:"authPassword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateEndpoint("validation", req.URL, 1); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	candidate := &types.RPCEndpoint{URL: req.URL, AuthUsername: req.AuthUsername, AuthPassword: req.AuthPassword}
+	result, err := h.multiChainHealthChecker.ValidateEndpoint(candidate, req.Chain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleMethodAnalytics reports per-method request counts and latency since start, optionally
+// filtered by ?chain=; ?history=24h adds the hourly database rollups for that period
+func (h *MultiChainAdminHandler) handleMethodAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.methodTracker == nil {
+		http.Error(w, "Method analytics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	chainName := r.URL.Query().Get("chain")
+	methods := h.methodTracker.Usage(chainName)
+
+	response := map[string]interface{}{
+		"since":   h.methodTracker.Since(),
+		"methods": methods,
+		"total":   len(methods),
+	}
+
+	if historyStr := r.URL.Query().Get("history"); historyStr != "" {
+		history, err := time.ParseDuration(historyStr)
+		if err != nil || history <= 0 {
+			http.Error(w, "Invalid history duration", http.StatusBadRequest)
+			return
+		}
+		if h.methodUsageRepo == nil {
+			http.Error(w, "Method usage history requires a database", http.StatusServiceUnavailable)
+			return
+		}
+
+		rollups, err := h.methodUsageRepo.GetSince(chainName, time.Now().Add(-history).Truncate(time.Hour))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get method usage history: %v", err), http.StatusInternalServerError)
+			return
+		}
+		response["rollups"] = rollups
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleClientAnalytics reports the busiest clients over the rolling window. ?by=ip or ?by=key
+// groups on one field (default both) and ?limit= caps the list (default 20).
+func (h *MultiChainAdminHandler) handleClientAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.clientTracker == nil {
+		http.Error(w, "Client analytics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	groupBy := r.URL.Query().Get("by")
+	switch groupBy {
+	case "", "both", "ip", "key":
+	default:
+		http.Error(w, "by must be ip, key or both", http.StatusBadRequest)
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	clients := h.clientTracker.TopClients(groupBy, limit)
+
+	response := map[string]interface{}{
+		"window":  h.clientTracker.Window().String(),
+		"clients": clients,
+		"total":   len(clients),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleMaintenanceWindows handles requests to /admin/maintenance
+func (h *MultiChainAdminHandler) handleMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	if h.maintenanceRepo == nil {
+		http.Error(w, "Maintenance windows require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		h.listMaintenanceWindows(w, r)
+	case "POST":
+		h.createMaintenanceWindow(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMaintenanceWindow handles requests to /admin/maintenance/{windowId}
+func (h *MultiChainAdminHandler) handleMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if h.maintenanceRepo == nil {
+		http.Error(w, "Maintenance windows require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/admin/maintenance/"))
+	if err != nil {
+		http.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case "DELETE":
+		h.deleteMaintenanceWindow(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // Implementation methods
 
 func (h *MultiChainAdminHandler) listChains(w http.ResponseWriter, r *http.Request) {
-	chains := h.config.Chains
+	if wantsDeleted(r) {
+		h.listDeletedChains(w, r)
+		return
+	}
+
+	chains := h.config.GetChains()
 	
 	response := map[string]interface{}{
 		"chains": chains,
@@ -270,8 +692,56 @@ func (h *MultiChainAdminHandler) listChains(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *MultiChainAdminHandler) createChain(w http.ResponseWriter, r *http.Request) {
-	// Placeholder implementation
-	http.Error(w, "Chain creation not implemented yet", http.StatusNotImplemented)
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	var chain types.Chain
+	if err := json.NewDecoder(r.Body).Decode(&chain); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if chain.RPCPath == "" {
+		chain.RPCPath = chain.Name
+	}
+	if chain.DisplayName == "" {
+		chain.DisplayName = chain.Name
+	}
+	if chain.Type == "" {
+		chain.Type = types.ChainTypeEVM
+	}
+	chain.ID = 0
+	chain.IsEnabled = true
+
+	if err := h.validateChain(&chain, ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Trashed chains still hold their unique name in the database
+	if _, err := h.chainRepo.GetDeletedByName(chain.Name); err == nil {
+		http.Error(w, fmt.Sprintf("Chain %s is in the trash; restore it or delete it permanently first", chain.Name), http.StatusConflict)
+		return
+	}
+
+	if err := h.chainRepo.Create(&chain); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.config.AddChain(&chain)
+	h.multiChainHealthChecker.AddChain(chain.Name, &health.ChainConfig{
+		Chain:     &chain,
+		Endpoints: []*types.RPCEndpoint{},
+		Configs:   make(map[string]string),
+	})
+	log.Printf("Created chain %s (chain ID %d)", chain.Name, chain.ChainID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&chain)
 }
 
 func (h *MultiChainAdminHandler) getChain(w http.ResponseWriter, r *http.Request, chainName string) {
@@ -281,8 +751,8 @@ func (h *MultiChainAdminHandler) getChain(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	endpoints := h.config.ChainEndpoints[chainName]
-	configs := h.config.ChainConfigs[chainName]
+	endpoints := h.config.GetChainEndpoints(chainName)
+	configs := h.config.GetChainConfigs(chainName)
 
 	response := map[string]interface{}{
 		"chain":     chain,
@@ -295,78 +765,807 @@ func (h *MultiChainAdminHandler) getChain(w http.ResponseWriter, r *http.Request
 }
 
 func (h *MultiChainAdminHandler) updateChain(w http.ResponseWriter, r *http.Request, chainName string) {
-	// Placeholder implementation
-	http.Error(w, "Chain update not implemented yet", http.StatusNotImplemented)
-}
-
-func (h *MultiChainAdminHandler) deleteChain(w http.ResponseWriter, r *http.Request, chainName string) {
-	// Placeholder implementation
-	http.Error(w, "Chain deletion not implemented yet", http.StatusNotImplemented)
-}
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
 
-func (h *MultiChainAdminHandler) listChainEndpoints(w http.ResponseWriter, r *http.Request, chainName string) {
-	if !h.multiChainHealthChecker.IsChainSupported(chainName) {
+	existing := h.config.GetChainByName(chainName)
+	if existing == nil {
 		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
 		return
 	}
 
-	endpoints := h.multiChainHealthChecker.GetAllEndpointsForChain(chainName)
-	healthyEndpoints := h.multiChainHealthChecker.GetHealthyEndpointsForChain(chainName)
+	var req updateChainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
 
-	response := map[string]interface{}{
-		"chain_name":        chainName,
-		"total_endpoints":   len(endpoints),
-		"healthy_endpoints": len(healthyEndpoints),
-		"endpoints":         endpoints,
+	// Work on a copy so a failed validation or write leaves the running chain untouched
+	chain := *existing
+	req.applyTo(&chain)
+
+	if err := h.validateChain(&chain, chainName); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
+	if err := h.chainRepo.Update(&chain); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update chain: %v", err), http.StatusInternalServerError)
+		return
+	}
 
-func (h *MultiChainAdminHandler) createChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string) {
-	// Placeholder implementation
-	http.Error(w, "Endpoint creation not implemented yet", http.StatusNotImplemented)
-}
+	h.config.UpdateChain(chainName, &chain)
+	// Disabled chains are not in the health checker; they pick up the change when re-enabled
+	if chain.IsEnabled {
+		if err := h.multiChainHealthChecker.UpdateChain(chainName, &chain); err != nil {
+			log.Printf("Chain %s updated in database but not in health checker: %v", chainName, err)
+		}
+	}
+	log.Printf("Updated chain %s", chain.Name)
 
-func (h *MultiChainAdminHandler) getChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
-	// Placeholder implementation
-	http.Error(w, "Individual endpoint retrieval not implemented yet", http.StatusNotImplemented)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&chain)
 }
 
-func (h *MultiChainAdminHandler) updateChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
-	// Placeholder implementation
-	http.Error(w, "Endpoint update not implemented yet", http.StatusNotImplemented)
-}
+func (h *MultiChainAdminHandler) deleteChain(w http.ResponseWriter, r *http.Request, chainName string) {
+	if wantsPermanent(r) {
+		h.purgeChain(w, r, chainName)
+		return
+	}
 
-func (h *MultiChainAdminHandler) deleteChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
-	// Placeholder implementation
-	http.Error(w, "Endpoint deletion not implemented yet", http.StatusNotImplemented)
-}
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
 
-func (h *MultiChainAdminHandler) getChainConfig(w http.ResponseWriter, r *http.Request, chainName string) {
-	if !h.multiChainHealthChecker.IsChainSupported(chainName) {
+	chain := h.config.GetChainByName(chainName)
+	if chain == nil {
 		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
 		return
 	}
 
-	configs := h.config.ChainConfigs[chainName]
-	if configs == nil {
-		configs = make(map[string]string)
+	// Soft delete: endpoints and chain configs stay attached so a restore brings them back
+	if err := h.chainRepo.Delete(chain.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete chain: %v", err), http.StatusInternalServerError)
+		return
 	}
 
-	response := map[string]interface{}{
-		"chain_name": chainName,
-		"configs":    configs,
-	}
+	h.multiChainHealthChecker.RemoveChain(chainName)
+	h.config.RemoveChain(chainName)
+	log.Printf("Deleted chain %s (restore with POST /admin/chains/%s/restore)", chainName, chainName)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *MultiChainAdminHandler) updateChainConfig(w http.ResponseWriter, r *http.Request, chainName string) {
-	// Placeholder implementation
-	http.Error(w, "Chain config update not implemented yet", http.StatusNotImplemented)
+// patchChain toggles a chain with {"isEnabled": true|false}. Disabling stops the chain's health
+// checks and rejects its requests immediately; other chains are unaffected.
+func (h *MultiChainAdminHandler) patchChain(w http.ResponseWriter, r *http.Request, chainName string) {
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	existing := h.config.GetChainByName(chainName)
+	if existing == nil {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		IsEnabled *bool `json:"isEnabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.IsEnabled == nil {
+		http.Error(w, "isEnabled is required", http.StatusBadRequest)
+		return
+	}
+
+	chain := *existing
+	if chain.IsEnabled == *req.IsEnabled {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&chain)
+		return
+	}
+	chain.IsEnabled = *req.IsEnabled
+
+	if err := h.chainRepo.Update(&chain); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.config.UpdateChain(chainName, &chain)
+	if chain.IsEnabled {
+		h.multiChainHealthChecker.AddChain(chainName, &health.ChainConfig{
+			Chain:     &chain,
+			Endpoints: h.config.GetChainEndpoints(chainName),
+			Configs:   h.config.GetChainConfigs(chainName),
+		})
+		log.Printf("Enabled chain %s", chainName)
+	} else {
+		h.multiChainHealthChecker.RemoveChain(chainName)
+		log.Printf("Disabled chain %s", chainName)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&chain)
+}
+
+// updateChainRequest is a partial chain update; omitted fields keep their current value
+type updateChainRequest struct {
+	ChainID                *int    `json:"chainId,omitempty"`
+	Name                   *string `json:"name,omitempty"`
+	Type                   *string `json:"type,omitempty"`
+	DisplayName            *string `json:"displayName,omitempty"`
+	RPCPath                *string `json:"rpcPath,omitempty"`
+	IsTestnet              *bool   `json:"isTestnet,omitempty"`
+	NativeCurrencySymbol   *string `json:"nativeCurrencySymbol,omitempty"`
+	NativeCurrencyDecimals *int    `json:"nativeCurrencyDecimals,omitempty"`
+	BlockExplorerURL       *string `json:"blockExplorerUrl,omitempty"`
+}
+
+func (req *updateChainRequest) applyTo(chain *types.Chain) {
+	if req.ChainID != nil {
+		chain.ChainID = *req.ChainID
+	}
+	if req.Name != nil {
+		chain.Name = *req.Name
+	}
+	if req.Type != nil {
+		chain.Type = *req.Type
+	}
+	if req.DisplayName != nil {
+		chain.DisplayName = *req.DisplayName
+	}
+	if req.RPCPath != nil {
+		chain.RPCPath = *req.RPCPath
+	}
+	if req.IsTestnet != nil {
+		chain.IsTestnet = *req.IsTestnet
+	}
+	if req.NativeCurrencySymbol != nil {
+		chain.NativeCurrencySymbol = *req.NativeCurrencySymbol
+	}
+	if req.NativeCurrencyDecimals != nil {
+		chain.NativeCurrencyDecimals = *req.NativeCurrencyDecimals
+	}
+	if req.BlockExplorerURL != nil {
+		chain.BlockExplorerURL = *req.BlockExplorerURL
+	}
+}
+
+// validateChain checks a chain payload and that its name, chain ID and RPC path don't collide
+// with another chain; currentName is the chain being updated, or empty on create
+func (h *MultiChainAdminHandler) validateChain(chain *types.Chain, currentName string) error {
+	if !chainNamePattern.MatchString(chain.Name) {
+		return fmt.Errorf("chain name must be non-empty and contain only letters, digits and hyphens")
+	}
+	if !chainNamePattern.MatchString(chain.RPCPath) {
+		return fmt.Errorf("rpc path must be non-empty and contain only letters, digits and hyphens")
+	}
+	if chain.ChainID <= 0 {
+		return fmt.Errorf("chain ID must be positive")
+	}
+	if err := config.ValidateChainType(chain.Type); err != nil {
+		return err
+	}
+	// A chain can't become one whose endpoints it no longer supports
+	for _, endpoint := range h.config.GetChainEndpoints(currentName) {
+		if err := config.ValidateEndpointTransport(chain.GetType(), endpoint.URL, endpoint.GetTransport(), endpoint.WSURL); err != nil {
+			return fmt.Errorf("endpoint %s: %w", endpoint.URL, err)
+		}
+		if err := config.ValidateGraphQLURL(chain.GetType(), endpoint.URL, endpoint.GetTransport(), endpoint.GraphQLURL); err != nil {
+			return fmt.Errorf("endpoint %s: %w", endpoint.URL, err)
+		}
+		if err := config.ValidateCapabilities(chain.GetType(), endpoint.Capabilities); err != nil {
+			return fmt.Errorf("endpoint %s: %w", endpoint.URL, err)
+		}
+	}
+
+	for _, other := range h.config.GetChains() {
+		if other.Name == currentName {
+			continue
+		}
+		switch {
+		case other.Name == chain.Name:
+			return fmt.Errorf("chain %s already exists", chain.Name)
+		case other.ChainID == chain.ChainID:
+			return fmt.Errorf("chain ID %d is already used by chain %s", chain.ChainID, other.Name)
+		case other.RPCPath == chain.RPCPath:
+			return fmt.Errorf("rpc path %s is already used by chain %s", chain.RPCPath, other.Name)
+		}
+	}
+
+	return nil
+}
+
+// endpointWithTraffic adds live request counters to an endpoint in admin responses
+type endpointWithTraffic struct {
+	*types.RPCEndpoint
+	Traffic types.TrafficStats `json:"traffic"`
+}
+
+func (h *MultiChainAdminHandler) listChainEndpoints(w http.ResponseWriter, r *http.Request, chainName string) {
+	if wantsDeleted(r) {
+		h.listDeletedChainEndpoints(w, r, chainName)
+		return
+	}
+
+	if !h.multiChainHealthChecker.IsChainSupported(chainName) {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	endpoints := h.multiChainHealthChecker.GetAllEndpointsForChain(chainName)
+	healthyEndpoints := h.multiChainHealthChecker.GetHealthyEndpointsForChain(chainName)
+
+	withTraffic := make([]endpointWithTraffic, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		withTraffic = append(withTraffic, endpointWithTraffic{endpoint, endpoint.GetTrafficStats()})
+	}
+
+	response := map[string]interface{}{
+		"chain_name":        chainName,
+		"total_endpoints":   len(endpoints),
+		"healthy_endpoints": len(healthyEndpoints),
+		"endpoints":         withTraffic,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MultiChainAdminHandler) createChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string) {
+	if h.endpointRepo == nil {
+		http.Error(w, "Endpoint management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	chain := h.config.GetChainByName(chainName)
+	if chain == nil {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	var req repository.CreateRPCEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Set default weight if not provided
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+	req.ChainID = chain.ID
+	if req.Transport == "" {
+		req.Transport = types.TransportForURL(req.URL)
+	}
+
+	if err := validateEndpoint(req.Name, req.URL, req.Weight); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := config.ValidateEndpointTransport(chain.GetType(), req.URL, req.Transport, req.WSURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := config.ValidateGraphQLURL(chain.GetType(), req.URL, req.Transport, req.GraphQLURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := config.ValidateCapabilities(chain.GetType(), req.Capabilities); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := h.endpointRepo.Create(&req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create endpoint: %v", err), http.StatusInternalServerError)
+		return
+	}
+	endpoint.ChainName = chainName
+
+	h.applyEndpoint(chainName, endpoint)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+func (h *MultiChainAdminHandler) getChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	// Live endpoints carry health state; disabled ones only exist in the database
+	if endpoint := h.multiChainHealthChecker.GetEndpoint(chainName, endpointID); endpoint != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(endpointWithTraffic{endpoint, endpoint.GetTrafficStats()})
+		return
+	}
+
+	endpoint, status, err := h.lookupChainEndpoint(chainName, endpointID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+func (h *MultiChainAdminHandler) updateChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	if h.endpointRepo == nil {
+		http.Error(w, "Endpoint management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	existing, status, err := h.lookupChainEndpoint(chainName, endpointID)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var req repository.UpdateRPCEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	name, endpointURL, weight := existing.Name, existing.URL, existing.Weight
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.URL != nil {
+		endpointURL = *req.URL
+	}
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+	// A new URL of the other kind takes its transport along unless one is given
+	transport, wsURL := existing.GetTransport(), existing.WSURL
+	if req.Transport == nil && req.URL != nil && types.TransportForURL(endpointURL) != types.TransportForURL(existing.URL) {
+		implied := types.TransportForURL(endpointURL)
+		req.Transport = &implied
+	}
+	if req.Transport != nil {
+		transport = *req.Transport
+	}
+	if req.WSURL != nil {
+		wsURL = *req.WSURL
+	}
+	graphqlURL := existing.GraphQLURL
+	if req.GraphQLURL != nil {
+		graphqlURL = *req.GraphQLURL
+	}
+	if err := validateEndpoint(name, endpointURL, weight); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chainType := types.ChainTypeEVM
+	if chain := h.config.GetChainByName(chainName); chain != nil {
+		chainType = chain.GetType()
+	}
+	if err := config.ValidateEndpointTransport(chainType, endpointURL, transport, wsURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := config.ValidateGraphQLURL(chainType, endpointURL, transport, graphqlURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Capabilities != nil {
+		if err := config.ValidateCapabilities(chainType, *req.Capabilities); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	endpoint, err := h.endpointRepo.Update(endpointID, &req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update endpoint: %v", err), http.StatusInternalServerError)
+		return
+	}
+	endpoint.ChainName = chainName
+
+	// The live endpoint is replaced rather than mutated so in-flight requests see a consistent view
+	h.applyEndpoint(chainName, endpoint)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+func (h *MultiChainAdminHandler) deleteChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	if wantsPermanent(r) {
+		h.purgeChainEndpoint(w, r, chainName, endpointID)
+		return
+	}
+
+	if h.endpointRepo == nil {
+		http.Error(w, "Endpoint management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	if _, status, err := h.lookupChainEndpoint(chainName, endpointID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if err := h.endpointRepo.Delete(endpointID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete endpoint: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.multiChainHealthChecker.RemoveEndpoint(chainName, endpointID); err != nil {
+		log.Printf("Endpoint %d deleted from database but not from health checker: %v", endpointID, err)
+	}
+	h.config.SetChainEndpoints(chainName, h.multiChainHealthChecker.GetAllEndpoints(chainName))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookupChainEndpoint loads an endpoint from the database and verifies it belongs to the chain
+func (h *MultiChainAdminHandler) lookupChainEndpoint(chainName string, endpointID int) (*types.RPCEndpoint, int, error) {
+	chain := h.config.GetChainByName(chainName)
+	if chain == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Chain %s not found", chainName)
+	}
+
+	if h.endpointRepo == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Endpoint %d not found on chain %s", endpointID, chainName)
+	}
+
+	endpoint, err := h.endpointRepo.GetByID(endpointID)
+	if err != nil || endpoint.ChainID != chain.ID {
+		return nil, http.StatusNotFound, fmt.Errorf("Endpoint %d not found on chain %s", endpointID, chainName)
+	}
+	endpoint.ChainName = chainName
+
+	return endpoint, http.StatusOK, nil
+}
+
+// applyEndpoint pushes a created or updated endpoint into the running chain: enabled endpoints
+// are (re)added and probed right away so they can take traffic, disabled ones are removed
+func (h *MultiChainAdminHandler) applyEndpoint(chainName string, endpoint *types.RPCEndpoint) {
+	if !endpoint.Enabled {
+		if err := h.multiChainHealthChecker.RemoveEndpoint(chainName, endpoint.ID); err != nil {
+			log.Printf("Failed to remove endpoint %d from chain %s: %v", endpoint.ID, chainName, err)
+		}
+	} else {
+		if err := h.multiChainHealthChecker.AddEndpoint(chainName, endpoint); err != nil {
+			log.Printf("Failed to add endpoint %d to chain %s: %v", endpoint.ID, chainName, err)
+			return
+		}
+
+		go func() {
+			if _, err := h.multiChainHealthChecker.CheckEndpointNow(chainName, endpoint.ID); err != nil {
+				log.Printf("Initial health check for endpoint %d on chain %s failed: %v", endpoint.ID, chainName, err)
+			}
+		}()
+	}
+
+	h.config.SetChainEndpoints(chainName, h.multiChainHealthChecker.GetAllEndpoints(chainName))
+}
+
+// validateEndpoint checks the fields shared by endpoint create and update payloads
+func validateEndpoint(name, endpointURL string, weight int) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("endpoint name is required")
+	}
+
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid endpoint URL %q", endpointURL)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return fmt.Errorf("endpoint URL scheme must be http, https, ws or wss")
+	}
+
+	if weight < 1 || weight > 100 {
+		return fmt.Errorf("weight must be between 1 and 100")
+	}
+
+	return nil
+}
+
+// patchChainEndpoint changes runtime-only endpoint state; currently {"draining": true|false}.
+// A draining endpoint keeps being health checked and finishes in-flight requests but gets no new ones.
+func (h *MultiChainAdminHandler) patchChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	var req struct {
+		Draining *bool `json:"draining"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Draining == nil {
+		http.Error(w, "draining is required", http.StatusBadRequest)
+		return
+	}
+
+	endpoint := h.multiChainHealthChecker.GetEndpoint(chainName, endpointID)
+	if endpoint == nil {
+		http.Error(w, fmt.Sprintf("Endpoint %d not found on chain %s", endpointID, chainName), http.StatusNotFound)
+		return
+	}
+
+	endpoint.SetDraining(*req.Draining)
+	if *req.Draining {
+		log.Printf("Endpoint %s (%d) on chain %s is draining, %d requests in flight", endpoint.URL, endpointID, chainName, endpoint.GetInFlight())
+	} else {
+		log.Printf("Endpoint %s (%d) on chain %s is back in rotation", endpoint.URL, endpointID, chainName)
+	}
+
+	inFlight := endpoint.GetInFlight()
+	response := map[string]interface{}{
+		"chain_name": chainName,
+		"draining":   endpoint.IsDraining(),
+		"in_flight":  inFlight,
+		"drained":    endpoint.IsDraining() && inFlight == 0,
+		"endpoint":   endpoint,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// overrideChainEndpoint forces an endpoint in or out of rotation independent of health checks
+func (h *MultiChainAdminHandler) overrideChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	var req struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	switch req.State {
+	case types.OverrideAuto, types.OverrideForceUp, types.OverrideForceDown:
+	default:
+		http.Error(w, fmt.Sprintf("Invalid override state %q, must be one of: %s, %s, %s",
+			req.State, types.OverrideForceUp, types.OverrideForceDown, types.OverrideAuto), http.StatusBadRequest)
+		return
+	}
+
+	endpoint := h.multiChainHealthChecker.GetEndpoint(chainName, endpointID)
+	if endpoint == nil {
+		http.Error(w, fmt.Sprintf("Endpoint %d not found on chain %s", endpointID, chainName), http.StatusNotFound)
+		return
+	}
+
+	endpoint.SetOverride(req.State)
+	log.Printf("Endpoint %s (%d) on chain %s override set to %s", endpoint.URL, endpointID, chainName, req.State)
+
+	response := map[string]interface{}{
+		"chain_name": chainName,
+		"override":   endpoint.GetOverride(),
+		"available":  endpoint.IsAvailable(),
+		"endpoint":   endpoint,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MultiChainAdminHandler) getChainConfig(w http.ResponseWriter, r *http.Request, chainName string) {
+	if !h.multiChainHealthChecker.IsChainSupported(chainName) {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	configs := h.config.GetChainConfigs(chainName)
+	if configs == nil {
+		configs = make(map[string]string)
+	}
+
+	response := map[string]interface{}{
+		"chain_name": chainName,
+		"configs":    configs,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// updateChainConfig sets or deletes chain config keys; a null value deletes the key.
+// All keys are validated before anything is written.
+func (h *MultiChainAdminHandler) updateChainConfig(w http.ResponseWriter, r *http.Request, chainName string) {
+	if h.chainConfigRepo == nil {
+		http.Error(w, "Chain config management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	chain := h.config.GetChainByName(chainName)
+	if chain == nil {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		Configs map[string]*string `json:"configs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.Configs) == 0 {
+		http.Error(w, "configs must contain at least one key", http.StatusBadRequest)
+		return
+	}
+
+	for key, value := range req.Configs {
+		if value == nil {
+			continue
+		}
+		if err := config.ValidateChainConfig(key, *value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Copy-on-write so running health checks never see a partially applied update
+	configs := make(map[string]string)
+	for key, value := range h.config.GetChainConfigs(chainName) {
+		configs[key] = value
+	}
+
+	for key, value := range req.Configs {
+		if value == nil {
+			if err := h.chainConfigRepo.DeleteConfig(chain.ID, key); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to delete config %s: %v", key, err), http.StatusInternalServerError)
+				return
+			}
+			delete(configs, key)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(*value)
+		if err := h.chainConfigRepo.SetConfig(chain.ID, key, trimmed, ""); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to set config %s: %v", key, err), http.StatusInternalServerError)
+			return
+		}
+		configs[key] = trimmed
+	}
+
+	h.config.SetChainConfigs(chainName, configs)
+	if err := h.multiChainHealthChecker.SetChainConfigs(chainName, configs); err != nil {
+		log.Printf("Chain %s configs saved but not applied to health checker: %v", chainName, err)
+	}
+	log.Printf("Updated %d config keys for chain %s", len(req.Configs), chainName)
+
+	response := map[string]interface{}{
+		"chain_name": chainName,
+		"configs":    configs,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MultiChainAdminHandler) listMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := h.maintenanceRepo.GetAll()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get maintenance windows: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	items := make([]map[string]interface{}, len(windows))
+	for i, window := range windows {
+		items[i] = map[string]interface{}{
+			"window": window,
+			"active": window.Active(now),
+		}
+	}
+
+	response := map[string]interface{}{
+		"windows": items,
+		"total":   len(windows),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MultiChainAdminHandler) createMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var window types.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if window.Recurrence == "" {
+		window.Recurrence = types.RecurrenceNone
+	}
+	if err := validateMaintenanceWindow(&window); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.endpointExists(window.EndpointID) {
+		http.Error(w, fmt.Sprintf("Endpoint %d not found", window.EndpointID), http.StatusNotFound)
+		return
+	}
+
+	if err := h.maintenanceRepo.Create(&window); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create maintenance window: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.applyMaintenanceWindows()
+	log.Printf("Scheduled maintenance window %d for endpoint %d (%s to %s, %s)", window.ID, window.EndpointID,
+		window.StartsAt.Format(time.RFC3339), window.EndsAt.Format(time.RFC3339), window.Recurrence)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&window)
+}
+
+func (h *MultiChainAdminHandler) deleteMaintenanceWindow(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.maintenanceRepo.Delete(id); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete maintenance window: %v", err), http.StatusNotFound)
+		return
+	}
+	h.applyMaintenanceWindows()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// endpointExists reports whether an endpoint is known, from the database when endpoints are
+// stored there and from the running chains when a chains file or remote store owns them
+func (h *MultiChainAdminHandler) endpointExists(endpointID int) bool {
+	if h.endpointRepo != nil {
+		_, err := h.endpointRepo.GetByID(endpointID)
+		return err == nil
+	}
+	for _, chainName := range h.multiChainHealthChecker.GetSupportedChains() {
+		if h.multiChainHealthChecker.GetEndpoint(chainName, endpointID) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// applyMaintenanceWindows reloads all windows from the database into the health checker
+func (h *MultiChainAdminHandler) applyMaintenanceWindows() {
+	windows, err := h.maintenanceRepo.GetAll()
+	if err != nil {
+		log.Printf("Failed to reload maintenance windows: %v", err)
+		return
+	}
+	h.multiChainHealthChecker.SetMaintenanceWindows(windows)
+}
+
+// validateMaintenanceWindow checks a window's time span and recurrence
+func validateMaintenanceWindow(window *types.MaintenanceWindow) error {
+	if window.EndpointID <= 0 {
+		return fmt.Errorf("endpointId is required")
+	}
+	if window.StartsAt.IsZero() || window.EndsAt.IsZero() {
+		return fmt.Errorf("startsAt and endsAt are required")
+	}
+	if !window.EndsAt.After(window.StartsAt) {
+		return fmt.Errorf("endsAt must be after startsAt")
+	}
+
+	span := window.EndsAt.Sub(window.StartsAt)
+	switch window.Recurrence {
+	case types.RecurrenceNone:
+	case types.RecurrenceDaily:
+		if span >= 24*time.Hour {
+			return fmt.Errorf("daily maintenance windows must be shorter than 24 hours")
+		}
+	case types.RecurrenceWeekly:
+		if span >= 7*24*time.Hour {
+			return fmt.Errorf("weekly maintenance windows must be shorter than 7 days")
+		}
+	default:
+		return fmt.Errorf("recurrence must be one of: %s, %s, %s", types.RecurrenceNone, types.RecurrenceDaily, types.RecurrenceWeekly)
+	}
+
+	return nil
 }
 
 // Helper methods
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec documents the admin, health and proxy routes; keep it in step with RegisterRoutes
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage renders openAPISpec with Redoc
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>RPC Proxy API</title>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="/admin/openapi.json"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// RegisterDocsRoutes serves the OpenAPI document and its explorer page. They describe the
+// API without exposing any data, so they are mounted outside the admin key check.
+func RegisterDocsRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/openapi.json", handleOpenAPISpec)
+	mux.HandleFunc("/admin/docs", handleDocs)
+}
+
+func handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+func handleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
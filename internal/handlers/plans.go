@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rpc-proxy/internal/types"
+)
+
+// handlePlans handles requests to /admin/plans
+func (h *MultiChainAdminHandler) handlePlans(w http.ResponseWriter, r *http.Request) {
+	if h.planRepo == nil {
+		http.Error(w, "Plans require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		h.listPlans(w, r)
+	case "POST":
+		h.createPlan(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePlan handles requests to /admin/plans/{planId}
+func (h *MultiChainAdminHandler) handlePlan(w http.ResponseWriter, r *http.Request) {
+	if h.planRepo == nil {
+		http.Error(w, "Plans require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/plans/"), "/"))
+	if err != nil {
+		http.Error(w, "Invalid plan ID", http.StatusBadRequest)
+		return
+	}
+	existing, err := h.planRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		h.getPlan(w, r, existing)
+	case "PUT":
+		h.updatePlan(w, r, existing)
+	case "DELETE":
+		h.deletePlan(w, r, existing)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *MultiChainAdminHandler) listPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := h.planRepo.GetAll()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get plans: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"plans": plans,
+		"total": len(plans),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MultiChainAdminHandler) createPlan(w http.ResponseWriter, r *http.Request) {
+	var p types.Plan
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validatePlan(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.checkPlanNameFree(w, p.Name, 0) {
+		return
+	}
+
+	if err := h.planRepo.Create(&p); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create plan: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// Tenants may already name the plan
+	h.reloadTenants()
+	log.Printf("Created plan %s (ID %d)", p.Name, p.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&p)
+}
+
+// getPlan returns the plan and the tenants on it
+func (h *MultiChainAdminHandler) getPlan(w http.ResponseWriter, r *http.Request, p *types.Plan) {
+	tenants, err := h.planTenants(p.Name)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tenants: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"plan":    p,
+		"tenants": tenants,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// updatePlan applies the fields present in the body to the plan; the change applies to every
+// tenant on it at once
+func (h *MultiChainAdminHandler) updatePlan(w http.ResponseWriter, r *http.Request, existing *types.Plan) {
+	var update struct {
+		Name           *string   `json:"name"`
+		RateLimit      *float64  `json:"rateLimit"`
+		RateBurst      *int      `json:"rateBurst"`
+		MaxConcurrency *int      `json:"maxConcurrency"`
+		MonthlyQuota   *int64    `json:"monthlyQuota"`
+		AllowedChains  *[]string `json:"allowedChains"`
+		AllowedMethods *[]string `json:"allowedMethods"`
+		HedgingEnabled *bool     `json:"hedgingEnabled"`
+		CachePriority  *int      `json:"cachePriority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	p := *existing
+	if update.Name != nil {
+		p.Name = *update.Name
+	}
+	if update.RateLimit != nil {
+		p.RateLimit = *update.RateLimit
+	}
+	if update.RateBurst != nil {
+		p.RateBurst = *update.RateBurst
+	}
+	if update.MaxConcurrency != nil {
+		p.MaxConcurrency = *update.MaxConcurrency
+	}
+	if update.MonthlyQuota != nil {
+		p.MonthlyQuota = *update.MonthlyQuota
+	}
+	if update.AllowedChains != nil {
+		p.AllowedChains = *update.AllowedChains
+	}
+	if update.AllowedMethods != nil {
+		p.AllowedMethods = *update.AllowedMethods
+	}
+	if update.HedgingEnabled != nil {
+		p.HedgingEnabled = *update.HedgingEnabled
+	}
+	if update.CachePriority != nil {
+		p.CachePriority = *update.CachePriority
+	}
+
+	if err := h.validatePlan(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.checkPlanNameFree(w, p.Name, p.ID) {
+		return
+	}
+	// Tenants refer to their plan by name, so renaming would silently take them off it
+	if p.Name != existing.Name && !h.checkPlanUnused(w, existing.Name, "renamed") {
+		return
+	}
+
+	if err := h.planRepo.Update(&p); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update plan: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.reloadTenants()
+	log.Printf("Updated plan %s (ID %d)", p.Name, p.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&p)
+}
+
+func (h *MultiChainAdminHandler) deletePlan(w http.ResponseWriter, r *http.Request, p *types.Plan) {
+	if !h.checkPlanUnused(w, p.Name, "deleted") {
+		return
+	}
+
+	if err := h.planRepo.Delete(p.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete plan: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.reloadTenants()
+	log.Printf("Deleted plan %s (ID %d)", p.Name, p.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validatePlan checks a plan's fields before it is stored
+func (h *MultiChainAdminHandler) validatePlan(p *types.Plan) error {
+	p.Name = strings.TrimSpace(p.Name)
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("plan name is required")
+	case len(p.Name) > 50:
+		return fmt.Errorf("plan name must be at most 50 characters")
+	case p.RateLimit < 0 || p.RateBurst < 0 || p.MaxConcurrency < 0:
+		return fmt.Errorf("plan rate limit, burst and max concurrency must not be negative")
+	case p.MonthlyQuota < 0:
+		return fmt.Errorf("plan monthly quota must not be negative")
+	case p.CachePriority < 0:
+		return fmt.Errorf("plan cache priority must not be negative")
+	}
+
+	for _, chainName := range p.AllowedChains {
+		if h.config.GetChainByName(chainName) == nil {
+			return fmt.Errorf("allowed chain %s not found", chainName)
+		}
+	}
+	for _, pattern := range p.AllowedMethods {
+		if !methodPatternRegex.MatchString(pattern) {
+			return fmt.Errorf("allowed method %q must be a method name, optionally ending in * to match a prefix", pattern)
+		}
+	}
+	return nil
+}
+
+// checkPlanNameFree writes a conflict unless no plan other than id uses name
+func (h *MultiChainAdminHandler) checkPlanNameFree(w http.ResponseWriter, name string, id int) bool {
+	plans, err := h.planRepo.GetAll()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get plans: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	for _, other := range plans {
+		if other.ID != id && strings.EqualFold(other.Name, name) {
+			http.Error(w, fmt.Sprintf("Plan %s already exists", name), http.StatusConflict)
+			return false
+		}
+	}
+	return true
+}
+
+// checkPlanUnused writes a conflict if any tenant is on the plan named name, which therefore
+// can't be renamed or deleted
+func (h *MultiChainAdminHandler) checkPlanUnused(w http.ResponseWriter, name, action string) bool {
+	tenants, err := h.planTenants(name)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tenants: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	if len(tenants) > 0 {
+		http.Error(w, fmt.Sprintf("Plan %s can't be %s while %d tenant(s) are on it", name, action, len(tenants)),
+			http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// checkPlanExists writes a bad request unless name is empty or names a plan
+func (h *MultiChainAdminHandler) checkPlanExists(w http.ResponseWriter, name string) bool {
+	if name == "" {
+		return true
+	}
+	plans, err := h.planRepo.GetAll()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get plans: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	for _, p := range plans {
+		if p.Name == name {
+			return true
+		}
+	}
+	http.Error(w, fmt.Sprintf("Plan %s not found", name), http.StatusBadRequest)
+	return false
+}
+
+// planTenants returns the tenants on the plan named name
+func (h *MultiChainAdminHandler) planTenants(name string) ([]*types.Tenant, error) {
+	tenants, err := h.tenantRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	onPlan := make([]*types.Tenant, 0)
+	for _, t := range tenants {
+		if t.Plan == name {
+			onPlan = append(onPlan, t)
+		}
+	}
+	return onPlan, nil
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"rpc-proxy/internal/repository"
+)
+
+const (
+	defaultRequestLogLimit = 100
+	maxRequestLogLimit     = 1000
+)
+
+// handleRequestLogs lists sampled requests, newest first. ?chain=, ?method= and ?upstream=
+// narrow them, ?errors=true keeps failures only, ?history= bounds their age (e.g. 1h) and
+// ?limit= caps the list (default 100, at most 1000).
+func (h *MultiChainAdminHandler) handleRequestLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.requestLogRepo == nil {
+		http.Error(w, "Request logs require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.RequestLogFilter{
+		ChainName: query.Get("chain"),
+		Method:    query.Get("method"),
+		Upstream:  query.Get("upstream"),
+		Limit:     defaultRequestLogLimit,
+	}
+
+	if errorsStr := query.Get("errors"); errorsStr != "" {
+		errorsOnly, err := strconv.ParseBool(errorsStr)
+		if err != nil {
+			http.Error(w, "errors must be true or false", http.StatusBadRequest)
+			return
+		}
+		filter.ErrorsOnly = errorsOnly
+	}
+
+	if historyStr := query.Get("history"); historyStr != "" {
+		history, err := time.ParseDuration(historyStr)
+		if err != nil || history <= 0 {
+			http.Error(w, "Invalid history duration", http.StatusBadRequest)
+			return
+		}
+		filter.Since = time.Now().Add(-history)
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxRequestLogLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxRequestLogLimit), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	logs, err := h.requestLogRepo.Find(filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get request logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"logs":  logs,
+		"total": len(logs),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rpc-proxy/internal/jobs"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+const (
+	defaultRevisionLimit = 50
+	maxRevisionLimit     = 500
+)
+
+// revisionChange is one difference between two revisions; Before is empty for a create and
+// After for a delete
+type revisionChange struct {
+	Action string      `json:"action"` // create, update or delete
+	Kind   string      `json:"kind"`   // chain, endpoint, chain_config or setting
+	Chain  string      `json:"chain,omitempty"`
+	Key    string      `json:"key"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// SetSettingsJob lets a rollback apply restored settings to the running proxy
+func (h *MultiChainAdminHandler) SetSettingsJob(job *jobs.SettingsJob) {
+	h.settingsJob = job
+}
+
+// RevisionsEnabled reports whether configuration changes are versioned, which needs chains
+// managed in the database
+func (h *MultiChainAdminHandler) RevisionsEnabled() bool {
+	return h.revisionRepo != nil
+}
+
+// RecordRevision snapshots the configuration and stores it as a new revision attributed to
+// action, unless nothing changed since the latest one. It returns the new revision, or nil
+// when none was recorded or revisions are unavailable.
+func (h *MultiChainAdminHandler) RecordRevision(action string) (*repository.ConfigRevision, error) {
+	if h.revisionRepo == nil {
+		return nil, nil
+	}
+
+	// Serialized so concurrent changes are compared against each other's snapshots
+	h.revisionMu.Lock()
+	defer h.revisionMu.Unlock()
+
+	doc, err := h.configDocRepo.Export()
+	if err != nil {
+		return nil, err
+	}
+	latest, err := h.revisionRepo.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	previous := &repository.ConfigDocument{}
+	if latest != nil {
+		previous = latest.Document
+	}
+	changes := diffConfigDocuments(previous, doc)
+	if latest != nil && len(changes) == 0 {
+		return nil, nil
+	}
+
+	revision := &repository.ConfigRevision{
+		Action:   action,
+		Changes:  len(changes),
+		Document: doc,
+	}
+	if err := h.revisionRepo.Create(revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// TrackRevisions records a revision after every successful admin request that may have
+// changed chains, endpoints, chain configs or settings
+func (h *MultiChainAdminHandler) TrackRevisions(next http.Handler) http.Handler {
+	if h.revisionRepo == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		if sw.status >= 400 {
+			return
+		}
+
+		revision, err := h.RecordRevision(r.Method + " " + r.URL.Path)
+		if err != nil {
+			log.Printf("Failed to record config revision for %s %s: %v", r.Method, r.URL.Path, err)
+			return
+		}
+		// The change was applied here, but changes from other replicas may not have been yet;
+		// a reload applies both, moving this replica to the new config epoch
+		if revision != nil && h.reloadJob != nil {
+			go func() {
+				if _, err := h.reloadJob.Reload(); err != nil {
+					log.Printf("Failed to apply config epoch %d: %v", revision.ID, err)
+				}
+			}()
+		}
+	})
+}
+
+// handleRevisions handles GET /admin/revisions; ?limit= caps the list (default 50, at most 500)
+func (h *MultiChainAdminHandler) handleRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.revisionRepo == nil {
+		http.Error(w, "Configuration revisions require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := defaultRevisionLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > maxRevisionLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxRevisionLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	revisions, err := h.revisionRepo.List(limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list revisions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"revisions": revisions,
+		"total":     len(revisions),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleRevision handles GET /admin/revisions/{id} and POST /admin/revisions/{id}/rollback
+func (h *MultiChainAdminHandler) handleRevision(w http.ResponseWriter, r *http.Request) {
+	if h.revisionRepo == nil {
+		http.Error(w, "Configuration revisions require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/revisions/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || id <= 0 || len(parts) > 2 {
+		http.Error(w, "Invalid revision ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 {
+		if parts[1] != "rollback" {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.rollbackRevision(w, r, id)
+		return
+	}
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.getRevision(w, r, id)
+}
+
+// getRevision returns a revision with its document and its changes from the previous
+// revision, or from the revision named by ?against=
+func (h *MultiChainAdminHandler) getRevision(w http.ResponseWriter, r *http.Request, id int) {
+	revision, err := h.revisionRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var base *repository.ConfigRevision
+	if againstStr := r.URL.Query().Get("against"); againstStr != "" {
+		against, err := strconv.Atoi(againstStr)
+		if err != nil || against <= 0 {
+			http.Error(w, "Invalid against revision ID", http.StatusBadRequest)
+			return
+		}
+		if base, err = h.revisionRepo.GetByID(against); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	} else if base, err = h.revisionRepo.Previous(id); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load previous revision: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	previous := &repository.ConfigDocument{}
+	response := map[string]interface{}{
+		"revision": revision,
+	}
+	if base != nil {
+		previous = base.Document
+		response["against"] = base.ID
+	}
+	changes := diffConfigDocuments(previous, revision.Document)
+	response["changes"] = changes
+	response["total"] = len(changes)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// rollbackRevision restores the configuration recorded in a revision and applies it live.
+// Chains, endpoints, chain configs and settings added since are deleted; deleted chains go
+// to the trash. ?dry_run=true reports the changes without keeping them.
+func (h *MultiChainAdminHandler) rollbackRevision(w http.ResponseWriter, r *http.Request, id int) {
+	revision, err := h.revisionRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	opts := repository.ImportOptions{
+		DryRun:        r.URL.Query().Get("dry_run") == "true",
+		Prune:         true,
+		PruneSettings: true,
+	}
+
+	changes, err := h.configDocRepo.Import(revision.Document, opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to roll back configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"dryRun":   opts.DryRun,
+		"revision": id,
+		"changes":  changes,
+		"total":    len(changes),
+	}
+
+	if !opts.DryRun && len(changes) > 0 {
+		log.Printf("Rolled configuration back to revision %d: %d changes", id, len(changes))
+
+		if recorded, err := h.RecordRevision(fmt.Sprintf("rollback to revision %d", id)); err != nil {
+			log.Printf("Failed to record config revision for rollback: %v", err)
+		} else if recorded != nil {
+			response["newRevision"] = recorded.ID
+		}
+
+		// Apply the restored tree to the running proxy the same way POST /admin/reload does
+		if h.reloadJob != nil {
+			if result, err := h.reloadJob.Reload(); err != nil {
+				log.Printf("Configuration rolled back but reload failed: %v", err)
+				response["reloadError"] = err.Error()
+			} else {
+				response["reload"] = result
+			}
+		}
+		if h.settingsJob != nil {
+			if _, err := h.settingsJob.Run(); err != nil {
+				log.Printf("Settings rolled back but not applied: %v", err)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// diffConfigDocuments lists what changed from before to after. Chains are matched by name,
+// endpoints by URL within their chain, chain configs and settings by key.
+func diffConfigDocuments(before, after *repository.ConfigDocument) []revisionChange {
+	changes := []revisionChange{}
+
+	beforeChains := make(map[string]*repository.ConfigDocumentChain, len(before.Chains))
+	for i := range before.Chains {
+		beforeChains[before.Chains[i].Name] = &before.Chains[i]
+	}
+
+	empty := &repository.ConfigDocumentChain{}
+	for i := range after.Chains {
+		chain := &after.Chains[i]
+		old, exists := beforeChains[chain.Name]
+		delete(beforeChains, chain.Name)
+
+		switch {
+		case !exists:
+			changes = append(changes, revisionChange{Action: "create", Kind: "chain", Chain: chain.Name, Key: chain.Name, After: chainAttributes(chain)})
+			old = empty
+		case !reflect.DeepEqual(chainAttributes(old), chainAttributes(chain)):
+			changes = append(changes, revisionChange{Action: "update", Kind: "chain", Chain: chain.Name, Key: chain.Name,
+				Before: chainAttributes(old), After: chainAttributes(chain)})
+		}
+		changes = append(changes, diffChainContents(chain.Name, old, chain)...)
+	}
+
+	for i := range before.Chains {
+		chain := &before.Chains[i]
+		if _, deleted := beforeChains[chain.Name]; !deleted {
+			continue
+		}
+		changes = append(changes, revisionChange{Action: "delete", Kind: "chain", Chain: chain.Name, Key: chain.Name, Before: chainAttributes(chain)})
+		changes = append(changes, diffChainContents(chain.Name, chain, empty)...)
+	}
+
+	return append(changes, diffStringMaps("setting", "", before.Settings, after.Settings)...)
+}
+
+// diffChainContents lists the chain config and endpoint changes within one chain
+func diffChainContents(chainName string, before, after *repository.ConfigDocumentChain) []revisionChange {
+	changes := diffStringMaps("chain_config", chainName, before.Configs, after.Configs)
+
+	beforeEndpoints := make(map[string]repository.ConfigDocumentEndpoint, len(before.Endpoints))
+	for _, endpoint := range before.Endpoints {
+		beforeEndpoints[endpoint.URL] = withTransport(endpoint)
+	}
+
+	for _, endpoint := range after.Endpoints {
+		endpoint = withTransport(endpoint)
+		old, exists := beforeEndpoints[endpoint.URL]
+		delete(beforeEndpoints, endpoint.URL)
+
+		switch {
+		case !exists:
+			changes = append(changes, revisionChange{Action: "create", Kind: "endpoint", Chain: chainName, Key: endpoint.URL, After: endpoint})
+		case !reflect.DeepEqual(old, endpoint):
+			changes = append(changes, revisionChange{Action: "update", Kind: "endpoint", Chain: chainName, Key: endpoint.URL, Before: old, After: endpoint})
+		}
+	}
+
+	for _, endpoint := range before.Endpoints {
+		if _, deleted := beforeEndpoints[endpoint.URL]; deleted {
+			changes = append(changes, revisionChange{Action: "delete", Kind: "endpoint", Chain: chainName, Key: endpoint.URL, Before: endpoint})
+		}
+	}
+
+	return changes
+}
+
+// withTransport fills in the transport of an endpoint from a revision saved before endpoints
+// had one, so it compares equal to the same endpoint saved since
+func withTransport(endpoint repository.ConfigDocumentEndpoint) repository.ConfigDocumentEndpoint {
+	if endpoint.Transport == "" {
+		endpoint.Transport = types.TransportForURL(endpoint.URL)
+	}
+	return endpoint
+}
+
+// diffStringMaps lists the changed keys of a chain config or settings map in key order
+func diffStringMaps(kind, chainName string, before, after map[string]string) []revisionChange {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []revisionChange
+	for _, key := range keys {
+		old, existed := before[key]
+		value, exists := after[key]
+
+		switch {
+		case !existed:
+			changes = append(changes, revisionChange{Action: "create", Kind: kind, Chain: chainName, Key: key, After: value})
+		case !exists:
+			changes = append(changes, revisionChange{Action: "delete", Kind: kind, Chain: chainName, Key: key, Before: old})
+		case old != value:
+			changes = append(changes, revisionChange{Action: "update", Kind: kind, Chain: chainName, Key: key, Before: old, After: value})
+		}
+	}
+	return changes
+}
+
+// chainAttributes is a chain without its configs and endpoints, which are diffed separately.
+// Revisions saved before chains had a type are evm chains.
+func chainAttributes(chain *repository.ConfigDocumentChain) repository.ConfigDocumentChain {
+	attributes := *chain
+	attributes.Configs = nil
+	attributes.Endpoints = nil
+	if attributes.Type == "" {
+		attributes.Type = types.ChainTypeEVM
+	}
+	return attributes
+}
+
+// statusWriter records the status code a handler responds with
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wrote {
+		sw.status = status
+		sw.wrote = true
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	sw.wrote = true
+	return sw.ResponseWriter.Write(b)
+}
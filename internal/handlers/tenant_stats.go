@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// defaultTopMethods is how many methods tenant stats list unless ?top= asks otherwise
+	defaultTopMethods = 10
+	// maxTopMethods bounds ?top=
+	maxTopMethods = 100
+)
+
+// usageTotals are requests, errors and compute units over some span
+type usageTotals struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ErrorRate    float64 `json:"errorRate"`
+	ComputeUnits int64   `json:"computeUnits"`
+}
+
+func (u *usageTotals) add(requests, errors, computeUnits int64) {
+	u.Requests += requests
+	u.Errors += errors
+	u.ComputeUnits += computeUnits
+	if u.Requests > 0 {
+		u.ErrorRate = float64(u.Errors) / float64(u.Requests)
+	}
+}
+
+// tenantDayStats is a tenant's usage on one UTC day
+type tenantDayStats struct {
+	Day string `json:"day"`
+	usageTotals
+}
+
+// tenantMethodStats is a tenant's usage of one method, on every chain
+type tenantMethodStats struct {
+	Method string `json:"method"`
+	usageTotals
+}
+
+// tenantQuotaStats is a tenant's consumption in the current billing period, a UTC month
+type tenantQuotaStats struct {
+	Period       string `json:"period"`
+	Requests     int64  `json:"requests"`
+	ComputeUnits int64  `json:"computeUnits"`
+	// Limit is the plan's monthly quota of compute units; 0 is unlimited
+	Limit int64 `json:"limit"`
+}
+
+// handleTenantStats handles GET /admin/tenants/{tenantId}/stats
+func (h *MultiChainAdminHandler) handleTenantStats(w http.ResponseWriter, r *http.Request, t *types.Tenant) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.ServeTenantStats(w, r, t)
+}
+
+// ServeTenantStats writes a tenant's request volume, error rate, daily usage and top methods
+// over ?from= to ?to= (the last 30 days by default; ?top= sets how many methods), and its
+// consumption in the current UTC month. It backs both the admin route and the read-only
+// route tenants call with their own API key.
+func (h *MultiChainAdminHandler) ServeTenantStats(w http.ResponseWriter, r *http.Request, t *types.Tenant) {
+	if h.usageRepo == nil {
+		http.Error(w, "Usage metering requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	q, err := parseUsageQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	top := defaultTopMethods
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		top, err = strconv.Atoi(topStr)
+		if err != nil || top < 1 || top > maxTopMethods {
+			http.Error(w, fmt.Sprintf("top must be between 1 and %d", maxTopMethods), http.StatusBadRequest)
+			return
+		}
+	}
+
+	usage, err := h.usageRepo.GetRange(0, t.ID, q.from, q.to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+	buckets, err := h.usageRepo.GetBuckets(t.ID, q.from, q.to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	periodStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	period, err := h.usageRepo.GetRange(0, t.ID, periodStart, today)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+	quota := tenantQuotaStats{Period: periodStart.Format("2006-01")}
+	for _, u := range period {
+		quota.Requests += u.Requests
+		quota.ComputeUnits += u.ComputeUnits
+	}
+	if t.Plan != "" && h.planRepo != nil {
+		plans, err := h.planRepo.GetAll()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get plans: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, p := range plans {
+			if p.Name == t.Plan {
+				quota.Limit = p.MonthlyQuota
+			}
+		}
+	}
+
+	var totals usageTotals
+	days := make([]*tenantDayStats, 0)
+	byDay := make(map[string]*tenantDayStats)
+	for _, u := range usage {
+		totals.add(u.Requests, u.Errors, u.ComputeUnits)
+		day := u.Day.UTC().Format(usageDayFormat)
+		stats, ok := byDay[day]
+		if !ok {
+			stats = &tenantDayStats{Day: day}
+			byDay[day] = stats
+			days = append(days, stats)
+		}
+		stats.add(u.Requests, u.Errors, u.ComputeUnits)
+	}
+
+	methods := make([]*tenantMethodStats, 0)
+	byMethod := make(map[string]*tenantMethodStats)
+	for _, b := range buckets {
+		stats, ok := byMethod[b.Method]
+		if !ok {
+			stats = &tenantMethodStats{Method: b.Method}
+			byMethod[b.Method] = stats
+			methods = append(methods, stats)
+		}
+		stats.add(b.Requests, b.Errors, b.ComputeUnits)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if methods[i].Requests != methods[j].Requests {
+			return methods[i].Requests > methods[j].Requests
+		}
+		return methods[i].Method < methods[j].Method
+	})
+	if len(methods) > top {
+		methods = methods[:top]
+	}
+
+	response := map[string]interface{}{
+		"tenant": map[string]interface{}{
+			"id":     t.ID,
+			"name":   t.Name,
+			"plan":   t.Plan,
+			"status": t.Status,
+		},
+		"from":       q.from.Format(usageDayFormat),
+		"to":         q.to.Format(usageDayFormat),
+		"totals":     totals,
+		"daily":      days,
+		"topMethods": methods,
+		"quota":      quota,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
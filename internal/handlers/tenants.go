@@ -0,0 +1,496 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/types"
+)
+
+// methodPatternRegex matches an allowed method: a JSON-RPC method name, or a prefix ending in *
+var methodPatternRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{1,64}\*?$`)
+
+// SetTenantRegistry reloads the proxy's API key lookup after tenant and key changes
+func (h *MultiChainAdminHandler) SetTenantRegistry(registry *tenant.Registry) {
+	h.tenants = registry
+}
+
+// SetRefusalTracker enables GET /admin/analytics/refusals
+func (h *MultiChainAdminHandler) SetRefusalTracker(tracker *analytics.RefusalTracker) {
+	h.refusals = tracker
+}
+
+// issuedAPIKey is a newly created key; the key itself is only ever returned here
+type issuedAPIKey struct {
+	*types.APIKey
+	Key string `json:"key"`
+}
+
+// handleTenants handles requests to /admin/tenants
+func (h *MultiChainAdminHandler) handleTenants(w http.ResponseWriter, r *http.Request) {
+	if h.tenantRepo == nil {
+		http.Error(w, "Tenants require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		h.listTenants(w, r)
+	case "POST":
+		h.createTenant(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTenant handles requests to /admin/tenants/{tenantId}, /admin/tenants/{tenantId}/stats,
+// /admin/tenants/{tenantId}/keys and /admin/tenants/{tenantId}/keys/{keyId}
+func (h *MultiChainAdminHandler) handleTenant(w http.ResponseWriter, r *http.Request) {
+	if h.tenantRepo == nil {
+		http.Error(w, "Tenants require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/tenants/"), "/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid tenant ID", http.StatusBadRequest)
+		return
+	}
+	existing, err := h.tenantRepo.GetByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		switch r.Method {
+		case "GET":
+			h.getTenant(w, r, existing)
+		case "PUT":
+			h.updateTenant(w, r, existing)
+		case "DELETE":
+			h.deleteTenant(w, r, existing)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[1] == "stats":
+		h.handleTenantStats(w, r, existing)
+	case len(parts) == 2 && parts[1] == "keys":
+		switch r.Method {
+		case "GET":
+			h.listTenantKeys(w, r, existing)
+		case "POST":
+			h.createTenantKey(w, r, existing)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 3 && parts[1] == "keys":
+		keyID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			h.updateTenantKey(w, r, existing, keyID)
+		case "DELETE":
+			h.revokeTenantKey(w, r, existing, keyID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (h *MultiChainAdminHandler) listTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.tenantRepo.GetAll()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tenants: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"tenants": tenants,
+		"total":   len(tenants),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MultiChainAdminHandler) createTenant(w http.ResponseWriter, r *http.Request) {
+	var t types.Tenant
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if t.Status == "" {
+		t.Status = types.TenantActive
+	}
+
+	if err := h.validateTenant(&t); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.checkTenantNameFree(w, t.Name, 0) || !h.checkPlanExists(w, t.Plan) {
+		return
+	}
+
+	if err := h.tenantRepo.Create(&t); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create tenant: %v", err), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Created tenant %s (ID %d)", t.Name, t.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&t)
+}
+
+func (h *MultiChainAdminHandler) getTenant(w http.ResponseWriter, r *http.Request, t *types.Tenant) {
+	keys, err := h.apiKeyRepo.GetByTenant(t.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get API keys: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"tenant": t,
+		"keys":   keys,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// updateTenant applies the fields present in the body to the tenant
+func (h *MultiChainAdminHandler) updateTenant(w http.ResponseWriter, r *http.Request, existing *types.Tenant) {
+	var update struct {
+		Name    *string `json:"name"`
+		Contact *string `json:"contact"`
+		Plan    *string `json:"plan"`
+		Status  *string `json:"status"`
+		// An empty list allows every chain again
+		AllowedChains  *[]string `json:"allowedChains"`
+		AllowedMethods *[]string `json:"allowedMethods"`
+		// A 0 limit inherits the plan's limit again
+		RateLimit      *float64 `json:"rateLimit"`
+		RateBurst      *int     `json:"rateBurst"`
+		MaxConcurrency *int     `json:"maxConcurrency"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	t := *existing
+	if update.Name != nil {
+		t.Name = *update.Name
+	}
+	if update.Contact != nil {
+		t.Contact = *update.Contact
+	}
+	if update.Plan != nil {
+		t.Plan = *update.Plan
+	}
+	if update.Status != nil {
+		t.Status = *update.Status
+	}
+	if update.AllowedChains != nil {
+		t.AllowedChains = *update.AllowedChains
+	}
+	if update.AllowedMethods != nil {
+		t.AllowedMethods = *update.AllowedMethods
+	}
+	if update.RateLimit != nil {
+		t.RateLimit = *update.RateLimit
+	}
+	if update.RateBurst != nil {
+		t.RateBurst = *update.RateBurst
+	}
+	if update.MaxConcurrency != nil {
+		t.MaxConcurrency = *update.MaxConcurrency
+	}
+
+	if err := h.validateTenant(&t); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.checkTenantNameFree(w, t.Name, t.ID) {
+		return
+	}
+	// Tenants created before plans were defined may name one that doesn't exist; that is only
+	// rejected when the plan is changed
+	if t.Plan != existing.Plan && !h.checkPlanExists(w, t.Plan) {
+		return
+	}
+
+	if err := h.tenantRepo.Update(&t); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update tenant: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.reloadTenants()
+	if t.Status != existing.Status {
+		log.Printf("Tenant %s (ID %d) is now %s", t.Name, t.ID, t.Status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&t)
+}
+
+func (h *MultiChainAdminHandler) deleteTenant(w http.ResponseWriter, r *http.Request, t *types.Tenant) {
+	if err := h.tenantRepo.Delete(t.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete tenant: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.reloadTenants()
+	log.Printf("Deleted tenant %s (ID %d) and its API keys", t.Name, t.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MultiChainAdminHandler) listTenantKeys(w http.ResponseWriter, r *http.Request, t *types.Tenant) {
+	keys, err := h.apiKeyRepo.GetByTenant(t.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get API keys: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"keys":  keys,
+		"total": len(keys),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// createTenantKey issues a new API key for the tenant. The response is the only place the
+// key appears: only its hash is stored.
+func (h *MultiChainAdminHandler) createTenantKey(w http.ResponseWriter, r *http.Request, t *types.Tenant) {
+	var request struct {
+		Name           string   `json:"name"`
+		AllowedOrigins []string `json:"allowedOrigins"`
+		AllowedCIDRs   []string `json:"allowedCidrs"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(request.Name) > 100 {
+		http.Error(w, "API key name must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	origins, cidrs, err := normalizeKeyRestrictions(request.AllowedOrigins, request.AllowedCIDRs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, secret, err := tenant.NewAPIKey(t.ID, request.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	key.AllowedOrigins, key.AllowedCIDRs = origins, cidrs
+	if err := h.apiKeyRepo.Create(key); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create API key: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.reloadTenants()
+	log.Printf("Issued API key %s... (ID %d) to tenant %s", key.Prefix, key.ID, t.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issuedAPIKey{APIKey: key, Key: secret})
+}
+
+// updateTenantKey replaces the origins and client CIDRs a key may be used from; an omitted
+// list is kept and an empty one lifts the restriction
+func (h *MultiChainAdminHandler) updateTenantKey(w http.ResponseWriter, r *http.Request, t *types.Tenant, keyID int) {
+	var update struct {
+		AllowedOrigins *[]string `json:"allowedOrigins"`
+		AllowedCIDRs   *[]string `json:"allowedCidrs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	keys, err := h.apiKeyRepo.GetByTenant(t.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get API keys: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var existing *types.APIKey
+	for _, key := range keys {
+		if key.ID == keyID {
+			existing = key
+		}
+	}
+	if existing == nil {
+		http.Error(w, fmt.Sprintf("API key with ID %d not found", keyID), http.StatusNotFound)
+		return
+	}
+
+	origins, cidrs := existing.AllowedOrigins, existing.AllowedCIDRs
+	if update.AllowedOrigins != nil {
+		origins = *update.AllowedOrigins
+	}
+	if update.AllowedCIDRs != nil {
+		cidrs = *update.AllowedCIDRs
+	}
+	origins, cidrs, err = normalizeKeyRestrictions(origins, cidrs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.apiKeyRepo.SetRestrictions(t.ID, keyID, origins, cidrs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.reloadTenants()
+	log.Printf("Updated restrictions of API key %s... (ID %d) of tenant %s", key.Prefix, key.ID, t.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+func (h *MultiChainAdminHandler) revokeTenantKey(w http.ResponseWriter, r *http.Request, t *types.Tenant, keyID int) {
+	key, err := h.apiKeyRepo.Revoke(t.ID, keyID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.reloadTenants()
+	log.Printf("Revoked API key %s... (ID %d) of tenant %s", key.Prefix, key.ID, t.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+// validateTenant checks a tenant's fields before it is stored
+func (h *MultiChainAdminHandler) validateTenant(t *types.Tenant) error {
+	t.Name = strings.TrimSpace(t.Name)
+	switch {
+	case t.Name == "":
+		return fmt.Errorf("tenant name is required")
+	case len(t.Name) > 100:
+		return fmt.Errorf("tenant name must be at most 100 characters")
+	case len(t.Contact) > 200:
+		return fmt.Errorf("tenant contact must be at most 200 characters")
+	case len(t.Plan) > 50:
+		return fmt.Errorf("tenant plan must be at most 50 characters")
+	case t.Status != types.TenantActive && t.Status != types.TenantSuspended:
+		return fmt.Errorf("tenant status must be %s or %s", types.TenantActive, types.TenantSuspended)
+	case t.RateLimit < 0 || t.RateBurst < 0 || t.MaxConcurrency < 0:
+		return fmt.Errorf("tenant rate limit, burst and max concurrency must not be negative")
+	}
+
+	// Chains added later can be allowed by updating the tenant, so only known chains are accepted
+	for _, chainName := range t.AllowedChains {
+		if h.config.GetChainByName(chainName) == nil {
+			return fmt.Errorf("allowed chain %s not found", chainName)
+		}
+	}
+	for _, pattern := range t.AllowedMethods {
+		if !methodPatternRegex.MatchString(pattern) {
+			return fmt.Errorf("allowed method %q must be a method name, optionally ending in * to match a prefix", pattern)
+		}
+	}
+	return nil
+}
+
+// normalizeKeyRestrictions validates a key's allowed origins and CIDRs, returning them in
+// the form they are matched in
+func normalizeKeyRestrictions(origins, cidrs []string) ([]string, []string, error) {
+	normalizedOrigins := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		normalized, err := tenant.NormalizeOrigin(origin)
+		if err != nil {
+			return nil, nil, err
+		}
+		normalizedOrigins = append(normalizedOrigins, normalized)
+	}
+	normalizedCIDRs := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		normalized, err := tenant.NormalizeCIDR(cidr)
+		if err != nil {
+			return nil, nil, err
+		}
+		normalizedCIDRs = append(normalizedCIDRs, normalized)
+	}
+	return normalizedOrigins, normalizedCIDRs, nil
+}
+
+// checkTenantNameFree writes a conflict unless no tenant other than id uses name
+func (h *MultiChainAdminHandler) checkTenantNameFree(w http.ResponseWriter, name string, id int) bool {
+	tenants, err := h.tenantRepo.GetAll()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tenants: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	for _, other := range tenants {
+		if other.ID != id && strings.EqualFold(other.Name, name) {
+			http.Error(w, fmt.Sprintf("Tenant %s already exists", name), http.StatusConflict)
+			return false
+		}
+	}
+	return true
+}
+
+// handleRefusalAnalytics handles GET /admin/analytics/refusals: requests refused by tenant
+// policy since start, per tenant, kind and refused chain or method
+func (h *MultiChainAdminHandler) handleRefusalAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.refusals == nil {
+		http.Error(w, "Refusal analytics are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	tenantID := 0
+	if tenantStr := r.URL.Query().Get("tenant"); tenantStr != "" {
+		parsed, err := strconv.Atoi(tenantStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "tenant must be a tenant ID", http.StatusBadRequest)
+			return
+		}
+		tenantID = parsed
+	}
+
+	refusals := h.refusals.Refusals(tenantID)
+	response := map[string]interface{}{
+		"since":    h.refusals.Since(),
+		"refusals": refusals,
+		"total":    len(refusals),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// reloadTenants applies a tenant or key change to the proxy immediately
+func (h *MultiChainAdminHandler) reloadTenants() {
+	if h.tenants != nil {
+		h.tenants.ReloadAfterChange()
+	}
+}
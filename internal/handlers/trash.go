@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/types"
+)
+
+// Deleted chains and endpoints are soft-deleted: they stay in the database with deletedAt set,
+// can be listed with ?deleted=true, brought back with POST .../restore, or removed for good
+// with DELETE ...?permanent=true.
+
+// wantsDeleted reports whether a list request asks for trashed items instead of live ones
+func wantsDeleted(r *http.Request) bool {
+	return r.URL.Query().Get("deleted") == "true"
+}
+
+func wantsPermanent(r *http.Request) bool {
+	return r.URL.Query().Get("permanent") == "true"
+}
+
+// handleChainRestore handles POST /admin/chains/{chainName}/restore
+func (h *MultiChainAdminHandler) handleChainRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chainName := h.extractChainNameFromPath(r.URL.Path, "/admin/chains/")
+	if chainName == "" {
+		http.Error(w, "Invalid chain name", http.StatusBadRequest)
+		return
+	}
+
+	h.restoreChain(w, r, chainName)
+}
+
+func (h *MultiChainAdminHandler) listDeletedChains(w http.ResponseWriter, r *http.Request) {
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	chains, err := h.chainRepo.GetDeleted()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list deleted chains: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"chains": chains,
+		"total":  len(chains),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// restoreChain takes a chain out of the trash together with the endpoints and configs it had
+// when it was deleted, and starts health checking it again if it is enabled
+func (h *MultiChainAdminHandler) restoreChain(w http.ResponseWriter, r *http.Request, chainName string) {
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	if h.config.GetChainByName(chainName) != nil {
+		http.Error(w, fmt.Sprintf("Chain %s is not deleted", chainName), http.StatusConflict)
+		return
+	}
+
+	chain, err := h.chainRepo.GetDeletedByName(chainName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Deleted chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	// Load everything before restoring so a failed read leaves the chain in the trash
+	endpoints, err := h.endpointRepo.GetAllByChain(chainName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load endpoints: %v", err), http.StatusInternalServerError)
+		return
+	}
+	configs, err := h.chainConfigRepo.GetByChainID(chain.ID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load chain configs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.chainRepo.Restore(chain.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to restore chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+	chain.DeletedAt = nil
+
+	h.config.AddChain(chain)
+	h.config.SetChainEndpoints(chainName, endpoints)
+	h.config.SetChainConfigs(chainName, configs)
+	if chain.IsEnabled {
+		h.multiChainHealthChecker.AddChain(chainName, &health.ChainConfig{
+			Chain:     chain,
+			Endpoints: endpoints,
+			Configs:   configs,
+		})
+	}
+	log.Printf("Restored chain %s with %d endpoints", chainName, len(endpoints))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chain)
+}
+
+// purgeChain permanently deletes a live or trashed chain along with its endpoints and configs
+func (h *MultiChainAdminHandler) purgeChain(w http.ResponseWriter, r *http.Request, chainName string) {
+	if h.chainRepo == nil {
+		http.Error(w, "Chain management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	live := h.config.GetChainByName(chainName)
+	chain := live
+	if chain == nil {
+		trashed, err := h.chainRepo.GetDeletedByName(chainName)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+			return
+		}
+		chain = trashed
+	}
+
+	if err := h.chainRepo.Purge(chain.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete chain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if live != nil {
+		h.multiChainHealthChecker.RemoveChain(chainName)
+		h.config.RemoveChain(chainName)
+	}
+	log.Printf("Permanently deleted chain %s", chainName)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MultiChainAdminHandler) listDeletedChainEndpoints(w http.ResponseWriter, r *http.Request, chainName string) {
+	if h.endpointRepo == nil {
+		http.Error(w, "Endpoint management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	if h.config.GetChainByName(chainName) == nil {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	endpoints, err := h.endpointRepo.GetDeletedByChain(chainName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list deleted endpoints: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"chain_name":      chainName,
+		"total_endpoints": len(endpoints),
+		"endpoints":       endpoints,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// lookupDeletedChainEndpoint finds a trashed endpoint belonging to a live chain
+func (h *MultiChainAdminHandler) lookupDeletedChainEndpoint(chainName string, endpointID int) (*types.RPCEndpoint, int, error) {
+	if h.config.GetChainByName(chainName) == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("Chain %s not found", chainName)
+	}
+
+	endpoints, err := h.endpointRepo.GetDeletedByChain(chainName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to list deleted endpoints: %v", err)
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.ID == endpointID {
+			return endpoint, http.StatusOK, nil
+		}
+	}
+
+	return nil, http.StatusNotFound, fmt.Errorf("Deleted endpoint %d not found on chain %s", endpointID, chainName)
+}
+
+func (h *MultiChainAdminHandler) restoreChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	if h.endpointRepo == nil {
+		http.Error(w, "Endpoint management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	if _, status, err := h.lookupDeletedChainEndpoint(chainName, endpointID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	endpoint, err := h.endpointRepo.Restore(endpointID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to restore endpoint: %v", err), http.StatusInternalServerError)
+		return
+	}
+	endpoint.ChainName = chainName
+
+	h.applyEndpoint(chainName, endpoint)
+	log.Printf("Restored endpoint %d on chain %s", endpointID, chainName)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+// purgeChainEndpoint permanently deletes a live or trashed endpoint
+func (h *MultiChainAdminHandler) purgeChainEndpoint(w http.ResponseWriter, r *http.Request, chainName string, endpointID int) {
+	if h.endpointRepo == nil {
+		http.Error(w, "Endpoint management requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	if h.config.GetChainByName(chainName) == nil {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	_, _, err := h.lookupChainEndpoint(chainName, endpointID)
+	live := err == nil
+	if !live {
+		if _, status, err := h.lookupDeletedChainEndpoint(chainName, endpointID); err != nil {
+			if status == http.StatusNotFound {
+				err = fmt.Errorf("Endpoint %d not found on chain %s", endpointID, chainName)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+
+	if err := h.endpointRepo.Purge(endpointID); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete endpoint: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if live {
+		if err := h.multiChainHealthChecker.RemoveEndpoint(chainName, endpointID); err != nil {
+			log.Printf("Endpoint %d deleted from database but not from health checker: %v", endpointID, err)
+		}
+		h.config.SetChainEndpoints(chainName, h.multiChainHealthChecker.GetAllEndpoints(chainName))
+	}
+	log.Printf("Permanently deleted endpoint %d on chain %s", endpointID, chainName)
+
+	w.WriteHeader(http.StatusNoContent)
+}
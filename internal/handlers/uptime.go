@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// endpointUptime totals an endpoint's rollups over the requested history
+type endpointUptime struct {
+	EndpointID        int     `json:"endpointId"`
+	Name              string  `json:"name,omitempty"`
+	ChainName         string  `json:"chainName,omitempty"`
+	Checks            int64   `json:"checks"`
+	HealthyChecks     int64   `json:"healthyChecks"`
+	UptimePercent     float64 `json:"uptimePercent"`
+	AvgResponseTimeMs float64 `json:"avgResponseTimeMs"`
+	// WorstP95ResponseTimeMs is the highest p95 of any single period; p95s cannot be combined
+	WorstP95ResponseTimeMs int64 `json:"worstP95ResponseTimeMs"`
+}
+
+// handleUptimeAnalytics reports uptime and latency from the health check rollups rather than
+// raw health checks. ?granularity=hour (default) or day picks the rollups, ?history= how far
+// back they go (default 24h for hours, 720h for days), and ?chain= or ?endpointId= narrow them.
+func (h *MultiChainAdminHandler) handleUptimeAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.healthRollupRepo == nil {
+		http.Error(w, "Uptime analytics require a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+
+	granularity := query.Get("granularity")
+	history := 24 * time.Hour
+	switch granularity {
+	case "", types.RollupHourly:
+		granularity = types.RollupHourly
+	case types.RollupDaily:
+		history = 30 * 24 * time.Hour
+	default:
+		http.Error(w, "granularity must be hour or day", http.StatusBadRequest)
+		return
+	}
+
+	if historyStr := query.Get("history"); historyStr != "" {
+		parsed, err := time.ParseDuration(historyStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid history duration", http.StatusBadRequest)
+			return
+		}
+		history = parsed
+	}
+
+	endpointID := 0
+	if idStr := query.Get("endpointId"); idStr != "" {
+		parsed, err := strconv.Atoi(idStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid endpoint ID", http.StatusBadRequest)
+			return
+		}
+		endpointID = parsed
+	}
+
+	// Name the endpoints this process knows about; rollups can outlive an endpoint
+	endpoints := make(map[int]*types.RPCEndpoint)
+	for _, chain := range h.config.GetChains() {
+		for _, endpoint := range h.config.GetChainEndpoints(chain.Name) {
+			endpoints[endpoint.ID] = endpoint
+		}
+	}
+
+	chainName := query.Get("chain")
+	if chainName != "" && h.config.GetChainByName(chainName) == nil {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+
+	since := time.Now().UTC().Add(-history).Truncate(types.RollupPeriod(granularity))
+	rollups, err := h.healthRollupRepo.GetSince(granularity, endpointID, since)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get health check rollups: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	filtered := rollups[:0]
+	totals := make(map[int]*endpointUptime)
+	totalLatency := make(map[int]float64)
+	for _, rollup := range rollups {
+		endpoint := endpoints[rollup.EndpointID]
+		if chainName != "" && (endpoint == nil || endpoint.ChainName != chainName) {
+			continue
+		}
+		filtered = append(filtered, rollup)
+
+		total, exists := totals[rollup.EndpointID]
+		if !exists {
+			total = &endpointUptime{EndpointID: rollup.EndpointID}
+			if endpoint != nil {
+				total.Name = endpoint.Name
+				total.ChainName = endpoint.ChainName
+			}
+			totals[rollup.EndpointID] = total
+		}
+		total.Checks += rollup.Checks
+		total.HealthyChecks += rollup.HealthyChecks
+		totalLatency[rollup.EndpointID] += rollup.AvgResponseTimeMs * float64(rollup.HealthyChecks)
+		if rollup.P95ResponseTimeMs > total.WorstP95ResponseTimeMs {
+			total.WorstP95ResponseTimeMs = rollup.P95ResponseTimeMs
+		}
+	}
+
+	summaries := make([]*endpointUptime, 0, len(totals))
+	for id, total := range totals {
+		if total.Checks > 0 {
+			total.UptimePercent = float64(total.HealthyChecks) * 100 / float64(total.Checks)
+		}
+		if total.HealthyChecks > 0 {
+			total.AvgResponseTimeMs = totalLatency[id] / float64(total.HealthyChecks)
+		}
+		summaries = append(summaries, total)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].EndpointID < summaries[j].EndpointID })
+
+	response := map[string]interface{}{
+		"granularity": granularity,
+		"since":       since,
+		"endpoints":   summaries,
+		"rollups":     filtered,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// usageDayFormat is the format of the from and to query parameters
+	usageDayFormat = "2006-01-02"
+
+	// defaultUsageDays is the range reported when from is omitted, ending on to
+	defaultUsageDays = 30
+	// maxUsageDays bounds the range of one query
+	maxUsageDays = 366
+)
+
+// usageQuery selects metered API key usage
+type usageQuery struct {
+	keyID    int
+	tenantID int
+	from     time.Time
+	to       time.Time
+}
+
+// parseUsageQuery reads key, tenant, from and to; from and to are UTC days, inclusive, and
+// default to the last defaultUsageDays days
+func parseUsageQuery(r *http.Request) (usageQuery, error) {
+	query := r.URL.Query()
+	var q usageQuery
+
+	if keyStr := query.Get("key"); keyStr != "" {
+		keyID, err := strconv.Atoi(keyStr)
+		if err != nil || keyID <= 0 {
+			return q, fmt.Errorf("key must be an API key ID")
+		}
+		q.keyID = keyID
+	}
+	if tenantStr := query.Get("tenant"); tenantStr != "" {
+		tenantID, err := strconv.Atoi(tenantStr)
+		if err != nil || tenantID <= 0 {
+			return q, fmt.Errorf("tenant must be a tenant ID")
+		}
+		q.tenantID = tenantID
+	}
+
+	q.to = time.Now().UTC().Truncate(24 * time.Hour)
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := time.Parse(usageDayFormat, toStr)
+		if err != nil {
+			return q, fmt.Errorf("to must be a date like %s", usageDayFormat)
+		}
+		q.to = to
+	}
+	q.from = q.to.AddDate(0, 0, -(defaultUsageDays - 1))
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := time.Parse(usageDayFormat, fromStr)
+		if err != nil {
+			return q, fmt.Errorf("from must be a date like %s", usageDayFormat)
+		}
+		q.from = from
+	}
+
+	if q.from.After(q.to) {
+		return q, fmt.Errorf("from must not be after to")
+	}
+	if q.to.Sub(q.from) >= maxUsageDays*24*time.Hour {
+		return q, fmt.Errorf("a usage query covers at most %d days", maxUsageDays)
+	}
+	return q, nil
+}
+
+// handleUsage handles GET /admin/usage: daily requests, errors and compute units per API key
+func (h *MultiChainAdminHandler) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.usageRepo == nil {
+		http.Error(w, "Usage metering requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	q, err := parseUsageQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.usageRepo.GetRange(q.keyID, q.tenantID, q.from, q.to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var requests, errors, computeUnits int64
+	for _, u := range usage {
+		requests += u.Requests
+		errors += u.Errors
+		computeUnits += u.ComputeUnits
+	}
+
+	response := map[string]interface{}{
+		"from":  q.from.Format(usageDayFormat),
+		"to":    q.to.Format(usageDayFormat),
+		"usage": usage,
+		"totals": map[string]interface{}{
+			"requests":     requests,
+			"errors":       errors,
+			"computeUnits": computeUnits,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// usageExportRow is one tenant's usage of one method on one chain, over the whole range or,
+// for daily exports, on one day
+type usageExportRow struct {
+	Day          string `json:"day,omitempty"`
+	ChainName    string `json:"chainName"`
+	Method       string `json:"method"`
+	Requests     int64  `json:"requests"`
+	Errors       int64  `json:"errors"`
+	ComputeUnits int64  `json:"computeUnits"`
+}
+
+// usageExportTenant is one tenant's section of a usage export
+type usageExportTenant struct {
+	TenantID     int               `json:"tenantId"`
+	Tenant       string            `json:"tenant"`
+	Plan         string            `json:"plan"`
+	Requests     int64             `json:"requests"`
+	Errors       int64             `json:"errors"`
+	ComputeUnits int64             `json:"computeUnits"`
+	Buckets      []*usageExportRow `json:"buckets"`
+}
+
+// handleUsageExport handles GET /admin/usage/export: per-tenant usage by chain and method
+// over a date range, as JSON or, with ?format=csv or an Accept header naming text/csv, CSV.
+// ?daily=true splits the buckets by day.
+func (h *MultiChainAdminHandler) handleUsageExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.usageRepo == nil {
+		http.Error(w, "Usage metering requires a database", http.StatusServiceUnavailable)
+		return
+	}
+
+	q, err := parseUsageQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.keyID != 0 {
+		http.Error(w, "Usage exports are per tenant; filter by tenant instead of key", http.StatusBadRequest)
+		return
+	}
+	daily := false
+	if dailyStr := r.URL.Query().Get("daily"); dailyStr != "" {
+		daily, err = strconv.ParseBool(dailyStr)
+		if err != nil {
+			http.Error(w, "daily must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	buckets, err := h.usageRepo.GetBuckets(q.tenantID, q.from, q.to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get usage: %v", err), http.StatusInternalServerError)
+		return
+	}
+	tenants, err := h.tenantRepo.GetAll()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get tenants: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	export := buildUsageExport(buckets, tenants, daily)
+
+	from, to := q.from.Format(usageDayFormat), q.to.Format(usageDayFormat)
+	if wantsCSV(r.URL.Query().Get("format"), r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=usage-%s-%s.csv", from, to))
+		writeUsageCSV(w, export, daily)
+		return
+	}
+
+	response := map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"tenants": export,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// buildUsageExport groups buckets by tenant, summing them over the range unless daily.
+// Tenants are ordered by name and their buckets by day, chain and method; usage of deleted
+// tenants is kept under their ID.
+func buildUsageExport(buckets []*types.UsageBucket, tenants []*types.Tenant, daily bool) []*usageExportTenant {
+	byID := make(map[int]*types.Tenant, len(tenants))
+	for _, t := range tenants {
+		byID[t.ID] = t
+	}
+
+	type rowKey struct {
+		tenantID           int
+		day, chain, method string
+	}
+	sections := make(map[int]*usageExportTenant)
+	rows := make(map[rowKey]*usageExportRow)
+	for _, b := range buckets {
+		section, ok := sections[b.TenantID]
+		if !ok {
+			section = &usageExportTenant{TenantID: b.TenantID, Tenant: fmt.Sprintf("(deleted tenant %d)", b.TenantID)}
+			if t, ok := byID[b.TenantID]; ok {
+				section.Tenant, section.Plan = t.Name, t.Plan
+			}
+			sections[b.TenantID] = section
+		}
+		section.Requests += b.Requests
+		section.Errors += b.Errors
+		section.ComputeUnits += b.ComputeUnits
+
+		key := rowKey{tenantID: b.TenantID, chain: b.ChainName, method: b.Method}
+		if daily {
+			key.day = b.Day.UTC().Format(usageDayFormat)
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &usageExportRow{Day: key.day, ChainName: b.ChainName, Method: b.Method}
+			rows[key] = row
+			section.Buckets = append(section.Buckets, row)
+		}
+		row.Requests += b.Requests
+		row.Errors += b.Errors
+		row.ComputeUnits += b.ComputeUnits
+	}
+
+	export := make([]*usageExportTenant, 0, len(sections))
+	for _, section := range sections {
+		sort.Slice(section.Buckets, func(i, j int) bool {
+			a, b := section.Buckets[i], section.Buckets[j]
+			if a.Day != b.Day {
+				return a.Day < b.Day
+			}
+			if a.ChainName != b.ChainName {
+				return a.ChainName < b.ChainName
+			}
+			return a.Method < b.Method
+		})
+		export = append(export, section)
+	}
+	sort.Slice(export, func(i, j int) bool {
+		if export[i].Tenant != export[j].Tenant {
+			return export[i].Tenant < export[j].Tenant
+		}
+		return export[i].TenantID < export[j].TenantID
+	})
+	return export
+}
+
+// writeUsageCSV writes one line per tenant bucket
+func writeUsageCSV(w http.ResponseWriter, export []*usageExportTenant, daily bool) {
+	out := csv.NewWriter(w)
+	header := []string{"tenant_id", "tenant", "plan"}
+	if daily {
+		header = append(header, "day")
+	}
+	out.Write(append(header, "chain", "method", "requests", "errors", "compute_units"))
+
+	for _, section := range export {
+		for _, row := range section.Buckets {
+			record := []string{strconv.Itoa(section.TenantID), section.Tenant, section.Plan}
+			if daily {
+				record = append(record, row.Day)
+			}
+			record = append(record, row.ChainName, row.Method,
+				strconv.FormatInt(row.Requests, 10), strconv.FormatInt(row.Errors, 10), strconv.FormatInt(row.ComputeUnits, 10))
+			out.Write(record)
+		}
+	}
+	out.Flush()
+}
+
+// wantsCSV reports whether an explicit format or an Accept header asks for CSV
+func wantsCSV(format, accept string) bool {
+	if format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(strings.ToLower(accept), "text/csv")
+}
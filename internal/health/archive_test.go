@@ -0,0 +1,73 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+func TestProbeArchiveCapability(t *testing.T) {
+	tests := []struct {
+		name string
+		// reply answers the probe; nil closes the connection instead
+		reply       func(w http.ResponseWriter)
+		wasArchive  bool
+		wantArchive bool
+		wantProbed  bool
+	}{
+		{"state served", func(w http.ResponseWriter) {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x0"}`))
+		}, false, true, true},
+		{"state pruned", func(w http.ResponseWriter) {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"missing trie node"}}`))
+		}, true, false, true},
+		{"pruned with an error status", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"missing trie node"}}`))
+		}, true, false, true},
+		{"server error", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, true, true, false},
+		{"rate limited", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"rate limit exceeded"}}`))
+		}, true, true, false},
+		{"connection dropped", nil, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.reply == nil {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				tt.reply(w)
+			}))
+			defer upstream.Close()
+
+			endpoint := &types.RPCEndpoint{ID: 1, Name: "node", URL: upstream.URL, Weight: 1, Healthy: true, BlockNumber: "20000", Archive: tt.wasArchive}
+			chain := &ChainConfig{Chain: &types.Chain{Name: "ethereum"}, Endpoints: []*types.RPCEndpoint{endpoint}}
+			mc := NewMultiChainChecker(map[string]*ChainConfig{"ethereum": chain}, HealthCheckConfig{
+				Timeout:              time.Second,
+				Retries:              1,
+				ArchiveProbeDepth:    10000,
+				ArchiveProbeInterval: time.Hour,
+			})
+
+			mc.probeArchiveCapability("ethereum", chain)
+
+			if got := endpoint.IsArchive(); got != tt.wantArchive {
+				t.Errorf("archive = %v, want %v", got, tt.wantArchive)
+			}
+			if probed := !mc.archiveProbeDue(endpoint); probed != tt.wantProbed {
+				t.Errorf("probe recorded = %v, want %v", probed, tt.wantProbed)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Beacon API paths the health check reads
+const (
+	beaconHealthPath    = "/eth/v1/node/health"
+	beaconSyncingPath   = "/eth/v1/node/syncing"
+	beaconPeerCountPath = "/eth/v1/node/peer_count"
+)
+
+// beaconSyncing is the status /eth/v1/node/health returns while a beacon node is syncing
+const beaconSyncing = http.StatusPartialContent
+
+// beaconSyncStatus is the part of an /eth/v1/node/syncing reply the health check reads
+type beaconSyncStatus struct {
+	Data struct {
+		HeadSlot  string `json:"head_slot"`
+		IsSyncing bool   `json:"is_syncing"`
+	} `json:"data"`
+}
+
+// beaconStrategy checks consensus-layer nodes through their Beacon API: /eth/v1/node/health
+// must report ready rather than syncing, and the head slot from /eth/v1/node/syncing is their
+// height, so block lag is counted in slots. With sync checks the peer count comes from
+// /eth/v1/node/peer_count.
+type beaconStrategy struct{}
+
+func (beaconStrategy) BuildProbe(probe ProbeContext) []ProbeCall {
+	calls := []ProbeCall{{Path: beaconHealthPath}, {Path: beaconSyncingPath}}
+	if probe.CheckSync {
+		calls = append(calls, ProbeCall{Path: beaconPeerCountPath, Optional: true})
+	}
+	return calls
+}
+
+func (beaconStrategy) ValidateResponse(probe ProbeContext, replies []ProbeReply) (NodeState, error) {
+	health := findReply(replies, beaconHealthPath)
+	if health.Status != http.StatusOK && health.Status != beaconSyncing {
+		return NodeState{}, fmt.Errorf("HTTP %d", health.Status)
+	}
+	syncStatus, err := decodeBeaconSyncStatus(replies)
+	if err != nil {
+		return NodeState{}, err
+	}
+
+	state := NodeState{Syncing: health.Status == beaconSyncing || syncStatus.Data.IsSyncing, PeerCount: -1}
+	if reply := findReply(replies, beaconPeerCountPath); reply != nil && reply.Err == nil && reply.Status == http.StatusOK {
+		var peers struct {
+			Data struct {
+				Connected string `json:"connected"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(reply.Result, &peers) == nil {
+			if connected, err := strconv.ParseInt(peers.Data.Connected, 10, 64); err == nil {
+				state.PeerCount = connected
+			}
+		}
+	}
+	return state, nil
+}
+
+func (beaconStrategy) ExtractHeight(replies []ProbeReply) (int64, error) {
+	syncStatus, err := decodeBeaconSyncStatus(replies)
+	if err != nil {
+		return 0, err
+	}
+	headSlot, err := strconv.ParseInt(syncStatus.Data.HeadSlot, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid head slot %q", syncStatus.Data.HeadSlot)
+	}
+	return headSlot, nil
+}
+
+func decodeBeaconSyncStatus(replies []ProbeReply) (*beaconSyncStatus, error) {
+	reply := findReply(replies, beaconSyncingPath)
+	if reply.Status != http.StatusOK {
+		return nil, fmt.Errorf("sync status request returned HTTP %d", reply.Status)
+	}
+	var syncStatus beaconSyncStatus
+	if err := json.Unmarshal(reply.Result, &syncStatus); err != nil {
+		return nil, fmt.Errorf("invalid sync status response")
+	}
+	return &syncStatus, nil
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// bitcoinChainInfo is the part of a getblockchaininfo reply the health check reads
+type bitcoinChainInfo struct {
+	Chain                string `json:"chain"`
+	Blocks               int64  `json:"blocks"`
+	InitialBlockDownload bool   `json:"initialblockdownload"`
+	Pruned               bool   `json:"pruned"`
+}
+
+// bitcoinStrategy checks Bitcoin nodes with getblockchaininfo: a node still in initial block
+// download is syncing, its block count is its height and pruned nodes are not archive nodes.
+// With sync checks the peer count comes from getconnectioncount.
+type bitcoinStrategy struct{}
+
+func (bitcoinStrategy) BuildProbe(probe ProbeContext) []ProbeCall {
+	calls := []ProbeCall{{Method: "getblockchaininfo", Params: []interface{}{}}}
+	if probe.CheckSync {
+		calls = append(calls, ProbeCall{Method: "getconnectioncount", Params: []interface{}{}, Optional: true})
+	}
+	return calls
+}
+
+func (bitcoinStrategy) ValidateResponse(probe ProbeContext, replies []ProbeReply) (NodeState, error) {
+	info, err := decodeBitcoinChainInfo(replies)
+	if err != nil {
+		return NodeState{}, err
+	}
+
+	archive := !info.Pruned
+	state := NodeState{Syncing: info.InitialBlockDownload, PeerCount: -1, Archive: &archive}
+	if reply := findReply(replies, "getconnectioncount"); reply != nil && reply.Err == nil {
+		json.Unmarshal(reply.Result, &state.PeerCount)
+	}
+	return state, nil
+}
+
+func (bitcoinStrategy) ExtractHeight(replies []ProbeReply) (int64, error) {
+	info, err := decodeBitcoinChainInfo(replies)
+	if err != nil {
+		return 0, err
+	}
+	return info.Blocks, nil
+}
+
+func decodeBitcoinChainInfo(replies []ProbeReply) (*bitcoinChainInfo, error) {
+	var info bitcoinChainInfo
+	if err := json.Unmarshal(findReply(replies, "getblockchaininfo").Result, &info); err != nil || info.Chain == "" {
+		return nil, fmt.Errorf("invalid getblockchaininfo response")
+	}
+	return &info, nil
+}
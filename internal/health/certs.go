@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// certWarningInterval limits how often an expiring certificate is logged per endpoint
+const certWarningInterval = 24 * time.Hour
+
+// recordCertExpiry stores the leaf certificate expiry of an HTTPS upstream and warns when it
+// expires within CertExpiryWarningDays
+func (mc *MultiChainChecker) recordCertExpiry(endpoint *types.RPCEndpoint, state *tls.ConnectionState) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return
+	}
+
+	expiresAt := state.PeerCertificates[0].NotAfter
+	endpoint.SetCertExpiresAt(expiresAt)
+
+	if !certExpiresSoon(expiresAt, mc.healthSettings().CertExpiryWarningDays) || endpoint.IsInMaintenance() {
+		return
+	}
+
+	mc.certMu.Lock()
+	lastWarning, warned := mc.lastCertWarning[endpoint]
+	if warned && time.Since(lastWarning) < certWarningInterval {
+		mc.certMu.Unlock()
+		return
+	}
+	mc.lastCertWarning[endpoint] = time.Now()
+	mc.certMu.Unlock()
+
+	daysLeft := int(time.Until(expiresAt).Hours() / 24)
+	if daysLeft < 0 {
+		log.Printf("WARNING: TLS certificate for %s (%s) expired on %s",
+			endpoint.Name, endpoint.URL, expiresAt.Format(time.RFC3339))
+		return
+	}
+	log.Printf("WARNING: TLS certificate for %s (%s) expires in %d days (%s)",
+		endpoint.Name, endpoint.URL, daysLeft, expiresAt.Format(time.RFC3339))
+}
+
+// ScanCertExpiry logs every enabled HTTPS endpoint whose certificate expires within
+// CertExpiryWarningDays in one summary, regardless of when each was last warned about
+func (mc *MultiChainChecker) ScanCertExpiry(ctx context.Context) error {
+	warningDays := mc.healthSettings().CertExpiryWarningDays
+	if warningDays <= 0 {
+		return nil
+	}
+
+	mc.mu.RLock()
+	var expiring []string
+	for chainName, chainConfig := range mc.chains {
+		for _, endpoint := range chainConfig.Endpoints {
+			expiresAt := endpoint.GetCertExpiresAt()
+			if endpoint.Enabled && certExpiresSoon(expiresAt, warningDays) {
+				expiring = append(expiring, fmt.Sprintf("%s/%s (%s)", chainName, endpoint.Name, expiresAt.Format("2006-01-02")))
+			}
+		}
+	}
+	mc.mu.RUnlock()
+
+	if len(expiring) > 0 {
+		sort.Strings(expiring)
+		log.Printf("WARNING: %d upstream TLS certificates expire within %d days: %s",
+			len(expiring), warningDays, strings.Join(expiring, ", "))
+	}
+	return nil
+}
+
+// certExpiresSoon reports whether a certificate expires within the warning window
+func certExpiresSoon(expiresAt time.Time, warningDays int) bool {
+	if warningDays <= 0 || expiresAt.IsZero() {
+		return false
+	}
+	return time.Until(expiresAt) < time.Duration(warningDays)*24*time.Hour
+}
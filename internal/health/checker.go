@@ -17,6 +17,32 @@ type HealthCheckConfig struct {
 	Interval time.Duration
 	Timeout  time.Duration
 	Retries  int
+
+	// CheckSync enables eth_syncing/net_peerCount probing; syncing endpoints are excluded from routing
+	CheckSync bool
+	// MinPeerCount excludes endpoints reporting fewer peers (0 disables the peer check)
+	MinPeerCount int
+
+	// ArchiveProbeDepth is how many blocks behind head the archive probe queries (0 disables probing)
+	ArchiveProbeDepth int64
+	// ArchiveProbeInterval is how often each endpoint's archive capability is re-probed
+	ArchiveProbeInterval time.Duration
+
+	// Jitter is the maximum random delay added before each scheduled endpoint probe
+	Jitter time.Duration
+	// Spread randomizes each chain's phase and staggers endpoint probes across the interval
+	Spread bool
+
+	// MaxConcurrency is how many endpoint probes run at once across all chains
+	MaxConcurrency int
+	// MaxProbesPerHost caps concurrent probes against one upstream host (0 is unlimited)
+	MaxProbesPerHost int
+
+	// CheckGasPrice probes eth_gasPrice on chains that set gas_price_gwei_threshold
+	CheckGasPrice bool
+
+	// CertExpiryWarningDays warns when an upstream TLS certificate expires within this many days (0 disables)
+	CertExpiryWarningDays int
 }
 
 type Checker struct {
@@ -63,6 +89,11 @@ func (hc *Checker) Stop() {
 	close(hc.stopChan)
 }
 
+// SetTransport replaces the HTTP transport used for health probes; call it before Start
+func (hc *Checker) SetTransport(transport http.RoundTripper) {
+	hc.client.Transport = transport
+}
+
 func (hc *Checker) healthCheckLoop() {
 	ticker := time.NewTicker(hc.config.Interval)
 	defer ticker.Stop()
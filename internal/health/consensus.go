@@ -0,0 +1,151 @@
+package health
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// defaultMaxBlockDivergence applies when a chain doesn't configure max_block_divergence
+	defaultMaxBlockDivergence = 100
+	// consensusQuorum is the minimum number of reporting endpoints needed to judge divergence
+	consensusQuorum = 3
+)
+
+// MaxBlockDivergence returns how far an endpoint's head may diverge from the consensus head
+// before it is flagged as suspect, or 0 when divergence detection is disabled
+func (cc *ChainConfig) MaxBlockDivergence() int64 {
+	val, exists := cc.Configs["max_block_divergence"]
+	if !exists {
+		return defaultMaxBlockDivergence
+	}
+
+	divergence, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+	if err != nil || divergence < 0 {
+		logInvalidConfig(cc.Chain.Name, "max_block_divergence", val, fmt.Sprintf("using %d", defaultMaxBlockDivergence))
+		return defaultMaxBlockDivergence
+	}
+
+	return divergence
+}
+
+// evaluateConsensus computes the median head of the chain's healthy endpoints and flags endpoints
+// whose head diverges from it (forked, stale, or pointing at the wrong network) as suspect
+func (mc *MultiChainChecker) evaluateConsensus(chainName string, chainConfig *ChainConfig) {
+	var heads []int64
+	for _, endpoint := range chainConfig.Endpoints {
+		if !endpoint.IsHealthy() {
+			continue
+		}
+		if blockNum, ok := parseBlockNumber(endpoint.GetBlockNumber()); ok {
+			heads = append(heads, blockNum)
+		}
+	}
+
+	if len(heads) < consensusQuorum {
+		mc.setConsensusHead(chainName, 0)
+		for _, endpoint := range chainConfig.Endpoints {
+			endpoint.SetSuspect(false)
+		}
+		return
+	}
+
+	sort.Slice(heads, func(i, j int) bool { return heads[i] < heads[j] })
+	consensusHead := heads[len(heads)/2]
+	mc.setConsensusHead(chainName, consensusHead)
+
+	maxDivergence := chainConfig.MaxBlockDivergence()
+	for _, endpoint := range chainConfig.Endpoints {
+		// An endpoint out of rotation keeps its flag, so a suspect one its probe kept out
+		// stays suspect until it rejoins the consensus
+		if !endpoint.IsHealthy() {
+			continue
+		}
+		blockNum, ok := parseBlockNumber(endpoint.GetBlockNumber())
+		if !ok || maxDivergence == 0 {
+			endpoint.SetSuspect(false)
+			continue
+		}
+
+		divergence := blockNum - consensusHead
+		if divergence < 0 {
+			divergence = -divergence
+		}
+
+		suspect := divergence > maxDivergence
+		endpoint.SetSuspect(suspect)
+		if suspect {
+			log.Printf("Endpoint %s on chain %s reports block %d, %d from consensus head %d (max %d), excluding as suspect",
+				endpoint.URL, chainName, blockNum, blockNum-consensusHead, consensusHead, maxDivergence)
+			endpoint.MarkUnhealthy(fmt.Sprintf("block %d diverges from consensus head %d", blockNum, consensusHead))
+		}
+	}
+}
+
+// headVerdict reports why an endpoint answering at height must stay out of rotation, judged
+// against the chain as its last checks left it: trailing the other endpoints by more than
+// max_block_lag, or diverging from the last consensus head. Probes apply it so a lagging or
+// forked endpoint doesn't rejoin traffic until the end of the cycle rejudges it.
+func (mc *MultiChainChecker) headVerdict(chainName string, endpoint *types.RPCEndpoint, height int64) string {
+	mc.mu.RLock()
+	chainConfig, exists := mc.chains[chainName]
+	var snapshot ChainConfig
+	heads := []int64{height}
+	if exists {
+		snapshot = ChainConfig{Chain: chainConfig.Chain, Configs: chainConfig.Configs}
+		for _, other := range chainConfig.Endpoints {
+			if other == endpoint || !other.IsHealthy() {
+				continue
+			}
+			if blockNum, ok := parseBlockNumber(other.GetBlockNumber()); ok {
+				heads = append(heads, blockNum)
+			}
+		}
+	}
+	mc.mu.RUnlock()
+	if !exists {
+		return ""
+	}
+
+	// The cycle measures lag from the highest head once forked endpoints are excluded; until
+	// then a quorum's median stands in for it, which one forked endpoint can't move
+	sort.Slice(heads, func(i, j int) bool { return heads[i] < heads[j] })
+	head := heads[len(heads)-1]
+	if len(heads) >= consensusQuorum {
+		head = heads[len(heads)/2]
+	}
+	if maxLag := snapshot.MaxBlockLag(); maxLag > 0 && head-height > maxLag {
+		return fmt.Sprintf("%d blocks behind chain head (max %d)", head-height, maxLag)
+	}
+
+	consensusHead := mc.ConsensusHead(chainName)
+	maxDivergence := snapshot.MaxBlockDivergence()
+	if consensusHead == 0 || maxDivergence == 0 {
+		return ""
+	}
+	// Healthy endpoints run ahead of the last head as the chain grows, so only an endpoint
+	// already found suspect is kept out for running ahead of it
+	if consensusHead-height > maxDivergence || (endpoint.IsSuspect() && height-consensusHead > maxDivergence) {
+		return fmt.Sprintf("block %d diverges from consensus head %d", height, consensusHead)
+	}
+	return ""
+}
+
+func (mc *MultiChainChecker) setConsensusHead(chainName string, head int64) {
+	mc.consensusMu.Lock()
+	defer mc.consensusMu.Unlock()
+	mc.consensusHeads[chainName] = head
+}
+
+// ConsensusHead returns the median head across a chain's healthy endpoints from the last cycle,
+// or 0 when too few endpoints reported to form a quorum
+func (mc *MultiChainChecker) ConsensusHead(chainName string) int64 {
+	mc.consensusMu.RLock()
+	defer mc.consensusMu.RUnlock()
+	return mc.consensusHeads[chainName]
+}
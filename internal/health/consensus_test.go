@@ -0,0 +1,86 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+func TestProbeKeepsLaggingAndForkedEndpointsOut(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs map[string]string
+		height  int64
+		suspect bool
+		// forkedPeer adds a healthy endpoint far ahead, not yet found suspect
+		forkedPeer  bool
+		wantHealthy bool
+	}{
+		{"in step", map[string]string{"max_block_lag": "10"}, 1000, false, false, true},
+		{"lagging", map[string]string{"max_block_lag": "10"}, 980, false, false, false},
+		{"behind the consensus", nil, 850, false, false, false},
+		{"ahead of the last consensus", nil, 1150, false, false, true},
+		{"suspect and still ahead", nil, 1150, true, false, false},
+		{"suspect back in step", nil, 1001, true, false, true},
+		{"forked peer ahead", map[string]string{"max_block_lag": "10"}, 1000, false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var call struct {
+					ID     json.RawMessage `json:"id"`
+					Method string          `json:"method"`
+				}
+				json.NewDecoder(r.Body).Decode(&call)
+				w.Header().Set("Content-Type", "application/json")
+				if call.Method != "eth_blockNumber" {
+					fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`, call.ID)
+					return
+				}
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x%x"}`, call.ID, tt.height)
+			}))
+			defer upstream.Close()
+
+			target := &types.RPCEndpoint{ID: 1, Name: "target", URL: upstream.URL, Weight: 1, Enabled: true, Suspect: tt.suspect}
+			endpoints := []*types.RPCEndpoint{target}
+			for i := 2; i <= 4; i++ {
+				endpoints = append(endpoints, &types.RPCEndpoint{ID: i, Name: "peer", URL: "http://127.0.0.1:1", Weight: 1, Enabled: true, Healthy: true, BlockNumber: "1000"})
+			}
+			if tt.forkedPeer {
+				endpoints = append(endpoints, &types.RPCEndpoint{ID: 5, Name: "forked", URL: "http://127.0.0.1:1", Weight: 1, Enabled: true, Healthy: true, BlockNumber: "5000"})
+			}
+			chain := &ChainConfig{Chain: &types.Chain{Name: "ethereum"}, Endpoints: endpoints, Configs: tt.configs}
+			mc := NewMultiChainChecker(map[string]*ChainConfig{"ethereum": chain}, HealthCheckConfig{
+				Interval: time.Minute, Timeout: time.Second, Retries: 1,
+			})
+			defer mc.cancel()
+			mc.setConsensusHead("ethereum", 1000)
+
+			mc.checkEndpointHealth("ethereum", target)
+			if healthy := target.IsHealthy(); healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v (last error %q)", healthy, tt.wantHealthy, target.LastError)
+			}
+		})
+	}
+}
+
+func TestEvaluateConsensusKeepsSuspectOutOfRotation(t *testing.T) {
+	suspect := &types.RPCEndpoint{ID: 1, Name: "forked", Enabled: true, Suspect: true, BlockNumber: "5000"}
+	endpoints := []*types.RPCEndpoint{suspect}
+	for i := 2; i <= 4; i++ {
+		endpoints = append(endpoints, &types.RPCEndpoint{ID: i, Name: "peer", Enabled: true, Healthy: true, BlockNumber: "1000"})
+	}
+	chain := &ChainConfig{Chain: &types.Chain{Name: "ethereum"}, Endpoints: endpoints}
+	mc := NewMultiChainChecker(map[string]*ChainConfig{"ethereum": chain}, HealthCheckConfig{Interval: time.Minute, Timeout: time.Second})
+	defer mc.cancel()
+
+	mc.evaluateConsensus("ethereum", chain)
+	if !suspect.IsSuspect() {
+		t.Error("an endpoint kept out for diverging lost its suspect flag, so its next probe would let it back in")
+	}
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+func TestCheckChainHealthStopsWithChainContext(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer upstream.Close()
+
+	var endpoints []*types.RPCEndpoint
+	for i := 1; i <= 4; i++ {
+		endpoints = append(endpoints, &types.RPCEndpoint{ID: i, Name: "node", URL: upstream.URL, Weight: 1, Enabled: true})
+	}
+	chain := &ChainConfig{Chain: &types.Chain{Name: "ethereum"}, Endpoints: endpoints}
+	mc := NewMultiChainChecker(map[string]*ChainConfig{"ethereum": chain}, HealthCheckConfig{
+		Interval: time.Minute, Timeout: time.Second, Retries: 1,
+	})
+	defer mc.cancel()
+
+	// The checker itself keeps running; only the chain's checker is stopped
+	ctx, cancel := context.WithCancel(mc.ctx)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		mc.checkChainHealth(ctx, "ethereum", chain, true)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled cycle kept waiting for probe slots after its chain was stopped")
+	}
+
+	if got := calls.Load(); got != 0 {
+		t.Errorf("stopped chain probed %d times, want 0", got)
+	}
+	if stats, recorded := mc.checkStats["ethereum"]; recorded && stats.Cycles > 0 {
+		t.Errorf("stopped chain recorded %d cycles, want 0", stats.Cycles)
+	}
+
+	// A live chain context still probes every endpoint
+	mc.checkChainHealth(mc.ctx, "ethereum", chain, false)
+	if got := calls.Load(); got != int32(len(endpoints)) {
+		t.Errorf("live chain probed %d times, want %d", got, len(endpoints))
+	}
+}
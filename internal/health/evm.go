@@ -0,0 +1,59 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evmStrategy checks EVM nodes with eth_blockNumber, and with sync checks eth_syncing and
+// net_peerCount. It is also the strategy of chain types without their own. Providers that
+// don't serve eth_syncing get no sync verdict rather than failing every check.
+type evmStrategy struct{}
+
+func (evmStrategy) BuildProbe(probe ProbeContext) []ProbeCall {
+	calls := []ProbeCall{{Method: "eth_blockNumber", Params: []interface{}{}}}
+	if probe.CheckSync {
+		calls = append(calls,
+			ProbeCall{Method: "eth_syncing", Params: []interface{}{}, Optional: true},
+			ProbeCall{Method: "net_peerCount", Params: []interface{}{}, Optional: true})
+	}
+	return calls
+}
+
+func (evmStrategy) ValidateResponse(probe ProbeContext, replies []ProbeReply) (NodeState, error) {
+	state := NodeState{PeerCount: -1}
+	if reply := findReply(replies, "eth_syncing"); reply != nil {
+		switch {
+		case reply.Err == nil:
+			// eth_syncing returns false when in sync, or an object describing sync progress
+			state.Syncing = strings.TrimSpace(string(reply.Result)) != "false"
+		case !isMethodNotFound(reply.Err):
+			return state, reply.Err
+		}
+	}
+	if reply := findReply(replies, "net_peerCount"); reply != nil && reply.Err == nil {
+		if count, err := parseHexQuantity(reply.Result); err == nil {
+			state.PeerCount = count
+		}
+	}
+	return state, nil
+}
+
+func (evmStrategy) ExtractHeight(replies []ProbeReply) (int64, error) {
+	blockNum, err := parseHexQuantity(findReply(replies, "eth_blockNumber").Result)
+	if err != nil {
+		return 0, fmt.Errorf("invalid block number response")
+	}
+	return blockNum, nil
+}
+
+// parseHexQuantity decodes a JSON-RPC hex quantity such as "0x1b4"
+func parseHexQuantity(raw json.RawMessage) (int64, error) {
+	var quantity string
+	if err := json.Unmarshal(raw, &quantity); err != nil || !strings.HasPrefix(quantity, "0x") {
+		return 0, fmt.Errorf("unexpected quantity %s", string(raw))
+	}
+	return strconv.ParseInt(quantity[2:], 16, 64)
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"rpc-proxy/internal/types"
+)
+
+func TestEVMValidateResponseSyncing(t *testing.T) {
+	probe := ProbeContext{Chain: "ethereum", CheckSync: true}
+	notFound := &nodeError{method: "eth_syncing", status: 200, rpc: &types.JSONRPCError{Code: rpcMethodNotFound, Message: "the method eth_syncing does not exist"}}
+	notSupported := &nodeError{method: "eth_syncing", status: 200, rpc: &types.JSONRPCError{Code: rpcMethodNotSupported, Message: "method not supported"}}
+	internal := &nodeError{method: "eth_syncing", status: 200, rpc: &types.JSONRPCError{Code: -32603, Message: "internal error"}}
+
+	tests := []struct {
+		name        string
+		syncing     ProbeReply
+		wantSyncing bool
+		wantErr     bool
+	}{
+		{"in sync", ProbeReply{Result: json.RawMessage(`false`)}, false, false},
+		{"syncing", ProbeReply{Result: json.RawMessage(`{"currentBlock":"0x1","highestBlock":"0x10"}`)}, true, false},
+		{"method not found", ProbeReply{Err: notFound}, false, false},
+		{"method not supported", ProbeReply{Err: notSupported}, false, false},
+		{"node error", ProbeReply{Err: internal}, false, true},
+		{"transport error", ProbeReply{Err: errors.New("connection reset by peer")}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.syncing.Call = ProbeCall{Method: "eth_syncing", Optional: true}
+			replies := []ProbeReply{
+				{Call: ProbeCall{Method: "eth_blockNumber"}, Result: json.RawMessage(`"0x10"`)},
+				tt.syncing,
+				{Call: ProbeCall{Method: "net_peerCount", Optional: true}, Result: json.RawMessage(`"0x5"`)},
+			}
+			state, err := evmStrategy{}.ValidateResponse(probe, replies)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateResponse error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if state.Syncing != tt.wantSyncing {
+				t.Errorf("Syncing = %v, want %v", state.Syncing, tt.wantSyncing)
+			}
+			if state.PeerCount != 5 {
+				t.Errorf("PeerCount = %d, want 5", state.PeerCount)
+			}
+		})
+	}
+}
+
+func TestEVMBuildProbeSyncingOptional(t *testing.T) {
+	for _, call := range (evmStrategy{}).BuildProbe(ProbeContext{CheckSync: true}) {
+		if call.Method != "eth_blockNumber" && !call.Optional {
+			t.Errorf("%s is required; only eth_blockNumber should be", call.Method)
+		}
+	}
+}
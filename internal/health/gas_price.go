@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"rpc-proxy/internal/types"
+)
+
+// GasPriceThresholdGwei returns the gas_price_gwei_threshold chain config: the maximum deviation
+// from the chain median an endpoint's reported gas price may have, or 0 when unset
+func (cc *ChainConfig) GasPriceThresholdGwei() float64 {
+	val, exists := cc.Configs["gas_price_gwei_threshold"]
+	if !exists {
+		return 0
+	}
+
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil || threshold < 0 {
+		logInvalidConfig(cc.Chain.Name, "gas_price_gwei_threshold", val, "gas price check disabled")
+		return 0
+	}
+
+	return threshold
+}
+
+// checkGasPrices probes eth_gasPrice on every healthy endpoint and excludes endpoints whose
+// price deviates from the chain median by more than gas_price_gwei_threshold, which usually
+// indicates a stuck or misconfigured node
+func (mc *MultiChainChecker) checkGasPrices(chainName string, chainConfig *ChainConfig) {
+	threshold := chainConfig.GasPriceThresholdGwei()
+	if threshold == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, endpoint := range chainConfig.Endpoints {
+		if !endpoint.IsHealthy() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(ep *types.RPCEndpoint) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(mc.ctx, mc.healthSettings().Timeout)
+			defer cancel()
+
+			result, err := mc.callRPC(ctx, ep, "eth_gasPrice", []interface{}{})
+			if err != nil {
+				log.Printf("eth_gasPrice probe failed for %s: %v", ep.URL, err)
+				ep.SetGasPriceGwei(0)
+				return
+			}
+
+			gwei, err := parseGasPriceGwei(result)
+			if err != nil {
+				log.Printf("Invalid eth_gasPrice response from %s: %v", ep.URL, err)
+				ep.SetGasPriceGwei(0)
+				return
+			}
+			ep.SetGasPriceGwei(gwei)
+		}(endpoint)
+	}
+	wg.Wait()
+
+	var prices []float64
+	for _, endpoint := range chainConfig.Endpoints {
+		if gwei := endpoint.GetGasPriceGwei(); endpoint.IsHealthy() && gwei > 0 {
+			prices = append(prices, gwei)
+		}
+	}
+	if len(prices) < consensusQuorum {
+		return
+	}
+
+	sort.Float64s(prices)
+	median := prices[len(prices)/2]
+
+	for _, endpoint := range chainConfig.Endpoints {
+		gwei := endpoint.GetGasPriceGwei()
+		if !endpoint.IsHealthy() || gwei == 0 {
+			continue
+		}
+
+		if deviation := math.Abs(gwei - median); deviation > threshold {
+			log.Printf("Endpoint %s on chain %s reports gas price %.2f gwei, %.2f from median %.2f (max %.2f), marking unhealthy",
+				endpoint.URL, chainName, gwei, deviation, median, threshold)
+			endpoint.MarkUnhealthy(fmt.Sprintf("gas price %.2f gwei deviates from chain median %.2f gwei", gwei, median))
+		}
+	}
+}
+
+// parseGasPriceGwei converts a hex wei quantity into gwei
+func parseGasPriceGwei(result json.RawMessage) (float64, error) {
+	var hexWei string
+	if err := json.Unmarshal(result, &hexWei); err != nil {
+		return 0, err
+	}
+	if !strings.HasPrefix(hexWei, "0x") {
+		return 0, fmt.Errorf("gas price %q is not a hex quantity", hexWei)
+	}
+
+	wei, ok := new(big.Int).SetString(hexWei[2:], 16)
+	if !ok {
+		return 0, fmt.Errorf("gas price %q is not a hex quantity", hexWei)
+	}
+
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return gwei, nil
+}
@@ -0,0 +1,162 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// endpointState reports which per-endpoint maps still hold endpoint
+func endpointState(mc *MultiChainChecker, endpoint *types.RPCEndpoint) (score, probe, cert bool) {
+	mc.scoreMu.Lock()
+	_, score = mc.scoreStates[endpoint]
+	mc.scoreMu.Unlock()
+	mc.probeMu.Lock()
+	_, probe = mc.lastArchiveProbe[endpoint]
+	mc.probeMu.Unlock()
+	mc.certMu.Lock()
+	_, cert = mc.lastCertWarning[endpoint]
+	mc.certMu.Unlock()
+	return score, probe, cert
+}
+
+func newLifecycleChecker() (*MultiChainChecker, *ChainConfig) {
+	endpoint := &types.RPCEndpoint{ID: 1, Name: "node", URL: "http://127.0.0.1:1", Weight: 1, Enabled: true}
+	chain := &ChainConfig{Chain: &types.Chain{Name: "ethereum"}, Endpoints: []*types.RPCEndpoint{endpoint}}
+	mc := NewMultiChainChecker(map[string]*ChainConfig{"ethereum": chain}, HealthCheckConfig{Interval: time.Minute, Timeout: time.Second})
+
+	mc.updateScores(chain)
+	mc.probeMu.Lock()
+	mc.lastArchiveProbe[endpoint] = time.Now()
+	mc.probeMu.Unlock()
+	mc.certMu.Lock()
+	mc.lastCertWarning[endpoint] = time.Now()
+	mc.certMu.Unlock()
+	return mc, chain
+}
+
+func TestRemoveChainForgetsEndpoints(t *testing.T) {
+	mc, chain := newLifecycleChecker()
+	defer mc.cancel()
+	endpoint := chain.Endpoints[0]
+
+	if score, probe, cert := endpointState(mc, endpoint); !score || !probe || !cert {
+		t.Fatalf("setup left score=%v probe=%v cert=%v, want all recorded", score, probe, cert)
+	}
+
+	mc.RemoveChain("ethereum")
+	if score, probe, cert := endpointState(mc, endpoint); score || probe || cert {
+		t.Errorf("after RemoveChain score=%v probe=%v cert=%v, want all forgotten", score, probe, cert)
+	}
+}
+
+func TestAddChainForgetsReplacedEndpoints(t *testing.T) {
+	mc, chain := newLifecycleChecker()
+	defer mc.cancel()
+	endpoint := chain.Endpoints[0]
+
+	replacement := &types.RPCEndpoint{ID: 2, Name: "other", URL: "http://127.0.0.1:2", Weight: 1, Enabled: true}
+	mc.AddChain("ethereum", &ChainConfig{Chain: chain.Chain, Endpoints: []*types.RPCEndpoint{replacement}})
+	if score, probe, cert := endpointState(mc, endpoint); score || probe || cert {
+		t.Errorf("after replacing the chain score=%v probe=%v cert=%v, want all forgotten", score, probe, cert)
+	}
+}
+
+func TestUpdateChainRenameMovesChainState(t *testing.T) {
+	mc, _ := newLifecycleChecker()
+	defer mc.cancel()
+	mc.setConsensusHead("ethereum", 100)
+	mc.recordCheck("ethereum", true)
+
+	if err := mc.UpdateChain("ethereum", &types.Chain{Name: "mainnet"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if head := mc.ConsensusHead("mainnet"); head != 100 {
+		t.Errorf("consensus head under the new name = %d, want 100", head)
+	}
+	if head := mc.ConsensusHead("ethereum"); head != 0 {
+		t.Errorf("consensus head under the old name = %d, want 0", head)
+	}
+	mc.statsMu.Lock()
+	_, renamed := mc.checkStats["mainnet"]
+	_, old := mc.checkStats["ethereum"]
+	mc.statsMu.Unlock()
+	if !renamed || old {
+		t.Errorf("check stats under new name = %v, old name = %v, want moved", renamed, old)
+	}
+}
+
+func TestAddEndpointReplacementInheritsState(t *testing.T) {
+	mc, chain := newLifecycleChecker()
+	defer mc.cancel()
+	existing := chain.Endpoints[0]
+	existing.SetHealthy(true)
+	existing.SetBlockNumber("1000")
+	existing.SetOverride(types.OverrideForceDown)
+	existing.SetDraining(true)
+
+	updated := &types.RPCEndpoint{ID: existing.ID, Name: "renamed", URL: existing.URL, Weight: 5, Enabled: true}
+	if err := mc.AddEndpoint("ethereum", updated); err != nil {
+		t.Fatal(err)
+	}
+	if !updated.IsHealthy() || updated.GetBlockNumber() != "1000" {
+		t.Errorf("updated endpoint healthy=%v block=%q, want the health state carried over", updated.IsHealthy(), updated.GetBlockNumber())
+	}
+	if updated.GetOverride() != types.OverrideForceDown || !updated.Draining || updated.IsAvailable() {
+		t.Errorf("updated endpoint override=%s draining=%v, want it still forced down and draining", updated.GetOverride(), updated.Draining)
+	}
+	if endpoints := mc.GetAllEndpoints("ethereum"); len(endpoints) != 1 || endpoints[0] != updated {
+		t.Fatalf("chain holds %d endpoints, want only the updated one", len(endpoints))
+	}
+	if score, probe, cert := endpointState(mc, existing); score || probe || cert {
+		t.Errorf("replaced endpoint state score=%v probe=%v cert=%v, want it forgotten", score, probe, cert)
+	}
+
+	moved := &types.RPCEndpoint{ID: existing.ID, Name: "moved", URL: "http://127.0.0.1:3", Weight: 1, Enabled: true}
+	if err := mc.AddEndpoint("ethereum", moved); err != nil {
+		t.Fatal(err)
+	}
+	if moved.IsHealthy() || moved.GetOverride() != types.OverrideAuto {
+		t.Errorf("endpoint with a new URL healthy=%v override=%s, want fresh state", moved.IsHealthy(), moved.GetOverride())
+	}
+}
+
+func TestUpdateEndpointsMergesUnderLock(t *testing.T) {
+	mc, chain := newLifecycleChecker()
+	defer mc.cancel()
+	existing := chain.Endpoints[0]
+
+	err := mc.UpdateEndpoints("ethereum", func(current []*types.RPCEndpoint) ([]*types.RPCEndpoint, bool) {
+		return current, false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score, probe, cert := endpointState(mc, existing); !score || !probe || !cert {
+		t.Errorf("an unchanged update dropped endpoint state score=%v probe=%v cert=%v", score, probe, cert)
+	}
+
+	// An endpoint added after a caller read the list is still there for the update to keep
+	added := &types.RPCEndpoint{ID: 2, Name: "admin", URL: "http://127.0.0.1:2", Weight: 1, Enabled: true}
+	if err := mc.AddEndpoint("ethereum", added); err != nil {
+		t.Fatal(err)
+	}
+	discovered := &types.RPCEndpoint{ID: 1000000000, Name: "pod", URL: "http://127.0.0.1:3", Weight: 1, Enabled: true, Source: "kubernetes"}
+	err = mc.UpdateEndpoints("ethereum", func(current []*types.RPCEndpoint) ([]*types.RPCEndpoint, bool) {
+		return append(append([]*types.RPCEndpoint(nil), current...), discovered), true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoints := mc.GetAllEndpoints("ethereum"); len(endpoints) != 3 {
+		t.Errorf("chain holds %d endpoints, want the original, the admin one and the discovered one", len(endpoints))
+	}
+
+	if err := mc.UpdateEndpoints("missing", func(current []*types.RPCEndpoint) ([]*types.RPCEndpoint, bool) {
+		return current, true
+	}); err == nil {
+		t.Error("updating a missing chain succeeded")
+	}
+}
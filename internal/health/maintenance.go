@@ -0,0 +1,41 @@
+package health
+
+import (
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// SetMaintenanceWindows replaces the scheduled maintenance windows for all endpoints
+func (mc *MultiChainChecker) SetMaintenanceWindows(windows []*types.MaintenanceWindow) {
+	byEndpoint := make(map[int][]*types.MaintenanceWindow)
+	for _, window := range windows {
+		byEndpoint[window.EndpointID] = append(byEndpoint[window.EndpointID], window)
+	}
+
+	mc.maintenanceMu.Lock()
+	mc.maintenance = byEndpoint
+	mc.maintenanceMu.Unlock()
+	types.InvalidateRouting()
+}
+
+// inMaintenance reports whether an endpoint has an active maintenance window at t
+func (mc *MultiChainChecker) inMaintenance(endpoint *types.RPCEndpoint, t time.Time) bool {
+	mc.maintenanceMu.RLock()
+	defer mc.maintenanceMu.RUnlock()
+
+	for _, window := range mc.maintenance[endpoint.ID] {
+		if window.Active(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshMaintenance updates the InMaintenance flag shown in endpoint status
+func (mc *MultiChainChecker) refreshMaintenance(chainConfig *ChainConfig) {
+	now := time.Now()
+	for _, endpoint := range chainConfig.Endpoints {
+		endpoint.SetInMaintenance(mc.inMaintenance(endpoint, now))
+	}
+}
@@ -1,17 +1,19 @@
 package health
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"rpc-proxy/internal/metrics"
 	"rpc-proxy/internal/types"
 )
 
@@ -19,18 +21,94 @@ import (
 type ChainConfig struct {
 	Chain     *types.Chain
 	Endpoints []*types.RPCEndpoint
+	Configs   map[string]string // chain-specific config keys (max_block_lag, ...)
+}
+
+// MaxBlockLag returns the configured max_block_lag for the chain, or 0 when lag enforcement is disabled
+func (cc *ChainConfig) MaxBlockLag() int64 {
+	val, exists := cc.Configs["max_block_lag"]
+	if !exists {
+		return 0
+	}
+
+	lag, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+	if err != nil || lag < 0 {
+		logInvalidConfig(cc.Chain.Name, "max_block_lag", val, "lag enforcement disabled")
+		return 0
+	}
+
+	return lag
+}
+
+var (
+	invalidConfigMu     sync.Mutex
+	invalidConfigLogged = make(map[string]string)
+)
+
+// logInvalidConfig reports an unusable chain config value once per chain, key and value; the
+// accessors reading chain configs run on every health cycle and status request
+func logInvalidConfig(chainName, key, val, consequence string) {
+	invalidConfigMu.Lock()
+	defer invalidConfigMu.Unlock()
+
+	id := chainName + "/" + key
+	if logged, exists := invalidConfigLogged[id]; exists && logged == val {
+		return
+	}
+	invalidConfigLogged[id] = val
+	log.Printf("Invalid %s %q for chain %s, %s", key, val, chainName, consequence)
 }
 
 // MultiChainChecker manages health checks for multiple blockchain networks
 type MultiChainChecker struct {
 	chains        map[string]*ChainConfig
+	// healthConfig and client can be replaced at runtime; read them via healthSettings and httpClient
 	healthConfig  HealthCheckConfig
 	client        *http.Client
+	settingsMu    sync.RWMutex
 	ctx           context.Context
 	cancel        context.CancelFunc
 	wg            sync.WaitGroup
 	mu            sync.RWMutex
 	isRunning     bool
+
+	// chainCancels stops the checker goroutine of each running chain
+	chainCancels map[string]context.CancelFunc
+	startedAt    time.Time
+
+	checkStats map[string]*chainCheckStats
+	statsMu    sync.Mutex
+
+	// maintenance holds scheduled maintenance windows by endpoint ID
+	maintenance   map[int][]*types.MaintenanceWindow
+	maintenanceMu sync.RWMutex
+
+	// lastArchiveProbe tracks when each endpoint's archive capability was last probed
+	lastArchiveProbe map[*types.RPCEndpoint]time.Time
+	probeMu          sync.Mutex
+
+	scoreStates map[*types.RPCEndpoint]*endpointScoreState
+	scoreMu     sync.Mutex
+
+	consensusHeads map[string]int64
+	consensusMu    sync.RWMutex
+
+	lastCertWarning map[*types.RPCEndpoint]time.Time
+	certMu          sync.Mutex
+
+	// resultWriter persists check results when a health check repository is configured
+	resultWriter *resultWriter
+
+	// probes runs endpoint probes for all chains with bounded concurrency
+	probes *probePool
+
+	// metrics receives check results for the long-term export when it is configured
+	metrics *metrics.Collector
+
+	// shared shares check results with other replicas when Redis is configured
+	shared          *SharedState
+	lastSharedError time.Time
+	sharedErrMu     sync.Mutex
 }
 
 // NewMultiChainChecker creates a new multi-chain health checker
@@ -40,11 +118,20 @@ func NewMultiChainChecker(chains map[string]*ChainConfig, healthConfig HealthChe
 	return &MultiChainChecker{
 		chains:       chains,
 		healthConfig: healthConfig,
-		client: &http.Client{
-			Timeout: healthConfig.Timeout,
-		},
+		// Every probe is bounded by its context instead of a client timeout, so a chain's
+		// timeout_seconds may exceed the global timeout
+		client: &http.Client{},
 		ctx:    ctx,
 		cancel: cancel,
+
+		lastArchiveProbe: make(map[*types.RPCEndpoint]time.Time),
+		scoreStates:      make(map[*types.RPCEndpoint]*endpointScoreState),
+		consensusHeads:   make(map[string]int64),
+		chainCancels:     make(map[string]context.CancelFunc),
+		checkStats:       make(map[string]*chainCheckStats),
+		maintenance:      make(map[int][]*types.MaintenanceWindow),
+		lastCertWarning:  make(map[*types.RPCEndpoint]time.Time),
+		probes:           newProbePool(max(healthConfig.MaxConcurrency, 1), healthConfig.MaxProbesPerHost),
 	}
 }
 
@@ -58,26 +145,53 @@ func (mc *MultiChainChecker) Start() {
 	}
 	
 	mc.isRunning = true
+	mc.startedAt = time.Now()
 	log.Printf("Starting multi-chain health checker for %d chains", len(mc.chains))
 	
+	if mc.resultWriter != nil {
+		mc.wg.Add(1)
+		go func() {
+			defer mc.wg.Done()
+			mc.resultWriter.run(mc.ctx)
+		}()
+	}
+	
 	// Start health checker for each chain
 	for chainName, chainConfig := range mc.chains {
-		mc.wg.Add(1)
-		go mc.runChainHealthChecker(chainName, chainConfig)
+		mc.startChainLocked(chainName, chainConfig)
+	}
+}
+
+// startChainLocked launches the checker goroutine for a chain (must be called with lock held)
+func (mc *MultiChainChecker) startChainLocked(chainName string, chainConfig *ChainConfig) {
+	ctx, cancel := context.WithCancel(mc.ctx)
+	mc.chainCancels[chainName] = cancel
+	
+	mc.wg.Add(1)
+	go mc.runChainHealthChecker(ctx, chainName, chainConfig)
+}
+
+// stopChainLocked stops the checker goroutine for a chain, if any (must be called with lock held)
+func (mc *MultiChainChecker) stopChainLocked(chainName string) {
+	if cancel, exists := mc.chainCancels[chainName]; exists {
+		cancel()
+		delete(mc.chainCancels, chainName)
 	}
 }
 
 // Stop stops all health checking
 func (mc *MultiChainChecker) Stop() {
 	mc.mu.Lock()
-	defer mc.mu.Unlock()
-	
 	if !mc.isRunning {
+		mc.mu.Unlock()
 		return
 	}
 	
 	mc.isRunning = false
 	mc.cancel()
+	mc.mu.Unlock()
+	
+	// Wait without holding mu: chain checkers read chain state while finishing their cycle
 	mc.wg.Wait()
 	log.Printf("Multi-chain health checker stopped")
 }
@@ -92,9 +206,12 @@ func (mc *MultiChainChecker) GetHealthyEndpoints(chainName string) []*types.RPCE
 		return nil
 	}
 	
+	// Draining endpoints and endpoints in a maintenance window are excluded here since
+	// this is the set new requests are routed to
+	now := time.Now()
 	var healthy []*types.RPCEndpoint
 	for _, endpoint := range chainConfig.Endpoints {
-		if endpoint.IsHealthy() {
+		if endpoint.IsAvailable() && !endpoint.IsDraining() && !mc.inMaintenance(endpoint, now) {
 			healthy = append(healthy, endpoint)
 		}
 	}
@@ -102,6 +219,25 @@ func (mc *MultiChainChecker) GetHealthyEndpoints(chainName string) []*types.RPCE
 	return healthy
 }
 
+// GetEndpoint returns the endpoint with the given ID on a chain, or nil if it isn't monitored
+func (mc *MultiChainChecker) GetEndpoint(chainName string, endpointID int) *types.RPCEndpoint {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return nil
+	}
+	
+	for _, endpoint := range chainConfig.Endpoints {
+		if endpoint.ID == endpointID {
+			return endpoint
+		}
+	}
+	
+	return nil
+}
+
 // GetAllEndpoints returns all endpoints for a specific chain
 func (mc *MultiChainChecker) GetAllEndpoints(chainName string) []*types.RPCEndpoint {
 	mc.mu.RLock()
@@ -159,44 +295,129 @@ func (mc *MultiChainChecker) GetChainStatus(chainName string) *types.ChainHealth
 }
 
 // runChainHealthChecker runs health checking loop for a specific chain
-func (mc *MultiChainChecker) runChainHealthChecker(chainName string, chainConfig *ChainConfig) {
+func (mc *MultiChainChecker) runChainHealthChecker(ctx context.Context, chainName string, chainConfig *ChainConfig) {
 	defer mc.wg.Done()
 	
 	log.Printf("Started health checker for chain: %s", chainName)
-	ticker := time.NewTicker(mc.healthConfig.Interval)
-	defer ticker.Stop()
 	
-	// Initial health check
-	mc.checkChainHealth(chainName, chainConfig)
+	// Initial health check runs immediately so endpoints are routable at startup
+	mc.checkChainHealth(ctx, chainName, chainConfig, false)
+	
+	// Offset the ticker phase so chains and replicas don't probe in lockstep
+	if offset := mc.phaseOffset(); offset > 0 {
+		select {
+		case <-ctx.Done():
+			log.Printf("Health checker for chain %s stopped", chainName)
+			return
+		case <-time.After(offset):
+			mc.checkChainHealth(ctx, chainName, chainConfig, true)
+		}
+	}
+	
+	interval := mc.healthSettings().Interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 	
 	for {
 		select {
-		case <-mc.ctx.Done():
+		case <-ctx.Done():
 			log.Printf("Health checker for chain %s stopped", chainName)
 			return
 		case <-ticker.C:
-			mc.checkChainHealth(chainName, chainConfig)
+			mc.checkChainHealth(ctx, chainName, chainConfig, true)
+			
+			// Pick up interval changes applied through SetHealthConfig
+			if current := mc.healthSettings().Interval; current != interval {
+				interval = current
+				ticker.Reset(interval)
+			}
 		}
 	}
 }
 
-// checkChainHealth performs health check for all endpoints in a chain
-func (mc *MultiChainChecker) checkChainHealth(chainName string, chainConfig *ChainConfig) {
+// checkChainHealth performs health check for all endpoints in a chain. Scheduled cycles
+// stagger and jitter endpoint probes; on-demand cycles probe everything immediately. The cycle
+// stops submitting probes once ctx, the chain checker's context, is done, so a removed or
+// restarted chain doesn't finish a stale cycle.
+func (mc *MultiChainChecker) checkChainHealth(ctx context.Context, chainName string, chainConfig *ChainConfig, scheduled bool) {
+	chainConfig = mc.snapshotChain(chainConfig)
 	log.Printf("Checking health for chain: %s (%d endpoints)", chainName, len(chainConfig.Endpoints))
+	cycleStart := time.Now()
+	mc.refreshMaintenance(chainConfig)
+	mc.applySharedCooldowns(chainName, chainConfig)
 	
-	var wg sync.WaitGroup
-	for _, endpoint := range chainConfig.Endpoints {
+	// Probes run on the shared worker pool, each submitted when its staggered slot comes up
+	var slots []probeSlot
+	for i, endpoint := range chainConfig.Endpoints {
 		if !endpoint.Enabled {
 			continue
 		}
 		
+		slot := probeSlot{endpoint: endpoint}
+		if scheduled {
+			slot.delay = mc.probeDelay(i, len(chainConfig.Endpoints))
+		}
+		slots = append(slots, slot)
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].delay < slots[j].delay })
+	
+	var wg sync.WaitGroup
+	for _, slot := range slots {
+		if wait := slot.delay - time.Since(cycleStart); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		
+		ep := slot.endpoint
 		wg.Add(1)
-		go func(ep *types.RPCEndpoint) {
+		mc.probes.submit(ep.URL, func() {
 			defer wg.Done()
-			mc.checkEndpointHealth(chainName, ep)
-		}(endpoint)
+			if ctx.Err() != nil {
+				return
+			}
+			mc.probeEndpoint(chainName, ep, scheduled)
+			mc.recordCheck(chainName, ep.IsHealthy())
+		})
 	}
 	wg.Wait()
+	if ctx.Err() != nil {
+		log.Printf("Health check cycle for chain %s stopped", chainName)
+		return
+	}
+	
+	// Exclude forked/stale outliers first so they can't skew the head used for lag enforcement
+	mc.evaluateConsensus(chainName, chainConfig)
+	mc.enforceBlockLag(chainName, chainConfig)
+	
+	// Gas prices and eth_getBalance only exist on EVM chains; other types probe their own way
+	chainType := types.ChainTypeEVM
+	if chainConfig.Chain != nil {
+		chainType = chainConfig.Chain.GetType()
+	}
+	if mc.healthSettings().CheckGasPrice && chainType == types.ChainTypeEVM {
+		mc.checkGasPrices(chainName, chainConfig)
+	}
+	
+	// Bitcoin nodes report whether they are pruned in their health probe
+	if mc.healthSettings().ArchiveProbeDepth > 0 {
+		switch chainType {
+		case types.ChainTypeEVM:
+			mc.probeArchiveCapability(chainName, chainConfig)
+		case types.ChainTypeSolana:
+			mc.probeSolanaArchiveCapability(chainName, chainConfig)
+		}
+	}
+	
+	mc.updateScores(chainConfig)
+	mc.persistResults(chainName, chainConfig)
+	mc.recordCycle(chainName, time.Since(cycleStart))
 	
 	// Log chain health summary
 	healthy := mc.GetHealthyEndpoints(chainName)
@@ -204,119 +425,148 @@ func (mc *MultiChainChecker) checkChainHealth(chainName string, chainConfig *Cha
 		chainName, len(healthy), len(chainConfig.Endpoints))
 }
 
-// checkEndpointHealth performs health check for a single endpoint
+// checkEndpointHealth performs health check for a single endpoint with its chain type's strategy
 func (mc *MultiChainChecker) checkEndpointHealth(chainName string, endpoint *types.RPCEndpoint) {
-	start := time.Now()
-	
-	// Create health check request (get latest block)
-	requestBody := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  "eth_blockNumber",
-		"params":  []interface{}{},
-		"id":      1,
+	mc.runHealthProbe(chainName, endpoint, healthStrategyFor(mc.ChainType(chainName)))
+}
+
+// callRPC sends a single JSON-RPC request to the endpoint and returns the raw result
+func (mc *MultiChainChecker) callRPC(ctx context.Context, endpoint *types.RPCEndpoint, method string, params []interface{}) (json.RawMessage, error) {
+	if isWebSocketURL(endpoint.URL) {
+		result, _, err := mc.callWebSocketRPC(ctx, endpoint, method, params)
+		return result, err
 	}
-	
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		log.Printf("Failed to marshal request for %s: %v", endpoint.URL, err)
-		endpoint.SetHealthy(false)
+
+	result, _, err := mc.callNodeRPC(ctx, endpoint, method, params)
+	return result, err
+}
+
+// enforceBlockLag compares each endpoint's block number with the chain-wide highest block
+// and marks endpoints trailing by more than max_block_lag as unhealthy
+func (mc *MultiChainChecker) enforceBlockLag(chainName string, chainConfig *ChainConfig) {
+	highestBlock := highestBlockNumber(chainConfig.Endpoints)
+	if highestBlock == 0 {
 		return
 	}
-	
-	// Create HTTP request with timeout
-	ctx, cancel := context.WithTimeout(mc.ctx, mc.healthConfig.Timeout)
-	defer cancel()
-	
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.URL, bytes.NewReader(jsonBody))
-	if err != nil {
-		log.Printf("Failed to create request for %s: %v", endpoint.URL, err)
-		endpoint.SetHealthy(false)
+
+	maxLag := chainConfig.MaxBlockLag()
+	for _, endpoint := range chainConfig.Endpoints {
+		blockNum, ok := parseBlockNumber(endpoint.GetBlockNumber())
+		if !ok {
+			endpoint.SetBlockLag(0)
+			continue
+		}
+
+		lag := highestBlock - blockNum
+		endpoint.SetBlockLag(lag)
+
+		if maxLag > 0 && lag > maxLag && endpoint.IsHealthy() {
+			log.Printf("Endpoint %s on chain %s is %d blocks behind (max %d), marking unhealthy",
+				endpoint.URL, chainName, lag, maxLag)
+			endpoint.MarkUnhealthy(fmt.Sprintf("%d blocks behind chain head (max %d)", lag, maxLag))
+		}
+	}
+}
+
+// probeArchiveCapability queries state at a deep historical block on every healthy endpoint
+// whose last probe is older than ArchiveProbeInterval
+func (mc *MultiChainChecker) probeArchiveCapability(chainName string, chainConfig *ChainConfig) {
+	highestBlock := highestBlockNumber(chainConfig.Endpoints)
+	probeBlock := highestBlock - mc.healthSettings().ArchiveProbeDepth
+	if highestBlock == 0 || probeBlock < 0 {
 		return
 	}
-	
-	req.Header.Set("Content-Type", "application/json")
-	
-	// Perform request with retries
-	var lastErr error
-	for attempt := 0; attempt < mc.healthConfig.Retries; attempt++ {
-		if attempt > 0 {
-			select {
-			case <-time.After(time.Second):
-			case <-ctx.Done():
-				endpoint.SetHealthy(false)
+
+	var wg sync.WaitGroup
+	for _, endpoint := range chainConfig.Endpoints {
+		if !endpoint.IsHealthy() || !mc.archiveProbeDue(endpoint) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(ep *types.RPCEndpoint) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(mc.ctx, mc.healthSettings().Timeout)
+			defer cancel()
+
+			params := []interface{}{"0x0000000000000000000000000000000000000000", fmt.Sprintf("0x%x", probeBlock)}
+			_, err := mc.callRPC(ctx, ep, "eth_getBalance", params)
+			if ctx.Err() != nil {
+				// Timed out or shutting down; retry on the next cycle rather than flagging as pruned
 				return
 			}
-		}
-		
-		resp, err := mc.client.Do(req)
-		if err != nil {
-			lastErr = err
-			log.Printf("Health check attempt %d/%d failed for %s: %v", 
-				attempt+1, mc.healthConfig.Retries, endpoint.URL, err)
+
+			// Only a JSON-RPC error reply says the state is pruned; when the node can't be reached,
+			// rate limits or fails, its capability is kept and probed again next cycle
+			var replyErr *nodeError
+			if err != nil && !(errors.As(err, &replyErr) && replyErr.rpc != nil &&
+				replyErr.status != http.StatusTooManyRequests && replyErr.status < http.StatusInternalServerError) {
+				return
+			}
+
+			archive := err == nil
+			if archive != ep.IsArchive() {
+				log.Printf("Endpoint %s on chain %s archive capability: %v (probe block %d)",
+					ep.URL, chainName, archive, probeBlock)
+			}
+			ep.SetArchive(archive)
+
+			mc.probeMu.Lock()
+			mc.lastArchiveProbe[ep] = time.Now()
+			mc.probeMu.Unlock()
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+func (mc *MultiChainChecker) archiveProbeDue(endpoint *types.RPCEndpoint) bool {
+	mc.probeMu.Lock()
+	defer mc.probeMu.Unlock()
+
+	last, probed := mc.lastArchiveProbe[endpoint]
+	return !probed || time.Since(last) >= mc.healthSettings().ArchiveProbeInterval
+}
+
+// HighestBlock returns the highest block number reported by the chain's healthy endpoints
+func (mc *MultiChainChecker) HighestBlock(chainName string) int64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return 0
+	}
+
+	return highestBlockNumber(chainConfig.Endpoints)
+}
+
+// highestBlockNumber returns the highest block number reported by healthy endpoints
+func highestBlockNumber(endpoints []*types.RPCEndpoint) int64 {
+	var highest int64
+	for _, endpoint := range endpoints {
+		if !endpoint.IsHealthy() {
 			continue
 		}
-		
-		// Process response
-		if mc.processHealthCheckResponse(endpoint, resp, start) {
-			return
+		if blockNum, ok := parseBlockNumber(endpoint.GetBlockNumber()); ok && blockNum > highest {
+			highest = blockNum
 		}
-		
-		lastErr = fmt.Errorf("invalid response from %s", endpoint.URL)
 	}
-	
-	// All retries failed
-	endpoint.SetHealthy(false)
-	responseTime := time.Since(start).Milliseconds()
-	endpoint.SetResponseTime(responseTime)
-	
-	log.Printf("Health check failed for %s after %d attempts: %v", 
-		endpoint.URL, mc.healthConfig.Retries, lastErr)
+	return highest
 }
 
-// processHealthCheckResponse processes the health check response
-func (mc *MultiChainChecker) processHealthCheckResponse(endpoint *types.RPCEndpoint, resp *http.Response, start time.Time) bool {
-	defer resp.Body.Close()
-	
-	responseTime := time.Since(start).Milliseconds()
-	endpoint.SetResponseTime(responseTime)
-	
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Health check failed for %s: HTTP %d", endpoint.URL, resp.StatusCode)
-		endpoint.SetHealthy(false)
-		return true
+// parseBlockNumber parses a block number stored in decimal form by the health checker
+func parseBlockNumber(blockNumber string) (int64, bool) {
+	if blockNumber == "" {
+		return 0, false
 	}
-	
-	// Parse JSON response
-	var jsonResp map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&jsonResp); err != nil {
-		log.Printf("Failed to decode response from %s: %v", endpoint.URL, err)
-		endpoint.SetHealthy(false)
-		return true
-	}
-	
-	// Check for JSON-RPC error
-	if errorObj, exists := jsonResp["error"]; exists && errorObj != nil {
-		log.Printf("JSON-RPC error from %s: %v", endpoint.URL, errorObj)
-		endpoint.SetHealthy(false)
-		return true
-	}
-	
-	// Extract block number
-	if result, exists := jsonResp["result"]; exists && result != nil {
-		if blockHex, ok := result.(string); ok && strings.HasPrefix(blockHex, "0x") {
-			if blockNum, err := strconv.ParseInt(blockHex[2:], 16, 64); err == nil {
-				endpoint.SetBlockNumber(fmt.Sprintf("%d", blockNum))
-				endpoint.SetHealthy(true)
-				log.Printf("Health check passed for %s: block %d, response time %dms", 
-					endpoint.URL, blockNum, responseTime)
-				return true
-			}
-		}
+
+	blockNum, err := strconv.ParseInt(blockNumber, 10, 64)
+	if err != nil {
+		return 0, false
 	}
-	
-	log.Printf("Invalid block number response from %s", endpoint.URL)
-	endpoint.SetHealthy(false)
-	return true
+
+	return blockNum, true
 }
 
 // getChainHealthStatus creates health status for a chain (must be called with lock held)
@@ -324,9 +574,25 @@ func (mc *MultiChainChecker) getChainHealthStatus(chainName string, chainConfig
 	var healthyEndpoints []*types.RPCEndpoint
 	var unhealthyEndpoints []*types.RPCEndpoint
 	var currentRPC string
+	var degradedCount int
+	var expiringCerts int
+	var dnsFailures int
+	var maintenanceCount int
 	
 	for _, endpoint := range chainConfig.Endpoints {
-		if endpoint.IsHealthy() {
+		if endpoint.IsInMaintenance() {
+			maintenanceCount++
+		}
+		if endpoint.GetFailureKind() == types.FailureDNS {
+			dnsFailures++
+		}
+		if endpoint.IsDegraded() {
+			degradedCount++
+		}
+		if certExpiresSoon(endpoint.GetCertExpiresAt(), mc.healthSettings().CertExpiryWarningDays) {
+			expiringCerts++
+		}
+		if endpoint.IsAvailable() {
 			healthyEndpoints = append(healthyEndpoints, endpoint)
 			if currentRPC == "" && endpoint.Enabled {
 				currentRPC = endpoint.URL
@@ -343,7 +609,227 @@ func (mc *MultiChainChecker) getChainHealthStatus(chainName string, chainConfig
 		TotalEndpoints:     len(chainConfig.Endpoints),
 		HealthyCount:       len(healthyEndpoints),
 		CurrentRPC:         currentRPC,
+		HighestBlock:       highestBlockNumber(chainConfig.Endpoints),
+		ConsensusHead:      mc.ConsensusHead(chainName),
+		MaxBlockLag:        chainConfig.MaxBlockLag(),
+		DegradedCount:      degradedCount,
+		ExpiringCerts:      expiringCerts,
+		DNSFailures:        dnsFailures,
+		MaintenanceCount:   maintenanceCount,
+	}
+}
+
+// CheckChainNow runs an immediate health check cycle for a chain and returns the fresh status
+func (mc *MultiChainChecker) CheckChainNow(chainName string) (*types.ChainHealthStatus, error) {
+	mc.mu.RLock()
+	chainConfig, exists := mc.chains[chainName]
+	mc.mu.RUnlock()
+	
+	if !exists {
+		return nil, fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	mc.checkChainHealth(mc.ctx, chainName, chainConfig, false)
+	
+	return mc.GetChainStatus(chainName), nil
+}
+
+// CheckEndpointNow runs an immediate health check for a single endpoint and returns it
+func (mc *MultiChainChecker) CheckEndpointNow(chainName string, endpointID int) (*types.RPCEndpoint, error) {
+	mc.mu.RLock()
+	chainConfig, exists := mc.chains[chainName]
+	mc.mu.RUnlock()
+	
+	if !exists {
+		return nil, fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	endpoint := mc.GetEndpoint(chainName, endpointID)
+	if endpoint == nil {
+		return nil, fmt.Errorf("endpoint %d not found on chain %s", endpointID, chainName)
 	}
+	
+	mc.checkEndpointHealth(chainName, endpoint)
+	mc.recordCheck(chainName, endpoint.IsHealthy())
+	mc.enforceBlockLag(chainName, mc.snapshotChain(chainConfig))
+	
+	return endpoint, nil
+}
+
+// snapshotChain copies a chain config under the read lock; endpoint slices and config maps are
+// replaced rather than mutated, so the copy is safe to use without holding the lock
+func (mc *MultiChainChecker) snapshotChain(chainConfig *ChainConfig) *ChainConfig {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	
+	return &ChainConfig{
+		Chain:     chainConfig.Chain,
+		Endpoints: chainConfig.Endpoints,
+		Configs:   chainConfig.Configs,
+	}
+}
+
+// ChainConfigValue returns a chain-specific config value, or "" when unset
+func (mc *MultiChainChecker) ChainConfigValue(chainName, key string) string {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return ""
+	}
+	
+	return chainConfig.Configs[key]
+}
+
+// SetChainConfigs replaces a running chain's config keys; they take effect on the next check cycle
+func (mc *MultiChainChecker) SetChainConfigs(chainName string, configs map[string]string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	chainConfig.Configs = configs
+	types.InvalidateRouting()
+	return nil
+}
+
+// AddEndpoint adds an endpoint to a running chain; it is health checked on the chain's next cycle.
+// An endpoint replacing one with the same ID and URL inherits its runtime state, so an update
+// keeps it in rotation along with its override, drain flag and traffic counters.
+func (mc *MultiChainChecker) AddEndpoint(chainName string, endpoint *types.RPCEndpoint) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	endpoints := make([]*types.RPCEndpoint, 0, len(chainConfig.Endpoints)+1)
+	for _, existing := range chainConfig.Endpoints {
+		if existing.ID != endpoint.ID {
+			endpoints = append(endpoints, existing)
+			continue
+		}
+		if existing != endpoint {
+			if existing.URL == endpoint.URL {
+				endpoint.InheritState(existing)
+			}
+			mc.forgetEndpoint(existing)
+		}
+	}
+	chainConfig.Endpoints = append(endpoints, endpoint)
+	types.InvalidateRouting()
+	
+	log.Printf("Added endpoint %s (%d) to chain %s", endpoint.URL, endpoint.ID, chainName)
+	return nil
+}
+
+// SetEndpoints swaps a running chain's whole endpoint set in one step, so routing never sees a
+// partially applied change; endpoints that are no longer present are forgotten
+func (mc *MultiChainChecker) SetEndpoints(chainName string, endpoints []*types.RPCEndpoint) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	mc.setEndpointsLocked(chainConfig, endpoints)
+	return nil
+}
+
+// UpdateEndpoints replaces a running chain's endpoints with what update makes of the current
+// ones, both under the lock, so endpoints added or removed meanwhile by the admin API or a
+// reload are never lost or brought back. update must not call the checker; the chain is left
+// as it is when update reports no change.
+func (mc *MultiChainChecker) UpdateEndpoints(chainName string, update func(current []*types.RPCEndpoint) ([]*types.RPCEndpoint, bool)) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	endpoints, changed := update(chainConfig.Endpoints)
+	if !changed {
+		return nil
+	}
+	mc.setEndpointsLocked(chainConfig, endpoints)
+	return nil
+}
+
+// setEndpointsLocked swaps in a chain's endpoints and forgets the ones no longer present;
+// mc.mu must be held
+func (mc *MultiChainChecker) setEndpointsLocked(chainConfig *ChainConfig, endpoints []*types.RPCEndpoint) {
+	kept := make(map[*types.RPCEndpoint]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		kept[endpoint] = true
+	}
+	for _, existing := range chainConfig.Endpoints {
+		if !kept[existing] {
+			mc.forgetEndpoint(existing)
+		}
+	}
+	
+	chainConfig.Endpoints = endpoints
+	types.InvalidateRouting()
+}
+
+// RemoveEndpoint drops an endpoint from a running chain so it stops receiving traffic
+func (mc *MultiChainChecker) RemoveEndpoint(chainName string, endpointID int) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	
+	chainConfig, exists := mc.chains[chainName]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	endpoints := make([]*types.RPCEndpoint, 0, len(chainConfig.Endpoints))
+	for _, existing := range chainConfig.Endpoints {
+		if existing.ID == endpointID {
+			mc.forgetEndpoint(existing)
+			continue
+		}
+		endpoints = append(endpoints, existing)
+	}
+	chainConfig.Endpoints = endpoints
+	types.InvalidateRouting()
+	
+	log.Printf("Removed endpoint %d from chain %s", endpointID, chainName)
+	return nil
+}
+
+// forgetEndpoint drops per-endpoint bookkeeping kept outside the endpoint itself
+func (mc *MultiChainChecker) forgetEndpoint(endpoint *types.RPCEndpoint) {
+	mc.probeMu.Lock()
+	delete(mc.lastArchiveProbe, endpoint)
+	mc.probeMu.Unlock()
+	
+	mc.scoreMu.Lock()
+	delete(mc.scoreStates, endpoint)
+	mc.scoreMu.Unlock()
+	
+	mc.certMu.Lock()
+	delete(mc.lastCertWarning, endpoint)
+	mc.certMu.Unlock()
+}
+
+// SetTransport replaces the HTTP transport used for health probes
+func (mc *MultiChainChecker) SetTransport(transport http.RoundTripper) {
+	mc.settingsMu.Lock()
+	defer mc.settingsMu.Unlock()
+	
+	client := *mc.client
+	client.Transport = transport
+	mc.client = &client
 }
 
 // AddChain adds a new chain to be monitored (thread-safe)
@@ -351,22 +837,97 @@ func (mc *MultiChainChecker) AddChain(chainName string, chainConfig *ChainConfig
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 	
+	// Replacing a chain restarts its checker with the new configuration
+	mc.stopChainLocked(chainName)
+	if previous, exists := mc.chains[chainName]; exists {
+		kept := make(map[*types.RPCEndpoint]bool, len(chainConfig.Endpoints))
+		for _, endpoint := range chainConfig.Endpoints {
+			kept[endpoint] = true
+		}
+		for _, endpoint := range previous.Endpoints {
+			if !kept[endpoint] {
+				mc.forgetEndpoint(endpoint)
+			}
+		}
+	}
 	mc.chains[chainName] = chainConfig
+	types.InvalidateRouting()
 	
 	if mc.isRunning {
-		mc.wg.Add(1)
-		go mc.runChainHealthChecker(chainName, chainConfig)
+		mc.startChainLocked(chainName, chainConfig)
 	}
 	
 	log.Printf("Added chain %s to health checker", chainName)
 }
 
+// UpdateChain replaces a chain's metadata, keeping its endpoints and configs; if the chain
+// was renamed it is re-registered under the new name (thread-safe)
+func (mc *MultiChainChecker) UpdateChain(chainName string, chain *types.Chain) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	
+	existing, exists := mc.chains[chainName]
+	if !exists {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+	
+	mc.stopChainLocked(chainName)
+	delete(mc.chains, chainName)
+	
+	chainConfig := &ChainConfig{
+		Chain:     chain,
+		Endpoints: existing.Endpoints,
+		Configs:   existing.Configs,
+	}
+	mc.chains[chain.Name] = chainConfig
+	types.InvalidateRouting()
+	
+	if chain.Name != chainName {
+		mc.statsMu.Lock()
+		if stats, exists := mc.checkStats[chainName]; exists {
+			mc.checkStats[chain.Name] = stats
+			delete(mc.checkStats, chainName)
+		}
+		mc.statsMu.Unlock()
+		
+		mc.consensusMu.Lock()
+		if head, exists := mc.consensusHeads[chainName]; exists {
+			mc.consensusHeads[chain.Name] = head
+			delete(mc.consensusHeads, chainName)
+		}
+		mc.consensusMu.Unlock()
+	}
+	
+	if mc.isRunning {
+		mc.startChainLocked(chain.Name, chainConfig)
+	}
+	
+	log.Printf("Updated chain %s in health checker", chain.Name)
+	return nil
+}
+
 // RemoveChain removes a chain from monitoring (thread-safe)
 func (mc *MultiChainChecker) RemoveChain(chainName string) {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 	
+	mc.stopChainLocked(chainName)
+	if chainConfig, exists := mc.chains[chainName]; exists {
+		for _, endpoint := range chainConfig.Endpoints {
+			mc.forgetEndpoint(endpoint)
+		}
+	}
 	delete(mc.chains, chainName)
+	types.InvalidateRouting()
+	
+	mc.consensusMu.Lock()
+	delete(mc.consensusHeads, chainName)
+	mc.consensusMu.Unlock()
+	
+	mc.statsMu.Lock()
+	delete(mc.checkStats, chainName)
+	mc.statsMu.Unlock()
+	
 	log.Printf("Removed chain %s from health checker", chainName)
 }
 
@@ -0,0 +1,132 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"rpc-proxy/internal/types"
+)
+
+// nodeError is a reply from a node that isn't a result: an HTTP error status or a JSON-RPC
+// error. Unlike a transport error it means the node was reached, so it isn't retried.
+type nodeError struct {
+	method string
+	status int
+	rpc    *types.JSONRPCError
+}
+
+func (e *nodeError) Error() string {
+	if e.rpc != nil {
+		return fmt.Sprintf("%s returned JSON-RPC error %d: %s", e.method, e.rpc.Code, e.rpc.Message)
+	}
+	return fmt.Sprintf("%s returned HTTP %d", e.method, e.status)
+}
+
+// JSON-RPC error codes of a node that doesn't serve a method: the spec's method not found, and
+// EIP-1474's method not supported
+const (
+	rpcMethodNotFound     = -32601
+	rpcMethodNotSupported = -32004
+)
+
+// isMethodNotFound reports whether err is a node's JSON-RPC reply that it doesn't serve the method
+func isMethodNotFound(err error) bool {
+	var replyErr *nodeError
+	if !errors.As(err, &replyErr) || replyErr.rpc == nil {
+		return false
+	}
+	return replyErr.rpc.Code == rpcMethodNotFound || replyErr.rpc.Code == rpcMethodNotSupported
+}
+
+// ChainType returns the type of a chain, evm when the chain isn't known
+func (mc *MultiChainChecker) ChainType(chainName string) string {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	if chainConfig, exists := mc.chains[chainName]; exists && chainConfig.Chain != nil {
+		return chainConfig.Chain.GetType()
+	}
+	return types.ChainTypeEVM
+}
+
+// callNodeRPC sends a single JSON-RPC request over HTTP to a node and returns the raw result
+// along with the TLS state of the connection, if any. Replies that aren't a result are
+// returned as a *nodeError; a JSON-RPC error sent with an error status, as Bitcoin nodes do,
+// keeps its code.
+func (mc *MultiChainChecker) callNodeRPC(ctx context.Context, endpoint *types.RPCEndpoint, method string, params []interface{}) (json.RawMessage, *tls.ConnectionState, error) {
+	jsonBody, err := json.Marshal(types.JSONRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      1,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.URL, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth := endpoint.Authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := mc.httpClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result json.RawMessage     `json:"result"`
+		Error  *types.JSONRPCError `json:"error"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&rpcResp)
+	switch {
+	case rpcResp.Error != nil:
+		return nil, resp.TLS, &nodeError{method: method, status: resp.StatusCode, rpc: rpcResp.Error}
+	case resp.StatusCode != http.StatusOK:
+		return nil, resp.TLS, &nodeError{method: method, status: resp.StatusCode}
+	case decodeErr != nil:
+		return nil, resp.TLS, fmt.Errorf("failed to decode %s response: %w", method, decodeErr)
+	}
+
+	return rpcResp.Result, resp.TLS, nil
+}
+
+// maxProbeBodySize bounds how much of a REST health probe's response is read
+const maxProbeBodySize = 1 << 20
+
+// getNodePath sends a GET of a path below the endpoint's URL, for nodes with a REST API, and
+// returns the response status and body along with the TLS state of the connection, if any
+func (mc *MultiChainChecker) getNodePath(ctx context.Context, endpoint *types.RPCEndpoint, path string) (int, []byte, *tls.ConnectionState, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(endpoint.URL, "/")+path, nil)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to create %s request: %w", path, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if auth := endpoint.Authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := mc.httpClient().Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBodySize))
+	if err != nil {
+		return 0, nil, resp.TLS, fmt.Errorf("failed to read %s response: %w", path, err)
+	}
+	return resp.StatusCode, body, resp.TLS, nil
+}
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"rpc-proxy/internal/metrics"
+	"rpc-proxy/internal/repository"
+)
+
+const (
+	resultQueueSize     = 1000
+	resultBatchSize     = 100
+	resultFlushInterval = 5 * time.Second
+	// resultWriteTimeout bounds each batch write so a hung database can't stall the queue
+	// or shutdown
+	resultWriteTimeout = 10 * time.Second
+)
+
+// resultWriter persists health check results asynchronously in batches
+type resultWriter struct {
+	repo    repository.HealthCheckRepository
+	results chan *repository.CreateHealthCheckRequest
+}
+
+func newResultWriter(repo repository.HealthCheckRepository) *resultWriter {
+	return &resultWriter{
+		repo:    repo,
+		results: make(chan *repository.CreateHealthCheckRequest, resultQueueSize),
+	}
+}
+
+// enqueue queues a result without blocking the health check loop; results are dropped when the queue is full
+func (w *resultWriter) enqueue(result *repository.CreateHealthCheckRequest) {
+	select {
+	case w.results <- result:
+	default:
+		log.Printf("Health check result queue full, dropping result for endpoint %d", result.EndpointID)
+	}
+}
+
+// run writes queued results until ctx is cancelled, then flushes whatever is left
+func (w *resultWriter) run(ctx context.Context) {
+	ticker := time.NewTicker(resultFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*repository.CreateHealthCheckRequest, 0, resultBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		writeCtx, cancel := context.WithTimeout(context.Background(), resultWriteTimeout)
+		defer cancel()
+		if err := w.repo.CreateBatch(writeCtx, batch); err != nil {
+			log.Printf("Failed to persist %d health check results: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case result := <-w.results:
+			batch = append(batch, result)
+			if len(batch) >= resultBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case result := <-w.results:
+					batch = append(batch, result)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// SetHealthCheckRepository enables persisting health check results; it must be called before Start
+func (mc *MultiChainChecker) SetHealthCheckRepository(repo repository.HealthCheckRepository) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.resultWriter = newResultWriter(repo)
+}
+
+// SetMetricsCollector enables exporting check results to the long-term metrics store; it
+// must be called before Start
+func (mc *MultiChainChecker) SetMetricsCollector(collector *metrics.Collector) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.metrics = collector
+}
+
+// persistResults queues the final state of each checked endpoint after a health check cycle
+func (mc *MultiChainChecker) persistResults(chainName string, chainConfig *ChainConfig) {
+	if mc.resultWriter == nil && mc.metrics == nil {
+		return
+	}
+
+	for _, endpoint := range chainConfig.Endpoints {
+		if !endpoint.Enabled {
+			continue
+		}
+
+		healthy := endpoint.IsHealthy()
+		if mc.metrics != nil {
+			mc.metrics.RecordHealthCheck(chainName, endpoint.Name, endpoint.GetResponseTime(), healthy)
+		}
+
+		// Only endpoints stored in the database have rows to attach results to
+		if mc.resultWriter == nil || endpoint.ID <= 0 {
+			continue
+		}
+
+		errorMessage := ""
+		if !healthy {
+			errorMessage = endpoint.GetLastError()
+		}
+
+		mc.resultWriter.enqueue(&repository.CreateHealthCheckRequest{
+			EndpointID:     endpoint.ID,
+			Healthy:        healthy,
+			ResponseTimeMs: endpoint.GetResponseTime(),
+			BlockNumber:    endpoint.GetBlockNumber(),
+			ErrorMessage:   errorMessage,
+		})
+	}
+}
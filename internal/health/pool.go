@@ -0,0 +1,102 @@
+package health
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// probePool runs endpoint probes for every chain on a fixed number of workers, with at most
+// perHost probes at a time against one upstream host, so a provider serving many chains is not
+// hit by a burst of simultaneous probes. Workers live as long as the process.
+type probePool struct {
+	workers int
+	perHost int
+	queue   chan *probe
+	once    sync.Once
+
+	mu      sync.Mutex
+	active  map[string]int
+	waiting map[string][]*probe
+}
+
+// probe is one endpoint probe; run must not block on other probes
+type probe struct {
+	host string
+	run  func()
+}
+
+// newProbePool creates a pool of workers workers; perHost 0 leaves hosts unlimited
+func newProbePool(workers, perHost int) *probePool {
+	return &probePool{
+		workers: workers,
+		perHost: perHost,
+		queue:   make(chan *probe, workers),
+		active:  make(map[string]int),
+		waiting: make(map[string][]*probe),
+	}
+}
+
+// submit queues a probe of the endpoint at rawURL, blocking while every worker is busy and
+// the queue is full; the workers start on first use
+func (p *probePool) submit(rawURL string, run func()) {
+	p.once.Do(func() {
+		for i := 0; i < p.workers; i++ {
+			go p.work()
+		}
+	})
+	p.queue <- &probe{host: probeHost(rawURL), run: run}
+}
+
+func (p *probePool) work() {
+	for pr := range p.queue {
+		for pr != nil {
+			pr = p.runProbe(pr)
+		}
+	}
+}
+
+// runProbe runs pr if its host has a free slot, or parks it until a running probe of the same
+// host finishes. It returns the next parked probe of pr's host for the worker to run, so
+// parked probes never hold a worker while they wait.
+func (p *probePool) runProbe(pr *probe) *probe {
+	p.mu.Lock()
+	if p.perHost > 0 && p.active[pr.host] >= p.perHost {
+		p.waiting[pr.host] = append(p.waiting[pr.host], pr)
+		p.mu.Unlock()
+		return nil
+	}
+	p.active[pr.host]++
+	p.mu.Unlock()
+
+	pr.run()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.active[pr.host]--
+	if p.active[pr.host] == 0 {
+		delete(p.active, pr.host)
+	}
+
+	waiting := p.waiting[pr.host]
+	if len(waiting) == 0 {
+		return nil
+	}
+	if len(waiting) == 1 {
+		delete(p.waiting, pr.host)
+	} else {
+		p.waiting[pr.host] = waiting[1:]
+	}
+	return waiting[0]
+}
+
+// probeHost returns the upstream host probes are limited by, falling back to the raw URL when
+// it cannot be parsed
+func probeHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
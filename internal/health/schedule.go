@@ -0,0 +1,44 @@
+package health
+
+import (
+	"math/rand"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// probeSlot is an endpoint's place in a check cycle, probed delay after the cycle starts
+type probeSlot struct {
+	endpoint *types.RPCEndpoint
+	delay    time.Duration
+}
+
+// spreadWindowFraction bounds how much of the interval per-endpoint probes are spread across,
+// leaving the rest of the interval for block lag and archive evaluation
+const spreadWindowFraction = 2
+
+// phaseOffset returns a random delay before a chain's ticker starts so chains (and proxy
+// replicas) don't all probe providers at the same instant
+func (mc *MultiChainChecker) phaseOffset() time.Duration {
+	if !mc.healthSettings().Spread || mc.healthSettings().Interval <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(mc.healthSettings().Interval)))
+}
+
+// probeDelay returns how long the index-th of count endpoints waits before being probed
+// in a scheduled cycle: an evenly spaced slot within the spread window plus random jitter
+func (mc *MultiChainChecker) probeDelay(index, count int) time.Duration {
+	var delay time.Duration
+
+	if mc.healthSettings().Spread && count > 1 {
+		window := mc.healthSettings().Interval / spreadWindowFraction
+		delay = window * time.Duration(index) / time.Duration(count)
+	}
+
+	if mc.healthSettings().Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(mc.healthSettings().Jitter)))
+	}
+
+	return delay
+}
@@ -0,0 +1,94 @@
+package health
+
+import (
+	"math"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// scoreSmoothing is the EWMA factor applied to each new check result
+	scoreSmoothing = 0.3
+	// scoreLatencyCeilingMs is the latency at which the latency factor bottoms out
+	scoreLatencyCeilingMs = 2000.0
+	// scoreLagCeiling is the block lag at which the lag factor bottoms out
+	scoreLagCeiling = 20.0
+	// scoreFlapWindow is how long a health state change counts against the endpoint
+	scoreFlapWindow = 10 * time.Minute
+)
+
+// endpointScoreState holds the rolling inputs used to score an endpoint
+type endpointScoreState struct {
+	initialized bool
+	errorRate   float64 // EWMA of failed checks, 0..1
+	latencyMs   float64 // EWMA of response time
+	lastHealthy bool
+	flaps       []time.Time
+}
+
+// updateScores recomputes the composite health score of every endpoint in a chain
+func (mc *MultiChainChecker) updateScores(chainConfig *ChainConfig) {
+	mc.scoreMu.Lock()
+	defer mc.scoreMu.Unlock()
+
+	now := time.Now()
+	for _, endpoint := range chainConfig.Endpoints {
+		if !endpoint.Enabled {
+			continue
+		}
+
+		state, exists := mc.scoreStates[endpoint]
+		if !exists {
+			state = &endpointScoreState{}
+			mc.scoreStates[endpoint] = state
+		}
+
+		endpoint.SetScore(state.observe(endpoint, now))
+	}
+}
+
+// observe folds the endpoint's latest check result into the state and returns its score (0..100)
+func (s *endpointScoreState) observe(endpoint *types.RPCEndpoint, now time.Time) float64 {
+	healthy := endpoint.IsHealthy()
+	failed := 0.0
+	if !healthy {
+		failed = 1.0
+	}
+	latency := float64(endpoint.GetResponseTime())
+
+	if !s.initialized {
+		s.initialized = true
+		s.errorRate = failed
+		s.latencyMs = latency
+	} else {
+		s.errorRate = scoreSmoothing*failed + (1-scoreSmoothing)*s.errorRate
+		s.latencyMs = scoreSmoothing*latency + (1-scoreSmoothing)*s.latencyMs
+		if healthy != s.lastHealthy {
+			s.flaps = append(s.flaps, now)
+		}
+	}
+	s.lastHealthy = healthy
+
+	// Drop flaps that fell out of the window
+	recent := s.flaps[:0]
+	for _, flap := range s.flaps {
+		if now.Sub(flap) < scoreFlapWindow {
+			recent = append(recent, flap)
+		}
+	}
+	s.flaps = recent
+
+	if !healthy {
+		return 0
+	}
+
+	latencyFactor := 1 - 0.9*math.Min(s.latencyMs/scoreLatencyCeilingMs, 1)
+	errorFactor := 1 - s.errorRate
+	lagFactor := 1 - 0.5*math.Min(float64(endpoint.GetBlockLag())/scoreLagCeiling, 1)
+	flapFactor := 1 / (1 + float64(len(s.flaps)))
+
+	score := 100 * latencyFactor * errorFactor * lagFactor * flapFactor
+	// A healthy endpoint always keeps a minimal share of traffic
+	return math.Max(math.Round(score*10)/10, 1)
+}
@@ -0,0 +1,48 @@
+package health
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// healthSettings returns the health check configuration currently in effect
+func (mc *MultiChainChecker) healthSettings() HealthCheckConfig {
+	mc.settingsMu.RLock()
+	defer mc.settingsMu.RUnlock()
+	return mc.healthConfig
+}
+
+// probeSettings returns the health check configuration for a chain's probes: the chain's
+// timeout_seconds and retry_attempts configs, when set, replace the global timeout and retries
+func (mc *MultiChainChecker) probeSettings(chainName string) HealthCheckConfig {
+	settings := mc.healthSettings()
+	configs := mc.chainConfigs(chainName)
+	if seconds, err := strconv.Atoi(configs["timeout_seconds"]); err == nil && seconds > 0 {
+		settings.Timeout = time.Duration(seconds) * time.Second
+	}
+	if retries, err := strconv.Atoi(configs["retry_attempts"]); err == nil && retries > 0 {
+		settings.Retries = retries
+	}
+	return settings
+}
+
+func (mc *MultiChainChecker) httpClient() *http.Client {
+	mc.settingsMu.RLock()
+	defer mc.settingsMu.RUnlock()
+	return mc.client
+}
+
+// HealthConfig returns the health check configuration currently in effect
+func (mc *MultiChainChecker) HealthConfig() HealthCheckConfig {
+	return mc.healthSettings()
+}
+
+// SetHealthConfig applies new settings to the running checker. Probes in progress finish with
+// the old values; a changed interval takes effect at each chain's next tick.
+func (mc *MultiChainChecker) SetHealthConfig(cfg HealthCheckConfig) {
+	mc.settingsMu.Lock()
+	defer mc.settingsMu.Unlock()
+
+	mc.healthConfig = cfg
+}
@@ -0,0 +1,70 @@
+package health
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+func TestProbeSettings(t *testing.T) {
+	global := HealthCheckConfig{Interval: 30 * time.Second, Timeout: 5 * time.Second, Retries: 3}
+
+	tests := []struct {
+		name        string
+		configs     map[string]string
+		wantTimeout time.Duration
+		wantRetries int
+	}{
+		{"no chain configs", nil, 5 * time.Second, 3},
+		{"chain timeout and retries", map[string]string{"timeout_seconds": "15", "retry_attempts": "2"}, 15 * time.Second, 2},
+		{"chain timeout only", map[string]string{"timeout_seconds": "8"}, 8 * time.Second, 3},
+		{"invalid values are ignored", map[string]string{"timeout_seconds": "soon", "retry_attempts": "0"}, 5 * time.Second, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chains := map[string]*ChainConfig{
+				"ethereum": {Chain: &types.Chain{Name: "ethereum"}, Configs: tt.configs},
+			}
+			mc := NewMultiChainChecker(chains, global)
+
+			settings := mc.probeSettings("ethereum")
+			if settings.Timeout != tt.wantTimeout || settings.Retries != tt.wantRetries {
+				t.Errorf("probeSettings = %v timeout, %d retries, want %v, %d", settings.Timeout, settings.Retries, tt.wantTimeout, tt.wantRetries)
+			}
+			if settings.Interval != global.Interval {
+				t.Errorf("probeSettings interval = %v, want the global %v", settings.Interval, global.Interval)
+			}
+			if other := mc.probeSettings("base"); other.Timeout != global.Timeout || other.Retries != global.Retries {
+				t.Errorf("unknown chain got %v timeout, %d retries, want the global settings", other.Timeout, other.Retries)
+			}
+		})
+	}
+}
+
+func TestMaxBlockLagLogsInvalidValueOnce(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	chain := &ChainConfig{Chain: &types.Chain{Name: "lagtest"}, Configs: map[string]string{"max_block_lag": "ten"}}
+	for i := 0; i < 5; i++ {
+		if lag := chain.MaxBlockLag(); lag != 0 {
+			t.Fatalf("MaxBlockLag() = %d, want 0 for an invalid value", lag)
+		}
+	}
+	if n := strings.Count(output.String(), "Invalid max_block_lag"); n != 1 {
+		t.Errorf("logged the invalid value %d times, want once", n)
+	}
+
+	chain.Configs["max_block_lag"] = "-1"
+	chain.MaxBlockLag()
+	chain.MaxBlockLag()
+	if n := strings.Count(output.String(), "Invalid max_block_lag"); n != 2 {
+		t.Errorf("logged %d times after the value changed, want 2", n)
+	}
+}
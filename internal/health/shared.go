@@ -0,0 +1,227 @@
+package health
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"rpc-proxy/internal/redis"
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// sharedStateTTL is how many health check intervals a published result stays readable,
+	// so replicas fall back to probing themselves once the replica probing an endpoint is gone
+	sharedStateTTL = 3
+	// sharedTimeout bounds each Redis command so an unreachable server can't stall a cycle
+	sharedTimeout = 2 * time.Second
+	// sharedErrorLogInterval is how often Redis failures are logged
+	sharedErrorLogInterval = time.Minute
+)
+
+// SharedState shares endpoint health between proxy replicas through Redis. In each scheduled
+// cycle the first replica to claim an endpoint probes it and publishes the result, and the
+// others apply that result instead of probing; rate limit cooldowns are published too. When
+// Redis can't be reached every replica probes on its own.
+type SharedState struct {
+	client *redis.Client
+	prefix string
+	// replica identifies this proxy in probe claims
+	replica string
+}
+
+// NewSharedState shares health through client under keys starting with prefix
+func NewSharedState(client *redis.Client, prefix string) *SharedState {
+	hostname, _ := os.Hostname()
+	return &SharedState{
+		client:  client,
+		prefix:  prefix,
+		replica: fmt.Sprintf("%s/%d", hostname, os.Getpid()),
+	}
+}
+
+// SetSharedState shares health check results with other replicas; it must be called before Start
+func (mc *MultiChainChecker) SetSharedState(shared *SharedState) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.shared = shared
+}
+
+// key names an endpoint's entry of the given kind. URLs often embed provider API keys, so
+// they are hashed rather than used in key names.
+func (s *SharedState) key(kind, chainName, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return fmt.Sprintf("%s:health:%s:%s:%s", s.prefix, kind, chainName, hex.EncodeToString(sum[:8]))
+}
+
+// claimProbe reports whether this replica probes the endpoint in the current cycle; the
+// claim lapses shortly before the next cycle
+func (s *SharedState) claimProbe(ctx context.Context, chainName, url string, interval time.Duration) (bool, error) {
+	ttl := interval * 9 / 10
+	reply, err := s.client.Do(ctx, "SET", s.key("claim", chainName, url), s.replica,
+		"NX", "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// loadState returns the latest published result for the endpoint, or nil if there is none
+func (s *SharedState) loadState(ctx context.Context, chainName, url string) (*types.EndpointHealthState, error) {
+	reply, err := s.client.Do(ctx, "GET", s.key("state", chainName, url))
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	value, _ := reply.(string)
+	var state types.EndpointHealthState
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		return nil, fmt.Errorf("malformed shared health state: %w", err)
+	}
+	return &state, nil
+}
+
+// publishState stores this replica's result for the endpoint
+func (s *SharedState) publishState(ctx context.Context, chainName, url string, state types.EndpointHealthState, interval time.Duration) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	ttl := interval * sharedStateTTL
+	_, err = s.client.Do(ctx, "SET", s.key("state", chainName, url), string(value),
+		"PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	return err
+}
+
+// publishCooldown stores when the endpoint's rate limit cooldown ends
+func (s *SharedState) publishCooldown(ctx context.Context, chainName, url string, cooldown time.Duration) error {
+	until := time.Now().Add(cooldown)
+	_, err := s.client.Do(ctx, "SET", s.key("cooldown", chainName, url), strconv.FormatInt(until.UnixMilli(), 10),
+		"PX", strconv.FormatInt(max(cooldown.Milliseconds(), 1), 10))
+	return err
+}
+
+// loadCooldowns returns the end of every published cooldown among endpoints, by endpoint
+func (s *SharedState) loadCooldowns(ctx context.Context, chainName string, endpoints []*types.RPCEndpoint) (map[*types.RPCEndpoint]time.Time, error) {
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	args := []string{"MGET"}
+	for _, endpoint := range endpoints {
+		args = append(args, s.key("cooldown", chainName, endpoint.URL))
+	}
+	reply, err := s.client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	values, _ := reply.([]interface{})
+
+	cooldowns := make(map[*types.RPCEndpoint]time.Time)
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok || i >= len(endpoints) {
+			continue
+		}
+		if ms, err := strconv.ParseInt(str, 10, 64); err == nil {
+			cooldowns[endpoints[i]] = time.UnixMilli(ms)
+		}
+	}
+	return cooldowns, nil
+}
+
+// probeEndpoint checks an endpoint's health. With shared health, a scheduled check takes the
+// result another replica published for this cycle when it can't claim the probe; results of
+// its own probes are published for the others.
+func (mc *MultiChainChecker) probeEndpoint(chainName string, endpoint *types.RPCEndpoint, scheduled bool) {
+	shared := mc.shared
+	if shared == nil {
+		mc.checkEndpointHealth(chainName, endpoint)
+		return
+	}
+	interval := mc.healthSettings().Interval
+
+	if scheduled {
+		ctx, cancel := context.WithTimeout(mc.ctx, sharedTimeout)
+		claimed, err := shared.claimProbe(ctx, chainName, endpoint.URL, interval)
+		var state *types.EndpointHealthState
+		if err == nil && !claimed {
+			state, err = shared.loadState(ctx, chainName, endpoint.URL)
+		}
+		cancel()
+		if err != nil {
+			mc.logSharedError(err)
+		}
+		// Without a result yet, e.g. while the claiming replica's first probe runs, probe here
+		if state != nil {
+			endpoint.ApplyHealthState(*state)
+			return
+		}
+	}
+
+	mc.checkEndpointHealth(chainName, endpoint)
+
+	ctx, cancel := context.WithTimeout(mc.ctx, sharedTimeout)
+	defer cancel()
+	if err := shared.publishState(ctx, chainName, endpoint.URL, endpoint.HealthState(), interval); err != nil {
+		mc.logSharedError(err)
+	}
+}
+
+// applySharedCooldowns degrades endpoints that another replica found rate limited
+func (mc *MultiChainChecker) applySharedCooldowns(chainName string, chainConfig *ChainConfig) {
+	shared := mc.shared
+	if shared == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(mc.ctx, sharedTimeout)
+	defer cancel()
+	cooldowns, err := shared.loadCooldowns(ctx, chainName, chainConfig.Endpoints)
+	if err != nil {
+		mc.logSharedError(err)
+		return
+	}
+	for endpoint, until := range cooldowns {
+		if remaining := time.Until(until); remaining > 0 && !endpoint.IsDegraded() {
+			endpoint.MarkDegraded(remaining)
+			log.Printf("Endpoint %s on chain %s rate limited on another replica, degraded for %v",
+				endpoint.URL, chainName, remaining.Round(time.Second))
+		}
+	}
+}
+
+// ShareCooldown publishes an endpoint's rate limit cooldown to the other replicas, which
+// apply it at their next health check cycle; it doesn't block the caller
+func (mc *MultiChainChecker) ShareCooldown(chainName string, endpoint *types.RPCEndpoint, cooldown time.Duration) {
+	shared := mc.shared
+	if shared == nil || cooldown <= 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(mc.ctx, sharedTimeout)
+		defer cancel()
+		if err := shared.publishCooldown(ctx, chainName, endpoint.URL, cooldown); err != nil {
+			mc.logSharedError(err)
+		}
+	}()
+}
+
+// logSharedError logs a failed Redis command, at most once per sharedErrorLogInterval so an
+// outage doesn't flood the log
+func (mc *MultiChainChecker) logSharedError(err error) {
+	mc.sharedErrMu.Lock()
+	defer mc.sharedErrMu.Unlock()
+
+	if time.Since(mc.lastSharedError) < sharedErrorLogInterval {
+		return
+	}
+	mc.lastSharedError = time.Now()
+	log.Printf("Shared health state unavailable, probing locally: %v", err)
+}
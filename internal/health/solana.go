@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// solanaSlotSkipped is the JSON-RPC error a Solana node returns for a slot without a block,
+// which only a node still holding the slot's history can tell
+const solanaSlotSkipped = -32007
+
+// solanaStrategy checks Solana nodes with getHealth, which fails when the node is behind the
+// cluster, and takes getSlot as their height
+type solanaStrategy struct{}
+
+func (solanaStrategy) BuildProbe(probe ProbeContext) []ProbeCall {
+	return []ProbeCall{
+		{Method: "getHealth", Params: []interface{}{}},
+		{Method: "getSlot", Params: []interface{}{}},
+	}
+}
+
+func (solanaStrategy) ValidateResponse(probe ProbeContext, replies []ProbeReply) (NodeState, error) {
+	result := findReply(replies, "getHealth").Result
+	var status string
+	if err := json.Unmarshal(result, &status); err != nil || status != "ok" {
+		return NodeState{}, fmt.Errorf("getHealth returned %s", string(result))
+	}
+	return NodeState{PeerCount: -1}, nil
+}
+
+func (solanaStrategy) ExtractHeight(replies []ProbeReply) (int64, error) {
+	var slot int64
+	if err := json.Unmarshal(findReply(replies, "getSlot").Result, &slot); err != nil {
+		return 0, fmt.Errorf("invalid slot response")
+	}
+	return slot, nil
+}
+
+// probeSolanaArchiveCapability is probeArchiveCapability for Solana: it asks for the block at a
+// slot ArchiveProbeDepth behind the head, which nodes that pruned the ledger no longer have. A
+// skipped slot still counts, since only a node with the history knows it was skipped.
+func (mc *MultiChainChecker) probeSolanaArchiveCapability(chainName string, chainConfig *ChainConfig) {
+	highestSlot := highestBlockNumber(chainConfig.Endpoints)
+	probeSlot := highestSlot - mc.healthSettings().ArchiveProbeDepth
+	if highestSlot == 0 || probeSlot < 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, endpoint := range chainConfig.Endpoints {
+		if !endpoint.IsHealthy() || !mc.archiveProbeDue(endpoint) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(ep *types.RPCEndpoint) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(mc.ctx, mc.healthSettings().Timeout)
+			defer cancel()
+
+			params := []interface{}{probeSlot, map[string]interface{}{
+				"transactionDetails":             "none",
+				"rewards":                        false,
+				"maxSupportedTransactionVersion": 0,
+			}}
+			_, _, err := mc.callNodeRPC(ctx, ep, "getBlock", params)
+			if ctx.Err() != nil {
+				// Timed out or shutting down; retry on the next cycle rather than flagging as pruned
+				return
+			}
+
+			var replyErr *nodeError
+			archive := err == nil || (errors.As(err, &replyErr) && replyErr.rpc != nil && replyErr.rpc.Code == solanaSlotSkipped)
+			if archive != ep.IsArchive() {
+				log.Printf("Endpoint %s on chain %s archive capability: %v (probe slot %d)",
+					ep.URL, chainName, archive, probeSlot)
+			}
+			ep.SetArchive(archive)
+
+			mc.probeMu.Lock()
+			mc.lastArchiveProbe[ep] = time.Now()
+			mc.probeMu.Unlock()
+		}(endpoint)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"rpc-proxy/internal/types"
+)
+
+// starknetChainIDKey is the chain config key naming a Starknet chain's network, e.g. SN_MAIN.
+// Starknet chain IDs are short strings that don't fit the chain's numeric ID.
+const starknetChainIDKey = "starknet_chain_id"
+
+// starknetSyncStatus is the part of a starknet_syncing reply the health check reads. Nodes
+// that aren't syncing reply false instead.
+type starknetSyncStatus struct {
+	CurrentBlockNum int64 `json:"current_block_num"`
+	HighestBlockNum int64 `json:"highest_block_num"`
+}
+
+// starknetStrategy checks Starknet nodes with starknet_blockNumber. With the chain's
+// starknet_chain_id set, the node's starknet_chainId must match it, so an endpoint on another
+// network is never routed to; with sync checks a node starknet_syncing reports as behind is
+// syncing. Starknet nodes report no peer count.
+type starknetStrategy struct{}
+
+func (starknetStrategy) BuildProbe(probe ProbeContext) []ProbeCall {
+	calls := []ProbeCall{{Method: "starknet_blockNumber", Params: []interface{}{}}}
+	if probe.Configs[starknetChainIDKey] != "" {
+		calls = append(calls, ProbeCall{Method: "starknet_chainId", Params: []interface{}{}})
+	}
+	if probe.CheckSync {
+		calls = append(calls, ProbeCall{Method: "starknet_syncing", Params: []interface{}{}})
+	}
+	return calls
+}
+
+func (starknetStrategy) ValidateResponse(probe ProbeContext, replies []ProbeReply) (NodeState, error) {
+	if reply := findReply(replies, "starknet_chainId"); reply != nil {
+		chainID, err := decodeStarknetChainID(reply.Result)
+		if err != nil {
+			return NodeState{}, err
+		}
+		if expected := probe.Configs[starknetChainIDKey]; chainID != expected {
+			return NodeState{}, fmt.Errorf("chain ID %s does not match %s", chainID, expected)
+		}
+	}
+
+	state := NodeState{PeerCount: -1}
+	if reply := findReply(replies, "starknet_syncing"); reply != nil {
+		var status starknetSyncStatus
+		state.Syncing = string(reply.Result) != "false" && json.Unmarshal(reply.Result, &status) == nil &&
+			status.HighestBlockNum > status.CurrentBlockNum
+	}
+	return state, nil
+}
+
+func (starknetStrategy) ExtractHeight(replies []ProbeReply) (int64, error) {
+	var blockNumber int64
+	if err := json.Unmarshal(findReply(replies, "starknet_blockNumber").Result, &blockNumber); err != nil {
+		return 0, fmt.Errorf("invalid block number response")
+	}
+	return blockNumber, nil
+}
+
+// fetchStarknetChainID returns the endpoint's starknet_chainId as the short string it encodes
+func (mc *MultiChainChecker) fetchStarknetChainID(ctx context.Context, endpoint *types.RPCEndpoint) (string, error) {
+	raw, _, err := mc.callNodeRPC(ctx, endpoint, "starknet_chainId", []interface{}{})
+	if err != nil {
+		return "", err
+	}
+	return decodeStarknetChainID(raw)
+}
+
+// decodeStarknetChainID decodes a starknet_chainId felt into the short string it encodes, e.g.
+// SN_MAIN for 0x534e5f4d41494e
+func decodeStarknetChainID(raw json.RawMessage) (string, error) {
+	var felt string
+	if err := json.Unmarshal(raw, &felt); err != nil || !strings.HasPrefix(felt, "0x") {
+		return "", fmt.Errorf("unexpected starknet_chainId result %s", string(raw))
+	}
+	digits := strings.TrimPrefix(felt, "0x")
+	if len(digits)%2 == 1 {
+		digits = "0" + digits
+	}
+	decoded, err := hex.DecodeString(digits)
+	if err != nil {
+		return "", fmt.Errorf("unexpected starknet_chainId result %s", felt)
+	}
+
+	return string(decoded), nil
+}
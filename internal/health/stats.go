@@ -0,0 +1,180 @@
+package health
+
+import (
+	"sort"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// chainCheckStats accumulates health check counters for one chain
+type chainCheckStats struct {
+	Cycles            int64      `json:"check_cycles"`
+	ChecksPerformed   int64      `json:"checks_performed"`
+	SuccessfulChecks  int64      `json:"successful_checks"`
+	SuccessRate       float64    `json:"success_rate"`
+	LastCheckTime     *time.Time `json:"last_check_time,omitempty"`
+	LastCycleDuration int64      `json:"last_cycle_duration_ms"`
+	TotalEndpoints    int        `json:"total_endpoints"`
+	HealthyEndpoints  int        `json:"healthy_endpoints"`
+}
+
+// recordCheck counts a single endpoint probe for a chain
+func (mc *MultiChainChecker) recordCheck(chainName string, success bool) {
+	mc.statsMu.Lock()
+	defer mc.statsMu.Unlock()
+
+	stats := mc.chainStatsLocked(chainName)
+	stats.ChecksPerformed++
+	if success {
+		stats.SuccessfulChecks++
+	}
+	now := time.Now()
+	stats.LastCheckTime = &now
+}
+
+// recordCycle counts a completed health check cycle for a chain
+func (mc *MultiChainChecker) recordCycle(chainName string, duration time.Duration) {
+	mc.statsMu.Lock()
+	defer mc.statsMu.Unlock()
+
+	stats := mc.chainStatsLocked(chainName)
+	stats.Cycles++
+	stats.LastCycleDuration = duration.Milliseconds()
+}
+
+// chainStatsLocked returns the counters for a chain, creating them (must be called with statsMu held)
+func (mc *MultiChainChecker) chainStatsLocked(chainName string) *chainCheckStats {
+	stats, exists := mc.checkStats[chainName]
+	if !exists {
+		stats = &chainCheckStats{}
+		mc.checkStats[chainName] = stats
+	}
+	return stats
+}
+
+// GetAllChainStatuses returns the health status of every monitored chain
+func (mc *MultiChainChecker) GetAllChainStatuses() map[string]*types.ChainHealthStatus {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	statuses := make(map[string]*types.ChainHealthStatus, len(mc.chains))
+	for chainName, chainConfig := range mc.chains {
+		statuses[chainName] = mc.getChainHealthStatus(chainName, chainConfig)
+	}
+
+	return statuses
+}
+
+// GetHealthCheckStats aggregates check counters and success rates across all chains
+func (mc *MultiChainChecker) GetHealthCheckStats() map[string]interface{} {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	mc.statsMu.Lock()
+	defer mc.statsMu.Unlock()
+
+	chains := make(map[string]chainCheckStats, len(mc.chains))
+	var totalEndpoints, healthyEndpoints int
+	var checksPerformed, successfulChecks int64
+	var lastCheck *time.Time
+
+	for chainName, chainConfig := range mc.chains {
+		var stats chainCheckStats
+		if recorded, exists := mc.checkStats[chainName]; exists {
+			stats = *recorded
+		}
+
+		stats.TotalEndpoints = len(chainConfig.Endpoints)
+		for _, endpoint := range chainConfig.Endpoints {
+			if endpoint.IsAvailable() {
+				stats.HealthyEndpoints++
+			}
+		}
+		stats.SuccessRate = successRate(stats.SuccessfulChecks, stats.ChecksPerformed)
+		chains[chainName] = stats
+
+		totalEndpoints += stats.TotalEndpoints
+		healthyEndpoints += stats.HealthyEndpoints
+		checksPerformed += stats.ChecksPerformed
+		successfulChecks += stats.SuccessfulChecks
+		if stats.LastCheckTime != nil && (lastCheck == nil || stats.LastCheckTime.After(*lastCheck)) {
+			lastCheck = stats.LastCheckTime
+		}
+	}
+
+	result := map[string]interface{}{
+		"is_running":        mc.isRunning,
+		"interval":          mc.healthSettings().Interval.String(),
+		"timeout":           mc.healthSettings().Timeout.String(),
+		"retries":           mc.healthSettings().Retries,
+		"total_chains":      len(mc.chains),
+		"total_endpoints":   totalEndpoints,
+		"healthy_endpoints": healthyEndpoints,
+		"checks_performed":  checksPerformed,
+		"successful_checks": successfulChecks,
+		"success_rate":      successRate(successfulChecks, checksPerformed),
+		"chains":            chains,
+	}
+	if lastCheck != nil {
+		result["last_check_time"] = lastCheck
+	}
+	if !mc.startedAt.IsZero() {
+		result["started_at"] = mc.startedAt
+		result["uptime_seconds"] = int64(time.Since(mc.startedAt).Seconds())
+	}
+
+	return result
+}
+
+// Uptime returns how long the checker has been running, or 0 before Start
+func (mc *MultiChainChecker) Uptime() time.Duration {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	if mc.startedAt.IsZero() {
+		return 0
+	}
+	return time.Since(mc.startedAt)
+}
+
+// GetSupportedChains returns the names of all monitored chains in sorted order
+func (mc *MultiChainChecker) GetSupportedChains() []string {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	chains := make([]string, 0, len(mc.chains))
+	for chainName := range mc.chains {
+		chains = append(chains, chainName)
+	}
+	sort.Strings(chains)
+
+	return chains
+}
+
+// IsChainSupported reports whether a chain is monitored
+func (mc *MultiChainChecker) IsChainSupported(chainName string) bool {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	_, exists := mc.chains[chainName]
+	return exists
+}
+
+// GetHealthyEndpointsForChain returns the endpoints currently available for routing on a chain
+func (mc *MultiChainChecker) GetHealthyEndpointsForChain(chainName string) []*types.RPCEndpoint {
+	return mc.GetHealthyEndpoints(chainName)
+}
+
+// GetAllEndpointsForChain returns every endpoint monitored for a chain
+func (mc *MultiChainChecker) GetAllEndpointsForChain(chainName string) []*types.RPCEndpoint {
+	return mc.GetAllEndpoints(chainName)
+}
+
+// successRate returns the percentage of successful checks, or 0 before any checks ran
+func successRate(successes, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(successes) / float64(total) * 100
+}
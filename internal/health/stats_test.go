@@ -0,0 +1,179 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+func TestSuccessRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		successes int64
+		total     int64
+		want      float64
+	}{
+		{"no checks", 0, 0, 0},
+		{"all failed", 0, 4, 0},
+		{"all succeeded", 4, 4, 100},
+		{"partial", 1, 4, 25},
+		{"three of eight", 3, 8, 37.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := successRate(tt.successes, tt.total); got != tt.want {
+				t.Errorf("successRate(%d, %d) = %v, want %v", tt.successes, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHealthCheckStats(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) *time.Time {
+		t := base.Add(offset)
+		return &t
+	}
+
+	tests := []struct {
+		name  string
+		stats map[string]*chainCheckStats
+		// healthy endpoints of each chain, out of two
+		healthy        map[string]int
+		wantChecks     int64
+		wantSuccessful int64
+		wantRate       float64
+		wantHealthy    int
+		wantLastCheck  *time.Time
+		wantChainRates map[string]float64
+	}{
+		{
+			name:           "no checks yet",
+			stats:          map[string]*chainCheckStats{},
+			healthy:        map[string]int{"ethereum": 0, "base": 0},
+			wantChainRates: map[string]float64{"ethereum": 0, "base": 0},
+		},
+		{
+			name: "rates across chains",
+			stats: map[string]*chainCheckStats{
+				"ethereum": {ChecksPerformed: 4, SuccessfulChecks: 3, LastCheckTime: at(time.Minute)},
+				"base":     {ChecksPerformed: 4, SuccessfulChecks: 1, LastCheckTime: at(2 * time.Minute)},
+			},
+			healthy:        map[string]int{"ethereum": 2, "base": 1},
+			wantChecks:     8,
+			wantSuccessful: 4,
+			wantRate:       50,
+			wantHealthy:    3,
+			wantLastCheck:  at(2 * time.Minute),
+			wantChainRates: map[string]float64{"ethereum": 75, "base": 25},
+		},
+		{
+			name: "one chain never checked",
+			stats: map[string]*chainCheckStats{
+				"ethereum": {ChecksPerformed: 5, SuccessfulChecks: 5, LastCheckTime: at(time.Second)},
+			},
+			healthy:        map[string]int{"ethereum": 1, "base": 0},
+			wantChecks:     5,
+			wantSuccessful: 5,
+			wantRate:       100,
+			wantHealthy:    1,
+			wantLastCheck:  at(time.Second),
+			wantChainRates: map[string]float64{"ethereum": 100, "base": 0},
+		},
+		{
+			name: "stats of removed chains are ignored",
+			stats: map[string]*chainCheckStats{
+				"ethereum": {ChecksPerformed: 2, SuccessfulChecks: 1, LastCheckTime: at(time.Second)},
+				"removed":  {ChecksPerformed: 10, SuccessfulChecks: 10, LastCheckTime: at(time.Hour)},
+			},
+			healthy:        map[string]int{"ethereum": 0, "base": 2},
+			wantChecks:     2,
+			wantSuccessful: 1,
+			wantRate:       50,
+			wantHealthy:    2,
+			wantLastCheck:  at(time.Second),
+			wantChainRates: map[string]float64{"ethereum": 50, "base": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chains := make(map[string]*ChainConfig, len(tt.healthy))
+			for name, healthy := range tt.healthy {
+				endpoints := []*types.RPCEndpoint{{Name: name + "-1"}, {Name: name + "-2"}}
+				for i := 0; i < healthy; i++ {
+					endpoints[i].Healthy = true
+				}
+				chains[name] = &ChainConfig{Chain: &types.Chain{Name: name}, Endpoints: endpoints}
+			}
+			mc := NewMultiChainChecker(chains, HealthCheckConfig{Interval: time.Second, Timeout: time.Second})
+			mc.checkStats = tt.stats
+
+			result := mc.GetHealthCheckStats()
+
+			if got := result["total_endpoints"]; got != 2*len(tt.healthy) {
+				t.Errorf("total_endpoints = %v, want %d", got, 2*len(tt.healthy))
+			}
+			if got := result["healthy_endpoints"]; got != tt.wantHealthy {
+				t.Errorf("healthy_endpoints = %v, want %d", got, tt.wantHealthy)
+			}
+			if got := result["checks_performed"]; got != tt.wantChecks {
+				t.Errorf("checks_performed = %v, want %d", got, tt.wantChecks)
+			}
+			if got := result["successful_checks"]; got != tt.wantSuccessful {
+				t.Errorf("successful_checks = %v, want %d", got, tt.wantSuccessful)
+			}
+			if got := result["success_rate"]; got != tt.wantRate {
+				t.Errorf("success_rate = %v, want %v", got, tt.wantRate)
+			}
+
+			lastCheck, ok := result["last_check_time"].(*time.Time)
+			switch {
+			case tt.wantLastCheck == nil && ok:
+				t.Errorf("last_check_time = %v, want none", lastCheck)
+			case tt.wantLastCheck != nil && (!ok || !lastCheck.Equal(*tt.wantLastCheck)):
+				t.Errorf("last_check_time = %v, want %v", result["last_check_time"], tt.wantLastCheck)
+			}
+
+			chainStats := result["chains"].(map[string]chainCheckStats)
+			if len(chainStats) != len(tt.wantChainRates) {
+				t.Errorf("got stats for %d chains, want %d", len(chainStats), len(tt.wantChainRates))
+			}
+			for name, want := range tt.wantChainRates {
+				stats, exists := chainStats[name]
+				if !exists {
+					t.Errorf("no stats for chain %s", name)
+					continue
+				}
+				if stats.SuccessRate != want {
+					t.Errorf("%s success_rate = %v, want %v", name, stats.SuccessRate, want)
+				}
+				if stats.TotalEndpoints != 2 || stats.HealthyEndpoints != tt.healthy[name] {
+					t.Errorf("%s endpoints = %d/%d healthy, want %d/2", name, stats.HealthyEndpoints, stats.TotalEndpoints, tt.healthy[name])
+				}
+			}
+		})
+	}
+}
+
+func TestRecordCheck(t *testing.T) {
+	mc := NewMultiChainChecker(map[string]*ChainConfig{}, HealthCheckConfig{})
+
+	before := time.Now()
+	for _, success := range []bool{true, false, true, true} {
+		mc.recordCheck("ethereum", success)
+	}
+	mc.recordCycle("ethereum", 1500*time.Millisecond)
+
+	stats := mc.checkStats["ethereum"]
+	if stats.ChecksPerformed != 4 || stats.SuccessfulChecks != 3 {
+		t.Errorf("checks = %d/%d successful, want 3/4", stats.SuccessfulChecks, stats.ChecksPerformed)
+	}
+	if stats.Cycles != 1 || stats.LastCycleDuration != 1500 {
+		t.Errorf("cycles = %d lasting %dms, want 1 lasting 1500ms", stats.Cycles, stats.LastCycleDuration)
+	}
+	if stats.LastCheckTime == nil || stats.LastCheckTime.Before(before) {
+		t.Errorf("last_check_time = %v, want at or after %v", stats.LastCheckTime, before)
+	}
+}
@@ -0,0 +1,243 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// HealthStrategy is how the endpoints of one chain type are health checked. The checker sends
+// a strategy's probe, retries it on transport errors and classifies failures the same way for
+// every chain type; the strategy only says what to ask a node and how to read its answers.
+type HealthStrategy interface {
+	// BuildProbe returns the calls of one health check, sent in order
+	BuildProbe(probe ProbeContext) []ProbeCall
+	// ValidateResponse checks the replies to the probe's calls and returns what they tell about
+	// the node's sync state, or why the endpoint is unhealthy
+	ValidateResponse(probe ProbeContext, replies []ProbeReply) (NodeState, error)
+	// ExtractHeight returns the block number, or slot, the validated replies report
+	ExtractHeight(replies []ProbeReply) (int64, error)
+}
+
+// ProbeContext is what a strategy knows about the check it builds and validates
+type ProbeContext struct {
+	Chain string
+	// Configs are the chain's config keys; nil when validating an endpoint of no chain
+	Configs map[string]string
+	// CheckSync is set when sync state and peers should be probed too
+	CheckSync bool
+}
+
+// ProbeCall is one request of a probe: a JSON-RPC call of Method, or with Path set a GET of that
+// path below the endpoint's URL. A required JSON-RPC call the node answers with an error fails
+// the check; a GET's status is left to the strategy.
+type ProbeCall struct {
+	Method string
+	Params []interface{}
+	Path   string
+	// Optional calls only add detail such as the peer count: when they fail the probe goes on
+	// and their reply carries the error
+	Optional bool
+}
+
+// ProbeReply is the answer to a ProbeCall: the JSON-RPC result, or a GET's status and body
+type ProbeReply struct {
+	Call   ProbeCall
+	Result json.RawMessage
+	Status int
+	Err    error
+}
+
+// NodeState is what a probe tells about a node besides its height
+type NodeState struct {
+	Syncing bool
+	// PeerCount is -1 when the probe doesn't tell
+	PeerCount int64
+	// Archive is nil when the probe doesn't tell; archive probes decide then
+	Archive *bool
+}
+
+// healthStrategies holds the strategy of each chain type; see RegisterHealthStrategy
+var healthStrategies = map[string]HealthStrategy{
+	types.ChainTypeEVM:      evmStrategy{},
+	types.ChainTypeSolana:   solanaStrategy{},
+	types.ChainTypeBitcoin:  bitcoinStrategy{},
+	types.ChainTypeBeacon:   beaconStrategy{},
+	types.ChainTypeStarknet: starknetStrategy{},
+}
+
+// RegisterHealthStrategy health checks the endpoints of chains of chainType with strategy,
+// replacing the built-in one if there is one. Register from init, before any checker starts.
+func RegisterHealthStrategy(chainType string, strategy HealthStrategy) {
+	healthStrategies[chainType] = strategy
+}
+
+// healthStrategyFor returns the strategy of a chain type, the EVM one for types without their own
+func healthStrategyFor(chainType string) HealthStrategy {
+	if strategy, ok := healthStrategies[chainType]; ok {
+		return strategy
+	}
+	return healthStrategies[types.ChainTypeEVM]
+}
+
+// findReply returns the reply to the call of a JSON-RPC method or REST path, or nil when the
+// probe didn't make it
+func findReply(replies []ProbeReply, name string) *ProbeReply {
+	for i := range replies {
+		if replies[i].Call.Method == name || (replies[i].Call.Path != "" && replies[i].Call.Path == name) {
+			return &replies[i]
+		}
+	}
+	return nil
+}
+
+// runHealthProbe health checks an endpoint with strategy: the probe is retried while the node
+// can't be reached, and a node that answers is healthy only if its replies validate, it isn't
+// syncing and, with sync checks, it has enough peers
+func (mc *MultiChainChecker) runHealthProbe(chainName string, endpoint *types.RPCEndpoint, strategy HealthStrategy) {
+	start := time.Now()
+	settings := mc.probeSettings(chainName)
+	probe := ProbeContext{Chain: chainName, Configs: mc.chainConfigs(chainName), CheckSync: settings.CheckSync}
+	calls := strategy.BuildProbe(probe)
+
+	ctx, cancel := context.WithTimeout(mc.ctx, settings.Timeout)
+	defer cancel()
+
+	var lastErr error
+	for attempt := 0; attempt < settings.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				endpoint.MarkUnhealthy(fmt.Sprintf("health check timed out: %v", lastErr))
+				return
+			}
+		}
+
+		replies, err := mc.sendProbe(ctx, endpoint, calls, start)
+		var replyErr *nodeError
+		if errors.As(err, &replyErr) {
+			log.Printf("Health check failed for %s: %v", endpoint.URL, err)
+			endpoint.MarkUnhealthy(err.Error())
+			return
+		}
+		if err != nil {
+			lastErr = err
+			log.Printf("Health check attempt %d/%d failed for %s: %v",
+				attempt+1, settings.Retries, endpoint.URL, err)
+			continue
+		}
+
+		state, err := strategy.ValidateResponse(probe, replies)
+		if err != nil {
+			log.Printf("Health check failed for %s: %v", endpoint.URL, err)
+			endpoint.MarkUnhealthy(err.Error())
+			return
+		}
+		height, err := strategy.ExtractHeight(replies)
+		if err != nil {
+			log.Printf("Health check failed for %s: %v", endpoint.URL, err)
+			endpoint.MarkUnhealthy(err.Error())
+			return
+		}
+
+		endpoint.SetSyncState(state.Syncing, state.PeerCount)
+		endpoint.SetBlockNumber(fmt.Sprintf("%d", height))
+		if state.Archive != nil {
+			endpoint.SetArchive(*state.Archive)
+		}
+
+		if state.Syncing {
+			log.Printf("Endpoint %s on chain %s is syncing, marking unhealthy", endpoint.URL, chainName)
+			endpoint.MarkUnhealthy("node is syncing")
+			return
+		}
+		minPeers := int64(settings.MinPeerCount)
+		if settings.CheckSync && minPeers > 0 && state.PeerCount >= 0 && state.PeerCount < minPeers {
+			log.Printf("Endpoint %s on chain %s has %d peers (min %d), marking unhealthy",
+				endpoint.URL, chainName, state.PeerCount, minPeers)
+			endpoint.MarkUnhealthy(fmt.Sprintf("peer count %d below minimum %d", state.PeerCount, minPeers))
+			return
+		}
+
+		if reason := mc.headVerdict(chainName, endpoint, height); reason != "" {
+			log.Printf("Endpoint %s on chain %s answered but %s, keeping it out of rotation", endpoint.URL, chainName, reason)
+			endpoint.MarkUnhealthy(reason)
+			return
+		}
+
+		endpoint.SetHealthy(true)
+		endpoint.SetLastError("")
+		log.Printf("Health check passed for %s: block %d, response time %dms",
+			endpoint.URL, height, endpoint.GetResponseTime())
+		return
+	}
+
+	// All retries failed; transport errors are classified so DNS failures stand out
+	var dnsErr *net.DNSError
+	if errors.As(lastErr, &dnsErr) {
+		endpoint.MarkUnreachable(types.FailureDNS, fmt.Sprintf("DNS resolution failed: %v", lastErr))
+	} else {
+		endpoint.MarkUnreachable(types.FailureConnection, lastErr.Error())
+	}
+
+	// Failures during scheduled maintenance are expected, so they aren't reported
+	if !endpoint.IsInMaintenance() {
+		log.Printf("Health check failed for %s after %d attempts: %v",
+			endpoint.URL, settings.Retries, lastErr)
+	}
+}
+
+// sendProbe sends a probe's calls in order. The first call's latency is the endpoint's response
+// time and its connection's certificate is recorded.
+func (mc *MultiChainChecker) sendProbe(ctx context.Context, endpoint *types.RPCEndpoint, calls []ProbeCall, start time.Time) ([]ProbeReply, error) {
+	replies := make([]ProbeReply, 0, len(calls))
+	for i, call := range calls {
+		reply, tlsState, err := mc.sendProbeCall(ctx, endpoint, call)
+		if i == 0 {
+			endpoint.SetResponseTime(time.Since(start).Milliseconds())
+			mc.recordCertExpiry(endpoint, tlsState)
+		}
+		if err != nil && !call.Optional {
+			return nil, err
+		}
+		reply.Err = err
+		replies = append(replies, reply)
+	}
+	return replies, nil
+}
+
+// sendProbeCall sends one call of a probe, over WebSocket to ws endpoints
+func (mc *MultiChainChecker) sendProbeCall(ctx context.Context, endpoint *types.RPCEndpoint, call ProbeCall) (ProbeReply, *tls.ConnectionState, error) {
+	reply := ProbeReply{Call: call}
+	var tlsState *tls.ConnectionState
+	var err error
+	switch {
+	case call.Path != "":
+		reply.Status, reply.Result, tlsState, err = mc.getNodePath(ctx, endpoint, call.Path)
+	case endpoint.GetTransport() == types.TransportWS:
+		reply.Result, tlsState, err = mc.callWebSocketRPC(ctx, endpoint, call.Method, call.Params)
+	default:
+		reply.Result, tlsState, err = mc.callNodeRPC(ctx, endpoint, call.Method, call.Params)
+	}
+	return reply, tlsState, err
+}
+
+// chainConfigs returns a chain's config keys, nil when the chain isn't known. The map is
+// replaced rather than changed by SetChainConfigs, so it may be read without the lock.
+func (mc *MultiChainChecker) chainConfigs(chainName string) map[string]string {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	if chainConfig, exists := mc.chains[chainName]; exists {
+		return chainConfig.Configs
+	}
+	return nil
+}
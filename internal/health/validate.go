@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// EndpointValidation is the result of probing a candidate endpoint that isn't configured yet
+type EndpointValidation struct {
+	URL            string     `json:"url"`
+	Chain          string     `json:"chain,omitempty"`
+	Valid          bool       `json:"valid"`
+	Healthy        bool       `json:"healthy"`
+	ResponseTimeMs int64      `json:"responseTimeMs"`
+	BlockNumber    string     `json:"blockNumber,omitempty"`
+	Syncing        bool       `json:"syncing"`
+	PeerCount      int64      `json:"peerCount"`
+	CertExpiresAt  *time.Time `json:"certExpiresAt,omitempty"`
+	ChainID        int64      `json:"chainId,omitempty"`
+	// StarknetChainID is the decoded starknet_chainId of candidates for Starknet chains
+	StarknetChainID string `json:"starknetChainId,omitempty"`
+	// ExpectedChainID and BlockLag are only set when a configured chain was given
+	ExpectedChainID int      `json:"expectedChainId,omitempty"`
+	BlockLag        *int64   `json:"blockLag,omitempty"`
+	Errors          []string `json:"errors,omitempty"`
+}
+
+// ValidateEndpoint runs the standard health probe and an eth_chainId check against a candidate's
+// URL, with its credentials, without registering it anywhere. With chainName the chain ID must match the chain and the head is
+// compared with the chain's consensus head; Solana chains have no chain ID to check. Starknet
+// chains report starknet_chainId instead, which the health probe checks against the chain's
+// starknet_chain_id when set.
+func (mc *MultiChainChecker) ValidateEndpoint(candidate *types.RPCEndpoint, chainName string) (*EndpointValidation, error) {
+	var chain *types.Chain
+	if chainName != "" {
+		mc.mu.RLock()
+		chainConfig, exists := mc.chains[chainName]
+		mc.mu.RUnlock()
+		if !exists {
+			return nil, fmt.Errorf("chain %s not found", chainName)
+		}
+		chain = chainConfig.Chain
+	}
+
+	url := candidate.URL
+	endpoint := &types.RPCEndpoint{
+		Name:         "validation",
+		URL:          url,
+		Weight:       1,
+		Enabled:      true,
+		PeerCount:    -1,
+		AuthUsername: candidate.AuthUsername,
+		AuthPassword: candidate.AuthPassword,
+	}
+	mc.checkEndpointHealth(chainName, endpoint)
+
+	syncing, peerCount := endpoint.GetSyncState()
+	result := &EndpointValidation{
+		URL:            url,
+		Chain:          chainName,
+		Healthy:        endpoint.IsHealthy(),
+		ResponseTimeMs: endpoint.GetResponseTime(),
+		BlockNumber:    endpoint.GetBlockNumber(),
+		Syncing:        syncing,
+		PeerCount:      peerCount,
+	}
+	if expiresAt := endpoint.GetCertExpiresAt(); !expiresAt.IsZero() {
+		result.CertExpiresAt = &expiresAt
+	}
+	if !result.Healthy {
+		result.Errors = append(result.Errors, endpoint.GetLastError())
+	}
+
+	ctx, cancel := context.WithTimeout(mc.ctx, mc.healthSettings().Timeout)
+	defer cancel()
+
+	checkChainID := chain == nil || chain.GetType() == types.ChainTypeEVM
+	var chainID int64
+	var err error
+	if checkChainID {
+		chainID, err = mc.fetchChainID(ctx, endpoint)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("eth_chainId failed: %v", err))
+		} else {
+			result.ChainID = chainID
+		}
+	}
+
+	if chain != nil {
+		if checkChainID {
+			result.ExpectedChainID = chain.ChainID
+		}
+		if checkChainID && err == nil && chainID != int64(chain.ChainID) {
+			result.Errors = append(result.Errors, fmt.Sprintf("chain ID %d does not match %s (%d)", chainID, chainName, chain.ChainID))
+		}
+
+		if chain.GetType() == types.ChainTypeStarknet {
+			// The health probe has already compared it with the chain's starknet_chain_id
+			if starknetChainID, err := mc.fetchStarknetChainID(ctx, endpoint); err == nil {
+				result.StarknetChainID = starknetChainID
+			} else if result.Healthy {
+				result.Errors = append(result.Errors, fmt.Sprintf("starknet_chainId failed: %v", err))
+			}
+		}
+
+		if head := mc.ConsensusHead(chainName); head > 0 && result.BlockNumber != "" {
+			if block, err := strconv.ParseInt(result.BlockNumber, 10, 64); err == nil {
+				lag := head - block
+				result.BlockLag = &lag
+			}
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result, nil
+}
+
+// fetchChainID returns the endpoint's eth_chainId as an integer
+func (mc *MultiChainChecker) fetchChainID(ctx context.Context, endpoint *types.RPCEndpoint) (int64, error) {
+	raw, err := mc.callRPC(ctx, endpoint, "eth_chainId", []interface{}{})
+	if err != nil {
+		return 0, err
+	}
+
+	var chainIDHex string
+	if err := json.Unmarshal(raw, &chainIDHex); err != nil || !strings.HasPrefix(chainIDHex, "0x") {
+		return 0, fmt.Errorf("unexpected eth_chainId result %s", string(raw))
+	}
+
+	chainID, err := strconv.ParseInt(chainIDHex[2:], 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected eth_chainId result %s", chainIDHex)
+	}
+
+	return chainID, nil
+}
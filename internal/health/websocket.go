@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"rpc-proxy/internal/types"
+)
+
+// isWebSocketURL reports whether an endpoint is probed over WebSocket instead of HTTP
+func isWebSocketURL(url string) bool {
+	return strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://")
+}
+
+// callWebSocketRPC sends a single JSON-RPC request over a fresh WebSocket connection to the
+// endpoint's URL and returns the raw result along with the TLS state of the connection, if any
+func (mc *MultiChainChecker) callWebSocketRPC(ctx context.Context, endpoint *types.RPCEndpoint, method string, params []interface{}) (json.RawMessage, *tls.ConnectionState, error) {
+	// ctx bounds the handshake, as it does HTTP probes
+	dialer := websocket.Dialer{Proxy: http.ProxyFromEnvironment}
+	switch transport := mc.httpClient().Transport.(type) {
+	case interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	}:
+		dialer.NetDialContext = transport.DialContext
+	case *http.Transport:
+		dialer.NetDialContext = transport.DialContext
+	}
+
+	var header http.Header
+	if auth := endpoint.Authorization(); auth != "" {
+		header = http.Header{"Authorization": {auth}}
+	}
+	conn, resp, err := dialer.DialContext(ctx, endpoint.URL, header)
+	if err != nil {
+		if resp != nil {
+			return nil, nil, fmt.Errorf("websocket handshake returned HTTP %d: %w", resp.StatusCode, err)
+		}
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	var tlsState *tls.ConnectionState
+	if tlsConn, ok := conn.UnderlyingConn().(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		tlsState = &state
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+		conn.SetReadDeadline(deadline)
+	}
+
+	if err := conn.WriteJSON(types.JSONRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      1,
+	}); err != nil {
+		return nil, tlsState, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	var rpcResp struct {
+		Result json.RawMessage     `json:"result"`
+		Error  *types.JSONRPCError `json:"error"`
+	}
+	if err := conn.ReadJSON(&rpcResp); err != nil {
+		return nil, tlsState, fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+
+	if rpcResp.Error != nil {
+		return nil, tlsState, &nodeError{method: method, rpc: rpcResp.Error}
+	}
+
+	return rpcResp.Result, tlsState, nil
+}
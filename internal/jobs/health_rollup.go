@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+// healthRollupGrace is how long after a period ends the job waits before summarizing it, so
+// checks still being written for its last moments are included
+const healthRollupGrace = 5 * time.Minute
+
+// HealthRollupJob summarizes raw health checks into hourly and daily rollups when run by
+// the scheduler. Each run picks up after the latest rolled-up period, so a job that was down
+// catches up.
+type HealthRollupJob struct {
+	repo repository.HealthRollupRepository
+}
+
+func NewHealthRollupJob(repo repository.HealthRollupRepository) *HealthRollupJob {
+	return &HealthRollupJob{repo: repo}
+}
+
+// Run rolls up every complete hour and day not yet summarized; a cancelled ctx stops a long
+// catch-up between periods
+func (j *HealthRollupJob) Run(ctx context.Context) error {
+	var errs []error
+	for _, granularity := range []string{types.RollupHourly, types.RollupDaily} {
+		if err := j.rollup(ctx, granularity, time.Now().UTC()); err != nil {
+			errs = append(errs, fmt.Errorf("health check rollup by %s failed: %w", granularity, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (j *HealthRollupJob) rollup(ctx context.Context, granularity string, now time.Time) error {
+	period := types.RollupPeriod(granularity)
+
+	start, err := j.repo.LastPeriodStart(granularity)
+	if err != nil {
+		return err
+	}
+	if start.IsZero() {
+		// Nothing rolled up yet: start from the oldest raw check
+		first, err := j.repo.FirstCheckTime()
+		if err != nil || first.IsZero() {
+			return err
+		}
+		start = first.Truncate(period)
+	} else {
+		start = start.Add(period)
+	}
+
+	periods, endpoints := 0, 0
+	for ; !start.Add(period + healthRollupGrace).After(now); start = start.Add(period) {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		n, err := j.repo.Rollup(ctx, granularity, start)
+		if err != nil {
+			return err
+		}
+		periods++
+		endpoints += n
+	}
+
+	if periods > 0 {
+		log.Printf("Rolled up health checks for %d periods of one %s (%d endpoint summaries)", periods, granularity, endpoints)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/repository"
+)
+
+// MethodRollupJob writes the per-method counts accumulated by a MethodTracker to hourly
+// database rollups when run by the scheduler. Counts are attributed to the hour in which
+// they are flushed.
+type MethodRollupJob struct {
+	tracker *analytics.MethodTracker
+	repo    repository.MethodUsageRepository
+}
+
+func NewMethodRollupJob(tracker *analytics.MethodTracker, repo repository.MethodUsageRepository) *MethodRollupJob {
+	return &MethodRollupJob{
+		tracker: tracker,
+		repo:    repo,
+	}
+}
+
+// Run flushes pending counts once; on failure they are kept for the next run
+func (j *MethodRollupJob) Run(ctx context.Context) error {
+	rollups := j.tracker.Drain(time.Now().UTC().Truncate(time.Hour))
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	if err := j.repo.AddRollups(ctx, rollups); err != nil {
+		j.tracker.Restore(rollups)
+		return fmt.Errorf("method usage rollup failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/metrics"
+)
+
+// maxPendingMeasurements bounds the measurements kept for retry while the sink is down;
+// beyond it the oldest are dropped
+const maxPendingMeasurements = 100000
+
+// MetricsExportJob periodically drains a metrics Collector and writes the measurements to a
+// long-term sink. A failed batch is retried with the next one.
+type MetricsExportJob struct {
+	collector *metrics.Collector
+	sink      metrics.Sink
+	interval  time.Duration
+	pending   []metrics.Measurement
+	stopChan  chan struct{}
+	running   bool
+	mu        sync.Mutex
+	wg        sync.WaitGroup
+}
+
+func NewMetricsExportJob(collector *metrics.Collector, sink metrics.Sink, interval time.Duration) *MetricsExportJob {
+	return &MetricsExportJob{
+		collector: collector,
+		sink:      sink,
+		interval:  interval,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+func (j *MetricsExportJob) Start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running {
+		return
+	}
+	j.running = true
+
+	j.wg.Add(1)
+	go j.loop()
+
+	log.Printf("Exporting metrics to %s every %v", j.sink.Name(), j.interval)
+}
+
+// Stop ends periodic exports after exporting whatever is still collected
+func (j *MetricsExportJob) Stop() {
+	j.mu.Lock()
+	if !j.running {
+		j.mu.Unlock()
+		return
+	}
+	j.running = false
+	j.mu.Unlock()
+
+	close(j.stopChan)
+	j.wg.Wait()
+	j.Run()
+}
+
+func (j *MetricsExportJob) loop() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.Run()
+		case <-j.stopChan:
+			return
+		}
+	}
+}
+
+// Run drains the collector and writes its measurements, along with any batch that failed
+// earlier, to the sink once
+func (j *MetricsExportJob) Run() {
+	measurements, dropped := j.collector.Drain()
+	if dropped > 0 {
+		log.Printf("Metrics collector full, dropped %d measurements", dropped)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.pending = append(j.pending, measurements...)
+	if len(j.pending) == 0 {
+		return
+	}
+	if excess := len(j.pending) - maxPendingMeasurements; excess > 0 {
+		log.Printf("Metrics export backlog full, dropped %d oldest measurements", excess)
+		j.pending = append([]metrics.Measurement(nil), j.pending[excess:]...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := j.sink.Write(ctx, j.pending); err != nil {
+		log.Printf("Failed to export %d measurements to %s, will retry: %v", len(j.pending), j.sink.Name(), err)
+		return
+	}
+	j.pending = nil
+}
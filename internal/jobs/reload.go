@@ -0,0 +1,424 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ReloadResult summarizes the changes applied by a configuration reload
+type ReloadResult struct {
+	ChainsAdded      []string  `json:"chainsAdded"`
+	ChainsRemoved    []string  `json:"chainsRemoved"`
+	ChainsUpdated    []string  `json:"chainsUpdated"`
+	EndpointsAdded   int       `json:"endpointsAdded"`
+	EndpointsRemoved int       `json:"endpointsRemoved"`
+	EndpointsUpdated int       `json:"endpointsUpdated"`
+	ReloadedAt       time.Time `json:"reloadedAt"`
+	// ConfigEpoch is the config epoch applied, when the configuration is versioned
+	ConfigEpoch int64 `json:"configEpoch,omitempty"`
+}
+
+// Changed reports whether the reload applied anything
+func (r *ReloadResult) Changed() bool {
+	return len(r.ChainsAdded)+len(r.ChainsRemoved)+len(r.ChainsUpdated) > 0 ||
+		r.EndpointsAdded+r.EndpointsRemoved+r.EndpointsUpdated > 0
+}
+
+// ReloadJob periodically re-reads chains, endpoints and chain configs from the database, or
+// from the chains file when one is in use, and applies the differences to the running config
+// and health checker without a restart.
+type ReloadJob struct {
+	config          *config.Config
+	checker         *health.MultiChainChecker
+	chainRepo       repository.ChainRepository
+	endpointRepo    repository.RPCEndpointRepository
+	chainConfigRepo repository.ChainConfigRepository
+	load            func() (*config.ChainSet, error)
+	path            string // chains file, for file-backed jobs
+	watch           bool   // reload when the chains file changes
+	interval        time.Duration
+	stopChan        chan struct{}
+	running         bool
+	mu              sync.Mutex
+	reloadMu        sync.Mutex
+	wg              sync.WaitGroup
+
+	// revisionRepo, when set, versions the configuration: the latest revision when a reload
+	// begins is the config epoch it applies
+	revisionRepo repository.ConfigRevisionRepository
+	settingsJob  *SettingsJob
+	epoch        atomic.Int64
+}
+
+func NewReloadJob(cfg *config.Config, checker *health.MultiChainChecker, chainRepo repository.ChainRepository,
+	endpointRepo repository.RPCEndpointRepository, chainConfigRepo repository.ChainConfigRepository, interval time.Duration) *ReloadJob {
+	j := &ReloadJob{
+		config:          cfg,
+		checker:         checker,
+		chainRepo:       chainRepo,
+		endpointRepo:    endpointRepo,
+		chainConfigRepo: chainConfigRepo,
+		interval:        interval,
+		stopChan:        make(chan struct{}),
+	}
+	j.load = j.loadFromDatabase
+	return j
+}
+
+// SetRevisions makes the latest config revision the config epoch, so replicas can be checked
+// for having applied the same configuration. The configuration loaded at startup counts as
+// the current revision's; each reload then applies the revision current when it began, and
+// settings through settingsJob, which may be nil, as well when the epoch advanced.
+func (j *ReloadJob) SetRevisions(revisionRepo repository.ConfigRevisionRepository, settingsJob *SettingsJob) {
+	j.revisionRepo = revisionRepo
+	j.settingsJob = settingsJob
+	if epoch, err := j.latestEpoch(); err != nil {
+		log.Printf("Warning: Failed to read config epoch: %v", err)
+	} else {
+		j.epoch.Store(epoch)
+	}
+}
+
+// Epoch returns the config epoch applied last, or 0 when the configuration isn't versioned
+func (j *ReloadJob) Epoch() int64 {
+	return j.epoch.Load()
+}
+
+// latestEpoch returns the ID of the latest config revision
+func (j *ReloadJob) latestEpoch() (int64, error) {
+	latest, err := j.revisionRepo.List(1)
+	if err != nil {
+		return 0, err
+	}
+	if len(latest) == 0 {
+		return 0, nil
+	}
+	return int64(latest[0].ID), nil
+}
+
+// Start begins periodic reloads, and file watching for a watched file-backed job; it is a no-op
+// when neither applies (reload on demand only)
+func (j *ReloadJob) Start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running || (j.interval <= 0 && !j.watch) {
+		return
+	}
+
+	var watcher *fsnotify.Watcher
+	if j.watch {
+		var err error
+		if watcher, err = newFileWatcher(j.path); err != nil {
+			log.Printf("Warning: Not watching %s for changes: %v", j.path, err)
+			if j.interval <= 0 {
+				return
+			}
+		}
+	}
+	j.running = true
+
+	j.wg.Add(1)
+	go j.loop(watcher)
+}
+
+func (j *ReloadJob) Stop() {
+	j.mu.Lock()
+	if !j.running {
+		j.mu.Unlock()
+		return
+	}
+	j.running = false
+	j.mu.Unlock()
+
+	close(j.stopChan)
+	j.wg.Wait()
+}
+
+// loop reloads on every tick and, when watcher is non-nil, shortly after the file changes.
+// Nil channels never fire, so either source may be absent.
+func (j *ReloadJob) loop(watcher *fsnotify.Watcher) {
+	defer j.wg.Done()
+
+	var tick <-chan time.Time
+	if j.interval > 0 {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if watcher != nil {
+		defer watcher.Close()
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-tick:
+			if _, err := j.Reload(); err != nil {
+				log.Printf("Configuration reload failed: %v", err)
+			}
+		case event := <-events:
+			if isFileChange(event, j.path) {
+				// Editors often write a file in several steps; wait for them to settle
+				debounce = time.After(fileChangeDebounce)
+			}
+		case err := <-watchErrors:
+			log.Printf("Watching %s failed: %v", j.path, err)
+		case <-debounce:
+			debounce = nil
+			log.Printf("%s changed, reloading configuration", j.path)
+			if _, err := j.Reload(); err != nil {
+				log.Printf("Configuration reload failed: %v", err)
+			}
+		case <-j.stopChan:
+			return
+		}
+	}
+}
+
+func (j *ReloadJob) loadFromDatabase() (*config.ChainSet, error) {
+	chains, err := j.chainRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chains: %w", err)
+	}
+
+	set := &config.ChainSet{
+		Chains:    chains,
+		Endpoints: make(map[string][]*types.RPCEndpoint, len(chains)),
+		Configs:   make(map[string]map[string]string, len(chains)),
+	}
+	for _, chain := range chains {
+		endpoints, err := j.endpointRepo.GetAllByChain(chain.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load endpoints for chain %s: %w", chain.Name, err)
+		}
+		configs, err := j.chainConfigRepo.GetByChainName(chain.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load configs for chain %s: %w", chain.Name, err)
+		}
+		set.Endpoints[chain.Name] = endpoints
+		set.Configs[chain.Name] = configs
+	}
+
+	return set, nil
+}
+
+// Reload diffs the database (or chains file) against running state and applies additions,
+// removals and changes. The source is read in full before anything is applied, so a failed
+// read leaves the running configuration untouched.
+func (j *ReloadJob) Reload() (*ReloadResult, error) {
+	j.reloadMu.Lock()
+	defer j.reloadMu.Unlock()
+
+	// Read before the configuration, so the epoch applied is never newer than what was read
+	var epoch int64
+	if j.revisionRepo != nil {
+		var err error
+		if epoch, err = j.latestEpoch(); err != nil {
+			return nil, fmt.Errorf("failed to read config epoch: %w", err)
+		}
+	}
+
+	set, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+	chains, endpointsByChain, configsByChain := set.Chains, set.Endpoints, set.Configs
+
+	result := &ReloadResult{
+		ChainsAdded:   []string{},
+		ChainsRemoved: []string{},
+		ChainsUpdated: []string{},
+		ReloadedAt:    time.Now(),
+	}
+
+	wanted := make(map[string]bool, len(chains))
+	for _, chain := range chains {
+		wanted[chain.Name] = true
+	}
+	for _, running := range j.config.GetChains() {
+		if !wanted[running.Name] {
+			j.checker.RemoveChain(running.Name)
+			j.config.RemoveChain(running.Name)
+			result.ChainsRemoved = append(result.ChainsRemoved, running.Name)
+		}
+	}
+
+	for _, chain := range chains {
+		endpoints, configs := endpointsByChain[chain.Name], configsByChain[chain.Name]
+
+		running := j.config.GetChainByName(chain.Name)
+		if running == nil {
+			j.config.AddChain(chain)
+			j.config.SetChainEndpoints(chain.Name, endpoints)
+			j.config.SetChainConfigs(chain.Name, configs)
+			if chain.IsEnabled {
+				j.checker.AddChain(chain.Name, &health.ChainConfig{
+					Chain:     chain,
+					Endpoints: endpoints,
+					Configs:   configs,
+				})
+				result.EndpointsAdded += len(endpoints)
+			}
+			result.ChainsAdded = append(result.ChainsAdded, chain.Name)
+			continue
+		}
+
+		// Disabled chains stay in the configuration, so they can be listed and re-enabled,
+		// but are not health checked or routed
+		if !chain.IsEnabled {
+			if running.IsEnabled {
+				j.checker.RemoveChain(chain.Name)
+				result.ChainsUpdated = append(result.ChainsUpdated, chain.Name)
+			} else if chainChanged(running, chain) {
+				result.ChainsUpdated = append(result.ChainsUpdated, chain.Name)
+			}
+			j.config.UpdateChain(chain.Name, chain)
+			j.config.SetChainEndpoints(chain.Name, endpoints)
+			j.config.SetChainConfigs(chain.Name, configs)
+			continue
+		}
+		if !running.IsEnabled {
+			j.config.UpdateChain(chain.Name, chain)
+			j.config.SetChainEndpoints(chain.Name, endpoints)
+			j.config.SetChainConfigs(chain.Name, configs)
+			j.checker.AddChain(chain.Name, &health.ChainConfig{
+				Chain:     chain,
+				Endpoints: endpoints,
+				Configs:   configs,
+			})
+			result.ChainsUpdated = append(result.ChainsUpdated, chain.Name)
+			result.EndpointsAdded += len(endpoints)
+			continue
+		}
+
+		if chainChanged(running, chain) {
+			j.config.UpdateChain(chain.Name, chain)
+			if err := j.checker.UpdateChain(chain.Name, chain); err != nil {
+				log.Printf("Failed to update chain %s in health checker: %v", chain.Name, err)
+			}
+			result.ChainsUpdated = append(result.ChainsUpdated, chain.Name)
+		}
+
+		// Discovered endpoints aren't in the source; endpoint discovery keeps them up to date
+		current := j.checker.GetAllEndpoints(chain.Name)
+		for _, endpoint := range current {
+			if endpoint.Discovered() {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+		merged, added, removed, updated := mergeEndpoints(current, endpoints)
+		if added+removed+updated > 0 {
+			if err := j.checker.SetEndpoints(chain.Name, merged); err != nil {
+				log.Printf("Failed to apply endpoints for chain %s: %v", chain.Name, err)
+				continue
+			}
+			j.config.SetChainEndpoints(chain.Name, merged)
+			result.EndpointsAdded += added
+			result.EndpointsRemoved += removed
+			result.EndpointsUpdated += updated
+		}
+
+		if !configsEqual(j.config.GetChainConfigs(chain.Name), configs) {
+			j.config.SetChainConfigs(chain.Name, configs)
+			if err := j.checker.SetChainConfigs(chain.Name, configs); err != nil {
+				log.Printf("Failed to apply configs for chain %s: %v", chain.Name, err)
+			}
+		}
+	}
+
+	if result.Changed() {
+		log.Printf("Configuration reloaded: chains +%d -%d ~%d, endpoints +%d -%d ~%d",
+			len(result.ChainsAdded), len(result.ChainsRemoved), len(result.ChainsUpdated),
+			result.EndpointsAdded, result.EndpointsRemoved, result.EndpointsUpdated)
+	}
+
+	if j.revisionRepo != nil {
+		if previous := j.epoch.Load(); epoch != previous {
+			// The revision may have changed settings, which the settings job would only
+			// apply at its next poll
+			if j.settingsJob != nil {
+				if _, err := j.settingsJob.Run(); err != nil {
+					return nil, fmt.Errorf("failed to apply settings of config epoch %d: %w", epoch, err)
+				}
+			}
+			j.epoch.Store(epoch)
+			log.Printf("Config epoch %d applied (was %d)", epoch, previous)
+		}
+		result.ConfigEpoch = epoch
+	}
+
+	return result, nil
+}
+
+// mergeEndpoints builds the new endpoint set for a chain: unchanged endpoints keep their live
+// object, changed ones are replaced (inheriting health state when the URL is unchanged)
+func mergeEndpoints(current, loaded []*types.RPCEndpoint) (merged []*types.RPCEndpoint, added, removed, updated int) {
+	currentByID := make(map[int]*types.RPCEndpoint, len(current))
+	for _, endpoint := range current {
+		currentByID[endpoint.ID] = endpoint
+	}
+
+	merged = make([]*types.RPCEndpoint, 0, len(loaded))
+	for _, endpoint := range loaded {
+		existing, exists := currentByID[endpoint.ID]
+		delete(currentByID, endpoint.ID)
+
+		switch {
+		case !exists:
+			added++
+			merged = append(merged, endpoint)
+		case endpointChanged(existing, endpoint):
+			updated++
+			if existing.URL == endpoint.URL {
+				endpoint.InheritState(existing)
+			}
+			merged = append(merged, endpoint)
+		default:
+			merged = append(merged, existing)
+		}
+	}
+	removed = len(currentByID)
+
+	return merged, added, removed, updated
+}
+
+func endpointChanged(a, b *types.RPCEndpoint) bool {
+	return a.Name != b.Name || a.URL != b.URL || a.Weight != b.Weight || a.Enabled != b.Enabled ||
+		a.GetTransport() != b.GetTransport() || a.WSURL != b.WSURL || a.GraphQLURL != b.GraphQLURL ||
+		!slices.Equal(a.Capabilities, b.Capabilities) ||
+		a.AuthUsername != b.AuthUsername || a.AuthPassword != b.AuthPassword
+}
+
+func chainChanged(a, b *types.Chain) bool {
+	return a.ID != b.ID || a.ChainID != b.ChainID || a.DisplayName != b.DisplayName || a.RPCPath != b.RPCPath ||
+		a.IsTestnet != b.IsTestnet || a.NativeCurrencySymbol != b.NativeCurrencySymbol ||
+		a.BlockExplorerURL != b.BlockExplorerURL || a.GetType() != b.GetType()
+}
+
+func configsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, exists := b[key]; !exists || other != value {
+			return false
+		}
+	}
+	return true
+}
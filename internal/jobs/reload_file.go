@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileChangeDebounce is how long the chains file must be quiet before a watched change is applied
+const fileChangeDebounce = 500 * time.Millisecond
+
+// NewFileReloadJob creates a reload job backed by the chains file at path. With watch set, Start
+// reloads whenever the file changes; Reload can always be called directly, e.g. on SIGHUP.
+func NewFileReloadJob(cfg *config.Config, checker *health.MultiChainChecker, path string, watch bool, interval time.Duration) *ReloadJob {
+	j := &ReloadJob{
+		config:   cfg,
+		checker:  checker,
+		path:     filepath.Clean(path),
+		watch:    watch,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+	j.load = j.loadFromFile
+	return j
+}
+
+// loadFromFile reads the chains file and carries over the IDs of running chains and endpoints
+func (j *ReloadJob) loadFromFile() (*config.ChainSet, error) {
+	set, err := config.LoadChainsFile(j.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chains file: %w", err)
+	}
+
+	j.carryOverIDs(set)
+	return set, nil
+}
+
+// carryOverIDs gives chains and endpoints of a set without stored IDs the IDs of their running
+// counterparts, matching chains by name and endpoints by URL. Without this inserting an
+// endpoint would renumber the ones after it and reset their health state.
+func (j *ReloadJob) carryOverIDs(set *config.ChainSet) {
+	chainIDs := make(map[string]int)
+	endpointIDs := make(map[string]map[string]int) // chainName -> URL -> ID
+	maxChainID, maxEndpointID := 0, 0
+	for _, chain := range j.config.GetChains() {
+		chainIDs[chain.Name] = chain.ID
+		maxChainID = max(maxChainID, chain.ID)
+
+		byURL := make(map[string]int)
+		for _, endpoint := range j.config.GetChainEndpoints(chain.Name) {
+			if endpoint.Discovered() {
+				continue
+			}
+			byURL[endpoint.URL] = endpoint.ID
+			maxEndpointID = max(maxEndpointID, endpoint.ID)
+		}
+		endpointIDs[chain.Name] = byURL
+	}
+
+	for _, chain := range set.Chains {
+		if id, exists := chainIDs[chain.Name]; exists {
+			chain.ID = id
+		} else {
+			maxChainID++
+			chain.ID = maxChainID
+		}
+
+		for _, endpoint := range set.Endpoints[chain.Name] {
+			endpoint.ChainID = chain.ID
+			if id, exists := endpointIDs[chain.Name][endpoint.URL]; exists {
+				endpoint.ID = id
+				// A URL listed twice gets a fresh ID the second time
+				delete(endpointIDs[chain.Name], endpoint.URL)
+			} else {
+				maxEndpointID++
+				endpoint.ID = maxEndpointID
+			}
+		}
+	}
+}
+
+// newFileWatcher watches the directory holding path rather than the file itself, so the watch
+// survives editors and config management tools that replace the file instead of writing it
+func newFileWatcher(path string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", filepath.Dir(path), err)
+	}
+
+	return watcher, nil
+}
+
+func isFileChange(event fsnotify.Event, path string) bool {
+	if filepath.Clean(event.Name) != path {
+		return false
+	}
+	return event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename)
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/remote"
+)
+
+// remoteRetryDelay is how long RemoteJob waits after a failed watch before reading the store again
+const remoteRetryDelay = 5 * time.Second
+
+// NewRemoteReloadJob creates a reload job backed by a remote store; Reload reads its chains/
+// keys. Use RemoteJob to reload when the store changes.
+func NewRemoteReloadJob(cfg *config.Config, checker *health.MultiChainChecker, store remote.Store, interval time.Duration) *ReloadJob {
+	j := &ReloadJob{
+		config:   cfg,
+		checker:  checker,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+	j.load = func() (*config.ChainSet, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		kvs, _, err := store.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", store.Name(), err)
+		}
+		set, err := config.RemoteChains(kvs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration in %s: %w", store.Name(), err)
+		}
+
+		j.carryOverIDs(set)
+		return set, nil
+	}
+	return j
+}
+
+// RemoteJob watches a remote store and, whenever its prefix changes, applies the new settings
+// and reloads chains. Invalid chain configuration is rejected as a whole, keeping the running
+// chains; invalid settings are skipped one by one.
+type RemoteJob struct {
+	store       remote.Store
+	reloadJob   *ReloadJob
+	settingsJob *SettingsJob
+
+	// index is the store index last applied; only loop reads or writes it
+	index uint64
+
+	cancel  context.CancelFunc
+	running bool
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+}
+
+func NewRemoteJob(store remote.Store, reloadJob *ReloadJob, settingsJob *SettingsJob) *RemoteJob {
+	return &RemoteJob{
+		store:       store,
+		reloadJob:   reloadJob,
+		settingsJob: settingsJob,
+	}
+}
+
+func (j *RemoteJob) Start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running {
+		return
+	}
+	j.running = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+
+	j.wg.Add(1)
+	go j.loop(ctx)
+}
+
+func (j *RemoteJob) Stop() {
+	j.mu.Lock()
+	if !j.running {
+		j.mu.Unlock()
+		return
+	}
+	j.running = false
+	j.mu.Unlock()
+
+	j.cancel()
+	j.wg.Wait()
+}
+
+func (j *RemoteJob) loop(ctx context.Context) {
+	defer j.wg.Done()
+
+	// Config.Load already applied the store's contents; this catches anything written since
+	j.apply(ctx)
+
+	for ctx.Err() == nil {
+		index, err := j.store.Wait(ctx, j.index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Watching %s failed: %v", j.store.Name(), err)
+			select {
+			case <-time.After(remoteRetryDelay):
+			case <-ctx.Done():
+				return
+			}
+			// Changes may have been missed while the watch was down
+			j.apply(ctx)
+			continue
+		}
+
+		if index != j.index {
+			log.Printf("%s changed, reloading configuration", j.store.Name())
+			j.apply(ctx)
+		}
+	}
+}
+
+// apply reads the store, applies its settings and reloads its chains
+func (j *RemoteJob) apply(ctx context.Context) {
+	kvs, index, err := j.store.List(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to read %s: %v", j.store.Name(), err)
+		}
+		return
+	}
+	j.index = index
+
+	if j.settingsJob != nil {
+		j.settingsJob.Apply(config.RemoteSettings(kvs))
+	}
+	if _, err := j.reloadJob.Reload(); err != nil {
+		log.Printf("Configuration reload failed: %v", err)
+	}
+}
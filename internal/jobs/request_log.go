@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/repository"
+)
+
+// requestLogPruneInterval is how often RequestLogJob deletes logs past their retention
+const requestLogPruneInterval = time.Hour
+
+// requestLogWriteTimeout bounds a run's write and prune so a hung database can't stall the
+// job or shutdown
+const requestLogWriteTimeout = 30 * time.Second
+
+// RequestLogJob writes the requests sampled by a RequestLogger to the database and deletes
+// logs older than the retention period. A failed write loses that batch rather than letting
+// the buffer grow while the database is down.
+type RequestLogJob struct {
+	logger    *analytics.RequestLogger
+	repo      repository.RequestLogRepository
+	interval  time.Duration
+	retention time.Duration
+	lastPrune time.Time
+	stopChan  chan struct{}
+	running   bool
+	mu        sync.Mutex
+	wg        sync.WaitGroup
+}
+
+// NewRequestLogJob creates the job; a retention of 0 keeps logs forever
+func NewRequestLogJob(logger *analytics.RequestLogger, repo repository.RequestLogRepository, interval, retention time.Duration) *RequestLogJob {
+	return &RequestLogJob{
+		logger:    logger,
+		repo:      repo,
+		interval:  interval,
+		retention: retention,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+func (j *RequestLogJob) Start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running {
+		return
+	}
+	j.running = true
+
+	j.wg.Add(1)
+	go j.loop()
+}
+
+// Stop ends periodic writes after writing whatever is still buffered
+func (j *RequestLogJob) Stop() {
+	j.mu.Lock()
+	if !j.running {
+		j.mu.Unlock()
+		return
+	}
+	j.running = false
+	j.mu.Unlock()
+
+	close(j.stopChan)
+	j.wg.Wait()
+	j.Run()
+}
+
+func (j *RequestLogJob) loop() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.Run()
+		case <-j.stopChan:
+			return
+		}
+	}
+}
+
+// Run writes buffered logs once and prunes old ones when a prune is due
+func (j *RequestLogJob) Run() {
+	logs, dropped := j.logger.Drain()
+	if dropped > 0 {
+		log.Printf("Request log buffer full, dropped %d sampled requests", dropped)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestLogWriteTimeout)
+	defer cancel()
+
+	if err := j.repo.CreateBatch(ctx, logs); err != nil {
+		log.Printf("Failed to write %d request logs: %v", len(logs), err)
+	}
+
+	if j.retention > 0 && time.Since(j.lastPrune) >= requestLogPruneInterval {
+		j.lastPrune = time.Now()
+		deleted, err := j.repo.DeleteOlderThan(ctx, time.Now().Add(-j.retention))
+		if err != nil {
+			log.Printf("Request log retention cleanup failed: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d request logs older than %v", deleted, j.retention)
+		}
+	}
+}
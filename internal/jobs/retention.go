@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"rpc-proxy/internal/repository"
+)
+
+// defaultRetentionDays applies when the health_check_retention_days setting is missing
+const defaultRetentionDays = 30
+
+// RetentionJob prunes and downsamples the health_checks table when run by the scheduler.
+// The retention window is read from settings on every run so changes apply without restart.
+type RetentionJob struct {
+	healthRepo   repository.HealthCheckRepository
+	settingsRepo repository.SettingsRepository
+}
+
+func NewRetentionJob(healthRepo repository.HealthCheckRepository, settingsRepo repository.SettingsRepository) *RetentionJob {
+	return &RetentionJob{
+		healthRepo:   healthRepo,
+		settingsRepo: settingsRepo,
+	}
+}
+
+// Run applies the retention policy once
+func (j *RetentionJob) Run(ctx context.Context) error {
+	retentionDays := j.intSetting("health_check_retention_days", defaultRetentionDays)
+	downsampleDays := j.intSetting("health_check_downsample_after_days", 0)
+
+	// A failed downsample doesn't stop old records from being deleted
+	var errs []error
+	if downsampleDays > 0 && (retentionDays == 0 || downsampleDays < retentionDays) {
+		if err := j.healthRepo.DownsampleOldRecords(ctx, downsampleDays); err != nil {
+			errs = append(errs, fmt.Errorf("health check downsampling failed: %w", err))
+		}
+	}
+
+	if retentionDays > 0 {
+		if err := j.healthRepo.DeleteOldRecords(ctx, retentionDays); err != nil {
+			errs = append(errs, fmt.Errorf("health check retention cleanup failed: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (j *RetentionJob) intSetting(key string, defaultValue int) int {
+	val, err := j.settingsRepo.Get(key)
+	if err != nil {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(val)
+	if err != nil || parsed < 0 {
+		log.Printf("Invalid value %q for setting %s, using %d", val, key, defaultValue)
+		return defaultValue
+	}
+
+	return parsed
+}
@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/proxy"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/scheduler"
+)
+
+// SettingsJob polls the settings table and applies changed runtime settings to the health
+// checker and proxy server. Invalid values are logged and ignored, keeping the current value.
+type SettingsJob struct {
+	checker      *health.MultiChainChecker
+	server       *proxy.Server
+	settingsRepo repository.SettingsRepository
+	interval     time.Duration
+	scheduler    *scheduler.Scheduler
+
+	// current is what was last applied; only Run reads or writes it
+	current config.RuntimeSettings
+	runMu   sync.Mutex
+
+	stopChan chan struct{}
+	running  bool
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+func NewSettingsJob(cfg *config.Config, checker *health.MultiChainChecker, server *proxy.Server, settingsRepo repository.SettingsRepository, interval time.Duration) *SettingsJob {
+	return &SettingsJob{
+		checker:      checker,
+		server:       server,
+		settingsRepo: settingsRepo,
+		interval:     interval,
+		current:      cfg.RuntimeSettings(),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// SetScheduler applies job_<name>_enabled and job_<name>_interval settings to the
+// scheduler's tasks; call it before Start
+func (j *SettingsJob) SetScheduler(s *scheduler.Scheduler) {
+	j.scheduler = s
+}
+
+// Start begins polling; it does nothing when the interval is 0
+func (j *SettingsJob) Start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running || j.interval <= 0 {
+		return
+	}
+	j.running = true
+
+	j.wg.Add(1)
+	go j.loop()
+}
+
+func (j *SettingsJob) Stop() {
+	j.mu.Lock()
+	if !j.running {
+		j.mu.Unlock()
+		return
+	}
+	j.running = false
+	j.mu.Unlock()
+
+	close(j.stopChan)
+	j.wg.Wait()
+}
+
+func (j *SettingsJob) loop() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := j.Run(); err != nil {
+				log.Printf("Settings reload failed: %v", err)
+			}
+		case <-j.stopChan:
+			return
+		}
+	}
+}
+
+// Run reads the settings once and applies whatever changed, returning the changed keys
+func (j *SettingsJob) Run() ([]string, error) {
+	settings, err := j.settingsRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	return j.Apply(settings), nil
+}
+
+// Apply applies whatever changed in settings, returning the changed keys. It lets a job with
+// no settings repository be fed from another source.
+func (j *SettingsJob) Apply(settings map[string]string) []string {
+	j.runMu.Lock()
+	defer j.runMu.Unlock()
+
+	next, invalid := config.ParseRuntimeSettings(j.current, settings)
+	for key, err := range invalid {
+		log.Printf("Ignoring setting %s: %v", key, err)
+	}
+
+	var changed []string
+	if next.HealthCheckInterval != j.current.HealthCheckInterval {
+		changed = append(changed, "health_check_interval")
+	}
+	if next.HealthCheckTimeout != j.current.HealthCheckTimeout {
+		changed = append(changed, "health_check_timeout")
+	}
+	if next.HealthCheckRetries != j.current.HealthCheckRetries {
+		changed = append(changed, "health_check_retries")
+	}
+	if len(changed) > 0 {
+		healthConfig := j.checker.HealthConfig()
+		healthConfig.Interval = next.HealthCheckInterval
+		healthConfig.Timeout = next.HealthCheckTimeout
+		healthConfig.Retries = next.HealthCheckRetries
+		j.checker.SetHealthConfig(healthConfig)
+	}
+
+	if next.ProxyTimeout != j.current.ProxyTimeout {
+		j.server.SetTimeout(next.ProxyTimeout)
+		changed = append(changed, "proxy_timeout")
+	}
+	if next.MaxConnections != j.current.MaxConnections {
+		j.server.SetMaxConnections(next.MaxConnections)
+		changed = append(changed, "max_connections")
+	}
+	if next.ClientLimits != j.current.ClientLimits {
+		j.server.SetClientLimits(next.ClientLimits)
+		changed = append(changed, "client_limits")
+	}
+	if next.ServerPort != j.current.ServerPort {
+		log.Printf("Setting server_port changed to %d; it takes effect after a restart", next.ServerPort)
+		next.ServerPort = j.current.ServerPort
+	}
+
+	if j.scheduler != nil {
+		changed = append(changed, j.scheduler.ApplySettings(settings)...)
+	}
+
+	if len(changed) > 0 {
+		log.Printf("Applied settings changes: %v", changed)
+	}
+	j.current = next
+
+	return changed
+}
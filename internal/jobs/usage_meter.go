@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/repository"
+)
+
+// UsageMeterJob writes the per-API-key usage accumulated by a UsageMeter to daily database
+// rows when run by the scheduler
+type UsageMeterJob struct {
+	meter *analytics.UsageMeter
+	repo  repository.APIKeyUsageRepository
+}
+
+func NewUsageMeterJob(meter *analytics.UsageMeter, repo repository.APIKeyUsageRepository) *UsageMeterJob {
+	return &UsageMeterJob{
+		meter: meter,
+		repo:  repo,
+	}
+}
+
+// Run flushes pending usage once; on failure it is kept for the next run
+func (j *UsageMeterJob) Run(ctx context.Context) error {
+	usage, buckets := j.meter.Drain()
+	if len(usage) == 0 && len(buckets) == 0 {
+		return nil
+	}
+
+	if err := j.repo.AddUsage(ctx, usage, buckets); err != nil {
+		j.meter.Restore(usage, buckets)
+		return fmt.Errorf("API key usage flush failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+// Package loadtest fires a weighted mix of JSON-RPC calls at the proxy or at upstream
+// endpoints directly and measures throughput and latency, for capacity planning and
+// regression checks without external tooling.
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rpc-proxy/internal/transport"
+)
+
+// Call is one JSON-RPC method in the mix, picked in proportion to its weight
+type Call struct {
+	Method string
+	Params []interface{}
+	Weight int
+}
+
+// defaultParams are the params sent for well-known methods; other methods get none
+var defaultParams = map[string][]interface{}{
+	"eth_getBalance":                       {"0x0000000000000000000000000000000000000000", "latest"},
+	"eth_getTransactionCount":              {"0x0000000000000000000000000000000000000000", "latest"},
+	"eth_getCode":                          {"0x0000000000000000000000000000000000000000", "latest"},
+	"eth_getBlockByNumber":                 {"latest", false},
+	"eth_getBlockTransactionCountByNumber": {"latest"},
+	"eth_getLogs":                          {map[string]interface{}{"fromBlock": "latest", "toBlock": "latest"}},
+	"eth_feeHistory":                       {"0x4", "latest", []interface{}{}},
+}
+
+// ParseMix parses a comma-separated method mix such as "eth_blockNumber:5,eth_getBalance:2";
+// a method without a weight has weight 1
+func ParseMix(mix string) ([]Call, error) {
+	var calls []Call
+	for _, entry := range strings.Split(mix, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		method, weightStr, hasWeight := strings.Cut(entry, ":")
+		weight := 1
+		if hasWeight {
+			parsed, err := strconv.Atoi(weightStr)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid weight for %s: must be a positive integer", method)
+			}
+			weight = parsed
+		}
+
+		params, known := defaultParams[method]
+		if !known {
+			params = []interface{}{}
+		}
+		calls = append(calls, Call{Method: method, Params: params, Weight: weight})
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("the method mix is empty")
+	}
+	return calls, nil
+}
+
+type Options struct {
+	// Targets are JSON-RPC URLs, e.g. http://localhost:8888/rpc/ethereum or an upstream
+	// endpoint; requests go to them in turn
+	Targets []string
+	Calls   []Call
+	// Concurrency is how many requests are in flight at once
+	Concurrency int
+	// Duration bounds the run; Requests, when positive, stops it after that many requests
+	Duration time.Duration
+	Requests int
+	// Rate caps requests per second across all workers (0 is unlimited)
+	Rate float64
+	// Batch sends that many calls per request as a JSON-RPC batch (1 sends single calls)
+	Batch   int
+	Timeout time.Duration
+	Headers http.Header
+}
+
+// sample is the outcome of one request
+type sample struct {
+	method  string
+	target  string
+	latency time.Duration
+	failure string // "" on success, otherwise one of the Failure constants
+}
+
+// Failure kinds
+const (
+	FailureTransport = "transport"
+	FailureHTTP      = "http"
+	FailureRPC       = "rpc"
+	FailureInvalid   = "invalid_response"
+)
+
+// Run fires requests until the duration elapses, the request count is reached or ctx is
+// cancelled, and reports the results
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if len(opts.Targets) == 0 {
+		return nil, fmt.Errorf("no target URL")
+	}
+	if len(opts.Calls) == 0 {
+		return nil, fmt.Errorf("the method mix is empty")
+	}
+	opts.Concurrency = max(opts.Concurrency, 1)
+	opts.Batch = max(opts.Batch, 1)
+
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		Transport: transport.New(transport.Config{
+			MaxIdleConns:        opts.Concurrency,
+			MaxIdleConnsPerHost: opts.Concurrency,
+			IdleConnTimeout:     90 * time.Second,
+			DialTimeout:         5 * time.Second,
+			KeepAlive:           30 * time.Second,
+			TLSHandshakeTimeout: 5 * time.Second,
+		}, nil),
+	}
+
+	var tokens <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	totalWeight := 0
+	for _, call := range opts.Calls {
+		totalWeight += call.Weight
+	}
+
+	var issued, next atomic.Int64
+	results := make([][]sample, opts.Concurrency)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(time.Now().UnixNano() + int64(w)))
+
+			for ctx.Err() == nil {
+				if opts.Requests > 0 && issued.Add(1) > int64(opts.Requests) {
+					return
+				}
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-ctx.Done():
+						return
+					}
+				}
+
+				call := pickCall(opts.Calls, totalWeight, random)
+				target := opts.Targets[int(next.Add(1)-1)%len(opts.Targets)]
+				s := send(ctx, client, target, call, opts)
+				if ctx.Err() != nil && s.failure == FailureTransport {
+					// Cut short by the end of the run, not a real failure
+					return
+				}
+				results[w] = append(results[w], s)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var samples []sample
+	for _, workerSamples := range results {
+		samples = append(samples, workerSamples...)
+	}
+	return newReport(opts, samples, time.Since(start)), nil
+}
+
+func pickCall(calls []Call, totalWeight int, random *rand.Rand) Call {
+	n := random.Intn(totalWeight)
+	for _, call := range calls {
+		if n < call.Weight {
+			return call
+		}
+		n -= call.Weight
+	}
+	return calls[len(calls)-1]
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Error json.RawMessage `json:"error"`
+}
+
+// send makes one request and classifies its outcome
+func send(ctx context.Context, client *http.Client, target string, call Call, opts Options) sample {
+	var body []byte
+	if opts.Batch == 1 {
+		body, _ = json.Marshal(rpcRequest{JSONRPC: "2.0", ID: 1, Method: call.Method, Params: call.Params})
+	} else {
+		batch := make([]rpcRequest, opts.Batch)
+		for i := range batch {
+			batch[i] = rpcRequest{JSONRPC: "2.0", ID: i + 1, Method: call.Method, Params: call.Params}
+		}
+		body, _ = json.Marshal(batch)
+	}
+
+	s := sample{method: call.Method, target: target}
+	started := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		s.failure = FailureTransport
+		return s
+	}
+	for key, values := range opts.Headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		s.latency, s.failure = time.Since(started), FailureTransport
+		return s
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	s.latency = time.Since(started)
+
+	switch {
+	case err != nil:
+		s.failure = FailureTransport
+	case resp.StatusCode != http.StatusOK:
+		s.failure = FailureHTTP
+	default:
+		s.failure = classifyBody(respBody)
+	}
+	return s
+}
+
+// classifyBody reports FailureRPC if any response in a single or batch body carries a
+// JSON-RPC error, FailureInvalid if the body is not JSON-RPC, and "" otherwise
+func classifyBody(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	var responses []rpcResponse
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &responses); err != nil {
+			return FailureInvalid
+		}
+	} else {
+		var single rpcResponse
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return FailureInvalid
+		}
+		responses = []rpcResponse{single}
+	}
+
+	for _, response := range responses {
+		if len(response.Error) > 0 && string(response.Error) != "null" {
+			return FailureRPC
+		}
+	}
+	return ""
+}
+
+// sortedLatencies returns the latencies of samples in ascending order
+func sortedLatencies(samples []sample) []time.Duration {
+	latencies := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		latencies[i] = s.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencies
+}
@@ -0,0 +1,133 @@
+package loadtest
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Report summarizes a load test run. Latencies are in milliseconds.
+type Report struct {
+	Targets     []string `json:"targets"`
+	Concurrency int      `json:"concurrency"`
+	Batch       int      `json:"batch"`
+	DurationMs  int64    `json:"durationMs"`
+	Requests    int      `json:"requests"`
+	Succeeded   int      `json:"succeeded"`
+	Failed      int      `json:"failed"`
+	// Failures counts failed requests by kind: transport, http, rpc or invalid_response
+	Failures   map[string]int `json:"failures"`
+	ErrorRate  float64        `json:"errorRate"`
+	Throughput float64        `json:"throughput"` // requests per second
+	Latency    LatencySummary `json:"latency"`
+
+	Methods []BreakdownRow `json:"methods"`
+	// ByTarget is only reported when there is more than one target
+	ByTarget []BreakdownRow `json:"byTarget,omitempty"`
+}
+
+// LatencySummary reports latency percentiles over all requests, successful or not
+type LatencySummary struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// BreakdownRow reports the requests of one method or one target
+type BreakdownRow struct {
+	Name     string         `json:"name"`
+	Requests int            `json:"requests"`
+	Failed   int            `json:"failed"`
+	Latency  LatencySummary `json:"latency"`
+}
+
+func newReport(opts Options, samples []sample, elapsed time.Duration) *Report {
+	report := &Report{
+		Targets:     opts.Targets,
+		Concurrency: opts.Concurrency,
+		Batch:       opts.Batch,
+		DurationMs:  elapsed.Milliseconds(),
+		Requests:    len(samples),
+		Failures:    make(map[string]int),
+		Latency:     summarize(samples),
+		Methods:     breakdown(samples, func(s sample) string { return s.method }),
+	}
+	if len(opts.Targets) > 1 {
+		report.ByTarget = breakdown(samples, func(s sample) string { return s.target })
+	}
+
+	for _, s := range samples {
+		if s.failure != "" {
+			report.Failures[s.failure]++
+			report.Failed++
+		}
+	}
+	report.Succeeded = report.Requests - report.Failed
+	if report.Requests > 0 {
+		report.ErrorRate = float64(report.Failed) / float64(report.Requests)
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Requests) / elapsed.Seconds()
+	}
+	return report
+}
+
+// breakdown groups samples by key, busiest first
+func breakdown(samples []sample, key func(sample) string) []BreakdownRow {
+	groups := make(map[string][]sample)
+	for _, s := range samples {
+		groups[key(s)] = append(groups[key(s)], s)
+	}
+
+	rows := make([]BreakdownRow, 0, len(groups))
+	for name, group := range groups {
+		row := BreakdownRow{Name: name, Requests: len(group), Latency: summarize(group)}
+		for _, s := range group {
+			if s.failure != "" {
+				row.Failed++
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Requests != rows[j].Requests {
+			return rows[i].Requests > rows[j].Requests
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+func summarize(samples []sample) LatencySummary {
+	if len(samples) == 0 {
+		return LatencySummary{}
+	}
+
+	latencies := sortedLatencies(samples)
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	return LatencySummary{
+		Mean: milliseconds(total / time.Duration(len(latencies))),
+		P50:  milliseconds(percentile(latencies, 50)),
+		P90:  milliseconds(percentile(latencies, 90)),
+		P95:  milliseconds(percentile(latencies, 95)),
+		P99:  milliseconds(percentile(latencies, 99)),
+		Max:  milliseconds(latencies[len(latencies)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile of ascending latencies
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(latencies))))
+	return latencies[min(max(rank, 1), len(latencies))-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
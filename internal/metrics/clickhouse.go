@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// clickHouseSink writes to ClickHouse over its HTTP interface, so no driver is needed
+type clickHouseSink struct {
+	url    *url.URL
+	table  string
+	client *http.Client
+}
+
+func newClickHouseSink(cfg Config) (*clickHouseSink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ClickHouse URL, must be http(s)://[user:password@]host:port/")
+	}
+
+	s := &clickHouseSink{
+		url:    u,
+		table:  cfg.Table,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Monthly partitions let operators drop old months, or set a TTL, cheaply
+	create := `CREATE TABLE IF NOT EXISTS ` + s.table + ` (
+		time DateTime64(3, 'UTC'),
+		kind LowCardinality(String),
+		chain LowCardinality(String),
+		endpoint String,
+		requests UInt64,
+		errors UInt64,
+		total_latency_ms UInt64,
+		max_latency_ms UInt64,
+		bytes_sent UInt64,
+		bytes_received UInt64
+	) ENGINE = MergeTree
+	PARTITION BY toYYYYMM(time)
+	ORDER BY (kind, chain, endpoint, time)`
+	if err := s.exec(ctx, nil, strings.NewReader(create)); err != nil {
+		return nil, fmt.Errorf("failed to create ClickHouse table %s: %w", s.table, err)
+	}
+
+	return s, nil
+}
+
+func (s *clickHouseSink) Write(ctx context.Context, measurements []Measurement) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, m := range measurements {
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+	}
+
+	params := url.Values{
+		"query": {"INSERT INTO " + s.table + " FORMAT JSONEachRow"},
+		// JSON encodes times as RFC 3339, which the basic parser rejects
+		"date_time_input_format": {"best_effort"},
+	}
+	return s.exec(ctx, params, &body)
+}
+
+func (s *clickHouseSink) Close() error {
+	return nil
+}
+
+func (s *clickHouseSink) Name() string {
+	return "ClickHouse at " + s.url.Host
+}
+
+// exec POSTs body to the HTTP interface with params added to the configured URL's query;
+// credentials in the URL are sent as basic auth
+func (s *clickHouseSink) exec(ctx context.Context, params url.Values, body io.Reader) error {
+	u := *s.url
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("ClickHouse returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
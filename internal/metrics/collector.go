@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxTrafficSeries bounds the distinct chain/endpoint pairs aggregated per interval
+	maxTrafficSeries = 10000
+
+	// maxPendingHealthChecks bounds the health checks buffered between drains
+	maxPendingHealthChecks = 10000
+)
+
+type seriesKey struct {
+	chain    string
+	endpoint string
+}
+
+// Collector aggregates proxied traffic per endpoint and buffers health checks until they
+// are drained into measurements. It is safe for concurrent use.
+type Collector struct {
+	mu      sync.Mutex
+	since   time.Time
+	traffic map[seriesKey]*Measurement
+	health  []Measurement
+	dropped int64
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		since:   time.Now().UTC(),
+		traffic: make(map[seriesKey]*Measurement),
+	}
+}
+
+// RecordRequest adds one request forwarded to endpoint
+func (c *Collector) RecordRequest(chain, endpoint string, latency time.Duration, success bool, bytesSent, bytesReceived int64) {
+	latencyMs := latency.Milliseconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := seriesKey{chain: chain, endpoint: endpoint}
+	m, ok := c.traffic[key]
+	if !ok {
+		if len(c.traffic) >= maxTrafficSeries {
+			c.dropped++
+			return
+		}
+		m = &Measurement{Kind: KindTraffic, Chain: chain, Endpoint: endpoint}
+		c.traffic[key] = m
+	}
+
+	m.Requests++
+	if !success {
+		m.Errors++
+	}
+	m.TotalLatencyMs += latencyMs
+	if latencyMs > m.MaxLatencyMs {
+		m.MaxLatencyMs = latencyMs
+	}
+	m.BytesSent += bytesSent
+	m.BytesReceived += bytesReceived
+}
+
+// RecordHealthCheck adds the result of one health check of endpoint
+func (c *Collector) RecordHealthCheck(chain, endpoint string, responseTimeMs int64, healthy bool) {
+	m := Measurement{
+		Time:           time.Now().UTC(),
+		Kind:           KindHealth,
+		Chain:          chain,
+		Endpoint:       endpoint,
+		Requests:       1,
+		TotalLatencyMs: responseTimeMs,
+		MaxLatencyMs:   responseTimeMs,
+	}
+	if !healthy {
+		m.Errors = 1
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.health) >= maxPendingHealthChecks {
+		c.dropped++
+		return
+	}
+	c.health = append(c.health, m)
+}
+
+// Drain returns the measurements collected since the previous drain, with traffic stamped
+// at the start of the interval, and the number dropped because a bound was reached
+func (c *Collector) Drain() ([]Measurement, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	measurements := make([]Measurement, 0, len(c.traffic)+len(c.health))
+	for _, m := range c.traffic {
+		m.Time = c.since
+		measurements = append(measurements, *m)
+	}
+	measurements = append(measurements, c.health...)
+	dropped := c.dropped
+
+	c.since = time.Now().UTC()
+	c.traffic = make(map[seriesKey]*Measurement)
+	c.health = nil
+	c.dropped = 0
+
+	return measurements, dropped
+}
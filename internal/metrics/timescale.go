@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// timescaleSink writes to a TimescaleDB hypertable over its own connection pool, never the
+// operational database's
+type timescaleSink struct {
+	db    *gorm.DB
+	table string
+	host  string
+}
+
+func newTimescaleSink(cfg Config) (*timescaleSink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") || u.Host == "" {
+		return nil, fmt.Errorf("invalid TimescaleDB URL, must be postgres://[user:password@]host:port/database")
+	}
+
+	db, err := gorm.Open(postgres.Open(cfg.URL), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to TimescaleDB: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(2)
+
+	s := &timescaleSink{db: db, table: cfg.Table, host: u.Host}
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + s.table + ` (
+			time TIMESTAMPTZ NOT NULL,
+			kind TEXT NOT NULL,
+			chain TEXT NOT NULL,
+			endpoint TEXT NOT NULL,
+			requests BIGINT NOT NULL,
+			errors BIGINT NOT NULL,
+			total_latency_ms BIGINT NOT NULL,
+			max_latency_ms BIGINT NOT NULL,
+			bytes_sent BIGINT NOT NULL,
+			bytes_received BIGINT NOT NULL
+		)`,
+		`SELECT create_hypertable('` + s.table + `', 'time', if_not_exists => TRUE)`,
+		`CREATE INDEX IF NOT EXISTS ` + strings.ReplaceAll(s.table, ".", "_") + `_series_idx ON ` + s.table + ` (chain, endpoint, time DESC)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("failed to create TimescaleDB hypertable %s: %w", s.table, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *timescaleSink) Write(ctx context.Context, measurements []Measurement) error {
+	return s.db.WithContext(ctx).Table(s.table).CreateInBatches(measurements, 1000).Error
+}
+
+func (s *timescaleSink) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (s *timescaleSink) Name() string {
+	return "TimescaleDB at " + s.host
+}
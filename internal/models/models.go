@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Chain represents a blockchain network
@@ -20,6 +21,11 @@ type Chain struct {
 	BlockExplorerURL     string    `json:"blockExplorerUrl" gorm:"size:500"`
 	CreatedAt            time.Time `json:"createdAt"`
 	UpdatedAt            time.Time `json:"updatedAt"`
+	// DeletedAt makes deletes soft: trashed chains are hidden from queries until restored
+	DeletedAt gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
+
+	// Type is evm or solana, selecting the chain's health checks and routing
+	Type string `json:"type" gorm:"size:20;not null;default:'evm'"`
 
 	// Relationships
 	RPCEndpoints []RPCEndpoint `json:"rpcEndpoints,omitempty" gorm:"foreignKey:ChainID;constraint:OnDelete:CASCADE"`
@@ -42,14 +48,31 @@ type ChainConfig struct {
 
 // RPCEndpoint represents an RPC endpoint in the database
 type RPCEndpoint struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"size:100;not null"`
-	URL       string    `json:"url" gorm:"size:500;not null"`
-	Weight    int       `json:"weight" gorm:"default:1;check:weight > 0"`
-	Enabled   bool      `json:"enabled" gorm:"default:true;index"`
-	ChainID   uint      `json:"chainId" gorm:"not null;index"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"size:100;not null"`
+	URL       string         `json:"url" gorm:"size:500;not null"`
+	Weight    int            `json:"weight" gorm:"default:1;check:weight > 0"`
+	Enabled   bool           `json:"enabled" gorm:"default:true;index"`
+	ChainID   uint           `json:"chainId" gorm:"not null;index"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
+
+	// Transport is http or ws; WSURL is an http endpoint's WebSocket URL for subscriptions
+	Transport string `json:"transport" gorm:"size:10;not null;default:'http'"`
+	WSURL     string `json:"wsUrl,omitempty" gorm:"column:ws_url;size:500"`
+
+	// GraphQLURL is where an http endpoint serves GraphQL, such as Geth's /graphql; endpoints
+	// without one get no GraphQL requests
+	GraphQLURL string `json:"graphqlUrl,omitempty" gorm:"column:graphql_url;size:500"`
+
+	// Capabilities is a comma-separated list of the method namespaces, debug and trace, that
+	// are only routed to endpoints having them
+	Capabilities string `json:"capabilities,omitempty" gorm:"size:200"`
+
+	// Basic auth credentials sent with every upstream request, as Bitcoin nodes require
+	AuthUsername string `json:"authUsername,omitempty" gorm:"size:100"`
+	AuthPassword string `json:"-" gorm:"size:255"`
 
 	// Runtime fields (not stored in database)
 	Healthy      bool         `json:"healthy" gorm:"-"`
@@ -110,11 +133,79 @@ type HealthCheck struct {
 	BlockNumber    string    `json:"blockNumber" gorm:"size:20"`
 	ErrorMessage   string    `json:"errorMessage" gorm:"type:text"`
 	CheckedAt      time.Time `json:"checkedAt" gorm:"index;default:CURRENT_TIMESTAMP"`
+	SampleCount    int       `json:"sampleCount" gorm:"not null;default:1"` // >1 for downsampled hourly aggregates
 
 	// Relationships
 	Endpoint RPCEndpoint `json:"endpoint,omitempty" gorm:"foreignKey:EndpointID"`
 }
 
+// MaintenanceWindow excludes an endpoint from routing between StartsAt and EndsAt,
+// repeating daily or weekly when Recurrence is set
+type MaintenanceWindow struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	EndpointID  uint      `json:"endpointId" gorm:"not null;index"`
+	StartsAt    time.Time `json:"startsAt" gorm:"not null"`
+	EndsAt      time.Time `json:"endsAt" gorm:"not null"`
+	Recurrence  string    `json:"recurrence" gorm:"size:20;not null;default:'none'"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"createdAt"`
+
+	// Relationships
+	Endpoint RPCEndpoint `json:"endpoint,omitempty" gorm:"foreignKey:EndpointID;constraint:OnDelete:CASCADE"`
+}
+
+// MethodUsageRollup aggregates proxied calls per chain and JSON-RPC method per hour
+type MethodUsageRollup struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ChainName      string    `json:"chainName" gorm:"size:50;not null;uniqueIndex:idx_method_usage_period"`
+	Method         string    `json:"method" gorm:"size:100;not null;uniqueIndex:idx_method_usage_period"`
+	PeriodStart    time.Time `json:"periodStart" gorm:"not null;uniqueIndex:idx_method_usage_period;index"`
+	Requests       int64     `json:"requests" gorm:"not null;default:0"`
+	Errors         int64     `json:"errors" gorm:"not null;default:0"`
+	TotalLatencyMs int64     `json:"totalLatencyMs" gorm:"not null;default:0"`
+	MaxLatencyMs   int64     `json:"maxLatencyMs" gorm:"not null;default:0"`
+}
+
+// HealthCheckHourlyRollup summarizes an endpoint's health checks over one hour
+type HealthCheckHourlyRollup struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	EndpointID        uint      `json:"endpointId" gorm:"not null;uniqueIndex:idx_health_hourly_period"`
+	PeriodStart       time.Time `json:"periodStart" gorm:"not null;uniqueIndex:idx_health_hourly_period;index"`
+	Checks            int64     `json:"checks" gorm:"not null;default:0"`
+	HealthyChecks     int64     `json:"healthyChecks" gorm:"not null;default:0"`
+	AvgResponseTimeMs float64   `json:"avgResponseTimeMs" gorm:"not null;default:0"`
+	P95ResponseTimeMs int64     `json:"p95ResponseTimeMs" gorm:"not null;default:0"`
+}
+
+// HealthCheckDailyRollup summarizes an endpoint's health checks over one UTC day
+type HealthCheckDailyRollup struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	EndpointID        uint      `json:"endpointId" gorm:"not null;uniqueIndex:idx_health_daily_period"`
+	PeriodStart       time.Time `json:"periodStart" gorm:"not null;uniqueIndex:idx_health_daily_period;index"`
+	Checks            int64     `json:"checks" gorm:"not null;default:0"`
+	HealthyChecks     int64     `json:"healthyChecks" gorm:"not null;default:0"`
+	AvgResponseTimeMs float64   `json:"avgResponseTimeMs" gorm:"not null;default:0"`
+	P95ResponseTimeMs int64     `json:"p95ResponseTimeMs" gorm:"not null;default:0"`
+}
+
+// RequestLog is one sampled proxied request
+type RequestLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"not null;index"`
+	ChainName  string    `json:"chainName" gorm:"size:50;not null;index"`
+	Method     string    `json:"method" gorm:"size:100;not null"`
+	BatchSize  int       `json:"batchSize" gorm:"not null;default:1"`
+	Upstream   string    `json:"upstream" gorm:"size:100"`
+	Attempts   int       `json:"attempts" gorm:"not null;default:0"`
+	Status     int       `json:"status" gorm:"not null;default:0"`
+	DurationMs int64     `json:"durationMs" gorm:"not null;default:0"`
+	Success    bool      `json:"success" gorm:"not null;index"`
+	Error      string    `json:"error" gorm:"type:text"`
+	ClientIP   string    `json:"clientIp" gorm:"size:64"`
+	ClientKey  string    `json:"clientKey" gorm:"size:20"`
+	TenantID   *uint     `json:"tenantId" gorm:"index"`
+}
+
 // Setting represents a configuration setting
 type Setting struct {
 	Key         string    `json:"key" gorm:"primaryKey;size:100"`
@@ -123,6 +214,115 @@ type Setting struct {
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
+// ConfigRevision is a snapshot of the configuration document, stored as JSON
+type ConfigRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null;index"`
+	Action    string    `json:"action" gorm:"size:200;not null"`
+	Changes   int       `json:"changes" gorm:"not null;default:0"`
+	Document  string    `json:"document" gorm:"not null;type:text"`
+}
+
+// Tenant is a customer or project that API keys belong to
+type Tenant struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name" gorm:"uniqueIndex;size:100;not null"`
+	Contact string `json:"contact" gorm:"size:200"`
+	Plan    string `json:"plan" gorm:"size:50"`
+	Status  string `json:"status" gorm:"size:20;not null;default:'active'"`
+	// AllowedChains is a comma-separated list of chain names; empty allows every chain
+	AllowedChains string `json:"allowedChains" gorm:"type:text"`
+	// AllowedMethods is a comma-separated list of method names and prefix* patterns; empty
+	// allows every method
+	AllowedMethods string `json:"allowedMethods" gorm:"type:text"`
+	// RateLimit, RateBurst and MaxConcurrency override the plan's limits; 0 inherits them
+	RateLimit      float64   `json:"rateLimit" gorm:"not null;default:0"`
+	RateBurst      int       `json:"rateBurst" gorm:"not null;default:0"`
+	MaxConcurrency int       `json:"maxConcurrency" gorm:"not null;default:0"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Plan is a tier of service assigned to tenants by name
+type Plan struct {
+	ID             uint    `json:"id" gorm:"primaryKey"`
+	Name           string  `json:"name" gorm:"uniqueIndex;size:50;not null"`
+	RateLimit      float64 `json:"rateLimit" gorm:"not null;default:0"`
+	RateBurst      int     `json:"rateBurst" gorm:"not null;default:0"`
+	MaxConcurrency int     `json:"maxConcurrency" gorm:"not null;default:0"`
+	// MonthlyQuota is in compute units; 0 is unlimited
+	MonthlyQuota int64 `json:"monthlyQuota" gorm:"not null;default:0"`
+	// AllowedChains and AllowedMethods are comma-separated; empty allows any
+	AllowedChains  string    `json:"allowedChains" gorm:"type:text"`
+	AllowedMethods string    `json:"allowedMethods" gorm:"type:text"`
+	HedgingEnabled bool      `json:"hedgingEnabled" gorm:"not null;default:false"`
+	CachePriority  int       `json:"cachePriority" gorm:"not null;default:0"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// APIKey is a tenant's key for proxied requests, stored as a SHA-256 hash
+type APIKey struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TenantID  uint       `json:"tenantId" gorm:"not null;index"`
+	Name      string     `json:"name" gorm:"size:100"`
+	Prefix    string     `json:"prefix" gorm:"size:20;not null"`
+	KeyHash   string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	// ExpiresAt ends a rotated key's grace window
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// AllowedOrigins and AllowedCIDRs are comma-separated; empty allows any
+	AllowedOrigins string `json:"allowedOrigins" gorm:"type:text"`
+	AllowedCIDRs   string `json:"allowedCidrs" gorm:"type:text"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID;constraint:OnDelete:CASCADE"`
+}
+
+// APIKeyUsage meters one API key's requests per UTC day
+type APIKeyUsage struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	APIKeyID     uint      `json:"apiKeyId" gorm:"not null;uniqueIndex:idx_api_key_usage_day"`
+	TenantID     uint      `json:"tenantId" gorm:"not null;index"`
+	Day          time.Time `json:"day" gorm:"not null;uniqueIndex:idx_api_key_usage_day;index"`
+	Requests     int64     `json:"requests" gorm:"not null;default:0"`
+	Errors       int64     `json:"errors" gorm:"not null;default:0"`
+	ComputeUnits int64     `json:"computeUnits" gorm:"not null;default:0"`
+}
+
+// UsageBucket meters one tenant's calls per UTC day, chain and JSON-RPC method
+type UsageBucket struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	TenantID     uint      `json:"tenantId" gorm:"not null;uniqueIndex:idx_usage_bucket"`
+	Day          time.Time `json:"day" gorm:"not null;uniqueIndex:idx_usage_bucket;index"`
+	ChainName    string    `json:"chainName" gorm:"size:50;not null;uniqueIndex:idx_usage_bucket"`
+	Method       string    `json:"method" gorm:"size:100;not null;uniqueIndex:idx_usage_bucket"`
+	Requests     int64     `json:"requests" gorm:"not null;default:0"`
+	Errors       int64     `json:"errors" gorm:"not null;default:0"`
+	ComputeUnits int64     `json:"computeUnits" gorm:"not null;default:0"`
+}
+
+// Instance is a running proxy replica, kept current by its heartbeats
+type Instance struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:100"`
+	Hostname  string    `json:"hostname" gorm:"size:255"`
+	Version   string    `json:"version" gorm:"size:50"`
+	StartedAt time.Time `json:"startedAt" gorm:"not null"`
+	LastSeen  time.Time `json:"lastSeen" gorm:"not null;index"`
+	// Chains is comma-separated
+	Chains string `json:"chains" gorm:"type:text"`
+	// Stats is types.InstanceStats as JSON
+	Stats string `json:"stats" gorm:"type:text"`
+}
+
+// SchemaMigration records a versioned migration applied to the database
+type SchemaMigration struct {
+	Version   int       `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `json:"name" gorm:"size:200;not null"`
+	AppliedAt time.Time `json:"appliedAt" gorm:"not null"`
+}
+
 // GORM hooks for Chain
 func (c *Chain) BeforeCreate(tx *gorm.DB) error {
 	c.CreatedAt = time.Now()
@@ -167,6 +367,12 @@ func (h *HealthCheck) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// GORM hooks for MaintenanceWindow
+func (m *MaintenanceWindow) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
 // GORM hooks for Setting
 func (s *Setting) BeforeCreate(tx *gorm.DB) error {
 	s.UpdatedAt = time.Now()
@@ -186,6 +392,8 @@ func AutoMigrate(db *gorm.DB) error {
 		&RPCEndpoint{},
 		&HealthCheck{},
 		&Setting{},
+		&MaintenanceWindow{},
+		&MethodUsageRollup{},
 	)
 }
 
@@ -199,11 +407,13 @@ func SeedDefaultData(db *gorm.DB) error {
 		{Key: "proxy_timeout", Value: "10s", Description: "Timeout for proxy requests"},
 		{Key: "max_connections", Value: "1000", Description: "Maximum concurrent connections"},
 		{Key: "server_port", Value: "8080", Description: "Server port number"},
+		{Key: "health_check_retention_days", Value: "30", Description: "Days of health check history to keep (0 keeps everything)"},
+		{Key: "health_check_downsample_after_days", Value: "0", Description: "Collapse health checks older than this many days into hourly aggregates (0 disables)"},
 	}
 
 	for _, setting := range defaultSettings {
 		var existingSetting Setting
-		if err := db.Where("key = ?", setting.Key).First(&existingSetting).Error; err != nil {
+		if err := db.Where(clause.Eq{Column: "key", Value: setting.Key}).First(&existingSetting).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				if err := db.Create(&setting).Error; err != nil {
 					return err
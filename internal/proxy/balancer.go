@@ -0,0 +1,203 @@
+package proxy
+
+import (
+	"math/rand/v2"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// routeTableMaxAge bounds how long a routing table is reused while nothing invalidates it, so
+// maintenance windows and rate-limit cooldowns that open or end on the clock apply promptly
+const routeTableMaxAge = time.Second
+
+// routeTable holds a chain's healthy endpoints in failover order for its lb_strategy. It is
+// built when endpoint health, scores, latency, overrides or the endpoint set change, not per
+// request.
+type routeTable struct {
+	generation uint64
+	strategy   string
+	built      time.Time
+
+	all     routeSet // the endpoints taking proxied requests
+	archive routeSet // the archive-capable endpoints of all, in the same order
+	graphql routeSet // the endpoints of all serving GraphQL, in the same order
+
+	// subscriptions are the endpoints serving subscriptions, over their WebSocket URL
+	subscriptions routeSet
+
+	// ring places clients on endpoints for the sticky strategy; nil for other strategies
+	ring *hashRing
+}
+
+// routeSet is an ordered endpoint list whose first available endpoints are not degraded
+type routeSet struct {
+	endpoints []*types.RPCEndpoint
+	available int
+	// weights are the effective weights of endpoints, for the weighted strategy's first pick
+	weights []float64
+}
+
+// route is the failover order of one request: a route set, with its non-degraded head
+// rotated by start for round robin, or with the endpoint at first tried before the others
+// for the weighted strategy
+type route struct {
+	routeSet
+	start int
+	first int
+}
+
+func (r route) len() int {
+	return len(r.endpoints)
+}
+
+// at returns the endpoint to try on attempt i
+func (r route) at(i int) *types.RPCEndpoint {
+	if r.first > 0 && i <= r.first {
+		if i == 0 {
+			return r.endpoints[r.first]
+		}
+		return r.endpoints[i-1]
+	}
+	if i < r.available {
+		return r.endpoints[(r.start+i)%r.available]
+	}
+	return r.endpoints[i]
+}
+
+// routeTable returns the chain's current routing table, rebuilding it if routing changed
+func (s *Server) routeTable(chainName string) *routeTable {
+	strategy := s.multiChainHealthChecker.ChainConfigValue(chainName, "lb_strategy")
+	// Read before building so a change made during the build triggers another one
+	generation := types.RoutingGeneration()
+
+	if cached, ok := s.routes.Load(chainName); ok {
+		table := cached.(*routeTable)
+		if table.generation == generation && table.strategy == strategy && time.Since(table.built) < routeTableMaxAge {
+			return table
+		}
+	}
+
+	table := buildRouteTable(strategy, s.multiChainHealthChecker.GetHealthyEndpoints(chainName))
+	table.generation = generation
+	if strategy == types.LBStrategySticky {
+		table.ring = newHashRing(s.multiChainHealthChecker.GetAllEndpoints(chainName))
+	}
+	s.routes.Store(chainName, table)
+	return table
+}
+
+// route returns a request's failover order over set. Weighted routing starts each request on a
+// non-degraded endpoint picked at random in proportion to its effective weight, and fails over
+// in weight order; round robin starts each request on the next non-degraded endpoint, and
+// sticky starts a client's requests on its endpoint on the ring.
+func (s *Server) route(chainName string, table *routeTable, set routeSet, client string) route {
+	r := route{routeSet: set}
+	if (table.strategy == "" || table.strategy == types.LBStrategyWeighted) && set.available > 1 {
+		r.first = set.pickWeighted()
+	}
+	if table.strategy == types.LBStrategyRoundRobin && set.available > 1 {
+		counter, _ := s.rrCounters.LoadOrStore(chainName, new(uint64))
+		r.start = int(atomic.AddUint64(counter.(*uint64), 1) % uint64(set.available))
+	}
+	if table.ring != nil && set.available > 1 {
+		r.start = max(table.ring.lookup(client, set), 0)
+	}
+	return r
+}
+
+// rankedEndpoint is an endpoint with the routing inputs read once for sorting
+type rankedEndpoint struct {
+	endpoint *types.RPCEndpoint
+	degraded bool
+	rank     float64 // higher routes first
+	weight   float64
+}
+
+// buildRouteTable orders endpoints for strategy: degraded endpoints last as a last resort, the
+// rest by effective weight (configured weight scaled by health score), or by last health check
+// response time for the latency strategy. Ties keep their configured order. The weighted and
+// sticky strategies fail over in weight order, from the request's first endpoint on.
+func buildRouteTable(strategy string, endpoints []*types.RPCEndpoint) *routeTable {
+	ranked := make([]rankedEndpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		ranked[i] = rankedEndpoint{endpoint: endpoint, degraded: endpoint.IsDegraded(), weight: endpoint.EffectiveWeight()}
+		if strategy == types.LBStrategyLatency {
+			ranked[i].rank = -float64(endpoint.GetResponseTime())
+		} else {
+			ranked[i].rank = ranked[i].weight
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].degraded != ranked[j].degraded {
+			return !ranked[i].degraded
+		}
+		return ranked[i].rank > ranked[j].rank
+	})
+
+	table := &routeTable{strategy: strategy, built: time.Now()}
+	for _, r := range ranked {
+		if r.endpoint.SubscriptionURL() != "" {
+			table.subscriptions.add(r)
+		}
+		if !r.endpoint.ProxiesRequests() {
+			continue
+		}
+		table.all.add(r)
+		if r.endpoint.IsArchive() {
+			table.archive.add(r)
+		}
+		if r.endpoint.GraphQLURL != "" {
+			table.graphql.add(r)
+		}
+	}
+	return table
+}
+
+// pickWeighted returns the index of a non-degraded endpoint picked at random in proportion to
+// its weight, so each takes its share of traffic rather than the heaviest taking it all. When
+// no endpoint has a positive weight the first is picked.
+func (rs routeSet) pickWeighted() int {
+	var total float64
+	for _, weight := range rs.weights[:rs.available] {
+		total += max(weight, 0)
+	}
+	if total <= 0 {
+		return 0
+	}
+	target := rand.Float64() * total
+	for i, weight := range rs.weights[:rs.available] {
+		target -= max(weight, 0)
+		if target < 0 {
+			return i
+		}
+	}
+	return rs.available - 1
+}
+
+// filter returns the endpoints of the set keep accepts, in the same order
+func (rs routeSet) filter(keep func(endpoint *types.RPCEndpoint) bool) routeSet {
+	var filtered routeSet
+	for i, endpoint := range rs.endpoints {
+		if !keep(endpoint) {
+			continue
+		}
+		filtered.endpoints = append(filtered.endpoints, endpoint)
+		filtered.weights = append(filtered.weights, rs.weights[i])
+		if i < rs.available {
+			filtered.available++
+		}
+	}
+	return filtered
+}
+
+func (rs *routeSet) add(r rankedEndpoint) {
+	rs.endpoints = append(rs.endpoints, r.endpoint)
+	rs.weights = append(rs.weights, r.weight)
+	if !r.degraded {
+		rs.available++
+	}
+}
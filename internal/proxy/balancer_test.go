@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+func TestWeightedRouteSpreadsFirstPick(t *testing.T) {
+	endpoints := []*types.RPCEndpoint{
+		{ID: 1, Name: "light", Weight: 1},
+		{ID: 2, Name: "heavy", Weight: 3},
+		{ID: 3, Name: "scored", Weight: 4, Score: 50},
+	}
+	table := buildRouteTable(types.LBStrategyWeighted, endpoints)
+	s := &Server{}
+
+	const requests = 20000
+	first := make(map[string]int)
+	for i := 0; i < requests; i++ {
+		order := s.route("ethereum", table, table.all, "")
+		first[order.at(0).Name]++
+
+		// Failover keeps weight order after the picked endpoint
+		var rest []string
+		for j := 1; j < order.len(); j++ {
+			rest = append(rest, order.at(j).Name)
+		}
+		want := map[string][]string{
+			"heavy":  {"scored", "light"},
+			"scored": {"heavy", "light"},
+			"light":  {"heavy", "scored"},
+		}[order.at(0).Name]
+		if len(rest) != len(want) || rest[0] != want[0] || rest[1] != want[1] {
+			t.Fatalf("failover after %s = %v, want %v", order.at(0).Name, rest, want)
+		}
+	}
+
+	// Effective weights 1, 3 and 4*50% = 2
+	for name, share := range map[string]float64{"light": 1.0 / 6, "heavy": 3.0 / 6, "scored": 2.0 / 6} {
+		got := float64(first[name]) / requests
+		if math.Abs(got-share) > 0.03 {
+			t.Errorf("%s took %.3f of first picks, want about %.3f", name, got, share)
+		}
+	}
+}
+
+func TestWeightedRouteSkipsDegraded(t *testing.T) {
+	until := time.Now().Add(time.Hour)
+	endpoints := []*types.RPCEndpoint{
+		{ID: 1, Name: "degraded", Weight: 100, DegradedUntil: &until},
+		{ID: 2, Name: "primary", Weight: 1},
+		{ID: 3, Name: "zero", Weight: 0},
+	}
+	table := buildRouteTable("", endpoints)
+	s := &Server{}
+
+	for i := 0; i < 1000; i++ {
+		order := s.route("ethereum", table, table.all, "")
+		if order.at(0).Name != "primary" {
+			t.Fatalf("first pick = %s, want primary", order.at(0).Name)
+		}
+		if last := order.at(order.len() - 1); last.Name != "degraded" {
+			t.Fatalf("last resort = %s, want degraded", last.Name)
+		}
+	}
+}
+
+func TestWeightedRouteFilteredSet(t *testing.T) {
+	endpoints := []*types.RPCEndpoint{
+		{ID: 1, Name: "full", Weight: 5},
+		{ID: 2, Name: "archive", Weight: 1},
+	}
+	table := buildRouteTable(types.LBStrategyWeighted, endpoints)
+	set := table.all.filter(func(endpoint *types.RPCEndpoint) bool { return endpoint.Name == "archive" })
+	s := &Server{}
+
+	for i := 0; i < 100; i++ {
+		if order := s.route("ethereum", table, set, ""); order.len() != 1 || order.at(0).Name != "archive" {
+			t.Fatalf("filtered route = %d endpoints starting with %s, want archive alone", order.len(), order.at(0).Name)
+		}
+	}
+}
+
+func TestLatencyRouteKeepsOrder(t *testing.T) {
+	endpoints := []*types.RPCEndpoint{
+		{ID: 1, Name: "slow", Weight: 10, ResponseTime: 300},
+		{ID: 2, Name: "fast", Weight: 1, ResponseTime: 20},
+	}
+	table := buildRouteTable(types.LBStrategyLatency, endpoints)
+	s := &Server{}
+
+	for i := 0; i < 100; i++ {
+		if order := s.route("ethereum", table, table.all, ""); order.at(0).Name != "fast" || order.at(1).Name != "slow" {
+			t.Fatalf("latency route = %s, %s, want fast, slow", order.at(0).Name, order.at(1).Name)
+		}
+	}
+}
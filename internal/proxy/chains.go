@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/types"
+)
+
+// chainsPath lists the enabled chains' metadata for clients configuring their network pickers
+const chainsPath = "/chains"
+
+// publicChain is the metadata of a chain listed at /chains, without its endpoints or configs
+type publicChain struct {
+	Name        string `json:"name"`
+	ChainID     int    `json:"chainId"`
+	DisplayName string `json:"displayName"`
+	Type        string `json:"type"`
+	IsTestnet   bool   `json:"isTestnet"`
+	// RPC is the path the proxy serves the chain's JSON-RPC at, relative to the proxy's URL
+	RPC              string               `json:"rpc"`
+	NativeCurrency   publicNativeCurrency `json:"nativeCurrency"`
+	BlockExplorerURL string               `json:"blockExplorerUrl,omitempty"`
+}
+
+type publicNativeCurrency struct {
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+}
+
+// chainErrorData is the data of the error for a request to a chain that isn't configured or is
+// disabled, so a client that got the chain wrong can see the chains it may use
+type chainErrorData struct {
+	Chain    string `json:"chain"`
+	Exists   bool   `json:"exists"`
+	Disabled bool   `json:"disabled"`
+	// SupportedChains are the paths of the enabled chains
+	SupportedChains []string `json:"supportedChains"`
+	RequestID       string   `json:"requestId,omitempty"`
+}
+
+// chainUnavailableError is the JSON-RPC error for requests to chainName when chain, its
+// configuration, is nil or disabled
+func (s *Server) chainUnavailableError(chainName string, chain *types.Chain) *types.JSONRPCError {
+	data := chainErrorData{Chain: chainName, Exists: chain != nil, Disabled: chain != nil && !chain.IsEnabled, SupportedChains: []string{}}
+	for _, configured := range s.config.GetChains() {
+		if configured.IsEnabled {
+			data.SupportedChains = append(data.SupportedChains, "/rpc/"+configured.Name)
+		}
+	}
+
+	message := fmt.Sprintf("Chain %s not found", chainName)
+	if data.Disabled {
+		message = fmt.Sprintf("Chain %s is disabled", chainName)
+	}
+	return &types.JSONRPCError{Code: -32000, Message: message, Data: data}
+}
+
+// handleChains lists the enabled chains with the metadata a frontend needs to add them as
+// networks. Disabled chains are left out, since their requests are refused.
+func (s *Server) handleChains(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chains := []publicChain{}
+	for _, chain := range s.config.GetChains() {
+		if !chain.IsEnabled {
+			continue
+		}
+		// Chains from the database don't store their precision
+		decimals := chain.NativeCurrencyDecimals
+		if decimals == 0 {
+			decimals = config.DefaultCurrencyDecimals(chain.GetType())
+		}
+		chains = append(chains, publicChain{
+			Name:        chain.Name,
+			ChainID:     chain.ChainID,
+			DisplayName: chain.DisplayName,
+			Type:        chain.GetType(),
+			IsTestnet:   chain.IsTestnet,
+			RPC:         "/rpc/" + chain.Name,
+			NativeCurrency: publicNativeCurrency{
+				Symbol:   chain.NativeCurrencySymbol,
+				Decimals: decimals,
+			},
+			BlockExplorerURL: chain.BlockExplorerURL,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chains": chains,
+		"count":  len(chains),
+	})
+}
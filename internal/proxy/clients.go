@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/types"
+)
+
+// clientAPIKeyHeader carries an optional consumer API key on proxied requests
+const clientAPIKeyHeader = "X-API-Key"
+
+// keyFingerprintLength is how much of an API key's hash identifies it in reports, enough to
+// tell keys apart without revealing them
+const keyFingerprintLength = 12
+
+// clientKey identifies the consumer of a proxied request for analytics, and returns the
+// tenant key it presented, or nil if it sent no key or one no tenant has
+func (s *Server) clientKey(r *http.Request) (analytics.ClientKey, *tenant.Key) {
+	client := analytics.ClientKey{IP: clientIP(r, s.config.Proxy.TrustForwardedFor)}
+
+	key := r.Header.Get(clientAPIKeyHeader)
+	if key == "" {
+		return client, nil
+	}
+	hash := types.HashAPIKey(key)
+	client.Key = hash[:keyFingerprintLength]
+
+	if s.tenants == nil {
+		return client, nil
+	}
+	tenantKey := s.tenants.Lookup(hash)
+	if tenantKey != nil {
+		client.TenantID = tenantKey.Tenant.ID
+		client.KeyID = tenantKey.ID
+	}
+	return client, tenantKey
+}
+
+// clientIP returns the caller's address; with trustForwarded the first X-Forwarded-For
+// entry wins, which is only safe behind a proxy that overwrites the header
+func clientIP(r *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
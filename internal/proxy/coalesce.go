@@ -0,0 +1,276 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/redis"
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// coalescePollInterval is how often a replica waiting on another's call checks for its result
+	coalescePollInterval = 10 * time.Millisecond
+	// coalesceTimeout bounds each Redis command, so an unreachable server only delays a call briefly
+	coalesceTimeout = 250 * time.Millisecond
+	// coalesceErrorLogInterval is how often Redis failures are logged
+	coalesceErrorLogInterval = time.Minute
+)
+
+// coalescer lets identical concurrent calls of cheap, frequently polled methods such as
+// eth_blockNumber share one upstream request. Within a replica, calls arriving while one is in
+// flight wait for its result. With Redis, replicas take a short-lived lock per call: the
+// holder makes the request and publishes the result for CoalesceResultTTL, and the others
+// poll for it, so a thundering herd behind a load balancer costs one upstream call fleet-wide.
+// Failed calls aren't shared; waiters then make their own request.
+type coalescer struct {
+	methods   map[string]bool
+	lockTTL   time.Duration
+	resultTTL time.Duration
+
+	flights map[string]*flight
+	mu      sync.Mutex
+
+	// client is nil without Redis, coalescing within this replica only
+	client  *redis.Client
+	prefix  string
+	replica string
+
+	lastError time.Time
+	errMu     sync.Mutex
+}
+
+// flight is a call in progress on this replica; result is set, nil if it failed, when done closes
+type flight struct {
+	done   chan struct{}
+	result json.RawMessage
+}
+
+func newCoalescer(methods []string, lockTTL, resultTTL time.Duration) *coalescer {
+	c := &coalescer{
+		methods:   make(map[string]bool, len(methods)),
+		lockTTL:   lockTTL,
+		resultTTL: resultTTL,
+		flights:   make(map[string]*flight),
+	}
+	for _, method := range methods {
+		c.methods[method] = true
+	}
+	return c
+}
+
+// SetSharedCoalescing coalesces calls with other replicas through client, under keys starting
+// with prefix; call it before serving traffic
+func (s *Server) SetSharedCoalescing(client *redis.Client, prefix string) {
+	hostname, _ := os.Hostname()
+	s.coalescer.client = client
+	s.coalescer.prefix = prefix
+	s.coalescer.replica = fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
+
+// callKey returns the key identifying a request's call among identical ones, and false for
+// requests that aren't coalesced: batches, notifications and methods not configured
+func (c *coalescer) callKey(chainName string, body []byte, requests []rpcCall) (string, bool) {
+	if len(requests) != 1 || len(requests[0].ID) == 0 || bytes.Equal(requests[0].ID, []byte("null")) ||
+		!c.methods[requests[0].Method] {
+		return "", false
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '{' {
+		return "", false
+	}
+	parsed := parseRPCRequests(body)
+	if len(parsed) != 1 {
+		return "", false
+	}
+	// Params are re-encoded so formatting differences don't split identical calls
+	params, err := json.Marshal(parsed[0].Params)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(chainName + "\n" + parsed[0].Method + "\n" + string(params)))
+	return hex.EncodeToString(sum[:16]), true
+}
+
+// join returns the result of an identical call made on this or another replica, or, when there
+// is none, a finish func through which this request publishes its own result, nil if it
+// fails. With neither, the request goes upstream without sharing its result.
+func (c *coalescer) join(ctx context.Context, key string) (json.RawMessage, func(json.RawMessage)) {
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.result, nil
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	land := func(result json.RawMessage) {
+		c.mu.Lock()
+		delete(c.flights, key)
+		c.mu.Unlock()
+		f.result = result
+		close(f.done)
+	}
+
+	if c.client == nil {
+		return nil, land
+	}
+	result, locked, err := c.waitShared(ctx, key)
+	if err != nil {
+		c.logError(err)
+	}
+	if result != nil {
+		land(result)
+		return result, nil
+	}
+	return nil, func(result json.RawMessage) {
+		if result != nil || locked {
+			c.publish(key, result, locked)
+		}
+		land(result)
+	}
+}
+
+// waitShared returns a result another replica published for the call, waiting for it while
+// another replica holds the call's lock; it reports whether this replica took the lock instead
+func (c *coalescer) waitShared(ctx context.Context, key string) (json.RawMessage, bool, error) {
+	deadline := time.Now().Add(c.lockTTL)
+	for {
+		result, locked, err := c.tryShared(ctx, key)
+		if err != nil || result != nil || locked {
+			return result, locked, err
+		}
+
+		// Past the lock TTL the holder is slow or gone, and this request stops waiting
+		if time.Now().Add(coalescePollInterval).After(deadline) {
+			return nil, false, nil
+		}
+		select {
+		case <-time.After(coalescePollInterval):
+		case <-ctx.Done():
+			return nil, false, nil
+		}
+	}
+}
+
+// tryShared returns the call's published result, or else tries to take its lock
+func (c *coalescer) tryShared(ctx context.Context, key string) (json.RawMessage, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, coalesceTimeout)
+	defer cancel()
+
+	reply, err := c.client.Do(ctx, "GET", c.key("result", key))
+	if err != nil {
+		return nil, false, err
+	}
+	if value, ok := reply.(string); ok {
+		return json.RawMessage(value), false, nil
+	}
+
+	reply, err = c.client.Do(ctx, "SET", c.key("lock", key), c.replica,
+		"NX", "PX", strconv.FormatInt(max(c.lockTTL.Milliseconds(), 1), 10))
+	if err != nil {
+		return nil, false, err
+	}
+	return nil, reply != nil, nil
+}
+
+// publish stores a call's result for the other replicas and releases its lock, so replicas
+// waiting on a failed call stop waiting
+func (c *coalescer) publish(key string, result json.RawMessage, locked bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), coalesceTimeout)
+	defer cancel()
+
+	if result != nil {
+		if _, err := c.client.Do(ctx, "SET", c.key("result", key), string(result),
+			"PX", strconv.FormatInt(max(c.resultTTL.Milliseconds(), 1), 10)); err != nil {
+			c.logError(err)
+			return
+		}
+	}
+	if locked {
+		if _, err := c.client.Do(ctx, "DEL", c.key("lock", key)); err != nil {
+			c.logError(err)
+		}
+	}
+}
+
+// key names a call's entry of the given kind
+func (c *coalescer) key(kind, key string) string {
+	return c.prefix + ":coalesce:" + kind + ":" + key
+}
+
+// logError logs a failed Redis command, at most once per coalesceErrorLogInterval so an
+// outage doesn't flood the log
+func (c *coalescer) logError(err error) {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+
+	if time.Since(c.lastError) < coalesceErrorLogInterval {
+		return
+	}
+	c.lastError = time.Now()
+	log.Printf("Shared request coalescing unavailable, coalescing locally: %v", err)
+}
+
+// captureWriter passes a response through while keeping a copy of it, for the result of a
+// coalesced call
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// result returns the JSON-RPC result of a captured successful response, or nil for failures,
+// JSON-RPC errors and compressed bodies
+func (w *captureWriter) result() json.RawMessage {
+	if w.status != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+		return nil
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &resp); err != nil || len(resp.Result) == 0 ||
+		(len(resp.Error) > 0 && string(resp.Error) != "null") {
+		return nil
+	}
+	return resp.Result
+}
+
+// writeCoalesced answers a call with the result of an identical one, under the call's own id
+func (s *Server) writeCoalesced(w http.ResponseWriter, id, result json.RawMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.JSONRPCResponse{
+		Jsonrpc: "2.0",
+		Result:  result,
+		ID:      id,
+	})
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// debugUpstreamHeader asks for the upstream disclosure headers below; only clients holding
+	// the admin API key, sent as X-Admin-Key, get them
+	debugUpstreamHeader = "X-Debug-Upstream"
+	adminKeyHeader      = "X-Admin-Key"
+
+	// upstreamEndpointHeader names the endpoint that served the request, or the last one tried
+	upstreamEndpointHeader = "X-Upstream-Endpoint"
+	// upstreamAttemptsHeader counts the endpoints tried, failover included
+	upstreamAttemptsHeader = "X-Upstream-Attempts"
+	// upstreamCacheHeader is hit when the result was shared from an identical call, miss otherwise
+	upstreamCacheHeader = "X-Upstream-Cache"
+)
+
+// upstreamDebugHeaders are the disclosure headers, which REST routes copy from their call
+var upstreamDebugHeaders = []string{upstreamEndpointHeader, upstreamAttemptsHeader, upstreamCacheHeader}
+
+// debugUpstream reports whether r asked for the upstream disclosure headers and may have them.
+// With no admin API key configured nobody gets them, even when the admin API runs insecure.
+func (s *Server) debugUpstream(r *http.Request) bool {
+	if enabled, _ := strconv.ParseBool(r.Header.Get(debugUpstreamHeader)); !enabled {
+		return false
+	}
+	apiKey := s.config.Admin.APIKey
+	return apiKey != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(adminKeyHeader)), []byte(apiKey)) == 1
+}
+
+// discloseUpstream sets the disclosure headers for how outcome was served, when r asked for
+// them; call it before the response is written. Endpoints are named, never their URLs, which
+// may hold provider credentials.
+func (s *Server) discloseUpstream(w http.ResponseWriter, r *http.Request, outcome requestOutcome) {
+	if !s.debugUpstream(r) {
+		return
+	}
+	cache := "miss"
+	if outcome.upstream == "coalesced" {
+		cache = "hit"
+	} else if outcome.upstream != "" {
+		w.Header().Set(upstreamEndpointHeader, outcome.upstream)
+	}
+	w.Header().Set(upstreamAttemptsHeader, strconv.Itoa(outcome.attempts))
+	w.Header().Set(upstreamCacheHeader, cache)
+}
+
+// upstreamHeader reports whether a client header, keyed in canonical form as in http.Header, is
+// kept from requests forwarded upstream: the admin API key, the debug flag and the client's
+// tenant key are the proxy's alone
+func upstreamHeader(key string) bool {
+	switch http.CanonicalHeaderKey(key) {
+	case http.CanonicalHeaderKey(adminKeyHeader), http.CanonicalHeaderKey(debugUpstreamHeader), http.CanonicalHeaderKey(clientAPIKeyHeader):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"rpc-proxy/internal/config"
+)
+
+func TestDebugUpstream(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiKey   string
+		insecure bool
+		headers  map[string]string
+		want     bool
+	}{
+		{"matching key", "secret", false, map[string]string{"X-Debug-Upstream": "true", "X-Admin-Key": "secret"}, true},
+		{"wrong key", "secret", false, map[string]string{"X-Debug-Upstream": "true", "X-Admin-Key": "guess"}, false},
+		{"no key sent", "secret", false, map[string]string{"X-Debug-Upstream": "true"}, false},
+		{"not asked", "secret", false, map[string]string{"X-Admin-Key": "secret"}, false},
+		{"no key configured", "", false, map[string]string{"X-Debug-Upstream": "true"}, false},
+		{"no key configured, empty key sent", "", false, map[string]string{"X-Debug-Upstream": "true", "X-Admin-Key": ""}, false},
+		{"no key configured, insecure admin", "", true, map[string]string{"X-Debug-Upstream": "true"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{config: &config.Config{Admin: config.AdminConfig{APIKey: tt.apiKey, Insecure: tt.insecure}}}
+			r := httptest.NewRequest("POST", "/rpc/ethereum", nil)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			if got := s.debugUpstream(r); got != tt.want {
+				t.Errorf("debugUpstream() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
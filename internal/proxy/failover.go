@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxFailoverBackoff caps the doubling wait between failover attempts
+const maxFailoverBackoff = 10 * time.Second
+
+// failoverPolicy is how a chain's requests are forwarded, read from its chain configs on every
+// request so config changes apply without a restart
+type failoverPolicy struct {
+	timeout     time.Duration // per attempt; 0 keeps the proxy-wide timeout
+	maxAttempts int           // endpoints tried per request; 0 tries every healthy endpoint
+	backoff     time.Duration // wait before the second attempt, doubling for each later one
+}
+
+// failoverPolicy reads proxy_timeout, max_failover_attempts and failover_backoff for a chain.
+// Values were validated when stored; anything unparseable falls back to the default.
+func (s *Server) failoverPolicy(chainName string) failoverPolicy {
+	var policy failoverPolicy
+	if value := s.multiChainHealthChecker.ChainConfigValue(chainName, "proxy_timeout"); value != "" {
+		policy.timeout, _ = time.ParseDuration(value)
+	}
+	if value := s.multiChainHealthChecker.ChainConfigValue(chainName, "max_failover_attempts"); value != "" {
+		policy.maxAttempts, _ = strconv.Atoi(value)
+	}
+	if value := s.multiChainHealthChecker.ChainConfigValue(chainName, "failover_backoff"); value != "" {
+		policy.backoff, _ = time.ParseDuration(value)
+	}
+	return policy
+}
+
+// client returns the HTTP client for an attempt, sharing the server's transport
+func (s *Server) clientFor(policy failoverPolicy) *http.Client {
+	client := s.httpClient()
+	if policy.timeout <= 0 {
+		return client
+	}
+
+	withTimeout := *client
+	withTimeout.Timeout = policy.timeout
+	return &withTimeout
+}
+
+// attempts limits the failover order to the policy's maximum number of attempts
+func (p failoverPolicy) attempts(n int) int {
+	if p.maxAttempts > 0 && p.maxAttempts < n {
+		return p.maxAttempts
+	}
+	return n
+}
+
+// wait sleeps before the given attempt (the first is 0, which never waits) and reports false if
+// the client went away in the meantime
+func (p failoverPolicy) wait(ctx context.Context, attempt int) bool {
+	if attempt == 0 || p.backoff <= 0 {
+		return true
+	}
+
+	delay := p.backoff
+	for i := 1; i < attempt && delay < maxFailoverBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxFailoverBackoff)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
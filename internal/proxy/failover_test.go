@@ -0,0 +1,172 @@
+package proxy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/jobs"
+	"rpc-proxy/internal/proxy"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+// requestLogRepo records the logs written and the context of each write
+type requestLogRepo struct {
+	mu       sync.Mutex
+	logs     []*types.RequestLog
+	contexts []context.Context
+}
+
+func (r *requestLogRepo) CreateBatch(ctx context.Context, logs []*types.RequestLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, logs...)
+	r.contexts = append(r.contexts, ctx)
+	return nil
+}
+
+func (r *requestLogRepo) Find(filter repository.RequestLogFilter) ([]*types.RequestLog, error) {
+	return nil, nil
+}
+
+func (r *requestLogRepo) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contexts = append(r.contexts, ctx)
+	return 0, nil
+}
+
+func TestClientDisconnectStopsFailover(t *testing.T) {
+	// Every upstream stalls until the test ends, so whichever the proxy tries first, a
+	// second attempt would show up as a second call
+	var calls atomic.Int32
+	release := make(chan struct{})
+	called := make(chan struct{}, 4)
+	stall := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		called <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	var upstreams []*httptest.Server
+	for i := 0; i < 3; i++ {
+		upstream := httptest.NewServer(stall)
+		upstreams = append(upstreams, upstream)
+	}
+	defer func() {
+		close(release)
+		for _, upstream := range upstreams {
+			upstream.Close()
+		}
+	}()
+
+	var endpoints []*types.RPCEndpoint
+	for i, upstream := range upstreams {
+		endpoints = append(endpoints, &types.RPCEndpoint{
+			ID: i + 1, Name: "upstream-" + string(rune('a'+i)), URL: upstream.URL, Weight: 1, Healthy: true,
+		})
+	}
+	chains := map[string]*health.ChainConfig{
+		"ethereum": {Chain: &types.Chain{ID: 1, ChainID: 1, Name: "ethereum", IsEnabled: true}, Endpoints: endpoints},
+	}
+	checker := health.NewMultiChainChecker(chains, health.HealthCheckConfig{Interval: time.Minute, Timeout: time.Second})
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: 8080, Mode: config.ServerModeStandard},
+		Proxy:  config.ProxyConfig{Timeout: 30 * time.Second},
+	}
+	server := proxy.NewServer(cfg, checker)
+	requestLog := analytics.NewRequestLogger(1, true)
+	server.SetRequestLogger(requestLog)
+
+	front := httptest.NewServer(server.Handler())
+	defer front.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "POST", front.URL+"/rpc/ethereum",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first upstream was never called")
+	}
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("request succeeded after the client cancelled it")
+	}
+
+	// The proxy notices the disconnect when the upstream attempt is cancelled; give it time
+	// to try another endpoint if it were going to
+	deadline := time.Now().Add(2 * time.Second)
+	var logged []*types.RequestLog
+	repo := &requestLogRepo{}
+	job := jobs.NewRequestLogJob(requestLog, repo, time.Minute, time.Hour)
+	for time.Now().Before(deadline) && len(logged) == 0 {
+		time.Sleep(50 * time.Millisecond)
+		job.Run()
+		repo.mu.Lock()
+		logged = append([]*types.RequestLog(nil), repo.logs...)
+		repo.mu.Unlock()
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("upstreams called %d times, want 1: failover continued after the client disconnected", got)
+	}
+	for _, endpoint := range endpoints {
+		if inFlight := endpoint.GetInFlight(); inFlight != 0 {
+			t.Errorf("%s has %d requests in flight, want 0", endpoint.Name, inFlight)
+		}
+		if endpoint.FailCount != 0 || !endpoint.IsAvailable() {
+			t.Errorf("%s was counted as failed by a cancelled request", endpoint.Name)
+		}
+	}
+
+	if len(logged) != 1 {
+		t.Fatalf("logged %d requests, want 1", len(logged))
+	}
+	if logged[0].Success || logged[0].Error != "client disconnected" {
+		t.Errorf("logged request success=%v error=%q, want a failure with \"client disconnected\"", logged[0].Success, logged[0].Error)
+	}
+	// Attempts on a cancelled context fail before reaching an upstream, so they are counted
+	// here rather than by the upstreams
+	if logged[0].Attempts != 1 {
+		t.Errorf("logged %d attempts, want 1: failover continued after the client disconnected", logged[0].Attempts)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.contexts) == 0 {
+		t.Fatal("no database writes were made")
+	}
+	for i, ctx := range repo.contexts {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("database write %d has no deadline", i)
+		}
+	}
+}
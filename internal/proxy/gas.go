@@ -0,0 +1,312 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// gasPathPrefix is where the gas oracle of evm chains is served, as /gas/{chainName}
+	gasPathPrefix = "/gas/"
+
+	// gasOracleMethod names gas oracle requests for analytics and tenant method rules
+	gasOracleMethod = "gas_oracle"
+
+	// gasOracleSources is the number of endpoints sampled for a quote
+	gasOracleSources = 5
+
+	// gasQuoteMaxAge bounds how long a quote is served while the chain's health checks haven't
+	// seen a newer block, which they only notice once per health check interval
+	gasQuoteMaxAge = 12 * time.Second
+)
+
+// gasOracle keeps the last gas quote of each chain
+type gasOracle struct {
+	server *Server
+
+	mu     sync.Mutex
+	chains map[string]*gasChain
+}
+
+// gasChain holds a chain's quote; mu is held while a quote is fetched, so concurrent requests
+// share one round of upstream calls
+type gasChain struct {
+	mu    sync.Mutex
+	quote *gasQuote
+}
+
+// gasQuote is the fee suggestion served at /gas/{chainName}, aggregated over the sampled
+// endpoints. EIP1559 is nil on chains whose blocks have no base fee.
+type gasQuote struct {
+	Chain       string      `json:"chain"`
+	BlockNumber int64       `json:"blockNumber"`
+	Method      string      `json:"method"`
+	Sources     int         `json:"sources"`
+	GasPrice    gasAmount   `json:"gasPrice"`
+	EIP1559     *gasEIP1559 `json:"eip1559,omitempty"`
+
+	fetched time.Time
+}
+
+// gasEIP1559 is the dynamic fee suggestion: maxFeePerGas leaves room for the base fee to double
+type gasEIP1559 struct {
+	BaseFeePerGas        gasAmount `json:"baseFeePerGas"`
+	MaxPriorityFeePerGas gasAmount `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         gasAmount `json:"maxFeePerGas"`
+}
+
+// gasAmount is a fee as hex wei, as JSON-RPC takes it, and in gwei for display
+type gasAmount struct {
+	Wei  string  `json:"wei"`
+	Gwei float64 `json:"gwei"`
+}
+
+// gasSample is what one endpoint reported; priorityFee and baseFee are nil when it didn't
+type gasSample struct {
+	gasPrice    *big.Int
+	priorityFee *big.Int
+	baseFee     *big.Int
+	block       int64
+}
+
+func newGasOracle(s *Server) *gasOracle {
+	return &gasOracle{server: s, chains: make(map[string]*gasChain)}
+}
+
+// handleGas serves the gas oracle: a gas price and EIP-1559 fee suggestion aggregated from
+// several healthy endpoints of an evm chain, so a single provider's stale or skewed price doesn't
+// reach clients. Quotes are cached until the chain moves to a new block.
+func (s *Server) handleGas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeRESTError(w, http.StatusMethodNotAllowed, 0, "Method not allowed")
+		return
+	}
+
+	chainName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, gasPathPrefix), "/")
+	if !isChainPathName(chainName) {
+		writeRESTError(w, http.StatusNotFound, 0, "Invalid path format. Use /gas/{chainName}")
+		return
+	}
+	if !s.multiChainHealthChecker.IsChainSupported(chainName) || s.multiChainHealthChecker.ChainType(chainName) != types.ChainTypeEVM {
+		writeRESTError(w, http.StatusNotFound, 0, fmt.Sprintf("Chain %s not found", chainName))
+		return
+	}
+
+	if !s.acquire() {
+		writeRESTError(w, http.StatusServiceUnavailable, 0, "Too many concurrent requests")
+		return
+	}
+	defer s.release()
+
+	start := time.Now()
+	writeError := func(w http.ResponseWriter, status int, message string) {
+		writeRESTError(w, status, 0, message)
+	}
+	calls := []rpcCall{{Method: gasOracleMethod}}
+	consumer, releaseLimit, ok := s.admit(w, r, "gas oracle", chainName, calls, start, writeError)
+	if !ok {
+		return
+	}
+	defer releaseLimit()
+
+	quote, err := s.gas.quote(chainName)
+	if err != nil {
+		log.Printf("Gas oracle for chain %s failed: %v", chainName, err)
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: err.Error()})
+		writeRESTError(w, http.StatusBadGateway, 0, err.Error())
+		return
+	}
+	s.recordRequest(consumer, chainName, calls, start, requestOutcome{success: true, status: http.StatusOK, attempts: quote.Sources})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
+// quote returns the chain's cached quote while it is current, and otherwise a new one
+func (o *gasOracle) quote(chainName string) (*gasQuote, error) {
+	o.mu.Lock()
+	chain, exists := o.chains[chainName]
+	if !exists {
+		chain = &gasChain{}
+		o.chains[chainName] = chain
+	}
+	o.mu.Unlock()
+
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+
+	if quote := chain.quote; quote != nil && time.Since(quote.fetched) < gasQuoteMaxAge &&
+		quote.BlockNumber >= o.server.multiChainHealthChecker.HighestBlock(chainName) {
+		return quote, nil
+	}
+
+	quote, err := o.fetch(chainName)
+	if err != nil {
+		return nil, err
+	}
+	chain.quote = quote
+	return quote, nil
+}
+
+// fetch samples up to gasOracleSources endpoints, in routing order, and aggregates their prices
+// with the chain's gas_oracle_method
+func (o *gasOracle) fetch(chainName string) (*gasQuote, error) {
+	s := o.server
+	table := s.routeTable(chainName)
+	order := s.route(chainName, table, table.all, "")
+	count := min(order.len(), gasOracleSources)
+	if count == 0 {
+		return nil, fmt.Errorf("no healthy RPC endpoints available for chain %s", chainName)
+	}
+
+	samples := make([]*gasSample, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int, endpoint *types.RPCEndpoint) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), s.httpClient().Timeout)
+			defer cancel()
+
+			sample, err := sampleGas(ctx, s, endpoint)
+			if err != nil {
+				log.Printf("Gas oracle sample from %s failed: %v", endpoint.URL, err)
+				return
+			}
+			samples[i] = sample
+		}(i, order.at(i))
+	}
+	wg.Wait()
+
+	method := s.multiChainHealthChecker.ChainConfigValue(chainName, "gas_oracle_method")
+	if method == "" {
+		method = types.GasOracleMedian
+	}
+
+	quote := &gasQuote{Chain: chainName, Method: method, fetched: time.Now()}
+	var gasPrices, priorityFees, baseFees []*big.Int
+	for _, sample := range samples {
+		if sample == nil {
+			continue
+		}
+		quote.Sources++
+		quote.BlockNumber = max(quote.BlockNumber, sample.block)
+		gasPrices = append(gasPrices, sample.gasPrice)
+		if sample.baseFee != nil {
+			baseFees = append(baseFees, sample.baseFee)
+		}
+		if sample.priorityFee != nil {
+			priorityFees = append(priorityFees, sample.priorityFee)
+		}
+	}
+	if quote.Sources == 0 {
+		return nil, fmt.Errorf("no endpoint of chain %s returned a gas price", chainName)
+	}
+
+	gasPrice := aggregateFees(gasPrices, method)
+	quote.GasPrice = newGasAmount(gasPrice)
+	if len(baseFees) > 0 {
+		baseFee := aggregateFees(baseFees, method)
+		// Without eth_maxPriorityFeePerGas, the tip is what the legacy price pays above the base fee
+		priorityFee := new(big.Int).Sub(gasPrice, baseFee)
+		if len(priorityFees) > 0 {
+			priorityFee = aggregateFees(priorityFees, method)
+		}
+		if priorityFee.Sign() < 0 {
+			priorityFee.SetInt64(0)
+		}
+		maxFee := new(big.Int).Add(new(big.Int).Lsh(baseFee, 1), priorityFee)
+		quote.EIP1559 = &gasEIP1559{
+			BaseFeePerGas:        newGasAmount(baseFee),
+			MaxPriorityFeePerGas: newGasAmount(priorityFee),
+			MaxFeePerGas:         newGasAmount(maxFee),
+		}
+	}
+	return quote, nil
+}
+
+// sampleGas asks endpoint for its gas price, priority fee and latest block. Only the gas price
+// is required: nodes before London have neither eth_maxPriorityFeePerGas nor a base fee.
+func sampleGas(ctx context.Context, s *Server, endpoint *types.RPCEndpoint) (*gasSample, error) {
+	result, err := callEndpoint(ctx, s, endpoint, "eth_gasPrice", []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	sample := &gasSample{}
+	if sample.gasPrice, err = parseWei(result); err != nil {
+		return nil, fmt.Errorf("invalid eth_gasPrice response: %w", err)
+	}
+
+	if result, err := callEndpoint(ctx, s, endpoint, "eth_maxPriorityFeePerGas", []interface{}{}); err == nil {
+		sample.priorityFee, _ = parseWei(result)
+	}
+
+	if result, err := callEndpoint(ctx, s, endpoint, "eth_getBlockByNumber", []interface{}{"latest", false}); err == nil {
+		var block struct {
+			Number        string `json:"number"`
+			BaseFeePerGas string `json:"baseFeePerGas"`
+		}
+		if json.Unmarshal(result, &block) == nil {
+			if number, ok := new(big.Int).SetString(strings.TrimPrefix(block.Number, "0x"), 16); ok && number.IsInt64() {
+				sample.block = number.Int64()
+			}
+			if baseFee, ok := new(big.Int).SetString(strings.TrimPrefix(block.BaseFeePerGas, "0x"), 16); ok {
+				sample.baseFee = baseFee
+			}
+		}
+	}
+	return sample, nil
+}
+
+// parseWei decodes a hex quantity in wei
+func parseWei(result json.RawMessage) (*big.Int, error) {
+	var hex string
+	if err := json.Unmarshal(result, &hex); err != nil {
+		return nil, err
+	}
+	wei, ok := new(big.Int).SetString(strings.TrimPrefix(hex, "0x"), 16)
+	if !ok || wei.Sign() < 0 {
+		return nil, fmt.Errorf("invalid quantity %q", hex)
+	}
+	return wei, nil
+}
+
+// aggregateFees combines the fees reported by the sampled endpoints: their median, or their mean
+// without the highest and lowest fifth for trimmed-mean
+func aggregateFees(fees []*big.Int, method string) *big.Int {
+	sorted := append([]*big.Int(nil), fees...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+
+	if method == types.GasOracleTrimmedMean {
+		trim := len(sorted) / 5
+		kept := sorted[trim : len(sorted)-trim]
+		sum := new(big.Int)
+		for _, fee := range kept {
+			sum.Add(sum, fee)
+		}
+		return sum.Div(sum, big.NewInt(int64(len(kept))))
+	}
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Int).Set(sorted[middle])
+	}
+	sum := new(big.Int).Add(sorted[middle-1], sorted[middle])
+	return sum.Rsh(sum, 1)
+}
+
+func newGasAmount(wei *big.Int) gasAmount {
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return gasAmount{Wei: "0x" + wei.Text(16), Gwei: gwei}
+}
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"rpc-proxy/internal/types"
+)
+
+// graphqlPathPrefix is where the GraphQL API of evm chains is served, as /graphql/{chainName}
+const graphqlPathPrefix = "/graphql/"
+
+// graphqlMethod names GraphQL requests for analytics and tenant method rules
+const graphqlMethod = "graphql"
+
+// handleGraphQL proxies GraphQL queries, such as those of Geth's /graphql, to the healthy
+// endpoints of a chain that have a GraphQL URL, with the same routing, failover and client
+// limits as JSON-RPC requests. Queries are passed to the endpoint unchanged, as a POST body or
+// a GET query.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		writeGraphQLError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	chainName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, graphqlPathPrefix), "/")
+	if !isChainPathName(chainName) {
+		writeGraphQLError(w, http.StatusNotFound, "Invalid path format. Use /graphql/{chainName}")
+		return
+	}
+	if !s.multiChainHealthChecker.IsChainSupported(chainName) || s.multiChainHealthChecker.ChainType(chainName) != types.ChainTypeEVM {
+		writeGraphQLError(w, http.StatusNotFound, fmt.Sprintf("Chain %s not found", chainName))
+		return
+	}
+
+	s.proxyPassthrough(w, r, passthroughRequest{
+		api:        "GraphQL",
+		chainName:  chainName,
+		method:     graphqlMethod,
+		endpoints:  func(table *routeTable) routeSet { return table.graphql },
+		target:     func(endpoint *types.RPCEndpoint) string { return endpoint.GraphQLURL },
+		writeError: writeGraphQLError,
+	})
+}
+
+// writeGraphQLError writes an error in the GraphQL response format
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	graphqlErr := map[string]interface{}{"message": message}
+	if id := responseRequestID(w); id != "" {
+		graphqlErr["extensions"] = map[string]string{"requestId": id}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{graphqlErr},
+	})
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"rpc-proxy/internal/app"
+)
+
+// serviceIndex describes the proxy at /: its version, the chains it serves and its routes
+type serviceIndex struct {
+	Service string       `json:"service"`
+	Version string       `json:"version"`
+	Chains  []indexChain `json:"chains"`
+	// Routes maps the public route paths to what they serve
+	Routes map[string]string `json:"routes"`
+	Docs   map[string]string `json:"docs"`
+}
+
+type indexChain struct {
+	Name    string `json:"name"`
+	ChainID int    `json:"chainId"`
+	RPC     string `json:"rpc"`
+}
+
+// indexRoutes are the public routes listed at /
+var indexRoutes = map[string]string{
+	"/rpc/{chain}":               "JSON-RPC over HTTP or WebSocket; {chain} is a chain name or chain ID",
+	"/rpc/{chain}/archive":       "JSON-RPC served by archive endpoints only",
+	"/rpc":                       "JSON-RPC for the chain of the X-Chain-Id or X-Chain-Name header, ethereum without one",
+	"/chains":                    "Metadata of the enabled chains",
+	"/version":                   "Version, commit and build date of this build",
+	"/health":                    "Health of every chain",
+	"/health/{chain}":            "Health of one chain",
+	"/gas/{chain}":               "Gas price oracle of evm chains",
+	"/sse/{chain}/newHeads":      "New heads as Server-Sent Events",
+	"/graphql/{chain}":           "GraphQL of evm chains",
+	"/beacon/{chain}/eth/...":    "Beacon API of beacon chains",
+	"/rpc/{chain}/block/{block}": "Read-only REST routes over JSON-RPC, also /tx/{hash} and /balance/{address}",
+}
+
+// handleRoot answers paths no other route serves: GET / with the service index and other paths
+// with 404, so stray requests such as favicons and scanners don't reach upstreams. With
+// PROXY_LEGACY_ROOT_RPC they are proxied like requests to /rpc instead, as before the index.
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if s.config.Proxy.LegacyRootRPC {
+		s.handleLegacyRPC(w, r)
+		return
+	}
+	if r.URL.Path != "/" {
+		writeRESTError(w, http.StatusNotFound, 0, "Not found. GET / lists the routes served")
+		return
+	}
+	if r.Method != "GET" {
+		writeRESTError(w, http.StatusMethodNotAllowed, 0, "Method not allowed. Send JSON-RPC requests to /rpc/{chain}")
+		return
+	}
+
+	index := serviceIndex{
+		Service: "rpc-proxy",
+		Version: app.Build().Version,
+		Chains:  []indexChain{},
+		Routes:  indexRoutes,
+		Docs: map[string]string{
+			"openapi": "/admin/openapi.json",
+			"docs":    "/admin/docs",
+		},
+	}
+	for _, chain := range s.config.GetChains() {
+		if chain.IsEnabled {
+			index.Chains = append(index.Chains, indexChain{Name: chain.Name, ChainID: chain.ChainID, RPC: "/rpc/" + chain.Name})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(index)
+}
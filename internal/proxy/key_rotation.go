@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+// keyRotationPath is where tenants rotate the API key they present, without the admin API
+const keyRotationPath = "/keys/rotate"
+
+// rotatedAPIKey is the response to a key rotation; Key is the only copy of the new key
+type rotatedAPIKey struct {
+	APIKey   *types.APIKey `json:"apiKey"`
+	Key      string        `json:"key"`
+	Previous *types.APIKey `json:"previous"`
+}
+
+// handleKeyRotation handles POST /keys/rotate: the key in the X-API-Key header gets a
+// replacement and keeps working for the grace window, TENANTS_KEY_ROTATION_GRACE unless the
+// body asks for less with {"grace": "1h"}. A key can be rotated once; its replacement is
+// rotated next.
+func (s *Server) handleKeyRotation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, key, ok := s.authenticateTenant(w, r)
+	if !ok {
+		return
+	}
+	if !key.ExpiresAt.IsZero() {
+		http.Error(w, "API key has already been rotated; rotate its replacement instead", http.StatusConflict)
+		return
+	}
+
+	maxGrace := s.config.Tenants.KeyRotationGrace
+	grace := maxGrace
+	var request struct {
+		Grace string `json:"grace"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if request.Grace != "" {
+		d, err := time.ParseDuration(request.Grace)
+		if err != nil || d < 0 || d > maxGrace {
+			http.Error(w, fmt.Sprintf("grace must be a duration from 0s to %s", maxGrace), http.StatusBadRequest)
+			return
+		}
+		grace = d
+	}
+
+	replacement, secret, previous, err := s.tenants.Rotate(key, grace)
+	if errors.Is(err, repository.ErrKeyNotRotatable) {
+		// Another rotation of the key won meanwhile, or it was revoked
+		http.Error(w, fmt.Sprintf("Failed to rotate API key: %v", err), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to rotate API key: %v", err), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Tenant %s rotated API key %s... (ID %d) to %s... (ID %d), valid until %s",
+		key.Tenant.Name, previous.Prefix, previous.ID, replacement.Prefix, replacement.ID, previous.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rotatedAPIKey{APIKey: replacement, Key: secret, Previous: previous})
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/ratelimit"
+	"rpc-proxy/internal/redis"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/types"
+)
+
+// limitErrorCode is the JSON-RPC error code of requests refused by a rate, concurrency or
+// quota limit ("limit exceeded" in EIP-1474)
+const limitErrorCode = -32005
+
+// SetClientLimits changes the limits of each client address, which are also the defaults of
+// tenants without their own or plan limits; they apply from the next request
+func (s *Server) SetClientLimits(limits ratelimit.Limits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clientLimits = limits
+}
+
+// SetSharedLimits keeps the rate limit buckets in Redis through client, under keys starting
+// with prefix; call it before serving traffic
+func (s *Server) SetSharedLimits(client *redis.Client, prefix string) {
+	s.limiter.SetShared(ratelimit.NewSharedBuckets(client, prefix))
+}
+
+// limitsFor returns the limiter key and limits of a request. Requests with a tenant key share
+// their tenant's limits, resolved from the tenant, then its plan, then the client limits;
+// other requests are limited per address.
+func (s *Server) limitsFor(consumer analytics.ClientKey, key *tenant.Key) (string, ratelimit.Limits) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if key == nil {
+		return "ip:" + consumer.IP, s.clientLimits
+	}
+	t := key.Tenant
+	limits := ratelimit.Limits{Rate: t.RateLimit, Burst: t.RateBurst, MaxConcurrency: t.MaxConcurrency}
+	return fmt.Sprintf("tenant:%d", t.ID), limits.Overlay(planLimits(key.Plan)).Overlay(s.clientLimits)
+}
+
+// planLimits returns the limits of plan, which may be nil
+func planLimits(plan *types.Plan) ratelimit.Limits {
+	if plan == nil {
+		return ratelimit.Limits{}
+	}
+	return ratelimit.Limits{Rate: plan.RateLimit, Burst: plan.RateBurst, MaxConcurrency: plan.MaxConcurrency}
+}
+
+// limitRefusal returns the refusal kind and message for a limiter reason
+func limitRefusal(reason string, limits ratelimit.Limits) (kind, message string) {
+	if reason == ratelimit.ReasonConcurrency {
+		return analytics.RefusalConcurrencyLimited,
+			fmt.Sprintf("Concurrency limit of %d requests exceeded", limits.MaxConcurrency)
+	}
+	return analytics.RefusalRateLimited, fmt.Sprintf("Rate limit of %g calls per second exceeded", limits.Rate)
+}
+
+// quotaMessage is the error message of requests refused once a plan's monthly quota is used
+func quotaMessage(quota int64) string {
+	return fmt.Sprintf("Monthly quota of %d compute units exhausted", quota)
+}
+
+// setRetryAfter tells the client how many whole seconds to wait before retrying
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		return
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/config"
+)
+
+// HTTP server tuning for the performance listener mode
+const (
+	// performanceReadHeaderTimeout drops connections that stall before sending their headers
+	performanceReadHeaderTimeout = 5 * time.Second
+	// performanceIdleTimeout closes idle keep-alive connections so a sidecar's clients can't
+	// accumulate them forever
+	performanceIdleTimeout = 120 * time.Second
+	// performanceMaxHeaderBytes is ample for JSON-RPC clients and bounds per-connection memory
+	performanceMaxHeaderBytes = 64 << 10
+)
+
+// maxPreallocatedBody caps the buffer allocated up front from a request's Content-Length;
+// larger bodies are read incrementally
+const maxPreallocatedBody = 8 << 20
+
+// NewHTTPServer returns the HTTP server for the configured listener mode. The standard mode
+// keeps net/http's defaults, the performance mode bounds header reads, idle connections and
+// header sizes.
+func NewHTTPServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:    fmt.Sprintf("0.0.0.0:%d", cfg.Port),
+		Handler: handler,
+	}
+	if cfg.Mode == config.ServerModePerformance {
+		server.ReadHeaderTimeout = performanceReadHeaderTimeout
+		server.IdleTimeout = performanceIdleTimeout
+		server.MaxHeaderBytes = performanceMaxHeaderBytes
+	}
+	return server
+}
+
+// performanceHandler serves the same routes as the standard handler, matching RPC paths
+// without a route mux or regular expression
+func (s *Server) performanceHandler() http.Handler {
+	// Health routes are rare enough to keep on a mux
+	healthMux := http.NewServeMux()
+	healthMux.HandleFunc("/health", s.handleMultiChainHealth)
+	healthMux.HandleFunc("/health/", s.handleChainHealth)
+
+	return s.corsMiddleware(s.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.HasPrefix(path, "/rpc/") {
+			s.handleMultiChainRPC(w, r)
+			return
+		}
+		if strings.HasPrefix(path, ssePathPrefix) {
+			s.handleSSE(w, r)
+			return
+		}
+		if strings.HasPrefix(path, beaconPathPrefix) {
+			s.handleBeacon(w, r)
+			return
+		}
+		if strings.HasPrefix(path, graphqlPathPrefix) {
+			s.handleGraphQL(w, r)
+			return
+		}
+		if strings.HasPrefix(path, gasPathPrefix) {
+			s.handleGas(w, r)
+			return
+		}
+		if path == "/health" || strings.HasPrefix(path, "/health/") {
+			healthMux.ServeHTTP(w, r)
+			return
+		}
+		if path == chainsPath {
+			s.handleChains(w, r)
+			return
+		}
+		if path == versionPath {
+			s.handleVersion(w, r)
+			return
+		}
+		if path == keyRotationPath {
+			s.handleKeyRotation(w, r)
+			return
+		}
+		if handler, ok := s.tenantRoutes[path]; ok {
+			handler(w, r)
+			return
+		}
+		if path == "/rpc" {
+			s.handleLegacyRPC(w, r)
+			return
+		}
+		s.handleRoot(w, r)
+	})))
+}
+
+// isChainPathName matches the chain names, and chain IDs, accepted in /rpc/{chain}
+func isChainPathName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// readBody reads a request body into a buffer sized from its Content-Length, avoiding the
+// repeated growth and copying of io.ReadAll for large bodies
+func readBody(r *http.Request) ([]byte, error) {
+	if r.ContentLength <= 0 || r.ContentLength > maxPreallocatedBody {
+		return io.ReadAll(r.Body)
+	}
+
+	body := make([]byte, r.ContentLength)
+	if _, err := io.ReadFull(r.Body, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,201 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/types"
+)
+
+// passthroughRequest is a request of an API other than JSON-RPC that is proxied to a chain's
+// endpoints as is, such as the Beacon API or GraphQL
+type passthroughRequest struct {
+	// api names the API in logs and errors, e.g. GraphQL
+	api       string
+	chainName string
+	// method names the request for analytics and tenant method rules
+	method string
+	// endpoints picks the endpoints serving the API from the chain's routing table
+	endpoints func(table *routeTable) routeSet
+	// target is the URL the request is sent to on an endpoint, without the client's query
+	target func(endpoint *types.RPCEndpoint) string
+	// writeError writes an error in the API's own format
+	writeError func(w http.ResponseWriter, status int, message string)
+}
+
+// proxyPassthrough proxies a request of an API other than JSON-RPC with the same client
+// limits, tenant policies, routing and failover as JSON-RPC requests. Transport errors and rate
+// limited endpoints fail over to the next endpoint; other responses are the client's.
+func (s *Server) proxyPassthrough(w http.ResponseWriter, r *http.Request, req passthroughRequest) {
+	if !s.acquire() {
+		req.writeError(w, http.StatusServiceUnavailable, "Too many concurrent requests")
+		return
+	}
+	defer s.release()
+
+	start := time.Now()
+	body, err := readBody(r)
+	if err != nil {
+		req.writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	chainName := req.chainName
+	calls := []rpcCall{{Method: req.method}}
+	consumer, releaseLimit, ok := s.admit(w, r, req.api, chainName, calls, start, req.writeError)
+	if !ok {
+		return
+	}
+	defer releaseLimit()
+
+	table := s.routeTable(chainName)
+	order := s.route(chainName, table, req.endpoints(table), stickyClient(consumer))
+	policy := s.failoverPolicy(chainName)
+	attempts := policy.attempts(order.len())
+	client := s.clientFor(policy)
+	lastErr := fmt.Errorf("no healthy %s endpoints available for chain %s", req.api, chainName)
+	var outcome requestOutcome
+
+	for i := 0; i < attempts && r.Context().Err() == nil; i++ {
+		endpoint := order.at(i)
+		if !policy.wait(r.Context(), i) {
+			break
+		}
+		outcome.upstream, outcome.status, outcome.attempts = endpoint.Name, 0, i+1
+
+		endpoint.BeginRequest()
+		attemptStart := time.Now()
+		resp, err := s.forwardPassthrough(r.Context(), client, endpoint, r, req.target(endpoint), body)
+		if err != nil && r.Context().Err() != nil {
+			endpoint.AbortRequest()
+			break
+		}
+		if err != nil {
+			endpoint.EndRequest(false, int64(len(body)), 0)
+			s.recordAttempt(chainName, endpoint, attemptStart, false, int64(len(body)), 0)
+			log.Printf("%s request %s to %s failed (attempt %d/%d): %v", req.api, requestID(r), endpoint.URL, i+1, attempts, err)
+			lastErr = err
+			continue
+		}
+
+		outcome.status = resp.StatusCode
+		if resp.StatusCode == http.StatusTooManyRequests {
+			cooldown := s.rateLimitCooldown(resp)
+			resp.Body.Close()
+			endpoint.EndRequest(false, int64(len(body)), 0)
+			s.recordAttempt(chainName, endpoint, attemptStart, false, int64(len(body)), 0)
+			if !endpoint.IsDegraded() {
+				s.multiChainHealthChecker.ShareCooldown(chainName, endpoint, cooldown)
+			}
+			endpoint.MarkDegraded(cooldown)
+			lastErr = fmt.Errorf("upstream %s rate limited (HTTP 429)", endpoint.Name)
+			continue
+		}
+
+		s.discloseUpstream(w, r, outcome)
+		received := s.copyResponse(w, resp)
+		resp.Body.Close()
+		outcome.success = resp.StatusCode < http.StatusInternalServerError
+		endpoint.EndRequest(outcome.success, int64(len(body)), received)
+		s.recordAttempt(chainName, endpoint, attemptStart, outcome.success, int64(len(body)), received)
+		s.recordRequest(consumer, chainName, calls, start, outcome)
+		if !s.performance {
+			log.Printf("%s request %s forwarded to %s (chain: %s) completed in %v", req.api, r.URL.Path, endpoint.URL, chainName, time.Since(start))
+		}
+		return
+	}
+
+	if err := r.Context().Err(); err != nil {
+		outcome.err = "client disconnected"
+		s.recordRequest(consumer, chainName, calls, start, outcome)
+		return
+	}
+
+	log.Printf("%s request %s for chain %s failed: %v", req.api, requestID(r), chainName, lastErr)
+	outcome.err = lastErr.Error()
+	s.recordRequest(consumer, chainName, calls, start, outcome)
+	s.discloseUpstream(w, r, outcome)
+	req.writeError(w, http.StatusBadGateway, fmt.Sprintf("All %s endpoints failed: %v", req.api, lastErr))
+}
+
+// admit applies the tenant policies, quota, client limits and chain state of JSON-RPC requests
+// to a request of another API. A refused request is answered with writeError and recorded, and
+// ok is false; otherwise release frees the client's limit once the request is served.
+func (s *Server) admit(w http.ResponseWriter, r *http.Request, api, chainName string, calls []rpcCall, start time.Time, writeError func(w http.ResponseWriter, status int, message string)) (consumer analytics.ClientKey, release func(), ok bool) {
+	consumer, tenantKey := s.clientKey(r)
+	if refusal := tenantPolicy(tenantKey, s.requestKeyUse(r, consumer, tenantKey), chainName, calls); refusal != nil {
+		log.Printf("Rejecting %s request for chain %s: %s", api, chainName, refusal.message)
+		s.refusals.Record(consumer.TenantID, refusal.kind, refusal.detail)
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: refusal.message, refused: true})
+		writeError(w, refusal.status(), refusal.message)
+		return consumer, nil, false
+	}
+	if tenantKey != nil && tenantKey.QuotaExhausted() {
+		message := quotaMessage(tenantKey.Plan.MonthlyQuota)
+		s.refusals.Record(consumer.TenantID, analytics.RefusalQuotaExceeded, "")
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: message, refused: true})
+		writeError(w, http.StatusTooManyRequests, message)
+		return consumer, nil, false
+	}
+	limiterKey, limits := s.limitsFor(consumer, tenantKey)
+	releaseLimit, reason, retryAfter := s.limiter.Acquire(limiterKey, limits, 1)
+	if releaseLimit == nil {
+		kind, message := limitRefusal(reason, limits)
+		s.refusals.Record(consumer.TenantID, kind, "")
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: message, refused: true})
+		setRetryAfter(w, retryAfter)
+		writeError(w, http.StatusTooManyRequests, message)
+		return consumer, nil, false
+	}
+
+	if chain := s.config.GetChainByName(chainName); chain != nil && !chain.IsEnabled {
+		releaseLimit()
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: "chain disabled"})
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("Chain %s is disabled", chainName))
+		return consumer, nil, false
+	}
+	return consumer, releaseLimit, true
+}
+
+// forwardPassthrough sends a passthrough request to target on endpoint, keeping the client's
+// method, query and headers, so SSZ and other non-JSON requests and responses pass through too.
+// The client's proxy API key stays with the proxy.
+func (s *Server) forwardPassthrough(ctx context.Context, client *http.Client, endpoint *types.RPCEndpoint, r *http.Request, target string, body []byte) (*http.Response, error) {
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	var reqBody io.Reader
+	if len(body) > 0 {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, target, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range r.Header {
+		if key == "Host" || key == "Content-Length" || !upstreamHeader(key) {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if auth := endpoint.Authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// peerHopHeader marks a request forwarded by another proxy, naming that proxy's host. Such
+// requests are never forwarded to a peer again, so proxies listing each other can't bounce a
+// request between them.
+const peerHopHeader = "X-RPC-Proxy-Forwarded-By"
+
+// fromPeer reports whether r was forwarded by another proxy
+func fromPeer(r *http.Request) bool {
+	return r.Header.Get(peerHopHeader) != ""
+}
+
+// forwardToPeer relays a request for a chain without healthy endpoints to the configured peer
+// proxies in order, and reports whether a peer answered or the client went away. Tenant policy
+// and limits were applied here, so the client's API key is replaced by the peer key.
+func (s *Server) forwardToPeer(w http.ResponseWriter, r *http.Request, chainName string, body []byte, ip string) (requestOutcome, bool) {
+	var outcome requestOutcome
+	if len(s.config.Proxy.PeerURLs) == 0 || fromPeer(r) {
+		return outcome, false
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "rpc-proxy"
+	}
+	client := s.httpClient()
+
+	for i, peerURL := range s.config.Proxy.PeerURLs {
+		outcome.upstream, outcome.status, outcome.attempts = "peer "+peerHost(peerURL), 0, i+1
+
+		resp, err := s.forwardPeerRequest(r.Context(), client, peerURL, chainName, body, r.Header, ip, hostname)
+		if err != nil {
+			if r.Context().Err() != nil {
+				outcome.err = "client disconnected"
+				return outcome, true
+			}
+			log.Printf("Request for chain %s to peer %s failed: %v", chainName, peerHost(peerURL), err)
+			outcome.err = err.Error()
+			continue
+		}
+
+		outcome.status = resp.StatusCode
+		// A peer without healthy endpoints answers 503, see peerUnavailableStatus
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			resp.Body.Close()
+			log.Printf("Peer %s could not serve chain %s: HTTP %d", peerHost(peerURL), chainName, resp.StatusCode)
+			outcome.err = fmt.Sprintf("peer %s returned HTTP %d", peerHost(peerURL), resp.StatusCode)
+			continue
+		}
+
+		// The peer set its own CORS headers, which this proxy's middleware already wrote
+		for key := range resp.Header {
+			if strings.HasPrefix(key, "Access-Control-") {
+				resp.Header.Del(key)
+			}
+		}
+		s.copyResponse(w, resp)
+		resp.Body.Close()
+		outcome.success, outcome.err = true, ""
+		log.Printf("No healthy endpoints for chain %s, request served by peer %s", chainName, peerHost(peerURL))
+		return outcome, true
+	}
+	return outcome, false
+}
+
+// forwardPeerRequest sends body to a peer's /rpc/{chainName}
+func (s *Server) forwardPeerRequest(ctx context.Context, client *http.Client, peerURL, chainName string, body []byte, headers http.Header, ip, hostname string) (*http.Response, error) {
+	target := strings.TrimRight(peerURL, "/") + "/rpc/" + chainName
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range headers {
+		if key == "Host" || key == "Content-Length" || key == http.CanonicalHeaderKey(clientAPIKeyHeader) {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.Proxy.PeerAPIKey != "" {
+		req.Header.Set(clientAPIKeyHeader, s.config.Proxy.PeerAPIKey)
+	}
+	// The peer sees this proxy's address; ip lets one trusting X-Forwarded-For see the client's
+	req.Header.Set("X-Forwarded-For", ip)
+	req.Header.Set(peerHopHeader, hostname)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// peerUnavailableStatus is the HTTP status of a "no healthy endpoints" error: 503 for requests
+// from a peer, so it moves on to its next peer, and 200 with the JSON-RPC error otherwise
+func peerUnavailableStatus(r *http.Request) int {
+	if fromPeer(r) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// peerHost returns the host of a peer URL, which unlike the URL itself is safe to log
+func peerHost(peerURL string) string {
+	u, err := url.Parse(peerURL)
+	if err != nil {
+		return peerURL
+	}
+	return u.Host
+}
@@ -0,0 +1,97 @@
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/proxy"
+	"rpc-proxy/internal/transport"
+	"rpc-proxy/internal/types"
+)
+
+// TestReplayFixtures serves requests from the fixtures in testdata/fixtures, recorded from a
+// node at replay-node.test; the batch fixture answers in reverse order, as upstreams may
+func TestReplayFixtures(t *testing.T) {
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		t.Errorf("replay dialed %s", addr)
+		return nil, errors.New("dialing is not allowed in replay")
+	}
+	replay := transport.New(transport.Config{FixtureMode: transport.FixtureReplay, FixtureDir: "testdata/fixtures"}, dial)
+
+	endpoint := &types.RPCEndpoint{ID: 1, Name: "replay-node", URL: "http://replay-node.test/", Weight: 1, Healthy: true}
+	chains := map[string]*health.ChainConfig{
+		"ethereum": {Chain: &types.Chain{ID: 1, ChainID: 1, Name: "ethereum", IsEnabled: true}, Endpoints: []*types.RPCEndpoint{endpoint}},
+	}
+	checker := health.NewMultiChainChecker(chains, health.HealthCheckConfig{Interval: time.Minute, Timeout: time.Second})
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: 8080, Mode: config.ServerModeStandard},
+		Proxy:  config.ProxyConfig{Timeout: 30 * time.Second},
+	}
+	server := proxy.NewServer(cfg, checker)
+	server.SetTransport(replay)
+
+	front := httptest.NewServer(server.Handler())
+	defer front.Close()
+
+	call := func(body string) (int, []byte) {
+		resp, err := http.Post(front.URL+"/rpc/ethereum", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, data
+	}
+
+	t.Run("single call with another id", func(t *testing.T) {
+		status, body := call(`{"jsonrpc":"2.0","id":42,"method":"eth_blockNumber","params":[]}`)
+		var reply struct {
+			ID     json.RawMessage `json:"id"`
+			Result string          `json:"result"`
+		}
+		if err := json.Unmarshal(body, &reply); err != nil {
+			t.Fatalf("invalid reply %s: %v", body, err)
+		}
+		if status != http.StatusOK || string(reply.ID) != "42" || reply.Result != "0x1312d00" {
+			t.Errorf("reply = %d %s, want 200 with id 42 and the recorded block", status, body)
+		}
+	})
+
+	t.Run("batch with other ids", func(t *testing.T) {
+		status, body := call(`[{"jsonrpc":"2.0","id":"a","method":"eth_chainId","params":[]},{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber","params":[]}]`)
+		var replies []struct {
+			ID     json.RawMessage `json:"id"`
+			Result string          `json:"result"`
+		}
+		if err := json.Unmarshal(body, &replies); err != nil {
+			t.Fatalf("invalid reply %s: %v", body, err)
+		}
+		results := make(map[string]string)
+		for _, reply := range replies {
+			results[string(reply.ID)] = reply.Result
+		}
+		if status != http.StatusOK || len(replies) != 2 || results[`"a"`] != "0x1" || results["7"] != "0x1312d00" {
+			t.Errorf("reply = %d %s, want 200 with chain id for \"a\" and the block for 7", status, body)
+		}
+	})
+
+	t.Run("unrecorded call", func(t *testing.T) {
+		status, body := call(`{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice","params":[]}`)
+		if status == http.StatusOK && !strings.Contains(string(body), `"error"`) {
+			t.Errorf("reply = %d %s, want a failure for a call with no fixture", status, body)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	// requestIDHeader identifies a request to the client, in the proxy's logs and to the upstream
+	// it is forwarded to. A client's own ID is kept; other requests get a generated one.
+	requestIDHeader = "X-Request-Id"
+
+	// traceparentHeader carries a W3C trace context to upstreams
+	traceparentHeader = "traceparent"
+
+	// maxRequestIDLength bounds the client request IDs kept; longer ones are replaced
+	maxRequestIDLength = 128
+)
+
+// requestIDMiddleware gives every request an X-Request-Id, forwarded upstream with the client's
+// other headers, returned in the response and quoted in the proxy's errors, so a failing request
+// can be followed from the client through the proxy's logs to the provider
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.assignRequestID(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// assignRequestID sets the request ID of r on r and on the response. A client traceparent that
+// isn't valid is dropped, and with PROXY_GENERATE_TRACEPARENT a missing one is started.
+func (s *Server) assignRequestID(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(requestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+		r.Header.Set(requestIDHeader, id)
+	}
+	w.Header().Set(requestIDHeader, id)
+
+	if traceparent := r.Header.Get(traceparentHeader); traceparent != "" && !validTraceparent(traceparent) {
+		r.Header.Del(traceparentHeader)
+		r.Header.Del("tracestate")
+	}
+	if s.config.Proxy.GenerateTraceparent && r.Header.Get(traceparentHeader) == "" {
+		r.Header.Set(traceparentHeader, newTraceparent(id))
+	}
+}
+
+// requestID returns the ID assigned to r
+func requestID(r *http.Request) string {
+	return r.Header.Get(requestIDHeader)
+}
+
+// responseRequestID returns the ID of the request w answers, for error writers that only get
+// the response
+func responseRequestID(w http.ResponseWriter) string {
+	return w.Header().Get(requestIDHeader)
+}
+
+// validRequestID accepts the IDs clients commonly send, such as UUIDs and ULIDs, and nothing
+// that could break a log line
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// validTraceparent checks a traceparent header, version-traceid-parentid-flags, as the W3C
+// trace context specifies; later versions may append fields
+func validTraceparent(value string) bool {
+	if len(value) < 55 || (value[2] != '-' || value[35] != '-' || value[52] != '-') {
+		return false
+	}
+	version, traceID, parentID, flags := value[0:2], value[3:35], value[36:52], value[53:55]
+	if !isLowerHex(version) || version == "ff" || !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) {
+		return false
+	}
+	if version == "00" && len(value) != 55 {
+		return false
+	}
+	return strings.Trim(traceID, "0") != "" && strings.Trim(parentID, "0") != ""
+}
+
+// newTraceparent starts a trace for a request. A generated request ID doubles as the trace ID,
+// so the two can be matched in an upstream's traces.
+func newTraceparent(requestID string) string {
+	traceID := requestID
+	if len(traceID) != 32 || !isLowerHex(traceID) || strings.Trim(traceID, "0") == "" {
+		var id [16]byte
+		rand.Read(id[:])
+		traceID = hex.EncodeToString(id[:])
+	}
+	var parentID [8]byte
+	rand.Read(parentID[:])
+	// Not sampled: the proxy records no spans of its own
+	return "00-" + traceID + "-" + hex.EncodeToString(parentID[:]) + "-00"
+}
+
+func isLowerHex(value string) bool {
+	for _, c := range value {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// requestErrorData is the data of the proxy's JSON-RPC errors that have no data of their own, or
+// only a detail message
+type requestErrorData struct {
+	RequestID string `json:"requestId"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// withRequestID adds the request ID to the data of a JSON-RPC error
+func withRequestID(data interface{}, id string) interface{} {
+	if id == "" {
+		return data
+	}
+	switch data := data.(type) {
+	case nil:
+		return requestErrorData{RequestID: id}
+	case string:
+		return requestErrorData{RequestID: id, Detail: data}
+	case chainErrorData:
+		data.RequestID = id
+		return data
+	}
+	return data
+}
@@ -0,0 +1,236 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rpc-proxy/internal/types"
+)
+
+var (
+	// errUnknownRESTRoute is returned for paths below /rpc/{chainName}/ that aren't REST routes
+	errUnknownRESTRoute = errors.New("unknown route")
+
+	hashPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+	addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+// restCall is the JSON-RPC call a REST route stands for
+type restCall struct {
+	method string
+	params []interface{}
+	// notFound describes a null result, which is answered with 404
+	notFound string
+}
+
+// translateREST maps a read-only REST route below /rpc/{chainName}/ to its JSON-RPC call:
+//
+//	block/{latest|number|hash}[?full=true]  eth_getBlockByNumber or eth_getBlockByHash
+//	tx/{hash}                               eth_getTransactionByHash
+//	tx/{hash}/receipt                       eth_getTransactionReceipt
+//	balance/{address}[?block=...]           eth_getBalance
+func translateREST(route string, query url.Values) (*restCall, error) {
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	switch {
+	case len(segments) == 2 && segments[0] == "block":
+		full := query.Get("full") == "true"
+		if hashPattern.MatchString(segments[1]) {
+			return &restCall{method: "eth_getBlockByHash", params: []interface{}{segments[1], full}, notFound: "block not found"}, nil
+		}
+		tag, err := restBlockTag(segments[1])
+		if err != nil {
+			return nil, err
+		}
+		return &restCall{method: "eth_getBlockByNumber", params: []interface{}{tag, full}, notFound: "block not found"}, nil
+
+	case (len(segments) == 2 || len(segments) == 3 && segments[2] == "receipt") && segments[0] == "tx":
+		if !hashPattern.MatchString(segments[1]) {
+			return nil, fmt.Errorf("invalid transaction hash %q", segments[1])
+		}
+		if len(segments) == 3 {
+			return &restCall{method: "eth_getTransactionReceipt", params: []interface{}{segments[1]}, notFound: "receipt not found"}, nil
+		}
+		return &restCall{method: "eth_getTransactionByHash", params: []interface{}{segments[1]}, notFound: "transaction not found"}, nil
+
+	case len(segments) == 2 && segments[0] == "balance":
+		if !addressPattern.MatchString(segments[1]) {
+			return nil, fmt.Errorf("invalid address %q", segments[1])
+		}
+		tag := "latest"
+		if block := query.Get("block"); block != "" {
+			var err error
+			if tag, err = restBlockTag(block); err != nil {
+				return nil, err
+			}
+		}
+		return &restCall{method: "eth_getBalance", params: []interface{}{segments[1], tag}}, nil
+	}
+	return nil, errUnknownRESTRoute
+}
+
+// restBlockTag turns a block named in a REST path into a JSON-RPC block parameter: a tag such
+// as latest, or a decimal or hex block number
+func restBlockTag(block string) (string, error) {
+	switch block {
+	case "latest", "earliest", "pending", "safe", "finalized":
+		return block, nil
+	}
+	if hex, ok := strings.CutPrefix(block, "0x"); ok {
+		if _, err := strconv.ParseUint(hex, 16, 64); err == nil {
+			return block, nil
+		}
+	} else if number, err := strconv.ParseUint(block, 10, 64); err == nil {
+		return "0x" + strconv.FormatUint(number, 16), nil
+	}
+	return "", fmt.Errorf("invalid block %q, use a number, a hash or latest, earliest, pending, safe or finalized", block)
+}
+
+// handleREST serves a read-only REST route of an EVM chain by sending its JSON-RPC call through
+// handleRPCForChain, so client limits, tenant policies, coalescing, routing and failover all
+// apply, and answering with the bare result
+func (s *Server) handleREST(w http.ResponseWriter, r *http.Request, chainName, route string) {
+	if r.Method != "GET" {
+		writeRESTError(w, http.StatusMethodNotAllowed, 0, "Method not allowed")
+		return
+	}
+	if s.multiChainHealthChecker.ChainType(chainName) != types.ChainTypeEVM {
+		writeRESTError(w, http.StatusNotFound, 0, fmt.Sprintf("REST routes are only served for evm chains, and %s isn't one", chainName))
+		return
+	}
+
+	call, err := translateREST(route, r.URL.Query())
+	if errors.Is(err, errUnknownRESTRoute) {
+		writeRESTError(w, http.StatusNotFound, 0, "Unknown route. Use /rpc/{chainName}/block/{block}, /tx/{hash}, /tx/{hash}/receipt or /balance/{address}")
+		return
+	}
+	if err != nil {
+		writeRESTError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+
+	body, err := json.Marshal(types.JSONRPCRequest{Jsonrpc: "2.0", Method: call.method, Params: call.params, ID: 1})
+	if err != nil {
+		writeRESTError(w, http.StatusInternalServerError, 0, "Failed to build request")
+		return
+	}
+	rpcReq := r.Clone(r.Context())
+	rpcReq.Method = "POST"
+	rpcReq.Body = io.NopCloser(bytes.NewReader(body))
+	rpcReq.ContentLength = int64(len(body))
+	rpcReq.Header.Set("Content-Type", "application/json")
+	// The reply is decoded here, so it must not arrive compressed
+	rpcReq.Header.Del("Accept-Encoding")
+
+	recorder := &restRecorder{header: make(http.Header)}
+	s.handleRPCForChain(recorder, rpcReq, chainName)
+	recorder.writeTo(w, call)
+}
+
+// restRecorder keeps the JSON-RPC response of a REST route's call for translation
+type restRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *restRecorder) Header() http.Header { return rec.header }
+
+func (rec *restRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *restRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(p)
+}
+
+// writeTo answers a REST route from its call's JSON-RPC response: the result with 200, 404 for
+// a null result, and JSON-RPC errors and refusals in the REST error format
+func (rec *restRecorder) writeTo(w http.ResponseWriter, call *restCall) {
+	if retryAfter := rec.header.Get("Retry-After"); retryAfter != "" {
+		w.Header().Set("Retry-After", retryAfter)
+	}
+	for _, header := range upstreamDebugHeaders {
+		if value := rec.header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	var resp struct {
+		Result json.RawMessage     `json:"result"`
+		Error  *types.JSONRPCError `json:"error"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil {
+		// Refusals made before the call was read, such as the connection limit, are plain text
+		status := rec.status
+		if status == http.StatusOK {
+			status = http.StatusBadGateway
+		}
+		writeRESTError(w, status, 0, strings.TrimSpace(rec.body.String()))
+		return
+	}
+
+	if resp.Error != nil {
+		status := rec.status
+		if status == http.StatusOK {
+			status = restErrorStatus(resp.Error.Code)
+		}
+		writeRESTError(w, status, resp.Error.Code, resp.Error.Message)
+		return
+	}
+
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		message := call.notFound
+		if message == "" {
+			message = "not found"
+		}
+		writeRESTError(w, http.StatusNotFound, 0, message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp.Result)
+	w.Write([]byte("\n"))
+}
+
+// restErrorStatus is the HTTP status of a JSON-RPC error answered with 200: the client's fault
+// for invalid requests and params, the upstream's otherwise
+func restErrorStatus(code int) int {
+	switch code {
+	case -32700, -32600, -32602:
+		return http.StatusBadRequest
+	case -32601:
+		return http.StatusNotImplemented
+	}
+	return http.StatusBadGateway
+}
+
+// writeRESTError writes an error of a REST route; code is the JSON-RPC error code, if any
+func writeRESTError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	body := map[string]interface{}{"status": status, "message": message}
+	if code != 0 {
+		body["code"] = code
+	}
+	if id := responseRequestID(w); id != "" {
+		body["requestId"] = id
+	}
+	json.NewEncoder(w).Encode(body)
+}
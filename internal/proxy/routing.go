@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"rpc-proxy/internal/types"
+)
+
+// recentStateBlocks is the window of recent state that pruned (non-archive) nodes typically retain
+const recentStateBlocks = 128
+
+// stateBlockParamIndex maps state-reading methods to the position of their block parameter
+var stateBlockParamIndex = map[string]int{
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_getStorageAt":        2,
+	"eth_call":                1,
+	"eth_getProof":            2,
+}
+
+// solanaSlotMethods are the Solana methods whose first parameter is a slot
+var solanaSlotMethods = map[string]bool{
+	"getBlock":           true,
+	"getBlockTime":       true,
+	"getBlocks":          true,
+	"getBlocksWithLimit": true,
+}
+
+// parseRPCRequests decodes a single or batch JSON-RPC body; it returns nil if the body can't be parsed
+func parseRPCRequests(body []byte) []*types.JSONRPCRequest {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	if trimmed[0] == '[' {
+		var batch []*types.JSONRPCRequest
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return nil
+		}
+		return batch
+	}
+
+	var req types.JSONRPCRequest
+	if err := json.Unmarshal(trimmed, &req); err != nil {
+		return nil
+	}
+	return []*types.JSONRPCRequest{&req}
+}
+
+// requiresArchive reports whether a request needs an archive node given the chain head
+func requiresArchive(req *types.JSONRPCRequest, head int64) bool {
+	idx, isStateMethod := stateBlockParamIndex[req.Method]
+	if !isStateMethod || idx >= len(req.Params) {
+		return false
+	}
+
+	blockTag, ok := req.Params[idx].(string)
+	if !ok {
+		// Block hash objects (EIP-1898) can't be resolved without a lookup; treat as recent
+		return false
+	}
+
+	switch blockTag {
+	case "latest", "pending", "safe", "finalized":
+		return false
+	case "earliest":
+		return true
+	}
+
+	if !strings.HasPrefix(blockTag, "0x") {
+		return false
+	}
+
+	blockNum, err := strconv.ParseInt(blockTag[2:], 16, 64)
+	if err != nil {
+		return false
+	}
+
+	// Without a known head we can't tell how old the block is, so route conservatively
+	return head == 0 || head-blockNum > recentStateBlocks
+}
+
+// requestRequiresArchive reports whether any call in the body must be served by an archive
+// node. The body's params are only decoded when one of its sniffed calls reads state at a block.
+// debug_* and trace_* calls go to the endpoints with their capability instead; see
+// requiredCapabilities.
+func (s *Server) requestRequiresArchive(chainName string, body []byte, calls []rpcCall) bool {
+	switch s.multiChainHealthChecker.ChainType(chainName) {
+	case types.ChainTypeSolana:
+		return s.solanaRequestRequiresArchive(chainName, body, calls)
+	case types.ChainTypeBitcoin:
+		// Bitcoin calls name blocks by hash, so their age can't be told from the request
+		return false
+	case types.ChainTypeStarknet:
+		// Starknet has no eth_ state reads, and its nodes keep
+		// state history unless pruned by hand, so there is no archive probe to route by
+		return false
+	}
+
+	readsState := false
+	for _, call := range calls {
+		if _, ok := stateBlockParamIndex[call.Method]; ok {
+			readsState = true
+		}
+	}
+	if !readsState {
+		return false
+	}
+
+	requests := parseRPCRequests(body)
+	if len(requests) == 0 {
+		return false
+	}
+
+	head := s.multiChainHealthChecker.HighestBlock(chainName)
+	for _, req := range requests {
+		if requiresArchive(req, head) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredCapabilities returns the endpoint capabilities serving the namespaces of a request's
+// debug_* and trace_* calls, which only endpoints having all of them may serve
+func requiredCapabilities(calls []rpcCall) []string {
+	var capabilities []string
+	for _, call := range calls {
+		capability := ""
+		switch {
+		case strings.HasPrefix(call.Method, "debug_"):
+			capability = types.CapabilityDebug
+		case strings.HasPrefix(call.Method, "trace_"):
+			capability = types.CapabilityTrace
+		default:
+			continue
+		}
+		if !slices.Contains(capabilities, capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities
+}
+
+// unconfiguredCapability returns the first of capabilities no endpoint of the chain has,
+// healthy or not, or "" when each has one
+func (s *Server) unconfiguredCapability(chainName string, capabilities []string) string {
+	endpoints := s.multiChainHealthChecker.GetAllEndpoints(chainName)
+	for _, capability := range capabilities {
+		if !slices.ContainsFunc(endpoints, func(endpoint *types.RPCEndpoint) bool { return endpoint.HasCapability(capability) }) {
+			return capability
+		}
+	}
+	return ""
+}
+
+// solanaRequestRequiresArchive is requestRequiresArchive for Solana chains: calls reading a
+// slot further behind the head than the archive probe goes need an endpoint that passed it
+func (s *Server) solanaRequestRequiresArchive(chainName string, body []byte, calls []rpcCall) bool {
+	readsSlot := false
+	for _, call := range calls {
+		if solanaSlotMethods[call.Method] {
+			readsSlot = true
+		}
+	}
+	if !readsSlot {
+		return false
+	}
+
+	requests := parseRPCRequests(body)
+	head := s.multiChainHealthChecker.HighestBlock(chainName)
+	for _, req := range requests {
+		if !solanaSlotMethods[req.Method] || len(req.Params) == 0 {
+			continue
+		}
+		slot, ok := req.Params[0].(float64)
+		if !ok {
+			continue
+		}
+		// Without a known head we can't tell how old the slot is, so route conservatively
+		if head == 0 || head-int64(slot) > s.config.HealthCheck.ArchiveProbeDepth {
+			return true
+		}
+	}
+	return false
+}
@@ -8,39 +8,186 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gorilla/websocket"
+
+	"rpc-proxy/internal/analytics"
 	"rpc-proxy/internal/config"
 	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/metrics"
+	"rpc-proxy/internal/ratelimit"
+	"rpc-proxy/internal/tenant"
 	"rpc-proxy/internal/types"
 )
 
 type Server struct {
 	config                  *config.Config
 	multiChainHealthChecker *health.MultiChainChecker
-	client                  *http.Client
-	mu                      sync.RWMutex
-	chainPathRegex          *regexp.Regexp
+
+	// performance serves requests without per-request debug logging, for the performance
+	// listener mode
+	performance bool
+
+	// client, maxConnections and the client limits can be replaced at runtime; mu guards them
+	client         *http.Client
+	maxConnections int
+	clientLimits   ratelimit.Limits
+	mu             sync.RWMutex
+
+	// limiter enforces the client and tenant rate and concurrency limits
+	limiter *ratelimit.Limiter
+
+	// active counts proxied requests currently being served, bounded by maxConnections
+	active atomic.Int64
+
+	// rrCounters holds a *uint64 request counter per chain for round-robin balancing
+	rrCounters sync.Map
+
+	// routes holds each chain's *routeTable
+	routes sync.Map
+
+	methods *analytics.MethodTracker
+	clients *analytics.ClientTracker
+	// usage meters calls per tenant API key until the usage job writes them out
+	usage *analytics.UsageMeter
+	// refusals counts requests refused by tenant policy
+	refusals *analytics.RefusalTracker
+
+	// requestLog samples requests for the database request log; nil when it is disabled
+	requestLog *analytics.RequestLogger
+
+	// metrics aggregates per-endpoint traffic for the long-term export; nil when it is disabled
+	metrics *metrics.Collector
+
+	// tenants resolves API keys to tenants; nil without a database
+	tenants *tenant.Registry
+	// tenantRoutes are the read-only routes tenants call with their own API key, by path
+	tenantRoutes map[string]http.HandlerFunc
+
+	// coalescer shares one upstream request among identical concurrent calls
+	coalescer *coalescer
+
+	// subscriptions shares upstream eth_subscribe subscriptions among WebSocket clients
+	subscriptions *subscriptionHub
+	// heads follows new heads for SSE streams
+	heads *headFeeds
+	// gas keeps the gas oracle's quotes
+	gas *gasOracle
 }
 
 func NewServer(cfg *config.Config, multiChainHealthChecker *health.MultiChainChecker) *Server {
-	// Compile regex for chain path matching: /rpc/{chain}
-	chainPathRegex := regexp.MustCompile(`^/rpc/([a-zA-Z0-9-]+)/?$`)
-
-	return &Server{
+	s := &Server{
 		config:                  cfg,
 		multiChainHealthChecker: multiChainHealthChecker,
 		client: &http.Client{
 			Timeout: cfg.Proxy.Timeout,
 		},
-		chainPathRegex: chainPathRegex,
+		maxConnections: cfg.Proxy.MaxConnections,
+		clientLimits: ratelimit.Limits{
+			Rate:           cfg.Proxy.ClientRateLimit,
+			Burst:          cfg.Proxy.ClientBurst,
+			MaxConcurrency: cfg.Proxy.ClientMaxConcurrency,
+		},
+		limiter:     ratelimit.New(),
+		performance: cfg.Server.Mode == config.ServerModePerformance,
+		methods:     analytics.NewMethodTracker(),
+		clients:     analytics.NewClientTracker(cfg.Analytics.ClientWindow),
+		usage:       analytics.NewUsageMeter(),
+		refusals:    analytics.NewRefusalTracker(),
+		coalescer:   newCoalescer(cfg.Proxy.CoalesceMethods, cfg.Proxy.CoalesceLockTTL, cfg.Proxy.CoalesceResultTTL),
 	}
+	s.subscriptions = newSubscriptionHub(s)
+	s.heads = newHeadFeeds(s)
+	s.gas = newGasOracle(s)
+	return s
+}
+
+// MethodTracker returns the per-method request analytics for proxied calls
+func (s *Server) MethodTracker() *analytics.MethodTracker {
+	return s.methods
+}
+
+// ClientTracker returns the per-client request counts over the configured window
+func (s *Server) ClientTracker() *analytics.ClientTracker {
+	return s.clients
+}
+
+// UsageMeter returns the per-API-key daily usage of proxied calls not yet written out
+func (s *Server) UsageMeter() *analytics.UsageMeter {
+	return s.usage
+}
+
+// SetRequestLogger enables sampled request logging; call it before serving traffic
+func (s *Server) SetRequestLogger(logger *analytics.RequestLogger) {
+	s.requestLog = logger
+}
+
+// SetMetricsCollector enables the long-term metrics export; call it before serving traffic
+func (s *Server) SetMetricsCollector(collector *metrics.Collector) {
+	s.metrics = collector
+}
+
+// SetTransport replaces the HTTP transport used to forward requests upstream
+func (s *Server) SetTransport(transport http.RoundTripper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client := *s.client
+	client.Transport = transport
+	s.client = &client
+}
+
+// SetTimeout changes the upstream request timeout; requests already in flight keep the old one
+func (s *Server) SetTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client := *s.client
+	client.Timeout = timeout
+	s.client = &client
+}
+
+// SetMaxConnections changes how many proxied requests may be served concurrently
+func (s *Server) SetMaxConnections(maxConnections int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxConnections = maxConnections
+}
+
+func (s *Server) httpClient() *http.Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
+}
+
+// acquire reserves a slot for a proxied request, reporting false when maxConnections are in use
+func (s *Server) acquire() bool {
+	s.mu.RLock()
+	limit := int64(s.maxConnections)
+	s.mu.RUnlock()
+
+	if n := s.active.Add(1); limit > 0 && n > limit {
+		s.active.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (s *Server) release() {
+	s.active.Add(-1)
 }
 
 func (s *Server) Handler() http.Handler {
+	if s.performance {
+		return s.performanceHandler()
+	}
+
 	mux := http.NewServeMux()
 
 	// Multi-chain health endpoint
@@ -49,22 +196,48 @@ func (s *Server) Handler() http.Handler {
 	// Chain-specific health endpoints
 	mux.HandleFunc("/health/", s.handleChainHealth)
 
+	// Metadata of the enabled chains
+	mux.HandleFunc(chainsPath, s.handleChains)
+
+	// Build information
+	mux.HandleFunc(versionPath, s.handleVersion)
+
 	// Multi-chain RPC endpoints
 	mux.HandleFunc("/rpc/", s.handleMultiChainRPC)
 
+	// New heads as Server-Sent Events: /sse/{chainName}/newHeads
+	mux.HandleFunc(ssePathPrefix, s.handleSSE)
+
+	// Beacon API of consensus-layer chains: /beacon/{chainName}/eth/...
+	mux.HandleFunc(beaconPathPrefix, s.handleBeacon)
+
+	// GraphQL of endpoints serving it: /graphql/{chainName}
+	mux.HandleFunc(graphqlPathPrefix, s.handleGraphQL)
+
+	// Gas price oracle of evm chains: /gas/{chainName}
+	mux.HandleFunc(gasPathPrefix, s.handleGas)
+
+	// Self-service rotation of the presented API key, and other routes for tenants
+	mux.HandleFunc(keyRotationPath, s.handleKeyRotation)
+	for path, handler := range s.tenantRoutes {
+		mux.HandleFunc(path, handler)
+	}
+
 	// Legacy single-chain RPC endpoint (defaults to ethereum)
 	mux.HandleFunc("/rpc", s.handleLegacyRPC)
-	mux.HandleFunc("/", s.handleLegacyRPC)
 
-	return s.corsMiddleware(mux)
+	// Service index, and 404 for paths no route serves
+	mux.HandleFunc("/", s.handleRoot)
+
+	return s.corsMiddleware(s.requestIDMiddleware(mux))
 }
 
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, X-Requested-With")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, X-Requested-With, X-API-Key, Last-Event-ID, X-Chain-Id, X-Chain-Name, X-Request-Id, traceparent, tracestate, X-Debug-Upstream, X-Admin-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Type, X-Request-Id, X-Upstream-Endpoint, X-Upstream-Attempts, X-Upstream-Cache")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -116,10 +289,13 @@ func (s *Server) handleChainHealth(w http.ResponseWriter, r *http.Request) {
 
 	// Legacy format for backward compatibility
 	legacyStatus := types.HealthStatus{
-		Proxy:        "healthy",
-		CurrentRPC:   chainStatus.CurrentRPC,
-		RPCEndpoints: append(chainStatus.HealthyEndpoints, chainStatus.UnhealthyEndpoints...),
-		Chain:        chainName,
+		Proxy:         "healthy",
+		CurrentRPC:    chainStatus.CurrentRPC,
+		RPCEndpoints:  append(chainStatus.HealthyEndpoints, chainStatus.UnhealthyEndpoints...),
+		Chain:         chainName,
+		HighestBlock:  chainStatus.HighestBlock,
+		ConsensusHead: chainStatus.ConsensusHead,
+		MaxBlockLag:   chainStatus.MaxBlockLag,
 	}
 
 	if chainStatus.HealthyCount == 0 {
@@ -131,30 +307,110 @@ func (s *Server) handleChainHealth(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(legacyStatus)
 }
 
-// handleMultiChainRPC handles requests to specific chains via /rpc/{chainName}
+// archivePathSuffix follows /rpc/{chainName} in requests only archive-capable endpoints serve
+const archivePathSuffix = "/archive"
+
+// isArchivePath reports whether a request was made to /rpc/{chain}/archive. Calls on a
+// WebSocket connection keep the path of its upgrade request.
+func isArchivePath(r *http.Request) bool {
+	chainPath, ok := strings.CutSuffix(strings.TrimSuffix(r.URL.Path, "/"), archivePathSuffix)
+	if !ok {
+		return false
+	}
+	segment, ok := strings.CutPrefix(chainPath, "/rpc/")
+	return ok && isChainPathName(segment)
+}
+
+// handleMultiChainRPC handles requests to specific chains via /rpc/{chain}, archive-only
+// requests via /rpc/{chain}/archive, and REST routes below it. The chain is named by its name
+// or its numeric chain ID.
 func (s *Server) handleMultiChainRPC(w http.ResponseWriter, r *http.Request) {
-	// Extract chain name from URL path
-	matches := s.chainPathRegex.FindStringSubmatch(r.URL.Path)
-	if len(matches) != 2 {
-		log.Printf("Invalid multi-chain RPC path: %s", r.URL.Path)
+	segment, route, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rpc/"), "/"), "/")
+	if !isChainPathName(segment) {
+		if !s.performance {
+			log.Printf("Invalid multi-chain RPC path: %s", r.URL.Path)
+		}
 		s.writeErrorResponse(w, -32600, "Invalid request path. Use /rpc/{chainName}", nil)
 		return
 	}
 
-	chainName := matches[1]
-	s.handleRPCForChain(w, r, chainName)
+	chainName := s.resolveChainPath(segment)
+	if route == "" || isArchivePath(r) {
+		s.handleRPCForChain(w, r, chainName)
+		return
+	}
+	// Read-only REST routes: /rpc/{chain}/block/latest, ...
+	s.handleREST(w, r, chainName, route)
 }
 
-// handleLegacyRPC handles legacy requests to /rpc (defaults to ethereum mainnet)
+// resolveChainPath returns the name of the chain a /rpc/{chain} path segment names: the chain of
+// that name or, for a number no chain is named, the chain with that chain ID, so /rpc/1 serves
+// the same chain as /rpc/ethereum. Unknown chains are returned as is, to be refused by name.
+func (s *Server) resolveChainPath(segment string) string {
+	chainID, err := strconv.Atoi(segment)
+	if err != nil || s.config.GetChainByName(segment) != nil {
+		return segment
+	}
+	if chain := s.config.GetChainByID(chainID); chain != nil {
+		return chain.Name
+	}
+	return segment
+}
+
+// Headers selecting the chain of requests to /rpc, for clients whose URL is fixed
+const (
+	chainIDHeader   = "X-Chain-Id"
+	chainNameHeader = "X-Chain-Name"
+)
+
+// handleLegacyRPC handles legacy requests to /rpc: for the chain an X-Chain-Id or X-Chain-Name
+// header selects, and otherwise for ethereum mainnet
 func (s *Server) handleLegacyRPC(w http.ResponseWriter, r *http.Request) {
-	s.handleRPCForChain(w, r, "ethereum") // Default to Ethereum mainnet
+	chainName := "ethereum" // Default to Ethereum mainnet
+	if value := strings.TrimSpace(r.Header.Get(chainIDHeader)); value != "" {
+		chainID, err := parseChainIDHeader(value)
+		if err != nil {
+			s.writeErrorResponse(w, -32600, fmt.Sprintf("Invalid %s header %q: %v", chainIDHeader, value, err), nil)
+			return
+		}
+		chain := s.config.GetChainByID(chainID)
+		if chain == nil {
+			s.writeErrorResponse(w, -32000, fmt.Sprintf("No chain with chain ID %d", chainID), nil)
+			return
+		}
+		chainName = chain.Name
+	} else if value := strings.TrimSpace(r.Header.Get(chainNameHeader)); value != "" {
+		if !isChainPathName(value) {
+			s.writeErrorResponse(w, -32600, fmt.Sprintf("Invalid %s header %q", chainNameHeader, value), nil)
+			return
+		}
+		chainName = value
+	}
+	s.handleRPCForChain(w, r, chainName)
+}
+
+// parseChainIDHeader reads a chain ID in decimal or, as eth_chainId returns it, in 0x-prefixed hex
+func parseChainIDHeader(value string) (int, error) {
+	var chainID int64
+	var err error
+	if hex, ok := strings.CutPrefix(strings.ToLower(value), "0x"); ok {
+		chainID, err = strconv.ParseInt(hex, 16, 64)
+	} else {
+		chainID, err = strconv.ParseInt(value, 10, 64)
+	}
+	if err != nil || chainID <= 0 {
+		return 0, fmt.Errorf("must be a positive decimal or 0x-prefixed hex chain ID")
+	}
+	return int(chainID), nil
 }
 
 // handleRPCForChain processes RPC requests for a specific chain
 func (s *Server) handleRPCForChain(w http.ResponseWriter, r *http.Request, chainName string) {
 	// Log incoming request details for debugging
-	log.Printf("Incoming request: Method=%s, ContentType=%s, ContentLength=%d, URL=%s, Chain=%s",
-		r.Method, r.Header.Get("Content-Type"), r.ContentLength, r.URL.Path, chainName)
+	if !s.performance {
+		log.Printf("Incoming request: Method=%s, ContentType=%s, ContentLength=%d, URL=%s, Chain=%s",
+			r.Method, r.Header.Get("Content-Type"), r.ContentLength, r.URL.Path, chainName)
+	}
 
 	if r.Method != "POST" && r.Method != "GET" {
 		log.Printf("Method not allowed: %s", r.Method)
@@ -162,8 +418,21 @@ func (s *Server) handleRPCForChain(w http.ResponseWriter, r *http.Request, chain
 		return
 	}
 
+	// WebSocket clients keep a connection open for their calls and subscriptions
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWebSocket(w, r, chainName)
+		return
+	}
+
+	if !s.acquire() {
+		log.Printf("Rejecting request for chain %s: max connections reached", chainName)
+		http.Error(w, "Too many concurrent requests", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.release()
+
 	// Accept any Content-Type for POST requests, don't validate
-	if r.Method == "POST" {
+	if r.Method == "POST" && !s.performance {
 		contentType := r.Header.Get("Content-Type")
 		log.Printf("POST request with Content-Type: %s", contentType)
 
@@ -181,7 +450,7 @@ func (s *Server) handleRPCForChain(w http.ResponseWriter, r *http.Request, chain
 
 	start := time.Now()
 
-	body, err := io.ReadAll(r.Body)
+	body, err := readBody(r)
 	if err != nil {
 		log.Printf("Failed to read request body: %v", err)
 		s.writeErrorResponse(w, -32700, "Parse error", nil)
@@ -189,77 +458,305 @@ func (s *Server) handleRPCForChain(w http.ResponseWriter, r *http.Request, chain
 	}
 	defer r.Body.Close()
 
-	healthyEndpoints := s.multiChainHealthChecker.GetHealthyEndpoints(chainName)
-	if len(healthyEndpoints) == 0 {
+	// Only the methods are needed here, so skip decoding params
+	requests := sniffRPCCalls(body)
+
+	consumer, tenantKey := s.clientKey(r)
+	if refusal := tenantPolicy(tenantKey, s.requestKeyUse(r, consumer, tenantKey), chainName, requests); refusal != nil {
+		log.Printf("Rejecting request for chain %s: %s", chainName, refusal.message)
+		s.refusals.Record(consumer.TenantID, refusal.kind, refusal.detail)
+		s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: refusal.message, refused: true})
+		s.writeErrorResponseStatus(w, refusal.status(), refusal.code, refusal.message, nil)
+		return
+	}
+
+	if tenantKey != nil && tenantKey.QuotaExhausted() {
+		message := quotaMessage(tenantKey.Plan.MonthlyQuota)
+		log.Printf("Rejecting request for chain %s from tenant %s: %s", chainName, tenantKey.Tenant.Name, message)
+		s.refusals.Record(consumer.TenantID, analytics.RefusalQuotaExceeded, "")
+		s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: message, refused: true})
+		s.writeErrorResponseStatus(w, http.StatusTooManyRequests, limitErrorCode, message, nil)
+		return
+	}
+
+	limiterKey, limits := s.limitsFor(consumer, tenantKey)
+	releaseLimit, reason, retryAfter := s.limiter.Acquire(limiterKey, limits, len(requests))
+	if releaseLimit == nil {
+		kind, message := limitRefusal(reason, limits)
+		log.Printf("Rejecting request for chain %s from %s: %s", chainName, limiterKey, message)
+		s.refusals.Record(consumer.TenantID, kind, "")
+		s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: message, refused: true})
+		setRetryAfter(w, retryAfter)
+		s.writeErrorResponseStatus(w, http.StatusTooManyRequests, limitErrorCode, message, nil)
+		return
+	}
+	defer releaseLimit()
+
+	chain := s.config.GetChainByName(chainName)
+	if chain != nil && !chain.IsEnabled {
+		log.Printf("Rejecting request for disabled chain: %s", chainName)
+		s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: "chain disabled"})
+		rpcErr := s.chainUnavailableError(chainName, chain)
+		s.writeErrorResponse(w, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+		return
+	} else if chain != nil && chain.GetType() == types.ChainTypeBeacon {
+		s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: "beacon chain"})
+		s.writeErrorResponseStatus(w, http.StatusNotFound, -32601,
+			fmt.Sprintf("Chain %s serves the Beacon API at %s%s/eth/...", chainName, beaconPathPrefix, chainName), nil)
+		return
+	}
+
+	table := s.routeTable(chainName)
+	endpoints := table.all
+	if len(endpoints.endpoints) == 0 {
+		// Hand the request to a peer proxy that may still reach the chain
+		outcome, served := s.forwardToPeer(w, r, chainName, body, consumer.IP)
+		if served {
+			s.recordRequest(consumer, chainName, requests, start, outcome)
+			return
+		}
+		if chain == nil {
+			// Peers may serve chains this proxy doesn't, so unknown chains are only refused here
+			log.Printf("Rejecting request for unknown chain: %s", chainName)
+			if outcome.err == "" {
+				outcome.err = "unknown chain"
+			}
+			s.recordRequest(consumer, chainName, requests, start, outcome)
+			rpcErr := s.chainUnavailableError(chainName, nil)
+			s.writeErrorResponseStatus(w, peerUnavailableStatus(r), rpcErr.Code, rpcErr.Message, rpcErr.Data)
+			return
+		}
 		log.Printf("No healthy RPC endpoints available for chain: %s", chainName)
-		s.writeErrorResponse(w, -32000, fmt.Sprintf("No healthy RPC endpoints available for chain: %s", chainName), nil)
+		if outcome.err == "" {
+			outcome.err = "no healthy endpoints"
+		}
+		s.recordRequest(consumer, chainName, requests, start, outcome)
+		s.writeErrorResponseStatus(w, peerUnavailableStatus(r), -32000,
+			fmt.Sprintf("No healthy RPC endpoints available for chain: %s", chainName), nil)
 		return
 	}
 
-	// Sort endpoints by weight (highest first) for failover
-	sortedEndpoints := s.getSortedEndpointsByWeight(healthyEndpoints)
+	// Route historical state reads, and every call made to /rpc/{chainName}/archive, only to
+	// endpoints that passed the archive probe
+	if isArchivePath(r) || (s.config.HealthCheck.ArchiveProbeDepth > 0 && s.requestRequiresArchive(chainName, body, requests)) {
+		endpoints = table.archive
+		if len(endpoints.endpoints) == 0 {
+			log.Printf("No archive-capable RPC endpoints available for chain: %s", chainName)
+			if s.config.HealthCheck.ArchiveProbeDepth == 0 {
+				log.Printf("Archive probing is off, set HEALTH_CHECK_ARCHIVE_PROBE_DEPTH to find archive endpoints")
+			}
+			s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: "no archive-capable endpoints"})
+			s.writeErrorResponse(w, -32000, fmt.Sprintf("No archive-capable RPC endpoints available for chain: %s", chainName), nil)
+			return
+		}
+	}
+
+	// debug_* and trace_* calls only go to the endpoints marked as serving their namespaces
+	if capabilities := requiredCapabilities(requests); len(capabilities) > 0 && s.multiChainHealthChecker.ChainType(chainName) == types.ChainTypeEVM {
+		if missing := s.unconfiguredCapability(chainName, capabilities); missing != "" {
+			message := fmt.Sprintf("%s_* calls are only served by endpoints with the %s capability, and chain %s has none", missing, missing, chainName)
+			s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: "no capable endpoints"})
+			s.writeErrorResponse(w, -32601, message, nil)
+			return
+		}
+		endpoints = endpoints.filter(func(endpoint *types.RPCEndpoint) bool {
+			for _, capability := range capabilities {
+				if !endpoint.HasCapability(capability) {
+					return false
+				}
+			}
+			return true
+		})
+		if len(endpoints.endpoints) == 0 {
+			message := fmt.Sprintf("No healthy RPC endpoints with the %s capability available for chain: %s", strings.Join(capabilities, " and "), chainName)
+			log.Print(message)
+			s.recordRequest(consumer, chainName, requests, start, requestOutcome{err: "no healthy capable endpoints"})
+			s.writeErrorResponse(w, -32000, message, nil)
+			return
+		}
+	}
+
+	// Identical concurrent calls, here or on other replicas, share one upstream request
+	if key, ok := s.coalescer.callKey(chainName, body, requests); ok {
+		result, finish := s.coalescer.join(r.Context(), key)
+		if result != nil {
+			if !s.performance {
+				log.Printf("Request for chain %s (%s) answered with the result of an identical call", chainName, requests[0].Method)
+			}
+			outcome := requestOutcome{success: true, upstream: "coalesced"}
+			s.recordRequest(consumer, chainName, requests, start, outcome)
+			s.discloseUpstream(w, r, outcome)
+			s.writeCoalesced(w, requests[0].ID, result)
+			return
+		}
+		if finish != nil {
+			capture := &captureWriter{ResponseWriter: w}
+			w = capture
+			defer func() { finish(capture.result()) }()
+		}
+	}
+
+	// Order endpoints for failover according to the chain's load balancing strategy, trying
+	// as many as the chain's failover policy allows
+	failoverOrder := s.route(chainName, table, endpoints, stickyClient(consumer))
+	policy := s.failoverPolicy(chainName)
+	attempts := policy.attempts(failoverOrder.len())
+	client := s.clientFor(policy)
 	var lastErr error
+	var outcome requestOutcome
+
+	// Try each endpoint by weight priority, stopping as soon as the client is gone
+	for i := 0; i < attempts && r.Context().Err() == nil; i++ {
+		endpoint := failoverOrder.at(i)
+		if !policy.wait(r.Context(), i) {
+			break
+		}
+
+		outcome.upstream, outcome.status, outcome.attempts = endpoint.Name, 0, i+1
 
-	// Try each endpoint by weight priority
-	for i, endpoint := range sortedEndpoints {
-		resp, err := s.forwardRequest(r.Context(), endpoint, body, r.Header)
+		// In-flight requests are tracked so a draining endpoint can report when it is idle
+		endpoint.BeginRequest()
+		attemptStart := time.Now()
+		resp, err := s.forwardRequest(r.Context(), client, endpoint, body, r.Header)
+		if err != nil && r.Context().Err() != nil {
+			// Cancelled by the client disconnecting, not the endpoint's failure
+			endpoint.AbortRequest()
+			break
+		}
 		if err != nil {
-			log.Printf("Request to %s failed (attempt %d/%d): %v", endpoint.URL, i+1, len(sortedEndpoints), err)
+			endpoint.EndRequest(false, int64(len(body)), 0)
+			s.recordAttempt(chainName, endpoint, attemptStart, false, int64(len(body)), 0)
+			log.Printf("Request %s to %s failed (attempt %d/%d): %v", requestID(r), endpoint.URL, i+1, attempts, err)
 			lastErr = err
 			continue
 		}
 
-		s.copyResponse(w, resp)
+		// Rate-limited upstreams are demoted to last resort until their cooldown expires
+		outcome.status = resp.StatusCode
+		if resp.StatusCode == http.StatusTooManyRequests {
+			cooldown := s.rateLimitCooldown(resp)
+			resp.Body.Close()
+			endpoint.EndRequest(false, int64(len(body)), 0)
+			s.recordAttempt(chainName, endpoint, attemptStart, false, int64(len(body)), 0)
+			if !endpoint.IsDegraded() {
+				s.multiChainHealthChecker.ShareCooldown(chainName, endpoint, cooldown)
+			}
+			endpoint.MarkDegraded(cooldown)
+			log.Printf("Endpoint %s rate limited request %s (attempt %d/%d), degraded for %v", endpoint.URL, requestID(r), i+1, attempts, cooldown)
+			lastErr = fmt.Errorf("upstream %s rate limited (HTTP 429)", endpoint.Name)
+			continue
+		}
+
+		s.discloseUpstream(w, r, outcome)
+		received := s.copyResponse(w, resp)
 		resp.Body.Close()
+		outcome.success = resp.StatusCode < http.StatusInternalServerError
+		endpoint.EndRequest(outcome.success, int64(len(body)), received)
+		s.recordAttempt(chainName, endpoint, attemptStart, outcome.success, int64(len(body)), received)
+		s.recordRequest(consumer, chainName, requests, start, outcome)
+
+		if !s.performance {
+			duration := time.Since(start)
+			log.Printf("Request forwarded to %s (chain: %s, weight: %d, score: %.1f) completed in %v", endpoint.URL, chainName, endpoint.Weight, endpoint.GetScore(), duration)
+		}
+		return
+	}
 
-		duration := time.Since(start)
-		log.Printf("Request forwarded to %s (chain: %s, weight: %d) completed in %v", endpoint.URL, chainName, endpoint.Weight, duration)
+	if err := r.Context().Err(); err != nil {
+		log.Printf("Client disconnected from %s request after %d attempts: %v", chainName, outcome.attempts, err)
+		outcome.err = "client disconnected"
+		s.recordRequest(consumer, chainName, requests, start, outcome)
 		return
 	}
 
-	log.Printf("All retry attempts failed, last error: %v", lastErr)
+	log.Printf("All retry attempts failed for request %s, last error: %v", requestID(r), lastErr)
+	outcome.err = lastErr.Error()
+	s.recordRequest(consumer, chainName, requests, start, outcome)
+	s.discloseUpstream(w, r, outcome)
 	s.writeErrorResponse(w, -32000, "All RPC endpoints failed", lastErr.Error())
 }
 
-func (s *Server) selectHealthyEndpointForChain(chainName string) *types.RPCEndpoint {
-	healthyEndpoints := s.multiChainHealthChecker.GetHealthyEndpoints(chainName)
-	if len(healthyEndpoints) == 0 {
-		return nil
-	}
+// requestOutcome is how a proxied request ended: the last upstream tried, if any, with its
+// HTTP status, and the error returned to the client on failure
+type requestOutcome struct {
+	success  bool
+	upstream string
+	status   int
+	attempts int
+	err      string
+	// refused requests were turned away by tenant policy and are not metered
+	refused bool
+}
 
-	return s.selectEndpointByWeight(healthyEndpoints)
+// recordAttempt adds one upstream attempt to the long-term metrics export
+func (s *Server) recordAttempt(chainName string, endpoint *types.RPCEndpoint, start time.Time, success bool, bytesSent, bytesReceived int64) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.RecordRequest(chainName, endpoint.Name, time.Since(start), success, bytesSent, bytesReceived)
 }
 
-func (s *Server) selectEndpointByWeight(endpoints []*types.RPCEndpoint) *types.RPCEndpoint {
-	sortedEndpoints := s.getSortedEndpointsByWeight(endpoints)
-	if len(sortedEndpoints) == 0 {
-		return nil
+// recordRequest adds a proxied request to the client analytics, each call in it to the
+// per-method analytics, and a sample to the request log; methods of unparseable bodies and
+// unknown chains are not recorded
+func (s *Server) recordRequest(consumer analytics.ClientKey, chainName string, requests []rpcCall, start time.Time, outcome requestOutcome) {
+	s.clients.Record(consumer, outcome.success)
+
+	if len(requests) == 0 || !s.multiChainHealthChecker.IsChainSupported(chainName) {
+		return
+	}
+
+	latency := time.Since(start)
+	for _, req := range requests {
+		s.methods.Record(chainName, req.Method, latency, outcome.success)
+		if consumer.KeyID != 0 && !outcome.refused {
+			s.usage.Record(consumer.KeyID, consumer.TenantID, chainName, req.Method, outcome.success)
+		}
+	}
+
+	if s.requestLog != nil && s.requestLog.Sampled(outcome.success) {
+		s.requestLog.Record(&types.RequestLog{
+			CreatedAt:  start,
+			ChainName:  chainName,
+			Method:     requests[0].Method,
+			BatchSize:  len(requests),
+			Upstream:   outcome.upstream,
+			Attempts:   outcome.attempts,
+			Status:     outcome.status,
+			DurationMs: latency.Milliseconds(),
+			Success:    outcome.success,
+			Error:      outcome.err,
+			ClientIP:   consumer.IP,
+			ClientKey:  consumer.Key,
+			TenantID:   consumer.TenantID,
+		})
 	}
-	return sortedEndpoints[0]
 }
 
-func (s *Server) getSortedEndpointsByWeight(endpoints []*types.RPCEndpoint) []*types.RPCEndpoint {
-	if len(endpoints) == 0 {
+func (s *Server) selectHealthyEndpointForChain(chainName string) *types.RPCEndpoint {
+	table := s.routeTable(chainName)
+	if len(table.all.endpoints) == 0 {
 		return nil
 	}
+	return s.route(chainName, table, table.all, "").at(0)
+}
 
-	// Sort endpoints by weight (highest first)
-	sortedEndpoints := make([]*types.RPCEndpoint, len(endpoints))
-	copy(sortedEndpoints, endpoints)
-
-	// Simple bubble sort by weight (descending)
-	for i := 0; i < len(sortedEndpoints)-1; i++ {
-		for j := 0; j < len(sortedEndpoints)-i-1; j++ {
-			if sortedEndpoints[j].Weight < sortedEndpoints[j+1].Weight {
-				sortedEndpoints[j], sortedEndpoints[j+1] = sortedEndpoints[j+1], sortedEndpoints[j]
-			}
+// rateLimitCooldown honors an upstream Retry-After header (in seconds), bounded by ten times the configured cooldown
+func (s *Server) rateLimitCooldown(resp *http.Response) time.Duration {
+	cooldown := s.config.Proxy.RateLimitCooldown
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && retryAfter > 0 {
+		upstream := time.Duration(retryAfter) * time.Second
+		if upstream > 10*cooldown {
+			upstream = 10 * cooldown
+		}
+		if upstream > cooldown {
+			cooldown = upstream
 		}
 	}
-
-	return sortedEndpoints
+	return cooldown
 }
 
-func (s *Server) forwardRequest(ctx context.Context, endpoint *types.RPCEndpoint, body []byte, headers http.Header) (*http.Response, error) {
+func (s *Server) forwardRequest(ctx context.Context, client *http.Client, endpoint *types.RPCEndpoint, body []byte, headers http.Header) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.URL, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -267,7 +764,7 @@ func (s *Server) forwardRequest(ctx context.Context, endpoint *types.RPCEndpoint
 
 	// Copy headers first, then ensure Content-Type is set correctly
 	for key, values := range headers {
-		if key == "Host" || key == "Content-Length" {
+		if key == "Host" || key == "Content-Length" || !upstreamHeader(key) {
 			continue
 		}
 		for _, value := range values {
@@ -277,39 +774,59 @@ func (s *Server) forwardRequest(ctx context.Context, endpoint *types.RPCEndpoint
 
 	// Always ensure Content-Type is application/json for RPC requests
 	req.Header.Set("Content-Type", "application/json")
+	// Endpoint credentials replace any the client sent
+	if auth := endpoint.Authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
 
-	log.Printf("Forwarding request to %s with Content-Type: %s", endpoint.URL, req.Header.Get("Content-Type"))
+	if !s.performance {
+		log.Printf("Forwarding request to %s with Content-Type: %s", endpoint.URL, req.Header.Get("Content-Type"))
+	}
 
-	resp, err := s.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
-	log.Printf("Response from %s: Status=%d, Content-Type=%s", endpoint.URL, resp.StatusCode, resp.Header.Get("Content-Type"))
+	if !s.performance {
+		log.Printf("Response from %s: Status=%d, Content-Type=%s", endpoint.URL, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
 	return resp, nil
 }
 
-func (s *Server) copyResponse(w http.ResponseWriter, resp *http.Response) {
+// copyResponse relays resp to the client and returns the number of body bytes written
+func (s *Server) copyResponse(w http.ResponseWriter, resp *http.Response) int64 {
 	for key, values := range resp.Header {
+		// The client gets its own request ID back, not one the upstream assigned
+		if key == requestIDHeader {
+			continue
+		}
 		for _, value := range values {
 			w.Header().Add(key, value)
 		}
 	}
 
 	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	n, _ := io.Copy(w, resp.Body)
+	return n
 }
 
 func (s *Server) writeErrorResponse(w http.ResponseWriter, code int, message string, data interface{}) {
+	s.writeErrorResponseStatus(w, http.StatusOK, code, message, data)
+}
+
+// writeErrorResponseStatus writes a JSON-RPC error with a non-200 HTTP status, for refusals
+// clients should be able to tell apart without parsing the body
+func (s *Server) writeErrorResponseStatus(w http.ResponseWriter, status, code int, message string, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 
 	response := types.JSONRPCResponse{
 		Jsonrpc: "2.0",
 		Error: &types.JSONRPCError{
 			Code:    code,
 			Message: message,
-			Data:    data,
+			Data:    withRequestID(data, responseRequestID(w)),
 		},
 		ID: nil,
 	}
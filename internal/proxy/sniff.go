@@ -0,0 +1,238 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// rpcCall is what routing and metrics need from one JSON-RPC call: its method and raw id
+type rpcCall struct {
+	Method string
+	ID     json.RawMessage
+}
+
+// sniffRPCCalls extracts the method and id of each call in a single or batch JSON-RPC body
+// without decoding params, which may be huge (eth_sendRawTransaction, large eth_call data).
+// It returns nil if the body isn't a well-formed request object or array of them. Values
+// other than method and id are only skipped, not validated, so features that need params
+// still decode the body with parseRPCRequests.
+func sniffRPCCalls(body []byte) []rpcCall {
+	s := &sniffer{data: body}
+	s.skipSpace()
+	if s.pos >= len(s.data) {
+		return nil
+	}
+
+	var calls []rpcCall
+	if s.data[s.pos] == '[' {
+		s.pos++
+		s.skipSpace()
+		if s.consume(']') {
+			return nil
+		}
+		for {
+			call, ok := s.object()
+			if !ok {
+				return nil
+			}
+			calls = append(calls, call)
+
+			s.skipSpace()
+			if s.consume(']') {
+				break
+			}
+			if !s.consume(',') {
+				return nil
+			}
+			s.skipSpace()
+		}
+	} else {
+		call, ok := s.object()
+		if !ok {
+			return nil
+		}
+		calls = []rpcCall{call}
+	}
+
+	s.skipSpace()
+	if s.pos != len(s.data) {
+		return nil
+	}
+	return calls
+}
+
+// sniffer is a cursor over a JSON document that understands just enough of the grammar to
+// find object keys and step over values
+type sniffer struct {
+	data []byte
+	pos  int
+}
+
+func (s *sniffer) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume advances past c if it is the next byte
+func (s *sniffer) consume(c byte) bool {
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// object reads a call object, keeping method and id and skipping every other member. Keys
+// match case-insensitively, as encoding/json matches them, so "Method" is the method an
+// upstream written in Go would call. Since parsers differ on which duplicate key wins, an
+// object with a second method or id, in any case, is refused.
+func (s *sniffer) object() (rpcCall, bool) {
+	var call rpcCall
+	var hasMethod, hasID bool
+	if !s.consume('{') {
+		return call, false
+	}
+
+	s.skipSpace()
+	if s.consume('}') {
+		return call, true
+	}
+	for {
+		s.skipSpace()
+		key, ok := s.str()
+		if !ok {
+			return call, false
+		}
+		s.skipSpace()
+		if !s.consume(':') {
+			return call, false
+		}
+		s.skipSpace()
+
+		start := s.pos
+		if !s.skipValue() {
+			return call, false
+		}
+		raw := s.data[start:s.pos]
+
+		switch {
+		case strings.EqualFold(key, "method"):
+			// A non-string method fails to decode, as it would when unmarshalling the request
+			if hasMethod || raw[0] != '"' || json.Unmarshal(raw, &call.Method) != nil {
+				return call, false
+			}
+			hasMethod = true
+		case strings.EqualFold(key, "id"):
+			if hasID {
+				return call, false
+			}
+			call.ID = json.RawMessage(raw)
+			hasID = true
+		}
+
+		s.skipSpace()
+		if s.consume('}') {
+			return call, true
+		}
+		if !s.consume(',') {
+			return call, false
+		}
+	}
+}
+
+// str reads a string token and returns its decoded value, decoding escapes only when present
+func (s *sniffer) str() (string, bool) {
+	start := s.pos
+	escaped := false
+	if !s.skipString(&escaped) {
+		return "", false
+	}
+
+	raw := s.data[start:s.pos]
+	if !escaped {
+		return string(raw[1 : len(raw)-1]), true
+	}
+	var decoded string
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return "", false
+	}
+	return decoded, true
+}
+
+// skipString steps over a string token, reporting whether it contains escapes
+func (s *sniffer) skipString(escaped *bool) bool {
+	if !s.consume('"') {
+		return false
+	}
+	for {
+		// Two IndexByte scans beat one IndexAny over long hex strings
+		quote := bytes.IndexByte(s.data[s.pos:], '"')
+		if quote < 0 {
+			return false
+		}
+		backslash := bytes.IndexByte(s.data[s.pos:s.pos+quote], '\\')
+		if backslash < 0 {
+			s.pos += quote + 1
+			return true
+		}
+		// Skip the backslash and the byte it escapes
+		*escaped = true
+		s.pos += backslash + 2
+		if s.pos > len(s.data) {
+			return false
+		}
+	}
+}
+
+// skipValue steps over one value. Nested objects and arrays are skipped by matching their
+// brackets outside of strings, without checking what lies between them.
+func (s *sniffer) skipValue() bool {
+	if s.pos >= len(s.data) {
+		return false
+	}
+
+	var escaped bool
+	switch s.data[s.pos] {
+	case '"':
+		return s.skipString(&escaped)
+	case '{', '[':
+		depth := 0
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case '"':
+				if !s.skipString(&escaped) {
+					return false
+				}
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					s.pos++
+					return true
+				}
+			}
+			s.pos++
+		}
+		return false
+	default:
+		// Numbers, true, false and null run until the next delimiter
+		start := s.pos
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return s.pos > start
+			}
+			s.pos++
+		}
+		return s.pos > start
+	}
+}
@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/types"
+)
+
+func TestSniffRPCCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		// want is the method and id of each call; nil when the body must be refused
+		want []rpcCall
+	}{
+		{"single", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`,
+			[]rpcCall{{Method: "eth_blockNumber", ID: json.RawMessage(`1`)}}},
+		{"batch", `[{"id":1,"method":"eth_chainId"}, {"id":"b","method":"eth_call","params":[{"data":"0x"}]}]`,
+			[]rpcCall{{Method: "eth_chainId", ID: json.RawMessage(`1`)}, {Method: "eth_call", ID: json.RawMessage(`"b"`)}}},
+		{"escaped key", `{"id":1,"\u006dethod":"eth_call"}`,
+			[]rpcCall{{Method: "eth_call", ID: json.RawMessage(`1`)}}},
+		// encoding/json matches keys case-insensitively, so a Go upstream calls this method
+		{"case variant key", `{"ID":7,"Method":"debug_traceTransaction"}`,
+			[]rpcCall{{Method: "debug_traceTransaction", ID: json.RawMessage(`7`)}}},
+		{"duplicate method", `{"id":1,"method":"eth_call","method":"debug_traceTransaction"}`, nil},
+		{"case variant duplicate method", `{"id":1,"method":"eth_call","Method":"debug_traceTransaction"}`, nil},
+		{"case variant duplicate method first", `{"METHOD":"debug_traceTransaction","id":1,"method":"eth_call"}`, nil},
+		{"case variant duplicate in batch", `[{"id":1,"method":"eth_call"},{"id":2,"method":"eth_call","mEtHoD":"admin_peers"}]`, nil},
+		{"case variant duplicate id", `{"id":1,"Id":2,"method":"eth_call"}`, nil},
+		{"non-string method", `{"id":1,"method":1}`, nil},
+		{"empty batch", `[]`, nil},
+		{"trailing data", `{"id":1,"method":"eth_call"} {}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sniffRPCCalls([]byte(tt.body))
+			if len(got) != len(tt.want) || (got == nil) != (tt.want == nil) {
+				t.Fatalf("sniffRPCCalls(%s) = %+v, want %+v", tt.body, got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i].Method != tt.want[i].Method || string(got[i].ID) != string(tt.want[i].ID) {
+					t.Errorf("call %d = %s %s, want %s %s", i, got[i].Method, got[i].ID, tt.want[i].Method, tt.want[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestTenantPolicyMethodKeyCase(t *testing.T) {
+	key := &tenant.Key{Tenant: &types.Tenant{
+		Name:           "acme",
+		Status:         types.TenantActive,
+		AllowedMethods: []string{"eth_call", "eth_blockNumber"},
+	}}
+
+	tests := []struct {
+		name    string
+		body    string
+		allowed bool
+	}{
+		{"allowed method", `{"id":1,"method":"eth_call"}`, true},
+		{"other method", `{"id":1,"method":"debug_traceTransaction"}`, false},
+		{"case variant key", `{"id":1,"Method":"debug_traceTransaction"}`, false},
+		{"case variant duplicate after allowed", `{"id":1,"method":"eth_call","Method":"debug_traceTransaction"}`, false},
+		{"case variant duplicate before allowed", `{"id":1,"Method":"debug_traceTransaction","method":"eth_call"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refusal := tenantPolicy(key, keyUse{}, "ethereum", sniffRPCCalls([]byte(tt.body)))
+			if allowed := refusal == nil; allowed != tt.allowed {
+				t.Errorf("tenantPolicy(%s) allowed = %v, want %v (refusal %+v)", tt.body, allowed, tt.allowed, refusal)
+			}
+			if refusal != nil && refusal.code != methodNotAllowedCode {
+				t.Errorf("refusal code = %d, want %d", refusal.code, methodNotAllowedCode)
+			}
+		})
+	}
+}
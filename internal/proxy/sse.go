@@ -0,0 +1,382 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// ssePathPrefix is where SSE streams are served, as /sse/{chainName}/newHeads
+	ssePathPrefix = "/sse/"
+	// sseKeepAliveInterval is how often an idle stream gets a comment, so proxies and load
+	// balancers in between don't time it out
+	sseKeepAliveInterval = 15 * time.Second
+	// headFeedBuffer is how many recent heads a feed keeps for clients resuming with
+	// Last-Event-ID
+	headFeedBuffer = 128
+	// headFeedLinger is how long a feed keeps running after its last client left, so clients
+	// reconnecting can resume
+	headFeedLinger = time.Minute
+	// headPollInterval is how often a feed without a WebSocket endpoint polls for the head
+	headPollInterval = time.Second
+	// headResubscribeInterval is how often a polling feed tries the shared subscription again
+	headResubscribeInterval = 30 * time.Second
+)
+
+// headFeeds holds a new heads feed per chain with SSE clients
+type headFeeds struct {
+	server *Server
+
+	mu    sync.Mutex
+	feeds map[string]*headFeed
+}
+
+// headFeed follows a chain's new heads for its SSE streams: from the shared newHeads
+// subscription when the chain has a WebSocket endpoint, so SSE clients and WebSocket clients
+// share one upstream subscription, and otherwise by polling eth_getBlockByNumber. It keeps the
+// last headFeedBuffer heads for clients resuming with Last-Event-ID.
+type headFeed struct {
+	feeds     *headFeeds
+	chainName string
+	subscriptionSet
+
+	mu    sync.Mutex
+	heads []feedHead
+	seq   uint64
+	// updated is closed and replaced when a head is added
+	updated chan struct{}
+	// clients counts connected streams; idleSince is when the last one left
+	clients   int
+	idleSince time.Time
+
+	// lost is signalled when the shared subscription is lost
+	lost chan struct{}
+}
+
+// feedHead is a block header in a feed; seq orders heads as received, which a reorg can give
+// the same number as an earlier one
+type feedHead struct {
+	seq    uint64
+	number int64
+	data   []byte
+}
+
+func newHeadFeeds(server *Server) *headFeeds {
+	return &headFeeds{server: server, feeds: make(map[string]*headFeed)}
+}
+
+// join returns the chain's feed with a client added, starting it if needed
+func (f *headFeeds) join(chainName string) *headFeed {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	feed, ok := f.feeds[chainName]
+	if !ok {
+		feed = &headFeed{
+			feeds:           f,
+			chainName:       chainName,
+			subscriptionSet: subscriptionSet{subscriptions: make(map[string]*sharedSubscription)},
+			updated:         make(chan struct{}),
+			lost:            make(chan struct{}, 1),
+		}
+		f.feeds[chainName] = feed
+		go feed.run()
+	}
+	feed.mu.Lock()
+	feed.clients++
+	feed.mu.Unlock()
+	return feed
+}
+
+// leave removes a client from the feed
+func (feed *headFeed) leave() {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	feed.clients--
+	if feed.clients == 0 {
+		feed.idleSince = time.Now()
+	}
+}
+
+// idle reports whether the feed has had no client for headFeedLinger, removing it if so
+func (feed *headFeed) idle() bool {
+	feed.feeds.mu.Lock()
+	defer feed.feeds.mu.Unlock()
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	if feed.clients > 0 || time.Since(feed.idleSince) < headFeedLinger {
+		return false
+	}
+	delete(feed.feeds.feeds, feed.chainName)
+	return true
+}
+
+// run follows the chain's heads until the feed is idle, through the shared subscription when
+// it can be made and by polling otherwise
+func (feed *headFeed) run() {
+	s := feed.feeds.server
+	defer s.subscriptions.drop(feed)
+
+	check := time.NewTicker(headPollInterval)
+	defer check.Stop()
+	subscribed := false
+	var lastAttempt time.Time
+	for {
+		if !subscribed && time.Since(lastAttempt) >= headResubscribeInterval {
+			lastAttempt = time.Now()
+			_, err := s.subscriptions.subscribe(feed, feed.chainName, json.RawMessage(`["newHeads"]`), nil)
+			if err == nil {
+				log.Printf("New heads feed for chain %s follows the shared subscription", feed.chainName)
+				subscribed = true
+			} else if !s.performance {
+				log.Printf("New heads feed for chain %s polls for heads: %v", feed.chainName, err)
+			}
+		}
+
+		select {
+		case <-check.C:
+			if feed.idle() {
+				return
+			}
+			if !subscribed {
+				feed.poll()
+			}
+		case <-feed.lost:
+			log.Printf("New heads feed for chain %s lost the shared subscription, polling", feed.chainName)
+			subscribed = false
+		}
+	}
+}
+
+// poll adds the chain's latest block, if new, from the first HTTP endpoint that returns it
+func (feed *headFeed) poll() {
+	s := feed.feeds.server
+	table := s.routeTable(feed.chainName)
+	order := s.route(feed.chainName, table, table.all, "")
+
+	for i := 0; i < order.len(); i++ {
+		endpoint := order.at(i)
+		ctx, cancel := context.WithTimeout(context.Background(), s.httpClient().Timeout)
+		block, err := callEndpoint(ctx, s, endpoint, "eth_getBlockByNumber", []interface{}{"latest", false})
+		cancel()
+		if err != nil {
+			continue
+		}
+		feed.add(block, true)
+		return
+	}
+}
+
+// callEndpoint returns the result of a JSON-RPC call the proxy makes itself to endpoint
+func callEndpoint(ctx context.Context, s *Server, endpoint *types.RPCEndpoint, method string, params []interface{}) (json.RawMessage, error) {
+	body, err := json.Marshal(types.JSONRPCRequest{Jsonrpc: "2.0", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth := endpoint.Authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result json.RawMessage     `json:"result"`
+		Error  *types.JSONRPCError `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, wsMaxMessage)).Decode(&rpcResp); err != nil {
+		return nil, err
+	}
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("%s returned %d: %s", method, rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	return rpcResp.Result, nil
+}
+
+// queue takes notifications of the shared newHeads subscription; the reply to the subscribe
+// call is ignored
+func (feed *headFeed) queue(message []byte) {
+	var msg upstreamMessage
+	if err := json.Unmarshal(message, &msg); err != nil || msg.Method != "eth_subscription" || msg.Params == nil {
+		return
+	}
+	feed.add(msg.Params.Result, false)
+}
+
+// disconnect switches the feed to polling once the shared subscription is lost
+func (feed *headFeed) disconnect(code int, reason string) {
+	select {
+	case feed.lost <- struct{}{}:
+	default:
+	}
+}
+
+// add appends a header to the feed. Polled blocks are trimmed to the fields of a newHeads
+// header, and only added when their number is above the last head's.
+func (feed *headFeed) add(header json.RawMessage, polled bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(header, &fields); err != nil {
+		return
+	}
+	var numberHex string
+	if err := json.Unmarshal(fields["number"], &numberHex); err != nil {
+		return
+	}
+	number, err := strconv.ParseInt(strings.TrimPrefix(numberHex, "0x"), 16, 64)
+	if err != nil {
+		return
+	}
+
+	var data bytes.Buffer
+	if polled {
+		for _, key := range []string{"transactions", "uncles", "withdrawals", "size", "totalDifficulty"} {
+			delete(fields, key)
+		}
+		trimmed, err := json.Marshal(fields)
+		if err != nil {
+			return
+		}
+		data.Write(trimmed)
+	} else if err := json.Compact(&data, header); err != nil {
+		return
+	}
+
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	if polled && len(feed.heads) > 0 && number <= feed.heads[len(feed.heads)-1].number {
+		return
+	}
+	feed.seq++
+	feed.heads = append(feed.heads, feedHead{seq: feed.seq, number: number, data: data.Bytes()})
+	if len(feed.heads) > headFeedBuffer {
+		feed.heads = feed.heads[len(feed.heads)-headFeedBuffer:]
+	}
+	close(feed.updated)
+	feed.updated = make(chan struct{})
+}
+
+// start returns the sequence number a new stream continues after: before the first head above
+// lastEventID when the client resumes, or before the latest head so a new client gets it
+// right away
+func (feed *headFeed) start(lastEventID string) uint64 {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	if len(feed.heads) == 0 {
+		return feed.seq
+	}
+	last, err := strconv.ParseInt(lastEventID, 10, 64)
+	if err != nil {
+		return feed.heads[len(feed.heads)-1].seq - 1
+	}
+	for _, head := range feed.heads {
+		if head.number > last {
+			return head.seq - 1
+		}
+	}
+	return feed.seq
+}
+
+// since returns the heads after seq, and a channel closed when another arrives
+func (feed *headFeed) since(seq uint64) ([]feedHead, <-chan struct{}) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	var heads []feedHead
+	for _, head := range feed.heads {
+		if head.seq > seq {
+			heads = append(heads, head)
+		}
+	}
+	return heads, feed.updated
+}
+
+// handleSSE streams a chain's new block headers as Server-Sent Events from
+// /sse/{chainName}/newHeads, for clients that can't use WebSocket. Each event's ID is the block
+// number, so a client reconnecting with Last-Event-ID gets the heads it missed that the feed
+// still holds.
+func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chainName, stream, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, ssePathPrefix), "/")
+	if !ok || !isChainPathName(chainName) || stream != "newHeads" {
+		http.Error(w, "Invalid path format. Use /sse/{chainName}/newHeads", http.StatusNotFound)
+		return
+	}
+	if !s.multiChainHealthChecker.IsChainSupported(chainName) {
+		http.Error(w, fmt.Sprintf("Chain %s not found", chainName), http.StatusNotFound)
+		return
+	}
+	if s.multiChainHealthChecker.ChainType(chainName) != types.ChainTypeEVM {
+		http.Error(w, fmt.Sprintf("Chain %s has no newHeads stream", chainName), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	calls := []rpcCall{{Method: "eth_subscribe"}}
+	if _, rpcErr, status := s.admitSubscription(r, chainName, calls, time.Now()); rpcErr != nil {
+		http.Error(w, rpcErr.Message, status)
+		return
+	}
+
+	feed := s.heads.join(chainName)
+	defer feed.leave()
+	seq := feed.start(r.Header.Get("Last-Event-ID"))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		heads, updated := feed.since(seq)
+		for _, head := range heads {
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: newHeads\ndata: %s\n\n", head.number, head.data); err != nil {
+				return
+			}
+			seq = head.seq
+		}
+		flusher.Flush()
+
+		select {
+		case <-updated:
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/types"
+)
+
+// ringPoints is how many points each unit of weight gives an endpoint on the hash ring
+const ringPoints = 64
+
+// hashRing places a chain's endpoints on a consistent-hash ring for the sticky strategy. It is
+// built from every endpoint of the chain, healthy or not, positioned by configured IDs and
+// weights only, so every replica sharing the configuration builds the same ring and hashes a
+// client to the same upstream. When that endpoint can't take the request, the next one along
+// the ring does, and only the clients of a failed endpoint move.
+type hashRing struct {
+	points []ringPoint
+}
+
+type ringPoint struct {
+	hash       uint64
+	endpointID int
+}
+
+func newHashRing(endpoints []*types.RPCEndpoint) *hashRing {
+	ring := &hashRing{}
+	for _, endpoint := range endpoints {
+		identity := ringIdentity(endpoint)
+		for i := 0; i < ringPoints*max(endpoint.Weight, 1); i++ {
+			ring.points = append(ring.points, ringPoint{
+				hash:       ringHash(identity + "#" + strconv.Itoa(i)),
+				endpointID: endpoint.ID,
+			})
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i].hash < ring.points[j].hash })
+	return ring
+}
+
+// ringIdentity is where an endpoint sits on the ring. Discovered endpoints are numbered by
+// each replica in the order it found them, so their URL places them instead of their ID.
+func ringIdentity(endpoint *types.RPCEndpoint) string {
+	if endpoint.Discovered() {
+		return endpoint.URL
+	}
+	return strconv.Itoa(endpoint.ID)
+}
+
+func ringHash(value string) uint64 {
+	sum := sha256.Sum256([]byte(value))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// lookup returns the index among set's non-degraded endpoints of the first one at or after
+// client's position on the ring, or -1 if none of them is on it
+func (ring *hashRing) lookup(client string, set routeSet) int {
+	if len(ring.points) == 0 || set.available == 0 {
+		return -1
+	}
+	available := make(map[int]int, set.available)
+	for i, endpoint := range set.endpoints[:set.available] {
+		available[endpoint.ID] = i
+	}
+
+	hash := ringHash(client)
+	start := sort.Search(len(ring.points), func(i int) bool { return ring.points[i].hash >= hash })
+	for i := range ring.points {
+		point := ring.points[(start+i)%len(ring.points)]
+		if index, ok := available[point.endpointID]; ok {
+			return index
+		}
+	}
+	return -1
+}
+
+// stickyClient identifies a client for sticky routing: its API key when it sends one, so
+// clients behind a shared address are told apart, or else its address
+func stickyClient(consumer analytics.ClientKey) string {
+	if consumer.Key != "" {
+		return "key:" + consumer.Key
+	}
+	return "ip:" + consumer.IP
+}
@@ -0,0 +1,786 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// maxClientSubscriptions bounds the subscriptions one client connection may hold
+	maxClientSubscriptions = 100
+	// wsEndpointCheckInterval is how often an upstream connection's endpoint is checked to
+	// still be in rotation
+	wsEndpointCheckInterval = 5 * time.Second
+	// wsResumeBackoff is the first wait between attempts to reconnect lost subscriptions,
+	// doubling up to wsResumeMaxBackoff
+	wsResumeBackoff    = time.Second
+	wsResumeMaxBackoff = 10 * time.Second
+	// wsResumeTimeout is how long lost subscriptions are retried before their clients are
+	// disconnected
+	wsResumeTimeout = time.Minute
+)
+
+var (
+	errClientClosed         = errors.New("client disconnected")
+	errUpstreamClosed       = errors.New("upstream WebSocket connection closed")
+	errTooManySubscriptions = fmt.Errorf("at most %d subscriptions are allowed per connection", maxClientSubscriptions)
+)
+
+// subscriber receives the notifications of shared subscriptions: a WebSocket client, or the
+// new heads feed of SSE streams
+type subscriber interface {
+	// queue delivers a message without blocking
+	queue(message []byte)
+	// disconnect ends the subscriber once its subscriptions are lost
+	disconnect(code int, reason string)
+	set() *subscriptionSet
+}
+
+// subscriptionSet holds a subscriber's subscriptions by the ID it was given, and closed is
+// set once the subscriber is gone; both are guarded by the subscription hub's mutex
+type subscriptionSet struct {
+	subscriptions map[string]*sharedSubscription
+	closed        bool
+}
+
+func (set *subscriptionSet) set() *subscriptionSet {
+	return set
+}
+
+// upstreamError is a JSON-RPC error returned by the upstream, passed on to clients as is
+type upstreamError struct {
+	*types.JSONRPCError
+}
+
+func (e upstreamError) Error() string {
+	return fmt.Sprintf("upstream returned JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// subscriptionError is the JSON-RPC error a client gets for a failed eth_subscribe
+func subscriptionError(err error) *types.JSONRPCError {
+	var rpcErr upstreamError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.JSONRPCError
+	}
+	return &types.JSONRPCError{Code: -32000, Message: err.Error()}
+}
+
+// subscriptionHub shares eth_subscribe subscriptions between client WebSocket connections.
+// Each chain gets one upstream WebSocket connection, opened on its first subscription and
+// closed after its last, carrying one upstream subscription per distinct eth_subscribe params
+// (newHeads, logs with a given filter, ...). Each notification is fanned out to every client
+// subscribed, under the subscription ID that client was given, so any number of clients
+// following new heads costs one upstream subscription. When the connection fails, its
+// subscriptions are made again on another endpoint under the same client IDs.
+type subscriptionHub struct {
+	server *Server
+
+	// mu guards the maps below, the subscriptions of clients and upstream connections, and
+	// the clients of subscriptions
+	mu        sync.Mutex
+	upstreams map[string]*upstreamConn
+	subs      map[subscriptionKey]*sharedSubscription
+}
+
+type subscriptionKey struct {
+	chainName string
+	params    string
+}
+
+// sharedSubscription is one upstream subscription and the clients it is fanned out to
+type sharedSubscription struct {
+	key      subscriptionKey
+	params   json.RawMessage
+	upstream *upstreamConn
+	// upstreamID is the subscription's ID on the upstream connection
+	upstreamID string
+	// clients are the subscribers, by the subscription ID each was given
+	clients map[string]subscriber
+
+	// ready is closed once the upstream subscription is made, or failed with err
+	ready chan struct{}
+	err   error
+}
+
+// upstreamConn is a chain's WebSocket connection to an upstream, shared by its subscriptions
+type upstreamConn struct {
+	chainName string
+	endpoint  *types.RPCEndpoint
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+
+	// ready is closed once connected, or failed with err
+	ready chan struct{}
+	err   error
+	// closed is closed once the connection is gone
+	closed chan struct{}
+
+	nextID    atomic.Uint64
+	pending   map[uint64]*upstreamCall
+	pendingMu sync.Mutex
+
+	// subs are the subscriptions made on the connection by upstream ID, users counts those
+	// made or being made, and closing is set once the last has ended or the connection was
+	// lost; guarded by the hub's mu, like conn and endpoint until ready is closed
+	subs    map[string]*sharedSubscription
+	users   int
+	closing bool
+}
+
+// upstreamCall is a call on an upstream connection waiting for its reply
+type upstreamCall struct {
+	reply chan upstreamReply
+	// subscription is registered under the ID the reply returns as soon as it is read, so
+	// notifications following it aren't missed
+	subscription *sharedSubscription
+}
+
+type upstreamReply struct {
+	result json.RawMessage
+	err    error
+}
+
+// upstreamMessage is a reply or a notification read from an upstream connection
+type upstreamMessage struct {
+	ID     *uint64             `json:"id"`
+	Method string              `json:"method"`
+	Result json.RawMessage     `json:"result"`
+	Error  *types.JSONRPCError `json:"error"`
+	Params *notificationParams `json:"params"`
+}
+
+type notificationParams struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+type notification struct {
+	Jsonrpc string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  notificationParams `json:"params"`
+}
+
+func newSubscriptionHub(server *Server) *subscriptionHub {
+	return &subscriptionHub{
+		server:    server,
+		upstreams: make(map[string]*upstreamConn),
+		subs:      make(map[subscriptionKey]*sharedSubscription),
+	}
+}
+
+// subscribe joins c to the chain's subscription for params, making it upstream if no other
+// client holds it, and queues the reply to the call with id before any notification. It
+// returns the endpoint serving the subscription.
+func (h *subscriptionHub) subscribe(c subscriber, chainName string, params json.RawMessage, id json.RawMessage) (*types.RPCEndpoint, error) {
+	key := subscriptionKey{chainName: chainName, params: string(params)}
+	set := c.set()
+	for {
+		h.mu.Lock()
+		if set.closed {
+			h.mu.Unlock()
+			return nil, errClientClosed
+		}
+		sub, ok := h.subs[key]
+		if !ok {
+			sub = &sharedSubscription{
+				key:     key,
+				params:  params,
+				clients: make(map[string]subscriber),
+				ready:   make(chan struct{}),
+			}
+			h.subs[key] = sub
+		}
+		h.mu.Unlock()
+
+		if !ok {
+			h.open(sub)
+		}
+		<-sub.ready
+		if sub.err != nil {
+			return nil, sub.err
+		}
+
+		h.mu.Lock()
+		if h.subs[key] != sub {
+			// It ended before this client could join; make it again
+			h.mu.Unlock()
+			continue
+		}
+		if set.closed || len(set.subscriptions) >= maxClientSubscriptions {
+			h.releaseLocked(sub)
+			h.mu.Unlock()
+			if set.closed {
+				return nil, errClientClosed
+			}
+			return nil, errTooManySubscriptions
+		}
+		clientID := newSubscriptionID()
+		sub.clients[clientID] = c
+		set.subscriptions[clientID] = sub
+		c.queue(wsResultMessage(id, clientID))
+		endpoint := sub.upstream.endpoint
+		h.mu.Unlock()
+		return endpoint, nil
+	}
+}
+
+// unsubscribe removes c from its subscription with clientID, reporting whether it had one
+func (h *subscriptionHub) unsubscribe(c subscriber, clientID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	set := c.set()
+	sub, ok := set.subscriptions[clientID]
+	if !ok {
+		return false
+	}
+	delete(set.subscriptions, clientID)
+	delete(sub.clients, clientID)
+	h.releaseLocked(sub)
+	return true
+}
+
+// drop removes a subscriber that is gone from all its subscriptions
+func (h *subscriptionHub) drop(c subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	set := c.set()
+	set.closed = true
+	for clientID, sub := range set.subscriptions {
+		delete(set.subscriptions, clientID)
+		delete(sub.clients, clientID)
+		h.releaseLocked(sub)
+	}
+}
+
+// open makes sub upstream, on the chain's shared connection
+func (h *subscriptionHub) open(sub *sharedSubscription) {
+	up, err := h.connect(sub.key.chainName)
+	if err == nil {
+		h.mu.Lock()
+		sub.upstream = up
+		h.mu.Unlock()
+		_, err = up.call(h.server.httpClient().Timeout, "eth_subscribe", sub.params, sub)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		sub.err = err
+		if h.subs[sub.key] == sub {
+			delete(h.subs, sub.key)
+		}
+		if up != nil {
+			// A reply racing the timeout may have registered it
+			delete(up.subs, sub.upstreamID)
+			h.leaveLocked(up)
+		}
+	}
+	close(sub.ready)
+}
+
+// releaseLocked ends sub upstream once no client is left on it; h.mu must be held
+func (h *subscriptionHub) releaseLocked(sub *sharedSubscription) {
+	if len(sub.clients) > 0 || h.subs[sub.key] != sub {
+		return
+	}
+	delete(h.subs, sub.key)
+	up := sub.upstream
+	// A subscription being resumed has no upstream ID yet; deliver ends it once it has one.
+	// Closing the connection ends its last subscription without a call.
+	if sub.upstreamID != "" {
+		delete(up.subs, sub.upstreamID)
+		if up.users > 1 {
+			go up.call(h.server.httpClient().Timeout, "eth_unsubscribe", wsParams(sub.upstreamID), nil)
+		}
+	}
+	h.leaveLocked(up)
+}
+
+// leaveLocked closes up once no subscription uses it; h.mu must be held
+func (h *subscriptionHub) leaveLocked(up *upstreamConn) {
+	up.users--
+	if up.users > 0 || up.closing {
+		return
+	}
+	if h.upstreams[up.chainName] == up {
+		delete(h.upstreams, up.chainName)
+	}
+	up.closing = true
+	// A connection still being dialed is closed by start
+	if up.conn != nil {
+		go up.close()
+	}
+}
+
+// abandon ends subscriptions that couldn't be resumed, disconnecting their clients
+func (h *subscriptionHub) abandon(subs []*sharedSubscription) {
+	h.mu.Lock()
+	var clients []subscriber
+	for _, sub := range subs {
+		if h.subs[sub.key] != sub {
+			continue
+		}
+		for clientID, c := range sub.clients {
+			delete(c.set().subscriptions, clientID)
+			clients = append(clients, c)
+		}
+		sub.clients = make(map[string]subscriber)
+		h.releaseLocked(sub)
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		c.disconnect(websocket.CloseTryAgainLater, "upstream subscription lost")
+	}
+}
+
+// connect returns the chain's upstream connection, dialing it if there is none, with a use
+// added for the caller
+func (h *subscriptionHub) connect(chainName string) (*upstreamConn, error) {
+	h.mu.Lock()
+	up, ok := h.upstreams[chainName]
+	if !ok {
+		up = newUpstreamConn(chainName)
+		h.upstreams[chainName] = up
+	}
+	up.users++
+	h.mu.Unlock()
+
+	if !ok {
+		up.err = h.start(up)
+		if up.err != nil {
+			h.mu.Lock()
+			if h.upstreams[chainName] == up {
+				delete(h.upstreams, chainName)
+			}
+			h.mu.Unlock()
+		}
+		close(up.ready)
+	}
+
+	// A connection being resumed elsewhere may take a while
+	timer := time.NewTimer(h.server.httpClient().Timeout)
+	defer timer.Stop()
+	select {
+	case <-up.ready:
+	case <-timer.C:
+		h.mu.Lock()
+		h.leaveLocked(up)
+		h.mu.Unlock()
+		return nil, fmt.Errorf("upstream WebSocket connection for chain %s is reconnecting", chainName)
+	}
+	if up.err != nil {
+		return nil, up.err
+	}
+	return up, nil
+}
+
+func newUpstreamConn(chainName string) *upstreamConn {
+	return &upstreamConn{
+		chainName: chainName,
+		ready:     make(chan struct{}),
+		closed:    make(chan struct{}),
+		pending:   make(map[uint64]*upstreamCall),
+		subs:      make(map[string]*sharedSubscription),
+	}
+}
+
+// start dials up and begins reading from it, unless every subscription left meanwhile
+func (h *subscriptionHub) start(up *upstreamConn) error {
+	conn, endpoint, err := h.dial(up.chainName)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	up.conn, up.endpoint = conn, endpoint
+	closing := up.closing
+	h.mu.Unlock()
+	if closing {
+		conn.Close()
+		return errUpstreamClosed
+	}
+
+	log.Printf("Opened shared subscription connection to %s for chain %s", endpoint.SubscriptionURL(), up.chainName)
+	go h.read(up)
+	go h.watch(up)
+	return nil
+}
+
+// dial connects to the first of the chain's healthy endpoints serving subscriptions that
+// accepts, over its WebSocket URL, in the order the chain's load balancing strategy routes
+// requests
+func (h *subscriptionHub) dial(chainName string) (*websocket.Conn, *types.RPCEndpoint, error) {
+	s := h.server
+	table := s.routeTable(chainName)
+	order := s.route(chainName, table, table.subscriptions, "")
+	client := s.httpClient()
+	dialer := webSocketDialer(client)
+
+	lastErr := fmt.Errorf("no healthy WebSocket endpoints available for chain %s", chainName)
+	for i := 0; i < order.len(); i++ {
+		endpoint := order.at(i)
+		var header http.Header
+		if auth := endpoint.Authorization(); auth != "" {
+			header = http.Header{"Authorization": {auth}}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), client.Timeout)
+		conn, resp, err := dialer.DialContext(ctx, endpoint.SubscriptionURL(), header)
+		cancel()
+		if err != nil {
+			if resp != nil {
+				err = fmt.Errorf("websocket handshake returned HTTP %d: %w", resp.StatusCode, err)
+			}
+			log.Printf("Failed to open subscription connection to %s for chain %s: %v", endpoint.SubscriptionURL(), chainName, err)
+			lastErr = fmt.Errorf("failed to connect to %s: %w", endpoint.Name, err)
+			continue
+		}
+
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		return conn, endpoint, nil
+	}
+	return nil, nil, lastErr
+}
+
+// webSocketDialer dials upstream WebSocket connections the way client's transport dials HTTP
+// ones
+func webSocketDialer(client *http.Client) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: client.Timeout,
+	}
+	switch transport := client.Transport.(type) {
+	case interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	}:
+		dialer.NetDialContext = transport.DialContext
+	case *http.Transport:
+		dialer.NetDialContext = transport.DialContext
+	}
+	return dialer
+}
+
+// read dispatches replies and notifications from up until the connection fails
+func (h *subscriptionHub) read(up *upstreamConn) {
+	for {
+		_, data, err := up.conn.ReadMessage()
+		if err != nil {
+			h.lost(up, err)
+			return
+		}
+		up.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var message upstreamMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			continue
+		}
+		if message.Method == "eth_subscription" && message.Params != nil {
+			h.notify(up, message.Params)
+		} else if message.ID != nil {
+			h.deliver(up, *message.ID, message)
+		}
+	}
+}
+
+// deliver hands a reply to the call waiting for it
+func (h *subscriptionHub) deliver(up *upstreamConn, id uint64, message upstreamMessage) {
+	up.pendingMu.Lock()
+	call := up.pending[id]
+	delete(up.pending, id)
+	up.pendingMu.Unlock()
+	if call == nil {
+		return
+	}
+
+	if message.Error != nil {
+		call.reply <- upstreamReply{err: upstreamError{message.Error}}
+		return
+	}
+	if call.subscription != nil {
+		var upstreamID string
+		if err := json.Unmarshal(message.Result, &upstreamID); err != nil || upstreamID == "" {
+			call.reply <- upstreamReply{err: fmt.Errorf("upstream %s returned an invalid subscription ID", up.endpoint.Name)}
+			return
+		}
+		h.mu.Lock()
+		sub := call.subscription
+		live := h.subs[sub.key] == sub && sub.upstream == up
+		if live {
+			sub.upstreamID = upstreamID
+			up.subs[upstreamID] = sub
+		}
+		h.mu.Unlock()
+		if !live {
+			// Its clients left while it was being resumed
+			go up.call(h.server.httpClient().Timeout, "eth_unsubscribe", wsParams(upstreamID), nil)
+		}
+	}
+	call.reply <- upstreamReply{result: message.Result}
+}
+
+// notify fans a notification out to the subscription's clients
+func (h *subscriptionHub) notify(up *upstreamConn, params *notificationParams) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := up.subs[params.Subscription]
+	if sub == nil {
+		return
+	}
+	for clientID, c := range sub.clients {
+		message, err := json.Marshal(notification{
+			Jsonrpc: "2.0",
+			Method:  "eth_subscription",
+			Params:  notificationParams{Subscription: clientID, Result: params.Result},
+		})
+		if err != nil {
+			continue
+		}
+		c.queue(message)
+	}
+}
+
+// lost moves the subscriptions of a failed upstream connection to a new one, which resume
+// dials in the background
+func (h *subscriptionHub) lost(up *upstreamConn, err error) {
+	h.mu.Lock()
+	closing := up.closing
+	up.closing = true
+	up.subs = make(map[string]*sharedSubscription)
+
+	// Subscriptions made on the connection, or being resumed on it, carry over; those still
+	// being made for the first time fail and are reported to their client
+	var subs []*sharedSubscription
+	for _, sub := range h.subs {
+		if sub.upstream == up && isClosed(sub.ready) && sub.err == nil {
+			subs = append(subs, sub)
+		}
+	}
+	var next *upstreamConn
+	if !closing && len(subs) > 0 {
+		next = newUpstreamConn(up.chainName)
+		next.users = len(subs)
+		for _, sub := range subs {
+			sub.upstream, sub.upstreamID = next, ""
+		}
+	}
+	if h.upstreams[up.chainName] == up {
+		if next != nil {
+			h.upstreams[up.chainName] = next
+		} else {
+			delete(h.upstreams, up.chainName)
+		}
+	}
+	h.mu.Unlock()
+
+	close(up.closed)
+	up.conn.Close()
+	if closing {
+		return
+	}
+
+	log.Printf("Shared subscription connection to %s for chain %s lost: %v", up.endpoint.SubscriptionURL(), up.chainName, err)
+	if next != nil {
+		go h.resume(next, subs)
+	}
+}
+
+// resume connects the subscriptions of a lost connection again, to the first healthy
+// WebSocket endpoint that accepts, retrying with backoff for up to wsResumeTimeout. Each
+// subscription is made again upstream and keeps the IDs its clients were given, so clients
+// only miss the notifications sent in between; those that can't be resumed are abandoned.
+func (h *subscriptionHub) resume(up *upstreamConn, subs []*sharedSubscription) {
+	deadline := time.Now().Add(wsResumeTimeout)
+	backoff := wsResumeBackoff
+	for {
+		err := h.start(up)
+		if err == nil {
+			break
+		}
+		h.mu.Lock()
+		if up.closing {
+			err = errUpstreamClosed
+		}
+		h.mu.Unlock()
+		if errors.Is(err, errUpstreamClosed) {
+			// Every client left meanwhile
+			up.err = err
+			close(up.ready)
+			return
+		}
+		if time.Now().After(deadline) {
+			log.Printf("Giving up resuming %d subscriptions for chain %s: %v", len(subs), up.chainName, err)
+			h.mu.Lock()
+			if h.upstreams[up.chainName] == up {
+				delete(h.upstreams, up.chainName)
+			}
+			up.closing = true
+			h.mu.Unlock()
+			up.err = err
+			close(up.ready)
+			h.abandon(subs)
+			return
+		}
+		time.Sleep(backoff)
+		backoff = min(2*backoff, wsResumeMaxBackoff)
+	}
+	close(up.ready)
+
+	resumed := 0
+	for _, sub := range subs {
+		h.mu.Lock()
+		live := h.subs[sub.key] == sub && sub.upstream == up
+		h.mu.Unlock()
+		if !live {
+			continue
+		}
+		_, err := up.call(h.server.httpClient().Timeout, "eth_subscribe", sub.params, sub)
+		if errors.Is(err, errUpstreamClosed) {
+			// Lost again; the next connection resumes the rest
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to resume subscription %s for chain %s on %s: %v", sub.params, up.chainName, up.endpoint.SubscriptionURL(), err)
+			h.abandon([]*sharedSubscription{sub})
+			continue
+		}
+		resumed++
+	}
+	log.Printf("Resumed %d subscriptions for chain %s on %s", resumed, up.chainName, up.endpoint.SubscriptionURL())
+}
+
+// isClosed reports whether ch has been closed
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// call sends a call on up and waits up to timeout for its reply; an eth_subscribe call
+// registers sub under the returned ID
+func (up *upstreamConn) call(timeout time.Duration, method string, params json.RawMessage, sub *sharedSubscription) (json.RawMessage, error) {
+	id := up.nextID.Add(1)
+	call := &upstreamCall{reply: make(chan upstreamReply, 1), subscription: sub}
+	up.pendingMu.Lock()
+	up.pending[id] = call
+	up.pendingMu.Unlock()
+	defer func() {
+		up.pendingMu.Lock()
+		delete(up.pending, id)
+		up.pendingMu.Unlock()
+	}()
+
+	message, err := json.Marshal(struct {
+		Jsonrpc string          `json:"jsonrpc"`
+		ID      uint64          `json:"id"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params"`
+	}{"2.0", id, method, params})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	up.writeMu.Lock()
+	up.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	err = up.conn.WriteMessage(websocket.TextMessage, message)
+	up.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-call.reply:
+		return reply.result, reply.err
+	case <-up.closed:
+		return nil, errUpstreamClosed
+	case <-timer.C:
+		return nil, fmt.Errorf("%s timed out after %v", method, timeout)
+	}
+}
+
+// watch pings the upstream until the connection is gone, and moves its subscriptions to
+// another endpoint once its own leaves rotation, e.g. failing health checks or draining
+func (h *subscriptionHub) watch(up *upstreamConn) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	check := time.NewTicker(wsEndpointCheckInterval)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-ping.C:
+			if err := up.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				up.conn.Close()
+				return
+			}
+		case <-check.C:
+			if h.outOfRotation(up) {
+				log.Printf("Endpoint %s left rotation, moving subscriptions for chain %s", up.endpoint.SubscriptionURL(), up.chainName)
+				up.conn.Close()
+				return
+			}
+		case <-up.closed:
+			return
+		}
+	}
+}
+
+// outOfRotation reports whether up's endpoint no longer takes requests while another endpoint
+// of the chain serving subscriptions does
+func (h *subscriptionHub) outOfRotation(up *upstreamConn) bool {
+	table := h.server.routeTable(up.chainName)
+	available := table.subscriptions.endpoints[:table.subscriptions.available]
+	for _, endpoint := range available {
+		if endpoint.SubscriptionURL() == up.endpoint.SubscriptionURL() {
+			return false
+		}
+	}
+	return len(available) > 0
+}
+
+// close shuts the connection down once its last subscription has ended
+func (up *upstreamConn) close() {
+	up.writeMu.Lock()
+	up.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	up.writeMu.Unlock()
+	log.Printf("Closed shared subscription connection to %s for chain %s", up.endpoint.SubscriptionURL(), up.chainName)
+	up.conn.Close()
+}
+
+// wsParams encodes params of an upstream call
+func wsParams(params ...interface{}) json.RawMessage {
+	encoded, _ := json.Marshal(params)
+	return encoded
+}
+
+// newSubscriptionID returns a random subscription ID for a client, in the format of geth's
+func newSubscriptionID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return "0x" + hex.EncodeToString(id[:])
+}
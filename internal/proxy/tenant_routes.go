@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"net/http"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/types"
+)
+
+// TenantHandler serves a request a tenant makes with its own API key
+type TenantHandler func(w http.ResponseWriter, r *http.Request, t *types.Tenant)
+
+// HandleTenant serves read-only GET requests to path on the proxy listener with handler,
+// for callers presenting a usable tenant API key, subject to the key's restrictions and the
+// tenant's limits. Call it before Handler.
+func (s *Server) HandleTenant(path string, handler TenantHandler) {
+	if s.tenantRoutes == nil {
+		s.tenantRoutes = make(map[string]http.HandlerFunc)
+	}
+	s.tenantRoutes[path] = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		consumer, key, ok := s.authenticateTenant(w, r)
+		if !ok {
+			return
+		}
+
+		limiterKey, limits := s.limitsFor(consumer, key)
+		release, reason, retryAfter := s.limiter.Acquire(limiterKey, limits, 1)
+		if release == nil {
+			kind, message := limitRefusal(reason, limits)
+			s.refusals.Record(key.Tenant.ID, kind, "")
+			setRetryAfter(w, retryAfter)
+			http.Error(w, message, http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
+		handler(w, r, key.Tenant)
+	}
+}
+
+// authenticateTenant returns the caller and the usable tenant key r presents, or writes why
+// there is none
+func (s *Server) authenticateTenant(w http.ResponseWriter, r *http.Request) (analytics.ClientKey, *tenant.Key, bool) {
+	if s.tenants == nil {
+		http.Error(w, "Tenant endpoints require a database", http.StatusServiceUnavailable)
+		return analytics.ClientKey{}, nil, false
+	}
+
+	consumer, key := s.clientKey(r)
+	if key == nil {
+		http.Error(w, "A valid API key is required in the "+clientAPIKeyHeader+" header", http.StatusUnauthorized)
+		return consumer, nil, false
+	}
+	if refusal := keyRefusal(key, s.requestKeyUse(r, consumer, key)); refusal != nil {
+		s.refusals.Record(consumer.TenantID, refusal.kind, refusal.detail)
+		http.Error(w, refusal.message, http.StatusForbidden)
+		return consumer, nil, false
+	}
+	return consumer, key, true
+}
@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// policyErrorCode is the JSON-RPC error code of requests refused by tenant policy
+	policyErrorCode = -32003
+	// methodNotAllowedCode is the JSON-RPC error code of calls to a method outside the
+	// tenant's allowlist ("method not supported" in EIP-1474)
+	methodNotAllowedCode = -32004
+)
+
+// SetTenantRegistry resolves API keys on proxied requests to tenants and enforces their
+// policies; call it before serving traffic
+func (s *Server) SetTenantRegistry(registry *tenant.Registry) {
+	s.tenants = registry
+}
+
+// RefusalTracker returns the counts of requests refused by tenant policy or client limits
+func (s *Server) RefusalTracker() *analytics.RefusalTracker {
+	return s.refusals
+}
+
+// policyRefusal is why a request is refused by tenant policy
+type policyRefusal struct {
+	kind    string
+	detail  string
+	code    int
+	message string
+}
+
+// status is the HTTP status of the refusal: 401 for a key that isn't recognized, 403 for a
+// key that may not make the request
+func (p *policyRefusal) status() int {
+	if p.kind == analytics.RefusalUnknownKey {
+		return http.StatusUnauthorized
+	}
+	return http.StatusForbidden
+}
+
+// noOrigin is the refusal detail of requests without an Origin or Referer
+const noOrigin = "(none)"
+
+// keyUse is where a request presenting an API key comes from
+type keyUse struct {
+	// origin is the request's web origin, see tenant.RequestOrigin
+	origin string
+	ip     string
+	// unknownKey is set when the request sent a key no tenant owns, e.g. one of a deleted tenant
+	unknownKey bool
+}
+
+// requestKeyUse returns where r, made by consumer with tenantKey, comes from
+func (s *Server) requestKeyUse(r *http.Request, consumer analytics.ClientKey, tenantKey *tenant.Key) keyUse {
+	return keyUse{
+		origin:     tenant.RequestOrigin(r.Header.Get("Origin"), r.Header.Get("Referer")),
+		ip:         consumer.IP,
+		unknownKey: s.tenants != nil && consumer.Key != "" && tenantKey == nil,
+	}
+}
+
+// keyRefusal returns why key can't be used at all from use, or nil
+func keyRefusal(key *tenant.Key, use keyUse) *policyRefusal {
+	t := key.Tenant
+
+	switch {
+	case key.Revoked:
+		return &policyRefusal{kind: analytics.RefusalRevokedKey, code: policyErrorCode,
+			message: "API key has been revoked"}
+	case key.Expired():
+		return &policyRefusal{kind: analytics.RefusalRevokedKey, code: policyErrorCode,
+			message: "API key has expired after rotation"}
+	case t.Status != types.TenantActive:
+		return &policyRefusal{kind: analytics.RefusalSuspendedTenant, code: policyErrorCode,
+			message: fmt.Sprintf("Tenant %s is %s", t.Name, t.Status)}
+	case !key.AllowsOrigin(use.origin):
+		detail := use.origin
+		if detail == "" {
+			detail = noOrigin
+		}
+		return &policyRefusal{kind: analytics.RefusalOriginNotAllowed, detail: detail, code: policyErrorCode,
+			message: "API key may not be used from this origin"}
+	case !key.AllowsAddr(use.ip):
+		return &policyRefusal{kind: analytics.RefusalIPNotAllowed, detail: use.ip, code: policyErrorCode,
+			message: "API key may not be used from this address"}
+	}
+	return nil
+}
+
+// tenantPolicy returns why a request made with key from use to chainName must be refused,
+// or nil to serve it. Requests without a key are served as before; a key that isn't
+// recognized is refused, so deleting a tenant doesn't turn its keys anonymous.
+func tenantPolicy(key *tenant.Key, use keyUse, chainName string, calls []rpcCall) *policyRefusal {
+	if key == nil {
+		if use.unknownKey {
+			return &policyRefusal{kind: analytics.RefusalUnknownKey, code: policyErrorCode,
+				message: "API key is not recognized"}
+		}
+		return nil
+	}
+	if refusal := keyRefusal(key, use); refusal != nil {
+		return refusal
+	}
+	t := key.Tenant
+
+	if !key.AllowsChain(chainName) {
+		return &policyRefusal{kind: analytics.RefusalChainNotAllowed, detail: chainName, code: policyErrorCode,
+			message: fmt.Sprintf("Tenant %s may not call chain %s", t.Name, chainName)}
+	}
+
+	if len(key.AllowedMethods()) == 0 {
+		return nil
+	}
+	// A body the sniffer can't read could hide any method, so it is refused rather than
+	// left for the upstream to interpret
+	if len(calls) == 0 {
+		return &policyRefusal{kind: analytics.RefusalMethodNotAllowed, detail: "(invalid)", code: methodNotAllowedCode,
+			message: fmt.Sprintf("Tenant %s may only send well-formed JSON-RPC requests", t.Name)}
+	}
+	for _, call := range calls {
+		if !key.AllowsMethod(call.Method) {
+			return &policyRefusal{kind: analytics.RefusalMethodNotAllowed, detail: call.Method, code: methodNotAllowedCode,
+				message: fmt.Sprintf("Method %s is not allowed for tenant %s", call.Method, t.Name)}
+		}
+	}
+	return nil
+}
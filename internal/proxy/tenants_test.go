@@ -0,0 +1,81 @@
+package proxy_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/proxy"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/types"
+)
+
+func TestUnknownAPIKeyRefused(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer upstream.Close()
+
+	endpoint := &types.RPCEndpoint{ID: 1, Name: "node", URL: upstream.URL, Weight: 1, Healthy: true}
+	chains := map[string]*health.ChainConfig{
+		"ethereum": {Chain: &types.Chain{ID: 1, ChainID: 1, Name: "ethereum", IsEnabled: true}, Endpoints: []*types.RPCEndpoint{endpoint}},
+	}
+	checker := health.NewMultiChainChecker(chains, health.HealthCheckConfig{Interval: time.Minute, Timeout: time.Second})
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: 8080, Mode: config.ServerModeStandard},
+		Proxy:  config.ProxyConfig{Timeout: 30 * time.Second},
+	}
+
+	tests := []struct {
+		name       string
+		registry   bool
+		key        string
+		wantStatus int
+	}{
+		// A key of a deleted tenant is no longer in the registry
+		{"key no tenant owns", true, "rpk_deleted", http.StatusUnauthorized},
+		{"no key", true, "", http.StatusOK},
+		{"key without tenants configured", false, "rpk_anything", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := proxy.NewServer(cfg, checker)
+			if tt.registry {
+				server.SetTenantRegistry(tenant.NewRegistry(nil, nil, nil, nil))
+			}
+			front := httptest.NewServer(server.Handler())
+			defer front.Close()
+
+			req, _ := http.NewRequest("POST", front.URL+"/rpc/ethereum",
+				strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			var reply struct {
+				Error *types.JSONRPCError `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.Error == nil || reply.Error.Code != -32003 {
+				t.Errorf("reply error = %+v (%v), want JSON-RPC error -32003", reply.Error, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"rpc-proxy/internal/app"
+)
+
+// versionPath reports the build a replica runs
+const versionPath = "/version"
+
+// handleVersion returns the version, commit and build date of this binary
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(app.Build())
+}
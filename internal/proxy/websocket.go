@@ -0,0 +1,362 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/types"
+)
+
+const (
+	// wsPingInterval is how often WebSocket connections, to clients and upstreams, are pinged
+	wsPingInterval = 30 * time.Second
+	// wsPongWait is how long a WebSocket peer may stay silent before its connection is dropped
+	wsPongWait = 2 * wsPingInterval
+	// wsWriteTimeout bounds each write to a WebSocket peer
+	wsWriteTimeout = 10 * time.Second
+	// wsMaxMessage bounds the size of a message a client may send, like maxPreallocatedBody
+	wsMaxMessage = maxPreallocatedBody
+	// wsSendBuffer is how many messages may queue for a client; a client further behind is
+	// disconnected rather than holding up notifications to everyone else
+	wsSendBuffer = 256
+	// wsMaxPending bounds the calls a client may have in flight on its connection; reading
+	// stops until one completes
+	wsMaxPending = 32
+)
+
+// wsHandshakeHeaders are the headers of a WebSocket upgrade request left out of the calls
+// forwarded on its behalf. Accept-Encoding is dropped too, since messages are relayed as text,
+// and the request ID, since each call gets its own.
+var wsHandshakeHeaders = []string{
+	"Connection", "Upgrade", "Sec-Websocket-Key", "Sec-Websocket-Version",
+	"Sec-Websocket-Extensions", "Sec-Websocket-Protocol", "Accept-Encoding", requestIDHeader,
+}
+
+var upgrader = websocket.Upgrader{
+	// Any origin may connect, as the CORS headers allow for HTTP
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsClient is a client's WebSocket connection to /rpc/{chainName}
+type wsClient struct {
+	conn      *websocket.Conn
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	subscriptionSet
+}
+
+// queue sends message to the client without blocking, disconnecting a client that fell
+// wsSendBuffer messages behind
+func (c *wsClient) queue(message []byte) {
+	select {
+	case c.send <- message:
+	case <-c.done:
+	default:
+		log.Printf("Disconnecting WebSocket client %s: too far behind", c.conn.RemoteAddr())
+		c.close()
+	}
+}
+
+// disconnect tells the client why its connection ends, then closes it
+func (c *wsClient) disconnect(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(wsWriteTimeout))
+	c.close()
+}
+
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// writeLoop writes queued messages to the client and pings it while it is connected
+func (c *wsClient) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// serveWebSocket upgrades a request to /rpc/{chainName} to a WebSocket connection. Calls sent
+// on it are served like HTTP requests from the same client, with the API key and address of
+// the upgrade request, except eth_subscribe and eth_unsubscribe, which join and leave
+// subscriptions shared with other clients through the subscription hub.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request, chainName string) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered with an HTTP error
+		log.Printf("WebSocket upgrade failed for chain %s: %v", chainName, err)
+		return
+	}
+	if !s.performance {
+		log.Printf("WebSocket client %s connected to chain %s", conn.RemoteAddr(), chainName)
+	}
+
+	c := &wsClient{
+		conn:            conn,
+		send:            make(chan []byte, wsSendBuffer),
+		done:            make(chan struct{}),
+		subscriptionSet: subscriptionSet{subscriptions: make(map[string]*sharedSubscription)},
+	}
+	go c.writeLoop()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	pending := make(chan struct{}, wsMaxPending)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		c.close()
+		wg.Wait()
+		s.subscriptions.drop(c)
+	}()
+
+	conn.SetReadLimit(wsMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) &&
+				!errors.Is(err, net.ErrClosed) && !s.performance {
+				log.Printf("WebSocket client %s of chain %s disconnected: %v", conn.RemoteAddr(), chainName, err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		select {
+		case pending <- struct{}{}:
+		case <-c.done:
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-pending }()
+			s.serveWebSocketCall(ctx, r, c, chainName, message)
+		}()
+	}
+}
+
+// serveWebSocketCall answers one message from a WebSocket client
+func (s *Server) serveWebSocketCall(ctx context.Context, r *http.Request, c *wsClient, chainName string, message []byte) {
+	calls := sniffRPCCalls(message)
+	if len(calls) == 1 && bytes.TrimSpace(message)[0] != '[' {
+		switch calls[0].Method {
+		case "eth_subscribe":
+			s.subscribe(r, c, chainName, message, calls)
+			return
+		case "eth_unsubscribe":
+			s.unsubscribe(r, c, chainName, message, calls)
+			return
+		}
+	}
+
+	// Other calls take the HTTP path, with its limits, routing and failover
+	req := r.Clone(ctx)
+	req.Method = "POST"
+	req.Body = io.NopCloser(bytes.NewReader(message))
+	req.ContentLength = int64(len(message))
+	for _, header := range wsHandshakeHeaders {
+		req.Header.Del(header)
+	}
+
+	resp := &wsResponseWriter{header: make(http.Header)}
+	s.assignRequestID(resp, req)
+	s.handleRPCForChain(resp, req, chainName)
+	if ctx.Err() == nil {
+		c.queue(resp.message())
+	}
+}
+
+// subscribe joins the client to the shared subscription for the call's params
+func (s *Server) subscribe(r *http.Request, c *wsClient, chainName string, message []byte, calls []rpcCall) {
+	start := time.Now()
+	id := calls[0].ID
+	consumer, rpcErr, _ := s.admitSubscription(r, chainName, calls, start)
+	if rpcErr != nil {
+		c.queue(wsErrorMessage(id, rpcErr))
+		return
+	}
+
+	params, err := subscriptionParams(message)
+	if err != nil {
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: err.Error()})
+		c.queue(wsErrorMessage(id, &types.JSONRPCError{Code: -32602, Message: err.Error()}))
+		return
+	}
+
+	endpoint, err := s.subscriptions.subscribe(c, chainName, params, id)
+	if err != nil {
+		if errors.Is(err, errClientClosed) {
+			return
+		}
+		log.Printf("Subscription for chain %s failed: %v", chainName, err)
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: err.Error()})
+		c.queue(wsErrorMessage(id, subscriptionError(err)))
+		return
+	}
+	s.recordRequest(consumer, chainName, calls, start, requestOutcome{success: true, upstream: endpoint.Name, attempts: 1})
+}
+
+// unsubscribe removes the client from one of its subscriptions
+func (s *Server) unsubscribe(r *http.Request, c *wsClient, chainName string, message []byte, calls []rpcCall) {
+	start := time.Now()
+	id := calls[0].ID
+	consumer, rpcErr, _ := s.admitSubscription(r, chainName, calls, start)
+	if rpcErr != nil {
+		c.queue(wsErrorMessage(id, rpcErr))
+		return
+	}
+
+	var req struct {
+		Params []string `json:"params"`
+	}
+	if err := json.Unmarshal(message, &req); err != nil || len(req.Params) != 1 {
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: "invalid params"})
+		c.queue(wsErrorMessage(id, &types.JSONRPCError{Code: -32602, Message: "eth_unsubscribe takes a subscription ID"}))
+		return
+	}
+
+	found := s.subscriptions.unsubscribe(c, req.Params[0])
+	s.recordRequest(consumer, chainName, calls, start, requestOutcome{success: true, upstream: "subscription"})
+	c.queue(wsResultMessage(id, found))
+}
+
+// admitSubscription applies the tenant policy, quota and limits of HTTP requests to a
+// subscription call or SSE stream, returning the error to answer it with if it is refused and
+// the HTTP status an SSE stream answers it with
+func (s *Server) admitSubscription(r *http.Request, chainName string, calls []rpcCall, start time.Time) (analytics.ClientKey, *types.JSONRPCError, int) {
+	consumer, tenantKey := s.clientKey(r)
+	if refusal := tenantPolicy(tenantKey, s.requestKeyUse(r, consumer, tenantKey), chainName, calls); refusal != nil {
+		s.refusals.Record(consumer.TenantID, refusal.kind, refusal.detail)
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: refusal.message, refused: true})
+		return consumer, &types.JSONRPCError{Code: refusal.code, Message: refusal.message}, refusal.status()
+	}
+
+	if tenantKey != nil && tenantKey.QuotaExhausted() {
+		message := quotaMessage(tenantKey.Plan.MonthlyQuota)
+		s.refusals.Record(consumer.TenantID, analytics.RefusalQuotaExceeded, "")
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: message, refused: true})
+		return consumer, &types.JSONRPCError{Code: limitErrorCode, Message: message}, http.StatusTooManyRequests
+	}
+
+	limiterKey, limits := s.limitsFor(consumer, tenantKey)
+	releaseLimit, reason, _ := s.limiter.Acquire(limiterKey, limits, len(calls))
+	if releaseLimit == nil {
+		kind, message := limitRefusal(reason, limits)
+		s.refusals.Record(consumer.TenantID, kind, "")
+		s.recordRequest(consumer, chainName, calls, start, requestOutcome{err: message, refused: true})
+		return consumer, &types.JSONRPCError{Code: limitErrorCode, Message: message}, http.StatusTooManyRequests
+	}
+	releaseLimit()
+
+	if chain := s.config.GetChainByName(chainName); chain == nil || !chain.IsEnabled {
+		outcome := requestOutcome{err: "chain disabled"}
+		if chain == nil {
+			outcome.err = "unknown chain"
+		}
+		s.recordRequest(consumer, chainName, calls, start, outcome)
+		return consumer, s.chainUnavailableError(chainName, chain), http.StatusForbidden
+	}
+	return consumer, nil, http.StatusOK
+}
+
+// subscriptionParams returns the params of an eth_subscribe call re-encoded with sorted object
+// keys, so clients subscribing to the same thing share a subscription however they spell it
+func subscriptionParams(message []byte) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+	var req struct {
+		Params []interface{} `json:"params"`
+	}
+	if err := decoder.Decode(&req); err != nil || len(req.Params) == 0 {
+		return nil, errors.New("eth_subscribe takes a subscription type")
+	}
+	if _, ok := req.Params[0].(string); !ok {
+		return nil, errors.New("eth_subscribe takes a subscription type")
+	}
+	return json.Marshal(req.Params)
+}
+
+// wsResponseWriter collects the response to a call forwarded for a WebSocket client
+type wsResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *wsResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *wsResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *wsResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// message returns the collected JSON-RPC response, turning the plain text of HTTP errors such
+// as "Too many concurrent requests" into a JSON-RPC error
+func (w *wsResponseWriter) message() []byte {
+	body := bytes.TrimSpace(w.body.Bytes())
+	if json.Valid(body) {
+		return body
+	}
+	message := string(body)
+	if message == "" {
+		message = http.StatusText(w.status)
+	}
+	return wsErrorMessage(nil, &types.JSONRPCError{Code: -32000, Message: message})
+}
+
+func wsResultMessage(id json.RawMessage, result interface{}) []byte {
+	message, _ := json.Marshal(types.JSONRPCResponse{Jsonrpc: "2.0", Result: result, ID: id})
+	return message
+}
+
+func wsErrorMessage(id json.RawMessage, rpcErr *types.JSONRPCError) []byte {
+	message, _ := json.Marshal(types.JSONRPCResponse{Jsonrpc: "2.0", Error: rpcErr, ID: id})
+	return message
+}
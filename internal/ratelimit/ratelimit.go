@@ -0,0 +1,177 @@
+// Package ratelimit enforces per-client request rates and concurrency on proxied traffic,
+// keyed by tenant when an API key is presented and by address otherwise.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limits bound one client's traffic; a zero field is unlimited
+type Limits struct {
+	// Rate is the sustained number of JSON-RPC calls per second; each member of a batch counts
+	Rate float64 `json:"rate"`
+	// Burst is how many calls may be made at once above Rate; it defaults to Rate, at least 1
+	Burst int `json:"burst"`
+	// MaxConcurrency bounds the client's requests in flight
+	MaxConcurrency int `json:"maxConcurrency"`
+}
+
+// Overlay returns l with its zero fields taken from defaults
+func (l Limits) Overlay(defaults Limits) Limits {
+	if l.Rate == 0 {
+		l.Rate = defaults.Rate
+	}
+	if l.Burst == 0 {
+		l.Burst = defaults.Burst
+	}
+	if l.MaxConcurrency == 0 {
+		l.MaxConcurrency = defaults.MaxConcurrency
+	}
+	return l
+}
+
+// IsZero reports whether l limits nothing
+func (l Limits) IsZero() bool {
+	return l.Rate == 0 && l.MaxConcurrency == 0
+}
+
+// burst returns the bucket size for l
+func (l Limits) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// Reasons Acquire refuses a request
+const (
+	ReasonRate        = "rate"
+	ReasonConcurrency = "concurrency"
+)
+
+// sweepInterval is how often idle clients are dropped from memory
+const sweepInterval = time.Minute
+
+type client struct {
+	tokens   float64
+	updated  time.Time
+	inFlight int
+	// refilled is when the bucket will be full again, after which forgetting the client
+	// changes nothing
+	refilled time.Time
+}
+
+// Limiter holds a token bucket and in-flight count per client
+type Limiter struct {
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+
+	// shared holds the rate limit buckets instead when set; nil keeps them in memory
+	shared *SharedBuckets
+}
+
+func New() *Limiter {
+	return &Limiter{
+		clients:   make(map[string]*client),
+		lastSweep: time.Now(),
+	}
+}
+
+// Acquire admits a request of calls JSON-RPC calls for the client named key under limits.
+// On success it returns a release func to call when the request completes; otherwise it
+// returns the reason and, for rate refusals, how long until enough tokens are available.
+func (l *Limiter) Acquire(key string, limits Limits, calls int) (release func(), reason string, retryAfter time.Duration) {
+	if limits.IsZero() {
+		return func() {}, "", 0
+	}
+	if calls < 1 {
+		calls = 1
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	l.sweepLocked(now)
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{tokens: limits.burst(), updated: now}
+		l.clients[key] = c
+	}
+
+	if limits.MaxConcurrency > 0 && c.inFlight >= limits.MaxConcurrency {
+		l.mu.Unlock()
+		return nil, ReasonConcurrency, 0
+	}
+
+	if limits.Rate > 0 && l.shared == nil {
+		if ok, wait := c.take(limits, calls, now); !ok {
+			l.mu.Unlock()
+			return nil, ReasonRate, wait
+		}
+	}
+	// The slot is taken before a shared bucket is consulted, so requests waiting on Redis
+	// can't exceed MaxConcurrency
+	c.inFlight++
+	l.mu.Unlock()
+
+	released := false
+	release = func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !released {
+			released = true
+			c.inFlight--
+		}
+	}
+
+	if limits.Rate > 0 && l.shared != nil {
+		ok, wait, err := l.shared.take(key, limits, calls)
+		if err != nil {
+			// Without Redis each replica enforces the limit on its own
+			l.shared.logError(err)
+			l.mu.Lock()
+			ok, wait = c.take(limits, calls, time.Now())
+			l.mu.Unlock()
+		}
+		if !ok {
+			release()
+			return nil, ReasonRate, wait
+		}
+	}
+	return release, "", 0
+}
+
+// take refills the client's bucket and takes calls tokens from it, or returns how long until
+// enough are available; l.mu must be held
+func (c *client) take(limits Limits, calls int, now time.Time) (bool, time.Duration) {
+	burst := limits.burst()
+	c.tokens = math.Min(burst, c.tokens+now.Sub(c.updated).Seconds()*limits.Rate)
+	c.updated = now
+
+	// A batch larger than the burst is admitted from a full bucket and leaves it in debt,
+	// so it is allowed but paid for
+	need := math.Min(float64(calls), burst)
+	if c.tokens < need {
+		return false, time.Duration((need - c.tokens) / limits.Rate * float64(time.Second))
+	}
+	c.tokens -= float64(calls)
+	c.refilled = now.Add(time.Duration((burst - c.tokens) / limits.Rate * float64(time.Second)))
+	return true, 0
+}
+
+// sweepLocked drops clients with nothing in flight whose buckets are full again; l.mu must
+// be held
+func (l *Limiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, c := range l.clients {
+		if c.inFlight == 0 && !now.Before(c.refilled) {
+			delete(l.clients, key)
+		}
+	}
+}
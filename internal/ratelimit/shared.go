@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/redis"
+)
+
+const (
+	// sharedTimeout bounds each Redis command, so an unreachable server only delays a request briefly
+	sharedTimeout = 250 * time.Millisecond
+	// sharedErrorLogInterval is how often Redis failures are logged
+	sharedErrorLogInterval = time.Minute
+)
+
+// takeScript refills and takes from a token bucket stored as a hash, on the server's clock so
+// replicas with skewed clocks agree. It returns 1 and the tokens left, or 0 and the
+// milliseconds until enough are available; numbers are returned as strings since Redis
+// truncates Lua numbers to integers. The bucket expires once it would be full again.
+const takeScript = `
+local rate, burst, calls = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + tonumber(time[2]) / 1000
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) / 1000 * rate)
+local need = math.min(calls, burst)
+if tokens < need then
+  return {0, tostring((need - tokens) / rate * 1000)}
+end
+tokens = tokens - calls
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {1, tostring(tokens)}
+`
+
+// SharedBuckets keeps clients' rate limit buckets in Redis, so replicas enforce one limit
+// between them rather than one each, and a restarted replica doesn't hand out a full bucket
+// to a client that just used it up. Concurrency limits stay per replica.
+type SharedBuckets struct {
+	client *redis.Client
+	prefix string
+
+	lastError time.Time
+	errMu     sync.Mutex
+}
+
+// NewSharedBuckets stores buckets through client under keys starting with prefix
+func NewSharedBuckets(client *redis.Client, prefix string) *SharedBuckets {
+	return &SharedBuckets{client: client, prefix: prefix}
+}
+
+// SetShared keeps rate limit buckets in shared; call it before serving traffic
+func (l *Limiter) SetShared(shared *SharedBuckets) {
+	l.shared = shared
+}
+
+// take takes calls tokens from the client's bucket, or returns how long until enough are
+// available
+func (b *SharedBuckets) take(key string, limits Limits, calls int) (bool, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedTimeout)
+	defer cancel()
+
+	reply, err := b.client.Do(ctx, "EVAL", takeScript, "1", b.prefix+":ratelimit:"+key,
+		strconv.FormatFloat(limits.Rate, 'f', -1, 64),
+		strconv.FormatFloat(limits.burst(), 'f', -1, 64),
+		strconv.Itoa(calls))
+	if err != nil {
+		return false, 0, err
+	}
+	values, _ := reply.([]interface{})
+	if len(values) != 2 {
+		return false, 0, fmt.Errorf("malformed rate limit script reply")
+	}
+	admitted, _ := values[0].(int64)
+	value, _ := values[1].(string)
+	if admitted == 1 {
+		return true, 0, nil
+	}
+	wait, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("malformed rate limit script reply: %w", err)
+	}
+	return false, time.Duration(wait * float64(time.Millisecond)), nil
+}
+
+// logError logs a failed Redis command, at most once per sharedErrorLogInterval so an
+// outage doesn't flood the log
+func (b *SharedBuckets) logError(err error) {
+	b.errMu.Lock()
+	defer b.errMu.Unlock()
+
+	if time.Since(b.lastError) < sharedErrorLogInterval {
+		return
+	}
+	b.lastError = time.Now()
+	log.Printf("Shared rate limits unavailable, limiting locally: %v", err)
+}
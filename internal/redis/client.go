@@ -0,0 +1,262 @@
+// Package redis is a minimal Redis client speaking RESP over TCP, covering the few commands
+// the proxy uses to share state between replicas
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTimeout bounds a command when the context has no deadline
+	defaultTimeout = 5 * time.Second
+	// maxIdleConns is how many connections are kept open between commands
+	maxIdleConns = 8
+)
+
+// Error is an error reply from the server, e.g. WRONGTYPE; the connection stays usable
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Options address a Redis server
+type Options struct {
+	Address  string
+	Username string
+	Password string
+	DB       int
+	TLS      bool
+}
+
+// ParseURL reads redis://[[user]:password@]host[:port][/db], or rediss:// for TLS
+func ParseURL(rawURL string) (Options, error) {
+	var opts Options
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return opts, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		opts.TLS = true
+	default:
+		return opts, fmt.Errorf("redis URL must start with redis:// or rediss://")
+	}
+	if u.Hostname() == "" {
+		return opts, fmt.Errorf("redis URL must name a host")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+	opts.Address = net.JoinHostPort(u.Hostname(), port)
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		opts.DB, err = strconv.Atoi(db)
+		if err != nil || opts.DB < 0 {
+			return opts, fmt.Errorf("redis URL database must be a non-negative number")
+		}
+	}
+	return opts, nil
+}
+
+// Client runs commands on a small pool of connections; it is safe for concurrent use
+type Client struct {
+	opts Options
+	idle chan *conn
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// New returns a client for the server at rawURL, see ParseURL; connections are opened on
+// first use
+func New(rawURL string) (*Client, error) {
+	opts, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{opts: opts, idle: make(chan *conn, maxIdleConns)}, nil
+}
+
+// Address returns the server's host:port
+func (c *Client) Address() string {
+	return c.opts.Address
+}
+
+// Do runs one command and returns its reply: a string for simple and bulk strings, an int64
+// for integers, a []interface{} for arrays, nil for a nil reply, or an Error
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	cn.SetDeadline(deadline)
+
+	reply, err := cn.do(args)
+	if err != nil {
+		if _, isReply := err.(Error); !isReply {
+			cn.Close()
+			return nil, err
+		}
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Close closes the idle connections; commands in flight finish on their own
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+	var netConn net.Conn
+	var err error
+	if c.opts.TLS {
+		host, _, _ := net.SplitHostPort(c.opts.Address)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		netConn, err = tlsDialer.DialContext(ctx, "tcp", c.opts.Address)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", c.opts.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", c.opts.Address, err)
+	}
+	cn := &conn{Conn: netConn, r: bufio.NewReader(netConn)}
+	netConn.SetDeadline(time.Now().Add(defaultTimeout))
+
+	if c.opts.Password != "" {
+		auth := []string{"AUTH", c.opts.Password}
+		if c.opts.Username != "" {
+			auth = []string{"AUTH", c.opts.Username, c.opts.Password}
+		}
+		if _, err := cn.do(auth); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis authentication failed: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.do([]string{"SELECT", strconv.Itoa(c.opts.DB)}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("failed to select redis database %d: %w", c.opts.DB, err)
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// do writes a command as an array of bulk strings and reads the reply
+func (cn *conn) do(args []string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(cn, b.String()); err != nil {
+		return nil, fmt.Errorf("failed to write redis command: %w", err)
+	}
+	return cn.readReply()
+}
+
+func (cn *conn) readReply() (interface{}, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redis reply: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("malformed redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis integer reply: %w", err)
+		}
+		return n, nil
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis bulk reply: %w", err)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(cn.r, buf); err != nil {
+			return nil, fmt.Errorf("failed to read redis reply: %w", err)
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis array reply: %w", err)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			// An error inside an array is an element, not a failed command
+			item, err := cn.readReply()
+			if err != nil {
+				if replyErr, ok := err.(Error); ok {
+					items[i] = replyErr
+					continue
+				}
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply type %q", line[0])
+	}
+}
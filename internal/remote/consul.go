@@ -0,0 +1,106 @@
+package remote
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// consulStore reads a Consul KV prefix and waits for changes with blocking queries
+type consulStore struct {
+	client  *http.Client
+	address string
+	prefix  string
+	token   string
+}
+
+type consulKV struct {
+	Key   string  `json:"Key"`
+	Value *string `json:"Value"` // base64, null for folders
+}
+
+func (s *consulStore) Name() string {
+	return fmt.Sprintf("consul %s/%s", s.address, s.prefix)
+}
+
+func (s *consulStore) List(ctx context.Context) (map[string]string, uint64, error) {
+	entries, index, err := s.get(ctx, url.Values{"recurse": {"true"}})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.Value == nil || strings.HasSuffix(entry.Key, "/") {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(*entry.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode consul key %s: %w", entry.Key, err)
+		}
+		kvs[strings.TrimPrefix(entry.Key, s.prefix)] = string(value)
+	}
+
+	return kvs, index, nil
+}
+
+func (s *consulStore) Wait(ctx context.Context, index uint64) (uint64, error) {
+	_, next, err := s.get(ctx, url.Values{
+		"recurse": {"true"},
+		"keys":    {"true"},
+		"index":   {strconv.FormatUint(index, 10)},
+		"wait":    {fmt.Sprintf("%ds", int(maxWait.Seconds()))},
+	})
+	if err != nil {
+		return index, err
+	}
+
+	// Consul may reset the index; anything lower than before is a change too
+	return next, nil
+}
+
+// get queries the prefix; a missing prefix is an empty listing, not an error
+func (s *consulStore) get(ctx context.Context, query url.Values) ([]consulKV, uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.address+"/v1/kv/"+s.prefix+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create consul request: %w", err)
+	}
+	if s.token != "" {
+		req.Header.Set("X-Consul-Token", s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	index, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, index, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, 0, fmt.Errorf("consul returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// With keys=true Consul returns bare key names, which Wait discards
+	if query.Get("keys") == "true" {
+		return nil, index, nil
+	}
+
+	var entries []consulKV
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode consul response: %w", err)
+	}
+
+	return entries, index, nil
+}
@@ -0,0 +1,180 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etcdStore reads an etcd v3 key prefix through the JSON gateway and waits for changes with a
+// watch starting after the last listed revision
+type etcdStore struct {
+	client  *http.Client
+	address string
+	prefix  string
+	token   string
+}
+
+// etcdHeader carries the store revision; the gateway encodes 64-bit integers as strings
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+func (s *etcdStore) Name() string {
+	return fmt.Sprintf("etcd %s/%s", s.address, s.prefix)
+}
+
+func (s *etcdStore) List(ctx context.Context) (map[string]string, uint64, error) {
+	var resp struct {
+		Header etcdHeader `json:"header"`
+		KVs    []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := s.post(ctx, "/v3/kv/range", s.rangeRequest(nil), &resp); err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make(map[string]string, len(resp.KVs))
+	for _, kv := range resp.KVs {
+		key, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode etcd key: %w", err)
+		}
+		value, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode etcd key %s: %w", key, err)
+		}
+		kvs[strings.TrimPrefix(string(key), s.prefix)] = string(value)
+	}
+
+	revision, _ := strconv.ParseUint(resp.Header.Revision, 10, 64)
+	return kvs, revision, nil
+}
+
+func (s *etcdStore) Wait(ctx context.Context, index uint64) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	body := map[string]interface{}{
+		"create_request": s.rangeRequest(map[string]interface{}{
+			"start_revision": strconv.FormatUint(index+1, 10),
+		}),
+	}
+	resp, err := s.do(ctx, "/v3/watch", body)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return index, nil
+		}
+		return index, err
+	}
+	defer resp.Body.Close()
+
+	// The watch streams one JSON object per message: first the creation acknowledgement, then
+	// batches of events
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message struct {
+			Result struct {
+				Header   etcdHeader        `json:"header"`
+				Events   []json.RawMessage `json:"events"`
+				Canceled bool              `json:"canceled"`
+				Reason   string            `json:"cancel_reason"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return index, nil
+			}
+			return index, fmt.Errorf("etcd watch failed: %w", err)
+		}
+
+		if message.Error != nil {
+			return index, fmt.Errorf("etcd watch failed: %s", message.Error.Message)
+		}
+		if message.Result.Canceled {
+			// Typically a compacted start revision; listing again picks up the current state
+			return 0, fmt.Errorf("etcd watch canceled: %s", message.Result.Reason)
+		}
+		if len(message.Result.Events) > 0 {
+			revision, _ := strconv.ParseUint(message.Result.Header.Revision, 10, 64)
+			return revision, nil
+		}
+	}
+}
+
+// rangeRequest selects every key under the prefix, plus any extra fields
+func (s *etcdStore) rangeRequest(extra map[string]interface{}) map[string]interface{} {
+	request := map[string]interface{}{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixEnd(s.prefix)),
+	}
+	for key, value := range extra {
+		request[key] = value
+	}
+	return request
+}
+
+// prefixEnd is the smallest key greater than every key starting with prefix
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
+
+func (s *etcdStore) post(ctx context.Context, path string, body interface{}, result interface{}) error {
+	resp, err := s.do(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	return nil
+}
+
+func (s *etcdStore) do(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode etcd request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.address+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("etcd request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("etcd returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return resp, nil
+}
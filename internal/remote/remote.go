@@ -0,0 +1,66 @@
+// Package remote reads configuration from a key prefix in Consul or etcd using their HTTP APIs
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported backends
+const (
+	BackendConsul = "consul"
+	BackendEtcd   = "etcd"
+)
+
+// maxWait bounds a single Wait call so dead connections are noticed and replaced
+const maxWait = 5 * time.Minute
+
+// Store is a key prefix in a remote key-value store. Keys are returned relative to the prefix.
+type Store interface {
+	// List returns every key under the prefix along with the store's current index
+	List(ctx context.Context) (map[string]string, uint64, error)
+	// Wait blocks until something under the prefix changes after index, or until an internal
+	// timeout, and returns the new index; an unchanged index means nothing changed
+	Wait(ctx context.Context, index uint64) (uint64, error)
+	// Name describes the store in logs, e.g. consul://127.0.0.1:8500/rpc-proxy/
+	Name() string
+}
+
+// Config selects and addresses a remote store
+type Config struct {
+	Backend string // consul or etcd
+	Address string // base URL; defaults to the backend's local agent
+	Prefix  string // key prefix, e.g. rpc-proxy
+	Token   string // ACL token (Consul) or auth token (etcd), optional
+}
+
+// New returns the store described by config
+func New(config Config) (Store, error) {
+	prefix := strings.Trim(config.Prefix, "/")
+	if prefix == "" {
+		return nil, fmt.Errorf("remote config prefix is required")
+	}
+	prefix += "/"
+
+	client := &http.Client{Timeout: maxWait + 30*time.Second}
+
+	switch config.Backend {
+	case BackendConsul:
+		address := config.Address
+		if address == "" {
+			address = "http://127.0.0.1:8500"
+		}
+		return &consulStore{client: client, address: strings.TrimRight(address, "/"), prefix: prefix, token: config.Token}, nil
+	case BackendEtcd:
+		address := config.Address
+		if address == "" {
+			address = "http://127.0.0.1:2379"
+		}
+		return &etcdStore{client: client, address: strings.TrimRight(address, "/"), prefix: prefix, token: config.Token}, nil
+	default:
+		return nil, fmt.Errorf("unsupported remote config backend %q, must be %s or %s", config.Backend, BackendConsul, BackendEtcd)
+	}
+}
@@ -0,0 +1,180 @@
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	db *database.GormDB
+}
+
+func NewAPIKeyRepository(db *database.GormDB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) GetAll() ([]*types.APIKey, error) {
+	return r.find(r.db.DB.Order("id ASC"))
+}
+
+func (r *APIKeyRepository) GetByTenant(tenantID int) ([]*types.APIKey, error) {
+	return r.find(r.db.DB.Where("tenant_id = ?", tenantID).Order("id ASC"))
+}
+
+func (r *APIKeyRepository) find(query *gorm.DB) ([]*types.APIKey, error) {
+	var rows []models.APIKey
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+
+	result := make([]*types.APIKey, len(rows))
+	for i := range rows {
+		result[i] = r.modelToType(&rows[i])
+	}
+	return result, nil
+}
+
+func (r *APIKeyRepository) Create(key *types.APIKey) error {
+	model := &models.APIKey{
+		TenantID: uint(key.TenantID),
+		Name:     key.Name,
+		Prefix:   key.Prefix,
+		KeyHash:  key.KeyHash,
+
+		AllowedOrigins: strings.Join(key.AllowedOrigins, ","),
+		AllowedCIDRs:   strings.Join(key.AllowedCIDRs, ","),
+	}
+	if err := r.db.DB.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	key.ID = int(model.ID)
+	key.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (r *APIKeyRepository) Revoke(tenantID, id int) (*types.APIKey, error) {
+	var model models.APIKey
+	if err := r.db.DB.Where("id = ? AND tenant_id = ?", id, tenantID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("API key with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if model.RevokedAt == nil {
+		now := time.Now()
+		model.RevokedAt = &now
+		if err := r.db.DB.Model(&model).Update("revoked_at", now).Error; err != nil {
+			return nil, fmt.Errorf("failed to revoke API key: %w", err)
+		}
+	}
+
+	return r.modelToType(&model), nil
+}
+
+func (r *APIKeyRepository) Rotate(tenantID, id int, replacement *types.APIKey, expiresAt time.Time) (*types.APIKey, error) {
+	var rotated models.APIKey
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND tenant_id = ?", id, tenantID).First(&rotated).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("API key with ID %d not found", id)
+			}
+			return fmt.Errorf("failed to get API key: %w", err)
+		}
+
+		// The key must be neither rotated nor revoked; checking that in the update itself
+		// lets only one of two concurrent rotations set the expiry and create a replacement
+		result := tx.Model(&models.APIKey{}).
+			Where("id = ? AND tenant_id = ? AND expires_at IS NULL AND revoked_at IS NULL", id, tenantID).
+			Update("expires_at", expiresAt)
+		if result.Error != nil {
+			return fmt.Errorf("failed to set API key expiry: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("API key with ID %d: %w", id, repository.ErrKeyNotRotatable)
+		}
+		rotated.ExpiresAt = &expiresAt
+
+		model := &models.APIKey{
+			TenantID: uint(tenantID),
+			Name:     rotated.Name,
+			Prefix:   replacement.Prefix,
+			KeyHash:  replacement.KeyHash,
+
+			AllowedOrigins: rotated.AllowedOrigins,
+			AllowedCIDRs:   rotated.AllowedCIDRs,
+		}
+		if err := tx.Create(model).Error; err != nil {
+			return fmt.Errorf("failed to create API key: %w", err)
+		}
+		*replacement = *r.modelToType(model)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.modelToType(&rotated), nil
+}
+
+func (r *APIKeyRepository) SetRestrictions(tenantID, id int, origins, cidrs []string) (*types.APIKey, error) {
+	var model models.APIKey
+	if err := r.db.DB.Where("id = ? AND tenant_id = ?", id, tenantID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("API key with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	model.AllowedOrigins = strings.Join(origins, ",")
+	model.AllowedCIDRs = strings.Join(cidrs, ",")
+	err := r.db.DB.Model(&model).Updates(map[string]interface{}{
+		"allowed_origins": model.AllowedOrigins,
+		"allowed_cidrs":   model.AllowedCIDRs,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update API key restrictions: %w", err)
+	}
+
+	return r.modelToType(&model), nil
+}
+
+func (r *APIKeyRepository) RevokeExpired(now time.Time) (int64, error) {
+	result := r.db.DB.Model(&models.APIKey{}).
+		Where("revoked_at IS NULL AND expires_at IS NOT NULL AND expires_at <= ?", now).
+		Update("revoked_at", gorm.Expr("expires_at"))
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke expired API keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *APIKeyRepository) modelToType(m *models.APIKey) *types.APIKey {
+	key := &types.APIKey{
+		ID:        int(m.ID),
+		TenantID:  int(m.TenantID),
+		Name:      m.Name,
+		Prefix:    m.Prefix,
+		KeyHash:   m.KeyHash,
+		CreatedAt: m.CreatedAt,
+		RevokedAt: m.RevokedAt,
+		ExpiresAt: m.ExpiresAt,
+	}
+	if m.AllowedOrigins != "" {
+		key.AllowedOrigins = strings.Split(m.AllowedOrigins, ",")
+	}
+	if m.AllowedCIDRs != "" {
+		key.AllowedCIDRs = strings.Split(m.AllowedCIDRs, ",")
+	}
+	return key
+}
@@ -0,0 +1,174 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyUsageRepository struct {
+	db *database.GormDB
+}
+
+func NewAPIKeyUsageRepository(db *database.GormDB) *APIKeyUsageRepository {
+	return &APIKeyUsageRepository{db: db}
+}
+
+func (r *APIKeyUsageRepository) AddUsage(ctx context.Context, usage []*types.APIKeyUsage, buckets []*types.UsageBucket) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, u := range usage {
+			var existing models.APIKeyUsage
+			err := tx.Where("api_key_id = ? AND day = ?", u.APIKeyID, u.Day).First(&existing).Error
+
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(r.typeToModel(u)).Error; err != nil {
+					return fmt.Errorf("failed to create API key usage: %w", err)
+				}
+			case err != nil:
+				return fmt.Errorf("failed to load API key usage: %w", err)
+			default:
+				existing.Requests += u.Requests
+				existing.Errors += u.Errors
+				existing.ComputeUnits += u.ComputeUnits
+				if err := tx.Save(&existing).Error; err != nil {
+					return fmt.Errorf("failed to update API key usage: %w", err)
+				}
+			}
+		}
+
+		for _, b := range buckets {
+			var existing models.UsageBucket
+			err := tx.Where("tenant_id = ? AND day = ? AND chain_name = ? AND method = ?",
+				b.TenantID, b.Day, b.ChainName, b.Method).First(&existing).Error
+
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(r.bucketToModel(b)).Error; err != nil {
+					return fmt.Errorf("failed to create usage bucket: %w", err)
+				}
+			case err != nil:
+				return fmt.Errorf("failed to load usage bucket: %w", err)
+			default:
+				existing.Requests += b.Requests
+				existing.Errors += b.Errors
+				existing.ComputeUnits += b.ComputeUnits
+				if err := tx.Save(&existing).Error; err != nil {
+					return fmt.Errorf("failed to update usage bucket: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *APIKeyUsageRepository) ComputeUnitsByTenant(from, to time.Time) (map[int]int64, error) {
+	var rows []struct {
+		TenantID     int
+		ComputeUnits int64
+	}
+	err := r.db.DB.Model(&models.APIKeyUsage{}).
+		Select("tenant_id, SUM(compute_units) AS compute_units").
+		Where("day >= ? AND day <= ?", from, to).
+		Group("tenant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum compute units: %w", err)
+	}
+
+	result := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		result[row.TenantID] = row.ComputeUnits
+	}
+	return result, nil
+}
+
+func (r *APIKeyUsageRepository) GetRange(keyID, tenantID int, from, to time.Time) ([]*types.APIKeyUsage, error) {
+	query := r.db.DB.Where("day >= ? AND day <= ?", from, to)
+	if keyID != 0 {
+		query = query.Where("api_key_id = ?", keyID)
+	}
+	if tenantID != 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+
+	var rows []*models.APIKeyUsage
+	if err := query.Order("day ASC, api_key_id ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API key usage: %w", err)
+	}
+
+	result := make([]*types.APIKeyUsage, len(rows))
+	for i, row := range rows {
+		result[i] = r.modelToType(row)
+	}
+	return result, nil
+}
+
+func (r *APIKeyUsageRepository) GetBuckets(tenantID int, from, to time.Time) ([]*types.UsageBucket, error) {
+	query := r.db.DB.Where("day >= ? AND day <= ?", from, to)
+	if tenantID != 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+
+	var rows []*models.UsageBucket
+	if err := query.Order("day ASC, tenant_id ASC, chain_name ASC, method ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get usage buckets: %w", err)
+	}
+
+	result := make([]*types.UsageBucket, len(rows))
+	for i, row := range rows {
+		result[i] = &types.UsageBucket{
+			ID:           int(row.ID),
+			TenantID:     int(row.TenantID),
+			Day:          row.Day,
+			ChainName:    row.ChainName,
+			Method:       row.Method,
+			Requests:     row.Requests,
+			Errors:       row.Errors,
+			ComputeUnits: row.ComputeUnits,
+		}
+	}
+	return result, nil
+}
+
+func (r *APIKeyUsageRepository) modelToType(m *models.APIKeyUsage) *types.APIKeyUsage {
+	return &types.APIKeyUsage{
+		ID:           int(m.ID),
+		APIKeyID:     int(m.APIKeyID),
+		TenantID:     int(m.TenantID),
+		Day:          m.Day,
+		Requests:     m.Requests,
+		Errors:       m.Errors,
+		ComputeUnits: m.ComputeUnits,
+	}
+}
+
+func (r *APIKeyUsageRepository) typeToModel(t *types.APIKeyUsage) *models.APIKeyUsage {
+	return &models.APIKeyUsage{
+		APIKeyID:     uint(t.APIKeyID),
+		TenantID:     uint(t.TenantID),
+		Day:          t.Day,
+		Requests:     t.Requests,
+		Errors:       t.Errors,
+		ComputeUnits: t.ComputeUnits,
+	}
+}
+
+func (r *APIKeyUsageRepository) bucketToModel(b *types.UsageBucket) *models.UsageBucket {
+	return &models.UsageBucket{
+		TenantID:     uint(b.TenantID),
+		Day:          b.Day,
+		ChainName:    b.ChainName,
+		Method:       b.Method,
+		Requests:     b.Requests,
+		Errors:       b.Errors,
+		ComputeUnits: b.ComputeUnits,
+	}
+}
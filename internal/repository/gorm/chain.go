@@ -16,9 +16,10 @@ func NewChainRepository(db *database.GormDB) *ChainRepository {
 	return &ChainRepository{db: db}
 }
 
+// GetAll returns every chain, disabled ones included, so they can be listed and re-enabled
 func (r *ChainRepository) GetAll() ([]*types.Chain, error) {
 	var chains []*models.Chain
-	if err := r.db.DB.Where("is_enabled = ?", true).Find(&chains).Error; err != nil {
+	if err := r.db.DB.Order("id ASC").Find(&chains).Error; err != nil {
 		return nil, fmt.Errorf("failed to get all chains: %w", err)
 	}
 
@@ -76,6 +77,7 @@ func (r *ChainRepository) Update(chain *types.Chain) error {
 	return nil
 }
 
+// Delete moves a chain to the trash; its endpoints and configs are kept so Restore brings it back whole
 func (r *ChainRepository) Delete(id int) error {
 	if err := r.db.DB.Delete(&models.Chain{}, id).Error; err != nil {
 		return fmt.Errorf("failed to delete chain with id %d: %w", id, err)
@@ -84,6 +86,53 @@ func (r *ChainRepository) Delete(id int) error {
 	return nil
 }
 
+// GetDeleted returns trashed chains, most recently deleted first
+func (r *ChainRepository) GetDeleted() ([]*types.Chain, error) {
+	var chains []*models.Chain
+	if err := r.db.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&chains).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted chains: %w", err)
+	}
+
+	result := make([]*types.Chain, len(chains))
+	for i, chain := range chains {
+		result[i] = r.modelToType(chain)
+	}
+
+	return result, nil
+}
+
+func (r *ChainRepository) GetDeletedByName(name string) (*types.Chain, error) {
+	var chain models.Chain
+	if err := r.db.DB.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", name).First(&chain).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted chain by name %s: %w", name, err)
+	}
+
+	return r.modelToType(&chain), nil
+}
+
+// Restore takes a chain out of the trash
+func (r *ChainRepository) Restore(id int) error {
+	result := r.db.DB.Unscoped().Model(&models.Chain{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("failed to restore chain with id %d: %w", id, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("deleted chain with id %d not found", id)
+	}
+
+	return nil
+}
+
+// Purge permanently removes a chain, live or trashed; endpoints and configs go with it via ON DELETE CASCADE
+func (r *ChainRepository) Purge(id int) error {
+	if err := r.db.DB.Unscoped().Delete(&models.Chain{}, id).Error; err != nil {
+		return fmt.Errorf("failed to purge chain with id %d: %w", id, err)
+	}
+
+	return nil
+}
+
 func (r *ChainRepository) modelToType(m *models.Chain) *types.Chain {
 	if m == nil {
 		return nil
@@ -99,8 +148,10 @@ func (r *ChainRepository) modelToType(m *models.Chain) *types.Chain {
 		IsEnabled:            m.IsEnabled,
 		NativeCurrencySymbol: m.NativeCurrencySymbol,
 		BlockExplorerURL:     m.BlockExplorerURL,
+		Type:                 m.Type,
 		CreatedAt:            m.CreatedAt,
 		UpdatedAt:            m.UpdatedAt,
+		DeletedAt:            deletedAtPtr(m.DeletedAt),
 	}
 }
 
@@ -119,6 +170,7 @@ func (r *ChainRepository) typeToModel(t *types.Chain) *models.Chain {
 		IsEnabled:            t.IsEnabled,
 		NativeCurrencySymbol: t.NativeCurrencySymbol,
 		BlockExplorerURL:     t.BlockExplorerURL,
+		Type:                 t.GetType(),
 		CreatedAt:            t.CreatedAt,
 		UpdatedAt:            t.UpdatedAt,
 	}
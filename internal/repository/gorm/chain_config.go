@@ -78,6 +78,19 @@ func (r *ChainConfigRepository) SetConfig(chainID int, configKey, configValue, d
 		Description: description,
 	}
 
+	// Update the existing row for this key, if any, instead of inserting a duplicate
+	var existing models.ChainConfig
+	if err := r.db.DB.Where("chain_id = ? AND config_key = ?", chainID, configKey).Limit(1).Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to look up config %s for chain_id %d: %w", configKey, chainID, err)
+	}
+	if existing.ID != 0 {
+		config.ID = existing.ID
+		config.CreatedAt = existing.CreatedAt
+		if description == "" {
+			config.Description = existing.Description
+		}
+	}
+
 	if err := r.db.DB.Save(config).Error; err != nil {
 		return fmt.Errorf("failed to set config %s for chain_id %d: %w", configKey, chainID, err)
 	}
@@ -0,0 +1,399 @@
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// configDocumentVersion is bumped when the export format changes incompatibly
+const configDocumentVersion = 1
+
+// errDryRun rolls back a dry-run import after its changes have been computed
+var errDryRun = errors.New("dry run")
+
+type ConfigDocumentRepository struct {
+	db *database.GormDB
+}
+
+func NewConfigDocumentRepository(db *database.GormDB) *ConfigDocumentRepository {
+	return &ConfigDocumentRepository{db: db}
+}
+
+// Export reads every chain (enabled or not) with its configs and endpoints, plus all settings
+func (r *ConfigDocumentRepository) Export() (*repository.ConfigDocument, error) {
+	var chains []*models.Chain
+	if err := r.db.DB.Preload("ChainConfigs").Preload("RPCEndpoints").Order("id ASC").Find(&chains).Error; err != nil {
+		return nil, fmt.Errorf("failed to export chains: %w", err)
+	}
+
+	var settings []*models.Setting
+	if err := r.db.DB.Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to export settings: %w", err)
+	}
+
+	doc := &repository.ConfigDocument{
+		Version:  configDocumentVersion,
+		Chains:   make([]repository.ConfigDocumentChain, 0, len(chains)),
+		Settings: make(map[string]string, len(settings)),
+	}
+
+	for _, chain := range chains {
+		docChain := repository.ConfigDocumentChain{
+			ChainID:              chain.ChainID,
+			Name:                 chain.Name,
+			DisplayName:          chain.DisplayName,
+			RPCPath:              chain.RPCPath,
+			IsTestnet:            chain.IsTestnet,
+			IsEnabled:            chain.IsEnabled,
+			NativeCurrencySymbol: chain.NativeCurrencySymbol,
+			BlockExplorerURL:     chain.BlockExplorerURL,
+			Type:                 chain.Type,
+			Configs:              make(map[string]string, len(chain.ChainConfigs)),
+			Endpoints:            make([]repository.ConfigDocumentEndpoint, 0, len(chain.RPCEndpoints)),
+		}
+		for _, cfg := range chain.ChainConfigs {
+			docChain.Configs[cfg.ConfigKey] = cfg.ConfigValue
+		}
+		for i := range chain.RPCEndpoints {
+			endpoint := &chain.RPCEndpoints[i]
+			docChain.Endpoints = append(docChain.Endpoints, repository.ConfigDocumentEndpoint{
+				Name:      endpoint.Name,
+				URL:       endpoint.URL,
+				Transport: endpoint.Transport,
+				WSURL:     endpoint.WSURL,
+				Weight:    endpoint.Weight,
+				Enabled:   endpoint.Enabled,
+
+				GraphQLURL:   endpoint.GraphQLURL,
+				Capabilities: splitCapabilities(endpoint.Capabilities),
+				AuthUsername: endpoint.AuthUsername,
+				AuthPassword: endpoint.AuthPassword,
+			})
+		}
+		sort.Slice(docChain.Endpoints, func(a, b int) bool {
+			return docChain.Endpoints[a].URL < docChain.Endpoints[b].URL
+		})
+		doc.Chains = append(doc.Chains, docChain)
+	}
+
+	for _, setting := range settings {
+		doc.Settings[setting.Key] = setting.Value
+	}
+
+	return doc, nil
+}
+
+// Import applies a document in a single transaction and returns the changes made; chains are
+// matched by name and endpoints by URL within their chain
+func (r *ConfigDocumentRepository) Import(doc *repository.ConfigDocument, opts repository.ImportOptions) ([]repository.ImportChange, error) {
+	var changes []repository.ImportChange
+
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		changes = nil
+
+		// Trashed chains are loaded too: importing one restores it instead of colliding on its name
+		var existingChains []*models.Chain
+		if err := tx.Unscoped().Find(&existingChains).Error; err != nil {
+			return fmt.Errorf("failed to load chains: %w", err)
+		}
+		chainsByName := make(map[string]*models.Chain, len(existingChains))
+		for _, chain := range existingChains {
+			chainsByName[chain.Name] = chain
+		}
+
+		for i := range doc.Chains {
+			docChain := &doc.Chains[i]
+			chain, exists := chainsByName[docChain.Name]
+			delete(chainsByName, docChain.Name)
+
+			if exists && chain.DeletedAt.Valid {
+				if err := tx.Unscoped().Model(chain).Update("deleted_at", nil).Error; err != nil {
+					return fmt.Errorf("failed to restore chain %s: %w", chain.Name, err)
+				}
+				chain.DeletedAt = gorm.DeletedAt{}
+				changes = append(changes, repository.ImportChange{Action: "restore", Kind: "chain", Chain: chain.Name, Key: chain.Name})
+			}
+
+			chainChanges, err := importChain(tx, chain, exists, docChain, opts.Prune)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, chainChanges...)
+		}
+
+		if opts.Prune {
+			for _, chain := range existingChains {
+				if _, remaining := chainsByName[chain.Name]; !remaining || chain.DeletedAt.Valid {
+					continue
+				}
+				if err := tx.Delete(&models.Chain{}, chain.ID).Error; err != nil {
+					return fmt.Errorf("failed to delete chain %s: %w", chain.Name, err)
+				}
+				changes = append(changes, repository.ImportChange{Action: "delete", Kind: "chain", Chain: chain.Name, Key: chain.Name})
+			}
+		}
+
+		settingChanges, err := importSettings(tx, doc.Settings, opts.PruneSettings)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, settingChanges...)
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, fmt.Errorf("failed to import configuration: %w", err)
+	}
+	if changes == nil {
+		changes = []repository.ImportChange{}
+	}
+
+	return changes, nil
+}
+
+// importChain creates or updates one chain, then reconciles its configs and endpoints
+func importChain(tx *gorm.DB, chain *models.Chain, exists bool, docChain *repository.ConfigDocumentChain, prune bool) ([]repository.ImportChange, error) {
+	var changes []repository.ImportChange
+
+	if !exists {
+		chain = &models.Chain{}
+	}
+	before := *chain
+	chain.ChainID = docChain.ChainID
+	chain.Name = docChain.Name
+	chain.DisplayName = docChain.DisplayName
+	chain.RPCPath = docChain.RPCPath
+	chain.IsTestnet = docChain.IsTestnet
+	chain.IsEnabled = docChain.IsEnabled
+	chain.NativeCurrencySymbol = docChain.NativeCurrencySymbol
+	chain.BlockExplorerURL = docChain.BlockExplorerURL
+	chain.Type = docChain.Type
+
+	switch {
+	case !exists:
+		if err := tx.Create(chain).Error; err != nil {
+			return nil, fmt.Errorf("failed to create chain %s: %w", chain.Name, err)
+		}
+		// A false IsEnabled is skipped on insert in favour of the column default
+		if !chain.IsEnabled {
+			if err := tx.Model(chain).Update("is_enabled", false).Error; err != nil {
+				return nil, fmt.Errorf("failed to disable chain %s: %w", chain.Name, err)
+			}
+		}
+		changes = append(changes, repository.ImportChange{Action: "create", Kind: "chain", Chain: chain.Name, Key: chain.Name})
+	case chainModelChanged(&before, chain):
+		// Select("*") so false/empty values are written too
+		if err := tx.Model(chain).Select("*").Omit("CreatedAt").Updates(chain).Error; err != nil {
+			return nil, fmt.Errorf("failed to update chain %s: %w", chain.Name, err)
+		}
+		changes = append(changes, repository.ImportChange{Action: "update", Kind: "chain", Chain: chain.Name, Key: chain.Name})
+	}
+
+	configChanges, err := importChainConfigs(tx, chain, docChain.Configs, prune)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, configChanges...)
+
+	endpointChanges, err := importEndpoints(tx, chain, docChain.Endpoints, prune)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, endpointChanges...)
+
+	return changes, nil
+}
+
+func importChainConfigs(tx *gorm.DB, chain *models.Chain, configs map[string]string, prune bool) ([]repository.ImportChange, error) {
+	var changes []repository.ImportChange
+
+	var existing []*models.ChainConfig
+	if err := tx.Where("chain_id = ?", chain.ID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to load configs for chain %s: %w", chain.Name, err)
+	}
+	byKey := make(map[string]*models.ChainConfig, len(existing))
+	for _, cfg := range existing {
+		byKey[cfg.ConfigKey] = cfg
+	}
+
+	keys := make([]string, 0, len(configs))
+	for key := range configs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := configs[key]
+		cfg, exists := byKey[key]
+		delete(byKey, key)
+
+		switch {
+		case !exists:
+			cfg = &models.ChainConfig{ChainID: chain.ID, ConfigKey: key, ConfigValue: value}
+			if err := tx.Create(cfg).Error; err != nil {
+				return nil, fmt.Errorf("failed to create config %s for chain %s: %w", key, chain.Name, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "create", Kind: "chain_config", Chain: chain.Name, Key: key})
+		case cfg.ConfigValue != value:
+			if err := tx.Model(cfg).Update("config_value", value).Error; err != nil {
+				return nil, fmt.Errorf("failed to update config %s for chain %s: %w", key, chain.Name, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "update", Kind: "chain_config", Chain: chain.Name, Key: key})
+		}
+	}
+
+	if prune {
+		for key, cfg := range byKey {
+			if err := tx.Delete(cfg).Error; err != nil {
+				return nil, fmt.Errorf("failed to delete config %s for chain %s: %w", key, chain.Name, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "delete", Kind: "chain_config", Chain: chain.Name, Key: key})
+		}
+	}
+
+	return changes, nil
+}
+
+func importEndpoints(tx *gorm.DB, chain *models.Chain, endpoints []repository.ConfigDocumentEndpoint, prune bool) ([]repository.ImportChange, error) {
+	var changes []repository.ImportChange
+
+	var existing []*models.RPCEndpoint
+	if err := tx.Where("chain_id = ?", chain.ID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to load endpoints for chain %s: %w", chain.Name, err)
+	}
+	byURL := make(map[string]*models.RPCEndpoint, len(existing))
+	for _, endpoint := range existing {
+		byURL[endpoint.URL] = endpoint
+	}
+
+	for _, docEndpoint := range endpoints {
+		endpoint, exists := byURL[docEndpoint.URL]
+		delete(byURL, docEndpoint.URL)
+
+		switch {
+		case !exists:
+			endpoint = &models.RPCEndpoint{
+				Name:      docEndpoint.Name,
+				URL:       docEndpoint.URL,
+				Transport: docEndpoint.Transport,
+				WSURL:     docEndpoint.WSURL,
+				Weight:    docEndpoint.Weight,
+				Enabled:   docEndpoint.Enabled,
+				ChainID:   chain.ID,
+
+				GraphQLURL:   docEndpoint.GraphQLURL,
+				Capabilities: strings.Join(docEndpoint.Capabilities, ","),
+				AuthUsername: docEndpoint.AuthUsername,
+				AuthPassword: docEndpoint.AuthPassword,
+			}
+			if err := tx.Create(endpoint).Error; err != nil {
+				return nil, fmt.Errorf("failed to create endpoint %s for chain %s: %w", docEndpoint.URL, chain.Name, err)
+			}
+			if !endpoint.Enabled {
+				if err := tx.Model(endpoint).Update("enabled", false).Error; err != nil {
+					return nil, fmt.Errorf("failed to disable endpoint %s for chain %s: %w", docEndpoint.URL, chain.Name, err)
+				}
+			}
+			changes = append(changes, repository.ImportChange{Action: "create", Kind: "endpoint", Chain: chain.Name, Key: docEndpoint.URL})
+		case endpoint.Name != docEndpoint.Name || endpoint.Weight != docEndpoint.Weight || endpoint.Enabled != docEndpoint.Enabled ||
+			endpoint.Transport != docEndpoint.Transport || endpoint.WSURL != docEndpoint.WSURL || endpoint.GraphQLURL != docEndpoint.GraphQLURL ||
+			endpoint.Capabilities != strings.Join(docEndpoint.Capabilities, ",") ||
+			endpoint.AuthUsername != docEndpoint.AuthUsername || endpoint.AuthPassword != docEndpoint.AuthPassword:
+			updates := map[string]interface{}{
+				"name":          docEndpoint.Name,
+				"transport":     docEndpoint.Transport,
+				"ws_url":        docEndpoint.WSURL,
+				"graphql_url":   docEndpoint.GraphQLURL,
+				"capabilities":  strings.Join(docEndpoint.Capabilities, ","),
+				"weight":        docEndpoint.Weight,
+				"enabled":       docEndpoint.Enabled,
+				"auth_username": docEndpoint.AuthUsername,
+				"auth_password": docEndpoint.AuthPassword,
+			}
+			if err := tx.Model(endpoint).Updates(updates).Error; err != nil {
+				return nil, fmt.Errorf("failed to update endpoint %s for chain %s: %w", docEndpoint.URL, chain.Name, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "update", Kind: "endpoint", Chain: chain.Name, Key: docEndpoint.URL})
+		}
+	}
+
+	if prune {
+		for url, endpoint := range byURL {
+			if err := tx.Delete(&models.RPCEndpoint{}, endpoint.ID).Error; err != nil {
+				return nil, fmt.Errorf("failed to delete endpoint %s for chain %s: %w", url, chain.Name, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "delete", Kind: "endpoint", Chain: chain.Name, Key: url})
+		}
+	}
+
+	return changes, nil
+}
+
+func importSettings(tx *gorm.DB, settings map[string]string, prune bool) ([]repository.ImportChange, error) {
+	var changes []repository.ImportChange
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := settings[key]
+
+		var setting models.Setting
+		err := tx.Where(clause.Eq{Column: "key", Value: key}).First(&setting).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&models.Setting{Key: key, Value: value}).Error; err != nil {
+				return nil, fmt.Errorf("failed to create setting %s: %w", key, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "create", Kind: "setting", Key: key})
+		case err != nil:
+			return nil, fmt.Errorf("failed to load setting %s: %w", key, err)
+		case setting.Value != value:
+			if err := tx.Model(&setting).Update("value", value).Error; err != nil {
+				return nil, fmt.Errorf("failed to update setting %s: %w", key, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "update", Kind: "setting", Key: key})
+		}
+	}
+
+	if prune {
+		var existing []*models.Setting
+		if err := tx.Find(&existing).Error; err != nil {
+			return nil, fmt.Errorf("failed to load settings: %w", err)
+		}
+		for _, setting := range existing {
+			if _, keep := settings[setting.Key]; keep {
+				continue
+			}
+			if err := tx.Delete(setting).Error; err != nil {
+				return nil, fmt.Errorf("failed to delete setting %s: %w", setting.Key, err)
+			}
+			changes = append(changes, repository.ImportChange{Action: "delete", Kind: "setting", Key: setting.Key})
+		}
+	}
+
+	return changes, nil
+}
+
+func chainModelChanged(a, b *models.Chain) bool {
+	return a.ChainID != b.ChainID || a.DisplayName != b.DisplayName || a.RPCPath != b.RPCPath ||
+		a.IsTestnet != b.IsTestnet || a.IsEnabled != b.IsEnabled ||
+		a.NativeCurrencySymbol != b.NativeCurrencySymbol || a.BlockExplorerURL != b.BlockExplorerURL ||
+		a.Type != b.Type
+}
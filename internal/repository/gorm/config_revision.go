@@ -0,0 +1,112 @@
+package gorm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type ConfigRevisionRepository struct {
+	db *database.GormDB
+}
+
+func NewConfigRevisionRepository(db *database.GormDB) *ConfigRevisionRepository {
+	return &ConfigRevisionRepository{db: db}
+}
+
+func (r *ConfigRevisionRepository) Create(revision *repository.ConfigRevision) error {
+	document, err := json.Marshal(revision.Document)
+	if err != nil {
+		return fmt.Errorf("failed to encode config revision: %w", err)
+	}
+
+	model := &models.ConfigRevision{
+		Action:   revision.Action,
+		Changes:  revision.Changes,
+		Document: string(document),
+	}
+	if err := r.db.DB.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create config revision: %w", err)
+	}
+
+	revision.ID = int(model.ID)
+	revision.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (r *ConfigRevisionRepository) GetByID(id int) (*repository.ConfigRevision, error) {
+	var model models.ConfigRevision
+	if err := r.db.DB.First(&model, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("config revision %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get config revision: %w", err)
+	}
+
+	return r.modelToRevision(&model)
+}
+
+func (r *ConfigRevisionRepository) Latest() (*repository.ConfigRevision, error) {
+	return r.first(r.db.DB.Order("id DESC"))
+}
+
+func (r *ConfigRevisionRepository) Previous(id int) (*repository.ConfigRevision, error) {
+	return r.first(r.db.DB.Where("id < ?", id).Order("id DESC"))
+}
+
+func (r *ConfigRevisionRepository) List(limit int) ([]*repository.ConfigRevision, error) {
+	query := r.db.DB.Model(&models.ConfigRevision{}).Omit("document").Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var rows []models.ConfigRevision
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list config revisions: %w", err)
+	}
+
+	result := make([]*repository.ConfigRevision, len(rows))
+	for i := range rows {
+		result[i] = &repository.ConfigRevision{
+			ID:        int(rows[i].ID),
+			CreatedAt: rows[i].CreatedAt,
+			Action:    rows[i].Action,
+			Changes:   rows[i].Changes,
+		}
+	}
+	return result, nil
+}
+
+// first returns the first revision matched by query, or nil when there is none
+func (r *ConfigRevisionRepository) first(query *gorm.DB) (*repository.ConfigRevision, error) {
+	var rows []models.ConfigRevision
+	if err := query.Limit(1).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get config revision: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return r.modelToRevision(&rows[0])
+}
+
+func (r *ConfigRevisionRepository) modelToRevision(m *models.ConfigRevision) (*repository.ConfigRevision, error) {
+	var doc repository.ConfigDocument
+	if err := json.Unmarshal([]byte(m.Document), &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode config revision %d: %w", m.ID, err)
+	}
+
+	return &repository.ConfigRevision{
+		ID:        int(m.ID),
+		CreatedAt: m.CreatedAt,
+		Action:    m.Action,
+		Changes:   m.Changes,
+		Document:  &doc,
+	}, nil
+}
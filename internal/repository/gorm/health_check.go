@@ -1,12 +1,15 @@
 package gorm
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"rpc-proxy/internal/database"
 	"rpc-proxy/internal/models"
 	"rpc-proxy/internal/repository"
+
+	"gorm.io/gorm"
 )
 
 type healthCheckRepository struct {
@@ -33,6 +36,29 @@ func (r *healthCheckRepository) Create(req *repository.CreateHealthCheckRequest)
 	return nil
 }
 
+func (r *healthCheckRepository) CreateBatch(ctx context.Context, reqs []*repository.CreateHealthCheckRequest) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	healthChecks := make([]models.HealthCheck, len(reqs))
+	for i, req := range reqs {
+		healthChecks[i] = models.HealthCheck{
+			EndpointID:     uint(req.EndpointID),
+			Healthy:        req.Healthy,
+			ResponseTimeMs: req.ResponseTimeMs,
+			BlockNumber:    req.BlockNumber,
+			ErrorMessage:   req.ErrorMessage,
+		}
+	}
+
+	if err := r.db.WithContext(ctx).CreateInBatches(&healthChecks, len(healthChecks)).Error; err != nil {
+		return fmt.Errorf("failed to create health checks: %w", err)
+	}
+
+	return nil
+}
+
 func (r *healthCheckRepository) GetByEndpointID(endpointID int, limit int) ([]*repository.HealthCheck, error) {
 	var healthChecks []models.HealthCheck
 	if err := r.db.Where("endpoint_id = ?", endpointID).
@@ -56,10 +82,10 @@ func (r *healthCheckRepository) GetLatestByEndpointID(endpointID int) (*reposito
 	return r.modelToRepo(&healthCheck), nil
 }
 
-func (r *healthCheckRepository) DeleteOldRecords(days int) error {
+func (r *healthCheckRepository) DeleteOldRecords(ctx context.Context, days int) error {
 	cutoffDate := time.Now().AddDate(0, 0, -days)
 	
-	result := r.db.Where("checked_at < ?", cutoffDate).Delete(&models.HealthCheck{})
+	result := r.db.WithContext(ctx).Where("checked_at < ?", cutoffDate).Delete(&models.HealthCheck{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete old health check records: %w", result.Error)
 	}
@@ -71,6 +97,89 @@ func (r *healthCheckRepository) DeleteOldRecords(days int) error {
 	return nil
 }
 
+// DownsampleOldRecords collapses health checks older than the given number of days into one
+// aggregate row per endpoint per hour. Already-aggregated hours are left untouched.
+func (r *healthCheckRepository) DownsampleOldRecords(ctx context.Context, days int) error {
+	cutoffDate := time.Now().AddDate(0, 0, -days)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Bound the work to rows that exist now so the new aggregates aren't deleted below
+		var maxID uint
+		if err := tx.Model(&models.HealthCheck{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+			return fmt.Errorf("failed to get max health check id: %w", err)
+		}
+
+		bucket := hourBucketSQL(tx, "checked_at")
+		insertQuery := `
+			INSERT INTO health_checks (endpoint_id, healthy, response_time_ms, block_number, error_message, checked_at, sample_count)
+			SELECT endpoint_id,
+			       SUM(CASE WHEN healthy THEN sample_count ELSE 0 END) * 2 >= SUM(sample_count),
+			       SUM(response_time_ms * sample_count) / SUM(sample_count),
+			       ` + maxBlockNumberSQL(tx, "block_number") + `,
+			       '',
+			       ` + bucket + `,
+			       SUM(sample_count)
+			FROM health_checks
+			WHERE checked_at < ? AND id <= ?
+			GROUP BY endpoint_id, ` + bucket + `
+			HAVING COUNT(*) > 1
+		`
+		inserted := tx.Exec(insertQuery, cutoffDate, maxID)
+		if inserted.Error != nil {
+			return fmt.Errorf("failed to aggregate old health checks: %w", inserted.Error)
+		}
+		if inserted.RowsAffected == 0 {
+			return nil
+		}
+
+		buckets := `
+			SELECT endpoint_id, ` + bucket + ` AS bucket
+			FROM health_checks
+			WHERE checked_at < ? AND id <= ?
+			GROUP BY endpoint_id, ` + bucket + `
+			HAVING COUNT(*) > 1
+		`
+		deleteQuery := `
+			DELETE FROM health_checks h
+			USING (` + buckets + `) b
+			WHERE h.endpoint_id = b.endpoint_id AND ` + hourBucketSQL(tx, "h.checked_at") + ` = b.bucket AND h.id <= ?
+		`
+		if tx.Dialector.Name() == database.DriverMySQL {
+			deleteQuery = `
+				DELETE h FROM health_checks h
+				JOIN (` + buckets + `) b
+				ON h.endpoint_id = b.endpoint_id AND ` + hourBucketSQL(tx, "h.checked_at") + ` = b.bucket
+				WHERE h.id <= ?
+			`
+		}
+		deleted := tx.Exec(deleteQuery, cutoffDate, maxID, maxID)
+		if deleted.Error != nil {
+			return fmt.Errorf("failed to delete downsampled health checks: %w", deleted.Error)
+		}
+
+		fmt.Printf("Downsampled %d health check records into %d hourly aggregates\n", deleted.RowsAffected, inserted.RowsAffected)
+		return nil
+	})
+}
+
+// maxBlockNumberSQL aggregates the highest block number of a varchar column in the connection's
+// dialect. Decimal block numbers are compared as numbers, since as strings "999" sorts above
+// "1000"; groups holding none, such as the hex numbers of legacy checks, fall back to MAX.
+func maxBlockNumberSQL(db *gorm.DB, column string) string {
+	if db.Dialector.Name() == database.DriverMySQL {
+		return "COALESCE(CAST(MAX(CASE WHEN " + column + " REGEXP '^[0-9]+$' THEN CAST(" + column + " AS DECIMAL(20,0)) END) AS CHAR), MAX(" + column + "))"
+	}
+	return "COALESCE(CAST(MAX(CASE WHEN " + column + " ~ '^[0-9]+$' THEN CAST(" + column + " AS NUMERIC) END) AS VARCHAR), MAX(" + column + "))"
+}
+
+// hourBucketSQL truncates a timestamp column to the start of its hour in the connection's dialect
+func hourBucketSQL(db *gorm.DB, column string) string {
+	if db.Dialector.Name() == database.DriverMySQL {
+		return "DATE_FORMAT(" + column + ", '%Y-%m-%d %H:00:00')"
+	}
+	return "date_trunc('hour', " + column + ")"
+}
+
 // Helper methods to convert between models and repository types
 func (r *healthCheckRepository) modelToRepo(model *models.HealthCheck) *repository.HealthCheck {
 	return &repository.HealthCheck{
@@ -81,13 +190,14 @@ func (r *healthCheckRepository) modelToRepo(model *models.HealthCheck) *reposito
 		BlockNumber:    model.BlockNumber,
 		ErrorMessage:   model.ErrorMessage,
 		CheckedAt:      model.CheckedAt.Format(time.RFC3339),
+		SampleCount:    model.SampleCount,
 	}
 }
 
 func (r *healthCheckRepository) modelsToRepo(models []models.HealthCheck) []*repository.HealthCheck {
 	results := make([]*repository.HealthCheck, len(models))
-	for i, model := range models {
-		results[i] = r.modelToRepo(&model)
+	for i := range models {
+		results[i] = r.modelToRepo(&models[i])
 	}
 	return results
 }
\ No newline at end of file
@@ -0,0 +1,204 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+type HealthRollupRepository struct {
+	db *database.GormDB
+}
+
+func NewHealthRollupRepository(db *database.GormDB) *HealthRollupRepository {
+	return &HealthRollupRepository{db: db}
+}
+
+func (r *HealthRollupRepository) LastPeriodStart(granularity string) (time.Time, error) {
+	model, err := rollupModel(granularity)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var rows []time.Time
+	if err := r.db.DB.Model(model).Order("period_start DESC").Limit(1).Pluck("period_start", &rows).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last health check rollup: %w", err)
+	}
+	if len(rows) == 0 {
+		return time.Time{}, nil
+	}
+	return rows[0].UTC(), nil
+}
+
+func (r *HealthRollupRepository) FirstCheckTime() (time.Time, error) {
+	var rows []time.Time
+	if err := r.db.DB.Model(&models.HealthCheck{}).Order("checked_at ASC").Limit(1).Pluck("checked_at", &rows).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to get oldest health check: %w", err)
+	}
+	if len(rows) == 0 {
+		return time.Time{}, nil
+	}
+	return rows[0].UTC(), nil
+}
+
+// healthCheckSample is the part of a raw health check a rollup needs
+type healthCheckSample struct {
+	EndpointID     uint
+	Healthy        bool
+	ResponseTimeMs int64
+	SampleCount    int
+}
+
+// Rollup summarizes the period in Go rather than SQL so the p95 works the same on PostgreSQL
+// and MySQL. Downsampled rows count as SampleCount checks at their average latency. Latency
+// figures cover healthy checks only, since a failed check's response time is a timeout or
+// error rather than a measurement.
+func (r *HealthRollupRepository) Rollup(ctx context.Context, granularity string, start time.Time) (int, error) {
+	model, err := rollupModel(granularity)
+	if err != nil {
+		return 0, err
+	}
+	end := start.Add(types.RollupPeriod(granularity))
+
+	var samples []healthCheckSample
+	if err := r.db.DB.WithContext(ctx).Model(&models.HealthCheck{}).
+		Select("endpoint_id, healthy, response_time_ms, sample_count").
+		Where("checked_at >= ? AND checked_at < ?", start, end).
+		Find(&samples).Error; err != nil {
+		return 0, fmt.Errorf("failed to read health checks: %w", err)
+	}
+
+	byEndpoint := make(map[uint][]healthCheckSample)
+	for _, sample := range samples {
+		byEndpoint[sample.EndpointID] = append(byEndpoint[sample.EndpointID], sample)
+	}
+
+	rollups := make([]models.HealthCheckHourlyRollup, 0, len(byEndpoint))
+	for endpointID, endpointSamples := range byEndpoint {
+		rollups = append(rollups, summarizeHealthChecks(endpointID, start, endpointSamples))
+	}
+
+	err = r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("period_start = ?", start).Delete(model).Error; err != nil {
+			return err
+		}
+		for _, rollup := range rollups {
+			var row interface{} = &rollup
+			if granularity == types.RollupDaily {
+				daily := models.HealthCheckDailyRollup(rollup)
+				row = &daily
+			}
+			if err := tx.Create(row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save health check rollups: %w", err)
+	}
+
+	return len(rollups), nil
+}
+
+func (r *HealthRollupRepository) GetSince(granularity string, endpointID int, since time.Time) ([]*types.HealthCheckRollup, error) {
+	if _, err := rollupModel(granularity); err != nil {
+		return nil, err
+	}
+
+	query := r.db.DB.Where("period_start >= ?", since)
+	if endpointID != 0 {
+		query = query.Where("endpoint_id = ?", endpointID)
+	}
+	query = query.Order("period_start ASC, endpoint_id ASC")
+
+	var rows []models.HealthCheckHourlyRollup
+	if granularity == types.RollupDaily {
+		var daily []models.HealthCheckDailyRollup
+		if err := query.Find(&daily).Error; err != nil {
+			return nil, fmt.Errorf("failed to get health check rollups: %w", err)
+		}
+		for _, row := range daily {
+			rows = append(rows, models.HealthCheckHourlyRollup(row))
+		}
+	} else if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get health check rollups: %w", err)
+	}
+
+	result := make([]*types.HealthCheckRollup, len(rows))
+	for i := range rows {
+		result[i] = r.modelToType(granularity, &rows[i])
+	}
+	return result, nil
+}
+
+// summarizeHealthChecks computes one endpoint's rollup for a period
+func summarizeHealthChecks(endpointID uint, start time.Time, samples []healthCheckSample) models.HealthCheckHourlyRollup {
+	rollup := models.HealthCheckHourlyRollup{EndpointID: endpointID, PeriodStart: start}
+
+	var healthy []healthCheckSample
+	var totalLatency int64
+	for _, sample := range samples {
+		count := int64(sample.SampleCount)
+		if count < 1 {
+			count = 1
+		}
+		rollup.Checks += count
+		if sample.Healthy {
+			rollup.HealthyChecks += count
+			totalLatency += sample.ResponseTimeMs * count
+			healthy = append(healthy, sample)
+		}
+	}
+	if rollup.HealthyChecks == 0 {
+		return rollup
+	}
+	rollup.AvgResponseTimeMs = float64(totalLatency) / float64(rollup.HealthyChecks)
+
+	// Nearest-rank p95 over the healthy checks, each row weighted by its sample count
+	sort.Slice(healthy, func(i, j int) bool { return healthy[i].ResponseTimeMs < healthy[j].ResponseTimeMs })
+	rank := (rollup.HealthyChecks*95 + 99) / 100
+	var seen int64
+	for _, sample := range healthy {
+		seen += int64(max(sample.SampleCount, 1))
+		if seen >= rank {
+			rollup.P95ResponseTimeMs = sample.ResponseTimeMs
+			break
+		}
+	}
+	return rollup
+}
+
+func rollupModel(granularity string) (interface{}, error) {
+	switch granularity {
+	case types.RollupHourly:
+		return &models.HealthCheckHourlyRollup{}, nil
+	case types.RollupDaily:
+		return &models.HealthCheckDailyRollup{}, nil
+	default:
+		return nil, fmt.Errorf("unknown rollup granularity %q", granularity)
+	}
+}
+
+func (r *HealthRollupRepository) modelToType(granularity string, m *models.HealthCheckHourlyRollup) *types.HealthCheckRollup {
+	rollup := &types.HealthCheckRollup{
+		EndpointID:        int(m.EndpointID),
+		Granularity:       granularity,
+		PeriodStart:       m.PeriodStart,
+		Checks:            m.Checks,
+		HealthyChecks:     m.HealthyChecks,
+		AvgResponseTimeMs: m.AvgResponseTimeMs,
+		P95ResponseTimeMs: m.P95ResponseTimeMs,
+	}
+	if m.Checks > 0 {
+		rollup.UptimePercent = float64(m.HealthyChecks) * 100 / float64(m.Checks)
+	}
+	return rollup
+}
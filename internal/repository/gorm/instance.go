@@ -0,0 +1,93 @@
+package gorm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+)
+
+type InstanceRepository struct {
+	db *database.GormDB
+}
+
+func NewInstanceRepository(db *database.GormDB) *InstanceRepository {
+	return &InstanceRepository{db: db}
+}
+
+func (r *InstanceRepository) Upsert(instance *types.Instance) error {
+	model, err := r.typeToModel(instance)
+	if err != nil {
+		return err
+	}
+	// Save updates the row by primary key and inserts it when there is none yet
+	if err := r.db.DB.Save(model).Error; err != nil {
+		return fmt.Errorf("failed to register instance: %w", err)
+	}
+	return nil
+}
+
+func (r *InstanceRepository) GetAll() ([]*types.Instance, error) {
+	var rows []models.Instance
+	if err := r.db.DB.Order("started_at DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get instances: %w", err)
+	}
+
+	instances := make([]*types.Instance, 0, len(rows))
+	for i := range rows {
+		instances = append(instances, r.modelToType(&rows[i]))
+	}
+	return instances, nil
+}
+
+func (r *InstanceRepository) Delete(id string) error {
+	if err := r.db.DB.Delete(&models.Instance{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to deregister instance: %w", err)
+	}
+	return nil
+}
+
+func (r *InstanceRepository) DeleteStale(before time.Time) (int64, error) {
+	result := r.db.DB.Where("last_seen < ?", before).Delete(&models.Instance{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete stale instances: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *InstanceRepository) typeToModel(i *types.Instance) (*models.Instance, error) {
+	stats, err := json.Marshal(i.Stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode instance stats: %w", err)
+	}
+	return &models.Instance{
+		ID:        i.ID,
+		Hostname:  i.Hostname,
+		Version:   i.Version,
+		StartedAt: i.StartedAt,
+		LastSeen:  i.LastSeen,
+		Chains:    strings.Join(i.Chains, ","),
+		Stats:     string(stats),
+	}, nil
+}
+
+func (r *InstanceRepository) modelToType(m *models.Instance) *types.Instance {
+	i := &types.Instance{
+		ID:        m.ID,
+		Hostname:  m.Hostname,
+		Version:   m.Version,
+		StartedAt: m.StartedAt,
+		LastSeen:  m.LastSeen,
+		Chains:    []string{},
+	}
+	if m.Chains != "" {
+		i.Chains = strings.Split(m.Chains, ",")
+	}
+	// Stats written by another version may not decode fully; what does decode is kept
+	json.Unmarshal([]byte(m.Stats), &i.Stats)
+	return i
+}
@@ -0,0 +1,88 @@
+package gorm
+
+import (
+	"fmt"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+type MaintenanceWindowRepository struct {
+	db *database.GormDB
+}
+
+func NewMaintenanceWindowRepository(db *database.GormDB) *MaintenanceWindowRepository {
+	return &MaintenanceWindowRepository{db: db}
+}
+
+func (r *MaintenanceWindowRepository) GetAll() ([]*types.MaintenanceWindow, error) {
+	var windows []*models.MaintenanceWindow
+	if err := r.db.DB.Order("starts_at ASC").Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get maintenance windows: %w", err)
+	}
+
+	result := make([]*types.MaintenanceWindow, len(windows))
+	for i, window := range windows {
+		result[i] = r.modelToType(window)
+	}
+
+	return result, nil
+}
+
+func (r *MaintenanceWindowRepository) GetByID(id int) (*types.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	if err := r.db.DB.First(&window, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("maintenance window with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get maintenance window by ID: %w", err)
+	}
+
+	return r.modelToType(&window), nil
+}
+
+func (r *MaintenanceWindowRepository) Create(window *types.MaintenanceWindow) error {
+	model := &models.MaintenanceWindow{
+		EndpointID:  uint(window.EndpointID),
+		StartsAt:    window.StartsAt,
+		EndsAt:      window.EndsAt,
+		Recurrence:  window.Recurrence,
+		Description: window.Description,
+	}
+
+	if err := r.db.DB.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+
+	window.ID = int(model.ID)
+	window.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (r *MaintenanceWindowRepository) Delete(id int) error {
+	result := r.db.DB.Delete(&models.MaintenanceWindow{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("maintenance window with ID %d not found", id)
+	}
+
+	return nil
+}
+
+func (r *MaintenanceWindowRepository) modelToType(m *models.MaintenanceWindow) *types.MaintenanceWindow {
+	return &types.MaintenanceWindow{
+		ID:          int(m.ID),
+		EndpointID:  int(m.EndpointID),
+		StartsAt:    m.StartsAt,
+		EndsAt:      m.EndsAt,
+		Recurrence:  m.Recurrence,
+		Description: m.Description,
+		CreatedAt:   m.CreatedAt,
+	}
+}
@@ -0,0 +1,98 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+type MethodUsageRepository struct {
+	db *database.GormDB
+}
+
+func NewMethodUsageRepository(db *database.GormDB) *MethodUsageRepository {
+	return &MethodUsageRepository{db: db}
+}
+
+func (r *MethodUsageRepository) AddRollups(ctx context.Context, rollups []*types.MethodUsageRollup) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rollup := range rollups {
+			var existing models.MethodUsageRollup
+			err := tx.Where("chain_name = ? AND method = ? AND period_start = ?",
+				rollup.ChainName, rollup.Method, rollup.PeriodStart).First(&existing).Error
+
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(r.typeToModel(rollup)).Error; err != nil {
+					return fmt.Errorf("failed to create method usage rollup: %w", err)
+				}
+			case err != nil:
+				return fmt.Errorf("failed to load method usage rollup: %w", err)
+			default:
+				existing.Requests += rollup.Requests
+				existing.Errors += rollup.Errors
+				existing.TotalLatencyMs += rollup.TotalLatencyMs
+				if rollup.MaxLatencyMs > existing.MaxLatencyMs {
+					existing.MaxLatencyMs = rollup.MaxLatencyMs
+				}
+				if err := tx.Save(&existing).Error; err != nil {
+					return fmt.Errorf("failed to update method usage rollup: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// GetSince returns rollups for periods starting at or after since, oldest first; an empty
+// chainName returns every chain
+func (r *MethodUsageRepository) GetSince(chainName string, since time.Time) ([]*types.MethodUsageRollup, error) {
+	query := r.db.DB.Where("period_start >= ?", since)
+	if chainName != "" {
+		query = query.Where("chain_name = ?", chainName)
+	}
+
+	var rollups []*models.MethodUsageRollup
+	if err := query.Order("period_start ASC, requests DESC").Find(&rollups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get method usage rollups: %w", err)
+	}
+
+	result := make([]*types.MethodUsageRollup, len(rollups))
+	for i, rollup := range rollups {
+		result[i] = r.modelToType(rollup)
+	}
+
+	return result, nil
+}
+
+func (r *MethodUsageRepository) modelToType(m *models.MethodUsageRollup) *types.MethodUsageRollup {
+	return &types.MethodUsageRollup{
+		ID:             int(m.ID),
+		ChainName:      m.ChainName,
+		Method:         m.Method,
+		PeriodStart:    m.PeriodStart,
+		Requests:       m.Requests,
+		Errors:         m.Errors,
+		TotalLatencyMs: m.TotalLatencyMs,
+		MaxLatencyMs:   m.MaxLatencyMs,
+	}
+}
+
+func (r *MethodUsageRepository) typeToModel(t *types.MethodUsageRollup) *models.MethodUsageRollup {
+	return &models.MethodUsageRollup{
+		ChainName:      t.ChainName,
+		Method:         t.Method,
+		PeriodStart:    t.PeriodStart,
+		Requests:       t.Requests,
+		Errors:         t.Errors,
+		TotalLatencyMs: t.TotalLatencyMs,
+		MaxLatencyMs:   t.MaxLatencyMs,
+	}
+}
@@ -0,0 +1,125 @@
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+type PlanRepository struct {
+	db *database.GormDB
+}
+
+func NewPlanRepository(db *database.GormDB) *PlanRepository {
+	return &PlanRepository{db: db}
+}
+
+func (r *PlanRepository) GetAll() ([]*types.Plan, error) {
+	var rows []models.Plan
+	if err := r.db.DB.Order("name ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get plans: %w", err)
+	}
+
+	result := make([]*types.Plan, len(rows))
+	for i := range rows {
+		result[i] = r.modelToType(&rows[i])
+	}
+	return result, nil
+}
+
+func (r *PlanRepository) GetByID(id int) (*types.Plan, error) {
+	var model models.Plan
+	if err := r.db.DB.First(&model, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("plan with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get plan by ID: %w", err)
+	}
+
+	return r.modelToType(&model), nil
+}
+
+func (r *PlanRepository) Create(plan *types.Plan) error {
+	model := r.typeToModel(plan)
+	if err := r.db.DB.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	*plan = *r.modelToType(model)
+	return nil
+}
+
+func (r *PlanRepository) Update(plan *types.Plan) error {
+	var existing models.Plan
+	if err := r.db.DB.First(&existing, plan.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("plan with ID %d not found", plan.ID)
+		}
+		return fmt.Errorf("failed to get plan by ID: %w", err)
+	}
+
+	model := r.typeToModel(plan)
+	model.CreatedAt = existing.CreatedAt
+	if err := r.db.DB.Save(model).Error; err != nil {
+		return fmt.Errorf("failed to update plan: %w", err)
+	}
+
+	*plan = *r.modelToType(model)
+	return nil
+}
+
+func (r *PlanRepository) Delete(id int) error {
+	result := r.db.DB.Delete(&models.Plan{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete plan: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("plan with ID %d not found", id)
+	}
+
+	return nil
+}
+
+func (r *PlanRepository) typeToModel(p *types.Plan) *models.Plan {
+	return &models.Plan{
+		ID:             uint(p.ID),
+		Name:           p.Name,
+		RateLimit:      p.RateLimit,
+		RateBurst:      p.RateBurst,
+		MaxConcurrency: p.MaxConcurrency,
+		MonthlyQuota:   p.MonthlyQuota,
+		AllowedChains:  strings.Join(p.AllowedChains, ","),
+		AllowedMethods: strings.Join(p.AllowedMethods, ","),
+		HedgingEnabled: p.HedgingEnabled,
+		CachePriority:  p.CachePriority,
+	}
+}
+
+func (r *PlanRepository) modelToType(m *models.Plan) *types.Plan {
+	p := &types.Plan{
+		ID:             int(m.ID),
+		Name:           m.Name,
+		RateLimit:      m.RateLimit,
+		RateBurst:      m.RateBurst,
+		MaxConcurrency: m.MaxConcurrency,
+		MonthlyQuota:   m.MonthlyQuota,
+		HedgingEnabled: m.HedgingEnabled,
+		CachePriority:  m.CachePriority,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	if m.AllowedChains != "" {
+		p.AllowedChains = strings.Split(m.AllowedChains, ",")
+	}
+	if m.AllowedMethods != "" {
+		p.AllowedMethods = strings.Split(m.AllowedMethods, ",")
+	}
+	return p
+}
@@ -0,0 +1,121 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+type RequestLogRepository struct {
+	db *database.GormDB
+}
+
+func NewRequestLogRepository(db *database.GormDB) *RequestLogRepository {
+	return &RequestLogRepository{db: db}
+}
+
+func (r *RequestLogRepository) CreateBatch(ctx context.Context, logs []*types.RequestLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	rows := make([]models.RequestLog, len(logs))
+	for i, entry := range logs {
+		rows[i] = r.typeToModel(entry)
+	}
+
+	if err := r.db.DB.WithContext(ctx).CreateInBatches(&rows, 500).Error; err != nil {
+		return fmt.Errorf("failed to create request logs: %w", err)
+	}
+	return nil
+}
+
+func (r *RequestLogRepository) Find(filter repository.RequestLogFilter) ([]*types.RequestLog, error) {
+	query := r.db.DB.Model(&models.RequestLog{})
+	if filter.ChainName != "" {
+		query = query.Where("chain_name = ?", filter.ChainName)
+	}
+	if filter.Method != "" {
+		query = query.Where("method = ?", filter.Method)
+	}
+	if filter.Upstream != "" {
+		query = query.Where("upstream = ?", filter.Upstream)
+	}
+	if filter.ErrorsOnly {
+		query = query.Where("success = ?", false)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var rows []models.RequestLog
+	if err := query.Order("created_at DESC, id DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get request logs: %w", err)
+	}
+
+	result := make([]*types.RequestLog, len(rows))
+	for i := range rows {
+		result[i] = r.modelToType(&rows[i])
+	}
+	return result, nil
+}
+
+func (r *RequestLogRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.DB.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&models.RequestLog{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old request logs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *RequestLogRepository) modelToType(m *models.RequestLog) *types.RequestLog {
+	entry := &types.RequestLog{
+		ID:         int(m.ID),
+		CreatedAt:  m.CreatedAt,
+		ChainName:  m.ChainName,
+		Method:     m.Method,
+		BatchSize:  m.BatchSize,
+		Upstream:   m.Upstream,
+		Attempts:   m.Attempts,
+		Status:     m.Status,
+		DurationMs: m.DurationMs,
+		Success:    m.Success,
+		Error:      m.Error,
+		ClientIP:   m.ClientIP,
+		ClientKey:  m.ClientKey,
+	}
+	if m.TenantID != nil {
+		entry.TenantID = int(*m.TenantID)
+	}
+	return entry
+}
+
+func (r *RequestLogRepository) typeToModel(t *types.RequestLog) models.RequestLog {
+	model := models.RequestLog{
+		CreatedAt:  t.CreatedAt,
+		ChainName:  t.ChainName,
+		Method:     t.Method,
+		BatchSize:  t.BatchSize,
+		Upstream:   t.Upstream,
+		Attempts:   t.Attempts,
+		Status:     t.Status,
+		DurationMs: t.DurationMs,
+		Success:    t.Success,
+		Error:      t.Error,
+		ClientIP:   t.ClientIP,
+		ClientKey:  t.ClientKey,
+	}
+	if t.TenantID != 0 {
+		tenantID := uint(t.TenantID)
+		model.TenantID = &tenantID
+	}
+	return model
+}
@@ -2,6 +2,8 @@ package gorm
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"rpc-proxy/internal/database"
 	"rpc-proxy/internal/models"
@@ -44,7 +46,7 @@ func (r *rpcEndpointRepository) GetEnabledByChain(chainName string) ([]*types.RP
 		SELECT re.* 
 		FROM rpc_endpoints re
 		JOIN chains c ON re.chain_id = c.id
-		WHERE re.enabled = true AND c.name = ?
+		WHERE re.enabled = true AND re.deleted_at IS NULL AND c.name = ?
 		ORDER BY re.weight DESC, re.created_at ASC
 	`
 	
@@ -68,7 +70,7 @@ func (r *rpcEndpointRepository) GetAllByChain(chainName string) ([]*types.RPCEnd
 		SELECT re.*
 		FROM rpc_endpoints re
 		JOIN chains c ON re.chain_id = c.id
-		WHERE c.name = ?
+		WHERE re.deleted_at IS NULL AND c.name = ?
 		ORDER BY re.weight DESC, re.created_at ASC
 	`
 	
@@ -111,10 +113,21 @@ func (r *rpcEndpointRepository) GetByName(name string) (*types.RPCEndpoint, erro
 
 func (r *rpcEndpointRepository) Create(req *repository.CreateRPCEndpointRequest) (*types.RPCEndpoint, error) {
 	endpoint := models.RPCEndpoint{
-		Name:    req.Name,
-		URL:     req.URL,
-		Weight:  req.Weight,
-		Enabled: req.Enabled,
+		Name:      req.Name,
+		URL:       req.URL,
+		Transport: req.Transport,
+		WSURL:     req.WSURL,
+		Weight:    req.Weight,
+		Enabled:   req.Enabled,
+		ChainID:   uint(req.ChainID),
+
+		GraphQLURL:   req.GraphQLURL,
+		Capabilities: strings.Join(req.Capabilities, ","),
+		AuthUsername: req.AuthUsername,
+		AuthPassword: req.AuthPassword,
+	}
+	if endpoint.Transport == "" {
+		endpoint.Transport = types.TransportForURL(req.URL)
 	}
 
 	if err := r.db.Create(&endpoint).Error; err != nil {
@@ -140,6 +153,24 @@ func (r *rpcEndpointRepository) Update(id int, req *repository.UpdateRPCEndpoint
 	if req.URL != nil {
 		updates["url"] = *req.URL
 	}
+	if req.Transport != nil {
+		updates["transport"] = *req.Transport
+	}
+	if req.WSURL != nil {
+		updates["ws_url"] = *req.WSURL
+	}
+	if req.GraphQLURL != nil {
+		updates["graphql_url"] = *req.GraphQLURL
+	}
+	if req.Capabilities != nil {
+		updates["capabilities"] = strings.Join(*req.Capabilities, ",")
+	}
+	if req.AuthUsername != nil {
+		updates["auth_username"] = *req.AuthUsername
+	}
+	if req.AuthPassword != nil {
+		updates["auth_password"] = *req.AuthPassword
+	}
 	if req.Weight != nil {
 		updates["weight"] = *req.Weight
 	}
@@ -176,6 +207,57 @@ func (r *rpcEndpointRepository) Delete(id int) error {
 	return nil
 }
 
+// GetDeletedByChain returns a chain's trashed endpoints, most recently deleted first
+func (r *rpcEndpointRepository) GetDeletedByChain(chainName string) ([]*types.RPCEndpoint, error) {
+	var endpoints []models.RPCEndpoint
+	query := `
+		SELECT re.*
+		FROM rpc_endpoints re
+		JOIN chains c ON re.chain_id = c.id
+		WHERE re.deleted_at IS NOT NULL AND c.name = ?
+		ORDER BY re.deleted_at DESC
+	`
+
+	if err := r.db.Raw(query, chainName).Scan(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deleted endpoints for chain %s: %w", chainName, err)
+	}
+
+	result := r.modelsToTypes(endpoints)
+	for _, endpoint := range result {
+		endpoint.ChainName = chainName
+	}
+
+	return result, nil
+}
+
+// Restore takes an endpoint out of the trash and returns it
+func (r *rpcEndpointRepository) Restore(id int) (*types.RPCEndpoint, error) {
+	result := r.db.Unscoped().Model(&models.RPCEndpoint{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to restore endpoint: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("deleted endpoint with ID %d not found", id)
+	}
+
+	return r.GetByID(id)
+}
+
+// Purge permanently removes an endpoint, live or trashed
+func (r *rpcEndpointRepository) Purge(id int) error {
+	result := r.db.Unscoped().Delete(&models.RPCEndpoint{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge endpoint: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("endpoint with ID %d not found", id)
+	}
+
+	return nil
+}
+
 func (r *rpcEndpointRepository) SetEnabled(id int, enabled bool) error {
 	result := r.db.Model(&models.RPCEndpoint{}).Where("id = ?", id).Update("enabled", enabled)
 	if result.Error != nil {
@@ -206,6 +288,14 @@ func (r *rpcEndpointRepository) UpdateHealthStatus(id int, healthy bool, respons
 	return nil
 }
 
+// splitCapabilities reads an endpoint's comma-separated capabilities column
+func splitCapabilities(column string) []string {
+	if column == "" {
+		return nil
+	}
+	return strings.Split(column, ",")
+}
+
 // Helper methods to convert between models and types
 func (r *rpcEndpointRepository) modelToType(model *models.RPCEndpoint) *types.RPCEndpoint {
 	return &types.RPCEndpoint{
@@ -215,8 +305,15 @@ func (r *rpcEndpointRepository) modelToType(model *models.RPCEndpoint) *types.RP
 		Weight:       model.Weight,
 		Enabled:      model.Enabled,
 		ChainID:      int(model.ChainID),
+		Transport:    model.Transport,
+		WSURL:        model.WSURL,
+		GraphQLURL:   model.GraphQLURL,
+		Capabilities: splitCapabilities(model.Capabilities),
+		AuthUsername: model.AuthUsername,
+		AuthPassword: model.AuthPassword,
 		CreatedAt:    model.CreatedAt,
 		UpdatedAt:    model.UpdatedAt,
+		DeletedAt:    deletedAtPtr(model.DeletedAt),
 		Healthy:      model.Healthy,
 		LastCheck:    model.LastCheck,
 		ResponseTime: model.ResponseTime,
@@ -225,10 +322,18 @@ func (r *rpcEndpointRepository) modelToType(model *models.RPCEndpoint) *types.RP
 	}
 }
 
+// deletedAtPtr converts a soft-delete column to the nil-when-live form used by the API types
+func deletedAtPtr(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return &deletedAt.Time
+}
+
 func (r *rpcEndpointRepository) modelsToTypes(models []models.RPCEndpoint) []*types.RPCEndpoint {
 	types := make([]*types.RPCEndpoint, len(models))
-	for i, model := range models {
-		types[i] = r.modelToType(&model)
+	for i := range models {
+		types[i] = r.modelToType(&models[i])
 	}
 	return types
 }
\ No newline at end of file
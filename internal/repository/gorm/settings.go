@@ -8,6 +8,7 @@ import (
 	"rpc-proxy/internal/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type settingsRepository struct {
@@ -20,7 +21,7 @@ func NewSettingsRepository(db *database.GormDB) repository.SettingsRepository {
 
 func (r *settingsRepository) Get(key string) (string, error) {
 	var setting models.Setting
-	if err := r.db.Where("key = ?", key).First(&setting).Error; err != nil {
+	if err := r.db.Where(clause.Eq{Column: "key", Value: key}).First(&setting).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return "", fmt.Errorf("setting with key %s not found", key)
 		}
@@ -60,7 +61,7 @@ func (r *settingsRepository) GetAll() (map[string]string, error) {
 }
 
 func (r *settingsRepository) Delete(key string) error {
-	result := r.db.Where("key = ?", key).Delete(&models.Setting{})
+	result := r.db.Where(clause.Eq{Column: "key", Value: key}).Delete(&models.Setting{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete setting: %w", result.Error)
 	}
@@ -0,0 +1,128 @@
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"rpc-proxy/internal/database"
+	"rpc-proxy/internal/models"
+	"rpc-proxy/internal/types"
+
+	"gorm.io/gorm"
+)
+
+type TenantRepository struct {
+	db *database.GormDB
+}
+
+func NewTenantRepository(db *database.GormDB) *TenantRepository {
+	return &TenantRepository{db: db}
+}
+
+func (r *TenantRepository) GetAll() ([]*types.Tenant, error) {
+	var rows []models.Tenant
+	if err := r.db.DB.Order("name ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenants: %w", err)
+	}
+
+	result := make([]*types.Tenant, len(rows))
+	for i := range rows {
+		result[i] = r.modelToType(&rows[i])
+	}
+	return result, nil
+}
+
+func (r *TenantRepository) GetByID(id int) (*types.Tenant, error) {
+	var model models.Tenant
+	if err := r.db.DB.First(&model, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tenant with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get tenant by ID: %w", err)
+	}
+
+	return r.modelToType(&model), nil
+}
+
+func (r *TenantRepository) Create(tenant *types.Tenant) error {
+	model := &models.Tenant{
+		Name:           tenant.Name,
+		Contact:        tenant.Contact,
+		Plan:           tenant.Plan,
+		Status:         tenant.Status,
+		AllowedChains:  strings.Join(tenant.AllowedChains, ","),
+		AllowedMethods: strings.Join(tenant.AllowedMethods, ","),
+		RateLimit:      tenant.RateLimit,
+		RateBurst:      tenant.RateBurst,
+		MaxConcurrency: tenant.MaxConcurrency,
+	}
+	if err := r.db.DB.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	*tenant = *r.modelToType(model)
+	return nil
+}
+
+func (r *TenantRepository) Update(tenant *types.Tenant) error {
+	var model models.Tenant
+	if err := r.db.DB.First(&model, tenant.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("tenant with ID %d not found", tenant.ID)
+		}
+		return fmt.Errorf("failed to get tenant by ID: %w", err)
+	}
+
+	model.Name = tenant.Name
+	model.Contact = tenant.Contact
+	model.Plan = tenant.Plan
+	model.Status = tenant.Status
+	model.AllowedChains = strings.Join(tenant.AllowedChains, ",")
+	model.AllowedMethods = strings.Join(tenant.AllowedMethods, ",")
+	model.RateLimit = tenant.RateLimit
+	model.RateBurst = tenant.RateBurst
+	model.MaxConcurrency = tenant.MaxConcurrency
+	if err := r.db.DB.Save(&model).Error; err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	*tenant = *r.modelToType(&model)
+	return nil
+}
+
+// Delete removes a tenant; its API keys go with it through the foreign key cascade
+func (r *TenantRepository) Delete(id int) error {
+	result := r.db.DB.Delete(&models.Tenant{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete tenant: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tenant with ID %d not found", id)
+	}
+
+	return nil
+}
+
+func (r *TenantRepository) modelToType(m *models.Tenant) *types.Tenant {
+	t := &types.Tenant{
+		ID:             int(m.ID),
+		Name:           m.Name,
+		Contact:        m.Contact,
+		Plan:           m.Plan,
+		Status:         m.Status,
+		RateLimit:      m.RateLimit,
+		RateBurst:      m.RateBurst,
+		MaxConcurrency: m.MaxConcurrency,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+	if m.AllowedChains != "" {
+		t.AllowedChains = strings.Split(m.AllowedChains, ",")
+	}
+	if m.AllowedMethods != "" {
+		t.AllowedMethods = strings.Split(m.AllowedMethods, ",")
+	}
+	return t
+}
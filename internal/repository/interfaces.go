@@ -1,6 +1,16 @@
 package repository
 
-import "rpc-proxy/internal/types"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// ErrKeyNotRotatable is returned by APIKeyRepository.Rotate for a key that is revoked or was
+// already rotated, e.g. by a concurrent rotation
+var ErrKeyNotRotatable = errors.New("API key has already been rotated or revoked")
 
 type RPCEndpointRepository interface {
 	GetAll() ([]*types.RPCEndpoint, error)
@@ -12,6 +22,9 @@ type RPCEndpointRepository interface {
 	Create(endpoint *CreateRPCEndpointRequest) (*types.RPCEndpoint, error)
 	Update(id int, endpoint *UpdateRPCEndpointRequest) (*types.RPCEndpoint, error)
 	Delete(id int) error
+	GetDeletedByChain(chainName string) ([]*types.RPCEndpoint, error)
+	Restore(id int) (*types.RPCEndpoint, error)
+	Purge(id int) error
 	SetEnabled(id int, enabled bool) error
 	UpdateHealthStatus(id int, healthy bool, responseTime int64, blockNumber string, errorMsg string) error
 }
@@ -24,6 +37,10 @@ type ChainRepository interface {
 	Create(chain *types.Chain) error
 	Update(chain *types.Chain) error
 	Delete(id int) error
+	GetDeleted() ([]*types.Chain, error)
+	GetDeletedByName(name string) (*types.Chain, error)
+	Restore(id int) error
+	Purge(id int) error
 }
 
 type ChainConfigRepository interface {
@@ -45,24 +62,177 @@ type SettingsRepository interface {
 
 type HealthCheckRepository interface {
 	Create(healthCheck *CreateHealthCheckRequest) error
+	CreateBatch(ctx context.Context, healthChecks []*CreateHealthCheckRequest) error
 	GetByEndpointID(endpointID int, limit int) ([]*HealthCheck, error)
 	GetLatestByEndpointID(endpointID int) (*HealthCheck, error)
-	DeleteOldRecords(days int) error
+	DeleteOldRecords(ctx context.Context, days int) error
+	DownsampleOldRecords(ctx context.Context, days int) error
+}
+
+type MaintenanceWindowRepository interface {
+	GetAll() ([]*types.MaintenanceWindow, error)
+	GetByID(id int) (*types.MaintenanceWindow, error)
+	Create(window *types.MaintenanceWindow) error
+	Delete(id int) error
+}
+
+// MethodUsageRepository stores hourly per-method request rollups
+type MethodUsageRepository interface {
+	// AddRollups merges rollups into existing rows for the same chain, method and period
+	AddRollups(ctx context.Context, rollups []*types.MethodUsageRollup) error
+	GetSince(chainName string, since time.Time) ([]*types.MethodUsageRollup, error)
+}
+
+// APIKeyUsageRepository stores metered usage per API key per day, and per tenant, chain and
+// method per day for billing exports
+type APIKeyUsageRepository interface {
+	// AddUsage merges usage and buckets into existing rows for the same period in one
+	// transaction
+	AddUsage(ctx context.Context, usage []*types.APIKeyUsage, buckets []*types.UsageBucket) error
+	// GetRange returns usage for days from through to, inclusive, oldest first; a zero
+	// keyID or tenantID matches every key or tenant
+	GetRange(keyID, tenantID int, from, to time.Time) ([]*types.APIKeyUsage, error)
+	// GetBuckets returns buckets for days from through to, inclusive, oldest first; a zero
+	// tenantID matches every tenant
+	GetBuckets(tenantID int, from, to time.Time) ([]*types.UsageBucket, error)
+	// ComputeUnitsByTenant sums compute units per tenant for days from through to, inclusive
+	ComputeUnitsByTenant(from, to time.Time) (map[int]int64, error)
+}
+
+// HealthRollupRepository stores hourly and daily health check summaries per endpoint
+type HealthRollupRepository interface {
+	// LastPeriodStart returns the start of the latest rolled-up period, or the zero time
+	LastPeriodStart(granularity string) (time.Time, error)
+	// FirstCheckTime returns the time of the oldest raw health check, or the zero time
+	FirstCheckTime() (time.Time, error)
+	// Rollup summarizes the raw health checks of the period starting at start, replacing any
+	// earlier rollups of it, and returns the number of endpoints summarized
+	Rollup(ctx context.Context, granularity string, start time.Time) (int, error)
+	// GetSince returns rollups for periods starting at or after since, oldest first; an
+	// endpointID of 0 returns every endpoint
+	GetSince(granularity string, endpointID int, since time.Time) ([]*types.HealthCheckRollup, error)
+}
+
+// RequestLogRepository stores sampled proxied requests
+type RequestLogRepository interface {
+	CreateBatch(ctx context.Context, logs []*types.RequestLog) error
+	// Find returns the newest logs matching filter, newest first
+	Find(filter RequestLogFilter) ([]*types.RequestLog, error)
+	// DeleteOlderThan removes logs created before cutoff and returns how many it removed
+	DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// RequestLogFilter narrows RequestLogRepository.Find; zero fields match everything
+type RequestLogFilter struct {
+	ChainName  string
+	Method     string
+	Upstream   string
+	ErrorsOnly bool
+	Since      time.Time
+	Limit      int
+}
+
+// InstanceRepository registers the running proxy replicas
+type InstanceRepository interface {
+	// Upsert creates or replaces the instance's registration
+	Upsert(instance *types.Instance) error
+	// GetAll returns every registered instance, most recently started first
+	GetAll() ([]*types.Instance, error)
+	Delete(id string) error
+	// DeleteStale removes instances not seen since before, returning how many were removed
+	DeleteStale(before time.Time) (int64, error)
+}
+
+// PlanRepository stores the plans tenants are assigned to by name
+type PlanRepository interface {
+	GetAll() ([]*types.Plan, error)
+	GetByID(id int) (*types.Plan, error)
+	Create(plan *types.Plan) error
+	Update(plan *types.Plan) error
+	Delete(id int) error
+}
+
+// TenantRepository stores tenants; deleting one deletes its API keys
+type TenantRepository interface {
+	GetAll() ([]*types.Tenant, error)
+	GetByID(id int) (*types.Tenant, error)
+	Create(tenant *types.Tenant) error
+	Update(tenant *types.Tenant) error
+	Delete(id int) error
+}
+
+// APIKeyRepository stores tenants' API keys by hash
+type APIKeyRepository interface {
+	// GetAll returns every key, revoked or not, for building the key lookup
+	GetAll() ([]*types.APIKey, error)
+	GetByTenant(tenantID int) ([]*types.APIKey, error)
+	Create(key *types.APIKey) error
+	// Revoke marks a tenant's key revoked; revoking it again is a no-op
+	Revoke(tenantID, id int) (*types.APIKey, error)
+	// Rotate creates replacement with the rotated key's name and restrictions and sets the
+	// rotated key to expire at expiresAt, returning the rotated key. Revoked keys and keys
+	// already rotated can't be rotated, failing with ErrKeyNotRotatable.
+	Rotate(tenantID, id int, replacement *types.APIKey, expiresAt time.Time) (*types.APIKey, error)
+	// SetRestrictions replaces the origins and client CIDRs a tenant's key may be used from
+	SetRestrictions(tenantID, id int, origins, cidrs []string) (*types.APIKey, error)
+	// RevokeExpired revokes the keys whose expiry has passed, returning how many
+	RevokeExpired(now time.Time) (int64, error)
+}
+
+// ConfigDocumentRepository exports and imports the full configuration tree
+type ConfigDocumentRepository interface {
+	Export() (*ConfigDocument, error)
+	Import(doc *ConfigDocument, opts ImportOptions) ([]ImportChange, error)
+}
+
+// ConfigRevisionRepository stores snapshots of the configuration tree taken after each change
+type ConfigRevisionRepository interface {
+	Create(revision *ConfigRevision) error
+	GetByID(id int) (*ConfigRevision, error)
+	// Latest returns the newest revision, or nil when none has been recorded
+	Latest() (*ConfigRevision, error)
+	// Previous returns the newest revision older than id, or nil when id is the first
+	Previous(id int) (*ConfigRevision, error)
+	// List returns the newest revisions first, without their documents
+	List(limit int) ([]*ConfigRevision, error)
 }
 
 // Request/Response types
 type CreateRPCEndpointRequest struct {
-	Name    string `json:"name" validate:"required,min=1,max=100"`
-	URL     string `json:"url" validate:"required,url,max=500"`
-	Weight  int    `json:"weight" validate:"min=1,max=100"`
-	Enabled bool   `json:"enabled"`
+	Name      string `json:"name" validate:"required,min=1,max=100"`
+	URL       string `json:"url" validate:"required,url,max=500"`
+	Transport string `json:"transport,omitempty" validate:"omitempty,oneof=http ws"` // defaults to the URL's
+	WSURL     string `json:"wsUrl,omitempty" validate:"omitempty,url,max=500"`
+	Weight    int    `json:"weight" validate:"min=1,max=100"`
+	Enabled   bool   `json:"enabled"`
+	ChainID   int    `json:"chainId" validate:"required"`
+
+	// GraphQLURL is where the endpoint serves GraphQL, such as Geth's /graphql
+	GraphQLURL string `json:"graphqlUrl,omitempty" validate:"omitempty,url,max=500"`
+	// Capabilities are the method namespaces, debug and trace, the endpoint serves
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Basic auth credentials for the upstream
+	AuthUsername string `json:"authUsername,omitempty" validate:"omitempty,max=100"`
+	AuthPassword string `json:"authPassword,omitempty" validate:"omitempty,max=255"`
 }
 
 type UpdateRPCEndpointRequest struct {
-	Name    *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
-	URL     *string `json:"url,omitempty" validate:"omitempty,url,max=500"`
-	Weight  *int    `json:"weight,omitempty" validate:"omitempty,min=1,max=100"`
-	Enabled *bool   `json:"enabled,omitempty"`
+	Name      *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	URL       *string `json:"url,omitempty" validate:"omitempty,url,max=500"`
+	Transport *string `json:"transport,omitempty" validate:"omitempty,oneof=http ws"`
+	WSURL     *string `json:"wsUrl,omitempty" validate:"omitempty,url,max=500"` // "" removes it
+	Weight    *int    `json:"weight,omitempty" validate:"omitempty,min=1,max=100"`
+	Enabled   *bool   `json:"enabled,omitempty"`
+
+	// GraphQLURL is where the endpoint serves GraphQL; "" removes it
+	GraphQLURL *string `json:"graphqlUrl,omitempty" validate:"omitempty,url,max=500"`
+	// Capabilities replace the endpoint's; [] removes them
+	Capabilities *[]string `json:"capabilities,omitempty"`
+
+	// Basic auth credentials for the upstream; "" removes them
+	AuthUsername *string `json:"authUsername,omitempty" validate:"omitempty,max=100"`
+	AuthPassword *string `json:"authPassword,omitempty" validate:"omitempty,max=255"`
 }
 
 type CreateHealthCheckRequest struct {
@@ -81,6 +251,7 @@ type HealthCheck struct {
 	BlockNumber    string `json:"blockNumber" db:"block_number"`
 	ErrorMessage   string `json:"errorMessage" db:"error_message"`
 	CheckedAt      string `json:"checkedAt" db:"checked_at"`
+	SampleCount    int    `json:"sampleCount" db:"sample_count"`
 }
 
 type Setting struct {
@@ -88,4 +259,72 @@ type Setting struct {
 	Value       string `json:"value" db:"value"`
 	Description string `json:"description" db:"description"`
 	UpdatedAt   string `json:"updatedAt" db:"updated_at"`
-}
\ No newline at end of file
+}
+
+// ConfigDocument is the portable chain/endpoint/config/settings tree used for export and import
+type ConfigDocument struct {
+	Version  int                   `json:"version" yaml:"version"`
+	Chains   []ConfigDocumentChain `json:"chains" yaml:"chains"`
+	Settings map[string]string     `json:"settings" yaml:"settings"`
+}
+
+type ConfigDocumentChain struct {
+	ChainID              int                      `json:"chainId" yaml:"chainId"`
+	Name                 string                   `json:"name" yaml:"name"`
+	DisplayName          string                   `json:"displayName" yaml:"displayName"`
+	RPCPath              string                   `json:"rpcPath" yaml:"rpcPath"`
+	IsTestnet            bool                     `json:"isTestnet" yaml:"isTestnet"`
+	IsEnabled            bool                     `json:"isEnabled" yaml:"isEnabled"`
+	NativeCurrencySymbol string                   `json:"nativeCurrencySymbol" yaml:"nativeCurrencySymbol"`
+	BlockExplorerURL     string                   `json:"blockExplorerUrl" yaml:"blockExplorerUrl"`
+	Type                 string                   `json:"type,omitempty" yaml:"type,omitempty"` // defaults to evm
+	Configs              map[string]string        `json:"configs" yaml:"configs"`
+	Endpoints            []ConfigDocumentEndpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// ConfigDocumentEndpoint is identified within its chain by URL
+type ConfigDocumentEndpoint struct {
+	Name      string `json:"name" yaml:"name"`
+	URL       string `json:"url" yaml:"url"`
+	Transport string `json:"transport,omitempty" yaml:"transport,omitempty"` // defaults to the URL's
+	WSURL     string `json:"wsUrl,omitempty" yaml:"wsUrl,omitempty"`
+	Weight    int    `json:"weight" yaml:"weight"`
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+
+	// GraphQLURL is where the endpoint serves GraphQL, if it does
+	GraphQLURL string `json:"graphqlUrl,omitempty" yaml:"graphqlUrl,omitempty"`
+	// Capabilities are the method namespaces, debug and trace, the endpoint serves
+	Capabilities []string `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+
+	// Basic auth credentials; exports include the password so they can be imported again
+	AuthUsername string `json:"authUsername,omitempty" yaml:"authUsername,omitempty"`
+	AuthPassword string `json:"authPassword,omitempty" yaml:"authPassword,omitempty"`
+}
+
+type ImportOptions struct {
+	// DryRun computes the changes inside a transaction that is then rolled back
+	DryRun bool
+	// Prune deletes chains, endpoints and chain configs missing from the document
+	Prune bool
+	// PruneSettings also deletes settings missing from the document, so a rollback restores them exactly
+	PruneSettings bool
+}
+
+// ImportChange describes one change made (or, in a dry run, that would be made) by an import
+type ImportChange struct {
+	Action string `json:"action"` // create, update, delete or restore
+	Kind   string `json:"kind"`   // chain, endpoint, chain_config or setting
+	Chain  string `json:"chain,omitempty"`
+	Key    string `json:"key"`
+}
+
+// ConfigRevision is the configuration tree as it stood after a change. Action names the
+// request that made the change, e.g. "PUT /admin/chains/ethereum", and Changes counts the
+// differences from the previous revision.
+type ConfigRevision struct {
+	ID        int             `json:"id"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Action    string          `json:"action"`
+	Changes   int             `json:"changes"`
+	Document  *ConfigDocument `json:"document,omitempty"`
+}
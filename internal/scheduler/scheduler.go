@@ -0,0 +1,340 @@
+// Package scheduler runs the proxy's recurring maintenance tasks, such as health history
+// retention and rollups, on per-task intervals that can be changed or disabled at runtime
+// through job_<name>_enabled and job_<name>_interval settings.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings that override a task's defaults are named SettingPrefix + name + a suffix, e.g.
+// job_health_check_retention_interval
+const (
+	SettingPrefix         = "job_"
+	EnabledSettingSuffix  = "_enabled"
+	IntervalSettingSuffix = "_interval"
+)
+
+// tick is how often the scheduler looks for due tasks
+const tick = time.Second
+
+// stopRunTimeout bounds each RunOnStop run so shutdown can't hang on a stuck task
+const stopRunTimeout = 30 * time.Second
+
+// Task is one recurring job
+type Task struct {
+	Name        string
+	Description string
+	// Interval between the starts of consecutive runs
+	Interval time.Duration
+	// Disabled tasks only run when enabled by setting or triggered by RunNow
+	Disabled bool
+	// RunOnStart runs the task as soon as the scheduler starts instead of one interval later
+	RunOnStart bool
+	// RunOnStop runs the task once more when the scheduler stops, if it is enabled, e.g. to
+	// flush buffers
+	RunOnStop bool
+	// Run does the work; ctx is cancelled when the scheduler stops, and the run on stop gets
+	// a ctx with a deadline instead
+	Run func(ctx context.Context) error
+}
+
+// TaskStatus reports a task's schedule and its last run
+type TaskStatus struct {
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Enabled        bool       `json:"enabled"`
+	Interval       string     `json:"interval"`
+	Running        bool       `json:"running"`
+	NextRun        *time.Time `json:"nextRun,omitempty"`
+	LastRun        *time.Time `json:"lastRun,omitempty"`
+	LastDurationMs int64      `json:"lastDurationMs"`
+	LastError      string     `json:"lastError,omitempty"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+}
+
+// task is a registered Task with its current schedule and run history
+type task struct {
+	Task
+	enabled      bool
+	interval     time.Duration
+	nextRun      time.Time
+	running      bool
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+	runs         int64
+	failures     int64
+}
+
+// Scheduler runs registered tasks when they are due, each in its own goroutine. A task
+// still running when it is due again is skipped rather than run twice.
+type Scheduler struct {
+	tasks  []*task
+	byName map[string]*task
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopChan chan struct{}
+	running  bool
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	runs     sync.WaitGroup
+}
+
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		byName:   make(map[string]*task),
+		ctx:      ctx,
+		cancel:   cancel,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Register adds a task; it must be called before Start, and names must be unique
+func (s *Scheduler) Register(t Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.byName[t.Name]; exists {
+		panic(fmt.Sprintf("scheduler: task %s registered twice", t.Name))
+	}
+
+	registered := &task{Task: t, enabled: !t.Disabled && t.Interval > 0, interval: t.Interval}
+	s.tasks = append(s.tasks, registered)
+	s.byName[t.Name] = registered
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+
+	now := time.Now()
+	for _, t := range s.tasks {
+		t.nextRun = now.Add(t.interval)
+		if t.RunOnStart {
+			t.nextRun = now
+		}
+	}
+
+	s.wg.Add(1)
+	go s.loop()
+	log.Printf("Scheduler started with %d tasks", len(s.tasks))
+}
+
+// Stop cancels running tasks, waits for them to return, then runs the enabled RunOnStop
+// tasks once
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+	s.wg.Wait()
+	s.cancel()
+	s.runs.Wait()
+
+	for _, t := range s.tasks {
+		if t.RunOnStop && t.enabled {
+			ctx, cancel := context.WithTimeout(context.Background(), stopRunTimeout)
+			if err := t.Run(ctx); err != nil {
+				log.Printf("Task %s failed on shutdown: %v", t.Name, err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	s.runDue(time.Now())
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.runDue(now)
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// runDue starts every enabled task whose next run has come
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tasks {
+		if !t.enabled || now.Before(t.nextRun) {
+			continue
+		}
+		// Keep the cadence, but never queue up runs missed while busy
+		for !t.nextRun.After(now) {
+			t.nextRun = t.nextRun.Add(t.interval)
+		}
+		if !t.running {
+			s.startLocked(t)
+		}
+	}
+}
+
+// startLocked runs t in the background; s.mu must be held
+func (s *Scheduler) startLocked(t *task) {
+	t.running = true
+	s.runs.Add(1)
+
+	go func() {
+		defer s.runs.Done()
+
+		start := time.Now()
+		err := t.Run(s.ctx)
+		duration := time.Since(start)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		t.running = false
+		t.lastRun = start
+		t.lastDuration = duration
+		t.runs++
+		t.lastError = ""
+		if err != nil {
+			t.failures++
+			t.lastError = err.Error()
+			log.Printf("Task %s failed after %v: %v", t.Name, duration.Round(time.Millisecond), err)
+		}
+	}()
+}
+
+// RunNow starts a task immediately, enabled or not, without changing its schedule
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.byName[name]
+	if !ok {
+		return fmt.Errorf("task %s not found", name)
+	}
+	if !s.running {
+		return fmt.Errorf("scheduler is not running")
+	}
+	if t.running {
+		return fmt.Errorf("task %s is already running", name)
+	}
+
+	s.startLocked(t)
+	return nil
+}
+
+// Has reports whether a task is registered
+func (s *Scheduler) Has(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.byName[name]
+	return ok
+}
+
+// Status reports every task in registration order
+func (s *Scheduler) Status() []TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]TaskStatus, len(s.tasks))
+	for i, t := range s.tasks {
+		status := TaskStatus{
+			Name:           t.Name,
+			Description:    t.Description,
+			Enabled:        t.enabled,
+			Interval:       t.interval.String(),
+			Running:        t.running,
+			LastDurationMs: t.lastDuration.Milliseconds(),
+			LastError:      t.lastError,
+			Runs:           t.runs,
+			Failures:       t.failures,
+		}
+		if t.enabled && s.running {
+			nextRun := t.nextRun
+			status.NextRun = &nextRun
+		}
+		if !t.lastRun.IsZero() {
+			lastRun := t.lastRun
+			status.LastRun = &lastRun
+		}
+		statuses[i] = status
+	}
+	return statuses
+}
+
+// ApplySettings overlays job_<name>_enabled and job_<name>_interval settings on each task's
+// defaults and returns the names of the settings that changed a schedule. A task whose
+// settings are removed returns to its defaults; invalid values are logged and ignored.
+func (s *Scheduler) ApplySettings(settings map[string]string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changed []string
+	for _, t := range s.tasks {
+		enabled, interval := !t.Disabled && t.Interval > 0, t.Interval
+
+		intervalKey := SettingPrefix + t.Name + IntervalSettingSuffix
+		if value, ok := settings[intervalKey]; ok {
+			if d, err := time.ParseDuration(strings.TrimSpace(value)); err != nil || d <= 0 {
+				log.Printf("Ignoring setting %s: must be a positive duration", intervalKey)
+			} else {
+				interval = d
+				enabled = !t.Disabled
+			}
+		}
+
+		enabledKey := SettingPrefix + t.Name + EnabledSettingSuffix
+		if value, ok := settings[enabledKey]; ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+				log.Printf("Ignoring setting %s: must be true or false", enabledKey)
+			} else {
+				enabled = b
+			}
+		}
+
+		// A task enabled by setting without any interval has nothing to run on
+		if interval <= 0 {
+			enabled = false
+		}
+
+		if interval != t.interval {
+			changed = append(changed, intervalKey)
+			// Reschedule from now so a shorter interval takes effect without waiting out the old one
+			t.nextRun = time.Now().Add(interval)
+		}
+		if enabled != t.enabled {
+			changed = append(changed, enabledKey)
+			if enabled {
+				t.nextRun = time.Now().Add(interval)
+			}
+		}
+		t.enabled, t.interval = enabled, interval
+	}
+
+	return changed
+}
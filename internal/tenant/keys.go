@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"rpc-proxy/internal/types"
+)
+
+// keyPrefix starts every issued API key, so leaked keys are easy to recognize
+const keyPrefix = "rpk_"
+
+// keyDisplayLength is how many leading characters of a key are kept to identify it
+const keyDisplayLength = len(keyPrefix) + 8
+
+// NewAPIKey generates a key for the tenant, returning the record to store and the key
+// itself, which is not stored and must be handed to the tenant now
+func NewAPIKey(tenantID int, name string) (*types.APIKey, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := keyPrefix + hex.EncodeToString(b)
+
+	key := &types.APIKey{
+		TenantID: tenantID,
+		Name:     name,
+		Prefix:   secret[:keyDisplayLength],
+		KeyHash:  types.HashAPIKey(secret),
+	}
+	return key, secret, nil
+}
+
+// Rotate issues a replacement for key, which stays valid for grace and is then revoked by
+// the tenant_sync job. It returns the replacement, the key itself, and the rotated key with
+// its expiry.
+func (r *Registry) Rotate(key *Key, grace time.Duration) (*types.APIKey, string, *types.APIKey, error) {
+	replacement, secret, err := NewAPIKey(key.Tenant.ID, "")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	previous, err := r.keyRepo.Rotate(key.Tenant.ID, key.ID, replacement, time.Now().Add(grace))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	r.ReloadAfterChange()
+	return replacement, secret, previous, nil
+}
@@ -0,0 +1,188 @@
+// Package tenant resolves the API keys presented on proxied requests to the tenants they
+// belong to, so usage, limits and policies can be applied per tenant.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"sync"
+	"time"
+
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/types"
+)
+
+// SyncInterval is how often the key lookup is reloaded from the database, picking up
+// changes made by other replicas or directly in the database
+const SyncInterval = 30 * time.Second
+
+// Key is an API key resolved to its tenant
+type Key struct {
+	ID      int
+	Revoked bool
+	// ExpiresAt ends a rotated key's grace window; zero if the key doesn't expire
+	ExpiresAt time.Time
+	// AllowedOrigins are the normalized origin patterns the key may be used from
+	AllowedOrigins  []string
+	allowedPrefixes []netip.Prefix
+	Tenant          *types.Tenant
+	// Plan is the tenant's plan, or nil if it has none or the plan doesn't exist
+	Plan *types.Plan
+	// quotaUsed is the tenant's compute units this UTC month as of the last reload
+	quotaUsed int64
+}
+
+// Expired reports whether the key's grace window has passed, which takes effect before the
+// tenant_sync job revokes it
+func (k *Key) Expired() bool {
+	return !k.ExpiresAt.IsZero() && !time.Now().Before(k.ExpiresAt)
+}
+
+// AllowsChain reports whether the key may call the chain: the tenant's allowlist applies if
+// it has one, otherwise the plan's
+func (k *Key) AllowsChain(chainName string) bool {
+	allowed := k.Tenant.AllowedChains
+	if len(allowed) == 0 && k.Plan != nil {
+		allowed = k.Plan.AllowedChains
+	}
+	return types.ChainAllowed(allowed, chainName)
+}
+
+// AllowedMethods returns the method allowlist that applies to the key: the tenant's if it
+// has one, otherwise the plan's; empty allows every method
+func (k *Key) AllowedMethods() []string {
+	if len(k.Tenant.AllowedMethods) == 0 && k.Plan != nil {
+		return k.Plan.AllowedMethods
+	}
+	return k.Tenant.AllowedMethods
+}
+
+// AllowsMethod reports whether the key may call the JSON-RPC method
+func (k *Key) AllowsMethod(method string) bool {
+	return types.MethodAllowed(k.AllowedMethods(), method)
+}
+
+// QuotaExhausted reports whether the tenant has used its plan's monthly quota. Usage is
+// counted as of the last reload, so enforcement trails traffic by up to the usage flush
+// interval plus SyncInterval.
+func (k *Key) QuotaExhausted() bool {
+	return k.Plan != nil && k.Plan.MonthlyQuota > 0 && k.quotaUsed >= k.Plan.MonthlyQuota
+}
+
+// Registry holds every API key by hash in memory. It is rebuilt from the database after
+// each admin change and every SyncInterval, so lookups on the request path never touch
+// the database.
+type Registry struct {
+	tenantRepo repository.TenantRepository
+	keyRepo    repository.APIKeyRepository
+	planRepo   repository.PlanRepository
+	usageRepo  repository.APIKeyUsageRepository
+
+	keys map[string]*Key
+	mu   sync.RWMutex
+	// reloadMu serializes reloads so an older snapshot can't replace a newer one
+	reloadMu sync.Mutex
+}
+
+func NewRegistry(tenantRepo repository.TenantRepository, keyRepo repository.APIKeyRepository,
+	planRepo repository.PlanRepository, usageRepo repository.APIKeyUsageRepository) *Registry {
+	return &Registry{
+		tenantRepo: tenantRepo,
+		keyRepo:    keyRepo,
+		planRepo:   planRepo,
+		usageRepo:  usageRepo,
+		keys:       make(map[string]*Key),
+	}
+}
+
+// Reload rebuilds the key lookup from the database; on failure the previous one is kept
+func (r *Registry) Reload() error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	tenants, err := r.tenantRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load tenants: %w", err)
+	}
+	apiKeys, err := r.keyRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load API keys: %w", err)
+	}
+	plans, err := r.planRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load plans: %w", err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	quotaUsed, err := r.usageRepo.ComputeUnitsByTenant(monthStart, today)
+	if err != nil {
+		return fmt.Errorf("failed to load quota usage: %w", err)
+	}
+
+	plansByName := make(map[string]*types.Plan, len(plans))
+	for _, p := range plans {
+		plansByName[p.Name] = p
+	}
+
+	byID := make(map[int]*types.Tenant, len(tenants))
+	for _, t := range tenants {
+		byID[t.ID] = t
+	}
+
+	keys := make(map[string]*Key, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		t, ok := byID[apiKey.TenantID]
+		if !ok {
+			continue
+		}
+		key := &Key{
+			ID:              apiKey.ID,
+			Revoked:         apiKey.RevokedAt != nil,
+			AllowedOrigins:  apiKey.AllowedOrigins,
+			allowedPrefixes: parsePrefixes(apiKey.AllowedCIDRs),
+			Tenant:          t,
+			Plan:            plansByName[t.Plan],
+			quotaUsed:       quotaUsed[t.ID],
+		}
+		if apiKey.ExpiresAt != nil {
+			key.ExpiresAt = *apiKey.ExpiresAt
+		}
+		keys[apiKey.KeyHash] = key
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.mu.Unlock()
+	return nil
+}
+
+// Run revokes rotated keys whose grace window has passed and reloads the registry once; it
+// is the scheduler's tenant_sync task
+func (r *Registry) Run(ctx context.Context) error {
+	revoked, err := r.keyRepo.RevokeExpired(time.Now())
+	if err != nil {
+		return err
+	}
+	if revoked > 0 {
+		log.Printf("Revoked %d rotated API key(s) at the end of their grace window", revoked)
+	}
+	return r.Reload()
+}
+
+// ReloadAfterChange reloads after an admin change, logging rather than failing the change
+// if the database can't be read; the next sync retries
+func (r *Registry) ReloadAfterChange() {
+	if err := r.Reload(); err != nil {
+		log.Printf("Failed to reload tenants after change: %v", err)
+	}
+}
+
+// Lookup returns the key with the given hash (see types.HashAPIKey), or nil if no tenant
+// has it
+func (r *Registry) Lookup(hash string) *Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[hash]
+}
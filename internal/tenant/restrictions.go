@@ -0,0 +1,121 @@
+package tenant
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// wildcardLabel starts an origin host matching every subdomain, as in https://*.example.com
+const wildcardLabel = "*."
+
+// NormalizeOrigin validates an allowed origin pattern, scheme://host[:port] with an
+// optional *. subdomain wildcard, and returns it lower-cased without a trailing slash
+func NormalizeOrigin(pattern string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(pattern), "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("allowed origin %q must look like https://app.example.com", pattern)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("allowed origin %q must not have a path, query or credentials", pattern)
+	}
+	host := strings.ToLower(u.Host)
+	if strings.Contains(strings.TrimPrefix(host, wildcardLabel), "*") {
+		return "", fmt.Errorf("allowed origin %q may only use * as its first label", pattern)
+	}
+	return u.Scheme + "://" + host, nil
+}
+
+// NormalizeCIDR validates an allowed client address range, a CIDR or a single address, and
+// returns it in CIDR form
+func NormalizeCIDR(cidr string) (string, error) {
+	cidr = strings.TrimSpace(cidr)
+	if addr, err := netip.ParseAddr(cidr); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return "", fmt.Errorf("allowed CIDR %q must be an address range like 203.0.113.0/24 or an address", cidr)
+	}
+	return prefix.Masked().String(), nil
+}
+
+// RequestOrigin returns the origin of a browser request, scheme://host[:port] lower-cased,
+// from its Origin header or else its Referer, or "" if it has neither
+func RequestOrigin(origin, referer string) string {
+	if origin != "" && origin != "null" {
+		return strings.ToLower(strings.TrimSuffix(origin, "/"))
+	}
+	if referer == "" {
+		return ""
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// AllowsOrigin reports whether the key may be used from origin, as returned by
+// RequestOrigin; a key without allowed origins may be used from anywhere, but a key with
+// them refuses requests that carry no origin
+func (k *Key) AllowsOrigin(origin string) bool {
+	if len(k.AllowedOrigins) == 0 {
+		return true
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok {
+		return false
+	}
+	for _, pattern := range k.AllowedOrigins {
+		if pattern == origin {
+			return true
+		}
+		patternScheme, patternHost, _ := strings.Cut(pattern, "://")
+		if suffix, ok := strings.CutPrefix(patternHost, wildcardLabel); ok && patternScheme == scheme &&
+			strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAddr reports whether the key may be used from the client address ip; a key
+// without allowed CIDRs may be used from any address
+func (k *Key) AllowsAddr(ip string) bool {
+	if len(k.allowedPrefixes) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range k.allowedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefixes parses stored CIDRs, skipping any that don't parse. If none parse, an
+// invalid prefix that matches nothing is returned, so the key refuses every address rather
+// than allowing all.
+func parsePrefixes(cidrs []string) []netip.Prefix {
+	if len(cidrs) == 0 {
+		return nil
+	}
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		prefixes = append(prefixes, netip.Prefix{})
+	}
+	return prefixes
+}
@@ -0,0 +1,311 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Fixture modes of Config.FixtureMode
+const (
+	// FixtureRecord forwards upstream calls and saves each request and response to a fixture file
+	FixtureRecord = "record"
+	// FixtureReplay answers upstream calls from the fixture files without contacting upstreams
+	FixtureReplay = "replay"
+)
+
+// errReplayDial refuses the connections, such as WebSocket ones, that replay can't serve
+var errReplayDial = errors.New("upstream connections are disabled in fixture replay mode")
+
+// fixtureHeaders are the response headers kept in fixtures; the rest describe the recording
+// connection rather than the response
+var fixtureHeaders = []string{"Content-Type", "Retry-After"}
+
+// fixture is one recorded upstream call. Bodies are kept as JSON when they are JSON, so fixture
+// files can be read and edited, and in base64 otherwise.
+type fixture struct {
+	RecordedAt time.Time       `json:"recordedAt"`
+	Request    fixtureRequest  `json:"request"`
+	Response   fixtureResponse `json:"response"`
+}
+
+type fixtureRequest struct {
+	Method string `json:"method"`
+	// Host is the upstream's host; its URL is left out, as paths often carry provider keys
+	Host string `json:"host"`
+	fixtureBody
+}
+
+type fixtureResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	fixtureBody
+}
+
+type fixtureBody struct {
+	Body       json.RawMessage `json:"body,omitempty"`
+	BodyBase64 string          `json:"bodyBase64,omitempty"`
+}
+
+func newFixtureBody(body []byte) fixtureBody {
+	if len(body) == 0 {
+		return fixtureBody{}
+	}
+	if json.Valid(body) {
+		return fixtureBody{Body: json.RawMessage(body)}
+	}
+	return fixtureBody{BodyBase64: base64.StdEncoding.EncodeToString(body)}
+}
+
+func (b fixtureBody) bytes() []byte {
+	if len(b.Body) > 0 {
+		return b.Body
+	}
+	body, _ := base64.StdEncoding.DecodeString(b.BodyBase64)
+	return body
+}
+
+// fixtureStore records upstream calls to, or replays them from, the fixture files in dir
+type fixtureStore struct {
+	mode string
+	dir  string
+}
+
+// roundTrip serves req in the store's mode; next makes the real upstream call when recording
+func (f *fixtureStore) roundTrip(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+	}
+	path := f.path(req, body)
+
+	if f.mode == FixtureReplay {
+		return f.replay(req, body, path)
+	}
+
+	// Fixtures are stored uncompressed; the transport still negotiates compression itself
+	recorded := req.Clone(req.Context())
+	recorded.Header.Del("Accept-Encoding")
+	recorded.Body = io.NopCloser(bytes.NewReader(body))
+	recorded.ContentLength = int64(len(body))
+
+	resp, err := next(recorded)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	entry := fixture{
+		RecordedAt: time.Now().UTC(),
+		Request:    fixtureRequest{Method: req.Method, Host: req.URL.Host, fixtureBody: newFixtureBody(body)},
+		Response:   fixtureResponse{Status: resp.StatusCode, fixtureBody: newFixtureBody(respBody)},
+	}
+	for _, header := range fixtureHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			if entry.Response.Headers == nil {
+				entry.Response.Headers = make(map[string]string)
+			}
+			entry.Response.Headers[header] = value
+		}
+	}
+	if err := writeFixture(path, entry); err != nil {
+		// The call itself succeeded, so the client still gets its response
+		log.Printf("Failed to record upstream fixture %s: %v", path, err)
+	}
+	return resp, nil
+}
+
+// replay answers req from its fixture, with the JSON-RPC ids of req in place of the recorded ones
+func (f *fixtureStore) replay(req *http.Request, body []byte, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no fixture recorded for %s %s at %s", req.Method, req.URL.Host, path)
+		}
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var entry fixture
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+
+	respBody := replaceRPCIDs(entry.Response.bytes(), rpcIDs(entry.Request.bytes()), rpcIDs(body))
+	header := make(http.Header)
+	for key, value := range entry.Response.Headers {
+		header.Set(key, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Response.Status, http.StatusText(entry.Response.Status)),
+		StatusCode:    entry.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// path names the fixture of a request, dir/{host}/{method}-{hash}, where the hash covers the
+// method, URL and body of the request, JSON-RPC ids left out so that calls differing only in
+// their ids share a fixture
+func (f *fixtureStore) path(req *http.Request, body []byte) string {
+	canonical, name := canonicalRPCBody(body)
+	if name == "" {
+		name = strings.ToLower(req.Method)
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL.String())
+	hash.Write(canonical)
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	host := strings.NewReplacer(":", "_", "/", "_").Replace(req.URL.Host)
+	return filepath.Join(f.dir, host, fmt.Sprintf("%s-%s.json", sanitizeFixtureName(name), sum[:16]))
+}
+
+// writeFixture replaces the fixture at path, through a temporary file so a concurrent replay
+// never reads half of one
+func writeFixture(path string, entry fixture) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fixture-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// canonicalRPCBody returns a JSON-RPC call or batch without its ids, with keys in a fixed order,
+// and the method it calls, "batch" for batches. Other bodies are returned as they are, with no
+// method.
+func canonicalRPCBody(body []byte) ([]byte, string) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var parsed interface{}
+	if err := decoder.Decode(&parsed); err != nil {
+		return body, ""
+	}
+
+	switch parsed := parsed.(type) {
+	case map[string]interface{}:
+		method, ok := parsed["method"].(string)
+		if !ok {
+			return body, ""
+		}
+		delete(parsed, "id")
+		canonical, _ := json.Marshal(parsed)
+		return canonical, method
+	case []interface{}:
+		for _, call := range parsed {
+			if call, ok := call.(map[string]interface{}); ok {
+				delete(call, "id")
+			}
+		}
+		canonical, _ := json.Marshal(parsed)
+		return canonical, "batch"
+	}
+	return body, ""
+}
+
+// rpcIDs returns the ids of a JSON-RPC call or batch, in order
+func rpcIDs(body []byte) []string {
+	var calls []struct {
+		ID json.RawMessage `json:"id"`
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		trimmed = append(append([]byte{'['}, trimmed...), ']')
+	}
+	if err := json.Unmarshal(trimmed, &calls); err != nil {
+		return nil
+	}
+	ids := make([]string, len(calls))
+	for i, call := range calls {
+		ids[i] = string(call.ID)
+	}
+	return ids
+}
+
+// replaceRPCIDs rewrites the ids of a recorded JSON-RPC response from those of the recorded
+// request to those of the replayed one, matched by position in the request
+func replaceRPCIDs(body []byte, recorded, current []string) []byte {
+	if len(recorded) == 0 || len(recorded) != len(current) {
+		return body
+	}
+	ids := make(map[string]json.RawMessage, len(recorded))
+	changed := false
+	for i := range recorded {
+		ids[recorded[i]] = json.RawMessage(current[i])
+		changed = changed || recorded[i] != current[i]
+	}
+	if !changed {
+		return body
+	}
+
+	replace := func(response map[string]json.RawMessage) {
+		if id, ok := ids[string(response["id"])]; ok && len(id) > 0 {
+			response["id"] = id
+		}
+	}
+	var single map[string]json.RawMessage
+	if err := json.Unmarshal(body, &single); err == nil {
+		replace(single)
+		rewritten, _ := json.Marshal(single)
+		return rewritten
+	}
+	var batch []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &batch); err == nil {
+		for _, response := range batch {
+			replace(response)
+		}
+		rewritten, _ := json.Marshal(batch)
+		return rewritten
+	}
+	return body
+}
+
+// sanitizeFixtureName keeps a method name usable as a file name
+func sanitizeFixtureName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' {
+			return c
+		}
+		return '_'
+	}, name)
+}
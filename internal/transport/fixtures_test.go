@@ -0,0 +1,147 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalRPCBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantCanonical string
+		wantMethod    string
+	}{
+		{"single", `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0x1"},"latest"]}`,
+			`{"jsonrpc":"2.0","method":"eth_call","params":[{"to":"0x1"},"latest"]}`, "eth_call"},
+		{"keys reordered", `{"params":[],"method":"eth_blockNumber","id":"x","jsonrpc":"2.0"}`,
+			`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[]}`, "eth_blockNumber"},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"id":2,"jsonrpc":"2.0","method":"eth_blockNumber"}]`,
+			`[{"jsonrpc":"2.0","method":"eth_chainId"},{"jsonrpc":"2.0","method":"eth_blockNumber"}]`, "batch"},
+		{"batch with a non-object", `[{"id":1,"method":"eth_chainId"},5]`,
+			`[{"method":"eth_chainId"},5]`, "batch"},
+		{"large number kept exact", `{"id":1,"method":"eth_call","params":[123456789012345678901234567890]}`,
+			`{"method":"eth_call","params":[123456789012345678901234567890]}`, "eth_call"},
+		{"no method", `{"id":1,"result":"0x1"}`, `{"id":1,"result":"0x1"}`, ""},
+		{"not json", `hello`, `hello`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, method := canonicalRPCBody([]byte(tt.body))
+			if string(canonical) != tt.wantCanonical || method != tt.wantMethod {
+				t.Errorf("canonicalRPCBody(%s) = %s, %q, want %s, %q", tt.body, canonical, method, tt.wantCanonical, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestCanonicalRPCBodyIgnoresIDs(t *testing.T) {
+	a, _ := canonicalRPCBody([]byte(`[{"id":1,"method":"eth_chainId"},{"id":2,"method":"eth_blockNumber"}]`))
+	b, _ := canonicalRPCBody([]byte(`[{"method":"eth_chainId","id":"first"},{"method":"eth_blockNumber","id":null}]`))
+	if !bytes.Equal(a, b) {
+		t.Errorf("batches differing only in ids canonicalize differently: %s and %s", a, b)
+	}
+	c, _ := canonicalRPCBody([]byte(`[{"id":1,"method":"eth_blockNumber"},{"id":2,"method":"eth_chainId"}]`))
+	if bytes.Equal(a, c) {
+		t.Errorf("batches with their calls in another order canonicalize the same: %s", a)
+	}
+}
+
+func TestRPCIDs(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{`{"id":1,"method":"eth_call"}`, []string{"1"}},
+		{` {"id":"a","method":"eth_call"}`, []string{`"a"`}},
+		{`[{"id":1},{"id":"b"},{"method":"eth_subscribe"}]`, []string{"1", `"b"`, ""}},
+		{`not json`, nil},
+	}
+	for _, tt := range tests {
+		if got := rpcIDs([]byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rpcIDs(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceRPCIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		recorded []string
+		current  []string
+		want     string
+	}{
+		{"single", `{"jsonrpc":"2.0","id":1,"result":"0x10"}`, []string{"1"}, []string{"42"},
+			`{"id":42,"jsonrpc":"2.0","result":"0x10"}`},
+		{"batch", `[{"id":1,"result":"0x1"},{"id":2,"result":"0x10"}]`, []string{"1", "2"}, []string{`"a"`, "7"},
+			`[{"id":"a","result":"0x1"},{"id":7,"result":"0x10"}]`},
+		// Responses are matched by id, not position, as upstreams may answer batches out of order
+		{"batch out of order", `[{"id":2,"result":"0x10"},{"id":1,"result":"0x1"}]`, []string{"1", "2"}, []string{`"a"`, "7"},
+			`[{"id":7,"result":"0x10"},{"id":"a","result":"0x1"}]`},
+		{"swapped ids", `[{"id":1,"result":"0x1"},{"id":2,"result":"0x10"}]`, []string{"1", "2"}, []string{"2", "1"},
+			`[{"id":2,"result":"0x1"},{"id":1,"result":"0x10"}]`},
+		{"unknown id kept", `[{"id":1,"result":"0x1"},{"id":9,"error":{"code":-32600}}]`, []string{"1"}, []string{"5"},
+			`[{"id":5,"result":"0x1"},{"error":{"code":-32600},"id":9}]`},
+		{"same ids untouched", `{"id":1, "result":"0x10"}`, []string{"1"}, []string{"1"},
+			`{"id":1, "result":"0x10"}`},
+		{"count mismatch untouched", `[{"id":1,"result":"0x1"}]`, []string{"1"}, []string{"1", "2"},
+			`[{"id":1,"result":"0x1"}]`},
+		{"notification untouched", `{"id":null,"result":"0x1"}`, []string{"1"}, []string{""},
+			`{"id":null,"result":"0x1"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceRPCIDs([]byte(tt.body), tt.recorded, tt.current); string(got) != tt.want {
+				t.Errorf("replaceRPCIDs(%s) = %s, want %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixtureRecordAndReplay(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var calls []map[string]json.RawMessage
+		json.NewDecoder(r.Body).Decode(&calls)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Node", "recording only")
+		w.Write([]byte(`[{"jsonrpc":"2.0","id":` + string(calls[1]["id"]) + `,"result":"0x10"},{"jsonrpc":"2.0","id":` + string(calls[0]["id"]) + `,"result":"0x1"}]`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	post := func(tr http.RoundTripper, body string) (*http.Response, string) {
+		req, _ := http.NewRequest("POST", upstream.URL, strings.NewReader(body))
+		resp, err := tr.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip(%s): %v", body, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	recorder := New(Config{FixtureMode: FixtureRecord, FixtureDir: dir}, nil)
+	post(recorder, `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"}]`)
+	upstream.Close()
+
+	replayer := New(Config{FixtureMode: FixtureReplay, FixtureDir: dir}, nil)
+	resp, body := post(replayer, `[{"id":"x","jsonrpc":"2.0","method":"eth_chainId"},{"jsonrpc":"2.0","method":"eth_blockNumber","id":3}]`)
+	if want := `[{"id":3,"jsonrpc":"2.0","result":"0x10"},{"id":"x","jsonrpc":"2.0","result":"0x1"}]`; body != want {
+		t.Errorf("replayed %s, want %s", body, want)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" || resp.Header.Get("X-Node") != "" {
+		t.Errorf("replayed status %d headers %v, want 200 with only the kept headers", resp.StatusCode, resp.Header)
+	}
+
+	req, _ := http.NewRequest("POST", upstream.URL, strings.NewReader(`[{"id":1,"method":"eth_blockNumber"},{"id":2,"method":"eth_chainId"}]`))
+	if _, err := replayer.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "no fixture recorded") {
+		t.Errorf("replaying an unrecorded batch = %v, want a missing fixture error", err)
+	}
+}
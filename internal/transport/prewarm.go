@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// prewarmTimeout bounds each prewarming request
+const prewarmTimeout = 10 * time.Second
+
+// prewarmBody is a cheap JSON-RPC call that every node answers without reading chain state
+var prewarmBody = []byte(`{"jsonrpc":"2.0","id":1,"method":"web3_clientVersion","params":[]}`)
+
+// Prewarm sends PrewarmConnections concurrent lightweight requests to each upstream host among
+// urls, so that many connections are open and idle in its pool (one suffices over HTTP/2).
+// Run it more often than IdleConnTimeout to keep them from being closed. WebSocket URLs are
+// skipped.
+func (t *Transport) Prewarm(ctx context.Context, urls []string) error {
+	// Replayed calls need no connections
+	if t.Replaying() {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var errs []error
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		host := u.Scheme + "://" + u.Host
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+
+		if err := t.prewarm(ctx, rawURL); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", u.Host, err))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// prewarm sends the concurrent requests for one upstream and returns the first error
+func (t *Transport) prewarm(ctx context.Context, rawURL string) error {
+	conns := max(t.config.PrewarmConnections, 1)
+	errs := make([]error, conns)
+
+	var wg sync.WaitGroup
+	for i := 0; i < conns; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = t.prewarmRequest(ctx, rawURL)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transport) prewarmRequest(ctx context.Context, rawURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, prewarmTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(prewarmBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	// Reading the body to the end returns the connection to the idle pool
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
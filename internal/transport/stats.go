@@ -0,0 +1,214 @@
+package transport
+
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ConnStats reports how requests to one upstream endpoint, proxied or health checks, got
+// their connections since startup
+type ConnStats struct {
+	Requests          int64   `json:"requests"`
+	Errors            int64   `json:"errors"`
+	ReusedConnections int64   `json:"reusedConnections"`
+	NewConnections    int64   `json:"newConnections"`
+	ReuseRatio        float64 `json:"reuseRatio"`
+	HTTP2Requests     int64   `json:"http2Requests"`
+	// Protocol is the one negotiated on the latest response, e.g. HTTP/2.0
+	Protocol          string  `json:"protocol,omitempty"`
+	ForceHTTP1        bool    `json:"forceHttp1"`
+	AvgDialMs         float64 `json:"avgDialMs"`
+	AvgTLSHandshakeMs float64 `json:"avgTlsHandshakeMs"`
+	// AvgRoundTripMs runs until the response headers arrive
+	AvgRoundTripMs float64 `json:"avgRoundTripMs"`
+	// SetupShare is the fraction of round-trip time spent dialing and in TLS handshakes
+	SetupShare float64 `json:"setupShare"`
+}
+
+type connStats struct {
+	requests   int64
+	errors     int64
+	reused     int64
+	opened     int64
+	http2      int64
+	protocol   string
+	forceHTTP1 bool
+
+	dials      int64
+	dialTime   time.Duration
+	handshakes int64
+	tlsTime    time.Duration
+	roundTrip  time.Duration
+}
+
+// requestTrace collects one request's connection events; the callbacks may run on the
+// transport's goroutines, so mu guards the fields they set
+type requestTrace struct {
+	transport *Transport
+	key       string
+	host      string
+	http1     bool
+	request   *http.Request
+	start     time.Time
+
+	mu          sync.Mutex
+	gotConn     bool
+	reused      bool
+	dialStart   time.Time
+	dials       int64
+	dialTime    time.Duration
+	tlsStart    time.Time
+	handshakes  int64
+	tlsTime     time.Duration
+	tlsComplete bool
+}
+
+// statsKey identifies an endpoint by its URL without credentials or query
+func statsKey(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// trace returns a trace whose request carries hooks recording req's connection events
+func (t *Transport) trace(req *http.Request, http1 bool) *requestTrace {
+	rt := &requestTrace{
+		transport: t,
+		key:       statsKey(req.URL),
+		host:      req.URL.Host,
+		http1:     http1,
+		start:     time.Now(),
+	}
+
+	clientTrace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.gotConn, rt.reused = true, info.Reused
+		},
+		ConnectStart: func(network, addr string) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.dialStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			if err == nil && !rt.dialStart.IsZero() {
+				rt.dials++
+				rt.dialTime += time.Since(rt.dialStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			if err == nil && !rt.tlsStart.IsZero() {
+				rt.handshakes++
+				rt.tlsTime += time.Since(rt.tlsStart)
+			}
+		},
+	}
+	rt.request = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace))
+	return rt
+}
+
+// done adds the finished round trip to its endpoint's statistics
+func (rt *requestTrace) done(resp *http.Response, err error) {
+	roundTrip := time.Since(rt.start)
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	t := rt.transport
+	t.statsMu.Lock()
+	defer t.statsMu.Unlock()
+
+	s, exists := t.stats[rt.key]
+	if !exists {
+		s = &connStats{}
+		t.stats[rt.key] = s
+	}
+
+	s.requests++
+	s.roundTrip += roundTrip
+	s.forceHTTP1 = rt.http1
+	if err != nil {
+		s.errors++
+	}
+	if rt.gotConn {
+		if rt.reused {
+			s.reused++
+		} else {
+			s.opened++
+		}
+	}
+	s.dials += rt.dials
+	s.dialTime += rt.dialTime
+	s.handshakes += rt.handshakes
+	s.tlsTime += rt.tlsTime
+
+	if resp != nil {
+		if resp.Proto != s.protocol {
+			log.Printf("Upstream %s responded over %s", rt.host, resp.Proto)
+		}
+		s.protocol = resp.Proto
+		if resp.ProtoMajor == 2 {
+			s.http2++
+		}
+	}
+}
+
+// Stats returns the connection statistics of the endpoint at rawURL, and false when it has
+// not been called through this transport
+func (t *Transport) Stats(rawURL string) (ConnStats, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ConnStats{}, false
+	}
+
+	t.statsMu.Lock()
+	defer t.statsMu.Unlock()
+
+	s, exists := t.stats[statsKey(u)]
+	if !exists {
+		return ConnStats{}, false
+	}
+
+	stats := ConnStats{
+		Requests:          s.requests,
+		Errors:            s.errors,
+		ReusedConnections: s.reused,
+		NewConnections:    s.opened,
+		HTTP2Requests:     s.http2,
+		Protocol:          s.protocol,
+		ForceHTTP1:        s.forceHTTP1,
+	}
+	if conns := s.reused + s.opened; conns > 0 {
+		stats.ReuseRatio = float64(s.reused) / float64(conns)
+	}
+	if s.dials > 0 {
+		stats.AvgDialMs = milliseconds(s.dialTime) / float64(s.dials)
+	}
+	if s.handshakes > 0 {
+		stats.AvgTLSHandshakeMs = milliseconds(s.tlsTime) / float64(s.handshakes)
+	}
+	if s.requests > 0 {
+		stats.AvgRoundTripMs = milliseconds(s.roundTrip) / float64(s.requests)
+	}
+	if s.roundTrip > 0 {
+		stats.SetupShare = float64(s.dialTime+s.tlsTime) / float64(s.roundTrip)
+	}
+	return stats, true
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
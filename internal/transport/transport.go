@@ -0,0 +1,192 @@
+// Package transport builds the HTTP transport shared by the proxy and the health checkers for
+// every upstream RPC call, so connection pools, keep-alives and TLS sessions are reused across
+// both and tuned in one place.
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tlsSessionCacheSize is how many upstream TLS sessions are kept for resumption
+const tlsSessionCacheSize = 256
+
+type Config struct {
+	// MaxIdleConns caps idle connections kept across all upstreams, MaxIdleConnsPerHost those
+	// kept per upstream host
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps connections to one upstream host, including active ones; 0 is unlimited
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	TLSHandshakeTimeout time.Duration
+	// DisableKeepAlives opens a new connection for every request
+	DisableKeepAlives bool
+	// PerEndpoint gives each upstream host its own connection pool, so a slow upstream holding
+	// connections open cannot starve the others
+	PerEndpoint bool
+	// HTTP1Hosts are upstream hostnames spoken to over HTTP/1.1 only, for providers whose
+	// HTTP/2 support is broken; "*" forces HTTP/1.1 everywhere. HTTP/2 is otherwise negotiated
+	// over TLS wherever the upstream offers it.
+	HTTP1Hosts []string
+
+	// PrewarmInterval is how often connections to healthy endpoints are opened or kept warm
+	// (0 disables prewarming); PrewarmConnections is how many are kept per upstream host
+	PrewarmInterval    time.Duration
+	PrewarmConnections int
+
+	// FixtureMode records upstream calls to fixture files in FixtureDir (FixtureRecord) or
+	// answers them from those files without contacting upstreams (FixtureReplay); "" is off
+	FixtureMode string
+	FixtureDir  string
+}
+
+// forcesHTTP1 reports whether host must be spoken to over HTTP/1.1
+func (c Config) forcesHTTP1(host string) bool {
+	for _, h := range c.HTTP1Hosts {
+		if h == "*" || strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dialer returns a dialer with the configured dial timeout and TCP keep-alive
+func (c Config) Dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   c.DialTimeout,
+		KeepAlive: c.KeepAlive,
+	}
+}
+
+// DialFunc dials upstream connections, e.g. through the DNS cache
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Transport is an http.RoundTripper for upstream calls. It shares one pool between all
+// upstreams, or keeps one per upstream host when PerEndpoint is set, and counts how each
+// upstream's requests got their connections.
+type Transport struct {
+	config   Config
+	dial     DialFunc
+	sessions tls.ClientSessionCache
+
+	// pools holds the transports by pool key: "" and "http1" when shared, scheme and host
+	// when PerEndpoint is set
+	pools map[string]*http.Transport
+	mu    sync.Mutex
+
+	// stats holds connection statistics by endpoint URL
+	stats   map[string]*connStats
+	statsMu sync.Mutex
+
+	// fixtures records or replays upstream calls; nil unless FixtureMode is set
+	fixtures *fixtureStore
+}
+
+// New builds a transport from cfg; dial nil dials directly with cfg's dialer
+func New(cfg Config, dial DialFunc) *Transport {
+	if dial == nil {
+		dial = cfg.Dialer().DialContext
+	}
+
+	t := &Transport{
+		config:   cfg,
+		dial:     dial,
+		sessions: tls.NewLRUClientSessionCache(tlsSessionCacheSize),
+		pools:    make(map[string]*http.Transport),
+		stats:    make(map[string]*connStats),
+	}
+	if cfg.FixtureMode != "" {
+		t.fixtures = &fixtureStore{mode: cfg.FixtureMode, dir: cfg.FixtureDir}
+	}
+	return t
+}
+
+// Replaying reports whether upstream calls are answered from fixtures
+func (t *Transport) Replaying() bool {
+	return t.fixtures != nil && t.fixtures.mode == FixtureReplay
+}
+
+func (t *Transport) newHTTPTransport(http1 bool) *http.Transport {
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         t.dial,
+		ForceAttemptHTTP2:   !http1,
+		MaxIdleConns:        t.config.MaxIdleConns,
+		MaxIdleConnsPerHost: t.config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     t.config.MaxConnsPerHost,
+		IdleConnTimeout:     t.config.IdleConnTimeout,
+		TLSHandshakeTimeout: t.config.TLSHandshakeTimeout,
+		DisableKeepAlives:   t.config.DisableKeepAlives,
+		// Resume TLS sessions with upstreams instead of a full handshake on every new connection
+		TLSClientConfig:       &tls.Config{ClientSessionCache: t.sessions},
+		ExpectContinueTimeout: time.Second,
+	}
+	if http1 {
+		// A non-nil empty TLSNextProto keeps the transport from upgrading to HTTP/2
+		transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
+
+// transportFor returns the pool serving req's upstream host
+func (t *Transport) transportFor(req *http.Request, http1 bool) *http.Transport {
+	key := ""
+	if t.config.PerEndpoint {
+		key = req.URL.Scheme + "://" + req.URL.Host
+	} else if http1 {
+		key = "http1"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transport, exists := t.pools[key]
+	if !exists {
+		transport = t.newHTTPTransport(http1)
+		t.pools[key] = transport
+	}
+	return transport
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.fixtures != nil {
+		return t.fixtures.roundTrip(req, t.roundTrip)
+	}
+	return t.roundTrip(req)
+}
+
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	http1 := t.config.forcesHTTP1(req.URL.Hostname())
+	trace := t.trace(req, http1)
+	resp, err := t.transportFor(req, http1).RoundTrip(trace.request)
+	trace.done(resp, err)
+	return resp, err
+}
+
+// DialContext dials a raw upstream connection the way the transport does, for protocols it
+// doesn't carry such as WebSocket. Replay has no fixtures for them, so it refuses to dial.
+func (t *Transport) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if t.Replaying() {
+		return nil, errReplayDial
+	}
+	return t.dial(ctx, network, addr)
+}
+
+// CloseIdleConnections closes idle connections in every pool
+func (t *Transport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, transport := range t.pools {
+		transport.CloseIdleConnections()
+	}
+}
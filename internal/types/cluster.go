@@ -0,0 +1,40 @@
+package types
+
+import "time"
+
+// Instance is one running proxy replica as registered for the cluster view
+type Instance struct {
+	ID        string    `json:"id" db:"id"`
+	Hostname  string    `json:"hostname" db:"hostname"`
+	Version   string    `json:"version" db:"version"`
+	StartedAt time.Time `json:"startedAt" db:"started_at"`
+	// LastSeen is the instance's latest heartbeat
+	LastSeen time.Time `json:"lastSeen" db:"last_seen"`
+	// Chains are the chains the instance serves
+	Chains []string      `json:"chains" db:"chains"`
+	Stats  InstanceStats `json:"stats" db:"stats"`
+}
+
+// InstanceStats is an instance's health, traffic and configuration as of its latest heartbeat
+type InstanceStats struct {
+	// ConfigEpoch is the config epoch the instance applied last, 0 when the configuration
+	// isn't versioned
+	ConfigEpoch int64 `json:"configEpoch,omitempty"`
+	// Traffic is every proxied attempt since the instance started, over all chains
+	Traffic  TrafficCounts                  `json:"traffic"`
+	InFlight int64                          `json:"inFlight"`
+	Chains   map[string]*InstanceChainStats `json:"chains"`
+}
+
+// InstanceChainStats is one chain's endpoint health and traffic as seen by one instance
+type InstanceChainStats struct {
+	HealthyEndpoints int           `json:"healthyEndpoints"`
+	TotalEndpoints   int           `json:"totalEndpoints"`
+	Traffic          TrafficCounts `json:"traffic"`
+	InFlight         int64         `json:"inFlight"`
+}
+
+// Add adds other's counts to c
+func (c *TrafficCounts) Add(other TrafficCounts) {
+	c.add(other)
+}
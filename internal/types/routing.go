@@ -0,0 +1,18 @@
+package types
+
+import "sync/atomic"
+
+// routingGeneration counts changes to anything that decides where requests are routed:
+// endpoint health, overrides, draining, degradation, scores, latency and the endpoint sets
+// themselves. Routing tables built at one generation are stale at the next.
+var routingGeneration atomic.Uint64
+
+// RoutingGeneration returns the current routing generation
+func RoutingGeneration() uint64 {
+	return routingGeneration.Load()
+}
+
+// InvalidateRouting marks every routing table stale, e.g. after endpoints are added or removed
+func InvalidateRouting() {
+	routingGeneration.Add(1)
+}
@@ -0,0 +1,142 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Tenant statuses
+const (
+	TenantActive = "active"
+	// TenantSuspended tenants keep their keys, but requests made with them are rejected
+	TenantSuspended = "suspended"
+)
+
+// Tenant is a customer or project that API keys belong to. Usage, limits and policies
+// attach to the tenant, so every key of a tenant shares them.
+type Tenant struct {
+	ID      int    `json:"id" db:"id"`
+	Name    string `json:"name" db:"name"`
+	Contact string `json:"contact" db:"contact"`
+	// Plan is the name of the tenant's Plan, if any
+	Plan   string `json:"plan" db:"plan"`
+	Status string `json:"status" db:"status"`
+	// AllowedChains limits the chains the tenant's keys may call; empty leaves it to the plan,
+	// or allows every chain
+	AllowedChains []string `json:"allowedChains" db:"allowed_chains"`
+	// AllowedMethods limits the JSON-RPC methods the tenant's keys may call; a trailing *
+	// matches a prefix, e.g. eth_*. Empty leaves it to the plan, or allows every method.
+	AllowedMethods []string `json:"allowedMethods" db:"allowed_methods"`
+	// RateLimit (calls per second), RateBurst and MaxConcurrency override the plan's limits and
+	// the proxy's client limits; 0 inherits them
+	RateLimit      float64   `json:"rateLimit" db:"rate_limit"`
+	RateBurst      int       `json:"rateBurst" db:"rate_burst"`
+	MaxConcurrency int       `json:"maxConcurrency" db:"max_concurrency"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Plan is a tier of service assigned to tenants by name. Its limits and allowlists apply to
+// every tenant on it that doesn't set its own.
+type Plan struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// RateLimit (calls per second), RateBurst and MaxConcurrency bound each tenant on the
+	// plan; 0 falls back to the proxy's client limits
+	RateLimit      float64 `json:"rateLimit" db:"rate_limit"`
+	RateBurst      int     `json:"rateBurst" db:"rate_burst"`
+	MaxConcurrency int     `json:"maxConcurrency" db:"max_concurrency"`
+	// MonthlyQuota is how many compute units each tenant may use per UTC month; 0 is unlimited
+	MonthlyQuota   int64    `json:"monthlyQuota" db:"monthly_quota"`
+	AllowedChains  []string `json:"allowedChains" db:"allowed_chains"`
+	AllowedMethods []string `json:"allowedMethods" db:"allowed_methods"`
+	// HedgingEnabled and CachePriority are recorded for request hedging and response caching
+	HedgingEnabled bool      `json:"hedgingEnabled" db:"hedging_enabled"`
+	CachePriority  int       `json:"cachePriority" db:"cache_priority"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// ChainAllowed reports whether an allowlist of chain names admits the chain; an empty list
+// admits every chain
+func ChainAllowed(allowed []string, chainName string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, name := range allowed {
+		if name == chainName {
+			return true
+		}
+	}
+	return false
+}
+
+// MethodAllowed reports whether an allowlist of methods admits the method; a trailing *
+// matches a prefix, e.g. eth_*, and an empty list admits every method
+func MethodAllowed(patterns []string, method string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		} else if pattern == method {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a key issued to a tenant for proxied requests. Only a hash of the key is
+// stored; Prefix, its first characters, lets operators tell keys apart.
+type APIKey struct {
+	ID        int        `json:"id" db:"id"`
+	TenantID  int        `json:"tenantId" db:"tenant_id"`
+	Name      string     `json:"name" db:"name"`
+	Prefix    string     `json:"prefix" db:"prefix"`
+	KeyHash   string     `json:"-" db:"key_hash"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	// ExpiresAt is when a rotated key stops working and is revoked
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	// AllowedOrigins limits the web origins, taken from Origin or Referer, the key may be used
+	// from; https://*.example.com matches subdomains. Empty allows any origin.
+	AllowedOrigins []string `json:"allowedOrigins" db:"allowed_origins"`
+	// AllowedCIDRs limits the client addresses the key may be used from; empty allows any
+	AllowedCIDRs []string `json:"allowedCidrs" db:"allowed_cidrs"`
+}
+
+// APIKeyUsage is one API key's metered usage on one UTC day
+type APIKeyUsage struct {
+	ID       int       `json:"id" db:"id"`
+	APIKeyID int       `json:"apiKeyId" db:"api_key_id"`
+	TenantID int       `json:"tenantId" db:"tenant_id"`
+	Day      time.Time `json:"day" db:"day"`
+	Requests int64     `json:"requests" db:"requests"`
+	Errors   int64     `json:"errors" db:"errors"`
+	// ComputeUnits weigh successful calls by method cost
+	ComputeUnits int64 `json:"computeUnits" db:"compute_units"`
+}
+
+// UsageBucket is one tenant's metered calls to one method on one chain on one UTC day, the
+// granularity of billing exports
+type UsageBucket struct {
+	ID           int       `json:"id" db:"id"`
+	TenantID     int       `json:"tenantId" db:"tenant_id"`
+	Day          time.Time `json:"day" db:"day"`
+	ChainName    string    `json:"chainName" db:"chain_name"`
+	Method       string    `json:"method" db:"method"`
+	Requests     int64     `json:"requests" db:"requests"`
+	Errors       int64     `json:"errors" db:"errors"`
+	ComputeUnits int64     `json:"computeUnits" db:"compute_units"`
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
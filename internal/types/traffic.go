@@ -0,0 +1,93 @@
+package types
+
+import "time"
+
+const (
+	trafficBucketWidth = 10 * time.Second
+	trafficBuckets     = int(time.Hour / trafficBucketWidth)
+)
+
+// TrafficWindows are the rolling windows reported alongside the since-start totals; none may
+// exceed an hour, the span kept by trafficCounter
+var TrafficWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+}
+
+// TrafficCounts counts proxied request attempts to an endpoint. BytesSent is request bodies
+// forwarded upstream, BytesReceived is response bodies relayed back.
+type TrafficCounts struct {
+	Requests      int64 `json:"requests"`
+	Successes     int64 `json:"successes"`
+	Failures      int64 `json:"failures"`
+	BytesSent     int64 `json:"bytesSent"`
+	BytesReceived int64 `json:"bytesReceived"`
+}
+
+func (c *TrafficCounts) add(other TrafficCounts) {
+	c.Requests += other.Requests
+	c.Successes += other.Successes
+	c.Failures += other.Failures
+	c.BytesSent += other.BytesSent
+	c.BytesReceived += other.BytesReceived
+}
+
+// TrafficStats is a point-in-time view of an endpoint's traffic
+type TrafficStats struct {
+	Since   time.Time                `json:"since"`
+	Total   TrafficCounts            `json:"total"`
+	Windows map[string]TrafficCounts `json:"windows"`
+}
+
+type trafficBucket struct {
+	slot   int64 // bucket start as a count of trafficBucketWidth since the epoch
+	counts TrafficCounts
+}
+
+// trafficCounter keeps since-start totals plus a ring of fixed-width buckets covering the
+// last hour; it is guarded by the owning RPCEndpoint's mutex
+type trafficCounter struct {
+	since   time.Time
+	total   TrafficCounts
+	buckets [trafficBuckets]trafficBucket
+}
+
+func (c *trafficCounter) record(now time.Time, counts TrafficCounts) {
+	if c.since.IsZero() {
+		c.since = now
+	}
+	c.total.add(counts)
+
+	slot := now.UnixNano() / int64(trafficBucketWidth)
+	bucket := &c.buckets[slot%int64(trafficBuckets)]
+	if bucket.slot != slot {
+		*bucket = trafficBucket{slot: slot}
+	}
+	bucket.counts.add(counts)
+}
+
+func (c *trafficCounter) stats(now time.Time) TrafficStats {
+	stats := TrafficStats{
+		Since:   c.since,
+		Total:   c.total,
+		Windows: make(map[string]TrafficCounts, len(TrafficWindows)),
+	}
+
+	current := now.UnixNano() / int64(trafficBucketWidth)
+	for _, window := range TrafficWindows {
+		oldest := current - int64(window.Duration/trafficBucketWidth) + 1
+		var counts TrafficCounts
+		for i := range c.buckets {
+			if bucket := &c.buckets[i]; bucket.slot >= oldest && bucket.slot <= current {
+				counts.add(bucket.counts)
+			}
+		}
+		stats.Windows[window.Name] = counts
+	}
+
+	return stats
+}
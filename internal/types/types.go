@@ -1,15 +1,18 @@
 package types
 
 import (
+	"encoding/base64"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
 
 // ChainIdentifier represents common chain identifiers
 type ChainIdentifier struct {
-	ChainID   int    `json:"chainId"`
-	Name      string `json:"name"`
-	RPCPath   string `json:"rpcPath"`
+	ChainID int    `json:"chainId"`
+	Name    string `json:"name"`
+	RPCPath string `json:"rpcPath"`
 }
 
 // Supported chains constants
@@ -46,8 +49,28 @@ type Chain struct {
 	NativeCurrencySymbol   string    `json:"nativeCurrencySymbol" db:"native_currency_symbol"`
 	NativeCurrencyDecimals int       `json:"nativeCurrencyDecimals" db:"native_currency_decimals"`
 	BlockExplorerURL       string    `json:"blockExplorerUrl" db:"block_explorer_url"`
+	Type                   string    `json:"type" db:"type"` // evm or solana, evm when empty
 	CreatedAt              time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt              time.Time `json:"updatedAt" db:"updated_at"`
+	// DeletedAt is set on chains listed from the trash
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+}
+
+// Chain types select how a chain's endpoints are health checked and its requests routed
+const (
+	ChainTypeEVM      = "evm"
+	ChainTypeSolana   = "solana"
+	ChainTypeBitcoin  = "bitcoin"
+	ChainTypeBeacon   = "beacon"
+	ChainTypeStarknet = "starknet"
+)
+
+// GetType returns the chain's type, evm when not set
+func (c *Chain) GetType() string {
+	if c.Type == "" {
+		return ChainTypeEVM
+	}
+	return c.Type
 }
 
 // ChainConfig represents chain-specific configuration
@@ -61,6 +84,154 @@ type ChainConfig struct {
 	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
 }
 
+// Failure kinds distinguish why an endpoint became unreachable
+const (
+	FailureDNS        = "dns"        // hostname could not be resolved
+	FailureConnection = "connection" // resolved, but the connection failed or timed out
+)
+
+// Load balancing strategies selectable per chain via the lb_strategy chain config
+const (
+	LBStrategyWeighted   = "weighted"    // spread in proportion to score-adjusted weight (default)
+	LBStrategyRoundRobin = "round-robin" // rotate across available endpoints
+	LBStrategyLatency    = "latency"     // lowest health check response time first
+	LBStrategySticky     = "sticky"      // each client to its endpoint on a consistent-hash ring
+)
+
+// Aggregation methods of the gas oracle selectable per chain via the gas_oracle_method chain config
+const (
+	GasOracleMedian      = "median"       // the middle price of the sampled endpoints (default)
+	GasOracleTrimmedMean = "trimmed-mean" // the mean price without the highest and lowest fifth
+)
+
+// Endpoint transports. HTTP endpoints take proxied requests, and also serve subscriptions when
+// they have a WSURL; WebSocket endpoints only serve subscriptions.
+const (
+	TransportHTTP = "http"
+	TransportWS   = "ws"
+)
+
+// Endpoint capabilities mark the endpoints serving method namespaces most nodes don't: calls of
+// a capability's namespace, e.g. debug_traceTransaction, are only routed to endpoints with it
+const (
+	CapabilityDebug = "debug"
+	CapabilityTrace = "trace"
+)
+
+// TransportForURL returns the transport implied by an endpoint URL's scheme
+func TransportForURL(url string) string {
+	if strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://") {
+		return TransportWS
+	}
+	return TransportHTTP
+}
+
+// Endpoint override states set by operators via the admin API
+const (
+	OverrideAuto      = "auto"       // follow automatic health checks
+	OverrideForceUp   = "force-up"   // always route, regardless of health checks
+	OverrideForceDown = "force-down" // never route, regardless of health checks
+)
+
+// Maintenance window recurrences
+const (
+	RecurrenceNone   = "none"
+	RecurrenceDaily  = "daily"
+	RecurrenceWeekly = "weekly"
+)
+
+// MaintenanceWindow is a scheduled period during which an endpoint is taken out of routing
+type MaintenanceWindow struct {
+	ID          int       `json:"id" db:"id"`
+	EndpointID  int       `json:"endpointId" db:"endpoint_id"`
+	StartsAt    time.Time `json:"startsAt" db:"starts_at"`
+	EndsAt      time.Time `json:"endsAt" db:"ends_at"`
+	Recurrence  string    `json:"recurrence" db:"recurrence"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Active reports whether the window covers t; recurring windows repeat their
+// StartsAt..EndsAt span every day or week from StartsAt onwards
+func (m *MaintenanceWindow) Active(t time.Time) bool {
+	if t.Before(m.StartsAt) {
+		return false
+	}
+
+	var period time.Duration
+	switch m.Recurrence {
+	case RecurrenceDaily:
+		period = 24 * time.Hour
+	case RecurrenceWeekly:
+		period = 7 * 24 * time.Hour
+	default:
+		return t.Before(m.EndsAt)
+	}
+
+	offset := t.Sub(m.StartsAt) % period
+	return offset < m.EndsAt.Sub(m.StartsAt)
+}
+
+// MethodUsageRollup is the request count and latency for one JSON-RPC method on one chain
+// over the hour starting at PeriodStart
+type MethodUsageRollup struct {
+	ID             int       `json:"id" db:"id"`
+	ChainName      string    `json:"chainName" db:"chain_name"`
+	Method         string    `json:"method" db:"method"`
+	PeriodStart    time.Time `json:"periodStart" db:"period_start"`
+	Requests       int64     `json:"requests" db:"requests"`
+	Errors         int64     `json:"errors" db:"errors"`
+	TotalLatencyMs int64     `json:"totalLatencyMs" db:"total_latency_ms"`
+	MaxLatencyMs   int64     `json:"maxLatencyMs" db:"max_latency_ms"`
+}
+
+// Health check rollup granularities
+const (
+	RollupHourly = "hour"
+	RollupDaily  = "day"
+)
+
+// HealthCheckRollup summarizes one endpoint's health checks over the hour or UTC day starting
+// at PeriodStart
+type HealthCheckRollup struct {
+	EndpointID        int       `json:"endpointId" db:"endpoint_id"`
+	Granularity       string    `json:"granularity"`
+	PeriodStart       time.Time `json:"periodStart" db:"period_start"`
+	Checks            int64     `json:"checks" db:"checks"`
+	HealthyChecks     int64     `json:"healthyChecks" db:"healthy_checks"`
+	UptimePercent     float64   `json:"uptimePercent"`
+	AvgResponseTimeMs float64   `json:"avgResponseTimeMs" db:"avg_response_time_ms"`
+	P95ResponseTimeMs int64     `json:"p95ResponseTimeMs" db:"p95_response_time_ms"`
+}
+
+// RollupPeriod returns the length of a rollup period: an hour, or a day for RollupDaily
+func RollupPeriod(granularity string) time.Duration {
+	if granularity == RollupDaily {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// RequestLog is one sampled proxied request. Method is the first call of a batch of
+// BatchSize; Upstream is the last endpoint tried and Status its HTTP status, both empty when
+// no endpoint was tried.
+type RequestLog struct {
+	ID         int       `json:"id" db:"id"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	ChainName  string    `json:"chainName" db:"chain_name"`
+	Method     string    `json:"method" db:"method"`
+	BatchSize  int       `json:"batchSize" db:"batch_size"`
+	Upstream   string    `json:"upstream" db:"upstream"`
+	Attempts   int       `json:"attempts" db:"attempts"`
+	Status     int       `json:"status" db:"status"`
+	DurationMs int64     `json:"durationMs" db:"duration_ms"`
+	Success    bool      `json:"success" db:"success"`
+	Error      string    `json:"error,omitempty" db:"error"`
+	ClientIP   string    `json:"clientIp" db:"client_ip"`
+	ClientKey  string    `json:"clientKey,omitempty" db:"client_key"`
+	TenantID   int       `json:"tenantId,omitempty" db:"tenant_id"`
+}
+
 type RPCEndpoint struct {
 	ID           int       `json:"id" db:"id"`
 	Name         string    `json:"name" db:"name"`
@@ -68,20 +239,93 @@ type RPCEndpoint struct {
 	Weight       int       `json:"weight" db:"weight" yaml:"weight"`
 	Enabled      bool      `json:"enabled" db:"enabled"`
 	ChainID      int       `json:"chainId" db:"chain_id"`
-	ChainName    string    `json:"chainName" db:"-"` // Populated from join
+	ChainName    string    `json:"chainName" db:"-"`                          // Populated from join
+	Transport    string    `json:"transport" db:"transport"`                  // http or ws, implied by the URL scheme when empty
+	WSURL        string    `json:"wsUrl,omitempty" db:"ws_url"`               // an HTTP endpoint's WebSocket URL, for subscriptions
+	GraphQLURL   string    `json:"graphqlUrl,omitempty" db:"graphql_url"`     // an HTTP endpoint's GraphQL URL, e.g. Geth's /graphql
+	Capabilities []string  `json:"capabilities,omitempty" db:"capabilities"`  // namespaces only endpoints with them serve, e.g. debug
+	AuthUsername string    `json:"authUsername,omitempty" db:"auth_username"` // basic auth sent upstream
+	AuthPassword string    `json:"-" db:"auth_password"`                      // never returned by the API
 	Healthy      bool      `json:"healthy"`
 	LastCheck    time.Time `json:"lastCheck"`
 	ResponseTime int64     `json:"responseTime"`
 	BlockNumber  string    `json:"blockNumber"`
-	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
-	FailCount    int       `json:"-"`
-	mu           sync.RWMutex
+	BlockLag     int64     `json:"blockLag"`
+	Syncing      bool      `json:"syncing"`
+	PeerCount    int64     `json:"peerCount"` // -1 when the endpoint doesn't expose net_peerCount
+	Archive      bool      `json:"archive"`   // serves state at deep historical blocks
+	Suspect      bool      `json:"suspect"`   // head diverges from the chain's consensus head
+	GasPriceGwei float64   `json:"gasPriceGwei,omitempty"`
+	// CertExpiresAt is the upstream TLS leaf certificate expiry, nil for plain HTTP endpoints
+	CertExpiresAt *time.Time `json:"certExpiresAt,omitempty"`
+	Score         float64    `json:"score"` // composite health score 0..100, 0 when unhealthy
+	LastError     string     `json:"lastError,omitempty"`
+	FailureKind   string     `json:"failureKind,omitempty"`
+	Override      string     `json:"override,omitempty"`
+	// Draining endpoints are still health checked but receive no new requests
+	Draining      bool  `json:"draining,omitempty"`
+	InMaintenance bool  `json:"inMaintenance,omitempty"`
+	InFlight      int64 `json:"inFlight"`
+	// DegradedUntil is set while the endpoint is cooling down after upstream rate limiting
+	DegradedUntil *time.Time `json:"degradedUntil,omitempty"`
+	// Source names where a discovered endpoint came from, e.g. kubernetes; it is empty for
+	// endpoints configured in the database, a chains file or the environment
+	Source    string     `json:"source,omitempty"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"` // set on endpoints listed from the trash
+	FailCount int        `json:"-"`
+	traffic   trafficCounter
+	mu        sync.RWMutex
+}
+
+// Discovered reports whether the endpoint was found by a discovery source rather than configured
+func (e *RPCEndpoint) Discovered() bool {
+	return e.Source != ""
+}
+
+// GetTransport returns the endpoint's transport, implied by its URL when not set
+func (e *RPCEndpoint) GetTransport() string {
+	if e.Transport != "" {
+		return e.Transport
+	}
+	return TransportForURL(e.URL)
+}
+
+// ProxiesRequests reports whether the endpoint takes proxied JSON-RPC requests over HTTP
+func (e *RPCEndpoint) ProxiesRequests() bool {
+	return e.GetTransport() == TransportHTTP
+}
+
+// Authorization returns the Authorization header value for the endpoint's basic auth
+// credentials, or "" when it has none
+func (e *RPCEndpoint) Authorization() string {
+	if e.AuthUsername == "" && e.AuthPassword == "" {
+		return ""
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(e.AuthUsername+":"+e.AuthPassword))
+}
+
+// HasCapability reports whether the endpoint serves a capability's method namespace
+func (e *RPCEndpoint) HasCapability(capability string) bool {
+	return slices.Contains(e.Capabilities, capability)
+}
+
+// SubscriptionURL returns the WebSocket URL the endpoint serves subscriptions on, or "" when it
+// serves none
+func (e *RPCEndpoint) SubscriptionURL() string {
+	if e.GetTransport() == TransportWS {
+		return e.URL
+	}
+	return e.WSURL
 }
 
 func (e *RPCEndpoint) SetHealthy(healthy bool) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	if e.Healthy != healthy {
+		InvalidateRouting()
+	}
 	e.Healthy = healthy
 	e.LastCheck = time.Now()
 	if healthy {
@@ -91,12 +335,256 @@ func (e *RPCEndpoint) SetHealthy(healthy bool) {
 	}
 }
 
+// MarkUnhealthy marks the endpoint unhealthy and records why
+func (e *RPCEndpoint) MarkUnhealthy(reason string) {
+	e.SetHealthy(false)
+	e.SetLastError(reason)
+}
+
+// MarkUnreachable marks the endpoint unhealthy because of a DNS or connection failure
+func (e *RPCEndpoint) MarkUnreachable(kind, reason string) {
+	e.SetHealthy(false)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.LastError = reason
+	e.FailureKind = kind
+}
+
+// SetLastError records the latest failure reason; it also clears any failure kind
+func (e *RPCEndpoint) SetLastError(msg string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.LastError = msg
+	e.FailureKind = ""
+}
+
+func (e *RPCEndpoint) GetFailureKind() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.FailureKind
+}
+
+func (e *RPCEndpoint) GetLastError() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.LastError
+}
+
 func (e *RPCEndpoint) IsHealthy() bool {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 	return e.Healthy
 }
 
+func (e *RPCEndpoint) SetOverride(override string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if override == OverrideAuto {
+		override = ""
+	}
+	if e.Override != override {
+		InvalidateRouting()
+	}
+	e.Override = override
+}
+
+func (e *RPCEndpoint) GetOverride() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.Override == "" {
+		return OverrideAuto
+	}
+	return e.Override
+}
+
+// IsAvailable reports whether the endpoint should receive traffic, honoring operator overrides
+func (e *RPCEndpoint) IsAvailable() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	switch e.Override {
+	case OverrideForceUp:
+		return true
+	case OverrideForceDown:
+		return false
+	}
+	return e.Healthy
+}
+
+// MarkDegraded demotes the endpoint to last-resort routing for the cooldown period
+func (e *RPCEndpoint) MarkDegraded(cooldown time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	until := time.Now().Add(cooldown)
+	e.DegradedUntil = &until
+	InvalidateRouting()
+}
+
+// IsDegraded reports whether the endpoint is still in its rate-limit cooldown; endpoints are
+// promoted back automatically once the cooldown expires
+func (e *RPCEndpoint) IsDegraded() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.DegradedUntil == nil {
+		return false
+	}
+	if time.Now().After(*e.DegradedUntil) {
+		e.DegradedUntil = nil
+		InvalidateRouting()
+		return false
+	}
+	return true
+}
+
+// InheritState copies runtime health state from the endpoint this one replaces, so a config
+// change that keeps the URL doesn't take the endpoint out of rotation until the next check
+func (e *RPCEndpoint) InheritState(from *RPCEndpoint) {
+	from.mu.RLock()
+	defer from.mu.RUnlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.Healthy = from.Healthy
+	e.LastCheck = from.LastCheck
+	e.ResponseTime = from.ResponseTime
+	e.BlockNumber = from.BlockNumber
+	e.BlockLag = from.BlockLag
+	e.Syncing = from.Syncing
+	e.PeerCount = from.PeerCount
+	e.Archive = from.Archive
+	e.Suspect = from.Suspect
+	e.GasPriceGwei = from.GasPriceGwei
+	e.CertExpiresAt = from.CertExpiresAt
+	e.Score = from.Score
+	e.LastError = from.LastError
+	e.FailureKind = from.FailureKind
+	e.Override = from.Override
+	e.DegradedUntil = from.DegradedUntil
+	e.Draining = from.Draining
+	e.FailCount = from.FailCount
+	e.traffic = from.traffic
+	InvalidateRouting()
+}
+
+// EndpointHealthState is the outcome of an endpoint's latest health check, as shared between
+// proxy replicas
+type EndpointHealthState struct {
+	Healthy      bool      `json:"healthy"`
+	CheckedAt    time.Time `json:"checkedAt"`
+	ResponseTime int64     `json:"responseTime"`
+	BlockNumber  string    `json:"blockNumber"`
+	Syncing      bool      `json:"syncing"`
+	PeerCount    int64     `json:"peerCount"`
+	LastError    string    `json:"lastError,omitempty"`
+	FailureKind  string    `json:"failureKind,omitempty"`
+}
+
+// HealthState returns the outcome of the endpoint's latest health check
+func (e *RPCEndpoint) HealthState() EndpointHealthState {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return EndpointHealthState{
+		Healthy:      e.Healthy,
+		CheckedAt:    e.LastCheck,
+		ResponseTime: e.ResponseTime,
+		BlockNumber:  e.BlockNumber,
+		Syncing:      e.Syncing,
+		PeerCount:    e.PeerCount,
+		LastError:    e.LastError,
+		FailureKind:  e.FailureKind,
+	}
+}
+
+// ApplyHealthState records a health check made elsewhere as if the endpoint had been checked here
+func (e *RPCEndpoint) ApplyHealthState(state EndpointHealthState) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Healthy != state.Healthy || e.ResponseTime != state.ResponseTime {
+		InvalidateRouting()
+	}
+	e.Healthy = state.Healthy
+	e.LastCheck = state.CheckedAt
+	e.ResponseTime = state.ResponseTime
+	e.BlockNumber = state.BlockNumber
+	e.Syncing = state.Syncing
+	e.PeerCount = state.PeerCount
+	e.LastError = state.LastError
+	e.FailureKind = state.FailureKind
+	if state.Healthy {
+		e.FailCount = 0
+	} else {
+		e.FailCount++
+	}
+}
+
+func (e *RPCEndpoint) SetInMaintenance(inMaintenance bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.InMaintenance = inMaintenance
+}
+
+func (e *RPCEndpoint) IsInMaintenance() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.InMaintenance
+}
+
+func (e *RPCEndpoint) SetDraining(draining bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Draining != draining {
+		InvalidateRouting()
+	}
+	e.Draining = draining
+}
+
+func (e *RPCEndpoint) IsDraining() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Draining
+}
+
+// BeginRequest and EndRequest bracket a proxied request so drains can tell when in-flight work is done
+func (e *RPCEndpoint) BeginRequest() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.InFlight++
+}
+
+// EndRequest also records the attempt in the endpoint's traffic counters
+func (e *RPCEndpoint) EndRequest(success bool, bytesSent, bytesReceived int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.InFlight--
+
+	counts := TrafficCounts{Requests: 1, BytesSent: bytesSent, BytesReceived: bytesReceived}
+	if success {
+		counts.Successes = 1
+	} else {
+		counts.Failures = 1
+	}
+	e.traffic.record(time.Now(), counts)
+}
+
+// AbortRequest ends a request the client gave up on, without counting it against the endpoint
+func (e *RPCEndpoint) AbortRequest() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.InFlight--
+}
+
+// GetTrafficStats returns request counters since the endpoint was first used and over TrafficWindows
+func (e *RPCEndpoint) GetTrafficStats() TrafficStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.traffic.stats(time.Now())
+}
+
+func (e *RPCEndpoint) GetInFlight() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.InFlight
+}
+
 func (e *RPCEndpoint) GetFailCount() int {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -106,15 +594,135 @@ func (e *RPCEndpoint) GetFailCount() int {
 func (e *RPCEndpoint) SetResponseTime(rt int64) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	if e.ResponseTime != rt {
+		InvalidateRouting()
+	}
 	e.ResponseTime = rt
 }
 
+func (e *RPCEndpoint) GetResponseTime() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.ResponseTime
+}
+
 func (e *RPCEndpoint) SetBlockNumber(bn string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.BlockNumber = bn
 }
 
+func (e *RPCEndpoint) GetBlockNumber() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.BlockNumber
+}
+
+// SetBlockLag records how many blocks the endpoint trails the chain's highest known block
+func (e *RPCEndpoint) SetBlockLag(lag int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.BlockLag = lag
+}
+
+func (e *RPCEndpoint) GetBlockLag() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.BlockLag
+}
+
+// SetSyncState records the result of the eth_syncing/net_peerCount probes
+func (e *RPCEndpoint) SetSyncState(syncing bool, peerCount int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Syncing = syncing
+	e.PeerCount = peerCount
+}
+
+func (e *RPCEndpoint) GetSyncState() (bool, int64) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Syncing, e.PeerCount
+}
+
+func (e *RPCEndpoint) SetSuspect(suspect bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Suspect = suspect
+}
+
+func (e *RPCEndpoint) IsSuspect() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Suspect
+}
+
+func (e *RPCEndpoint) SetGasPriceGwei(gwei float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.GasPriceGwei = gwei
+}
+
+func (e *RPCEndpoint) GetGasPriceGwei() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.GasPriceGwei
+}
+
+func (e *RPCEndpoint) SetCertExpiresAt(expiresAt time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.CertExpiresAt = &expiresAt
+}
+
+func (e *RPCEndpoint) GetCertExpiresAt() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.CertExpiresAt == nil {
+		return time.Time{}
+	}
+	return *e.CertExpiresAt
+}
+
+func (e *RPCEndpoint) SetArchive(archive bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Archive != archive {
+		InvalidateRouting()
+	}
+	e.Archive = archive
+}
+
+func (e *RPCEndpoint) IsArchive() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Archive
+}
+
+func (e *RPCEndpoint) SetScore(score float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Score != score {
+		InvalidateRouting()
+	}
+	e.Score = score
+}
+
+func (e *RPCEndpoint) GetScore() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Score
+}
+
+// EffectiveWeight scales the configured weight by the health score; unscored endpoints keep full weight
+func (e *RPCEndpoint) EffectiveWeight() float64 {
+	score := e.GetScore()
+	if score <= 0 {
+		return float64(e.Weight)
+	}
+	return float64(e.Weight) * score / 100
+}
+
 func (e *RPCEndpoint) IncrementFailCount() int {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -130,15 +738,15 @@ type JSONRPCRequest struct {
 }
 
 type JSONRPCResponse struct {
-	Jsonrpc string      `json:"jsonrpc"`
-	Result  interface{} `json:"result,omitempty"`
+	Jsonrpc string        `json:"jsonrpc"`
+	Result  interface{}   `json:"result,omitempty"`
 	Error   *JSONRPCError `json:"error,omitempty"`
-	ID      interface{} `json:"id"`
+	ID      interface{}   `json:"id"`
 }
 
 type JSONRPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 }
 
@@ -150,21 +758,31 @@ type ChainHealthStatus struct {
 	TotalEndpoints     int            `json:"totalEndpoints"`
 	HealthyCount       int            `json:"healthyCount"`
 	CurrentRPC         string         `json:"currentRPC"`
+	HighestBlock       int64          `json:"highestBlock"`
+	ConsensusHead      int64          `json:"consensusHead"`
+	MaxBlockLag        int64          `json:"maxBlockLag"`
+	DegradedCount      int            `json:"degradedCount"`
+	ExpiringCerts      int            `json:"expiringCerts"`
+	DNSFailures        int            `json:"dnsFailures"`
+	MaintenanceCount   int            `json:"maintenanceCount"`
 }
 
 // MultiChainHealthStatus represents overall proxy health status
 type MultiChainHealthStatus struct {
-	Proxy      string                        `json:"proxy"`
-	TotalChains int                          `json:"totalChains"`
-	HealthyChains int                        `json:"healthyChains"`
-	Chains     map[string]*ChainHealthStatus `json:"chains"`
-	Timestamp  time.Time                     `json:"timestamp"`
+	Proxy         string                        `json:"proxy"`
+	TotalChains   int                           `json:"totalChains"`
+	HealthyChains int                           `json:"healthyChains"`
+	Chains        map[string]*ChainHealthStatus `json:"chains"`
+	Timestamp     time.Time                     `json:"timestamp"`
 }
 
 // Legacy HealthStatus for backward compatibility
 type HealthStatus struct {
-	Proxy        string        `json:"proxy"`
-	CurrentRPC   string        `json:"currentRPC"`
-	RPCEndpoints []*RPCEndpoint `json:"rpcEndpoints"`
-	Chain        string        `json:"chain,omitempty"`
-}
\ No newline at end of file
+	Proxy         string         `json:"proxy"`
+	CurrentRPC    string         `json:"currentRPC"`
+	RPCEndpoints  []*RPCEndpoint `json:"rpcEndpoints"`
+	Chain         string         `json:"chain,omitempty"`
+	HighestBlock  int64          `json:"highestBlock,omitempty"`
+	ConsensusHead int64          `json:"consensusHead,omitempty"`
+	MaxBlockLag   int64          `json:"maxBlockLag,omitempty"`
+}
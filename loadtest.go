@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"rpc-proxy/internal/loadtest"
+)
+
+// isLoadtest reports whether the binary was started as `rpc-proxy loadtest` or `rpc-proxy bench`
+func isLoadtest(args []string) bool {
+	return len(args) >= 2 && (args[1] == "loadtest" || args[1] == "bench")
+}
+
+// headerFlags collects repeated -H "Name: value" flags
+type headerFlags http.Header
+
+func (h headerFlags) String() string {
+	return fmt.Sprint(http.Header(h))
+}
+
+func (h headerFlags) Set(value string) error {
+	name, headerValue, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header must be \"Name: value\"")
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(headerValue))
+	return nil
+}
+
+// runLoadtest fires a mix of JSON-RPC calls at the proxy or at endpoints directly, prints
+// throughput and latency percentiles and returns the process exit code: 1 when no request
+// succeeded or the run breaks the -max-error-rate or -max-p99 thresholds, 2 on invalid flags
+func runLoadtest(args []string) int {
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	target := flags.String("url", "http://localhost:8888/rpc/ethereum", "comma-separated JSON-RPC URLs to load, the proxy or upstream endpoints; requests go to them in turn")
+	mix := flags.String("mix", "eth_blockNumber:5,eth_chainId:2,eth_getBalance:2,eth_getBlockByNumber:1", "comma-separated method:weight mix of calls")
+	concurrency := flags.Int("c", 10, "requests in flight at once")
+	duration := flags.Duration("d", 10*time.Second, "how long to run")
+	requests := flags.Int("n", 0, "stop after this many requests (0 runs for -d)")
+	rate := flags.Float64("rate", 0, "cap on requests per second (0 is unlimited)")
+	batch := flags.Int("batch", 1, "calls per request, sent as a JSON-RPC batch when above 1")
+	timeout := flags.Duration("timeout", 10*time.Second, "per-request timeout")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	maxErrorRate := flags.Float64("max-error-rate", 1, "fail when the share of failed requests is above this (0 to 1)")
+	maxP99 := flags.Duration("max-p99", 0, "fail when the p99 latency is above this (0 disables)")
+	headers := headerFlags{}
+	flags.Var(headers, "H", "header to send, e.g. -H \"X-API-Key: secret\"; repeatable")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	calls, err := loadtest.ParseMix(*mix)
+	if err != nil {
+		fmt.Printf("Invalid -mix: %v\n", err)
+		return 2
+	}
+	if *concurrency <= 0 || *batch <= 0 || *requests < 0 || *rate < 0 || *timeout <= 0 {
+		fmt.Println("-c, -batch and -timeout must be positive, -n and -rate must not be negative")
+		return 2
+	}
+	if *duration <= 0 && *requests == 0 {
+		fmt.Println("Set a positive -d or -n")
+		return 2
+	}
+
+	var targets []string
+	for _, url := range strings.Split(*target, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			targets = append(targets, url)
+		}
+	}
+
+	// Ctrl-C ends the run early but still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	opts := loadtest.Options{
+		Targets:     targets,
+		Calls:       calls,
+		Concurrency: *concurrency,
+		Requests:    *requests,
+		Rate:        *rate,
+		Batch:       *batch,
+		Timeout:     *timeout,
+		Headers:     http.Header(headers),
+	}
+	if *requests == 0 {
+		opts.Duration = *duration
+	}
+
+	if !*asJSON {
+		fmt.Printf("Load testing %s with %d workers...\n", strings.Join(targets, ", "), *concurrency)
+	}
+	report, err := loadtest.Run(ctx, opts)
+	if err != nil {
+		fmt.Printf("FAIL %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		printLoadtestReport(report)
+	}
+
+	failed := false
+	if report.Succeeded == 0 {
+		fmt.Fprintln(os.Stderr, "FAIL no request succeeded")
+		failed = true
+	} else if report.ErrorRate > *maxErrorRate {
+		fmt.Fprintf(os.Stderr, "FAIL error rate %.2f%% is above %.2f%%\n", report.ErrorRate*100, *maxErrorRate*100)
+		failed = true
+	}
+	if *maxP99 > 0 && report.Latency.P99 > float64(*maxP99)/float64(time.Millisecond) {
+		fmt.Fprintf(os.Stderr, "FAIL p99 latency %.1fms is above %v\n", report.Latency.P99, *maxP99)
+		failed = true
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func printLoadtestReport(report *loadtest.Report) {
+	fmt.Println()
+	fmt.Printf("Duration:    %v\n", (time.Duration(report.DurationMs) * time.Millisecond).Round(time.Millisecond))
+	fmt.Printf("Requests:    %d (%.1f/s)\n", report.Requests, report.Throughput)
+	fmt.Printf("Succeeded:   %d\n", report.Succeeded)
+	fmt.Printf("Failed:      %d (%.2f%%)", report.Failed, report.ErrorRate*100)
+	if report.Failed > 0 {
+		var kinds []string
+		for _, kind := range []string{loadtest.FailureTransport, loadtest.FailureHTTP, loadtest.FailureRPC, loadtest.FailureInvalid} {
+			if count := report.Failures[kind]; count > 0 {
+				kinds = append(kinds, fmt.Sprintf("%d %s", count, kind))
+			}
+		}
+		fmt.Printf(": %s", strings.Join(kinds, ", "))
+	}
+	fmt.Println()
+	fmt.Printf("Latency:     %s\n", formatLatency(report.Latency))
+
+	printBreakdown := func(title string, rows []loadtest.BreakdownRow) {
+		fmt.Printf("\n%s\n", title)
+		for _, row := range rows {
+			fmt.Printf("  %-32s %8d requests %6d failed  %s\n", row.Name, row.Requests, row.Failed, formatLatency(row.Latency))
+		}
+	}
+	printBreakdown("By method:", report.Methods)
+	if len(report.ByTarget) > 0 {
+		printBreakdown("By target:", report.ByTarget)
+	}
+}
+
+func formatLatency(l loadtest.LatencySummary) string {
+	return fmt.Sprintf("mean %.1fms  p50 %.1fms  p90 %.1fms  p95 %.1fms  p99 %.1fms  max %.1fms",
+		l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+}
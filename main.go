@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -10,15 +9,47 @@ import (
 	"syscall"
 	"time"
 
+	"rpc-proxy/internal/analytics"
+	"rpc-proxy/internal/app"
+	"rpc-proxy/internal/cluster"
 	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/discovery"
+	"rpc-proxy/internal/dnscache"
+	"rpc-proxy/internal/flags"
+	"rpc-proxy/internal/handlers"
+	"rpc-proxy/internal/health"
+	"rpc-proxy/internal/jobs"
+	"rpc-proxy/internal/metrics"
 	"rpc-proxy/internal/proxy"
+	"rpc-proxy/internal/redis"
+	"rpc-proxy/internal/repository"
+	"rpc-proxy/internal/repository/gorm"
+	"rpc-proxy/internal/scheduler"
+	"rpc-proxy/internal/tenant"
+	"rpc-proxy/internal/transport"
 )
 
 func main() {
-	cfg, err := config.Load()
+	if isCheckConfig(os.Args) {
+		os.Exit(checkConfig())
+	}
+	if isMigrate(os.Args) {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	if isLoadtest(os.Args) {
+		os.Exit(runLoadtest(os.Args[2:]))
+	}
+
+	application, err := app.New()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	// Deferred first so the shared connection closes after every job and handler using it
+	defer func() {
+		log.Println("Closing database connection...")
+		application.Close()
+	}()
+	cfg, db := application.Config, application.DB
 
 	log.Printf("Configuration loaded successfully")
 	log.Printf("Supported chains: %d", len(cfg.Chains))
@@ -35,9 +66,118 @@ func main() {
 		log.Fatalf("Failed to create multi-chain health checker")
 	}
 
+	// Recurring maintenance runs on the scheduler; each task can be retimed or disabled with
+	// job_<name>_interval and job_<name>_enabled settings
+	jobScheduler := scheduler.New()
+	jobScheduler.Register(scheduler.Task{
+		Name:        "cert_expiry_scan",
+		Description: "Summarize upstream TLS certificates nearing expiry",
+		Interval:    24 * time.Hour,
+		Run:         multiChainHealthChecker.ScanCertExpiry,
+	})
+
+	// Persist health check results and prune old history when a database is connected
+	if db != nil {
+		healthRepo := gorm.NewHealthCheckRepository(db)
+		multiChainHealthChecker.SetHealthCheckRepository(healthRepo)
+
+		if windows, err := gorm.NewMaintenanceWindowRepository(db).GetAll(); err != nil {
+			log.Printf("Warning: Failed to load maintenance windows: %v", err)
+		} else {
+			multiChainHealthChecker.SetMaintenanceWindows(windows)
+		}
+
+		jobScheduler.Register(scheduler.Task{
+			Name:        "health_check_retention",
+			Description: "Downsample and delete old health checks",
+			Interval:    time.Hour,
+			RunOnStart:  true,
+			Run:         jobs.NewRetentionJob(healthRepo, gorm.NewSettingsRepository(db)).Run,
+		})
+
+		// Summarize raw health checks into hourly and daily uptime and latency rollups
+		jobScheduler.Register(scheduler.Task{
+			Name:        "health_check_rollup",
+			Description: "Roll health checks up into hourly and daily uptime",
+			Interval:    cfg.Analytics.HealthRollupInterval,
+			RunOnStart:  true,
+			Run:         jobs.NewHealthRollupJob(gorm.NewHealthRollupRepository(db)).Run,
+		})
+	}
+
 	// Create proxy server with multi-chain support
 	proxyServer := proxy.NewServer(cfg, multiChainHealthChecker)
 
+	// Resolve upstream hostnames out-of-band and share cached addresses between proxy and health checks
+	var dial transport.DialFunc
+	if cfg.DNS.CacheEnabled {
+		dnsCache := dnscache.New(cfg.DNS.CacheTTL, cfg.DNS.CacheMaxStale, cfg.Upstream.Dialer())
+		dnsCtx, stopDNS := context.WithCancel(context.Background())
+		defer stopDNS()
+		go dnsCache.Run(dnsCtx)
+		dial = dnsCache.DialContext
+	}
+
+	// Proxy and health checks share one tuned transport, so upstream connections and TLS
+	// sessions are reused between them
+	upstreamTransport := transport.New(cfg.Upstream, dial)
+	switch cfg.Upstream.FixtureMode {
+	case transport.FixtureRecord:
+		log.Printf("Recording upstream calls to fixtures in %s", cfg.Upstream.FixtureDir)
+	case transport.FixtureReplay:
+		log.Printf("Replaying upstream calls from fixtures in %s; upstreams are not contacted", cfg.Upstream.FixtureDir)
+	}
+	multiChainHealthChecker.SetTransport(upstreamTransport)
+	proxyServer.SetTransport(upstreamTransport)
+
+	// Keep connections to healthy endpoints open so the first request after a quiet period
+	// doesn't pay for the TCP and TLS handshakes
+	jobScheduler.Register(scheduler.Task{
+		Name:        "connection_prewarm",
+		Description: "Open and keep warm connections to healthy endpoints",
+		Interval:    cfg.Upstream.PrewarmInterval,
+		Run: func(ctx context.Context) error {
+			var urls []string
+			for _, chainName := range multiChainHealthChecker.GetSupportedChains() {
+				for _, endpoint := range multiChainHealthChecker.GetHealthyEndpoints(chainName) {
+					urls = append(urls, endpoint.URL)
+				}
+			}
+			return upstreamTransport.Prewarm(ctx, urls)
+		},
+	})
+
+	// Share endpoint health, rate limit cooldowns and coalesced calls with other replicas
+	if cfg.Redis.URL != "" {
+		if client, err := redis.New(cfg.Redis.URL); err != nil {
+			log.Printf("Warning: Shared health state disabled: %v", err)
+		} else {
+			defer client.Close()
+			multiChainHealthChecker.SetSharedState(health.NewSharedState(client, cfg.Redis.Prefix))
+			proxyServer.SetSharedCoalescing(client, cfg.Redis.Prefix)
+			log.Printf("Sharing health state and coalesced calls through redis at %s", client.Address())
+			if cfg.Redis.SharedLimits {
+				proxyServer.SetSharedLimits(client, cfg.Redis.Prefix)
+				log.Printf("Sharing rate limits through redis at %s", client.Address())
+			}
+		}
+	}
+
+	// Export per-endpoint latency and traffic to a long-term time-series store
+	if cfg.Metrics.Backend != "" {
+		if sink, err := metrics.New(cfg.Metrics.Sink()); err != nil {
+			log.Printf("Warning: Metrics export disabled: %v", err)
+		} else {
+			defer sink.Close()
+			collector := metrics.NewCollector()
+			proxyServer.SetMetricsCollector(collector)
+			multiChainHealthChecker.SetMetricsCollector(collector)
+			metricsExportJob := jobs.NewMetricsExportJob(collector, sink, cfg.Metrics.Interval)
+			metricsExportJob.Start()
+			defer metricsExportJob.Stop()
+		}
+	}
+
 	// Start health checking for all chains
 	multiChainHealthChecker.Start()
 	defer func() {
@@ -45,24 +185,254 @@ func main() {
 		multiChainHealthChecker.Stop()
 	}()
 
-	server := &http.Server{
-		Addr:    fmt.Sprintf("0.0.0.0:%d", cfg.Server.Port),
-		Handler: proxyServer.Handler(),
+	// Feature flags of experimental subsystems live in the settings table; without a database
+	// every flag keeps its default
+	var flagSettings repository.SettingsRepository
+	if db != nil {
+		flagSettings = gorm.NewSettingsRepository(db)
+	}
+	featureFlags := flags.NewStore(flagSettings, cfg.Settings.FlagCacheTTL)
+
+	// Admin API; database-backed routes are only available when a database is connected
+	adminMux := http.NewServeMux()
+	multiChainAdminHandler := handlers.NewMultiChainAdminHandler(cfg, multiChainHealthChecker, db)
+	multiChainAdminHandler.SetMethodTracker(proxyServer.MethodTracker())
+	multiChainAdminHandler.SetClientTracker(proxyServer.ClientTracker())
+	multiChainAdminHandler.SetRefusalTracker(proxyServer.RefusalTracker())
+	multiChainAdminHandler.SetUpstreamTransport(upstreamTransport)
+	multiChainAdminHandler.RegisterRoutes(adminMux)
+	multiChainAdminHandler.SetScheduler(jobScheduler)
+	multiChainAdminHandler.SetFlags(featureFlags)
+	var reloadJob *jobs.ReloadJob
+	var settingsJob *jobs.SettingsJob
+	if db != nil {
+		adminHandler := handlers.NewAdminHandler(db)
+		if cfg.Remote.Backend == "" {
+			// Apply settings table changes (timeouts, intervals, connection limits) without restart
+			settingsJob = jobs.NewSettingsJob(cfg, multiChainHealthChecker, proxyServer,
+				gorm.NewSettingsRepository(db), cfg.Settings.PollInterval)
+			settingsJob.SetScheduler(jobScheduler)
+			settingsJob.Start()
+			defer settingsJob.Stop()
+			adminHandler.SetSettingsJob(settingsJob)
+			multiChainAdminHandler.SetSettingsJob(settingsJob)
+		}
+		adminHandler.RegisterRoutes(adminMux)
+
+		// Snapshot the configuration so changes made while the proxy was down, and the first
+		// change made through the admin API, can be diffed and rolled back
+		if _, err := multiChainAdminHandler.RecordRevision("startup"); err != nil {
+			log.Printf("Warning: Failed to record config revision: %v", err)
+		}
+		if multiChainAdminHandler.RevisionsEnabled() {
+			// Capture changes made directly in the database between admin requests
+			jobScheduler.Register(scheduler.Task{
+				Name:        "config_snapshot",
+				Description: "Record a config revision if the configuration changed",
+				Interval:    time.Hour,
+				Run: func(ctx context.Context) error {
+					_, err := multiChainAdminHandler.RecordRevision("scheduled snapshot")
+					return err
+				},
+			})
+		}
+
+		// Persist per-method request counts as hourly rollups
+		jobScheduler.Register(scheduler.Task{
+			Name:        "method_usage_rollup",
+			Description: "Write per-method request counts to hourly rollups",
+			Interval:    cfg.Analytics.RollupInterval,
+			RunOnStop:   true,
+			Run: jobs.NewMethodRollupJob(proxyServer.MethodTracker(),
+				gorm.NewMethodUsageRepository(db)).Run,
+		})
+
+		// Resolve X-API-Key to tenants so revoked keys and suspended tenants are refused
+		tenantRegistry := tenant.NewRegistry(gorm.NewTenantRepository(db), gorm.NewAPIKeyRepository(db),
+			gorm.NewPlanRepository(db), gorm.NewAPIKeyUsageRepository(db))
+		if err := tenantRegistry.Reload(); err != nil {
+			log.Printf("Warning: Failed to load tenants: %v", err)
+		}
+		proxyServer.SetTenantRegistry(tenantRegistry)
+		multiChainAdminHandler.SetTenantRegistry(tenantRegistry)
+		// Tenants read their own stats with their API key on the proxy port
+		proxyServer.HandleTenant("/tenant/stats", multiChainAdminHandler.ServeTenantStats)
+		jobScheduler.Register(scheduler.Task{
+			Name:        "tenant_sync",
+			Description: "Revoke rotated API keys past their grace window and reload tenants and API keys",
+			Interval:    tenant.SyncInterval,
+			Run:         tenantRegistry.Run,
+		})
+
+		// Meter each tenant API key's calls per day
+		jobScheduler.Register(scheduler.Task{
+			Name:        "api_key_usage_flush",
+			Description: "Write per-API-key daily usage to the database",
+			Interval:    cfg.Analytics.UsageFlushInterval,
+			RunOnStop:   true,
+			Run: jobs.NewUsageMeterJob(proxyServer.UsageMeter(),
+				gorm.NewAPIKeyUsageRepository(db)).Run,
+		})
+
+		// Register this instance so every replica can report on the whole fleet; deferred
+		// before the scheduler's stop so the last heartbeat can't re-register it
+		instance := cluster.New(gorm.NewInstanceRepository(db), multiChainHealthChecker, app.Build().Label())
+		defer instance.Deregister()
+		multiChainAdminHandler.SetCluster(instance)
+		jobScheduler.Register(scheduler.Task{
+			Name:        "instance_heartbeat",
+			Description: "Record this instance's health and traffic for the cluster view",
+			Interval:    cluster.HeartbeatInterval,
+			RunOnStart:  true,
+			Run:         instance.Run,
+		})
+		log.Printf("Registered as instance %s", instance.ID())
+
+		// Keep a sample of proxied requests for forensics
+		if cfg.RequestLog.Enabled() {
+			requestLogger := analytics.NewRequestLogger(cfg.RequestLog.SampleRate, cfg.RequestLog.Errors)
+			proxyServer.SetRequestLogger(requestLogger)
+			requestLogJob := jobs.NewRequestLogJob(requestLogger, gorm.NewRequestLogRepository(db),
+				cfg.RequestLog.FlushInterval, cfg.RequestLog.Retention)
+			requestLogJob.Start()
+			defer requestLogJob.Stop()
+		}
+
+		// Pick up chain, endpoint and chain config edits made directly in the database,
+		// unless chains come from a file or a remote store
+		if cfg.App.ChainsFile == "" && cfg.Remote.Backend == "" {
+			reloadJob = jobs.NewReloadJob(cfg, multiChainHealthChecker, gorm.NewChainRepository(db),
+				gorm.NewRPCEndpointRepository(db), gorm.NewChainConfigRepository(db), cfg.Reload.Interval)
+			// Each replica reports the config revision it applied, to check rollouts converged
+			reloadJob.SetRevisions(gorm.NewConfigRevisionRepository(db), settingsJob)
+			instance.SetConfigEpoch(reloadJob.Epoch)
+		}
+	}
+	if db == nil && cfg.RequestLog.Enabled() {
+		log.Printf("Warning: Request logging requires a database, no requests will be logged")
+	}
+	if cfg.App.ChainsFile != "" {
+		// Re-read the chains file when it changes and on SIGHUP
+		reloadJob = jobs.NewFileReloadJob(cfg, multiChainHealthChecker, cfg.App.ChainsFile,
+			cfg.App.WatchChainsFile, cfg.Reload.Interval)
+	}
+	if cfg.ChainsSource == config.ChainsFromRemote {
+		// Apply chain and settings changes written to Consul or etcd as soon as they land
+		store, err := cfg.Remote.Store()
+		if err != nil {
+			log.Fatalf("Failed to create remote config store: %v", err)
+		}
+		reloadJob = jobs.NewRemoteReloadJob(cfg, multiChainHealthChecker, store, cfg.Reload.Interval)
+		remoteSettingsJob := jobs.NewSettingsJob(cfg, multiChainHealthChecker, proxyServer, nil, 0)
+		remoteSettingsJob.SetScheduler(jobScheduler)
+		remoteJob := jobs.NewRemoteJob(store, reloadJob, remoteSettingsJob)
+		remoteJob.Start()
+		defer remoteJob.Stop()
+	}
+	if reloadJob != nil {
+		reloadJob.Start()
+		defer reloadJob.Stop()
+		multiChainAdminHandler.SetReloadJob(reloadJob)
+	}
+
+	// Register node endpoints found in DNS or Kubernetes for chains with dns_srv, dns_name or
+	// k8s_selector chain configs
+	discoverySources := []discovery.Source{discovery.NewDNS()}
+	if source, err := discovery.NewKubernetes(cfg.Discovery.KubernetesAPIURL); err == nil {
+		discoverySources = append(discoverySources, source)
+	}
+	jobScheduler.Register(scheduler.Task{
+		Name:        "endpoint_discovery",
+		Description: "Register and deregister discovered endpoints",
+		Interval:    cfg.Discovery.Interval,
+		RunOnStart:  true,
+		Run:         discovery.NewJob(cfg, multiChainHealthChecker, discoverySources...).Run,
+	})
+
+	// Apply job settings saved in the database before the first runs are scheduled
+	if settingsJob != nil {
+		if _, err := settingsJob.Run(); err != nil {
+			log.Printf("Warning: Failed to apply job settings: %v", err)
+		}
+	}
+	jobScheduler.Start()
+	defer jobScheduler.Stop()
+
+	mux := http.NewServeMux()
+	handlers.RegisterDocsRoutes(mux)
+	// ADMIN_PORT moves the admin API off the public listener
+	adminRoutes := mux
+	var adminServer *http.Server
+	if cfg.Admin.Port != 0 {
+		adminRoutes = http.NewServeMux()
+		adminServer = proxy.NewHTTPServer(config.ServerConfig{Port: cfg.Admin.Port, Mode: config.ServerModeStandard}, adminRoutes)
+	}
+	// Every successful admin change is versioned in config_revisions
+	adminAPI := multiChainAdminHandler.TrackRevisions(adminMux)
+	switch {
+	case cfg.Admin.APIKey != "":
+		adminRoutes.Handle("/api/v1/", handlers.RequireAPIKey(cfg.Admin.APIKey, handlers.APIv1(adminAPI)))
+		adminRoutes.Handle("/admin/", handlers.RequireAPIKey(cfg.Admin.APIKey, handlers.Deprecated(adminAPI)))
+	case cfg.Admin.Insecure:
+		log.Printf("Warning: ADMIN_INSECURE is set, the admin API is served without a key")
+		adminRoutes.Handle("/api/v1/", handlers.APIv1(adminAPI))
+		adminRoutes.Handle("/admin/", handlers.Deprecated(adminAPI))
+	default:
+		// Fail closed rather than serve chain and endpoint changes to anyone
+		log.Printf("Warning: ADMIN_API_KEY is not set, the admin API is disabled (set ADMIN_INSECURE=true to serve it without a key)")
+	}
+	mux.Handle("/", proxyServer.Handler())
+
+	server := proxy.NewHTTPServer(cfg.Server, mux)
+
+	if adminServer != nil {
+		go func() {
+			log.Printf("Starting admin API server on port %d", cfg.Admin.Port)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Admin server failed to start: %v", err)
+			}
+		}()
 	}
 
 	go func() {
-		log.Printf("Starting Multi-Chain RPC Proxy server on port %d", cfg.Server.Port)
+		log.Printf("Starting Multi-Chain RPC Proxy server on port %d (%s mode)", cfg.Server.Port, cfg.Server.Mode)
 		log.Printf("Available endpoints:")
 		log.Printf("  - /health (overall health status)")
 		log.Printf("  - /health/{chainName} (chain-specific health)")
+		log.Printf("  - /chains (metadata of the enabled chains)")
+		log.Printf("  - /version (build information)")
 		log.Printf("  - /rpc/{chainName} (chain-specific RPC)")
+		log.Printf("  - /rpc/{chainName}/archive (chain-specific RPC on archive endpoints only)")
+		log.Printf("  - /rpc/{chainName}/block/..., /tx/..., /balance/... (read-only REST over JSON-RPC)")
+		log.Printf("  - /sse/{chainName}/newHeads (new heads as Server-Sent Events)")
+		log.Printf("  - /beacon/{chainName}/eth/... (Beacon API of beacon chains)")
+		log.Printf("  - /graphql/{chainName} (GraphQL of endpoints with a graphqlUrl)")
+		log.Printf("  - /gas/{chainName} (gas price oracle of evm chains)")
 		log.Printf("  - /rpc (legacy, defaults to ethereum)")
+		log.Printf("  - / (service index)")
+		log.Printf("  - /admin/... (admin API)")
 		
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed to start: %v", err)
 		}
 	}()
 
+	// SIGHUP reloads chain configuration; in-flight requests keep the endpoints they picked
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if reloadJob == nil {
+				log.Printf("SIGHUP received but there is no configuration source to reload")
+				continue
+			}
+			log.Printf("SIGHUP received, reloading configuration")
+			if _, err := reloadJob.Reload(); err != nil {
+				log.Printf("Configuration reload failed: %v", err)
+			}
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -74,6 +444,11 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			log.Printf("Admin server forced to shutdown: %v", err)
+		}
+	}
 
 	log.Println("Server exited")
 }
\ No newline at end of file
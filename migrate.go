@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"rpc-proxy/internal/config"
+	"rpc-proxy/internal/database"
+)
+
+// isMigrate reports whether the binary was started as `rpc-proxy migrate [up|status]`
+func isMigrate(args []string) bool {
+	return len(args) >= 2 && args[1] == "migrate"
+}
+
+// runMigrate applies pending schema migrations (`migrate` or `migrate up`) or lists them
+// with the time each was applied (`migrate status`), and returns the process exit code
+func runMigrate(args []string) int {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if command != "up" && command != "status" {
+		fmt.Printf("Unknown migrate command %q, must be up or status\n", command)
+		return 2
+	}
+
+	cfg := config.Read()
+	if cfg.Database.Host == "" {
+		fmt.Println("No database configured, set DB_HOST")
+		return 1
+	}
+
+	db, err := database.NewGormConnection(cfg.DatabaseConfig())
+	if err != nil {
+		fmt.Printf("FAIL %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	if command == "status" {
+		statuses, err := db.MigrationStatuses()
+		if err != nil {
+			fmt.Printf("FAIL %v\n", err)
+			return 1
+		}
+		for _, status := range statuses {
+			if status.AppliedAt != nil {
+				fmt.Printf("%4d  applied %s  %s\n", status.Version, status.AppliedAt.UTC().Format("2006-01-02 15:04:05"), status.Name)
+			} else {
+				fmt.Printf("%4d  pending                      %s\n", status.Version, status.Name)
+			}
+		}
+		return 0
+	}
+
+	applied, err := db.Migrate()
+	for _, migration := range applied {
+		fmt.Printf("OK   %d %s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		fmt.Printf("FAIL %v\n", err)
+		return 1
+	}
+	if err := db.SeedData(); err != nil {
+		fmt.Printf("FAIL failed to seed default data: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Database schema is at version %d\n", database.LatestMigrationVersion())
+	return 0
+}